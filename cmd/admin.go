@@ -1,11 +1,312 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+)
 
 var adminCmd = &cobra.Command{
 	Use:   "admin",
 	Short: "Command-line administrative tools for qatarina",
 	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// newAdminService connects to the database for the admin commands, the
+// connection is closed with the returned closer
+func newAdminService() (services.AdminService, *dbsqlc.Queries, logging.Logger, *sqlx.DB) {
+	db := qatarinaConfig.OpenDB()
+	queries := dbsqlc.New(db)
+	logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+	return services.NewAdminService(queries, logger), queries, logger, db
+}
+
+var adminBootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Creates the first super admin from the admin configuration",
+	Long: `Creates the first super admin from the admin section of the configuration or the
+QATARINA_ADMIN_EMAIL, QATARINA_ADMIN_PASSWORD, QATARINA_ADMIN_USERNAME and
+QATARINA_ADMIN_ENABLED environment variables. Nothing is changed once an active
+super admin exists. The server runs this on every start.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		created, err := adminService.BootstrapAdmin(context.Background(), &qatarinaConfig.Admin)
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap admin got %v", err)
+		}
+		if !created {
+			fmt.Println("no changes, a super admin already exists or the admin configuration is not enabled")
+			return nil
+		}
+		fmt.Println("bootstrapped super admin", qatarinaConfig.Admin.Email)
+		return nil
+	},
+}
+
+var adminUsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage user accounts, users are referred to by ID or email",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var adminListUsersCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all user accounts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		users, err := adminService.ListUsers(context.Background())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tNAME\tSUPER ADMIN\tACTIVE\tLAST LOGIN")
+		for _, user := range users {
+			lastLogin := "never"
+			if user.LastLoginAt.Valid {
+				lastLogin = user.LastLoginAt.Time.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%t\t%s\n", user.ID, user.Email, user.DisplayName.String,
+				user.IsSuperAdmin.Bool, user.IsActivated.Bool && !user.DeletedAt.Valid, lastLogin)
+		}
+		return w.Flush()
+	},
+}
+
+var adminPromoteCmd = &cobra.Command{
+	Use:   "promote <user>",
+	Short: "Makes the user a super admin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		if err := adminService.SetSuperAdmin(context.Background(), args[0], true); err != nil {
+			return fmt.Errorf("failed to promote user got %v", err)
+		}
+		fmt.Println("promoted", args[0], "to super admin")
+		return nil
+	},
+}
+
+var adminDemoteCmd = &cobra.Command{
+	Use:   "demote <user>",
+	Short: "Removes super admin rights from the user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		if err := adminService.SetSuperAdmin(context.Background(), args[0], false); err != nil {
+			return fmt.Errorf("failed to demote user got %v", err)
+		}
+		fmt.Println("demoted", args[0])
+		return nil
+	},
+}
+
+var adminResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password <user>",
+	Short: "Sets a new password for the user and ends their sessions",
+	Long:  `Sets a new password for the user and ends their sessions, a random password is generated and printed when --password is not given`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, _ := cmd.Flags().GetString("password")
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		newPassword, err := adminService.ResetPassword(context.Background(), args[0], password)
+		if err != nil {
+			return fmt.Errorf("failed to reset password got %v", err)
+		}
+		if password == "" {
+			fmt.Println("new password:", newPassword)
+			return nil
+		}
+		fmt.Println("password reset for", args[0])
+		return nil
+	},
+}
+
+var adminUnlockCmd = &cobra.Command{
+	Use:   "unlock <user>",
+	Short: "Clears a lockout caused by failed logins",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		adminService, queries, logger, db := newAdminService()
+		defer db.Close()
+		user, err := adminService.FindUser(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		signingKeyService := services.NewSigningKeyService(qatarinaConfig, queries, logger)
+		authService := services.NewAuthService(qatarinaConfig, db.DB, queries, signingKeyService, logger)
+		if err := authService.UnlockAccount(ctx, 0, int64(user.ID)); err != nil {
+			return fmt.Errorf("failed to unlock user got %v", err)
+		}
+		fmt.Println("unlocked", user.Email)
+		return nil
+	},
+}
+
+var adminDeactivateCmd = &cobra.Command{
+	Use:   "deactivate <user>",
+	Short: "Blocks the user from logging in and ends their sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		if err := adminService.DeactivateUser(context.Background(), args[0]); err != nil {
+			return fmt.Errorf("failed to deactivate user got %v", err)
+		}
+		fmt.Println("deactivated", args[0])
+		return nil
+	},
+}
+
+var adminReactivateCmd = &cobra.Command{
+	Use:   "reactivate <user>",
+	Short: "Lets a deactivated user log in again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		if err := adminService.ReactivateUser(context.Background(), args[0]); err != nil {
+			return fmt.Errorf("failed to reactivate user got %v", err)
+		}
+		fmt.Println("reactivated", args[0])
+		return nil
+	},
+}
+
+var adminOffboardCmd = &cobra.Command{
+	Use:   "offboard <user> --to <user>",
+	Short: "Hands the open test plans, plan cases and test runs of a user over to another user",
+	Long: `Hands the open test plans, test plan cases and test runs of a user over to another
+user, adding them as a tester on projects they are not part of yet. Use --dry-run to
+see what would move and --deactivate to deactivate the user afterwards.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		deactivate, _ := cmd.Flags().GetBool("deactivate")
+		if to == "" {
+			return fmt.Errorf("--to is required")
+		}
+
+		ctx := context.Background()
+		db := qatarinaConfig.OpenDB()
+		defer db.Close()
+		queries := dbsqlc.New(db)
+		logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+		adminService := services.NewAdminService(queries, logger)
+		userService := services.NewUserService(db.DB, queries, logger, qatarinaConfig.SMTP)
+
+		user, err := adminService.FindUser(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		reassignTo, err := adminService.FindUser(ctx, to)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			preview, err := userService.PreviewOffboarding(ctx, int64(user.ID), int64(reassignTo.ID))
+			if err != nil {
+				return fmt.Errorf("failed to preview offboarding got %v", err)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KIND\tPROJECT ID\tTEST PLAN ID\tITEM")
+			for _, plan := range preview.TestPlans {
+				fmt.Fprintf(w, "test plan\t%d\t%d\t%s\n", plan.ProjectID, plan.ID, plan.Description)
+			}
+			for _, planCase := range preview.PlanCases {
+				fmt.Fprintf(w, "plan case\t%d\t%d\t%s\n", planCase.ProjectID, planCase.TestPlanID, planCase.Title)
+			}
+			for _, run := range preview.TestRuns {
+				fmt.Fprintf(w, "test run\t%d\t%d\t%s\n", run.ProjectID, run.TestPlanID, run.Code)
+			}
+			for _, grant := range preview.GrantedProjects {
+				fmt.Fprintf(w, "add as %s\t%d\t\t%s\n", grant.Role, grant.ProjectID, reassignTo.Email)
+			}
+			return w.Flush()
+		}
+
+		result, err := userService.Offboard(ctx, 0, int64(user.ID), &schema.OffboardUserRequest{
+			ReassignToID: int64(reassignTo.ID),
+			Deactivate:   deactivate,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to offboard user got %v", err)
+		}
+		fmt.Printf("reassigned %d test plans, %d plan cases and %d test runs from %s to %s\n",
+			result.TestPlans, result.PlanCases, result.TestRuns, user.Email, reassignTo.Email)
+		if result.Deactivated {
+			fmt.Println("deactivated", user.Email)
+		}
+		return nil
+	},
+}
+
+var adminProjectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Manage projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var adminListProjectsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all projects",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		projects, err := adminService.ListProjects(context.Background())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCODE\tTITLE\tOWNER ID\tACTIVE\tCREATED")
+		for _, project := range projects {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%t\t%s\n", project.ID, project.Code, project.Title,
+				project.OwnerUserID, project.IsActive.Bool && !project.DeletedAt.Valid, project.CreatedAt.Format(time.DateTime))
+		}
+		return w.Flush()
+	},
+}
 
+var adminReassignProjectCmd = &cobra.Command{
+	Use:   "reassign <project-id> <user>",
+	Short: "Makes another user the owner of the project",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid project id %q", args[0])
+		}
+		adminService, _, _, db := newAdminService()
+		defer db.Close()
+		if err := adminService.ReassignProjectOwner(context.Background(), projectID, args[1]); err != nil {
+			return fmt.Errorf("failed to reassign project got %v", err)
+		}
+		fmt.Println("project", projectID, "is now owned by", args[1])
+		return nil
 	},
 }
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys access tokens are signed with",
+	Long: `Manage the keys access tokens are signed with. The public keys are published on
+/.well-known/jwks.json, running servers pick up changes within a minute.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// newSigningKeyService connects to the database for the keys commands, the
+// connection is closed with the returned closer
+func newSigningKeyService() (services.SigningKeyService, io.Closer) {
+	db := qatarinaConfig.OpenDB()
+	logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+	return services.NewSigningKeyService(qatarinaConfig, dbsqlc.New(db), logger), db
+}
+
+func printSigningKeys(keys ...schema.SigningKey) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALGORITHM\tSTATUS\tCREATED\tACTIVATES\tRETIRED")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.Kid, key.Algorithm, key.Status, key.CreatedAt, key.ActivatesAt, key.RetiredAt)
+	}
+	return w.Flush()
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the signing keys, newest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		signingKeyService, db := newSigningKeyService()
+		defer db.Close()
+		keys, err := signingKeyService.List(context.Background())
+		if err != nil {
+			return err
+		}
+		return printSigningKeys(keys...)
+	},
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Adds a signing key next to the current ones",
+	Long: `Adds a signing key, it is published right away and takes over signing once it
+activates. Tokens signed with the other keys stay valid.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		algorithm, _ := cmd.Flags().GetString("alg")
+		activateIn, _ := cmd.Flags().GetDuration("activate-in")
+		signingKeyService, db := newSigningKeyService()
+		defer db.Close()
+		key, err := signingKeyService.Generate(context.Background(), algorithm, time.Now().Add(activateIn))
+		if err != nil {
+			return fmt.Errorf("failed to generate signing key got %v", err)
+		}
+		return printSigningKeys(*key)
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replaces the current signing keys with a new one",
+	Long: `Adds a signing key and retires the other keys once it activates. Retired keys keep
+verifying tokens until they expire, so nobody is logged out. Give verifiers time
+to fetch the new key with --activate-in.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		algorithm, _ := cmd.Flags().GetString("alg")
+		activateIn, _ := cmd.Flags().GetDuration("activate-in")
+		signingKeyService, db := newSigningKeyService()
+		defer db.Close()
+		key, err := signingKeyService.Rotate(context.Background(), algorithm, time.Now().Add(activateIn))
+		if err != nil {
+			return fmt.Errorf("failed to rotate signing keys got %v", err)
+		}
+		return printSigningKeys(*key)
+	},
+}
+
+var keysRetireCmd = &cobra.Command{
+	Use:   "retire <kid>",
+	Short: "Stops a key from signing, tokens it signed stay valid until they expire",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		signingKeyService, db := newSigningKeyService()
+		defer db.Close()
+		if err := signingKeyService.Retire(context.Background(), args[0]); err != nil {
+			return fmt.Errorf("failed to retire signing key got %v", err)
+		}
+		fmt.Println("retired", args[0])
+		return nil
+	},
+}
+
+var keysDeleteCmd = &cobra.Command{
+	Use:   "delete <kid>",
+	Short: "Deletes a key, tokens it signed are rejected immediately",
+	Long:  `Deletes a key, for example when it was compromised. Users holding tokens signed with it have to log in again.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		signingKeyService, db := newSigningKeyService()
+		defer db.Close()
+		if err := signingKeyService.Delete(context.Background(), args[0]); err != nil {
+			return fmt.Errorf("failed to delete signing key got %v", err)
+		}
+		fmt.Println("deleted", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Move projects between instances",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var projectExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports a project with its data and files to a bundle",
+	Long: `Exports a project to a zip bundle holding its modules, environments, test cases,
+test plans, test runs and their results, comments, pages, scripts and report files.
+The bundle is imported on another instance with "qatarina project import".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectID, _ := cmd.Flags().GetInt64("project")
+		out, _ := cmd.Flags().GetString("out")
+		if projectID == 0 {
+			return fmt.Errorf("--project is required")
+		}
+		if out == "" {
+			out = fmt.Sprintf("project-%d.zip", projectID)
+		}
+
+		ctx := context.Background()
+		db := qatarinaConfig.OpenDB()
+		queries := dbsqlc.New(db)
+		logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+		bundleService := services.NewProjectBundleService(qatarinaConfig, db.DB, queries, logger)
+
+		orgID, err := queries.GetProjectOrgID(ctx, int32(projectID))
+		if err != nil {
+			return fmt.Errorf("failed to find project %d got %v", projectID, err)
+		}
+
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := bundleService.Export(services.WithOrgID(ctx, int64(orgID)), projectID, f); err != nil {
+			os.Remove(out)
+			return fmt.Errorf("failed to export project got %v", err)
+		}
+		fmt.Printf("Exported project %d to %s\n", projectID, out)
+		return nil
+	},
+}
+
+var projectImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Imports a project bundle into an org",
+	Long: `Imports a project bundle into an org as a new project. Users are matched by email,
+the work of users missing on this instance is attributed to --user. Use --dry-run to
+see the conflicts without importing anything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		userRef, _ := cmd.Flags().GetString("user")
+		name, _ := cmd.Flags().GetString("name")
+		code, _ := cmd.Flags().GetString("code")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if orgID == 0 {
+			return fmt.Errorf("--org is required")
+		}
+		if userRef == "" {
+			return fmt.Errorf("--user is required")
+		}
+
+		ctx := context.Background()
+		db := qatarinaConfig.OpenDB()
+		queries := dbsqlc.New(db)
+		logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+		adminService := services.NewAdminService(queries, logger)
+		bundleService := services.NewProjectBundleService(qatarinaConfig, db.DB, queries, logger)
+
+		user, err := adminService.FindUser(ctx, userRef)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		report, err := bundleService.Import(services.WithOrgID(ctx, orgID), f, info.Size(), &schema.ProjectImportOptions{
+			ImportedByID: int64(user.ID),
+			Name:         name,
+			Code:         code,
+			DryRun:       dryRun,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import project got %v", err)
+		}
+		return printProjectImportReport(report)
+	},
+}
+
+func printProjectImportReport(report *schema.ProjectImportReport) error {
+	if report.DryRun {
+		fmt.Println("Dry run, nothing was imported")
+	} else {
+		fmt.Printf("Imported project %d\n", report.ProjectID)
+	}
+	counts := report.Counts
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTITY\tCOUNT")
+	fmt.Fprintf(w, "modules\t%d\n", counts.Modules)
+	fmt.Fprintf(w, "environments\t%d\n", counts.Environments)
+	fmt.Fprintf(w, "test cases\t%d\n", counts.TestCases)
+	fmt.Fprintf(w, "test plans\t%d\n", counts.TestPlans)
+	fmt.Fprintf(w, "test plan cases\t%d\n", counts.TestPlanCases)
+	fmt.Fprintf(w, "test runs\t%d\n", counts.TestRuns)
+	fmt.Fprintf(w, "test run results\t%d\n", counts.TestRunResults)
+	fmt.Fprintf(w, "comments\t%d\n", counts.Comments)
+	fmt.Fprintf(w, "pages\t%d\n", counts.Pages)
+	fmt.Fprintf(w, "reports\t%d\n", counts.Reports)
+	fmt.Fprintf(w, "files\t%d\n", counts.Files)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Conflicts) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONFLICT\tREF\tRESOLUTION")
+	for _, conflict := range report.Conflicts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", conflict.Kind, conflict.Ref, conflict.Message)
+	}
+	return w.Flush()
+}
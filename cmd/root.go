@@ -37,6 +37,51 @@ func init() {
 	createTestCaseCmd.Flags().Bool("draft", false, "Is this a draft")
 	createTestCaseCmd.Flags().StringSlice("tags", []string{}, "Comma-separated tags")
 
+	adminResetPasswordCmd.Flags().String("password", "", "New password, generated when empty")
+	adminOffboardCmd.Flags().String("to", "", "User who takes over the work, by ID or email")
+	adminOffboardCmd.Flags().Bool("dry-run", false, "Only list the work that would be handed over")
+	adminOffboardCmd.Flags().Bool("deactivate", false, "Deactivate the user after handing over their work")
+	adminUsersCmd.AddCommand(adminListUsersCmd)
+	adminUsersCmd.AddCommand(adminPromoteCmd)
+	adminUsersCmd.AddCommand(adminDemoteCmd)
+	adminUsersCmd.AddCommand(adminResetPasswordCmd)
+	adminUsersCmd.AddCommand(adminUnlockCmd)
+	adminUsersCmd.AddCommand(adminDeactivateCmd)
+	adminUsersCmd.AddCommand(adminReactivateCmd)
+	adminUsersCmd.AddCommand(adminOffboardCmd)
+	adminProjectsCmd.AddCommand(adminListProjectsCmd)
+	adminProjectsCmd.AddCommand(adminReassignProjectCmd)
+	adminCmd.AddCommand(adminBootstrapCmd)
+	adminCmd.AddCommand(adminUsersCmd)
+	adminCmd.AddCommand(adminProjectsCmd)
+
+	keysGenerateCmd.Flags().String("alg", "", "Signing algorithm, RS256 or EdDSA, defaults to auth.signing_algorithm")
+	keysGenerateCmd.Flags().Duration("activate-in", 0, "Delay before the key starts signing, e.g. 1h")
+	keysRotateCmd.Flags().String("alg", "", "Signing algorithm, RS256 or EdDSA, defaults to auth.signing_algorithm")
+	keysRotateCmd.Flags().Duration("activate-in", 0, "Delay before the new key takes over signing, e.g. 1h")
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysGenerateCmd)
+	keysCmd.AddCommand(keysRotateCmd)
+	keysCmd.AddCommand(keysRetireCmd)
+	keysCmd.AddCommand(keysDeleteCmd)
+
+	scimTokensCreateCmd.Flags().Int64("org", 0, "ID of the org the directory provisions users for")
+	scimTokensCreateCmd.Flags().String("name", "directory", "Label of the token, e.g. the name of the directory")
+	scimTokensCmd.AddCommand(scimTokensListCmd)
+	scimTokensCmd.AddCommand(scimTokensCreateCmd)
+	scimTokensCmd.AddCommand(scimTokensRevokeCmd)
+	scimCmd.AddCommand(scimTokensCmd)
+
+	projectExportCmd.Flags().Int64("project", 0, "ID of the project to export")
+	projectExportCmd.Flags().String("out", "", "File to write the bundle to, defaults to project-<id>.zip")
+	projectImportCmd.Flags().Int64("org", 0, "ID of the org the project is imported into")
+	projectImportCmd.Flags().String("user", "", "User who owns the imported project, by ID or email")
+	projectImportCmd.Flags().String("name", "", "Title of the imported project, defaults to the bundled title")
+	projectImportCmd.Flags().String("code", "", "Code of the imported project, defaults to the bundled code")
+	projectImportCmd.Flags().Bool("dry-run", false, "Only report what would be imported and the conflicts")
+	projectCmd.AddCommand(projectExportCmd)
+	projectCmd.AddCommand(projectImportCmd)
+
 	testCaseImporterCmd.Flags().String("repo", "", "Repository directory path")
 	testCaseCmd.AddCommand(testCaseImporterCmd)
 	testCaseCmd.AddCommand(createTestCaseCmd)
@@ -44,6 +89,9 @@ func init() {
 	rootCmd.AddCommand(serverCmd)
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(adminCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(scimCmd)
+	rootCmd.AddCommand(projectCmd)
 	rootCmd.AddCommand(userCmd)
 	rootCmd.AddCommand(testCaseCmd)
 }
@@ -78,6 +126,14 @@ func initConfig() {
 		viper.AddConfigPath("/etc/qatarina.d")
 	}
 
+	// the first admin is usually provisioned by the deployment environment
+	viper.BindEnv("admin.email", "QATARINA_ADMIN_EMAIL")
+	viper.BindEnv("admin.password", "QATARINA_ADMIN_PASSWORD")
+	viper.BindEnv("admin.username", "QATARINA_ADMIN_USERNAME")
+	viper.BindEnv("admin.enabled", "QATARINA_ADMIN_ENABLED")
+	viper.BindEnv("dev_mode", "QATARINA_DEV_MODE")
+	viper.BindEnv("scim.enabled", "QATARINA_SCIM_ENABLED")
+
 	if err := viper.ReadInConfig(); err != nil {
 		fmt.Println("Can't read config:", err)
 		os.Exit(1)
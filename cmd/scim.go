@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var scimCmd = &cobra.Command{
+	Use:   "scim",
+	Short: "Manage SCIM provisioning",
+	Long: `Manage SCIM provisioning. Directories like Okta or Microsoft Entra ID provision the
+users of an org on /scim/v2 once scim.enabled is set, authenticated with a token
+issued for the org.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var scimTokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage the tokens directories authenticate with",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// newSCIMService connects to the database for the scim commands, the
+// connection is closed with the returned closer
+func newSCIMService() (services.SCIMService, io.Closer) {
+	db := qatarinaConfig.OpenDB()
+	logger := logging.NewFromConfig(&qatarinaConfig.Logging)
+	return services.NewSCIMService(qatarinaConfig, db.DB, dbsqlc.New(db), logger), db
+}
+
+func printSCIMTokens(tokens ...schema.SCIMToken) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tORG\tNAME\tCREATED\tLAST USED\tREVOKED")
+	for _, token := range tokens {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\n", token.ID, token.OrgID, token.Name, token.CreatedAt, token.LastUsedAt, token.RevokedAt)
+	}
+	return w.Flush()
+}
+
+var scimTokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the SCIM tokens of every org",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scimService, db := newSCIMService()
+		defer db.Close()
+		tokens, err := scimService.ListTokens(context.Background())
+		if err != nil {
+			return err
+		}
+		return printSCIMTokens(tokens...)
+	},
+}
+
+var scimTokensCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Issues a token for the directory of an org, the token is only shown once",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		name, _ := cmd.Flags().GetString("name")
+		if orgID == 0 {
+			return fmt.Errorf("--org is required")
+		}
+		scimService, db := newSCIMService()
+		defer db.Close()
+		token, err := scimService.CreateToken(context.Background(), orgID, name)
+		if err != nil {
+			return fmt.Errorf("failed to create scim token got %v", err)
+		}
+		if err := printSCIMTokens(token.SCIMToken); err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Println("SCIM endpoint:", qatarinaConfig.Server.BaseURL()+"/scim/v2")
+		fmt.Println("Token:", token.Token)
+		if !qatarinaConfig.SCIM.Enabled {
+			fmt.Println("scim.enabled is not set, the endpoint is not served yet")
+		}
+		return nil
+	},
+}
+
+var scimTokensRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revokes a SCIM token, the directory using it can no longer provision users",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid token id %q", args[0])
+		}
+		scimService, db := newSCIMService()
+		defer db.Close()
+		if err := scimService.RevokeToken(context.Background(), tokenID); err != nil {
+			return fmt.Errorf("failed to revoke scim token got %v", err)
+		}
+		fmt.Println("revoked", tokenID)
+		return nil
+	},
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/golang-malawi/qatarina/internal/api"
@@ -12,6 +14,10 @@ var serverCmd = &cobra.Command{
 	Short: "Starts the HelpAside server daemon",
 	Long:  `Starts the HelpAside server daemon`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := qatarinaConfig.CheckSecrets(); err != nil {
+			return err
+		}
+
 		// Run migrations on server startup unless the environment variable is set
 		if _, ok := os.LookupEnv("QATARINA_DISABLE_MIGRATIONS_ON_STARTUP"); !ok {
 			err := migrateCmd.RunE(cmd, []string{"up"})
@@ -20,6 +26,18 @@ var serverCmd = &cobra.Command{
 			}
 		}
 
+		if qatarinaConfig.Admin.Enabled {
+			adminService, _, _, db := newAdminService()
+			created, err := adminService.BootstrapAdmin(context.Background(), &qatarinaConfig.Admin)
+			db.Close()
+			if err != nil {
+				return fmt.Errorf("failed to bootstrap admin got %v", err)
+			}
+			if created {
+				fmt.Println("bootstrapped super admin", qatarinaConfig.Admin.Email)
+			}
+		}
+
 		apiServer := api.NewAPI(qatarinaConfig)
 		// var err error
 		// apiServer.RiverClient, err = worker.StartRiverWorker(qatarinaConfig)
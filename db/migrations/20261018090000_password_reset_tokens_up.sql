@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE password_reset_tokens (
+    id serial not null primary key,
+    user_id integer not null,
+    token_hash text not null,
+    expires_at timestamp without time zone not null,
+    used_at timestamp without time zone null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_password_reset_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_password_reset_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON password_reset_tokens (user_id);
+
+COMMENT ON COLUMN password_reset_tokens.token_hash IS 'SHA-256 hash of the reset token sent to the user, the raw token is never stored';
+COMMENT ON COLUMN password_reset_tokens.expires_at IS 'When the reset token stops being accepted';
+COMMENT ON COLUMN password_reset_tokens.used_at IS 'When the token was consumed or invalidated, tokens are single-use';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_password_reset_tokens_user;
+DROP TABLE password_reset_tokens;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE refresh_tokens (
+    id serial not null primary key,
+    user_id integer not null,
+    family_id uuid not null,
+    token_hash text not null,
+    user_agent text null,
+    ip_address text null,
+    expires_at timestamp without time zone not null,
+    used_at timestamp without time zone null,
+    revoked_at timestamp without time zone null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_refresh_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_refresh_token_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens (family_id);
+
+COMMENT ON COLUMN refresh_tokens.family_id IS 'Identifies the login session, every rotated token of a session shares the family';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 hash of the refresh token, the raw token is never stored';
+COMMENT ON COLUMN refresh_tokens.used_at IS 'When the token was exchanged for a new one, presenting it again is treated as reuse';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'When the token was revoked through logout or reuse detection';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+DROP INDEX IF EXISTS idx_refresh_tokens_user;
+DROP TABLE refresh_tokens;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id serial not null primary key,
+    user_id integer not null,
+    created_by_id integer not null,
+    name text not null,
+    token_prefix text not null,
+    token_hash text not null,
+    scopes text[] not null default '{}',
+    project_id integer null,
+    expires_at timestamp without time zone null,
+    last_used_at timestamp without time zone null,
+    revoked_at timestamp without time zone null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_api_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_api_token_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_token_created_by FOREIGN KEY (created_by_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_token_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_created_by ON api_tokens (created_by_id);
+
+COMMENT ON COLUMN api_tokens.user_id IS 'User the token acts as, either the creator or a bot account created for the token';
+COMMENT ON COLUMN api_tokens.token_prefix IS 'First characters of the token so users can tell their tokens apart';
+COMMENT ON COLUMN api_tokens.token_hash IS 'SHA-256 hash of the token, the raw token is only shown once on creation';
+COMMENT ON COLUMN api_tokens.scopes IS 'Allowed resource actions, for example test-runs:write';
+COMMENT ON COLUMN api_tokens.project_id IS 'When set the token can only access this project';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_tokens_created_by;
+DROP TABLE api_tokens;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE login_throttles (
+    id serial not null primary key,
+    scope text not null,
+    throttle_key text not null,
+    failed_attempts integer not null default 0,
+    lockouts integer not null default 0,
+    locked_until timestamp without time zone null,
+    last_failed_at timestamp without time zone null,
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_login_throttle UNIQUE (scope, throttle_key)
+);
+
+COMMENT ON COLUMN login_throttles.scope IS 'What the attempts are counted against, either account or ip';
+COMMENT ON COLUMN login_throttles.throttle_key IS 'Lower-cased email for account throttles or the client IP address';
+COMMENT ON COLUMN login_throttles.lockouts IS 'Number of consecutive lockouts, used to back off exponentially';
+
+CREATE TABLE audit_logs (
+    id bigserial not null primary key,
+    actor_id integer null,
+    action text not null,
+    subject_type text not null,
+    subject_id text not null,
+    ip_address text null,
+    details text null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT fk_audit_log_actor FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_subject ON audit_logs (subject_type, subject_id);
+
+COMMENT ON COLUMN audit_logs.actor_id IS 'User who performed the action, null for actions taken by the system';
+COMMENT ON COLUMN audit_logs.action IS 'What happened, for example auth.lockout';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_logs_subject;
+DROP TABLE audit_logs;
+DROP TABLE login_throttles;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE user_identities (
+    id serial not null primary key,
+    user_id integer not null,
+    provider text not null,
+    subject text not null,
+    email text null,
+    last_login_at timestamp without time zone null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_user_identity UNIQUE (provider, subject),
+    CONSTRAINT fk_user_identity_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities (user_id);
+
+COMMENT ON COLUMN user_identities.provider IS 'Issuer URL of the identity provider the user signed in with';
+COMMENT ON COLUMN user_identities.subject IS 'Identifier of the user at the identity provider, the sub claim of the ID token';
+COMMENT ON COLUMN user_identities.email IS 'E-mail address the identity provider last reported for the user';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_identities_user;
+DROP TABLE user_identities;
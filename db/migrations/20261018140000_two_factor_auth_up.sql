@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE totp_credentials (
+    user_id integer not null primary key,
+    secret text not null,
+    confirmed_at timestamp without time zone null,
+    last_used_step bigint not null default 0,
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT fk_totp_credential_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+COMMENT ON COLUMN totp_credentials.secret IS 'TOTP secret encrypted with AES-GCM, see services/two_factor.go';
+COMMENT ON COLUMN totp_credentials.confirmed_at IS 'When the user proved the authenticator works, two-factor is only enforced after confirmation';
+COMMENT ON COLUMN totp_credentials.last_used_step IS 'Time step of the last accepted code, codes from this step or earlier are rejected to prevent replay';
+
+CREATE TABLE recovery_codes (
+    id serial not null primary key,
+    user_id integer not null,
+    code_hash text not null,
+    used_at timestamp without time zone null,
+    created_at timestamp without time zone not null default now(),
+    CONSTRAINT fk_recovery_code_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes (user_id);
+
+COMMENT ON COLUMN recovery_codes.code_hash IS 'SHA-256 hash of the recovery code, the raw codes are only shown once';
+
+ALTER TABLE orgs ADD COLUMN require_two_factor boolean not null default false;
+
+COMMENT ON COLUMN orgs.require_two_factor IS 'Whether members of the org must use two-factor authentication to log in';
+
+-- +goose Down
+ALTER TABLE orgs DROP COLUMN require_two_factor;
+DROP INDEX IF EXISTS idx_recovery_codes_user;
+DROP TABLE recovery_codes;
+DROP TABLE totp_credentials;
@@ -0,0 +1,42 @@
+-- +goose Up
+ALTER TABLE invites
+    ADD COLUMN invited_by_id integer null,
+    ADD COLUMN org_id integer null,
+    ADD COLUMN project_id integer null,
+    ADD COLUMN role text not null default 'member',
+    ADD COLUMN accepted_at timestamp without time zone null,
+    ADD COLUMN accepted_by_id integer null,
+    ADD COLUMN revoked_at timestamp without time zone null,
+    ADD COLUMN created_at timestamp without time zone not null default now(),
+    ADD CONSTRAINT fk_invite_invited_by FOREIGN KEY (invited_by_id) REFERENCES users (id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_invite_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_invite_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_invite_accepted_by FOREIGN KEY (accepted_by_id) REFERENCES users (id) ON DELETE SET NULL;
+
+-- links sent before tokens were hashed can no longer be matched
+UPDATE invites SET revoked_at = now() WHERE accepted_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invites_token ON invites (token);
+CREATE INDEX IF NOT EXISTS idx_invites_receiver_email ON invites (receiver_email);
+
+COMMENT ON COLUMN invites.token IS 'SHA-256 hash of the token in the invitation link, the raw token is only sent by email';
+COMMENT ON COLUMN invites.org_id IS 'Org the invited user joins on acceptance';
+COMMENT ON COLUMN invites.project_id IS 'Project the invited user is added to as a tester on acceptance';
+COMMENT ON COLUMN invites.role IS 'Org role, or the tester role when the invite is for a project';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_invites_receiver_email;
+DROP INDEX IF EXISTS idx_invites_token;
+ALTER TABLE invites
+    DROP CONSTRAINT IF EXISTS fk_invite_accepted_by,
+    DROP CONSTRAINT IF EXISTS fk_invite_project,
+    DROP CONSTRAINT IF EXISTS fk_invite_org,
+    DROP CONSTRAINT IF EXISTS fk_invite_invited_by,
+    DROP COLUMN created_at,
+    DROP COLUMN revoked_at,
+    DROP COLUMN accepted_by_id,
+    DROP COLUMN accepted_at,
+    DROP COLUMN role,
+    DROP COLUMN project_id,
+    DROP COLUMN org_id,
+    DROP COLUMN invited_by_id;
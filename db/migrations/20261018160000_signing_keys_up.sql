@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid text not null primary key,
+    algorithm text not null,
+    -- PEM encoded private key encrypted with AES-GCM under a key derived from the JWT secret
+    private_key text not null,
+    created_at timestamp without time zone not null default now(),
+    activates_at timestamp without time zone not null default now(),
+    retired_at timestamp without time zone null
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_retired_at ON signing_keys (retired_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS signing_keys;
@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    id serial not null primary key,
+    org_id integer not null,
+    name text not null,
+    token_hash text not null,
+    created_at timestamp without time zone not null default now(),
+    last_used_at timestamp without time zone null,
+    revoked_at timestamp without time zone null,
+    CONSTRAINT unq_scim_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_scim_token_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE
+);
+
+COMMENT ON COLUMN scim_tokens.name IS 'Label of the token, usually the directory it was issued to';
+COMMENT ON COLUMN scim_tokens.token_hash IS 'SHA-256 hash of the bearer token, the token itself is only shown once';
+
+CREATE TABLE IF NOT EXISTS scim_users (
+    org_id integer not null,
+    user_id integer not null,
+    external_id text null,
+    user_name text not null,
+    manages_account boolean not null default false,
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    PRIMARY KEY (org_id, user_id),
+    CONSTRAINT fk_scim_user_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE,
+    CONSTRAINT fk_scim_user_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unq_scim_users_external_id ON scim_users (org_id, external_id) WHERE external_id IS NOT NULL;
+
+COMMENT ON TABLE scim_users IS 'Users an org provisions from its directory over SCIM';
+COMMENT ON COLUMN scim_users.external_id IS 'Identifier of the user in the directory';
+COMMENT ON COLUMN scim_users.user_name IS 'userName the directory knows the user by, usually the e-mail address';
+COMMENT ON COLUMN scim_users.manages_account IS 'Whether the directory created the account, accounts it adopted keep their credentials and only lose their org membership when deprovisioned';
+
+-- +goose Down
+DROP INDEX IF EXISTS unq_scim_users_external_id;
+DROP TABLE IF EXISTS scim_users;
+DROP TABLE IF EXISTS scim_tokens;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- org creators were treated as owners without a membership, make them owners
+-- so every org has an owner to enforce the last owner rule against
+INSERT INTO org_members (org_id, user_id, role, created_at)
+SELECT o.id, o.created_by_id, 'owner', now()
+FROM orgs o
+WHERE NOT EXISTS (
+    SELECT 1 FROM org_members m
+    WHERE m.org_id = o.id AND m.user_id = o.created_by_id AND m.removed_at IS NULL
+);
+
+CREATE TABLE IF NOT EXISTS session_orgs (
+    family_id uuid not null primary key,
+    user_id integer not null,
+    org_id integer not null,
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT fk_session_org_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_session_org_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE session_orgs IS 'Org a login session switched to, sessions without one use the default org of the user';
+COMMENT ON COLUMN session_orgs.family_id IS 'Login session, the family_id of its refresh tokens';
+
+-- +goose Down
+DROP TABLE IF EXISTS session_orgs;
@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN org_id integer null;
+
+-- owners without any org get a personal org to hold their projects
+INSERT INTO orgs (name, created_by_id, created_at, updated_at)
+SELECT COALESCE(NULLIF(trim(u.display_name), '') || '''s org', NULLIF(trim(u.first_name), '') || '''s org', 'Personal'), u.id, now(), now()
+FROM users u
+WHERE u.org_id IS NULL
+AND EXISTS (SELECT 1 FROM projects p WHERE p.owner_user_id = u.id)
+AND NOT EXISTS (SELECT 1 FROM org_members m WHERE m.user_id = u.id AND m.removed_at IS NULL);
+
+-- only the orgs created above have no members, existing orgs keep theirs
+INSERT INTO org_members (org_id, user_id, role, created_at)
+SELECT o.id, o.created_by_id, 'owner', now()
+FROM orgs o
+WHERE NOT EXISTS (SELECT 1 FROM org_members m WHERE m.org_id = o.id);
+
+UPDATE users u SET org_id = (
+    SELECT m.org_id FROM org_members m
+    WHERE m.user_id = u.id AND m.removed_at IS NULL
+    ORDER BY m.id LIMIT 1
+)
+WHERE u.org_id IS NULL
+AND EXISTS (SELECT 1 FROM projects p WHERE p.owner_user_id = u.id);
+
+UPDATE projects p SET org_id = u.org_id
+FROM users u
+WHERE u.id = p.owner_user_id AND p.org_id IS NULL;
+
+ALTER TABLE projects
+    ALTER COLUMN org_id SET NOT NULL,
+    ADD CONSTRAINT fk_project_org FOREIGN KEY (org_id) REFERENCES orgs (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_projects_org_id ON projects (org_id);
+
+COMMENT ON COLUMN projects.org_id IS 'Organization the project belongs to';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_org_id;
+ALTER TABLE projects DROP COLUMN org_id;
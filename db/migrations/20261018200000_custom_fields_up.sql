@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id serial not null primary key,
+    project_id integer not null,
+    entity text not null,
+    name text not null,
+    label text not null,
+    field_type text not null,
+    options text[] not null default '{}',
+    is_required boolean not null default false,
+    default_value jsonb null,
+    position integer not null default 0,
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_custom_field_name UNIQUE (project_id, entity, name),
+    CONSTRAINT fk_custom_field_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE custom_fields IS 'Fields project admins define on the test cases or test runs of a project';
+COMMENT ON COLUMN custom_fields.entity IS 'What the field is defined on, test_case or test_run';
+COMMENT ON COLUMN custom_fields.name IS 'Key of the field in requests, filters and import/export columns';
+COMMENT ON COLUMN custom_fields.field_type IS 'One of text, number, enum, multi_select, date or user';
+COMMENT ON COLUMN custom_fields.options IS 'Allowed values of enum and multi_select fields';
+
+CREATE TABLE IF NOT EXISTS custom_field_values (
+    field_id integer not null,
+    entity_id uuid not null,
+    value jsonb not null,
+    updated_at timestamp without time zone not null default now(),
+    PRIMARY KEY (field_id, entity_id),
+    CONSTRAINT fk_custom_field_value_field FOREIGN KEY (field_id) REFERENCES custom_fields (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_field_values_entity_id ON custom_field_values (entity_id);
+
+COMMENT ON COLUMN custom_field_values.entity_id IS 'ID of the test case or test run the value is set on';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_custom_field_values_entity_id;
+DROP TABLE IF EXISTS custom_field_values;
+DROP TABLE IF EXISTS custom_fields;
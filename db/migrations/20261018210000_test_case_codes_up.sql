@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN testcase_code_pattern text null;
+
+COMMENT ON COLUMN projects.testcase_code_pattern IS 'Pattern of generated test case codes, tokens are {project}, {module}, {kind} and {seq:N}';
+
+CREATE TABLE IF NOT EXISTS test_case_code_aliases (
+    project_id integer not null,
+    code text not null,
+    test_case_id uuid not null,
+    created_at timestamp without time zone not null default now(),
+    PRIMARY KEY (project_id, code),
+    CONSTRAINT fk_test_case_code_alias_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    CONSTRAINT fk_test_case_code_alias_test_case FOREIGN KEY (test_case_id) REFERENCES test_cases (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_test_case_code_aliases_test_case_id ON test_case_code_aliases (test_case_id);
+
+COMMENT ON TABLE test_case_code_aliases IS 'Former codes of renamed or re-sequenced test cases, kept so old codes still resolve';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_test_case_code_aliases_test_case_id;
+DROP TABLE IF EXISTS test_case_code_aliases;
+ALTER TABLE projects DROP COLUMN testcase_code_pattern;
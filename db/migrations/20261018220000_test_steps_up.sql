@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS test_case_steps (
+    id serial not null primary key,
+    test_case_id uuid not null,
+    position integer not null,
+    action text not null,
+    test_data text not null default '',
+    expected_result text not null default '',
+    attachments text[] not null default '{}',
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_test_case_step_position UNIQUE (test_case_id, position),
+    CONSTRAINT fk_test_case_step_test_case FOREIGN KEY (test_case_id) REFERENCES test_cases (id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE test_case_steps IS 'Ordered steps of a test case';
+COMMENT ON COLUMN test_case_steps.position IS 'Number of the step in the test case, starting at 1';
+COMMENT ON COLUMN test_case_steps.attachments IS 'URLs of files needed to perform the step';
+
+CREATE TABLE IF NOT EXISTS test_run_step_results (
+    test_run_id uuid not null,
+    position integer not null,
+    step_id integer null,
+    action text not null,
+    test_data text not null default '',
+    expected_result text not null default '',
+    status test_run_state not null default 'pending',
+    actual_result text not null default '',
+    notes text not null default '',
+    executed_by_id integer null,
+    executed_at timestamp without time zone null,
+    updated_at timestamp without time zone not null default now(),
+    PRIMARY KEY (test_run_id, position),
+    CONSTRAINT fk_test_run_step_result_test_run FOREIGN KEY (test_run_id) REFERENCES test_runs (id) ON DELETE CASCADE,
+    CONSTRAINT fk_test_run_step_result_step FOREIGN KEY (step_id) REFERENCES test_case_steps (id) ON DELETE SET NULL,
+    CONSTRAINT fk_test_run_step_result_executed_by FOREIGN KEY (executed_by_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE test_run_step_results IS 'Result of each step of the test case of a test run';
+COMMENT ON COLUMN test_run_step_results.action IS 'Copy of the step when the run started, so later edits of the test case do not change recorded results';
+
+-- +goose Down
+DROP TABLE IF EXISTS test_run_step_results;
+DROP TABLE IF EXISTS test_case_steps;
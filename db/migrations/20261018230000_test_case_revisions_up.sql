@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS test_case_revisions (
+    test_case_id uuid not null,
+    revision integer not null,
+    title text not null,
+    description text not null,
+    kind test_kind not null,
+    tags text[] not null default '{}',
+    runner text null,
+    script_path text null,
+    steps jsonb not null default '[]',
+    author_id integer null,
+    created_at timestamp without time zone not null default now(),
+    PRIMARY KEY (test_case_id, revision),
+    CONSTRAINT fk_test_case_revision_test_case FOREIGN KEY (test_case_id) REFERENCES test_cases (id) ON DELETE CASCADE,
+    CONSTRAINT fk_test_case_revision_author FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE test_case_revisions IS 'Numbered copies of a test case, one is saved for every edit';
+COMMENT ON COLUMN test_case_revisions.steps IS 'Steps of the test case at the revision';
+
+-- existing test cases start at revision 1
+INSERT INTO test_case_revisions (test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at)
+SELECT tc.id, 1, tc.title, tc.description, tc.kind, COALESCE(tc.tags, '{}'), tc.runner, tc.script_path,
+    COALESCE((
+        SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+            'action', s.action,
+            'test_data', NULLIF(s.test_data, ''),
+            'expected_result', NULLIF(s.expected_result, ''),
+            'attachments', CASE WHEN cardinality(s.attachments) > 0 THEN to_jsonb(s.attachments) END
+        )) ORDER BY s.position)
+        FROM test_case_steps s WHERE s.test_case_id = tc.id
+    ), '[]'),
+    (SELECT u.id FROM users u WHERE u.id = tc.created_by_id), COALESCE(tc.updated_at, tc.created_at, now())
+FROM test_cases tc
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE test_runs ADD COLUMN IF NOT EXISTS test_case_revision integer null;
+
+COMMENT ON COLUMN test_runs.test_case_revision IS 'Revision of the test case the results were recorded against';
+
+-- +goose Down
+ALTER TABLE test_runs DROP COLUMN IF EXISTS test_case_revision;
+DROP TABLE IF EXISTS test_case_revisions;
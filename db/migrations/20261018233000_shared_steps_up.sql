@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS shared_steps (
+    id serial not null primary key,
+    project_id integer not null,
+    name text not null,
+    description text not null default '',
+    parameters jsonb not null default '[]',
+    steps jsonb not null default '[]',
+    created_by_id integer null,
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT unq_shared_step_name UNIQUE (project_id, name),
+    CONSTRAINT fk_shared_step_project FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    CONSTRAINT fk_shared_step_created_by FOREIGN KEY (created_by_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE shared_steps IS 'Blocks of steps test cases of a project can reference instead of repeating them';
+COMMENT ON COLUMN shared_steps.parameters IS 'Parameters used as {{name}} in the steps, with their description and default';
+
+ALTER TABLE test_case_steps ADD COLUMN IF NOT EXISTS shared_step_id integer null;
+ALTER TABLE test_case_steps ADD COLUMN IF NOT EXISTS parameters jsonb null;
+ALTER TABLE test_case_steps ADD CONSTRAINT fk_test_case_step_shared_step FOREIGN KEY (shared_step_id) REFERENCES shared_steps (id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_test_case_steps_shared_step ON test_case_steps (shared_step_id) WHERE shared_step_id IS NOT NULL;
+
+COMMENT ON COLUMN test_case_steps.shared_step_id IS 'Shared step the step is replaced by, the action of the step is empty';
+COMMENT ON COLUMN test_case_steps.parameters IS 'Values of the parameters of the shared step';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_test_case_steps_shared_step;
+ALTER TABLE test_case_steps DROP CONSTRAINT IF EXISTS fk_test_case_step_shared_step;
+ALTER TABLE test_case_steps DROP COLUMN IF EXISTS parameters;
+ALTER TABLE test_case_steps DROP COLUMN IF EXISTS shared_step_id;
+DROP TABLE IF EXISTS shared_steps;
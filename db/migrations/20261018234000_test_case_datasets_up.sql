@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS test_case_datasets (
+    test_case_id uuid not null primary key,
+    parameters text[] not null default '{}',
+    rows jsonb not null default '[]',
+    updated_by_id integer null,
+    created_at timestamp without time zone not null default now(),
+    updated_at timestamp without time zone not null default now(),
+    CONSTRAINT fk_test_case_dataset_test_case FOREIGN KEY (test_case_id) REFERENCES test_cases (id) ON DELETE CASCADE,
+    CONSTRAINT fk_test_case_dataset_updated_by FOREIGN KEY (updated_by_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE test_case_datasets IS 'Rows of values a data-driven test case is run with, one test run per row';
+COMMENT ON COLUMN test_case_datasets.parameters IS 'Parameters of the test case, used as {{name}} in its steps';
+COMMENT ON COLUMN test_case_datasets.rows IS 'Values of the parameters by name, one object per row';
+
+ALTER TABLE test_runs ADD COLUMN IF NOT EXISTS data_row integer null;
+ALTER TABLE test_runs ADD COLUMN IF NOT EXISTS data_values jsonb null;
+-- runs are keyed by the values of their row rather than its position so
+-- reordering the rows of a dataset does not move results to other values
+CREATE UNIQUE INDEX IF NOT EXISTS unq_test_runs_data_values ON test_runs (test_plan_id, test_case_id, assigned_to_id, data_values) WHERE data_values IS NOT NULL;
+
+COMMENT ON COLUMN test_runs.data_row IS 'Row of the dataset of the test case the run was created for, from 1';
+COMMENT ON COLUMN test_runs.data_values IS 'Values of the dataset row the run tests';
+
+-- +goose Down
+DROP INDEX IF EXISTS unq_test_runs_data_values;
+ALTER TABLE test_runs DROP COLUMN IF EXISTS data_values;
+ALTER TABLE test_runs DROP COLUMN IF EXISTS data_row;
+DROP TABLE IF EXISTS test_case_datasets;
@@ -29,6 +29,17 @@ type API struct {
 	OrgService            services.OrgService
 	EnvironmentService    services.EnvironmentService
 	ReportService         services.ReportService
+	APITokenService       services.APITokenService
+	PermissionService     services.PermissionService
+	OIDCService           services.OIDCService
+	InviteService         services.InviteService
+	SigningKeyService     services.SigningKeyService
+	SCIMService           services.SCIMService
+	ProjectBundleService  services.ProjectBundleService
+	CustomFieldService    services.CustomFieldService
+	TestCaseExportService services.TestCaseExportService
+	TestCaseCodeService   services.TestCaseCodeService
+	SharedStepService     services.SharedStepService
 }
 
 func NewAPI(config *config.Config) *API {
@@ -38,27 +49,41 @@ func NewAPI(config *config.Config) *API {
 	logger := logging.NewFromConfig(&config.Logging)
 
 	moduleService := services.NewModuleService(dbConn)
-	projectService := services.NewProjectService(dbConn, logger, moduleService)
+	projectService := services.NewProjectService(rawDB.DB, dbConn, logger, moduleService)
 	environmentService := services.NewEnvironmentService(dbConn)
 	reportService := services.NewReportService(rawDB.DB, dbConn, logger)
+	signingKeyService := services.NewSigningKeyService(config, dbConn, logger)
+	authService := services.NewAuthService(config, rawDB.DB, dbConn, signingKeyService, logger)
+	permissionService := services.NewPermissionService(dbConn, logger)
 
 	return &API{
 		logger:                logger,
 		app:                   fiber.New(),
 		Config:                config,
-		AuthService:           services.NewAuthService(&config.Auth, dbConn, logger),
+		AuthService:           authService,
 		ProjectsService:       projectService,
 		TestCasesService:      services.NewTestCaseService(rawDB.DB, dbConn, logger),
-		TestPlansService:      services.NewTestPlanService(dbConn, logger),
+		TestPlansService:      services.NewTestPlanService(rawDB.DB, dbConn, logger),
 		TestRunsService:       services.NewTestRunService(rawDB.DB, dbConn, logger),
-		UserService:           services.NewUserService(dbConn, logger, config.SMTP),
+		UserService:           services.NewUserService(rawDB.DB, dbConn, logger, config.SMTP),
 		TesterService:         services.NewTesterService(dbConn, logger),
 		ModuleService:         moduleService,
 		DashboardService:      services.NewDashboardService(dbConn, logger),
 		TestCaseImportService: services.NewTestCaseImportService(projectService, logger, config.ImportFile),
-		OrgService:            services.NewOrgService(dbConn, logger),
+		OrgService:            services.NewOrgService(rawDB.DB, dbConn, logger),
 		EnvironmentService:    environmentService,
 		ReportService:         reportService,
+		APITokenService:       services.NewAPITokenService(rawDB.DB, dbConn, permissionService, logger),
+		PermissionService:     permissionService,
+		OIDCService:           services.NewOIDCService(config, rawDB.DB, dbConn, authService, logger),
+		InviteService:         services.NewInviteService(config, rawDB.DB, dbConn, permissionService, authService, logger),
+		SigningKeyService:     signingKeyService,
+		SCIMService:           services.NewSCIMService(config, rawDB.DB, dbConn, logger),
+		ProjectBundleService:  services.NewProjectBundleService(config, rawDB.DB, dbConn, logger),
+		CustomFieldService:    services.NewCustomFieldService(dbConn, logger),
+		TestCaseExportService: services.NewTestCaseExportService(dbConn, logger),
+		TestCaseCodeService:   services.NewTestCaseCodeService(rawDB.DB, dbConn, logger),
+		SharedStepService:     services.NewSharedStepService(rawDB.DB, dbConn, logger),
 	}
 }
 
@@ -67,6 +92,13 @@ func (api *API) registerRoutes() {
 	api.routes()
 }
 
+// App registers the routes and returns the Fiber app without listening, it
+// lets tests send requests through the full middleware and handler chain
+func (api *API) App() *fiber.App {
+	api.registerRoutes()
+	return api.app
+}
+
 func (api *API) Start(address string) error {
 	api.registerRoutes()
 	api.logger.Debug("startup", "Starting API", "address", address)
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+var errInvalidProjectReference = errors.New("request does not reference a valid project")
+
+// projectResolver finds the project a request operates on
+type projectResolver func(c *fiber.Ctx) (int64, error)
+
+// authorize only lets the request through when the authenticated user may
+// perform the action on the project the request operates on
+func (api *API) authorize(action services.Action, resolve projectResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := resolve(c)
+		if err != nil {
+			return api.authorizationProblem(c, err)
+		}
+		if err := api.authorizeProject(c, projectID, action); err != nil {
+			return api.authorizationProblem(c, err)
+		}
+		return c.Next()
+	}
+}
+
+func (api *API) authorizeProject(c *fiber.Ctx, projectID int64, action services.Action) error {
+	if principal, ok := authutil.GetAPITokenPrincipal(c); ok && principal.ProjectID != 0 && principal.ProjectID != projectID {
+		return services.ErrForbidden
+	}
+	return api.PermissionService.Authorize(c.UserContext(), authutil.GetAuthUserID(c), projectID, action)
+}
+
+func (api *API) authorizationProblem(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		return problemdetail.Forbidden(c, "you do not have permission to perform this action on the project")
+	case errors.Is(err, services.ErrNotFound):
+		return problemdetail.NotFound(c, "resource not found")
+	case errors.Is(err, errInvalidProjectReference):
+		return problemdetail.BadRequest(c, err.Error())
+	}
+	api.logger.Error(loggedmodule.ApiAuth, "failed to authorize request", "error", err)
+	return problemdetail.ServerErrorProblem(c, "failed to process request")
+}
+
+// requireSuperAdmin only lets system-wide administrators through
+func (api *API) requireSuperAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		isSuperAdmin, err := api.PermissionService.IsSuperAdmin(c.UserContext(), authutil.GetAuthUserID(c))
+		if err != nil {
+			return api.authorizationProblem(c, err)
+		}
+		if !isSuperAdmin {
+			return problemdetail.Forbidden(c, "only administrators can perform this action")
+		}
+		return c.Next()
+	}
+}
+
+// requireOrgAdmin only lets owners and admins of the org the request works in
+// through, API tokens scoped to a project are refused
+func (api *API) requireOrgAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if principal, ok := authutil.GetAPITokenPrincipal(c); ok && principal.ProjectID != 0 {
+			return problemdetail.Forbidden(c, "project scoped API tokens cannot perform this action")
+		}
+		isOrgAdmin, err := api.PermissionService.IsOrgAdmin(c.UserContext(), authutil.GetAuthUserID(c))
+		if err != nil {
+			return api.authorizationProblem(c, err)
+		}
+		if !isOrgAdmin {
+			return problemdetail.Forbidden(c, "only owners and admins of the organization can perform this action")
+		}
+		return c.Next()
+	}
+}
+
+func projectFromParam(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		projectID, err := strconv.ParseInt(c.Params(name), 10, 64)
+		if err != nil || projectID <= 0 {
+			return 0, errInvalidProjectReference
+		}
+		return projectID, nil
+	}
+}
+
+// projectFromBody reads the project_id field from a JSON or multipart request body
+func projectFromBody(c *fiber.Ctx) (int64, error) {
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return projectFromForm("project_id")(c)
+	}
+
+	var body struct {
+		ProjectID int64 `json:"project_id"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil || body.ProjectID <= 0 {
+		return 0, errInvalidProjectReference
+	}
+	return body.ProjectID, nil
+}
+
+// projectFromQuery reads the project ID from a query parameter
+func projectFromQuery(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		projectID, err := strconv.ParseInt(c.Query(name), 10, 64)
+		if err != nil || projectID <= 0 {
+			return 0, errInvalidProjectReference
+		}
+		return projectID, nil
+	}
+}
+
+// projectFromForm reads the project ID from a multipart form field
+func projectFromForm(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		projectID, err := strconv.ParseInt(c.FormValue(name), 10, 64)
+		if err != nil || projectID <= 0 {
+			return 0, errInvalidProjectReference
+		}
+		return projectID, nil
+	}
+}
+
+func (api *API) projectFromTestCase(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		return api.PermissionService.ProjectIDForTestCase(c.UserContext(), c.Params(name))
+	}
+}
+
+func (api *API) projectFromModule(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		moduleID, err := strconv.ParseInt(c.Params(name), 10, 32)
+		if err != nil {
+			return 0, services.ErrNotFound
+		}
+		return api.PermissionService.ProjectIDForModule(c.UserContext(), int32(moduleID))
+	}
+}
+
+func (api *API) projectFromTestPlan(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		testPlanID, err := strconv.ParseInt(c.Params(name), 10, 64)
+		if err != nil {
+			return 0, services.ErrNotFound
+		}
+		return api.PermissionService.ProjectIDForTestPlan(c.UserContext(), testPlanID)
+	}
+}
+
+func (api *API) projectFromTestRun(name string) projectResolver {
+	return func(c *fiber.Ctx) (int64, error) {
+		return api.PermissionService.ProjectIDForTestRun(c.UserContext(), c.Params(name))
+	}
+}
+
+// authorizeBulkTestRunCommit checks every test run in a bulk commit since they
+// may belong to different projects
+func (api *API) authorizeBulkTestRunCommit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.BulkCommitTestResults
+		if err := json.Unmarshal(c.Body(), &request); err != nil {
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		checked := map[int64]bool{}
+		for _, result := range request.TestResults {
+			projectID, err := api.PermissionService.ProjectIDForTestRun(c.UserContext(), result.TestRunID)
+			if err != nil {
+				return api.authorizationProblem(c, err)
+			}
+			if checked[projectID] {
+				continue
+			}
+			if err := api.authorizeProject(c, projectID, services.ActionExecuteTestRun); err != nil {
+				return api.authorizationProblem(c, err)
+			}
+			checked[projectID] = true
+		}
+		return c.Next()
+	}
+}
@@ -5,7 +5,45 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+const apiTokenLocalsKey = "apiToken"
+const scimOrgLocalsKey = "scimOrgID"
+const authOrgLocalsKey = "authOrgID"
+
+// APITokenPrincipal is the account a request authenticated with an API token acts as
+type APITokenPrincipal struct {
+	TokenID   int64
+	UserID    int64
+	Email     string
+	Name      string
+	Scopes    []string
+	ProjectID int64
+}
+
+func SetAPITokenPrincipal(ctx *fiber.Ctx, principal *APITokenPrincipal) {
+	ctx.Locals(apiTokenLocalsKey, principal)
+}
+
+// GetAPITokenPrincipal returns the principal when the request was authenticated with an API token
+func GetAPITokenPrincipal(ctx *fiber.Ctx) (*APITokenPrincipal, bool) {
+	principal, ok := ctx.Locals(apiTokenLocalsKey).(*APITokenPrincipal)
+	return principal, ok
+}
+
+// SetSCIMOrgID records the org the SCIM token of the request provisions
+func SetSCIMOrgID(ctx *fiber.Ctx, orgID int32) {
+	ctx.Locals(scimOrgLocalsKey, orgID)
+}
+
+// GetSCIMOrgID returns the org the SCIM token of the request provisions
+func GetSCIMOrgID(ctx *fiber.Ctx) int32 {
+	orgID, _ := ctx.Locals(scimOrgLocalsKey).(int32)
+	return orgID
+}
+
 func GetAuthUserID(ctx *fiber.Ctx) int64 {
+	if principal, ok := GetAPITokenPrincipal(ctx); ok {
+		return principal.UserID
+	}
 	token := ctx.Locals("user").(*jwt.Token)
 	claims := token.Claims.(jwt.MapClaims)
 	userID := claims["UserID"].(float64)
@@ -13,6 +51,9 @@ func GetAuthUserID(ctx *fiber.Ctx) int64 {
 }
 
 func GetAuthUsername(ctx *fiber.Ctx) string {
+	if principal, ok := GetAPITokenPrincipal(ctx); ok {
+		return principal.Name
+	}
 	token := ctx.Locals("user").(*jwt.Token)
 	claims := token.Claims.(jwt.MapClaims)
 	username := claims["Name"].(string)
@@ -20,8 +61,31 @@ func GetAuthUsername(ctx *fiber.Ctx) string {
 }
 
 func GetAuthUserEmail(ctx *fiber.Ctx) string {
+	if principal, ok := GetAPITokenPrincipal(ctx); ok {
+		return principal.Email
+	}
 	token := ctx.Locals("user").(*jwt.Token)
 	claims := token.Claims.(jwt.MapClaims)
 	email := claims["Email"].(string)
 	return string(email)
 }
+
+// SetAuthOrgID records the org the authenticated request works in
+func SetAuthOrgID(ctx *fiber.Ctx, orgID int64) {
+	ctx.Locals(authOrgLocalsKey, orgID)
+}
+
+// GetAuthOrgID returns the org the request works in, it is 0 for users who do
+// not belong to any org
+func GetAuthOrgID(ctx *fiber.Ctx) int64 {
+	if orgID, ok := ctx.Locals(authOrgLocalsKey).(int64); ok {
+		return orgID
+	}
+	if _, ok := GetAPITokenPrincipal(ctx); ok {
+		return 0
+	}
+	token := ctx.Locals("user").(*jwt.Token)
+	claims := token.Claims.(jwt.MapClaims)
+	orgID, _ := claims["OrgID"].(float64)
+	return int64(orgID)
+}
@@ -1,16 +1,24 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/pprof"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	jwtware "github.com/gofiber/jwt/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+	"github.com/golang-malawi/qatarina/pkg/scim"
 )
 
 func (api *API) middleware() {
@@ -37,17 +45,154 @@ func (api *API) middleware() {
 	}))
 }
 
+// errMissingJWT is reported when a request carries no access token
+var errMissingJWT = errors.New("Missing or malformed JWT")
+
 // Protected protect routes
-func RequireAuthentication(signingKey []byte) fiber.Handler {
-	return jwtware.New(jwtware.Config{
-		TokenLookup:  "header:Authorization,query:_auth,cookie:_qatarina_auth",
-		SigningKey:   signingKey,
-		ErrorHandler: jwtError,
-	})
+func RequireAuthentication(signingKeyService services.SigningKeyService, apiTokenService services.APITokenService, permissionService services.PermissionService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || !strings.HasPrefix(token, services.APITokenPrefix) {
+			return requireAccessToken(c, signingKeyService, permissionService)
+		}
+
+		apiToken, err := apiTokenService.Authenticate(c.Context(), token)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIToken) {
+				return c.Status(fiber.StatusUnauthorized).
+					JSON(fiber.Map{"status": "error", "message": "Invalid, expired or revoked API token", "data": nil})
+			}
+			return problemdetail.ServerErrorProblem(c, "failed to authenticate request")
+		}
+
+		principal := &authutil.APITokenPrincipal{
+			TokenID:   int64(apiToken.ID),
+			UserID:    int64(apiToken.UserID),
+			Email:     apiToken.Email,
+			Name:      apiToken.DisplayName.String,
+			Scopes:    apiToken.Scopes,
+			ProjectID: int64(apiToken.ProjectID.Int32),
+		}
+		if !apiTokenAllowsRequest(c, principal) {
+			return problemdetail.Forbidden(c, "API token is not allowed to access this resource")
+		}
+
+		authutil.SetAPITokenPrincipal(c, principal)
+		if err := scopeRequestToOrg(c, permissionService, principal.UserID, 0, principal.ProjectID); err != nil {
+			return problemdetail.ServerErrorProblem(c, "failed to authenticate request")
+		}
+		return c.Next()
+	}
+}
+
+// RequireSCIMToken authenticates the directory of an org on the SCIM endpoint,
+// errors are reported as SCIM errors since directories expect those
+func RequireSCIMToken(scimService services.SCIMService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || token == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+			return c.Status(fiber.StatusUnauthorized).
+				JSON(scim.NewError(fiber.StatusUnauthorized, "", "a SCIM bearer token is required"), scim.ContentType)
+		}
+
+		orgID, err := scimService.Authenticate(c.Context(), token)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSCIMToken) {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="scim", error="invalid_token"`)
+				return c.Status(fiber.StatusUnauthorized).
+					JSON(scim.NewError(fiber.StatusUnauthorized, "", "invalid or revoked SCIM token"), scim.ContentType)
+			}
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(scim.NewError(fiber.StatusInternalServerError, "", "failed to authenticate request"), scim.ContentType)
+		}
+
+		authutil.SetSCIMOrgID(c, orgID)
+		return c.Next()
+	}
+}
+
+// requireAccessToken verifies the access token from the Authorization header,
+// the _auth query parameter or the _qatarina_auth cookie against the published
+// signing keys and makes it available to authutil
+func requireAccessToken(c *fiber.Ctx, signingKeyService services.SigningKeyService, permissionService services.PermissionService) error {
+	tokenStr, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found || tokenStr == "" {
+		tokenStr = c.Query("_auth")
+	}
+	if tokenStr == "" {
+		tokenStr = c.Cookies("_qatarina_auth")
+	}
+	if tokenStr == "" {
+		return jwtError(c, errMissingJWT)
+	}
+
+	token, err := signingKeyService.ParseAccessToken(c.Context(), tokenStr)
+	if err != nil {
+		return jwtError(c, err)
+	}
+	c.Locals("user", token)
+	claims := token.Claims.(jwt.MapClaims)
+	userID, _ := claims["UserID"].(float64)
+	sessionOrgID, _ := claims["OrgID"].(float64)
+	if err := scopeRequestToOrg(c, permissionService, int64(userID), int64(sessionOrgID), 0); err != nil {
+		return problemdetail.ServerErrorProblem(c, "failed to authenticate request")
+	}
+	return c.Next()
+}
+
+// scopeRequestToOrg resolves the org the request works in and scopes the
+// context the handlers pass to services to it, so data of other orgs cannot
+// be reached through the request
+func scopeRequestToOrg(c *fiber.Ctx, permissionService services.PermissionService, userID, sessionOrgID, tokenProjectID int64) error {
+	orgID, err := permissionService.RequestOrgID(c.Context(), userID, sessionOrgID, tokenProjectID)
+	if err != nil {
+		return err
+	}
+	authutil.SetAuthOrgID(c, orgID)
+	c.SetUserContext(services.WithOrgID(c.UserContext(), orgID))
+	return nil
+}
+
+// apiTokenAllowsRequest checks the token scopes against the resource in the
+// request path, for example /v1/test-runs/... needs a test-runs scope
+func apiTokenAllowsRequest(c *fiber.Ctx, principal *authutil.APITokenPrincipal) bool {
+	segments := strings.Split(strings.TrimPrefix(c.Path(), "/v1/"), "/")
+	write := c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead
+	if !services.APITokenScopeAllows(principal.Scopes, segments[0], write) {
+		return false
+	}
+	if principal.ProjectID != 0 {
+		return apiTokenProjectAllows(segments, write, principal.ProjectID)
+	}
+	return true
+}
+
+// apiTokenProjectResources are the resources whose routes check the project
+// of the entity they work on, or of the one they create
+var apiTokenProjectResources = []string{"projects", "modules", "test-cases", "test-plans", "test-runs"}
+
+// apiTokenProjectAllows limits a project scoped token to the routes which
+// check its project, listings spanning projects are refused
+func apiTokenProjectAllows(segments []string, write bool, projectID int64) bool {
+	if !slices.Contains(apiTokenProjectResources, segments[0]) {
+		return false
+	}
+	entity := ""
+	if len(segments) > 1 {
+		entity = segments[1]
+	}
+	if segments[0] == "projects" {
+		id, err := strconv.ParseInt(entity, 10, 64)
+		return err == nil && id == projectID
+	}
+	// listing spans the projects of the org, creating checks the project
+	// named in the body
+	return entity != "" || write
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
-	if err.Error() == "Missing or malformed JWT" {
+	if errors.Is(err, errMissingJWT) {
 		return c.Status(fiber.StatusBadRequest).
 			JSON(fiber.Map{"status": "error", "message": "Missing or malformed JWT", "data": nil})
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	_ "github.com/golang-malawi/qatarina/docs"
 	apiv1 "github.com/golang-malawi/qatarina/internal/api/v1"
+	"github.com/golang-malawi/qatarina/internal/services"
 	"github.com/golang-malawi/qatarina/pkg/swagger"
 	"github.com/golang-malawi/qatarina/ui"
 )
@@ -18,20 +19,62 @@ func (api *API) routes() {
 	router.Get("/healthz", api.getSystemHealthz)
 	router.Get("/metrics", api.getSystemMetrics)
 	router.Get("/system/info", api.getSystemInfo)
+	router.Get("/.well-known/jwks.json", api.getJWKS)
 	router.Get("/swagger/*", swagger.New())
 
 	router.Post("/v1/auth/login", apiv1.AuthLogin(api.AuthService))
-	router.Post("/v1/auth/refresh-tokens", apiv1.AuthRefreshToken(api.AuthService))
+	router.Post("/v1/auth/login/2fa", apiv1.TwoFactorLogin(api.AuthService, api.logger))
+	router.Post("/v1/auth/login/2fa/enrol", apiv1.TwoFactorLoginEnrol(api.AuthService, api.logger))
+	router.Post("/v1/auth/refresh-tokens", apiv1.AuthRefreshToken(api.AuthService, api.logger))
+	router.Post("/v1/auth/reset-password", apiv1.RequestPasswordReset(api.AuthService, api.logger))
+	router.Post("/v1/auth/reset-password/confirm", apiv1.ConfirmPasswordReset(api.AuthService, api.logger))
+	router.Post("/v1/auth/verify-email", apiv1.VerifyEmail(api.AuthService, api.logger))
+	router.Post("/v1/auth/verify-email/resend", apiv1.ResendVerificationEmail(api.AuthService, api.logger))
+	router.Get("/v1/auth/oidc/login", apiv1.OIDCLogin(api.OIDCService, api.logger))
+	router.Get("/v1/auth/oidc/callback", apiv1.OIDCCallback(api.OIDCService, api.Config.OIDC.PostLoginRedirectURL, api.logger))
+	router.Get("/v1/invites/lookup", apiv1.LookupInvite(api.InviteService, api.logger))
+	router.Post("/v1/invites/accept", apiv1.AcceptInvite(api.InviteService, api.logger))
 
 	if api.Config.Auth.SignupEnabled {
 		router.Post("/v1/auth/signup", apiv1.Signup(api.AuthService))
 	}
 
-	authenticationMiddleware := RequireAuthentication([]byte(api.Config.Auth.JwtSecretKey))
+	if api.Config.SCIM.Enabled {
+		scimBaseURL := api.Config.Server.BaseURL() + "/scim/v2"
+		scimV2 := router.Group("/scim/v2", RequireSCIMToken(api.SCIMService))
+		{
+			scimV2.Get("/ServiceProviderConfig", apiv1.GetSCIMServiceProviderConfig(scimBaseURL))
+			scimV2.Get("/ResourceTypes", apiv1.ListSCIMResourceTypes(scimBaseURL))
+			scimV2.Get("/Users", apiv1.ListSCIMUsers(api.SCIMService, api.logger))
+			scimV2.Post("/Users", apiv1.CreateSCIMUser(api.SCIMService, api.logger))
+			scimV2.Get("/Users/:id", apiv1.GetSCIMUser(api.SCIMService, api.logger))
+			scimV2.Put("/Users/:id", apiv1.ReplaceSCIMUser(api.SCIMService, api.logger))
+			scimV2.Patch("/Users/:id", apiv1.PatchSCIMUser(api.SCIMService, api.logger))
+			scimV2.Delete("/Users/:id", apiv1.DeleteSCIMUser(api.SCIMService, api.logger))
+			scimV2.Get("/Groups", apiv1.ListSCIMGroups(api.SCIMService, api.logger))
+			scimV2.Post("/Groups", apiv1.CreateSCIMGroup(api.SCIMService, api.logger))
+			scimV2.Get("/Groups/:id", apiv1.GetSCIMGroup(api.SCIMService, api.logger))
+			scimV2.Put("/Groups/:id", apiv1.ReplaceSCIMGroup(api.SCIMService, api.logger))
+			scimV2.Patch("/Groups/:id", apiv1.PatchSCIMGroup(api.SCIMService, api.logger))
+			scimV2.Delete("/Groups/:id", apiv1.DeleteSCIMGroup())
+		}
+	}
+
+	authenticationMiddleware := RequireAuthentication(api.SigningKeyService, api.APITokenService, api.PermissionService)
 
 	authV1 := router.Group("/v1/auth", authenticationMiddleware)
 	{
 		authV1.Post("/change-password", apiv1.ChangePassword(api.AuthService, api.logger))
+		authV1.Post("/logout", apiv1.Logout(api.AuthService, api.logger))
+		authV1.Post("/logout-all", apiv1.LogoutAll(api.AuthService, api.logger))
+		authV1.Post("/switch-org", apiv1.SwitchOrg(api.AuthService, api.logger))
+		authV1.Get("/sessions", apiv1.ListSessions(api.AuthService, api.logger))
+		authV1.Delete("/sessions/:sessionID", apiv1.RevokeSession(api.AuthService, api.logger))
+		authV1.Get("/2fa", apiv1.GetTwoFactorStatus(api.AuthService, api.logger))
+		authV1.Delete("/2fa", apiv1.DisableTwoFactor(api.AuthService, api.logger))
+		authV1.Post("/2fa/enrol", apiv1.EnrolTwoFactor(api.AuthService, api.logger))
+		authV1.Post("/2fa/confirm", apiv1.ConfirmTwoFactor(api.AuthService, api.logger))
+		authV1.Post("/2fa/recovery-codes", apiv1.RegenerateRecoveryCodes(api.AuthService, api.logger))
 	}
 
 	usersV1 := router.Group("/v1/users", authenticationMiddleware)
@@ -41,8 +84,13 @@ func (api *API) routes() {
 		usersV1.Get("/query", apiv1.SearchUsers(api.UserService, api.logger))
 		usersV1.Get("/:userID", apiv1.GetOneUser(api.UserService, api.logger))
 		usersV1.Post("/:userID", apiv1.UpdateUser(api.UserService, api.logger))
-		usersV1.Post("/invite/:email", apiv1.InviteUser(api.UserService))
+		usersV1.Post("/invite/:email", apiv1.InviteUser(api.InviteService, api.logger))
 		usersV1.Delete("/:userID", apiv1.DeleteUser(api.UserService, api.logger))
+		usersV1.Post("/:userID/unlock", api.requireSuperAdmin(), apiv1.UnlockUser(api.AuthService, api.logger))
+		usersV1.Post("/:userID/reactivate", api.requireSuperAdmin(), apiv1.ReactivateUser(api.UserService, api.logger))
+		usersV1.Get("/:userID/offboarding", api.requireSuperAdmin(), apiv1.PreviewUserOffboarding(api.UserService, api.logger))
+		usersV1.Post("/:userID/offboard", api.requireSuperAdmin(), apiv1.OffboardUser(api.UserService, api.logger))
+		usersV1.Delete("/:userID/2fa", api.requireSuperAdmin(), apiv1.ResetUserTwoFactor(api.AuthService, api.logger))
 	}
 
 	projectsV1 := router.Group("/v1/projects", authenticationMiddleware)
@@ -50,38 +98,62 @@ func (api *API) routes() {
 		projectsV1.Get("", apiv1.ListProjects(api.ProjectsService))
 		projectsV1.Post("", apiv1.CreateProject(api.ProjectsService, api.TestPlansService, &api.Config.Platform, api.logger))
 		projectsV1.Get("/query", apiv1.SearchProjects(api.ProjectsService, api.logger))
-		projectsV1.Get("/:projectID/test-cases", apiv1.GetProjectTestCases(api.TestCasesService, api.logger))
-		projectsV1.Get("/:projectID/test-plans", apiv1.GetProjectTestPlans(api.TestPlansService, api.logger))
-		projectsV1.Get("/:projectID/test-runs", apiv1.GetProjectTestRuns(api.TestRunsService, api.logger))
-		projectsV1.Get("/:projectID/testers", apiv1.GetProjectTesters(api.ProjectsService, api.TesterService, api.logger))
-		projectsV1.Post("/:projectID/testers/assign", apiv1.AssignTesters(api.TesterService, api.logger))
-		projectsV1.Get("/:projectID", apiv1.GetOneProject(api.ProjectsService))
-		projectsV1.Post("/:projectID", apiv1.UpdateProject(api.ProjectsService, api.logger))
-		projectsV1.Delete("/:projectID", apiv1.DeleteProject(api.ProjectsService, api.logger))
-		projectsV1.Get("/:projectID/modules", apiv1.GetProjectModules(api.ModuleService, api.logger))
-		projectsV1.Get("/:projectID/test-cases/closed", apiv1.ListClosedTestCases(api.TestCasesService, api.logger))
-		projectsV1.Get("/:projectID/test-cases/failing", apiv1.ListFailingTestCases(api.TestCasesService, api.logger))
-		projectsV1.Get("/:projectID/test-cases/scheduled", apiv1.ListScheduledTestCases(api.TestCasesService, api.logger))
-		projectsV1.Get("/:projectID/test-cases/blocked", apiv1.ListBlockedTestCases(api.TestCasesService, api.logger))
-		projectsV1.Get("/:projectID/environments", apiv1.ListEnvironments(api.EnvironmentService, api.logger))
-		projectsV1.Post("/:projectID/environments", apiv1.CreateEnvironment(api.EnvironmentService, api.logger))
-		projectsV1.Post("/:projectID/environments/:environmentID", apiv1.UpdateEnvironment(api.EnvironmentService, api.logger))
-		projectsV1.Delete("/:projectID/environments/:environmentID", apiv1.DeleteEnvironment(api.EnvironmentService, api.logger))
-		projectsV1.Get("/:projectID/test-cases/suggested", apiv1.ListSuggestedTestCases(api.TestCasesService, api.logger))
-		projectsV1.Post("/:projectID/archive", apiv1.ArchiveProject(api.ProjectsService, api.logger))
-		projectsV1.Post("/:projectID/unarchive", apiv1.UnarchiveProject(api.ProjectsService, api.logger))
-		projectsV1.Get("/:projectID/test-case-template", apiv1.GetProjectTestCaseTemplate(api.ProjectsService, api.logger))
-		projectsV1.Post("/:projectID/test-case-template", apiv1.AddProjectTestCaseTemplate(api.ProjectsService, api.logger))
-		projectsV1.Post("/:projectID/automated-testing", apiv1.UpdateAutomatedTesting(api.ProjectsService, api.logger))
+		projectsV1.Post("/import", api.requireOrgAdmin(), apiv1.ImportProject(api.ProjectBundleService, api.logger))
+		projectsV1.Get("/:projectID/test-cases", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectTestCases(api.TestCasesService, api.logger))
+		projectsV1.Get("/:projectID/test-plans", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectTestPlans(api.TestPlansService, api.logger))
+		projectsV1.Get("/:projectID/test-runs", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectTestRuns(api.TestRunsService, api.logger))
+		projectsV1.Get("/:projectID/testers", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectTesters(api.ProjectsService, api.TesterService, api.logger))
+		projectsV1.Get("/:projectID/invites", api.authorize(services.ActionManageTesters, projectFromParam("projectID")), apiv1.ListProjectInvites(api.InviteService, api.logger))
+		projectsV1.Post("/:projectID/testers/assign", api.authorize(services.ActionManageTesters, projectFromParam("projectID")), apiv1.AssignTesters(api.TesterService, api.logger))
+		projectsV1.Post("/:projectID/testers/:testerID/update-role", api.authorize(services.ActionManageTesters, projectFromParam("projectID")), apiv1.UpdateTesterRole(api.TesterService, api.logger))
+		projectsV1.Delete("/:projectID/testers/:testerID", api.authorize(services.ActionManageTesters, projectFromParam("projectID")), apiv1.DeleteTester(api.TesterService, api.logger))
+		projectsV1.Get("/:projectID", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetOneProject(api.ProjectsService))
+		projectsV1.Post("/:projectID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.UpdateProject(api.ProjectsService, api.logger))
+		projectsV1.Delete("/:projectID", api.authorize(services.ActionDeleteProject, projectFromParam("projectID")), apiv1.DeleteProject(api.ProjectsService, api.logger))
+		projectsV1.Get("/:projectID/modules", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectModules(api.ModuleService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/export", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ExportTestCasesToFile(api.TestCaseExportService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/closed", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListClosedTestCases(api.TestCasesService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/failing", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListFailingTestCases(api.TestCasesService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/scheduled", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListScheduledTestCases(api.TestCasesService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/blocked", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListBlockedTestCases(api.TestCasesService, api.logger))
+		projectsV1.Get("/:projectID/environments", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListEnvironments(api.EnvironmentService, api.logger))
+		projectsV1.Post("/:projectID/environments", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.CreateEnvironment(api.EnvironmentService, api.logger))
+		projectsV1.Post("/:projectID/environments/:environmentID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.UpdateEnvironment(api.EnvironmentService, api.logger))
+		projectsV1.Delete("/:projectID/environments/:environmentID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.DeleteEnvironment(api.EnvironmentService, api.logger))
+		projectsV1.Get("/:projectID/test-cases/suggested", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListSuggestedTestCases(api.TestCasesService, api.logger))
+		projectsV1.Post("/:projectID/archive", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.ArchiveProject(api.ProjectsService, api.logger))
+		projectsV1.Post("/:projectID/unarchive", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.UnarchiveProject(api.ProjectsService, api.logger))
+		projectsV1.Post("/:projectID/clone", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.CloneProject(api.ProjectsService, api.logger))
+		projectsV1.Get("/:projectID/export", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.ExportProject(api.ProjectBundleService, api.logger))
+		projectsV1.Get("/:projectID/custom-fields", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListCustomFields(api.CustomFieldService, api.logger))
+		projectsV1.Post("/:projectID/custom-fields", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.CreateCustomField(api.CustomFieldService, api.logger))
+		projectsV1.Post("/:projectID/custom-fields/:fieldID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.UpdateCustomField(api.CustomFieldService, api.logger))
+		projectsV1.Delete("/:projectID/custom-fields/:fieldID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.DeleteCustomField(api.CustomFieldService, api.logger))
+		projectsV1.Get("/:projectID/sub-projects", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListSubProjects(api.ProjectsService, api.logger))
+		projectsV1.Post("/:projectID/parent", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.SetParentProject(api.ProjectsService, api.logger))
+		projectsV1.Get("/:projectID/rollup", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ProjectRollup(api.DashboardService, api.logger))
+		projectsV1.Get("/:projectID/test-case-template", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetProjectTestCaseTemplate(api.ProjectsService, api.logger))
+		projectsV1.Post("/:projectID/test-case-template", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.AddProjectTestCaseTemplate(api.ProjectsService, api.logger))
+		projectsV1.Get("/:projectID/test-case-code-pattern", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetTestCaseCodePattern(api.TestCaseCodeService, api.logger))
+		projectsV1.Post("/:projectID/test-case-code-pattern", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.SetTestCaseCodePattern(api.TestCaseCodeService, api.logger))
+		projectsV1.Post("/:projectID/test-case-codes/resequence", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.ResequenceTestCaseCodes(api.TestCaseCodeService, api.logger))
+		projectsV1.Get("/:projectID/test-case-codes/aliases", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListTestCaseCodeAliases(api.TestCaseCodeService, api.logger))
+		projectsV1.Get("/:projectID/shared-steps", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListSharedSteps(api.SharedStepService, api.logger))
+		projectsV1.Post("/:projectID/shared-steps", api.authorize(services.ActionCreateTestCase, projectFromParam("projectID")), apiv1.CreateSharedStep(api.SharedStepService, api.logger))
+		projectsV1.Get("/:projectID/shared-steps/:sharedStepID", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetSharedStep(api.SharedStepService, api.logger))
+		projectsV1.Post("/:projectID/shared-steps/:sharedStepID", api.authorize(services.ActionCreateTestCase, projectFromParam("projectID")), apiv1.UpdateSharedStep(api.SharedStepService, api.logger))
+		projectsV1.Delete("/:projectID/shared-steps/:sharedStepID", api.authorize(services.ActionCreateTestCase, projectFromParam("projectID")), apiv1.DeleteSharedStep(api.SharedStepService, api.logger))
+		projectsV1.Get("/:projectID/shared-steps/:sharedStepID/usages", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.GetSharedStepUsages(api.SharedStepService, api.logger))
+		projectsV1.Post("/:projectID/automated-testing", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.UpdateAutomatedTesting(api.ProjectsService, api.logger))
 	}
 
 	modulesV1 := router.Group("/v1/modules", authenticationMiddleware)
 	{
-		modulesV1.Post("", apiv1.CreateModule(api.ModuleService, api.logger))
-		modulesV1.Get("/:moduleID", apiv1.GetOneModule(api.ModuleService, api.logger))
+		modulesV1.Post("", api.authorize(services.ActionManageProject, projectFromBody), apiv1.CreateModule(api.ModuleService, api.logger))
+		modulesV1.Get("/:moduleID", api.authorize(services.ActionViewProject, api.projectFromModule("moduleID")), apiv1.GetOneModule(api.ModuleService, api.logger))
 		modulesV1.Get("", apiv1.GetAllModules(api.ModuleService, api.logger))
-		modulesV1.Post("/:moduleID", apiv1.UpdateModule(api.ModuleService, api.logger))
-		modulesV1.Delete("/:moduleID", apiv1.DeleteModule(api.ModuleService, api.logger))
+		modulesV1.Post("/:moduleID", api.authorize(services.ActionManageProject, api.projectFromModule("moduleID")), apiv1.UpdateModule(api.ModuleService, api.logger))
+		modulesV1.Delete("/:moduleID", api.authorize(services.ActionManageProject, api.projectFromModule("moduleID")), apiv1.DeleteModule(api.ModuleService, api.logger))
 
 	}
 
@@ -98,69 +170,82 @@ func (api *API) routes() {
 	{
 		meV1.Get("/test-cases/inbox", apiv1.ListAssignedTestCases(api.TestCasesService, api.logger))
 		meV1.Get("/test-cases/summary", apiv1.GetExecutionSummary(api.TestCasesService, api.logger))
+		meV1.Get("/orgs", apiv1.ListMyOrgs(api.OrgService, api.logger))
+		meV1.Get("/tokens", apiv1.ListAPITokens(api.APITokenService, api.logger))
+		meV1.Post("/tokens", apiv1.CreateAPIToken(api.APITokenService, api.logger))
+		meV1.Delete("/tokens/:tokenID", apiv1.RevokeAPIToken(api.APITokenService, api.logger))
 	}
 
 	testCasesV1 := router.Group("/v1/test-cases", authenticationMiddleware)
 	{
-		testCasesV1.Get("", apiv1.ListTestCases(api.TestCasesService, api.logger))
-		testCasesV1.Post("", apiv1.CreateTestCase(api.TestCasesService, api.ProjectsService, api.logger, api.Config))
-		testCasesV1.Post("/validate-script", apiv1.ValidateTestCaseScript(api.logger, api.Config))
-		testCasesV1.Post("/import-file", apiv1.ImportTestCasesFromFile(api.TestCasesService, api.TestCaseImportService, api.logger))
-		testCasesV1.Post("/bulk", apiv1.BulkCreateTestCases(api.TestCasesService, api.logger))
-		testCasesV1.Get("/query", apiv1.SearchTestCases(api.TestCasesService))
-		testCasesV1.Post("/github-import", apiv1.ImportIssuesFromGitHubAsTestCases(api.ProjectsService, api.TestCasesService, api.logger))
-		testCasesV1.Post("/suggest", apiv1.SuggestTestCase(api.TestCasesService, api.logger))
-		testCasesV1.Get("/:testCaseID", apiv1.GetOneTestCase(api.TestCasesService))
-		testCasesV1.Post("/:testCaseID", apiv1.UpdateTestCase(api.TestCasesService, api.ProjectsService, api.logger, api.Config))
-		testCasesV1.Delete("/:testCaseID", apiv1.DeleteTestCase(api.TestCasesService, api.logger))
-		testCasesV1.Post("/:testCaseID/mark-draft", apiv1.MarkTestCaseAsDraft(api.TestCasesService, api.logger))
-		testCasesV1.Post("/:testCaseID/unmark-draft", apiv1.UnMarkTestCaseAsDraft(api.TestCasesService, api.logger))
-		testCasesV1.Post("/:testCaseID/accept", apiv1.AcceptSuggestedTestCase(api.TestCasesService, api.logger))
-		testCasesV1.Delete("/:testCaseID/reject", apiv1.RejectSuggestedTestCase(api.TestCasesService, api.logger))
-		testCasesV1.Post("/:test_case_id/execute", apiv1.ExecuteTestCase(api.TestCasesService, api.TestRunsService, api.logger, api.Config))
-		testCasesV1.Post("/:testCaseID/branch", apiv1.BranchTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Get("", apiv1.ListTestCases(api.TestCasesService, api.CustomFieldService, api.logger))
+		testCasesV1.Post("", api.authorize(services.ActionCreateTestCase, projectFromBody), apiv1.CreateTestCase(api.TestCasesService, api.ProjectsService, api.CustomFieldService, api.logger, api.Config))
+		testCasesV1.Post("/validate-script", api.authorize(services.ActionCreateTestCase, projectFromForm("project_id")), apiv1.ValidateTestCaseScript(api.logger, api.Config))
+		testCasesV1.Post("/import-file", api.authorize(services.ActionCreateTestCase, projectFromForm("projectID")), apiv1.ImportTestCasesFromFile(api.TestCasesService, api.TestCaseImportService, api.logger))
+		testCasesV1.Post("/bulk", api.authorize(services.ActionCreateTestCase, projectFromBody), apiv1.BulkCreateTestCases(api.TestCasesService, api.logger))
+		testCasesV1.Get("/query", api.authorize(services.ActionViewProject, projectFromQuery("project_id")), apiv1.SearchTestCases(api.TestCasesService))
+		testCasesV1.Post("/github-import", api.authorize(services.ActionCreateTestCase, projectFromBody), apiv1.ImportIssuesFromGitHubAsTestCases(api.ProjectsService, api.TestCasesService, api.logger))
+		testCasesV1.Post("/suggest", api.authorize(services.ActionSuggestTestCase, projectFromBody), apiv1.SuggestTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Get("/:testCaseID", api.authorize(services.ActionViewProject, api.projectFromTestCase("testCaseID")), apiv1.GetOneTestCase(api.TestCasesService, api.CustomFieldService))
+		testCasesV1.Post("/:testCaseID", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.UpdateTestCase(api.TestCasesService, api.ProjectsService, api.CustomFieldService, api.logger, api.Config))
+		testCasesV1.Delete("/:testCaseID", api.authorize(services.ActionDeleteTestCase, api.projectFromTestCase("testCaseID")), apiv1.DeleteTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/mark-draft", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.MarkTestCaseAsDraft(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/unmark-draft", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.UnMarkTestCaseAsDraft(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/accept", api.authorize(services.ActionReviewTestCase, api.projectFromTestCase("testCaseID")), apiv1.AcceptSuggestedTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Delete("/:testCaseID/reject", api.authorize(services.ActionReviewTestCase, api.projectFromTestCase("testCaseID")), apiv1.RejectSuggestedTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:test_case_id/execute", api.authorize(services.ActionExecuteTestRun, api.projectFromTestCase("test_case_id")), apiv1.ExecuteTestCase(api.TestCasesService, api.TestRunsService, api.logger, api.Config))
+		testCasesV1.Post("/:testCaseID/branch", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.BranchTestCase(api.TestCasesService, api.logger))
+		testCasesV1.Get("/:testCaseID/revisions", api.authorize(services.ActionViewProject, api.projectFromTestCase("testCaseID")), apiv1.ListTestCaseRevisions(api.TestCasesService, api.logger))
+		testCasesV1.Get("/:testCaseID/revisions/diff", api.authorize(services.ActionViewProject, api.projectFromTestCase("testCaseID")), apiv1.DiffTestCaseRevisions(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/revisions/:revision/restore", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.RestoreTestCaseRevision(api.TestCasesService, api.logger))
+		testCasesV1.Get("/:testCaseID/dataset", api.authorize(services.ActionViewProject, api.projectFromTestCase("testCaseID")), apiv1.GetTestCaseDataset(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/dataset", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.SetTestCaseDataset(api.TestCasesService, api.logger))
+		testCasesV1.Post("/:testCaseID/dataset/import", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.ImportTestCaseDataset(api.TestCasesService, api.logger))
+		testCasesV1.Delete("/:testCaseID/dataset", api.authorize(services.ActionCreateTestCase, api.projectFromTestCase("testCaseID")), apiv1.DeleteTestCaseDataset(api.TestCasesService, api.logger))
 	}
 
 	testPlansV1 := router.Group("/v1/test-plans", authenticationMiddleware)
 	{
 		testPlansV1.Get("", apiv1.ListTestPlans(api.TestPlansService, api.logger))
-		testPlansV1.Post("", apiv1.CreateTestPlan(api.TestPlansService, api.logger))
-		testPlansV1.Get("/query", apiv1.SearchTestPlans(api.TestPlansService, api.logger))
-		testPlansV1.Get("/:testPlanID", apiv1.GetOneTestPlan(api.TestPlansService, api.logger))
-		testPlansV1.Post("/:testPlanID", apiv1.UpdateTestPlan(api.TestPlansService, api.logger))
-		testPlansV1.Get("/:testPlanID/test-cases", apiv1.GetTestPlanTestCases(api.TestCasesService, api.logger))
-		testPlansV1.Post("/:testPlanID/test-cases", apiv1.AssignTestsToPlan(api.TestPlansService, api.logger))
-		testPlansV1.Get("/:testPlanID/script-test-cases", apiv1.GetScriptTestPlanTestCases(api.TestCasesService, api.logger))
-		testPlansV1.Get("/:testPlanID/test-runs", apiv1.GetTestPlanTestRuns(api.TestPlansService, api.logger))
-		testPlansV1.Delete("/:testPlanID", apiv1.DeleteTestPlan(api.TestPlansService, api.logger))
-		testPlansV1.Post("/:testPlanID/close", apiv1.CloseTestPlan(api.TestPlansService, api.logger))
-		testPlansV1.Post("/:testPlanID/environment", apiv1.ChangeEnvironment(api.TestPlansService, api.logger))
-
-		testPlansV1.Post("/:testPlanID/test-cases/batch", apiv1.BatchAssignTestCasesToPlan(api.TestPlansService, api.logger))
+		testPlansV1.Post("", api.authorize(services.ActionManageTestPlan, projectFromBody), apiv1.CreateTestPlan(api.TestPlansService, api.logger))
+		testPlansV1.Get("/query", api.authorize(services.ActionViewProject, projectFromQuery("q")), apiv1.SearchTestPlans(api.TestPlansService, api.logger))
+		testPlansV1.Get("/:testPlanID", api.authorize(services.ActionViewProject, api.projectFromTestPlan("testPlanID")), apiv1.GetOneTestPlan(api.TestPlansService, api.logger))
+		testPlansV1.Post("/:testPlanID", api.authorize(services.ActionManageTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.UpdateTestPlan(api.TestPlansService, api.logger))
+		testPlansV1.Get("/:testPlanID/test-cases", api.authorize(services.ActionViewProject, api.projectFromTestPlan("testPlanID")), apiv1.GetTestPlanTestCases(api.TestCasesService, api.logger))
+		testPlansV1.Post("/:testPlanID/test-cases", api.authorize(services.ActionManageTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.AssignTestsToPlan(api.TestPlansService, api.logger))
+		testPlansV1.Get("/:testPlanID/script-test-cases", api.authorize(services.ActionViewProject, api.projectFromTestPlan("testPlanID")), apiv1.GetScriptTestPlanTestCases(api.TestCasesService, api.logger))
+		testPlansV1.Get("/:testPlanID/test-runs", api.authorize(services.ActionViewProject, api.projectFromTestPlan("testPlanID")), apiv1.GetTestPlanTestRuns(api.TestPlansService, api.logger))
+		testPlansV1.Delete("/:testPlanID", api.authorize(services.ActionDeleteTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.DeleteTestPlan(api.TestPlansService, api.logger))
+		testPlansV1.Post("/:testPlanID/close", api.authorize(services.ActionCloseTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.CloseTestPlan(api.TestPlansService, api.logger))
+		testPlansV1.Post("/:testPlanID/environment", api.authorize(services.ActionManageTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.ChangeEnvironment(api.TestPlansService, api.logger))
+
+		testPlansV1.Post("/:testPlanID/test-cases/batch", api.authorize(services.ActionManageTestPlan, api.projectFromTestPlan("testPlanID")), apiv1.BatchAssignTestCasesToPlan(api.TestPlansService, api.logger))
 		
 		commentsV1 := testPlansV1.Group("/:testPlanID/comments")
 		{
-			commentsV1.Get("", apiv1.ListTestPlanComments(api.TestPlansService, api.logger))
-			commentsV1.Post("", apiv1.CreateTestPlanComment(api.TestPlansService, api.logger))
-			commentsV1.Delete("/:commentID", apiv1.DeleteTestPlanComment(api.TestPlansService, api.logger))
-			commentsV1.Post("/:commentID/convert", apiv1.ConvertCommentToTestCase(api.TestPlansService, api.logger))
+			commentsV1.Get("", api.authorize(services.ActionViewProject, api.projectFromTestPlan("testPlanID")), apiv1.ListTestPlanComments(api.TestPlansService, api.logger))
+			commentsV1.Post("", api.authorize(services.ActionComment, api.projectFromTestPlan("testPlanID")), apiv1.CreateTestPlanComment(api.TestPlansService, api.logger))
+			commentsV1.Delete("/:commentID", api.authorize(services.ActionComment, api.projectFromTestPlan("testPlanID")), apiv1.DeleteTestPlanComment(api.TestPlansService, api.logger))
+			commentsV1.Post("/:commentID/convert", api.authorize(services.ActionCreateTestCase, api.projectFromTestPlan("testPlanID")), apiv1.ConvertCommentToTestCase(api.TestPlansService, api.logger))
 		}
 	}
 
 	testRunsV1 := router.Group("/v1/test-runs", authenticationMiddleware)
 	{
 		testRunsV1.Get("", apiv1.ListTestRuns(api.TestRunsService, api.logger))
-		testRunsV1.Post("", apiv1.CreateTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Get("/query", apiv1.SearchTestRuns(api.TestRunsService, api.logger))
-		testRunsV1.Post("/bulk/commit", apiv1.CommitBulkTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Get("/:testRunID", apiv1.GetOneTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Post("/:testRunID", apiv1.UpdateTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Post("/:testRunID/commit", apiv1.CommitTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Post("/:testRunID/feedback", apiv1.RecordTestRunFeedback(api.TestRunsService, api.logger))
-		testRunsV1.Post("/:testRunID/execute", apiv1.ExecuteTestRun(api.TestRunsService, api.TestCasesService, api.logger, api.Config))
-		testRunsV1.Get("/:testRunID/stream", apiv1.StreamTestRunLogs(api.TestRunsService, api.logger))
-		testRunsV1.Delete("/:testRunID", apiv1.DeleteTestRun(api.TestRunsService, api.logger))
-		testRunsV1.Post("/:testRunID/close", apiv1.CloseTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("", api.authorize(services.ActionExecuteTestRun, projectFromBody), apiv1.CreateTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Get("/query", api.authorize(services.ActionViewProject, projectFromQuery("q")), apiv1.SearchTestRuns(api.TestRunsService, api.logger))
+		testRunsV1.Post("/bulk/commit", api.authorizeBulkTestRunCommit(), apiv1.CommitBulkTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Get("/:testRunID", api.authorize(services.ActionViewProject, api.projectFromTestRun("testRunID")), apiv1.GetOneTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.UpdateTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID/commit", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.CommitTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID/feedback", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.RecordTestRunFeedback(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID/execute", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.ExecuteTestRun(api.TestRunsService, api.TestCasesService, api.logger, api.Config))
+		testRunsV1.Get("/:testRunID/custom-fields", api.authorize(services.ActionViewProject, api.projectFromTestRun("testRunID")), apiv1.GetTestRunCustomFields(api.TestRunsService, api.CustomFieldService, api.logger))
+		testRunsV1.Get("/:testRunID/stream", api.authorize(services.ActionViewProject, api.projectFromTestRun("testRunID")), apiv1.StreamTestRunLogs(api.TestRunsService, api.logger))
+		testRunsV1.Delete("/:testRunID", api.authorize(services.ActionDeleteTestRun, api.projectFromTestRun("testRunID")), apiv1.DeleteTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID/close", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.CloseTestRun(api.TestRunsService, api.logger))
+		testRunsV1.Post("/:testRunID/resume", api.authorize(services.ActionExecuteTestRun, api.projectFromTestRun("testRunID")), apiv1.ResumeTestRun(api.TestRunsService, api.logger))
 	}
 
 	testersV1 := router.Group("/v1/testers", authenticationMiddleware)
@@ -168,9 +253,15 @@ func (api *API) routes() {
 		testersV1.Get("", apiv1.ListTesters(api.TesterService, api.logger))
 		testersV1.Get("/query", apiv1.SearchTesters(api.TesterService, api.logger))
 		testersV1.Get("/:testerID", apiv1.GetOneTester(api.TesterService, api.logger))
-		testersV1.Post("/invite", apiv1.InviteTester(api.TesterService, api.logger))
-		testersV1.Post("/:testerID/update-role", apiv1.UpdateTesterRole(api.TesterService, api.logger))
-		testersV1.Delete("/:testerID", apiv1.DeleteTester(api.TesterService, api.logger))
+		testersV1.Post("/invite", api.authorize(services.ActionManageTesters, projectFromBody), apiv1.InviteTester(api.InviteService, api.logger))
+	}
+
+	invitesV1 := router.Group("/v1/invites", authenticationMiddleware)
+	{
+		invitesV1.Get("", apiv1.ListInvites(api.InviteService, api.logger))
+		invitesV1.Post("", apiv1.CreateInvite(api.InviteService, api.logger))
+		invitesV1.Delete("/:inviteID", apiv1.RevokeInvite(api.InviteService, api.logger))
+		invitesV1.Post("/:inviteID/resend", apiv1.ResendInvite(api.InviteService, api.logger))
 	}
 
 	orgsV1 := router.Group("/v1/orgs", authenticationMiddleware)
@@ -180,6 +271,11 @@ func (api *API) routes() {
 		orgsV1.Get("/:orgID", apiv1.GetOrg(api.OrgService, api.logger))
 		orgsV1.Put("/:orgID", apiv1.UpdateOrg(api.OrgService, api.logger))
 		orgsV1.Delete("/:orgID", apiv1.DeleteOrg(api.OrgService, api.logger))
+		orgsV1.Get("/:orgID/members", apiv1.ListOrgMembers(api.OrgService, api.logger))
+		orgsV1.Post("/:orgID/members", apiv1.AddOrgMember(api.OrgService, api.logger))
+		orgsV1.Put("/:orgID/members/:userID", apiv1.UpdateOrgMember(api.OrgService, api.logger))
+		orgsV1.Delete("/:orgID/members/:userID", apiv1.RemoveOrgMember(api.OrgService, api.logger))
+		orgsV1.Post("/:orgID/transfer-ownership", apiv1.TransferOrgOwnership(api.OrgService, api.logger))
 	}
 
 	settingsApi := router.Group("/v1/settings", authenticationMiddleware)
@@ -205,11 +301,11 @@ func (api *API) routes() {
 
 	reportsV1 := projectsV1.Group("/:projectID/reports", authenticationMiddleware)
 	{
-		reportsV1.Get("", apiv1.ListReports(api.ReportService, api.logger))
-		reportsV1.Post("", apiv1.CreateReport(api.ReportService, api.logger))
-		reportsV1.Delete("/:reportID", apiv1.DeleteReport(api.ReportService, api.logger))
-		reportsV1.Get("/:reportID/download", apiv1.DownloadReport(api.ReportService, api.logger))
-		reportsV1.Get("/:reportID/view", apiv1.ViewReport(api.ReportService, api.logger)) // ✅ new inline view route
+		reportsV1.Get("", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ListReports(api.ReportService, api.logger))
+		reportsV1.Post("", api.authorize(services.ActionCreateReport, projectFromParam("projectID")), apiv1.CreateReport(api.ReportService, api.logger))
+		reportsV1.Delete("/:reportID", api.authorize(services.ActionManageProject, projectFromParam("projectID")), apiv1.DeleteReport(api.ReportService, api.logger))
+		reportsV1.Get("/:reportID/download", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.DownloadReport(api.ReportService, api.logger))
+		reportsV1.Get("/:reportID/view", api.authorize(services.ActionViewProject, projectFromParam("projectID")), apiv1.ViewReport(api.ReportService, api.logger)) // ✅ new inline view route
 	}
 
 	// Serves the app at the root path  "/"
@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-malawi/qatarina/internal/schema"
 	"github.com/golang-malawi/qatarina/internal/version"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
 )
 
 // @Summary Health check endpoint
@@ -42,3 +43,22 @@ func (api *API) getSystemInfo(ctx *fiber.Ctx) error {
 		BuildDate:  version.BuildDate,
 	})
 }
+
+// @Summary JSON Web Key Set
+// @Description Returns the public keys access tokens are signed with so other services can verify them,
+// @Description keys are published before they start signing and until the tokens they signed have expired
+// @Tags system
+// @Produce json
+// @Success 200 {object} signingkey.JWKS
+// @Failure 500 {object} problemdetail.ProblemDetail
+// @Router /.well-known/jwks.json [get]
+func (api *API) getJWKS(ctx *fiber.Ctx) error {
+	jwks, err := api.SigningKeyService.JWKS(ctx.Context())
+	if err != nil {
+		api.logger.Error("system", "failed to load signing keys", "error", err)
+		return problemdetail.ServerErrorProblem(ctx, "failed to load signing keys")
+	}
+	// verifiers refetch the keys when they see an unknown kid, the cache only saves round trips
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(jwks)
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// ListAPITokens godoc
+//
+//	@ID				ListAPITokens
+//	@Summary		List API tokens
+//	@Description	List the active API tokens created by the authenticated user
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	schema.APITokenListResponse
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/me/tokens [get]
+func ListAPITokens(apiTokenService services.APITokenService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokens, err := apiTokenService.List(c.UserContext(), authutil.GetAuthUserID(c))
+		if err != nil {
+			logger.Error(loggedmodule.ApiTokens, "failed to list api tokens", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+		return c.JSON(schema.APITokenListResponse{Tokens: tokens})
+	}
+}
+
+// CreateAPIToken godoc
+//
+//	@ID				CreateAPIToken
+//	@Summary		Create an API token
+//	@Description	Create a scoped API token for the authenticated user or a new bot account, the token is only returned once. Bot accounts are added as testers of the project of the token, which needs permission to manage its testers
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.CreateAPITokenRequest	true	"API token data"
+//	@Success		200			{object}	schema.CreateAPITokenResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		403			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/me/tokens [post]
+func CreateAPIToken(apiTokenService services.APITokenService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// tokens must not be able to mint further tokens
+		if _, ok := authutil.GetAPITokenPrincipal(c); ok {
+			return problemdetail.Forbidden(c, "API tokens cannot be created using an API token")
+		}
+
+		var req schema.CreateAPITokenRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &req); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		token, err := apiTokenService.Create(c.UserContext(), authutil.GetAuthUserID(c), &req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPITokenScope) || errors.Is(err, services.ErrServiceAccountNeedsProject) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				return problemdetail.Forbidden(c, "you do not have access to the project")
+			}
+			logger.Error(loggedmodule.ApiTokens, "failed to create api token", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to create api token")
+		}
+		return c.JSON(token)
+	}
+}
+
+// RevokeAPIToken godoc
+//
+//	@ID				RevokeAPIToken
+//	@Summary		Revoke an API token
+//	@Description	Revoke an API token created by the authenticated user
+//	@Tags			me
+//	@Accept			json
+//	@Produce		json
+//	@Param			tokenID	path		string	true	"API token ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/me/tokens/{tokenID} [delete]
+func RevokeAPIToken(apiTokenService services.APITokenService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenID, err := c.ParamsInt("tokenID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+
+		err = apiTokenService.Revoke(c.UserContext(), authutil.GetAuthUserID(c), int64(tokenID))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "api token not found")
+			}
+			logger.Error(loggedmodule.ApiTokens, "failed to revoke api token", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to revoke api token")
+		}
+		return c.JSON(fiber.Map{"message": "API token revoked successfully"})
+	}
+}
@@ -15,13 +15,15 @@ import (
 )
 
 // @Summary User login
-// @Description Authenticates a user and returns access tokens
+// @Description Authenticates a user and returns access tokens, or a challenge token for /v1/auth/login/2fa when a second factor is required
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body schema.LoginRequest true "Login credentials"
 // @Success 200 {object} schema.LoginResponse
 // @Failure 400 {object} problemdetail.ProblemDetail "Invalid credentials or request body"
+// @Failure 403 {object} problemdetail.ProblemDetail "Email address not verified"
+// @Failure 429 {object} problemdetail.ProblemDetail "Too many failed login attempts"
 // @Failure 500 {object} problemdetail.ProblemDetail "Server error"
 // @Router /v1/auth/login [post]
 func AuthLogin(authService services.AuthService) fiber.Handler {
@@ -31,12 +33,20 @@ func AuthLogin(authService services.AuthService) fiber.Handler {
 		if err != nil {
 			return problemdetail.ValidationErrors(ctx, "invalid data in the request", err)
 		}
+		request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+		request.IPAddress = ctx.IP()
 
 		loginData, err := authService.SignIn(&request)
 		if err != nil {
 			if errors.Is(err, services.ErrInvalidCredentials) {
 				return problemdetail.BadRequest(ctx, "invalid credentials provided")
 			}
+			if errors.Is(err, services.ErrLoginLocked) {
+				return problemdetail.TooManyRequests(ctx, err.Error())
+			}
+			if errors.Is(err, services.ErrAccountNotVerified) {
+				return problemdetail.Forbidden(ctx, "email address has not been verified, check your email for the verification link")
+			}
 			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
 		}
 
@@ -53,10 +63,28 @@ func AuthLogin(authService services.AuthService) fiber.Handler {
 // @Success 200 {object} schema.RefreshTokenResponse
 // @Failure 400 {object} problemdetail.ProblemDetail "Invalid token"
 // @Failure 500 {object} problemdetail.ProblemDetail "Server error"
-// @Router /v1/auth/refresh-token [post]
-func AuthRefreshToken(authService services.AuthService) fiber.Handler {
+// @Failure 401 {object} problemdetail.ProblemDetail "Refresh token expired, revoked or reused"
+// @Router /v1/auth/refresh-tokens [post]
+func AuthRefreshToken(authService services.AuthService, logger logging.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		return problemdetail.NotImplemented(ctx, "not implemented yet")
+		var request schema.RefreshTokenRequest
+		_, err := common.ParseBodyThenValidate(ctx, &request)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid data in the request", err)
+		}
+		request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+		request.IPAddress = ctx.IP()
+
+		tokens, err := authService.RefreshToken(ctx.Context(), &request)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+				return problemdetail.NotAuthorizedProblem(ctx, err.Error())
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to refresh tokens", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
+		}
+
+		return ctx.JSON(tokens)
 	}
 }
 
@@ -77,6 +105,8 @@ func Signup(authService services.AuthService) fiber.Handler {
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse request body")
 		}
+		request.UserAgent = c.Get(fiber.HeaderUserAgent)
+		request.IPAddress = c.IP()
 		token, err := authService.SignUp(request)
 		if err != nil {
 			if errors.Is(err, services.ErrUserAlreadyExists) {
@@ -84,8 +114,12 @@ func Signup(authService services.AuthService) fiber.Handler {
 			}
 			return problemdetail.ServerErrorProblem(c, "failed to sign up")
 		}
+		message := "Sign up process completed successfully"
+		if token.Token == "" {
+			message = "Sign up process completed successfully, check your email to verify your account"
+		}
 		return c.JSON(fiber.Map{
-			"message": "Sign up process completed successfully",
+			"message": message,
 			"token":   token,
 		})
 	}
@@ -131,3 +165,247 @@ func ChangePassword(authService services.AuthService, logger logging.Logger) fib
 		return ctx.JSON(fiber.Map{"message": "Password changed successfully"})
 	}
 }
+
+// RequestPasswordReset godoc
+//
+//	@ID				RequestPasswordReset
+//	@Summary		Request a password reset link
+//	@Description	Sends a single-use password reset link to the email if it belongs to an account
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.PasswordResetRequest	true	"Password reset request"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/reset-password [post]
+func RequestPasswordReset(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req schema.PasswordResetRequest
+		_, err := common.ParseBodyThenValidate(ctx, &req)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid request body", err)
+		}
+
+		err = authService.ResetPassword(ctx.Context(), req.Email)
+		if err != nil {
+			logger.Error(loggedmodule.ApiAuth, "failed to process password reset request", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
+		}
+
+		// the same response is returned whether or not the account exists
+		return ctx.JSON(fiber.Map{"message": "If an account exists for the email, a password reset link has been sent"})
+	}
+}
+
+// ConfirmPasswordReset godoc
+//
+//	@ID				ConfirmPasswordReset
+//	@Summary		Set a new password using a password reset token
+//	@Description	Set a new password using a password reset token, tokens can only be used once
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.ConfirmPasswordResetRequest	true	"Password reset confirmation"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/reset-password/confirm [post]
+func ConfirmPasswordReset(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req schema.ConfirmPasswordResetRequest
+		_, err := common.ParseBodyThenValidate(ctx, &req)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid request body", err)
+		}
+
+		if req.NewPassword != req.ConfirmPassword {
+			return problemdetail.BadRequest(ctx, "new password and confirmation do not match")
+		}
+
+		err = authService.ConfirmPasswordReset(ctx.Context(), &req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidResetToken) {
+				return problemdetail.BadRequest(ctx, "password reset token is invalid or has expired")
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to reset password", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to reset password")
+		}
+
+		return ctx.JSON(fiber.Map{"message": "Password has been reset successfully"})
+	}
+}
+
+// Logout godoc
+//
+//	@ID				Logout
+//	@Summary		End the current session
+//	@Description	Revokes the refresh token and every token rotated from the same login
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.LogoutRequest	true	"Refresh token of the session"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/logout [post]
+func Logout(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req schema.LogoutRequest
+		_, err := common.ParseBodyThenValidate(ctx, &req)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid request body", err)
+		}
+
+		err = authService.Logout(ctx.Context(), authutil.GetAuthUserID(ctx), req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidRefreshToken) {
+				return problemdetail.BadRequest(ctx, "invalid refresh token")
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to logout", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to logout")
+		}
+
+		return ctx.JSON(fiber.Map{"message": "Logged out successfully"})
+	}
+}
+
+// LogoutAll godoc
+//
+//	@ID				LogoutAll
+//	@Summary		Sign out of all sessions
+//	@Description	Revokes every refresh token of the authenticated user
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	map[string]string
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/logout-all [post]
+func LogoutAll(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err := authService.LogoutAll(ctx.Context(), authutil.GetAuthUserID(ctx))
+		if err != nil {
+			logger.Error(loggedmodule.ApiAuth, "failed to logout of all sessions", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to logout")
+		}
+
+		return ctx.JSON(fiber.Map{"message": "Logged out of all sessions successfully"})
+	}
+}
+
+// ListSessions godoc
+//
+//	@ID				ListSessions
+//	@Summary		List active sessions
+//	@Description	List the active login sessions of the authenticated user
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		200			{object}	schema.UserSessionListResponse
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/sessions [get]
+func ListSessions(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		sessions, err := authService.ListSessions(ctx.Context(), authutil.GetAuthUserID(ctx))
+		if err != nil {
+			logger.Error(loggedmodule.ApiAuth, "failed to list sessions", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
+		}
+
+		return ctx.JSON(schema.UserSessionListResponse{Sessions: sessions})
+	}
+}
+
+// RevokeSession godoc
+//
+//	@ID				RevokeSession
+//	@Summary		Revoke a session
+//	@Description	Revoke one of the active login sessions of the authenticated user
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			sessionID	path		string	true	"Session ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/sessions/{sessionID} [delete]
+func RevokeSession(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err := authService.RevokeSession(ctx.Context(), authutil.GetAuthUserID(ctx), ctx.Params("sessionID"))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(ctx, "session not found")
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to revoke session", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to revoke session")
+		}
+
+		return ctx.JSON(fiber.Map{"message": "Session revoked successfully"})
+	}
+}
+
+// VerifyEmail godoc
+//
+//	@ID				VerifyEmail
+//	@Summary		Verify an email address
+//	@Description	Confirms the email address of an account using the token from a verification link
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.VerifyEmailRequest	true	"Verification token"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/verify-email [post]
+func VerifyEmail(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req schema.VerifyEmailRequest
+		_, err := common.ParseBodyThenValidate(ctx, &req)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid request body", err)
+		}
+
+		err = authService.VerifyEmail(ctx.Context(), req.Token)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidVerificationToken) {
+				return problemdetail.BadRequest(ctx, err.Error())
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to verify email", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to verify email")
+		}
+
+		return ctx.JSON(fiber.Map{"message": "Email address verified successfully"})
+	}
+}
+
+// ResendVerificationEmail godoc
+//
+//	@ID				ResendVerificationEmail
+//	@Summary		Resend the verification email
+//	@Description	Sends a new verification link if the account exists and is not verified yet
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.ResendVerificationEmailRequest	true	"Account email"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/verify-email/resend [post]
+func ResendVerificationEmail(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req schema.ResendVerificationEmailRequest
+		_, err := common.ParseBodyThenValidate(ctx, &req)
+		if err != nil {
+			return problemdetail.ValidationErrors(ctx, "invalid request body", err)
+		}
+
+		err = authService.ResendVerificationEmail(ctx.Context(), req.Email)
+		if err != nil {
+			logger.Error(loggedmodule.ApiAuth, "failed to resend verification email", "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
+		}
+
+		// the same response is returned whether or not the account exists
+		return ctx.JSON(fiber.Map{"message": "If the account exists and is not verified, a verification link has been sent"})
+	}
+}
@@ -0,0 +1,228 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// ListCustomFields godoc
+//
+//	@ID				ListCustomFields
+//	@Summary		List the custom fields of a project
+//	@Description	List the custom fields defined on the test cases and test runs of a project
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int		true	"Project ID"
+//	@Param			entity		query		string	false	"Only fields of test_case or test_run"
+//	@Success		200			{object}	schema.CustomFieldListResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/custom-fields [get]
+func ListCustomFields(customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+
+		fields, err := customFieldService.FindAllByProjectID(c.UserContext(), projectID, c.Query("entity"))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiCustomFields, "failed to list custom fields", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list custom fields")
+		}
+		return c.JSON(schema.NewCustomFieldListResponse(fields))
+	}
+}
+
+// CreateCustomField godoc
+//
+//	@ID				CreateCustomField
+//	@Summary		Define a custom field on a project
+//	@Description	Define a typed custom field (text, number, enum, multi_select, date or user) on the test cases or test runs of a project
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int								true	"Project ID"
+//	@Param			request		body		schema.CreateCustomFieldRequest	true	"Custom field"
+//	@Success		201			{object}	schema.CustomFieldResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/custom-fields [post]
+func CreateCustomField(customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.CreateCustomFieldRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+
+		field, err := customFieldService.Create(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrInvalidCustomField) || errors.Is(err, services.ErrCustomFieldExists) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiCustomFields, "failed to create custom field", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to create custom field")
+		}
+		return c.Status(fiber.StatusCreated).JSON(schema.NewCustomFieldResponse(field))
+	}
+}
+
+// UpdateCustomField godoc
+//
+//	@ID				UpdateCustomField
+//	@Summary		Update a custom field of a project
+//	@Description	Update the label, options, required flag, default and position of a custom field
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int								true	"Project ID"
+//	@Param			fieldID		path		int								true	"Custom field ID"
+//	@Param			request		body		schema.UpdateCustomFieldRequest	true	"Custom field"
+//	@Success		200			{object}	schema.CustomFieldResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/custom-fields/{fieldID} [post]
+func UpdateCustomField(customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		fieldID, err := common.ParseIDFromCtx(c, "fieldID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for fieldID")
+		}
+		request := new(schema.UpdateCustomFieldRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+		request.FieldID = fieldID
+
+		field, err := customFieldService.Update(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "custom field not found")
+			}
+			if errors.Is(err, services.ErrInvalidCustomField) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiCustomFields, "failed to update custom field", "fieldID", fieldID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to update custom field")
+		}
+		return c.JSON(schema.NewCustomFieldResponse(field))
+	}
+}
+
+// DeleteCustomField godoc
+//
+//	@ID				DeleteCustomField
+//	@Summary		Delete a custom field of a project
+//	@Description	Delete a custom field with the values stored for it
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Param			fieldID		path		int	true	"Custom field ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/custom-fields/{fieldID} [delete]
+func DeleteCustomField(customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		fieldID, err := common.ParseIDFromCtx(c, "fieldID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for fieldID")
+		}
+
+		if err := customFieldService.Delete(c.UserContext(), projectID, fieldID); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "custom field not found")
+			}
+			logger.Error(loggedmodule.ApiCustomFields, "failed to delete custom field", "fieldID", fieldID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to delete custom field")
+		}
+		return c.JSON(fiber.Map{
+			"message": "Custom field deleted successfully",
+		})
+	}
+}
+
+// GetTestRunCustomFields godoc
+//
+//	@ID				GetTestRunCustomFields
+//	@Summary		Get the custom field values of a test run
+//	@Description	Get the custom field values recorded on a test run
+//	@Tags			custom-fields
+//	@Accept			json
+//	@Produce		json
+//	@Param			testRunID	path		string	true	"Test Run ID"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-runs/{testRunID}/custom-fields [get]
+func GetTestRunCustomFields(testRunService services.TestRunService, customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testRun, err := testRunService.GetOneTestRun(c.UserContext(), c.Params("testRunID"))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test run not found")
+			}
+			logger.Error(loggedmodule.ApiCustomFields, "failed to get test run", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to fetch custom field values")
+		}
+		values, err := customFieldService.FindValues(c.UserContext(), testRun.ID)
+		if err != nil {
+			logger.Error(loggedmodule.ApiCustomFields, "failed to fetch custom field values", "testRunID", testRun.ID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to fetch custom field values")
+		}
+		customFields := values[testRun.ID]
+		if customFields == nil {
+			customFields = map[string]any{}
+		}
+		return c.JSON(fiber.Map{
+			"custom_fields": customFields,
+		})
+	}
+}
+
+// customFieldErrors reports whether err holds invalid custom field values
+func customFieldErrors(err error) (validation.CustomFieldErrors, bool) {
+	var errs validation.CustomFieldErrors
+	ok := errors.As(err, &errs)
+	return errs, ok
+}
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/common"
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
 	"github.com/golang-malawi/qatarina/internal/services"
@@ -22,7 +25,7 @@ import (
 //		@Router			/v1/dashboard/summary [get]
 func DashboardSummary(dashboardService services.DashboardService, logger logging.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		summary, err := dashboardService.GetDashboardSummary(ctx.Context())
+		summary, err := dashboardService.GetDashboardSummary(ctx.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiDashboard, "failed to retrieve dashboard", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failed to retrieve the dashboard")
@@ -31,3 +34,36 @@ func DashboardSummary(dashboardService services.DashboardService, logger logging
 		return ctx.JSON(summary)
 	}
 }
+
+// ProjectRollup godoc
+//
+//	@ID				ProjectRollup
+//	@Summary		Get the roll-up dashboard of a Project
+//	@Description	Returns the dashboard metrics aggregated over a project and all of its sub-projects
+//	@Tags			dashboard
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{object}	schema.ProjectRollupResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/rollup [get]
+func ProjectRollup(dashboardService services.DashboardService, logger logging.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(ctx, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(ctx, "invalid parameter for projectID")
+		}
+		rollup, err := dashboardService.GetProjectRollup(ctx.UserContext(), projectID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(ctx, "project not found")
+			}
+			logger.Error(loggedmodule.ApiDashboard, "failed to retrieve project rollup", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(ctx, "failed to retrieve the project rollup")
+		}
+
+		return ctx.JSON(rollup)
+	}
+}
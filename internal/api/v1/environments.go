@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-malawi/qatarina/internal/common"
 	"github.com/golang-malawi/qatarina/internal/logging"
@@ -30,8 +32,11 @@ func ListEnvironments(environmentService services.EnvironmentService, logger log
 			return problemdetail.BadRequest(c, "invalid parameter for ID")
 		}
 
-		envs, err := environmentService.FindByProjectID(c.Context(), int64(projectID))
+		envs, err := environmentService.FindByProjectID(c.UserContext(), int64(projectID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "environment not found")
+			}
 			logger.Error(loggedmodule.ApiProjects, "failed to list environments for project", "error", err, "projectID", projectID)
 			return problemdetail.ServerErrorProblem(c, "failed to list environments for project")
 		}
@@ -60,8 +65,11 @@ func GetEnvironment(environmentService services.EnvironmentService, logger loggi
 			return problemdetail.BadRequest(c, "invalid parameter for ID")
 		}
 
-		env, err := environmentService.FindByID(c.Context(), int64(envID))
+		env, err := environmentService.FindByID(c.UserContext(), int64(envID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "environment not found")
+			}
 			logger.Error(loggedmodule.ApiEnvironments, "failed to get environment", "error", err, "envID", envID)
 			return problemdetail.ServerErrorProblem(c, "failed to get environment")
 		}
@@ -101,8 +109,11 @@ func CreateEnvironment(environmentService services.EnvironmentService, logger lo
 			return problemdetail.BadRequest(c, "failed to parse data in request")
 		}
 
-		env, err := environmentService.Create(c.Context(), int64(projectID), request)
+		env, err := environmentService.Create(c.UserContext(), int64(projectID), request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "environment not found")
+			}
 			logger.Error(loggedmodule.ApiEnvironments, "failed to create environment", "error", err, "projectID", projectID)
 			return problemdetail.ServerErrorProblem(c, "failed to create environment")
 		}
@@ -135,8 +146,11 @@ func UpdateEnvironment(environmentService services.EnvironmentService, logger lo
 		request.ProjectID = int64(projectID)
 		request.ID = int64(environmentID)
 
-		env, err := environmentService.Update(c.Context(), request)
+		env, err := environmentService.Update(c.UserContext(), request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "environment not found")
+			}
 			logger.Error(loggedmodule.ApiEnvironments, "failed to update environment", "error", err, "projectID", projectID)
 			return problemdetail.ServerErrorProblem(c, "failed to update environment")
 		}
@@ -155,8 +169,11 @@ func DeleteEnvironment(environmentService services.EnvironmentService, logger lo
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for environment ID")
 		}
-		err = environmentService.Delete(c.Context(), int64(projectID), int64(environmentID))
+		err = environmentService.Delete(c.UserContext(), int64(projectID), int64(environmentID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "environment not found")
+			}
 			logger.Error(loggedmodule.ApiEnvironments, "failed to delete environment", "error", err, "projectID", projectID)
 			return problemdetail.ServerErrorProblem(c, "failed to delete environment")
 		}
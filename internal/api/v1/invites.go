@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// inviteProblem maps invitation errors to problem details
+func inviteProblem(c *fiber.Ctx, logger logging.Logger, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidInviteRole),
+		errors.Is(err, services.ErrInviteTargetRequired),
+		errors.Is(err, services.ErrInviteNotPending),
+		errors.Is(err, services.ErrInviteNameRequired):
+		return problemdetail.BadRequest(c, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return problemdetail.Forbidden(c, "not allowed to invite people to the org or project")
+	case errors.Is(err, services.ErrNotFound):
+		return problemdetail.NotFound(c, "invite not found")
+	}
+	logger.Error(loggedmodule.ApiInvites, message, "error", err)
+	return problemdetail.ServerErrorProblem(c, message)
+}
+
+// createInvite creates the invitation for the request and sends it
+func createInvite(c *fiber.Ctx, inviteService services.InviteService, logger logging.Logger, request *schema.CreateInviteRequest) error {
+	invite, err := inviteService.Create(c.UserContext(), authutil.GetAuthUserID(c), request)
+	if err != nil {
+		return inviteProblem(c, logger, err, "failed to send invite")
+	}
+	return c.JSON(invite)
+}
+
+// CreateInvite godoc
+//
+//	@ID				CreateInvite
+//	@Summary		Invite someone by email
+//	@Description	Emails an invitation to join an org or, when a project is given, to test the project with the given role.
+//	@Description	Without either the invitation is for the org of the authenticated user
+//	@Tags			invites
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.CreateInviteRequest	true	"Invite data"
+//	@Success		200		{object}	schema.Invite
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites [post]
+func CreateInvite(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.CreateInviteRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		return createInvite(c, inviteService, logger, &request)
+	}
+}
+
+// ListInvites godoc
+//
+//	@ID				ListInvites
+//	@Summary		List sent invitations
+//	@Description	Lists the invitations sent by the authenticated user
+//	@Tags			invites
+//	@Produce		json
+//	@Success		200	{object}	schema.InviteListResponse
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites [get]
+func ListInvites(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		invites, err := inviteService.ListSentBy(c.UserContext(), authutil.GetAuthUserID(c))
+		if err != nil {
+			return inviteProblem(c, logger, err, "failed to list invites")
+		}
+		return c.JSON(invites)
+	}
+}
+
+// ListProjectInvites godoc
+//
+//	@ID				ListProjectInvites
+//	@Summary		List invitations to a project
+//	@Description	Lists the invitations to test a project
+//	@Tags			projects
+//	@Produce		json
+//	@Param			projectID	path		string	true	"Project ID"
+//	@Success		200			{object}	schema.InviteListResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/invites [get]
+func ListProjectInvites(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+
+		invites, err := inviteService.ListByProject(c.UserContext(), int64(projectID))
+		if err != nil {
+			return inviteProblem(c, logger, err, "failed to list project invites")
+		}
+		return c.JSON(invites)
+	}
+}
+
+// RevokeInvite godoc
+//
+//	@ID				RevokeInvite
+//	@Summary		Revoke an invitation
+//	@Description	Revokes a pending invitation so its link can no longer be used
+//	@Tags			invites
+//	@Produce		json
+//	@Param			inviteID	path		string	true	"Invite ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites/{inviteID} [delete]
+func RevokeInvite(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		inviteID, err := c.ParamsInt("inviteID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+
+		err = inviteService.Revoke(c.UserContext(), authutil.GetAuthUserID(c), int64(inviteID))
+		if err != nil {
+			return inviteProblem(c, logger, err, "failed to revoke invite")
+		}
+		return c.JSON(fiber.Map{"message": "Invite revoked"})
+	}
+}
+
+// ResendInvite godoc
+//
+//	@ID				ResendInvite
+//	@Summary		Resend an invitation
+//	@Description	Emails a new link for a pending or expired invitation, the previous link stops working
+//	@Tags			invites
+//	@Produce		json
+//	@Param			inviteID	path		string	true	"Invite ID"
+//	@Success		200			{object}	schema.Invite
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites/{inviteID}/resend [post]
+func ResendInvite(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		inviteID, err := c.ParamsInt("inviteID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+
+		invite, err := inviteService.Resend(c.UserContext(), authutil.GetAuthUserID(c), int64(inviteID))
+		if err != nil {
+			return inviteProblem(c, logger, err, "failed to resend invite")
+		}
+		return c.JSON(invite)
+	}
+}
+
+// LookupInvite godoc
+//
+//	@ID				LookupInvite
+//	@Summary		Describe an invitation
+//	@Description	Shows who sent the invitation of a link and what it is for, and whether an account already exists for the invited email
+//	@Tags			invites
+//	@Produce		json
+//	@Param			token	query		string	true	"Token from the invitation link"
+//	@Success		200		{object}	schema.InvitePreview
+//	@Failure		400		{object}	problemdetail.ProblemDetail	"Invalid or expired invitation"
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites/lookup [get]
+func LookupInvite(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" {
+			return problemdetail.BadRequest(c, "token is required")
+		}
+
+		preview, err := inviteService.Lookup(c.UserContext(), token)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidInvite) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			return inviteProblem(c, logger, err, "failed to look up invite")
+		}
+		return c.JSON(preview)
+	}
+}
+
+// AcceptInvite godoc
+//
+//	@ID				AcceptInvite
+//	@Summary		Accept an invitation
+//	@Description	Joins the invited user to the org or project of the invitation. An account is created with the given names and password when none exists for the invited email,
+//	@Description	otherwise the password of the existing account is required. The response includes a login, which asks for a second factor when the account uses one
+//	@Tags			invites
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.AcceptInviteRequest	true	"Token and account details"
+//	@Success		200		{object}	schema.AcceptInviteResponse
+//	@Failure		400		{object}	problemdetail.ProblemDetail	"Invalid or expired invitation"
+//	@Failure		401		{object}	problemdetail.ProblemDetail	"Wrong password for the existing account"
+//	@Failure		429		{object}	problemdetail.ProblemDetail	"Too many failed attempts"
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/invites/accept [post]
+func AcceptInvite(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.AcceptInviteRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.UserAgent = c.Get(fiber.HeaderUserAgent)
+		request.IPAddress = c.IP()
+
+		res, err := inviteService.Accept(c.UserContext(), &request)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidInvite):
+				return problemdetail.BadRequest(c, err.Error())
+			case errors.Is(err, services.ErrInvalidCredentials):
+				return problemdetail.NotAuthorizedProblem(c, err.Error())
+			case errors.Is(err, services.ErrLoginLocked):
+				return problemdetail.TooManyRequests(c, err.Error())
+			}
+			return inviteProblem(c, logger, err, "failed to accept invite")
+		}
+		return c.JSON(res)
+	}
+}
@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"context"
 	"database/sql"
 	"errors"
 	"strconv"
@@ -39,8 +38,11 @@ func CreateModule(module services.ModuleService, logger logging.Logger) fiber.Ha
 			return problemdetail.BadRequest(c, "failed to parse data in request")
 		}
 
-		_, err := module.Create(request)
+		_, err := module.Create(c.UserContext(), request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
 			logger.Error("api-modules", "failed to process request", "error", err)
 			return problemdetail.BadRequest(c, "failed to process equest")
 		}
@@ -71,11 +73,10 @@ func GetOneModule(module services.ModuleService, logger logging.Logger) fiber.Ha
 			return problemdetail.BadRequest(c, "failed to parse id data in request")
 		}
 
-		module, err := module.GetOne(context.Background(), int32(moduleID))
+		module, err := module.GetOne(c.UserContext(), int32(moduleID))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				logger.Error("v1-modules", "module not found", "error", err)
-				return problemdetail.BadRequest(c, "failed to find module in request")
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "module not found")
 			}
 
 			logger.Error("v1-modules", "failed retrieve request data", "error", err)
@@ -100,7 +101,7 @@ func GetOneModule(module services.ModuleService, logger logging.Logger) fiber.Ha
 //	@Router			/v1/modules [get]
 func GetAllModules(moduleService services.ModuleService, logger logging.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		modules, err := moduleService.GetAll(context.Background())
+		modules, err := moduleService.GetAll(ctx.UserContext())
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return problemdetail.NotFound(ctx, "no modules found for this project")
@@ -145,8 +146,11 @@ func UpdateModule(module services.ModuleService, logger logging.Logger) fiber.Ha
 			return problemdetail.BadRequest(c, "failed to parse data in request")
 		}
 
-		_, err = module.Update(context.Background(), *request)
+		_, err = module.Update(c.UserContext(), *request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "module not found")
+			}
 			logger.Error("api-modules", "failed to process request", "error", err)
 			return problemdetail.BadRequest(c, "failed to process equest")
 		}
@@ -179,9 +183,9 @@ func DeleteModule(module services.ModuleService, logger logging.Logger) fiber.Ha
 			return problemdetail.BadRequest(c, "failed to parse projectID data in request")
 		}
 
-		err = module.Delete(context.Background(), int32(moduleID))
+		err = module.Delete(c.UserContext(), int32(moduleID))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, services.ErrNotFound) {
 				return problemdetail.NotFound(c, "module not found")
 			}
 			logger.Error(loggedmodule.ApiModules, "failed delete module", "error", err)
@@ -214,7 +218,7 @@ func GetProjectModules(modules services.ModuleService, logger logging.Logger) fi
 			return problemdetail.BadRequest(c, "failed to parse projectID data in request")
 		}
 
-		modules, err := modules.GetProjectModules(context.Background(), int32(projectID))
+		modules, err := modules.GetProjectModules(c.UserContext(), int32(projectID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Error(loggedmodule.ApiModules, "modules not found", "error", err)
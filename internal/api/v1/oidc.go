@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// oidcLoginStateCookie holds the signed login state between the redirect to
+// the identity provider and the callback
+const oidcLoginStateCookie = "qatarina_oidc_state"
+
+// OIDCLogin godoc
+//
+//	@ID				OIDCLogin
+//	@Summary		Start single sign-on login
+//	@Description	Redirects the browser to the OpenID Connect identity provider
+//	@Tags			auth
+//	@Success		302
+//	@Failure		404	{object}	problemdetail.ProblemDetail	"Single sign-on is not enabled"
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/oidc/login [get]
+func OIDCLogin(oidcService services.OIDCService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authURL, loginState, err := oidcService.BeginLogin(c.Context())
+		if err != nil {
+			if errors.Is(err, services.ErrSSODisabled) {
+				return problemdetail.NotFound(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to start single sign-on login", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     oidcLoginStateCookie,
+			Value:    loginState,
+			Path:     "/v1/auth/oidc",
+			Expires:  time.Now().Add(10 * time.Minute),
+			Secure:   c.Protocol() == "https",
+			HTTPOnly: true,
+			// the callback is a top-level navigation from the identity provider so Strict would drop the cookie
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+		return c.Redirect(authURL, fiber.StatusFound)
+	}
+}
+
+// OIDCCallback godoc
+//
+//	@ID				OIDCCallback
+//	@Summary		Complete single sign-on login
+//	@Description	Handles the redirect back from the OpenID Connect identity provider and signs in the user.
+//	@Description	Redirects to the configured post login URL with the tokens in the fragment, or returns them as JSON when none is configured.
+//	@Description	When a second factor is needed the fragment holds the challenge token to complete the login with instead
+//	@Tags			auth
+//	@Produce		json
+//	@Param			code				query		string	false	"Authorization code"
+//	@Param			state				query		string	true	"State sent with the authorization request"
+//	@Param			error				query		string	false	"Error returned by the identity provider"
+//	@Param			error_description	query		string	false	"Description of the error"
+//	@Success		200					{object}	schema.LoginResponse
+//	@Success		302
+//	@Failure		401	{object}	problemdetail.ProblemDetail
+//	@Failure		403	{object}	problemdetail.ProblemDetail	"Email address has not been verified"
+//	@Failure		404	{object}	problemdetail.ProblemDetail	"Single sign-on is not enabled"
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/oidc/callback [get]
+func OIDCCallback(oidcService services.OIDCService, postLoginRedirectURL string, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.OIDCCallbackRequest
+		if err := c.QueryParser(&request); err != nil {
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.LoginState = c.Cookies(oidcLoginStateCookie)
+		request.UserAgent = c.Get(fiber.HeaderUserAgent)
+		request.IPAddress = c.IP()
+
+		// the login state is single use
+		c.ClearCookie(oidcLoginStateCookie)
+
+		loginData, err := oidcService.CompleteLogin(c.Context(), &request)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrSSODisabled):
+				return problemdetail.NotFound(c, err.Error())
+			case errors.Is(err, services.ErrInvalidSSOState),
+				errors.Is(err, services.ErrSSOEmailNotVerified),
+				errors.Is(err, services.ErrInvalidCredentials):
+				return problemdetail.NotAuthorizedProblem(c, err.Error())
+			case errors.Is(err, services.ErrAccountNotVerified):
+				return problemdetail.Forbidden(c, "email address has not been verified, check your email for the verification link")
+			case errors.Is(err, services.ErrSSOLoginFailed):
+				logger.Error(loggedmodule.ApiAuth, "single sign-on login failed", "error", err)
+				return problemdetail.NotAuthorizedProblem(c, services.ErrSSOLoginFailed.Error())
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to complete single sign-on login", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+
+		if postLoginRedirectURL == "" {
+			return c.JSON(loginData)
+		}
+
+		// the fragment is never sent to servers so the tokens do not end up in access logs
+		if loginData.ChallengeToken != "" {
+			fragment := url.Values{
+				"challenge_token":           {loginData.ChallengeToken},
+				"two_factor_required":       {fmt.Sprint(loginData.TwoFactorRequired)},
+				"two_factor_setup_required": {fmt.Sprint(loginData.TwoFactorSetupRequired)},
+				"user_id":                   {fmt.Sprint(loginData.UserID)},
+			}
+			return c.Redirect(strings.TrimSuffix(postLoginRedirectURL, "#")+"#"+fragment.Encode(), fiber.StatusFound)
+		}
+		fragment := url.Values{
+			"token":         {loginData.Token},
+			"refresh_token": {loginData.RefreshToken},
+			"expires_at":    {fmt.Sprint(loginData.ExpiresAt)},
+			"user_id":       {fmt.Sprint(loginData.UserID)},
+			"email":         {loginData.Email},
+			"display_name":  {loginData.DisplayName},
+		}
+		return c.Redirect(strings.TrimSuffix(postLoginRedirectURL, "#")+"#"+fragment.Encode(), fiber.StatusFound)
+	}
+}
@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// orgMemberProblem maps org membership errors to problem details
+func orgMemberProblem(c *fiber.Ctx, logger logging.Logger, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrgRole),
+		errors.Is(err, services.ErrAlreadyOrgMember),
+		errors.Is(err, services.ErrLastOrgOwner),
+		errors.Is(err, services.ErrUserNotActive):
+		return problemdetail.BadRequest(c, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return problemdetail.Forbidden(c, "not allowed to manage the members of the org")
+	case errors.Is(err, services.ErrNotFound),
+		errors.Is(err, services.ErrNotOrgMember):
+		return problemdetail.NotFound(c, err.Error())
+	}
+	logger.Error(loggedmodule.ApiOrgs, message, "error", err)
+	return problemdetail.ServerErrorProblem(c, message)
+}
+
+// ListOrgMembers godoc
+//
+//	@ID				ListOrgMembers
+//	@Summary		List the members of an organization
+//	@Description	List the members of an organization with their roles, only members of the organization can list them
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgID	path		string	true	"Organization ID"
+//	@Success		200		{object}	schema.OrgMemberListResponse
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/orgs/{orgID}/members [get]
+func ListOrgMembers(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := c.ParamsInt("orgID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		members, err := orgService.ListMembers(c.Context(), authutil.GetAuthUserID(c), int64(orgID))
+		if err != nil {
+			return orgMemberProblem(c, logger, err, "failed to list org members")
+		}
+		return c.JSON(schema.OrgMemberListResponse{Members: members})
+	}
+}
+
+// AddOrgMember godoc
+//
+//	@ID				AddOrgMember
+//	@Summary		Add a user to an organization
+//	@Description	Add an existing user to an organization, owners and admins can add members and admins, only owners can add owners
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgID	path		string						true	"Organization ID"
+//	@Param			request	body		schema.AddOrgMemberRequest	true	"User and role"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/orgs/{orgID}/members [post]
+func AddOrgMember(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := c.ParamsInt("orgID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		var request schema.AddOrgMemberRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		err = orgService.AddMember(c.Context(), authutil.GetAuthUserID(c), int64(orgID), &request)
+		if err != nil {
+			return orgMemberProblem(c, logger, err, "failed to add org member")
+		}
+		return c.JSON(fiber.Map{"message": "Member added successfully"})
+	}
+}
+
+// UpdateOrgMember godoc
+//
+//	@ID				UpdateOrgMember
+//	@Summary		Change the role of an organization member
+//	@Description	Change the role of a member, only owners can make someone an owner or change the role of an owner. The last owner cannot be demoted
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgID	path		string							true	"Organization ID"
+//	@Param			userID	path		string							true	"User ID"
+//	@Param			request	body		schema.UpdateOrgMemberRequest	true	"New role"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/orgs/{orgID}/members/{userID} [put]
+func UpdateOrgMember(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := c.ParamsInt("orgID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		var request schema.UpdateOrgMemberRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		err = orgService.UpdateMemberRole(c.Context(), authutil.GetAuthUserID(c), int64(orgID), int64(userID), request.Role)
+		if err != nil {
+			return orgMemberProblem(c, logger, err, "failed to update org member")
+		}
+		return c.JSON(fiber.Map{"message": "Member updated successfully"})
+	}
+}
+
+// RemoveOrgMember godoc
+//
+//	@ID				RemoveOrgMember
+//	@Summary		Remove a member from an organization
+//	@Description	Remove a member from an organization, members can remove themselves to leave it. The last owner cannot be removed
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgID	path		string	true	"Organization ID"
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/orgs/{orgID}/members/{userID} [delete]
+func RemoveOrgMember(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := c.ParamsInt("orgID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+
+		err = orgService.RemoveMember(c.Context(), authutil.GetAuthUserID(c), int64(orgID), int64(userID))
+		if err != nil {
+			return orgMemberProblem(c, logger, err, "failed to remove org member")
+		}
+		return c.JSON(fiber.Map{"message": "Member removed successfully"})
+	}
+}
+
+// TransferOrgOwnership godoc
+//
+//	@ID				TransferOrgOwnership
+//	@Summary		Transfer ownership of an organization
+//	@Description	Make another member an owner of the organization, the owner transferring it becomes an admin
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgID	path		string								true	"Organization ID"
+//	@Param			request	body		schema.TransferOrgOwnershipRequest	true	"New owner"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/orgs/{orgID}/transfer-ownership [post]
+func TransferOrgOwnership(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, err := c.ParamsInt("orgID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to parse id data in request")
+		}
+		var request schema.TransferOrgOwnershipRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		err = orgService.TransferOwnership(c.Context(), authutil.GetAuthUserID(c), int64(orgID), request.UserID)
+		if err != nil {
+			return orgMemberProblem(c, logger, err, "failed to transfer org ownership")
+		}
+		return c.JSON(fiber.Map{"message": "Ownership transferred successfully"})
+	}
+}
+
+// ListMyOrgs godoc
+//
+//	@ID				ListMyOrgs
+//	@Summary		List the organizations of the current user
+//	@Description	List the organizations the current user belongs to, the one the session works in is marked as current
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	schema.UserOrgListResponse
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/me/orgs [get]
+func ListMyOrgs(orgService services.OrgService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgs, err := orgService.ListUserOrgs(c.Context(), authutil.GetAuthUserID(c), authutil.GetAuthOrgID(c))
+		if err != nil {
+			logger.Error(loggedmodule.ApiOrgs, "failed to list orgs of user", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+		return c.JSON(schema.UserOrgListResponse{Orgs: orgs})
+	}
+}
+
+// SwitchOrg godoc
+//
+//	@ID				SwitchOrg
+//	@Summary		Switch the organization of the session
+//	@Description	Exchange the refresh token of the session for tokens carrying another organization the user belongs to, the organization also becomes the default for new logins
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.SwitchOrgRequest	true	"Organization and refresh token of the session"
+//	@Success		200		{object}	schema.RefreshTokenResponse
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		401		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/switch-org [post]
+func SwitchOrg(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.SwitchOrgRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.UserAgent = c.Get(fiber.HeaderUserAgent)
+		request.IPAddress = c.IP()
+
+		tokens, err := authService.SwitchOrg(c.Context(), authutil.GetAuthUserID(c), &request)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidRefreshToken), errors.Is(err, services.ErrRefreshTokenReused):
+				return problemdetail.NotAuthorizedProblem(c, err.Error())
+			case errors.Is(err, services.ErrNotFound), errors.Is(err, services.ErrNotOrgMember):
+				return problemdetail.NotFound(c, "org not found")
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to switch org", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+		return c.JSON(tokens)
+	}
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-malawi/qatarina/internal/api/authutil"
@@ -53,7 +54,7 @@ func CreateOrg(orgService services.OrgService, logger logging.Logger) fiber.Hand
 //
 //	@ID				ListOrgs
 //	@Summary		List All organizations
-//	@Description	List All organizations
+//	@Description	List the organizations the user belongs to, super admins see all organizations
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
@@ -63,7 +64,7 @@ func CreateOrg(orgService services.OrgService, logger logging.Logger) fiber.Hand
 //	@Router			/v1/orgs [get]
 func ListOrgs(orgService services.OrgService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		orgs, err := orgService.ListAll(context.Background())
+		orgs, err := orgService.ListAll(context.Background(), authutil.GetAuthUserID(c))
 		if err != nil {
 			logger.Error(loggedmodule.ApiOrgs, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -86,6 +87,7 @@ func ListOrgs(orgService services.OrgService, logger logging.Logger) fiber.Handl
 //	@Param			orgID	path string	true	"Organization ID"
 //	@Success		200			{object}	schema.Org
 //	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
 //	@Router			/v1/orgs/{orgID} [get]
 func GetOrg(orgService services.OrgService, logger logging.Logger) fiber.Handler {
@@ -94,8 +96,11 @@ func GetOrg(orgService services.OrgService, logger logging.Logger) fiber.Handler
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse id data in request")
 		}
-		org, err := orgService.GetOne(context.Background(), int64(id))
+		org, err := orgService.GetOne(context.Background(), authutil.GetAuthUserID(c), int64(id))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "organization not found")
+			}
 			logger.Error(loggedmodule.ApiOrgs, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
 		}
@@ -115,6 +120,8 @@ func GetOrg(orgService services.OrgService, logger logging.Logger) fiber.Handler
 //	@Param			request	body		schema.UpdateOrgRequest	true	"Organization data"
 //	@Success		200			{object}	map[string]string
 //	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		403			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
 //	@Router			/v1/orgs/{orgID} [put]
 func UpdateOrg(orgService services.OrgService, logger logging.Logger) fiber.Handler {
@@ -133,8 +140,14 @@ func UpdateOrg(orgService services.OrgService, logger logging.Logger) fiber.Hand
 		}
 
 		req.ID = int32(id)
-		err = orgService.Update(context.Background(), req)
+		err = orgService.Update(context.Background(), authutil.GetAuthUserID(c), req)
 		if err != nil {
+			if errors.Is(err, services.ErrForbidden) {
+				return problemdetail.Forbidden(c, "only owners and admins of the organization can update it")
+			}
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "organization not found")
+			}
 			logger.Error(loggedmodule.ApiOrgs, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
 		}
@@ -155,6 +168,8 @@ func UpdateOrg(orgService services.OrgService, logger logging.Logger) fiber.Hand
 //	@Param			orgID	path		string	true	"Organization ID"
 //	@Success		200			{object}	map[string]string
 //	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		403			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
 //	@Router			/v1/orgs/{orgID} [delete]
 func DeleteOrg(orgService services.OrgService, logger logging.Logger) fiber.Handler {
@@ -163,8 +178,14 @@ func DeleteOrg(orgService services.OrgService, logger logging.Logger) fiber.Hand
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse id data in request")
 		}
-		err = orgService.DeleteOrg(context.Background(), int64(id))
+		err = orgService.DeleteOrg(context.Background(), authutil.GetAuthUserID(c), int64(id))
 		if err != nil {
+			if errors.Is(err, services.ErrForbidden) {
+				return problemdetail.Forbidden(c, "only owners of the organization can delete it")
+			}
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "organization not found")
+			}
 			logger.Error(loggedmodule.ApiOrgs, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
 		}
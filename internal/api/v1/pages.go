@@ -1,8 +1,6 @@
 package v1
 
 import (
-	"context"
-	"database/sql"
 	"errors"
 	"strconv"
 
@@ -43,8 +41,14 @@ func CreatePage(page services.PageService, logger logging.Logger) fiber.Handler
 		request.LastEditedBy = int32(authutil.GetAuthUserID(c))
 		request.CreatedBy = int32(authutil.GetAuthUserID(c))
 
-		_, err := page.Create(context.Background(), request)
+		_, err := page.Create(c.UserContext(), request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrNoOrg) {
+				return problemdetail.Forbidden(c, err.Error())
+			}
 			logger.Error(loggedmodule.ApiPages, "failed to process request", "error", err)
 			return problemdetail.BadRequest(c, "failed to process request")
 		}
@@ -75,9 +79,9 @@ func GetOnePage(pageService services.PageService, logger logging.Logger) fiber.H
 			return problemdetail.BadRequest(c, "failed to parse id data in request")
 		}
 
-		page, err := pageService.GetOnePage(context.Background(), int32(pageID))
+		page, err := pageService.GetOnePage(c.UserContext(), int32(pageID))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, services.ErrNotFound) {
 				logger.Info(loggedmodule.ApiPages, "page not found", "error", err)
 				return problemdetail.NotFound(c, "page not found")
 			}
@@ -103,7 +107,7 @@ func GetOnePage(pageService services.PageService, logger logging.Logger) fiber.H
 //	@Router			/v1/pages [get]
 func GetAllPages(pagesService services.PageService, logger logging.Logger) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		pages, err := pagesService.GetAllPages(ctx.Context())
+		pages, err := pagesService.GetAllPages(ctx.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiPages, "failed to get all pages", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failled to process request")
@@ -141,8 +145,11 @@ func UpdatePage(pageService services.PageService, logger logging.Logger) fiber.H
 
 		request.LastEditedBy = int32(authutil.GetAuthUserID(ctx))
 		request.CreatedBy = int32(authutil.GetAuthUserID(ctx))
-		_, err := pageService.UpdatePage(ctx.Context(), *request)
+		_, err := pageService.UpdatePage(ctx.UserContext(), *request)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(ctx, "page not found")
+			}
 			logger.Error(loggedmodule.ApiPages, "failed to process request", "error", err)
 			return problemdetail.BadRequest(ctx, "failed to process request")
 		}
@@ -174,8 +181,11 @@ func DeletePage(pageService services.PageService, logger logging.Logger) fiber.H
 			return problemdetail.BadRequest(ctx, "failed to parse pageID data in request")
 		}
 
-		err = pageService.DeletePage(ctx.Context(), int32(pageID))
+		err = pageService.DeletePage(ctx.UserContext(), int32(pageID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(ctx, "page not found")
+			}
 			logger.Error(loggedmodule.ApiPages, "failed to delete page", "error", err)
 			return problemdetail.BadRequest(ctx, "failed to delete page")
 		}
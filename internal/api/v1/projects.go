@@ -2,9 +2,10 @@
 package v1
 
 import (
-	"context"
+	"bytes"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
 	"github.com/golang-malawi/qatarina/internal/schema"
 	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/internal/validation"
 	"github.com/golang-malawi/qatarina/pkg/problemdetail"
 )
 
@@ -35,7 +37,7 @@ import (
 //	@Router			/v1/projects [get]
 func ListProjects(projectService services.ProjectService) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		projects, err := projectService.FindAll(context.Background())
+		projects, err := projectService.FindAll(ctx.UserContext())
 		if err != nil {
 			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
 		}
@@ -64,7 +66,7 @@ func SearchProjects(projectService services.ProjectService, logger logging.Logge
 			return problemdetail.BadRequest(q, "missing keyword parameter")
 		}
 
-		projects, err := projectService.Search(q.Context(), keyword)
+		projects, err := projectService.Search(q.UserContext(), keyword)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Error("project not found", "error", err)
@@ -97,7 +99,7 @@ func GetOneProject(projectService services.ProjectService) fiber.Handler {
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse id from path")
 		}
-		project, err := projectService.FindByID(context.Background(), int64(projectID))
+		project, err := projectService.FindByID(c.UserContext(), int64(projectID))
 		if err != nil {
 			// TODO: logging
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -155,7 +157,7 @@ func GetProjectTestCases(testCaseService services.TestCaseService, logger loggin
 			isDraft = &val
 		}
 
-		testCases, total, err := testCaseService.FindAllByProjectIDPaged(context.Background(), projectID, services.TestCaseQueryParams{
+		testCases, total, err := testCaseService.FindAllByProjectIDPaged(c.UserContext(), projectID, services.TestCaseQueryParams{
 			Page:      page,
 			PageSize:  pageSize,
 			SortBy:    sortBy,
@@ -199,7 +201,7 @@ func GetProjectTestPlans(testPlanService services.TestPlanService, logger loggin
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
-		testPlans, err := testPlanService.FindAllByProjectID(context.Background(), projectID)
+		testPlans, err := testPlanService.FindAllByProjectID(c.UserContext(), projectID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to fetch test plans for project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -230,7 +232,7 @@ func GetProjectTestRuns(testRunService services.TestRunService, logger logging.L
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
-		testPlans, err := testRunService.FindAllByProjectID(context.Background(), projectID)
+		testPlans, err := testRunService.FindAllByProjectID(c.UserContext(), projectID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to fetch test cases for project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -265,7 +267,7 @@ func CreateProject(projectService services.ProjectService, testPlanService servi
 
 		request.ProjectOwnerID = authutil.GetAuthUserID(ctx)
 
-		project, err := projectService.Create(context.Background(), &request)
+		project, err := projectService.Create(ctx.UserContext(), &request)
 		if err != nil {
 			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
 		}
@@ -285,7 +287,7 @@ func CreateProject(projectService services.ProjectService, testPlanService servi
 				PlannedTests:   []schema.TestCaseAssignment{},
 			}
 
-			_, err := testPlanService.Create(context.Background(), newDefaultTestPlan)
+			_, err := testPlanService.Create(ctx.UserContext(), newDefaultTestPlan)
 			if err != nil {
 				logger.Error(loggedmodule.ApiProjects, "failed to create a default test plan for project", "projectID", project.ID, "error", err)
 			}
@@ -322,8 +324,11 @@ func UpdateProject(projectService services.ProjectService, logger logging.Logger
 			return problemdetail.BadRequest(c, "failed to parse data in request")
 		}
 
-		_, err := projectService.Update(c.Context(), *request)
+		_, err := projectService.Update(c.UserContext(), *request)
 		if err != nil {
+			if errors.Is(err, services.ErrProjectCycle) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			logger.Error("projectsv1", "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
 		}
@@ -338,19 +343,85 @@ func UpdateProject(projectService services.ProjectService, logger logging.Logger
 // ImportProject godoc
 //
 //	@ID				ImportProject
-//	@Summary		Import Projects from some source
-//	@Description	Import Projects from some source
+//	@Summary		Import a Project from a bundle
+//	@Description	Create a project in the current org from a bundle exported by this or another instance, users are matched by email among the members of the org. Only owners and admins of the org can import. With dry_run set only the conflict report is returned
 //	@Tags			projects
-//	@Accept			json
+//	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			request	body		schema.ImportProjectRequest	true	"Import Specification"
-//	@Success		200		{object}	schema.ProjectListResponse
+//	@Param			file	formData	file	true	"Project bundle"
+//	@Param			name	formData	string	false	"Title of the imported project"
+//	@Param			code	formData	string	false	"Code of the imported project"
+//	@Param			dry_run	formData	bool	false	"Only report what would be imported"
+//	@Success		200		{object}	schema.ProjectImportReport
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/projects/import [post]
-func ImportProject(projectService services.ProjectService) fiber.Handler {
+func ImportProject(bundleService services.ProjectBundleService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		opts := new(schema.ProjectImportOptions)
+		if err := c.BodyParser(opts); err != nil {
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		opts.ImportedByID = authutil.GetAuthUserID(c)
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return problemdetail.BadRequest(c, "file is required")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			logger.Error(loggedmodule.ApiProjects, "failed to open uploaded file", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to open uploaded file")
+		}
+		defer file.Close()
+
+		report, err := bundleService.Import(c.UserContext(), file, fileHeader.Size, opts)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidBundle) || errors.Is(err, services.ErrUnsupportedBundleVersion) || errors.Is(err, services.ErrInvalidSharedStep) || errors.Is(err, services.ErrInvalidDataset) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			if errors.Is(err, services.ErrNoOrg) {
+				return problemdetail.Forbidden(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to import project", "filename", fileHeader.Filename, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to import project")
+		}
+		return c.JSON(report)
+	}
+}
+
+// ExportProject godoc
+//
+//	@ID				ExportProject
+//	@Summary		Export a Project as a bundle
+//	@Description	Download a zip bundle of the project with its test cases, plans, runs, environments, comments, pages, scripts and report files for import on another instance
+//	@Tags			projects
+//	@Produce		application/zip
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/export [get]
+func ExportProject(bundleService services.ProjectBundleService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		return problemdetail.NotImplemented(c, "failed to import Project")
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+
+		var buf bytes.Buffer
+		if err := bundleService.Export(c.UserContext(), projectID, &buf); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to export project", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to export project")
+		}
+
+		c.Attachment(fmt.Sprintf("project-%d.zip", projectID))
+		return c.Send(buf.Bytes())
 	}
 }
 
@@ -375,7 +446,7 @@ func DeleteProject(projectService services.ProjectService, logger logging.Logger
 			return problemdetail.BadRequest(c, "failed to parse projectID data in request")
 		}
 
-		err = projectService.DeleteProject(c.Context(), int64(projectID))
+		err = projectService.DeleteProject(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error("projectsv1", "failed to delete project", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -406,7 +477,7 @@ func GetProjectTesters(projectService services.ProjectService, testerService ser
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid project id")
 		}
-		testers, err := testerService.FindByProjectID(context.Background(), int64(projectID))
+		testers, err := testerService.FindByProjectID(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to fetch testers for project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch testers")
@@ -444,7 +515,7 @@ func AssignTesters(testerService services.TesterService, logger logging.Logger)
 			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
 		}
 
-		if err := testerService.AssignBulk(context.Background(), projectID, &request); err != nil {
+		if err := testerService.AssignBulk(c.UserContext(), projectID, &request); err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to assign testers to project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to assign testers")
 		}
@@ -474,7 +545,7 @@ func ArchiveProject(projectService services.ProjectService, logger logging.Logge
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
-		if err := projectService.ArchiveProject(c.Context(), projectID); err != nil {
+		if err := projectService.ArchiveProject(c.UserContext(), projectID); err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to archive project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to archive project")
 		}
@@ -504,7 +575,7 @@ func UnarchiveProject(projectService services.ProjectService, logger logging.Log
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
-		if err := projectService.UnarchiveProject(c.Context(), projectID); err != nil {
+		if err := projectService.UnarchiveProject(c.UserContext(), projectID); err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to unarchive project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to unarchive project")
 		}
@@ -514,11 +585,57 @@ func UnarchiveProject(projectService services.ProjectService, logger logging.Log
 	}
 }
 
+// CloneProject godoc
+//
+//	@ID				CloneProject
+//	@Summary		Clone a Project
+//	@Description	Copy a project with its modules, test cases, environments and test case template, optionally with its testers and open test plans
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int							true	"Project ID"
+//	@Param			request		body		schema.CloneProjectRequest	true	"Clone options"
+//	@Success		201			{object}	schema.CloneProjectResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/clone [post]
+func CloneProject(projectService services.ProjectService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.CloneProjectRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectOwnerID = authutil.GetAuthUserID(c)
+
+		project, counts, err := projectService.Clone(c.UserContext(), projectID, request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to clone project", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to clone project")
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(schema.CloneProjectResponse{
+			Project: schema.NewProjectResponse(project, nil),
+			Counts:  *counts,
+		})
+	}
+}
+
 // AddProjectTestCaseTemplate godoc
 //
 //	@ID				AddProjectTestCaseTemplate
 //	@Summary	Add or update the test case template for a project
-//	@Description	Add or update the test case template for a project. This template can be used as a default structure for test cases within the project.
+//	@Description	Add or update the test case template for a project. The template lists the sections of test case descriptions, which of them are required, placeholder variables and layouts for specific test kinds. Test cases which are not drafts are checked against it.
 //	@Tags		projects
 //	@Accept			json
 //	@Produce		json
@@ -541,7 +658,10 @@ func AddProjectTestCaseTemplate(projectService services.ProjectService, logger l
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
 
-		if err := projectService.AddProjectTestCaseTemplate(c.Context(), projectID, request.TestCaseTemplate); err != nil {
+		if err := projectService.AddProjectTestCaseTemplate(c.UserContext(), projectID, request.TestCaseTemplate); err != nil {
+			if errors.Is(err, services.ErrInvalidTestCaseTemplate) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			logger.Error(loggedmodule.ApiProjects, "failed to add test case template to project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to add test case template to project")
 		}
@@ -555,11 +675,13 @@ func AddProjectTestCaseTemplate(projectService services.ProjectService, logger l
 //
 //	@ID				GetProjectTestCaseTemplate
 //	@Summary	Get the test case template for a project
-//	@Description	Get the test case template for a project. This template can be used as a default structure for test cases within the project.
+//	@Description	Get the test case template for a project with the skeleton description of a new test case rendered from it. Variables are filled in from var.{name} query parameters or their defaults.
 //	@Tags		projects
 //	@Accept			json
 //	@Produce		json
-//	@Param			projectID	path		int	true	"Project ID"
+//	@Param			projectID	path		int		true	"Project ID"
+//	@Param			kind		query		string	false	"Test kind to render the skeleton for"
+//	@Param			var.{name}	query		string	false	"Value of the template variable with the name"
 //	@Success		200			{object}	schema.ProjectTestCaseTemplateResponse
 //	@Failure		400			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
@@ -570,23 +692,40 @@ func GetProjectTestCaseTemplate(projectService services.ProjectService, logger l
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid parameter for projectID")
 		}
-		template, err := projectService.GetProjectTestCaseTemplate(c.Context(), projectID)
+		template, err := projectService.GetProjectTestCaseTemplate(c.UserContext(), projectID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to get test case template for project", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to get test case template for project")
 		}
 
+		kind := c.Query("kind", "")
 		if template == nil {
 			return c.JSON(schema.ProjectTestCaseTemplateResponse{
-				TestCaseTemplate: "",
+				Kind: kind,
 			})
 		}
+		values := map[string]string{}
+		for key, value := range c.Queries() {
+			if name, ok := strings.CutPrefix(key, "var."); ok {
+				values[name] = value
+			}
+		}
 		return c.JSON(schema.ProjectTestCaseTemplateResponse{
-			TestCaseTemplate: *template,
+			TestCaseTemplate: template,
+			Kind:             kind,
+			Skeleton:         template.Layout(kind).Render(values),
 		})
 	}
 }
 
+// templateErrors reports whether err holds sections of a description which do
+// not follow the project template
+func templateErrors(err error) (validation.TestCaseTemplateErrors, bool) {
+	var errs validation.TestCaseTemplateErrors
+	ok := errors.As(err, &errs)
+	return errs, ok
+}
+
 // UpdateAutomatedTesting godoc
 //
 //	@ID				UpdateAutomatedTesting
@@ -616,7 +755,7 @@ func UpdateAutomatedTesting(projectService services.ProjectService, logger loggi
 
 		request.ProjectID = projectID
 
-		err = projectService.UpdateAutomatedTesting(c.Context(), &request)
+		err = projectService.UpdateAutomatedTesting(c.UserContext(), &request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiProjects, "failed to update automated testing setting", "projectID", projectID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to update automated testing setting")
@@ -627,3 +766,78 @@ func UpdateAutomatedTesting(projectService services.ProjectService, logger loggi
 		})
 	}
 }
+
+// ListSubProjects godoc
+//
+//	@ID				ListSubProjects
+//	@Summary		List the sub-projects of a Project
+//	@Description	List the direct sub-projects of a Project
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{object}	schema.ProjectListResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/sub-projects [get]
+func ListSubProjects(projectService services.ProjectService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		children, err := projectService.ListChildren(c.UserContext(), projectID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to list sub-projects", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list sub-projects")
+		}
+		return c.JSON(schema.ProjectListResponse{
+			Projects: schema.NewProjectResponseList(children),
+		})
+	}
+}
+
+// SetParentProject godoc
+//
+//	@ID				SetParentProject
+//	@Summary		Move a Project under a parent Project
+//	@Description	Move a Project under another Project of the same organization, a parent_project_id of 0 makes it a top level project
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int								true	"Project ID"
+//	@Param			request		body		schema.SetParentProjectRequest	true	"Parent project"
+//	@Success		200			{object}	map[string]string
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/parent [post]
+func SetParentProject(projectService services.ProjectService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.SetParentProjectRequest)
+		if err := c.BodyParser(request); err != nil {
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		if err := projectService.SetParent(c.UserContext(), projectID, request.ParentProjectID); err != nil {
+			switch {
+			case errors.Is(err, services.ErrNotFound):
+				return problemdetail.NotFound(c, "project not found")
+			case errors.Is(err, services.ErrProjectCycle):
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to set parent project", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to set parent project")
+		}
+		return c.JSON(fiber.Map{
+			"message": "Project moved successfully",
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
@@ -29,8 +30,11 @@ func ListReports(reportService services.ReportService, logger logging.Logger) fi
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid projectID")
 		}
-		reports, err := reportService.ListByProject(c.Context(), int64(projectID))
+		reports, err := reportService.ListByProject(c.UserContext(), int64(projectID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
 			return problemdetail.ServerErrorProblem(c, "failed to fetch reports")
 		}
 		return c.JSON(schema.ReportListResponse{
@@ -55,12 +59,20 @@ func ListReports(reportService services.ReportService, logger logging.Logger) fi
 //	@Router         /v1/projects/{projectID}/reports [post]
 func CreateReport(reportService services.ReportService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid projectID")
+		}
 		req := new(schema.CreateReportRequest)
 		if err := c.BodyParser(req); err != nil {
 			return problemdetail.BadRequest(c, "invalid request body")
 		}
-		report, err := reportService.Create(c.Context(), req)
+		req.ProjectID = int64(projectID)
+		report, err := reportService.Create(c.UserContext(), req)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project or test plan not found")
+			}
 			return problemdetail.ServerErrorProblem(c, "failed to create report")
 		}
 		return c.JSON(schema.NewReportResponse(*report))
@@ -78,8 +90,15 @@ func CreateReport(reportService services.ReportService, logger logging.Logger) f
 // @Router /v1/projects/{projectID}/reports/{reportID} [delete]
 func DeleteReport(reportService services.ReportService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid projectID")
+		}
 		reportID := c.Params("reportID")
-		if err := reportService.DeleteByID(c.Context(), reportID); err != nil {
+		if err := reportService.DeleteByID(c.UserContext(), int64(projectID), reportID); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "report not found")
+			}
 			return problemdetail.ServerErrorProblem(c, "failed to delete report")
 		}
 		return c.JSON(fiber.Map{"message": "Report deleted successfully"})
@@ -97,9 +116,16 @@ func DeleteReport(reportService services.ReportService, logger logging.Logger) f
 // @Router /v1/projects/{projectID}/reports/{reportID}/download [get]
 func DownloadReport(reportService services.ReportService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid projectID")
+		}
 		reportID := c.Params("reportID")
-		report, err := reportService.GetByID(c.Context(), reportID)
+		report, err := reportService.GetByID(c.UserContext(), int64(projectID), reportID)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "report not found")
+			}
 			return problemdetail.ServerErrorProblem(c, "report not found")
 		}
 
@@ -122,9 +148,16 @@ func DownloadReport(reportService services.ReportService, logger logging.Logger)
 // @Router /v1/projects/{projectID}/reports/{reportID}/view [get]
 func ViewReport(reportService services.ReportService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid projectID")
+		}
 		reportID := c.Params("reportID")
-		report, err := reportService.GetByID(c.Context(), reportID)
+		report, err := reportService.GetByID(c.UserContext(), int64(projectID), reportID)
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "report not found")
+			}
 			return problemdetail.ServerErrorProblem(c, "report not found")
 		}
 
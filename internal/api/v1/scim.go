@@ -0,0 +1,412 @@
+// Handlers for the SCIM 2.0 provisioning endpoints, they speak SCIM rather
+// than the problem details the rest of the API responds with
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/scim"
+)
+
+func scimJSON(c *fiber.Ctx, status int, data any) error {
+	return c.Status(status).JSON(data, scim.ContentType)
+}
+
+func scimError(c *fiber.Ctx, status int, scimType, detail string) error {
+	return scimJSON(c, status, scim.NewError(status, scimType, detail))
+}
+
+// scimProblem reports the error of a SCIM operation, errors of the request
+// carry their scimType so directories can tell what to fix
+func scimProblem(c *fiber.Ctx, logger logging.Logger, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return scimError(c, fiber.StatusNotFound, "", err.Error())
+	case errors.Is(err, services.ErrSCIMInvalidFilter):
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+	case errors.Is(err, services.ErrSCIMInvalidPath):
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+	case errors.Is(err, services.ErrSCIMInvalidSyntax):
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	case errors.Is(err, services.ErrSCIMInvalidValue):
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	case errors.Is(err, services.ErrSCIMUniqueness):
+		return scimError(c, fiber.StatusConflict, scim.ErrorUniqueness, err.Error())
+	case errors.Is(err, services.ErrSCIMMutability),
+		errors.Is(err, services.ErrLastOrgOwner),
+		errors.Is(err, services.ErrLastSuperAdmin):
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorMutability, err.Error())
+	}
+	logger.Error(loggedmodule.ApiSCIM, message, "error", err)
+	return scimError(c, fiber.StatusInternalServerError, "", message)
+}
+
+// parseSCIMBody reads the request body, clients send application/scim+json
+// which the fiber body parser does not pick up
+func parseSCIMBody(c *fiber.Ctx, v any) error {
+	if err := json.Unmarshal(c.Body(), v); err != nil {
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorInvalidSyntax, "request body is not valid JSON: "+err.Error())
+	}
+	return nil
+}
+
+// scimListParams reads the filter and pagination of a list request, count
+// defaults to and is capped at services.SCIMMaxResults
+func scimListParams(c *fiber.Ctx) (string, int, int) {
+	count := c.QueryInt("count", services.SCIMMaxResults)
+	if count < 0 {
+		count = 0
+	}
+	if count > services.SCIMMaxResults {
+		count = services.SCIMMaxResults
+	}
+	return c.Query("filter"), c.QueryInt("startIndex", 1), count
+}
+
+// GetSCIMServiceProviderConfig godoc
+//
+//	@ID				GetSCIMServiceProviderConfig
+//	@Summary		Get the SCIM service provider configuration
+//	@Description	Describes the SCIM features qatarina supports
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200	{object}	scim.ServiceProviderConfig
+//	@Router			/scim/v2/ServiceProviderConfig [get]
+func GetSCIMServiceProviderConfig(baseURL string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return scimJSON(c, fiber.StatusOK, scim.NewServiceProviderConfig(baseURL, services.SCIMMaxResults))
+	}
+}
+
+// ListSCIMResourceTypes godoc
+//
+//	@ID				ListSCIMResourceTypes
+//	@Summary		List the SCIM resource types
+//	@Description	Lists the User and Group resource types
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200	{object}	scim.ListResponse
+//	@Router			/scim/v2/ResourceTypes [get]
+func ListSCIMResourceTypes(baseURL string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return scimJSON(c, fiber.StatusOK, scim.NewListResponse(scim.ResourceTypes(baseURL), 1, -1))
+	}
+}
+
+// ListSCIMUsers godoc
+//
+//	@ID				ListSCIMUsers
+//	@Summary		List provisioned users
+//	@Description	Lists the users the directory provisioned in its org
+//	@Tags			scim
+//	@Produce		json
+//	@Param			filter		query		string	false	"SCIM filter, for example userName eq \"bjensen@example.com\""
+//	@Param			startIndex	query		int		false	"1-based index of the first result"
+//	@Param			count		query		int		false	"Maximum number of results"
+//	@Success		200			{object}	scim.ListResponse
+//	@Failure		400			{object}	scim.Error
+//	@Failure		401			{object}	scim.Error
+//	@Failure		500			{object}	scim.Error
+//	@Router			/scim/v2/Users [get]
+func ListSCIMUsers(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter, startIndex, count := scimListParams(c)
+		list, err := scimService.ListUsers(c.Context(), authutil.GetSCIMOrgID(c), filter, startIndex, count)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to list users")
+		}
+		return scimJSON(c, fiber.StatusOK, list)
+	}
+}
+
+// GetSCIMUser godoc
+//
+//	@ID				GetSCIMUser
+//	@Summary		Get a provisioned user
+//	@Tags			scim
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	scim.User
+//	@Failure		401	{object}	scim.Error
+//	@Failure		404	{object}	scim.Error
+//	@Failure		500	{object}	scim.Error
+//	@Router			/scim/v2/Users/{id} [get]
+func GetSCIMUser(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := scimService.GetUser(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"))
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to fetch user")
+		}
+		return scimJSON(c, fiber.StatusOK, user)
+	}
+}
+
+// CreateSCIMUser godoc
+//
+//	@ID				CreateSCIMUser
+//	@Summary		Provision a user
+//	@Description	Creates a verified account that is a member of the org, an existing account of an org member with the same email is adopted instead
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		scim.User	true	"User"
+//	@Success		201		{object}	scim.User
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		409		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Users [post]
+func CreateSCIMUser(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.User
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		user, err := scimService.CreateUser(c.Context(), authutil.GetSCIMOrgID(c), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to create user")
+		}
+		c.Set(fiber.HeaderLocation, user.Meta.Location)
+		return scimJSON(c, fiber.StatusCreated, user)
+	}
+}
+
+// ReplaceSCIMUser godoc
+//
+//	@ID				ReplaceSCIMUser
+//	@Summary		Replace a provisioned user
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string		true	"User ID"
+//	@Param			request	body		scim.User	true	"User"
+//	@Success		200		{object}	scim.User
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		404		{object}	scim.Error
+//	@Failure		409		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Users/{id} [put]
+func ReplaceSCIMUser(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.User
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		user, err := scimService.ReplaceUser(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to update user")
+		}
+		return scimJSON(c, fiber.StatusOK, user)
+	}
+}
+
+// PatchSCIMUser godoc
+//
+//	@ID				PatchSCIMUser
+//	@Summary		Patch a provisioned user
+//	@Description	Applies add, replace and remove operations, setting active to false deactivates the account
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"User ID"
+//	@Param			request	body		scim.PatchRequest	true	"Patch operations"
+//	@Success		200		{object}	scim.User
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		404		{object}	scim.Error
+//	@Failure		409		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Users/{id} [patch]
+func PatchSCIMUser(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.PatchRequest
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		user, err := scimService.PatchUser(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to update user")
+		}
+		return scimJSON(c, fiber.StatusOK, user)
+	}
+}
+
+// DeleteSCIMUser godoc
+//
+//	@ID				DeleteSCIMUser
+//	@Summary		Deprovision a user
+//	@Description	Deactivates the account and removes it from the org. The account is kept so the work the user authored stays attributed to them
+//	@Tags			scim
+//	@Param			id	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	scim.Error
+//	@Failure		401	{object}	scim.Error
+//	@Failure		404	{object}	scim.Error
+//	@Failure		500	{object}	scim.Error
+//	@Router			/scim/v2/Users/{id} [delete]
+func DeleteSCIMUser(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := scimService.DeleteUser(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id")); err != nil {
+			return scimProblem(c, logger, err, "failed to delete user")
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// ListSCIMGroups godoc
+//
+//	@ID				ListSCIMGroups
+//	@Summary		List groups
+//	@Description	Lists the groups of the org, there is one per org role: owner, admin and member
+//	@Tags			scim
+//	@Produce		json
+//	@Param			filter		query		string	false	"SCIM filter, for example displayName eq \"admin\""
+//	@Param			startIndex	query		int		false	"1-based index of the first result"
+//	@Param			count		query		int		false	"Maximum number of results"
+//	@Success		200			{object}	scim.ListResponse
+//	@Failure		400			{object}	scim.Error
+//	@Failure		401			{object}	scim.Error
+//	@Failure		500			{object}	scim.Error
+//	@Router			/scim/v2/Groups [get]
+func ListSCIMGroups(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter, startIndex, count := scimListParams(c)
+		list, err := scimService.ListGroups(c.Context(), authutil.GetSCIMOrgID(c), filter, startIndex, count)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to list groups")
+		}
+		return scimJSON(c, fiber.StatusOK, list)
+	}
+}
+
+// GetSCIMGroup godoc
+//
+//	@ID				GetSCIMGroup
+//	@Summary		Get a group
+//	@Tags			scim
+//	@Produce		json
+//	@Param			id	path		string	true	"Group ID, the org role"
+//	@Success		200	{object}	scim.Group
+//	@Failure		401	{object}	scim.Error
+//	@Failure		404	{object}	scim.Error
+//	@Failure		500	{object}	scim.Error
+//	@Router			/scim/v2/Groups/{id} [get]
+func GetSCIMGroup(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		group, err := scimService.GetGroup(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"))
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to fetch group")
+		}
+		return scimJSON(c, fiber.StatusOK, group)
+	}
+}
+
+// CreateSCIMGroup godoc
+//
+//	@ID				CreateSCIMGroup
+//	@Summary		Link a group
+//	@Description	Resolves the group to the org role named by its displayName and adds the members to it. The roles are fixed so no group is created
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		scim.Group	true	"Group"
+//	@Success		201		{object}	scim.Group
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Groups [post]
+func CreateSCIMGroup(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.Group
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		group, err := scimService.CreateGroup(c.Context(), authutil.GetSCIMOrgID(c), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to create group")
+		}
+		c.Set(fiber.HeaderLocation, group.Meta.Location)
+		return scimJSON(c, fiber.StatusCreated, group)
+	}
+}
+
+// ReplaceSCIMGroup godoc
+//
+//	@ID				ReplaceSCIMGroup
+//	@Summary		Replace the members of a group
+//	@Description	Gives the listed users the role of the group, owners and admins that are not listed become members
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string		true	"Group ID, the org role"
+//	@Param			request	body		scim.Group	true	"Group"
+//	@Success		200		{object}	scim.Group
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		404		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Groups/{id} [put]
+func ReplaceSCIMGroup(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.Group
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		group, err := scimService.ReplaceGroup(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to update group")
+		}
+		return scimJSON(c, fiber.StatusOK, group)
+	}
+}
+
+// PatchSCIMGroup godoc
+//
+//	@ID				PatchSCIMGroup
+//	@Summary		Patch the members of a group
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Group ID, the org role"
+//	@Param			request	body		scim.PatchRequest	true	"Patch operations"
+//	@Success		200		{object}	scim.Group
+//	@Failure		400		{object}	scim.Error
+//	@Failure		401		{object}	scim.Error
+//	@Failure		404		{object}	scim.Error
+//	@Failure		500		{object}	scim.Error
+//	@Router			/scim/v2/Groups/{id} [patch]
+func PatchSCIMGroup(scimService services.SCIMService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request scim.PatchRequest
+		if err := parseSCIMBody(c, &request); err != nil {
+			return err
+		}
+		group, err := scimService.PatchGroup(c.Context(), authutil.GetSCIMOrgID(c), c.Params("id"), &request)
+		if err != nil {
+			return scimProblem(c, logger, err, "failed to update group")
+		}
+		return scimJSON(c, fiber.StatusOK, group)
+	}
+}
+
+// DeleteSCIMGroup godoc
+//
+//	@ID				DeleteSCIMGroup
+//	@Summary		Delete a group
+//	@Description	Groups are the org roles and cannot be deleted
+//	@Tags			scim
+//	@Param			id	path		string	true	"Group ID, the org role"
+//	@Failure		400	{object}	scim.Error
+//	@Router			/scim/v2/Groups/{id} [delete]
+func DeleteSCIMGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return scimError(c, fiber.StatusBadRequest, scim.ErrorMutability, "groups map to org roles and cannot be deleted")
+	}
+}
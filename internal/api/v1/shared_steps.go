@@ -0,0 +1,264 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// ListSharedSteps godoc
+//
+//	@ID				ListSharedSteps
+//	@Summary		List the shared steps of a project
+//	@Description	List the blocks of steps the test cases of a project can reference
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{object}	schema.SharedStepListResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps [get]
+func ListSharedSteps(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+
+		sharedSteps, err := sharedStepService.FindAllByProjectID(c.UserContext(), projectID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to list shared steps", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list shared steps")
+		}
+		return c.JSON(schema.NewSharedStepListResponse(sharedSteps))
+	}
+}
+
+// GetSharedStep godoc
+//
+//	@ID				GetSharedStep
+//	@Summary		Get a shared step of a project
+//	@Description	Get the parameters and steps of a shared step
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID		path		int	true	"Project ID"
+//	@Param			sharedStepID	path		int	true	"Shared step ID"
+//	@Success		200				{object}	schema.SharedStepResponse
+//	@Failure		400				{object}	problemdetail.ProblemDetail
+//	@Failure		404				{object}	problemdetail.ProblemDetail
+//	@Failure		500				{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps/{sharedStepID} [get]
+func GetSharedStep(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		sharedStepID, err := common.ParseIDFromCtx(c, "sharedStepID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for sharedStepID")
+		}
+
+		sharedStep, err := sharedStepService.FindByID(c.UserContext(), projectID, sharedStepID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "shared step not found")
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to fetch shared step", "sharedStepID", sharedStepID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to fetch shared step")
+		}
+		return c.JSON(schema.NewSharedStepResponse(sharedStep))
+	}
+}
+
+// CreateSharedStep godoc
+//
+//	@ID				CreateSharedStep
+//	@Summary		Create a shared step in a project
+//	@Description	Create a named block of steps test cases of the project can reference, the steps use the parameters as {{name}}
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int								true	"Project ID"
+//	@Param			request		body		schema.CreateSharedStepRequest	true	"Shared step"
+//	@Success		201			{object}	schema.SharedStepResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps [post]
+func CreateSharedStep(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.CreateSharedStepRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+		request.CreatedByID = authutil.GetAuthUserID(c)
+
+		sharedStep, err := sharedStepService.Create(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrInvalidSharedStep) || errors.Is(err, services.ErrSharedStepExists) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to create shared step", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to create shared step")
+		}
+		return c.Status(fiber.StatusCreated).JSON(schema.NewSharedStepResponse(sharedStep))
+	}
+}
+
+// UpdateSharedStep godoc
+//
+//	@ID				UpdateSharedStep
+//	@Summary		Update a shared step of a project
+//	@Description	Replace the name, parameters and steps of a shared step. The change applies to every test case referencing it, list them with the usages endpoint first.
+//	@Description	A new revision is recorded for each of those test cases
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID		path		int								true	"Project ID"
+//	@Param			sharedStepID	path		int								true	"Shared step ID"
+//	@Param			request			body		schema.UpdateSharedStepRequest	true	"Shared step"
+//	@Success		200				{object}	schema.SharedStepResponse
+//	@Failure		400				{object}	problemdetail.ProblemDetail
+//	@Failure		404				{object}	problemdetail.ProblemDetail
+//	@Failure		500				{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps/{sharedStepID} [post]
+func UpdateSharedStep(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		sharedStepID, err := common.ParseIDFromCtx(c, "sharedStepID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for sharedStepID")
+		}
+		request := new(schema.UpdateSharedStepRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+		request.SharedStepID = sharedStepID
+		request.UpdatedByID = authutil.GetAuthUserID(c)
+
+		sharedStep, err := sharedStepService.Update(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "shared step not found")
+			}
+			if errors.Is(err, services.ErrInvalidSharedStep) || errors.Is(err, services.ErrSharedStepExists) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to update shared step", "sharedStepID", sharedStepID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to update shared step")
+		}
+		return c.JSON(schema.NewSharedStepResponse(sharedStep))
+	}
+}
+
+// DeleteSharedStep godoc
+//
+//	@ID				DeleteSharedStep
+//	@Summary		Delete a shared step of a project
+//	@Description	Delete a shared step, shared steps referenced by test cases cannot be deleted
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID		path		int	true	"Project ID"
+//	@Param			sharedStepID	path		int	true	"Shared step ID"
+//	@Success		200				{object}	map[string]string
+//	@Failure		400				{object}	problemdetail.ProblemDetail
+//	@Failure		404				{object}	problemdetail.ProblemDetail
+//	@Failure		500				{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps/{sharedStepID} [delete]
+func DeleteSharedStep(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		sharedStepID, err := common.ParseIDFromCtx(c, "sharedStepID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for sharedStepID")
+		}
+
+		if err := sharedStepService.Delete(c.UserContext(), projectID, sharedStepID); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "shared step not found")
+			}
+			if errors.Is(err, services.ErrSharedStepInUse) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to delete shared step", "sharedStepID", sharedStepID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to delete shared step")
+		}
+		return c.JSON(fiber.Map{
+			"message": "Shared step deleted successfully",
+		})
+	}
+}
+
+// GetSharedStepUsages godoc
+//
+//	@ID				GetSharedStepUsages
+//	@Summary		List the test cases referencing a shared step
+//	@Description	List the test cases a change of the shared step applies to, with the number of their steps referencing it
+//	@Tags			shared-steps
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID		path		int	true	"Project ID"
+//	@Param			sharedStepID	path		int	true	"Shared step ID"
+//	@Success		200				{object}	schema.SharedStepUsageResponse
+//	@Failure		400				{object}	problemdetail.ProblemDetail
+//	@Failure		404				{object}	problemdetail.ProblemDetail
+//	@Failure		500				{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/shared-steps/{sharedStepID}/usages [get]
+func GetSharedStepUsages(sharedStepService services.SharedStepService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		sharedStepID, err := common.ParseIDFromCtx(c, "sharedStepID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for sharedStepID")
+		}
+
+		sharedStep, usages, err := sharedStepService.FindUsages(c.UserContext(), projectID, sharedStepID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "shared step not found")
+			}
+			logger.Error(loggedmodule.ApiSharedSteps, "failed to list usages of shared step", "sharedStepID", sharedStepID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list usages of shared step")
+		}
+		return c.JSON(schema.NewSharedStepUsageResponse(sharedStep, usages))
+	}
+}
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// GetTestCaseCodePattern godoc
+//
+//	@ID				GetTestCaseCodePattern
+//	@Summary		Get the test case code pattern of a project
+//	@Description	Get the pattern of the codes generated for new test cases of a project
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{object}	schema.TestCaseCodePatternResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/test-case-code-pattern [get]
+func GetTestCaseCodePattern(testCaseCodeService services.TestCaseCodeService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+
+		res, err := testCaseCodeService.GetPattern(c.UserContext(), projectID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to get test case code pattern", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to get test case code pattern")
+		}
+		return c.JSON(res)
+	}
+}
+
+// SetTestCaseCodePattern godoc
+//
+//	@ID				SetTestCaseCodePattern
+//	@Summary		Set the test case code pattern of a project
+//	@Description	Set the pattern of the codes generated for new test cases, e.g. {project}-{module}-{seq:4}. Each combination of tokens has its own counter
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int									true	"Project ID"
+//	@Param			request		body		schema.TestCaseCodePatternRequest	true	"Code pattern"
+//	@Success		200			{object}	schema.TestCaseCodePatternResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/test-case-code-pattern [post]
+func SetTestCaseCodePattern(testCaseCodeService services.TestCaseCodeService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.TestCaseCodePatternRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+
+		res, err := testCaseCodeService.SetPattern(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrInvalidCodePattern) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to set test case code pattern", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to set test case code pattern")
+		}
+		return c.JSON(res)
+	}
+}
+
+// ResequenceTestCaseCodes godoc
+//
+//	@ID				ResequenceTestCaseCodes
+//	@Summary		Re-sequence or rename the test case codes of a project
+//	@Description	Renumber the codes of every test case with the code pattern, or rename the given codes. References in test plans and test runs are rewritten and the old codes are kept as aliases
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int										true	"Project ID"
+//	@Param			request		body		schema.ResequenceTestCaseCodesRequest	true	"Renames"
+//	@Success		200			{object}	schema.ResequenceTestCaseCodesResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/test-case-codes/resequence [post]
+func ResequenceTestCaseCodes(testCaseCodeService services.TestCaseCodeService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		request := new(schema.ResequenceTestCaseCodesRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.ProjectID = projectID
+
+		res, err := testCaseCodeService.Resequence(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			if errors.Is(err, services.ErrInvalidTestCaseCode) || errors.Is(err, services.ErrInvalidCodePattern) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to re-sequence test case codes", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to re-sequence test case codes")
+		}
+		return c.JSON(res)
+	}
+}
+
+// ListTestCaseCodeAliases godoc
+//
+//	@ID				ListTestCaseCodeAliases
+//	@Summary		List the old test case codes of a project
+//	@Description	List the codes test cases had before they were renamed, old codes still find the test case in search
+//	@Tags			projects
+//	@Accept			json
+//	@Produce		json
+//	@Param			projectID	path		int	true	"Project ID"
+//	@Success		200			{array}		schema.TestCaseCodeAliasResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/test-case-codes/aliases [get]
+func ListTestCaseCodeAliases(testCaseCodeService services.TestCaseCodeService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+
+		aliases, err := testCaseCodeService.ListAliases(c.UserContext(), projectID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiProjects, "failed to list test case code aliases", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list test case code aliases")
+		}
+		return c.JSON(schema.NewTestCaseCodeAliasListResponse(aliases))
+	}
+}
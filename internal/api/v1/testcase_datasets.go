@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// GetTestCaseDataset godoc
+//
+//	@ID				GetTestCaseDataset
+//	@Summary		Get the dataset of a test case
+//	@Description	Get the parameters and rows of values a data-driven test case is run with
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Success		200			{object}	schema.TestCaseDatasetResponse
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/dataset [get]
+func GetTestCaseDataset(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		dataset, err := testCaseService.FindDataset(c.UserContext(), testCaseID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case has no dataset")
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test case dataset", "testCaseID", testCaseID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to fetch test case dataset")
+		}
+		return c.JSON(schema.NewTestCaseDatasetResponse(dataset))
+	}
+}
+
+// SetTestCaseDataset godoc
+//
+//	@ID				SetTestCaseDataset
+//	@Summary		Set the dataset of a test case
+//	@Description	Replace the parameters and rows of the dataset of a test case. The steps use the parameters as {{name}}, assigning the test case to a plan creates a test run per row
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string								true	"Test Case ID"
+//	@Param			request		body		schema.SetTestCaseDatasetRequest	true	"Dataset"
+//	@Success		200			{object}	schema.TestCaseDatasetResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/dataset [post]
+func SetTestCaseDataset(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		request := new(schema.SetTestCaseDatasetRequest)
+		if validationErrors, err := common.ParseBodyThenValidate(c, request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		request.TestCaseID = testCaseID
+		request.UpdatedByID = authutil.GetAuthUserID(c)
+
+		dataset, err := testCaseService.SetDataset(c.UserContext(), request)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case not found")
+			}
+			if errors.Is(err, services.ErrInvalidDataset) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to save test case dataset", "testCaseID", testCaseID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to save test case dataset")
+		}
+		return c.JSON(schema.NewTestCaseDatasetResponse(dataset))
+	}
+}
+
+// ImportTestCaseDataset godoc
+//
+//	@ID				ImportTestCaseDataset
+//	@Summary		Import the dataset of a test case from Excel or CSV file
+//	@Description	Replace the dataset of a test case by the rows of a CSV file or the first sheet of an Excel file. The first row names the parameters
+//	@Tags			test-cases
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Param			file		formData	file	true	"Excel or CSV file"
+//	@Success		200			{object}	schema.TestCaseDatasetResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/dataset/import [post]
+func ImportTestCaseDataset(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return problemdetail.BadRequest(c, "file is required")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to open uploaded file", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to open uploaded file")
+		}
+		defer file.Close()
+
+		dataset, err := testCaseService.ImportDataset(c.UserContext(), testCaseID, authutil.GetAuthUserID(c), file, fileHeader.Filename)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case not found")
+			}
+			if errors.Is(err, services.ErrInvalidDataset) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to import test case dataset", "testCaseID", testCaseID, "filename", fileHeader.Filename, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to import test case dataset")
+		}
+		return c.JSON(schema.NewTestCaseDatasetResponse(dataset))
+	}
+}
+
+// DeleteTestCaseDataset godoc
+//
+//	@ID				DeleteTestCaseDataset
+//	@Summary		Delete the dataset of a test case
+//	@Description	Delete the dataset of a test case, test runs already created for its rows are kept
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Success		200			{object}	map[string]string
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/dataset [delete]
+func DeleteTestCaseDataset(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		if err := testCaseService.DeleteDataset(c.UserContext(), testCaseID); err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case has no dataset")
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to delete test case dataset", "testCaseID", testCaseID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to delete test case dataset")
+		}
+		return c.JSON(fiber.Map{
+			"message": "Test case dataset deleted successfully",
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package v1
 
 import (
-	"context"
+	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/common"
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
 	"github.com/golang-malawi/qatarina/internal/schema"
@@ -50,7 +52,7 @@ func ImportTestCasesFromFile(testCaseService services.TestCaseService, importSer
 		defer file.Close()
 
 		// Parse file contents into test cases
-		testCases, err := importService.FromFile(c.Context(), projectID, file, fileHeader.Filename)
+		testCases, err := importService.FromFile(c.UserContext(), projectID, file, fileHeader.Filename)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to parse test cases", "filename", fileHeader.Filename, "error", err)
 			return problemdetail.ServerErrorProblem(c, err.Error())
@@ -62,8 +64,17 @@ func ImportTestCasesFromFile(testCaseService services.TestCaseService, importSer
 			TestCases: testCases,
 		}
 
-		created, skipped, err := testCaseService.BulkCreate(context.Background(), request)
+		created, skipped, err := testCaseService.BulkCreate(c.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(c, err.Error(), fieldErrs)
+			}
+			if sectionErrs, ok := templateErrors(err); ok {
+				return problemdetail.ValidationErrors(c, err.Error(), sectionErrs)
+			}
+			if errors.Is(err, services.ErrInvalidTestCaseCode) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			logger.Error(loggedmodule.ApiTestCases, "failed to import test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to import test cases")
 		}
@@ -74,3 +85,42 @@ func ImportTestCasesFromFile(testCaseService services.TestCaseService, importSer
 		})
 	}
 }
+
+// ExportTestCasesToFile godoc
+//
+//	@ID				ExportTestCasesToFile
+//	@Summary		Export test cases to an Excel or CSV file
+//	@Description	Download the test cases of a project with their custom fields in the layout accepted by the import
+//	@Tags			test-cases
+//	@Produce		text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			projectID	path		int		true	"Project ID"
+//	@Param			format		query		string	false	"csv (default) or xlsx"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/projects/{projectID}/test-cases/export [get]
+func ExportTestCasesToFile(exportService services.TestCaseExportService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		projectID, err := common.ParseIDFromCtx(c, "projectID")
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for projectID")
+		}
+		format := c.Query("format", "csv")
+
+		var buf bytes.Buffer
+		if err := exportService.Export(c.UserContext(), projectID, format, &buf); err != nil {
+			if errors.Is(err, services.ErrUnsupportedExportFormat) {
+				return problemdetail.BadRequest(c, "format must be csv or xlsx")
+			}
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "project not found")
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to export test cases", "projectID", projectID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to export test cases")
+		}
+
+		c.Attachment(fmt.Sprintf("project-%d-test-cases.%s", projectID, format))
+		return c.Send(buf.Bytes())
+	}
+}
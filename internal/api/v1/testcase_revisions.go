@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// ListTestCaseRevisions godoc
+//
+//	@ID				ListTestCaseRevisions
+//	@Summary		List the revisions of a test case
+//	@Description	List the numbered revisions of a test case, newest first. A revision is saved for every edit
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Success		200			{array}		schema.TestCaseRevisionResponse
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/revisions [get]
+func ListTestCaseRevisions(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		revisions, err := testCaseService.FindRevisions(c.UserContext(), testCaseID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case not found")
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to list test case revisions", "testCaseID", testCaseID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to list test case revisions")
+		}
+		return c.JSON(schema.NewTestCaseRevisionListResponse(revisions))
+	}
+}
+
+// DiffTestCaseRevisions godoc
+//
+//	@ID				DiffTestCaseRevisions
+//	@Summary		Compare two revisions of a test case
+//	@Description	List the fields which differ between two revisions of a test case, the description and steps are compared line by line
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Param			from		query		int		true	"Revision to compare from"
+//	@Param			to			query		int		true	"Revision to compare to"
+//	@Success		200			{object}	schema.TestCaseRevisionDiffResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/revisions/diff [get]
+func DiffTestCaseRevisions(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		from, err := strconv.ParseInt(c.Query("from"), 10, 32)
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for from")
+		}
+		to, err := strconv.ParseInt(c.Query("to"), 10, 32)
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for to")
+		}
+
+		diff, err := testCaseService.DiffRevisions(c.UserContext(), testCaseID, int32(from), int32(to))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case or revision not found")
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to compare test case revisions", "testCaseID", testCaseID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to compare test case revisions")
+		}
+		return c.JSON(diff)
+	}
+}
+
+// RestoreTestCaseRevision godoc
+//
+//	@ID				RestoreTestCaseRevision
+//	@Summary		Restore a revision of a test case
+//	@Description	Set the title, description, steps, tags, kind, runner and script of a test case back to an earlier revision. The restored content is saved as a new revision
+//	@Tags			test-cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			testCaseID	path		string	true	"Test Case ID"
+//	@Param			revision	path		int		true	"Revision"
+//	@Success		200			{object}	schema.TestCaseResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-cases/{testCaseID}/revisions/{revision}/restore [post]
+func RestoreTestCaseRevision(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testCaseID := c.Params("testCaseID", "")
+		revision, err := strconv.ParseInt(c.Params("revision"), 10, 32)
+		if err != nil {
+			return problemdetail.BadRequest(c, "invalid parameter for revision")
+		}
+
+		testCase, err := testCaseService.RestoreRevision(c.UserContext(), testCaseID, int32(revision), authutil.GetAuthUserID(c))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test case or revision not found")
+			}
+			if errors.Is(err, services.ErrInvalidSharedStep) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiTestCases, "failed to restore test case revision", "testCaseID", testCaseID, "revision", revision, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to restore test case revision")
+		}
+
+		steps, stepDefinitions, err := testCaseService.FindSteps(c.UserContext(), testCaseID)
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test case steps", "error", err)
+		}
+		res := schema.NewTestCaseResponseFromRow(testCase)
+		res.Steps = steps
+		res.StepDefinitions = stepDefinitions
+		return c.JSON(res)
+	}
+}
@@ -3,7 +3,6 @@ package v1
 
 import (
 	"bytes"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -47,11 +46,12 @@ import (
 //	@Param			search		query		string	false	"Search query (matches code, title, description, feature_or_module)"
 //	@Param			kind		query		string	false	"Filter by kind"
 //	@Param			isDraft		query		bool	false	"Filter by draft state"
+//	@Param			cf.{name}	query		string	false	"Filter by the value of the custom field with the name"
 //	@Success		200	{object}	schema.TestCaseListResponse
 //	@Failure		400	{object}	problemdetail.ProblemDetail
 //	@Failure		500	{object}	problemdetail.ProblemDetail
 //	@Router			/v1/test-cases [get]
-func ListTestCases(testCasesService services.TestCaseService, logger logging.Logger) fiber.Handler {
+func ListTestCases(testCasesService services.TestCaseService, customFieldService services.CustomFieldService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		page, err := strconv.Atoi(c.Query("page", "1"))
 		if err != nil {
@@ -74,23 +74,41 @@ func ListTestCases(testCasesService services.TestCaseService, logger logging.Log
 			}
 			isDraft = &val
 		}
+		customFields := map[string]string{}
+		for key, value := range c.Queries() {
+			if name, ok := strings.CutPrefix(key, "cf."); ok && value != "" {
+				customFields[name] = value
+			}
+		}
 		suggested := false
-		testCases, total, err := testCasesService.FindAllPaged(context.Background(), services.TestCaseQueryParams{
-			Page:      page,
-			PageSize:  pageSize,
-			SortBy:    sortBy,
-			SortOrder: sortOrder,
-			Search:    search,
-			Kind:      kind,
-			IsDraft:   isDraft,
-			Suggested: &suggested,
+		testCases, total, err := testCasesService.FindAllPaged(c.UserContext(), services.TestCaseQueryParams{
+			Page:         page,
+			PageSize:     pageSize,
+			SortBy:       sortBy,
+			SortOrder:    sortOrder,
+			Search:       search,
+			Kind:         kind,
+			IsDraft:      isDraft,
+			Suggested:    &suggested,
+			CustomFields: customFields,
 		})
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch test cases")
 		}
+		ids := make([]uuid.UUID, 0, len(testCases))
+		for _, tc := range testCases {
+			ids = append(ids, tc.ID)
+		}
+		values, err := customFieldService.FindValues(c.UserContext(), ids...)
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch custom field values", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to fetch test cases")
+		}
+		items := schema.NewTestCaseResponseList(testCases)
+		schema.SetTestCaseCustomFields(items, values)
 		return c.JSON(schema.TestCaseListResponse{
-			TestCases: schema.NewTestCaseResponseList(testCases),
+			TestCases: items,
 			Pagination: &schema.Pagination{
 				Total:    total,
 				Page:     page,
@@ -104,10 +122,12 @@ func ListTestCases(testCasesService services.TestCaseService, logger logging.Log
 //
 //	@ID				SearchTestCases
 //	@Summary		Search for Test Cases
-//	@Description	Search for Test Cases
+//	@Description	Search for the Test Cases of a project by title, code or former code
 //	@Tags			test-cases
 //	@Accept			json
 //	@Produce		json
+//	@Param			project_id	query		int		true	"Project ID"
+//	@Param			keyword		query		string	true	"Keyword"
 //	@Success		200	{object}	schema.TestCaseListResponse
 //	@Failure		400	{object}	problemdetail.ProblemDetail
 //	@Failure		500	{object}	problemdetail.ProblemDetail
@@ -119,11 +139,20 @@ func SearchTestCases(testCaseService services.TestCaseService) fiber.Handler {
 			return problemdetail.BadRequest(q, "missing keyword parameter")
 		}
 
-		testCases, err := testCaseService.Search(q.Context(), keyword)
+		projectID, err := strconv.ParseInt(q.Query("project_id"), 10, 64)
+		if err != nil {
+			return problemdetail.BadRequest(q, "invalid project_id parameter")
+		}
+
+		testCases, err := testCaseService.Search(q.UserContext(), projectID, keyword)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return q.JSON([]dbsqlc.TestCase{})
 			}
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(q, "project not found")
+			}
+			return problemdetail.ServerErrorProblem(q, "failed to search test cases")
 		}
 		return q.JSON(testCases)
 	}
@@ -142,14 +171,26 @@ func SearchTestCases(testCaseService services.TestCaseService) fiber.Handler {
 //	@Failure		400			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
 //	@Router			/v1/test-cases/{testCaseID} [get]
-func GetOneTestCase(testCaseService services.TestCaseService) fiber.Handler {
+func GetOneTestCase(testCaseService services.TestCaseService, customFieldService services.CustomFieldService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		testCaseID := c.Params("testCaseId", "")
-		testCase, err := testCaseService.FindByID(context.Background(), testCaseID)
+		testCase, err := testCaseService.FindByID(c.UserContext(), testCaseID)
+		if err != nil {
+			return problemdetail.ServerErrorProblem(c, "failed to fetch test case with given id")
+		}
+		values, err := customFieldService.FindValues(c.UserContext(), testCase.ID)
 		if err != nil {
 			return problemdetail.ServerErrorProblem(c, "failed to fetch test case with given id")
 		}
-		return c.JSON(schema.NewTestCaseResponse(testCase))
+		steps, stepDefinitions, err := testCaseService.FindSteps(c.UserContext(), testCaseID)
+		if err != nil {
+			return problemdetail.ServerErrorProblem(c, "failed to fetch test case with given id")
+		}
+		res := schema.NewTestCaseResponse(testCase)
+		res.CustomFields = values[testCase.ID]
+		res.Steps = steps
+		res.StepDefinitions = stepDefinitions
+		return c.JSON(res)
 	}
 }
 
@@ -167,7 +208,7 @@ func GetOneTestCase(testCaseService services.TestCaseService) fiber.Handler {
 //	@Failure		400		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/test-cases [post]
-func CreateTestCase(testCaseService services.TestCaseService, projectService services.ProjectService, logger logging.Logger, cfg *config.Config) fiber.Handler {
+func CreateTestCase(testCaseService services.TestCaseService, projectService services.ProjectService, customFieldService services.CustomFieldService, logger logging.Logger, cfg *config.Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		request := new(schema.CreateTestCaseRequest)
 
@@ -192,7 +233,7 @@ func CreateTestCase(testCaseService services.TestCaseService, projectService ser
 			// … populate other fields …
 
 			// ✅ Guard: block scripts if project setting disabled
-			project, _ := projectService.FindByID(c.Context(), request.ProjectID)
+			project, _ := projectService.FindByID(c.UserContext(), request.ProjectID)
 			if !project.AutomatedTestingEnabled {
 				return problemdetail.BadRequest(c, "Automated testing is disabled for this project")
 			}
@@ -222,12 +263,33 @@ func CreateTestCase(testCaseService services.TestCaseService, projectService ser
 			}
 		}
 
-		testCase, err := testCaseService.Create(context.Background(), request)
+		testCase, err := testCaseService.Create(c.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(c, "invalid custom field values", fieldErrs)
+			}
+			if sectionErrs, ok := templateErrors(err); ok {
+				return problemdetail.ValidationErrors(c, "description does not follow the project template", sectionErrs)
+			}
+			if errors.Is(err, services.ErrInvalidTestCaseCode) || errors.Is(err, services.ErrInvalidSharedStep) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			return problemdetail.ServerErrorProblem(c, "failed to create a test case")
 		}
 
-		return c.JSON(schema.NewTestCaseResponseFromRow(testCase))
+		values, err := customFieldService.FindValues(c.UserContext(), testCase.ID)
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch custom field values", "error", err)
+		}
+		steps, stepDefinitions, err := testCaseService.FindSteps(c.UserContext(), testCase.ID.String())
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test case steps", "error", err)
+		}
+		res := schema.NewTestCaseResponseFromRow(testCase)
+		res.CustomFields = values[testCase.ID]
+		res.Steps = steps
+		res.StepDefinitions = stepDefinitions
+		return c.JSON(res)
 	}
 }
 
@@ -251,7 +313,7 @@ func ExecuteTestCase(testCaseService services.TestCaseService, testRunService se
 		caseID := c.Params("test_case_id")
 		userID := authutil.GetAuthUserID(c)
 
-		testCase, err := testCaseService.FindByID(c.Context(), caseID)
+		testCase, err := testCaseService.FindByID(c.UserContext(), caseID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test case", "error", err)
 			return problemdetail.NotFound(c, "test case not found")
@@ -286,7 +348,7 @@ func ExecuteTestCase(testCaseService services.TestCaseService, testRunService se
 			ScriptPath:   resolvedScriptPath,
 		}
 
-		createdRun, err := testRunService.Create(c.Context(), testRunReq)
+		createdRun, err := testRunService.Create(c.UserContext(), testRunReq)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "failed to create test run", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to create test run")
@@ -340,6 +402,7 @@ func ExecuteTestCase(testCaseService services.TestCaseService, testRunService se
 //	@Tags           test-cases
 //	@Accept         multipart/form-data
 //	@Produce        json
+//	@Param          project_id  formData int true "Project ID"
 //	@Param          script_file formData file true "Script file"
 //	@Param          runner      formData string true "Runner"
 //	@Success        200 {object} map[string]interface{}
@@ -465,8 +528,17 @@ func BulkCreateTestCases(testCaseService services.TestCaseService, logger loggin
 			return problemdetail.BadRequest(c, "failed to parse data in request")
 		}
 
-		testCases, _, err := testCaseService.BulkCreate(context.Background(), request)
+		testCases, _, err := testCaseService.BulkCreate(c.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(c, err.Error(), fieldErrs)
+			}
+			if sectionErrs, ok := templateErrors(err); ok {
+				return problemdetail.ValidationErrors(c, err.Error(), sectionErrs)
+			}
+			if errors.Is(err, services.ErrInvalidTestCaseCode) || errors.Is(err, services.ErrInvalidSharedStep) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			logger.Error(loggedmodule.ApiTestCases, "failed to create test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to create test cases")
 		}
@@ -492,7 +564,7 @@ func BulkCreateTestCases(testCaseService services.TestCaseService, logger loggin
 //	@Failure        400         {object}    problemdetail.ProblemDetail
 //	@Failure        500         {object}    problemdetail.ProblemDetail
 //	@Router         /v1/test-cases/{testCaseID} [post]
-func UpdateTestCase(testCaseService services.TestCaseService, projectService services.ProjectService, logger logging.Logger, cfg *config.Config) fiber.Handler {
+func UpdateTestCase(testCaseService services.TestCaseService, projectService services.ProjectService, customFieldService services.CustomFieldService, logger logging.Logger, cfg *config.Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		request := new(schema.UpdateTestCaseRequest)
 		fileHeader, err := c.FormFile("script_file")
@@ -515,7 +587,7 @@ func UpdateTestCase(testCaseService services.TestCaseService, projectService ser
 			// … populate other fields …
 
 			// ✅ Guard: block scripts if project setting disabled
-			project, _ := projectService.FindByID(c.Context(), request.ProjectID)
+			project, _ := projectService.FindByID(c.UserContext(), request.ProjectID)
 			if !project.AutomatedTestingEnabled {
 				return problemdetail.BadRequest(c, "Automated testing is disabled for this project")
 			}
@@ -540,12 +612,35 @@ func UpdateTestCase(testCaseService services.TestCaseService, projectService ser
 			}
 		}
 
-		updated, err := testCaseService.Update(context.Background(), request)
+		request.UpdatedByID = authutil.GetAuthUserID(c)
+
+		updated, err := testCaseService.Update(c.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(c, "invalid custom field values", fieldErrs)
+			}
+			if sectionErrs, ok := templateErrors(err); ok {
+				return problemdetail.ValidationErrors(c, "description does not follow the project template", sectionErrs)
+			}
+			if errors.Is(err, services.ErrInvalidSharedStep) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			return problemdetail.ServerErrorProblem(c, "failed to update test case")
 		}
 
-		return c.JSON(schema.NewTestCaseResponseFromRow(updated))
+		values, err := customFieldService.FindValues(c.UserContext(), updated.ID)
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch custom field values", "error", err)
+		}
+		steps, stepDefinitions, err := testCaseService.FindSteps(c.UserContext(), updated.ID.String())
+		if err != nil {
+			logger.Error(loggedmodule.ApiTestCases, "failed to fetch test case steps", "error", err)
+		}
+		res := schema.NewTestCaseResponseFromRow(updated)
+		res.CustomFields = values[updated.ID]
+		res.Steps = steps
+		res.StepDefinitions = stepDefinitions
+		return c.JSON(res)
 	}
 }
 
@@ -566,7 +661,7 @@ func DeleteTestCase(testCaseService services.TestCaseService, logger logging.Log
 	return func(c *fiber.Ctx) error {
 		testCaseIDParam := c.Params("testCaseID")
 
-		err := testCaseService.DeleteByID(c.Context(), testCaseIDParam)
+		err := testCaseService.DeleteByID(c.UserContext(), testCaseIDParam)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Error("test case not found", "error", err)
@@ -605,7 +700,7 @@ func ListAssignedTestCases(testCasesService services.TestCaseService, logger log
 		offset := (page - 1) * pageSize
 		includeClosed := ctx.QueryBool("includeClosed", false)
 
-		testCases, totalCount, err := testCasesService.FindAllAssignedToUser(ctx.Context(), userID, int32(pageSize), int32(offset), includeClosed)
+		testCases, totalCount, err := testCasesService.FindAllAssignedToUser(ctx.UserContext(), userID, int32(pageSize), int32(offset), includeClosed)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch assigned test cases", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failed to fetch assigned test cases")
@@ -647,7 +742,7 @@ func MarkTestCaseAsDraft(testCaseService services.TestCaseService, logger loggin
 			return problemdetail.BadRequest(c, "invalid testCaseID")
 		}
 
-		err = testCaseService.MarkAsDraft(c.Context(), testCaseID)
+		err = testCaseService.MarkAsDraft(c.UserContext(), testCaseID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to mark test case as draft ", slog.String("testCaseID", testCaseID), "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to mark test case as draft")
@@ -682,8 +777,11 @@ func UnMarkTestCaseAsDraft(testCaseService services.TestCaseService, logger logg
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid testCaseID")
 		}
-		err = testCaseService.UnMarkAsDraft(c.Context(), testCaseID)
+		err = testCaseService.UnMarkAsDraft(c.UserContext(), testCaseID)
 		if err != nil {
+			if sectionErrs, ok := templateErrors(err); ok {
+				return problemdetail.ValidationErrors(c, "description does not follow the project template", sectionErrs)
+			}
 			logger.Error(loggedmodule.ApiTestCases, "failed to unmark test case as draft", slog.String("testCaseID", testCaseID), "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to unmark test case as draft")
 		}
@@ -710,7 +808,7 @@ func GetExecutionSummary(testCasesService services.TestCaseService, logger loggi
 	return func(c *fiber.Ctx) error {
 		userID := authutil.GetAuthUserID(c)
 
-		summaries, err := testCasesService.GetExecutionSummaryByUser(c.Context(), userID)
+		summaries, err := testCasesService.GetExecutionSummaryByUser(c.UserContext(), userID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch execution summary", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch execution summary")
@@ -740,7 +838,7 @@ func ListClosedTestCases(testCasesService services.TestCaseService, logger loggi
 			return problemdetail.BadRequest(c, "missing projectID")
 		}
 
-		testCases, err := testCasesService.FindAllClosed(c.Context(), int64(projectID))
+		testCases, err := testCasesService.FindAllClosed(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch closed test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch closed test cases")
@@ -770,7 +868,7 @@ func ListFailingTestCases(testCasesService services.TestCaseService, logger logg
 			return problemdetail.BadRequest(c, "missing projectID")
 		}
 
-		testCases, err := testCasesService.FindAllFailing(c.Context(), int64(projectID))
+		testCases, err := testCasesService.FindAllFailing(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch feailing test cases")
 			return problemdetail.ServerErrorProblem(c, "failed to fetch failing test cases")
@@ -800,7 +898,7 @@ func ListScheduledTestCases(testCasesService services.TestCaseService, logger lo
 			return problemdetail.BadRequest(c, "missing projectID")
 		}
 
-		testCases, err := testCasesService.FindAllScheduled(c.Context(), int64(projectID))
+		testCases, err := testCasesService.FindAllScheduled(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch scheduled test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch scheduled test cases")
@@ -830,7 +928,7 @@ func ListBlockedTestCases(testCasesService services.TestCaseService, logger logg
 			return problemdetail.BadRequest(c, "missing projectID")
 		}
 
-		testCases, err := testCasesService.FindAllBlocked(c.Context(), int64(projectID))
+		testCases, err := testCasesService.FindAllBlocked(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch blocked test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch blocked test cases")
@@ -867,7 +965,7 @@ func SuggestTestCase(testCaseService services.TestCaseService, logger logging.Lo
 		userID := authutil.GetAuthUserID(c)
 		req.CreatedByID = userID
 
-		tc, err := testCaseService.Suggest(c.Context(), req)
+		tc, err := testCaseService.Suggest(c.UserContext(), req)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to suggest test case", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to suggest test case")
@@ -897,7 +995,7 @@ func ListSuggestedTestCases(testCaseService services.TestCaseService, logger log
 			return problemdetail.BadRequest(c, "missing or invalid project ID")
 		}
 
-		testCases, err := testCaseService.FindAllSuggested(c.Context(), int64(projectID))
+		testCases, err := testCaseService.FindAllSuggested(c.UserContext(), int64(projectID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to fetch suggested test cases", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch suggested test cases")
@@ -927,7 +1025,7 @@ func AcceptSuggestedTestCase(testCaseService services.TestCaseService, logger lo
 			return problemdetail.BadRequest(c, "missing testCaseID")
 		}
 
-		if err := testCaseService.AcceptSuggested(c.Context(), id); err != nil {
+		if err := testCaseService.AcceptSuggested(c.UserContext(), id); err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to accept suggested test case", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to accept suggested test case")
 		}
@@ -958,7 +1056,7 @@ func RejectSuggestedTestCase(testCaseService services.TestCaseService, logger lo
 			return problemdetail.BadRequest(c, "missing testCaseID")
 		}
 
-		if err := testCaseService.RejectSuggested(c.Context(), id); err != nil {
+		if err := testCaseService.RejectSuggested(c.UserContext(), id); err != nil {
 			logger.Error(loggedmodule.ApiTestCases, "failed to reject suggested test case", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to reject suggested test case")
 		}
@@ -1013,7 +1111,7 @@ func GetScriptTestPlanTestCases(testCaseService services.TestCaseService, logger
 			return problemdetail.BadRequest(c, "invalid testPlanID in request")
 		}
 
-		testCases, err := testCaseService.FindScriptCasesByPlanID(c.Context(), int64(testPlanID))
+		testCases, err := testCaseService.FindScriptCasesByPlanID(c.UserContext(), int64(testPlanID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info(loggedmodule.ApiTestPlans, "no script test cases found", "error", err)
@@ -1046,7 +1144,7 @@ func GetScriptTestPlanTestCases(testCaseService services.TestCaseService, logger
 func BranchTestCase(testCaseService services.TestCaseService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		parentID := c.Params("testCaseID")
-		parent, err := testCaseService.FindByID(c.Context(), parentID)
+		parent, err := testCaseService.FindByID(c.UserContext(), parentID)
 		if err != nil {
 			return problemdetail.NotFound(c, "parent test case not found")
 		}
@@ -1065,7 +1163,7 @@ func BranchTestCase(testCaseService services.TestCaseService, logger logging.Log
 			CreatedByID:      strconv.Itoa(int(authutil.GetAuthUserID(c))),
 		}
 
-		branched, err := testCaseService.Create(c.Context(), req)
+		branched, err := testCaseService.Create(c.UserContext(), req)
 		if err != nil {
 			return problemdetail.ServerErrorProblem(c, "failed to branch test case")
 		}
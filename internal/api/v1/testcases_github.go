@@ -1,8 +1,6 @@
 package v1
 
 import (
-	"context"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-malawi/qatarina/internal/common"
 	"github.com/golang-malawi/qatarina/internal/logging"
@@ -36,7 +34,7 @@ func ImportIssuesFromGitHubAsTestCases(projectService services.ProjectService, t
 		ghClient := github.NewClient(nil).WithAuthToken(request.GitHubToken)
 		githubIntegration := services.NewGitHubIntegration(ghClient, projectService, testCaseService)
 
-		testCases, err := githubIntegration.CreateTestCasesFromOpenIssues(context.Background(), request.Owner, request.Repository, request.ProjectID)
+		testCases, err := githubIntegration.CreateTestCasesFromOpenIssues(c.UserContext(), request.Owner, request.Repository, request.ProjectID)
 		if err != nil {
 			logger.Error("github-api-import", "failed to process data import", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to complete import of issues")
@@ -2,7 +2,6 @@
 package v1
 
 import (
-	"context"
 	"database/sql"
 	"errors"
 	"strconv"
@@ -30,7 +29,7 @@ import (
 //	@Router			/v1/testers [get]
 func ListTesters(testerService services.TesterService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		testers, err := testerService.FindAll(context.Background())
+		testers, err := testerService.FindAll(c.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiTesters, "failed to fetch testers", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch testers")
@@ -60,7 +59,7 @@ func SearchTesters(testerService services.TesterService, logger logging.Logger)
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to process request project id")
 		}
-		testers, err := testerService.FindByProjectID(c.Context(), int64(projectID))
+		testers, err := testerService.FindByProjectID(c.UserContext(), int64(projectID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info(loggedmodule.ApiTesters, "no testers found", "error", err)
@@ -93,9 +92,9 @@ func GetOneTester(testerService services.TesterService, logger logging.Logger) f
 			return problemdetail.BadRequest(c, "failed to parse tester id data in request")
 		}
 
-		tester, err := testerService.FindByID(c.Context(), int32(testerID))
+		tester, err := testerService.FindByID(c.UserContext(), int32(testerID))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, services.ErrNotFound) {
 				logger.Info(loggedmodule.ApiTesters, "no project tester found", "error", err)
 				return problemdetail.NotFound(c, "no project tester found")
 			}
@@ -110,44 +109,60 @@ func GetOneTester(testerService services.TesterService, logger logging.Logger) f
 //
 //	@ID				InviteTester
 //	@Summary		Invite a tester by Email
-//	@Description	Invite a tester by Email
+//	@Description	Invite a tester by Email to test a project with the given role, which defaults to engineer
 //	@Tags			testers
 //	@Accept			json
 //	@Produce		json
-//	@Param			email	path		string		true	"Email of tester"
-//	@Param			request	body		interface{}	true	"Invite data"
-//	@Success		200		{object}	interface{}
+//	@Param			request	body		schema.CreateInviteRequest	true	"Invite data"
+//	@Success		200		{object}	schema.Invite
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
-//	@Router			/v1/testers/invite/{email} [post]
-func InviteTester(testerService services.TesterService, logger logging.Logger) fiber.Handler {
+//	@Router			/v1/testers/invite [post]
+func InviteTester(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		return problemdetail.NotImplemented(c, "failed to invite Tester")
+		var request schema.CreateInviteRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+		if request.ProjectID == 0 {
+			return problemdetail.BadRequest(c, "project_id is required to invite a tester")
+		}
+		return createInvite(c, inviteService, logger, &request)
 	}
 }
 
 // DeleteTester godoc
 //
 //	@ID				DeleteTester
-//	@Summary		Delete a Tester by ID
-//	@Description	Delete a Tester by ID
+//	@Summary		Remove a Tester from a Project
+//	@Description	Remove a Tester from a Project
 //	@Tags			testers
 //	@Accept			json
 //	@Produce		json
-//	@Param			testerID	path		string	true	"Tester ID"
+//	@Param			projectID	path		string	true	"Project ID"
+//	@Param			testerID	path		string	true	"Tester User ID"
 //	@Success		200			{object}	interface{}
 //	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
-//	@Router			/v1/testers/{testerID} [delete]
+//	@Router			/v1/projects/{projectID}/testers/{testerID} [delete]
 func DeleteTester(testerService services.TesterService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID", 0)
+		if err != nil || projectID == 0 {
+			return problemdetail.BadRequest(c, "invalid project ID")
+		}
 		testerID, err := c.ParamsInt("testerID", 0)
 		if err != nil {
 			return problemdetail.BadRequest(c, "invalid tester ID")
 		}
-		err = testerService.DeleteTester(c.Context(), int32(testerID))
+		err = testerService.DeleteTester(c.UserContext(), int64(projectID), int32(testerID))
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, services.ErrNotFound) {
 				return problemdetail.NotFound(c, "tester not found")
 			}
 			logger.Error(loggedmodule.ApiTesters, "failed to delete tester", "error", err)
@@ -168,14 +183,20 @@ func DeleteTester(testerService services.TesterService, logger logging.Logger) f
 //	@Tags			testers
 //	@Accept			json
 //	@Produce		json
+//	@Param			projectID	path		string	true	"Project ID"
 //	@Param			testerID	path		string	true	"Tester User ID"
 //	@Param			request	body		schema.UpdateTesterRoleRequest	true	"New role"
 //	@Success		200			{object}	interface{}
 //	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
 //	@Failure		500			{object}	problemdetail.ProblemDetail
-//	@Router			/v1/testers/{testerID}/update-role [post]
+//	@Router			/v1/projects/{projectID}/testers/{testerID}/update-role [post]
 func UpdateTesterRole(testerService services.TesterService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		projectID, err := c.ParamsInt("projectID", 0)
+		if err != nil || projectID == 0 {
+			return problemdetail.BadRequest(c, "invalid project ID")
+		}
 		testerID, err := c.ParamsInt("testerID", 0)
 		if err != nil || testerID == 0 {
 			return problemdetail.BadRequest(c, "invalid tester ID")
@@ -190,9 +211,9 @@ func UpdateTesterRole(testerService services.TesterService, logger logging.Logge
 			return problemdetail.BadRequest(c, "validation failed")
 		}
 
-		err = testerService.UpdateRole(c.Context(), int32(testerID), request.Role)
+		err = testerService.UpdateRole(c.UserContext(), int64(projectID), int32(testerID), request.Role)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+			if errors.Is(err, services.ErrNotFound) {
 				return problemdetail.NotFound(c, "tester not found")
 			}
 			logger.Error(loggedmodule.ApiTesters, "failed to update tester role", "error", err)
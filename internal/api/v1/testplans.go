@@ -2,7 +2,6 @@
 package v1
 
 import (
-	"context"
 	"database/sql"
 	"errors"
 	"strconv"
@@ -31,7 +30,7 @@ import (
 //	@Router			/v1/test-plans [get]
 func ListTestPlans(testPlanService services.TestPlanService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		testPlans, err := testPlanService.FindAll(c.Context())
+		testPlans, err := testPlanService.FindAll(c.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to fetch test plans", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to fetch test plans")
@@ -62,7 +61,7 @@ func SearchTestPlans(testPlanService services.TestPlanService, logger logging.Lo
 			logger.Error(loggedmodule.ApiTestPlans, "invalid parameter query", "error", err)
 			return problemdetail.BadRequest(c, "invalid or missing projectID parameter in query")
 		}
-		testPlans, err := testPlanService.FindAllByProjectID(c.Context(), int64(projectID))
+		testPlans, err := testPlanService.FindAllByProjectID(c.UserContext(), int64(projectID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				return c.JSON(schema.TestPlanListResponse{})
@@ -95,7 +94,7 @@ func GetOneTestPlan(testPlanService services.TestPlanService, logger logging.Log
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse id from path")
 		}
-		testPlan, err := testPlanService.GetOneTestPlan(c.Context(), int64(testPlanID))
+		testPlan, err := testPlanService.GetOneTestPlan(c.UserContext(), int64(testPlanID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -125,7 +124,7 @@ func GetTestPlanTestRuns(testPlanService services.TestPlanService, logger loggin
 		if err != nil {
 			return problemdetail.BadRequest(c, "failed to parse test plan id from path")
 		}
-		testRuns, err := testPlanService.FindAllByTestPlanID(c.Context(), int32(testPlanID))
+		testRuns, err := testPlanService.FindAllByTestPlanID(c.UserContext(), int32(testPlanID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info(loggedmodule.ApiTestPlans, "test run not found", "error", err)
@@ -176,7 +175,7 @@ func CreateTestPlan(testPlanService services.TestPlanService, logger logging.Log
 		request.AssignedToID = userID
 		request.UpdatedByID = userID
 
-		_, err := testPlanService.Create(context.Background(), request)
+		_, err := testPlanService.Create(c.UserContext(), request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -221,7 +220,7 @@ func UpdateTestPlan(testPlanService services.TestPlanService, logger logging.Log
 		request.AssignedToID = userID
 		request.UpdatedByID = userID
 
-		_, err := testPlanService.Update(c.Context(), *request)
+		_, err := testPlanService.Update(c.UserContext(), *request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -249,7 +248,7 @@ func UpdateTestPlan(testPlanService services.TestPlanService, logger logging.Log
 func DeleteTestPlan(testPlanService services.TestPlanService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		testPlanID, _ := c.ParamsInt("testPlanID", 0)
-		err := testPlanService.DeleteByID(context.Background(), int64(testPlanID))
+		err := testPlanService.DeleteByID(c.UserContext(), int64(testPlanID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to delete test plan", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -265,7 +264,7 @@ func DeleteTestPlan(testPlanService services.TestPlanService, logger logging.Log
 //
 //	@ID				AssignTestsToPlan
 //	@Summary		Assign a test to a plan
-//	@Description	Assign a test to a plan
+//	@Description	Assign a test to a plan, test cases with a dataset get a test run per row of the dataset
 //	@Tags			test-plans
 //	@Accept			json
 //	@Produce		json
@@ -291,7 +290,7 @@ func AssignTestsToPlan(testPlanService services.TestPlanService, logger logging.
 			return problemdetail.BadRequest(c, "plan_id in request body and param do not match")
 		}
 
-		_, err := testPlanService.AddTestCaseToPlan(context.Background(), request)
+		_, err := testPlanService.AddTestCaseToPlan(c.UserContext(), request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -323,7 +322,7 @@ func GetTestPlanTestCases(testCaseService services.TestCaseService, logger loggi
 			return problemdetail.BadRequest(c, "invalid testPlanID in request")
 		}
 
-		testCases, err := testCaseService.FindAllByTestPlanID(c.Context(), int64(testPlanID))
+		testCases, err := testCaseService.FindAllByTestPlanID(c.UserContext(), int64(testPlanID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info(loggedmodule.ApiTestPlans, "test run not found", "error", err)
@@ -357,7 +356,7 @@ func CloseTestPlan(testPlanSevice services.TestPlanService, logger logging.Logge
 			return problemdetail.BadRequest(c, "invalid testPlanID in request")
 		}
 
-		err = testPlanSevice.CloseTestPlan(c.Context(), int32(testPlanID))
+		err = testPlanSevice.CloseTestPlan(c.UserContext(), int32(testPlanID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to close test plan", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to close test plan")
@@ -397,7 +396,7 @@ func ChangeEnvironment(testPlanService services.TestPlanService, logger logging.
 			return problemdetail.BadRequest(c, "invalid testPlaID in request")
 		}
 
-		err = testPlanService.ChangeEnvironment(c.Context(), int64(testPlanID), request.EnvironmentID)
+		err = testPlanService.ChangeEnvironment(c.UserContext(), int64(testPlanID), request.EnvironmentID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to update environment", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to update environment")
@@ -426,7 +425,7 @@ func ListTestPlanComments(testPlanService services.TestPlanService, logger loggi
 		if err != nil || planID <= 0 {
 			return problemdetail.BadRequest(c, "invalid testPlanID")
 		}
-		comments, err := testPlanService.ListComments(c.Context(), int64(planID))
+		comments, err := testPlanService.ListComments(c.UserContext(), int64(planID))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to list comments", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to list comments")
@@ -468,7 +467,7 @@ func CreateTestPlanComment(testPlanService services.TestPlanService, logger logg
 		}
 
 		// Verify that the test plan exists before proceeding
-		_, err := testPlanService.GetOneTestPlan(c.Context(), req.TestPlanID)
+		_, err := testPlanService.GetOneTestPlan(c.UserContext(), req.TestPlanID)
 		if err != nil {
 			if errors.Is(err, services.ErrNotFound) {
 				return problemdetail.NotFound(c, "test plan not found")
@@ -477,7 +476,7 @@ func CreateTestPlanComment(testPlanService services.TestPlanService, logger logg
 			return problemdetail.ServerErrorProblem(c, "failed to verify test plan existence")
 		}
 
-		comment, err := testPlanService.CreateComment(c.Context(), req)
+		comment, err := testPlanService.CreateComment(c.UserContext(), req)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to create comment", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to create comment")
@@ -502,7 +501,7 @@ func CreateTestPlanComment(testPlanService services.TestPlanService, logger logg
 func DeleteTestPlanComment(testPlanService services.TestPlanService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		commentID := c.Params("commentID")
-		if err := testPlanService.DeleteComment(c.Context(), commentID); err != nil {
+		if err := testPlanService.DeleteComment(c.UserContext(), commentID); err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to delete comment", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to delete comment")
 		}
@@ -525,7 +524,7 @@ func DeleteTestPlanComment(testPlanService services.TestPlanService, logger logg
 func ConvertCommentToTestCase(testPlanService services.TestPlanService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		commentID := c.Params("commentID")
-		newID, err := testPlanService.ConvertCommentToTestCase(c.Context(), commentID)
+		newID, err := testPlanService.ConvertCommentToTestCase(c.UserContext(), commentID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to convert comment", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to convert comment")
@@ -550,7 +549,7 @@ func BatchAssignTestCasesToPlan(testPlanService services.TestPlanService, logger
 			return problemdetail.BadRequest(c, "plan_id in request body and param do not match")
 		}
 
-		_, err := testPlanService.BatchAssignTestCasesToPlan(c.Context(), request)
+		_, err := testPlanService.BatchAssignTestCasesToPlan(c.UserContext(), request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestPlans, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -2,7 +2,6 @@
 package v1
 
 import (
-	"context"
 	"database/sql"
 	"errors"
 	"strconv"
@@ -34,7 +33,7 @@ import (
 //	@Router			/v1/test-runs [get]
 func ListTestRuns(testRunService services.TestRunService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		testRuns, err := testRunService.FindAll(context.Background())
+		testRuns, err := testRunService.FindAll(c.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "failed to list test runs")
 			return problemdetail.ServerErrorProblem(c, "failed to fetch test runs")
@@ -65,7 +64,7 @@ func SearchTestRuns(testRunService services.TestRunService, logger logging.Logge
 			return problemdetail.BadRequest(c, "invalid or missing projectID parameter in query")
 		}
 
-		testRun, err := testRunService.FindAllByProjectID(c.Context(), int64(projectID))
+		testRun, err := testRunService.FindAllByProjectID(c.UserContext(), int64(projectID))
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info(loggedmodule.ApiTestRuns, "test run not found", "error", err)
@@ -95,7 +94,7 @@ func SearchTestRuns(testRunService services.TestRunService, logger logging.Logge
 func GetOneTestRun(testRunService services.TestRunService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		testRunID := c.Params("testRunID")
-		testRun, err := testRunService.GetOneTestRun(c.Context(), testRunID)
+		testRun, err := testRunService.GetOneTestRun(c.UserContext(), testRunID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "failed to get a test run", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -130,7 +129,7 @@ func CreateTestRun(testRunService services.TestRunService, logger logging.Logger
 
 		}
 
-		testRun, err := testRunService.Create(c.Context(), request)
+		testRun, err := testRunService.Create(c.UserContext(), request)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "failed to process request", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
@@ -179,7 +178,7 @@ func DeleteTestRun(testRunService services.TestRunService, logger logging.Logger
 		if testRunID == "" {
 			return problemdetail.BadRequest(c, "invalid parameter in url for testRunID")
 		}
-		err := testRunService.DeleteByID(context.Background(), testRunID)
+		err := testRunService.DeleteByID(c.UserContext(), testRunID)
 		if err != nil {
 			return problemdetail.ServerErrorProblem(c, "failed to process request")
 		}
@@ -217,9 +216,19 @@ func CommitTestRun(testRunService services.TestRunService, logger logging.Logger
 			return problemdetail.BadRequest(ctx, "'test_run_id' in request body and parameter does not match")
 		}
 		request.UserID = authutil.GetAuthUserID(ctx)
+		if request.CustomFields == nil {
+			// checks the required custom fields are filled in
+			request.CustomFields = map[string]any{}
+		}
 
-		testRun, err := testRunService.Commit(context.Background(), request)
+		testRun, err := testRunService.Commit(ctx.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(ctx, "invalid custom field values", fieldErrs)
+			}
+			if errors.Is(err, services.ErrInvalidTestRunStep) {
+				return problemdetail.BadRequest(ctx, err.Error())
+			}
 			logger.Error(loggedmodule.ApiTestRuns, "failed to commit test-run results", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
 		}
@@ -256,9 +265,19 @@ func RecordTestRunFeedback(testRunService services.TestRunService, logger loggin
 			return problemdetail.BadRequest(ctx, "'test_run_id' in request body and parameter does not match")
 		}
 		request.UserID = authutil.GetAuthUserID(ctx)
+		if request.CustomFields == nil {
+			// checks the required custom fields are filled in
+			request.CustomFields = map[string]any{}
+		}
 
-		testRun, err := testRunService.Commit(context.Background(), request)
+		testRun, err := testRunService.Commit(ctx.UserContext(), request)
 		if err != nil {
+			if fieldErrs, ok := customFieldErrors(err); ok {
+				return problemdetail.ValidationErrors(ctx, "invalid custom field values", fieldErrs)
+			}
+			if errors.Is(err, services.ErrInvalidTestRunStep) {
+				return problemdetail.BadRequest(ctx, err.Error())
+			}
 			logger.Error(loggedmodule.ApiTestRuns, "failed to record feedback", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failed to record feedback")
 		}
@@ -275,7 +294,7 @@ func CommitBulkTestRun(testRunService services.TestRunService, logger logging.Lo
 			return problemdetail.ValidationErrors(ctx, "invalid data in the request", err)
 		}
 		request.UserID = authutil.GetAuthUserID(ctx)
-		_, err = testRunService.CommitBulk(context.Background(), request)
+		_, err = testRunService.CommitBulk(ctx.UserContext(), request)
 		if err != nil {
 			logger.Debug(loggedmodule.ApiTestRuns, "failed to commit bulk test results", "error", err)
 			return problemdetail.ServerErrorProblem(ctx, "failed to process request")
@@ -322,7 +341,7 @@ func ExecuteTestRun(testRunService services.TestRunService, testCaseService serv
 		request.ExecutedBy = userID
 
 		// Validation checks
-		tr, err := testRunService.GetOneTestRun(c.Context(), pathID)
+		tr, err := testRunService.GetOneTestRun(c.UserContext(), pathID)
 		if errors.Is(err, sql.ErrNoRows) {
 			logger.Info(loggedmodule.ApiTestRuns, "test run not found", "testRunID", pathID)
 			return problemdetail.BadRequest(c, "test run not found")
@@ -342,7 +361,7 @@ func ExecuteTestRun(testRunService services.TestRunService, testCaseService serv
 		}
 
 		// Check if test plan is active
-		planActive, err := testRunService.IsTestPlanActive(c.Context(), int64(tr.TestPlanID.Int32))
+		planActive, err := testRunService.IsTestPlanActive(c.UserContext(), int64(tr.TestPlanID.Int32))
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "db error fetching test plan", "testPlanID", tr.TestPlanID, "testRunID", pathID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "error fetching test plan")
@@ -352,7 +371,7 @@ func ExecuteTestRun(testRunService services.TestRunService, testCaseService serv
 		}
 
 		// Check test case active
-		caseActive, err := testRunService.IsTestCaseActive(c.Context(), tr.TestCaseID.String())
+		caseActive, err := testRunService.IsTestCaseActive(c.UserContext(), tr.TestCaseID.String())
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "db error fetching test case", "testRunID", pathID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "error fetching test case")
@@ -375,7 +394,7 @@ func ExecuteTestRun(testRunService services.TestRunService, testCaseService serv
 
 		// Pick the correct WebSocket URL based on runner type
 		var wsURL string
-		tc, err := testCaseService.FindByID(c.Context(), tr.TestCaseID.String())
+		tc, err := testCaseService.FindByID(c.UserContext(), tr.TestCaseID.String())
 		if err != nil {
 			return problemdetail.ServerErrorProblem(c, "failed to fetch test case for runner info")
 		}
@@ -404,7 +423,7 @@ func ExecuteTestRun(testRunService services.TestRunService, testCaseService serv
 			return problemdetail.ServerErrorProblem(c, "failed to execute runner stream")
 		}
 
-		updatedRun, _ := testRunService.GetOneTestRun(c.Context(), pathID)
+		updatedRun, _ := testRunService.GetOneTestRun(c.UserContext(), pathID)
 		return c.JSON(fiber.Map{
 			"test_run": schema.NewTestRunResponseFromEntity(updatedRun),
 		})
@@ -430,7 +449,7 @@ func CloseTestRun(testRunService services.TestRunService, logger logging.Logger)
 		if testRunID == "" {
 			return problemdetail.BadRequest(c, "missing testRunID")
 		}
-		tr, err := testRunService.CloseTestRun(c.Context(), testRunID)
+		tr, err := testRunService.CloseTestRun(c.UserContext(), testRunID)
 		if err != nil {
 			logger.Error(loggedmodule.ApiTestRuns, "failed to close test run", "testRunID", testRunID, "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to close test run")
@@ -441,6 +460,41 @@ func CloseTestRun(testRunService services.TestRunService, logger logging.Logger)
 	}
 }
 
+// ResumeTestRun godoc
+//
+//	@ID				ResumeTestRun
+//	@Summary		Resume a partially executed Test Run
+//	@Description	Get the results of the steps of a Test Run and the step to continue from. The steps of the test case are copied to the run when it is first resumed
+//	@Tags			test-runs
+//	@Accept			json
+//	@Produce		json
+//	@Param			testRunID	path		string	true	"Test Run ID"
+//	@Success		200			{object}	schema.TestRunProgressResponse
+//	@Failure		400			{object}	problemdetail.ProblemDetail
+//	@Failure		404			{object}	problemdetail.ProblemDetail
+//	@Failure		500			{object}	problemdetail.ProblemDetail
+//	@Router			/v1/test-runs/{testRunID}/resume [post]
+func ResumeTestRun(testRunService services.TestRunService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		testRunID := c.Params("testRunID", "")
+		if testRunID == "" {
+			return problemdetail.BadRequest(c, "missing testRunID")
+		}
+		progress, err := testRunService.Resume(c.UserContext(), testRunID)
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "test run not found")
+			}
+			if errors.Is(err, services.ErrTestRunClosed) {
+				return problemdetail.BadRequest(c, "test run is closed")
+			}
+			logger.Error(loggedmodule.ApiTestRuns, "failed to resume test run", "testRunID", testRunID, "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to resume test run")
+		}
+		return c.JSON(progress)
+	}
+}
+
 func StreamTestRunLogs(testRunService services.TestRunService, logger logging.Logger) fiber.Handler {
 	return websocket.New(func(c *websocket.Conn) {
 		runID := c.Params("testRunID")
@@ -0,0 +1,233 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-malawi/qatarina/internal/api/authutil"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/pkg/problemdetail"
+)
+
+// twoFactorProblem maps two-factor errors to problem details
+func twoFactorProblem(c *fiber.Ctx, logger logging.Logger, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode),
+		errors.Is(err, services.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, services.ErrTwoFactorNotEnabled):
+		return problemdetail.BadRequest(c, err.Error())
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		return problemdetail.Forbidden(c, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		return problemdetail.NotFound(c, "user not found")
+	}
+	logger.Error(loggedmodule.ApiAuth, message, "error", err)
+	return problemdetail.ServerErrorProblem(c, message)
+}
+
+// TwoFactorLogin godoc
+//
+//	@ID				TwoFactorLogin
+//	@Summary		Finish a login with a second factor
+//	@Description	Exchanges the challenge token returned by the login endpoint and a TOTP or recovery code for access tokens.
+//	@Description	When the login required enrolment the code confirms the new authenticator and the response includes the recovery codes
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.TwoFactorLoginRequest	true	"Challenge token and code"
+//	@Success		200		{object}	schema.LoginResponse
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		401		{object}	problemdetail.ProblemDetail	"Invalid code or expired challenge"
+//	@Failure		429		{object}	problemdetail.ProblemDetail	"Too many failed attempts"
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/login/2fa [post]
+func TwoFactorLogin(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.TwoFactorLoginRequest
+		if _, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+		}
+		request.UserAgent = c.Get(fiber.HeaderUserAgent)
+		request.IPAddress = c.IP()
+
+		loginData, err := authService.VerifyTwoFactorLogin(c.Context(), &request)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidTwoFactorCode),
+				errors.Is(err, services.ErrInvalidTwoFactorChallenge),
+				errors.Is(err, services.ErrTwoFactorNotEnabled):
+				return problemdetail.NotAuthorizedProblem(c, err.Error())
+			case errors.Is(err, services.ErrLoginLocked):
+				return problemdetail.TooManyRequests(c, err.Error())
+			}
+			logger.Error(loggedmodule.ApiAuth, "failed to verify two-factor login", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to process request")
+		}
+
+		return c.JSON(loginData)
+	}
+}
+
+// TwoFactorLoginEnrol godoc
+//
+//	@ID				TwoFactorLoginEnrol
+//	@Summary		Enrol an authenticator during login
+//	@Description	Creates a TOTP secret for a user whose organization requires two-factor authentication,
+//	@Description	the login is finished by sending a code from the authenticator to /v1/auth/login/2fa
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.TwoFactorChallengeRequest	true	"Challenge token"
+//	@Success		200		{object}	schema.TwoFactorEnrolment
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		401		{object}	problemdetail.ProblemDetail	"Expired challenge"
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/login/2fa/enrol [post]
+func TwoFactorLoginEnrol(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.TwoFactorChallengeRequest
+		if _, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+		}
+
+		enrolment, err := authService.BeginChallengeEnrolment(c.Context(), request.ChallengeToken)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTwoFactorChallenge) {
+				return problemdetail.NotAuthorizedProblem(c, err.Error())
+			}
+			return twoFactorProblem(c, logger, err, "failed to start two-factor enrolment")
+		}
+
+		return c.JSON(enrolment)
+	}
+}
+
+// GetTwoFactorStatus godoc
+//
+//	@ID				GetTwoFactorStatus
+//	@Summary		Get two-factor authentication status
+//	@Description	Reports whether two-factor authentication is enabled or required for the authenticated user
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	schema.TwoFactorStatus
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/2fa [get]
+func GetTwoFactorStatus(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status, err := authService.TwoFactorStatus(c.Context(), authutil.GetAuthUserID(c))
+		if err != nil {
+			return twoFactorProblem(c, logger, err, "failed to get two-factor status")
+		}
+		return c.JSON(status)
+	}
+}
+
+// EnrolTwoFactor godoc
+//
+//	@ID				EnrolTwoFactor
+//	@Summary		Start two-factor enrolment
+//	@Description	Creates a TOTP secret and provisioning URI for an authenticator app, it is used for logins once confirmed
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	schema.TwoFactorEnrolment
+//	@Failure		400	{object}	problemdetail.ProblemDetail	"Two-factor authentication already enabled"
+//	@Failure		500	{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/2fa/enrol [post]
+func EnrolTwoFactor(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		enrolment, err := authService.BeginTwoFactorEnrolment(c.Context(), authutil.GetAuthUserID(c))
+		if err != nil {
+			return twoFactorProblem(c, logger, err, "failed to start two-factor enrolment")
+		}
+		return c.JSON(enrolment)
+	}
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@ID				ConfirmTwoFactor
+//	@Summary		Confirm two-factor enrolment
+//	@Description	Enables two-factor authentication using a code from the authenticator and returns the recovery codes, which are only shown once
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.TwoFactorCodeRequest	true	"Code from the authenticator"
+//	@Success		200		{object}	schema.RecoveryCodesResponse
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/2fa/confirm [post]
+func ConfirmTwoFactor(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.TwoFactorCodeRequest
+		if _, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+		}
+
+		res, err := authService.ConfirmTwoFactorEnrolment(c.Context(), authutil.GetAuthUserID(c), request.Code)
+		if err != nil {
+			return twoFactorProblem(c, logger, err, "failed to confirm two-factor enrolment")
+		}
+		return c.JSON(res)
+	}
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@ID				RegenerateRecoveryCodes
+//	@Summary		Regenerate recovery codes
+//	@Description	Replaces the recovery codes of the authenticated user, the old codes stop working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.TwoFactorCodeRequest	true	"Code from the authenticator"
+//	@Success		200		{object}	schema.RecoveryCodesResponse
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.TwoFactorCodeRequest
+		if _, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+		}
+
+		res, err := authService.RegenerateRecoveryCodes(c.Context(), authutil.GetAuthUserID(c), request.Code)
+		if err != nil {
+			return twoFactorProblem(c, logger, err, "failed to regenerate recovery codes")
+		}
+		return c.JSON(res)
+	}
+}
+
+// DisableTwoFactor godoc
+//
+//	@ID				DisableTwoFactor
+//	@Summary		Disable two-factor authentication
+//	@Description	Turns off two-factor authentication for the authenticated user, not allowed when an organization of the user requires it
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		schema.TwoFactorCodeRequest	true	"TOTP or recovery code"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail	"Required by the organization"
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/auth/2fa [delete]
+func DisableTwoFactor(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request schema.TwoFactorCodeRequest
+		if _, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			return problemdetail.ValidationErrors(c, "invalid data in the request", err)
+		}
+
+		err := authService.DisableTwoFactor(c.Context(), authutil.GetAuthUserID(c), request.Code)
+		if err != nil {
+			return twoFactorProblem(c, logger, err, "failed to disable two-factor authentication")
+		}
+		return c.JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+	}
+}
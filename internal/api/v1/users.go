@@ -22,7 +22,7 @@ import (
 //
 //	@ID				ListUsers
 //	@Summary		List all Users
-//	@Description	List all Users
+//	@Description	List the Users in the current organization
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -32,7 +32,7 @@ import (
 //	@Router			/v1/users [get]
 func ListUsers(userService services.UserService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		users, err := userService.FindAll(context.Background())
+		users, err := userService.FindAll(c.UserContext())
 		if err != nil {
 			logger.Error(loggedmodule.ApiUsers, "failed to load users", "error", err)
 			return problemdetail.ServerErrorProblem(c, "failed to load users")
@@ -46,7 +46,7 @@ func ListUsers(userService services.UserService, logger logging.Logger) fiber.Ha
 //
 //	@ID				SearchUsers
 //	@Summary		Search all Users
-//	@Description	Search all Users
+//	@Description	Search the Users in the current organization
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -61,7 +61,7 @@ func SearchUsers(userService services.UserService, logger logging.Logger) fiber.
 			return problemdetail.BadRequest(q, "missing keyword parameter")
 		}
 
-		users, err := userService.Search(q.Context(), keyword)
+		users, err := userService.Search(q.UserContext(), keyword)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Error("error", "search error:", err)
@@ -85,6 +85,7 @@ func SearchUsers(userService services.UserService, logger logging.Logger) fiber.
 //	@Param			userID	path		string	true	"User ID"
 //	@Success		200		{object}	schema.User
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/users/{userID} [get]
 func GetOneUser(userService services.UserService, logger logging.Logger) fiber.Handler {
@@ -94,8 +95,11 @@ func GetOneUser(userService services.UserService, logger logging.Logger) fiber.H
 			return problemdetail.BadRequest(c, "failed to parse id data in request")
 		}
 
-		user, err := userService.GetOne(c.Context(), int32(userID))
+		user, err := userService.GetOne(c.UserContext(), int32(userID))
 		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "no user found")
+			}
 			if errors.Is(err, sql.ErrNoRows) {
 				logger.Info("apiv1:users", "user not found", "error", err)
 				return problemdetail.BadRequest(c, "no user found")
@@ -150,7 +154,7 @@ func CreateUser(userService services.UserService, logger logging.Logger) fiber.H
 //
 //	@ID				UpdateUser
 //	@Summary		Update a User
-//	@Description	Update a User
+//	@Description	Update a User, only super admins can update other users
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -158,6 +162,7 @@ func CreateUser(userService services.UserService, logger logging.Logger) fiber.H
 //	@Param			request	body		schema.UpdateUserRequest	true	"User ID"
 //	@Success		200		{object}	interface{}
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/users/{userID} [post]
 func UpdateUser(userService services.UserService, logger logging.Logger) fiber.Handler {
@@ -175,8 +180,14 @@ func UpdateUser(userService services.UserService, logger logging.Logger) fiber.H
 			return problemdetail.BadRequest(c, "failed to pass id data in request")
 		}
 		request.ID = int32(userID)
-		_, err = userService.Update(c.Context(), *request)
+		_, err = userService.Update(c.UserContext(), authutil.GetAuthUserID(c), *request)
 		if err != nil {
+			if errors.Is(err, services.ErrForbidden) {
+				return problemdetail.Forbidden(c, "only super admins can update other users")
+			}
+			if errors.Is(err, services.ErrNotOrgMember) {
+				return problemdetail.BadRequest(c, err.Error())
+			}
 			logger.Error("apiv1:users", "failed to process request", "error", err)
 			return problemdetail.BadRequest(c, "failed to process request")
 		}
@@ -190,48 +201,56 @@ func UpdateUser(userService services.UserService, logger logging.Logger) fiber.H
 //
 //	@ID				InviteUser
 //	@Summary		Invite a User by email
-//	@Description	Invite a User by email
+//	@Description	Invite a User by email to the org of the authenticated user
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			email	path		string		true	"User's email"
-//	@Param			request	body		interface{}	true	"User invite data"
-//	@Success		200		{object}	interface{}
+//	@Param			email	path		string	true	"User's email"
+//	@Success		200		{object}	schema.Invite
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/users/invite/{email} [post]
-func InviteUser(userSevice services.UserService) fiber.Handler {
+func InviteUser(inviteService services.InviteService, logger logging.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		receiverEmail := c.Params("email")
 		if receiverEmail == "" {
 			return problemdetail.BadRequest(c, "No email address in request")
 		}
-		senderEmail := authutil.GetAuthUserEmail(c)
-		if senderEmail == "" {
-			return problemdetail.BadRequest(c, "Sender not auntheticated")
-		}
+		return createInvite(c, inviteService, logger, &schema.CreateInviteRequest{Email: receiverEmail})
+	}
+}
 
-		err := userSevice.Invite(c.Context(), senderEmail, receiverEmail)
-		if err != nil {
-			return problemdetail.BadRequest(c, "failed to send invite")
-		}
-		return c.JSON(fiber.Map{
-			"message": "Invite sent",
-		})
+// userLifecycleProblem maps deactivation and offboarding errors to problem details
+func userLifecycleProblem(c *fiber.Ctx, logger logging.Logger, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return problemdetail.NotFound(c, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return problemdetail.Forbidden(c, "only administrators can deactivate other users")
+	case errors.Is(err, services.ErrLastSuperAdmin),
+		errors.Is(err, services.ErrUserNotActive),
+		errors.Is(err, services.ErrReassignToSameUser):
+		return problemdetail.BadRequest(c, err.Error())
 	}
+	logger.Error(loggedmodule.ApiUsers, message, "error", err)
+	return problemdetail.ServerErrorProblem(c, message)
 }
 
 // DeleteUser godoc
 //
 //	@ID				DeleteUser
-//	@Summary		Delete a user
-//	@Description	Delete a user
+//	@Summary		Deactivate a user
+//	@Description	Deactivates a user so they can no longer log in or refresh their tokens. Accounts are kept so the work they authored stays attributed to them.
+//	@Description	Users can deactivate themselves, deactivating others needs a super admin
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			userID	path		string	true	"User ID"
 //	@Success		200		{object}	interface{}
 //	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
 //	@Failure		500		{object}	problemdetail.ProblemDetail
 //	@Router			/v1/users/{userID} [delete]
 func DeleteUser(userService services.UserService, logger logging.Logger) fiber.Handler {
@@ -239,19 +258,192 @@ func DeleteUser(userService services.UserService, logger logging.Logger) fiber.H
 		userIDParam := c.Params("userID")
 		userID, err := strconv.Atoi(userIDParam)
 		if err != nil {
-			logger.Error("Failed to retrieve user id", "error", err)
+			logger.Error(loggedmodule.ApiUsers, "Failed to retrieve user id", "error", err)
 			return problemdetail.BadRequest(c, "failed to process request id")
 		}
 
-		err = userService.Delete(c.Context(), int32(userID))
+		err = userService.Deactivate(c.Context(), authutil.GetAuthUserID(c), int64(userID))
 		if err != nil {
-			logger.Error("apiv1:users", "failed to delete user", "error", err)
-			return problemdetail.BadRequest(c, "failed to delete user")
+			return userLifecycleProblem(c, logger, err, "failed to deactivate user")
 		}
 
 		return c.JSON(fiber.Map{
-			"message": "User deleted successfully",
+			"message": "User deactivated successfully",
 			"userID":  userID,
 		})
 	}
 }
+
+// ReactivateUser godoc
+//
+//	@ID				ReactivateUser
+//	@Summary		Reactivate a user
+//	@Description	Lets a deactivated user log in again
+//	@Tags			users
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/users/{userID}/reactivate [post]
+func ReactivateUser(userService services.UserService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to process request id")
+		}
+
+		err = userService.Reactivate(c.Context(), authutil.GetAuthUserID(c), int64(userID))
+		if err != nil {
+			return userLifecycleProblem(c, logger, err, "failed to reactivate user")
+		}
+		return c.JSON(fiber.Map{"message": "User reactivated successfully"})
+	}
+}
+
+// PreviewUserOffboarding godoc
+//
+//	@ID				PreviewUserOffboarding
+//	@Summary		Preview offboarding a user
+//	@Description	Lists the open test plans, test plan cases and test runs of the user that offboarding would hand over,
+//	@Description	and the projects the new assignee would join as a tester
+//	@Tags			users
+//	@Produce		json
+//	@Param			userID			path		string	true	"User ID"
+//	@Param			reassign_to_id	query		int		true	"User who takes over the work"
+//	@Success		200				{object}	schema.OffboardingPreview
+//	@Failure		400				{object}	problemdetail.ProblemDetail
+//	@Failure		403				{object}	problemdetail.ProblemDetail
+//	@Failure		404				{object}	problemdetail.ProblemDetail
+//	@Failure		500				{object}	problemdetail.ProblemDetail
+//	@Router			/v1/users/{userID}/offboarding [get]
+func PreviewUserOffboarding(userService services.UserService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to process request id")
+		}
+		reassignToID := c.QueryInt("reassign_to_id", 0)
+		if reassignToID <= 0 {
+			return problemdetail.BadRequest(c, "reassign_to_id is required")
+		}
+
+		preview, err := userService.PreviewOffboarding(c.Context(), int64(userID), int64(reassignToID))
+		if err != nil {
+			return userLifecycleProblem(c, logger, err, "failed to preview offboarding")
+		}
+		return c.JSON(preview)
+	}
+}
+
+// OffboardUser godoc
+//
+//	@ID				OffboardUser
+//	@Summary		Offboard a user
+//	@Description	Hands the open test plans, test plan cases and test runs of the user over to another user, adding them as a tester
+//	@Description	where needed, and deactivates the user when requested. Closed work keeps its original assignee
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string						true	"User ID"
+//	@Param			request	body		schema.OffboardUserRequest	true	"Offboarding data"
+//	@Success		200		{object}	schema.OffboardingResult
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/users/{userID}/offboard [post]
+func OffboardUser(userService services.UserService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to process request id")
+		}
+
+		var request schema.OffboardUserRequest
+		if validationErrors, err := common.ParseBodyThenValidate(c, &request); err != nil {
+			if validationErrors {
+				return problemdetail.ValidationErrors(c, "invalid data in request", err)
+			}
+			return problemdetail.BadRequest(c, "failed to parse data in request")
+		}
+
+		result, err := userService.Offboard(c.Context(), authutil.GetAuthUserID(c), int64(userID), &request)
+		if err != nil {
+			return userLifecycleProblem(c, logger, err, "failed to offboard user")
+		}
+		return c.JSON(result)
+	}
+}
+
+// UnlockUser godoc
+//
+//	@ID				UnlockUser
+//	@Summary		Unlock a user account
+//	@Description	Clear a lockout caused by too many failed login attempts
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/users/{userID}/unlock [post]
+func UnlockUser(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to process request id")
+		}
+
+		err = authService.UnlockAccount(c.Context(), authutil.GetAuthUserID(c), int64(userID))
+		if err != nil {
+			if errors.Is(err, services.ErrNotFound) {
+				return problemdetail.NotFound(c, "user not found")
+			}
+			logger.Error(loggedmodule.ApiUsers, "failed to unlock user", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to unlock user")
+		}
+
+		return c.JSON(fiber.Map{"message": "User unlocked successfully"})
+	}
+}
+
+// ResetUserTwoFactor godoc
+//
+//	@ID				ResetUserTwoFactor
+//	@Summary		Reset two-factor authentication of a user
+//	@Description	Removes the authenticator and recovery codes of a user who lost access to them and signs them out of all sessions
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		string	true	"User ID"
+//	@Success		200		{object}	map[string]string
+//	@Failure		400		{object}	problemdetail.ProblemDetail
+//	@Failure		403		{object}	problemdetail.ProblemDetail
+//	@Failure		404		{object}	problemdetail.ProblemDetail
+//	@Failure		500		{object}	problemdetail.ProblemDetail
+//	@Router			/v1/users/{userID}/2fa [delete]
+func ResetUserTwoFactor(authService services.AuthService, logger logging.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := c.ParamsInt("userID", 0)
+		if err != nil {
+			return problemdetail.BadRequest(c, "failed to process request id")
+		}
+
+		err = authService.ResetTwoFactor(c.Context(), authutil.GetAuthUserID(c), int64(userID))
+		if err != nil {
+			if errors.Is(err, services.ErrTwoFactorNotEnabled) {
+				return problemdetail.NotFound(c, "user does not have two-factor authentication")
+			}
+			logger.Error(loggedmodule.ApiUsers, "failed to reset two-factor authentication", "error", err)
+			return problemdetail.ServerErrorProblem(c, "failed to reset two-factor authentication")
+		}
+
+		return c.JSON(fiber.Map{"message": "Two-factor authentication reset successfully"})
+	}
+}
@@ -66,6 +66,14 @@ func FormatSqlDateTime(t interface{}) string {
 	return ""
 }
 
+// TimeOrNil is the inverse of NullTime(ZeroOrTime(t))
+func TimeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
 func ZeroOrTime(t *time.Time) time.Time {
 	if t == nil {
 		return time.Time{}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -10,6 +12,8 @@ import (
 )
 
 type Config struct {
+	// DevMode relaxes checks meant for production deployments, like refusing the default secret
+	DevMode    bool                    `mapstructure:"dev_mode" envconfig:"QATARINA_DEV_MODE"`
 	Server     HTTPServerConfiguration `mapstructure:"server"`
 	Auth       AuthConfiguration       `mapstructure:"auth"`
 	Database   DatabaseConfiguration   `mapstructure:"db"`
@@ -19,6 +23,9 @@ type Config struct {
 	ImportFile ImportFileConfiguration `mapstructure:"import_file"`
 	Runner     RunnerConfiguration     `mapstructure:"runner"`
 	Storage    StorageConfiguration    `mapstructure:"storage"`
+	OIDC       OIDCConfiguration       `mapstructure:"oidc"`
+	Admin      AdminConfiguration      `mapstructure:"admin"`
+	SCIM       SCIMConfiguration       `mapstructure:"scim"`
 }
 
 type DatabaseConfiguration struct {
@@ -59,13 +66,60 @@ type AuthConfiguration struct {
 	JwtSecretKey            string `mapstructure:"jwt_secret_key" envconfig:"QATARINA_AUTH_JWT_SECRET_KEY"`
 	JwtIssuer               string `mapstructure:"jwt_issuer" envconfig:"QATARINA_AUTH_JWT_ISSUER"`
 	JwtExpiryTimeout        int    `mapstructure:"jwt_expiry_timeout" envconfig:"QATARINA_AUTH_JWT_EXPIRY_TIMEOUT"`
+	RefreshTokenTimeout     int    `mapstructure:"refresh_token_timeout" envconfig:"QATARINA_AUTH_REFRESH_TOKEN_TIMEOUT"`
+	// SigningAlgorithm is used for signing keys generated automatically, RS256 or EdDSA
+	SigningAlgorithm string `mapstructure:"signing_algorithm" envconfig:"QATARINA_AUTH_SIGNING_ALGORITHM"`
 }
 
+// AdminConfiguration the first super admin, created on startup when the
+// database does not have one yet
 type AdminConfiguration struct {
-	Username string `mapstructure:"" envconfig:"QATARINA_ADMIN_USERNAME"`
-	Password string `mapstructure:"" envconfig:"QATARINA_ADMIN_PASSWORD"`
-	Email    string `mapstructure:"" envconfig:"QATARINA_ADMIN_EMAIL"`
-	Enabled  bool   `mapstructure:"" envconfig:"QATARINA_ADMIN_ENABLED"`
+	Username string `mapstructure:"username" envconfig:"QATARINA_ADMIN_USERNAME"`
+	Password string `mapstructure:"password" envconfig:"QATARINA_ADMIN_PASSWORD"`
+	Email    string `mapstructure:"email" envconfig:"QATARINA_ADMIN_EMAIL"`
+	Enabled  bool   `mapstructure:"enabled" envconfig:"QATARINA_ADMIN_ENABLED"`
+}
+
+// OIDCConfiguration configures single sign-on through an OpenID Connect identity provider
+type OIDCConfiguration struct {
+	Enabled      bool     `mapstructure:"enabled" envconfig:"QATARINA_OIDC_ENABLED"`
+	IssuerURL    string   `mapstructure:"issuer_url" envconfig:"QATARINA_OIDC_ISSUER_URL"`
+	ClientID     string   `mapstructure:"client_id" envconfig:"QATARINA_OIDC_CLIENT_ID"`
+	ClientSecret string   `mapstructure:"client_secret" envconfig:"QATARINA_OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `mapstructure:"redirect_url" envconfig:"QATARINA_OIDC_REDIRECT_URL"`
+	Scopes       []string `mapstructure:"scopes" envconfig:"QATARINA_OIDC_SCOPES"`
+	// GroupsClaim is the ID token claim holding the groups of the user
+	GroupsClaim string `mapstructure:"groups_claim" envconfig:"QATARINA_OIDC_GROUPS_CLAIM"`
+	// PostLoginRedirectURL is where the browser is sent with the tokens after login,
+	// the callback responds with JSON when it is empty
+	PostLoginRedirectURL string             `mapstructure:"post_login_redirect_url" envconfig:"QATARINA_OIDC_POST_LOGIN_REDIRECT_URL"`
+	GroupMappings        []OIDCGroupMapping `mapstructure:"group_mappings"`
+}
+
+// OIDCGroupMapping grants members of an identity provider group membership
+// of an org and/or a tester role on a project
+type OIDCGroupMapping struct {
+	Group       string `mapstructure:"group"`
+	OrgID       int32  `mapstructure:"org_id"`
+	OrgRole     string `mapstructure:"org_role"`
+	ProjectID   int32  `mapstructure:"project_id"`
+	ProjectRole string `mapstructure:"project_role"`
+}
+
+// CallbackURL returns the redirect URL registered at the identity provider,
+// defaulting to the callback endpoint on the public URL of the server
+func (o *OIDCConfiguration) CallbackURL(server *HTTPServerConfiguration) string {
+	if o.RedirectURL != "" {
+		return o.RedirectURL
+	}
+	return server.BaseURL() + "/v1/auth/oidc/callback"
+}
+
+// SCIMConfiguration enables the SCIM 2.0 endpoint on /scim/v2 which lets the
+// directory of an org provision its users, tokens are issued per org with
+// the scim tokens command
+type SCIMConfiguration struct {
+	Enabled bool `mapstructure:"enabled" envconfig:"QATARINA_SCIM_ENABLED"`
 }
 
 type PlatformConfiguration struct {
@@ -105,6 +159,33 @@ func (c *Config) ListenAddress() string {
 	return net.JoinHostPort(c.Server.Host, fmt.Sprint(c.Server.Port))
 }
 
+// BaseURL returns the public URL of the server used when building links sent
+// to users, the scheme defaults to https when the FQDN does not include one
+func (s *HTTPServerConfiguration) BaseURL() string {
+	if s.FQDN == "" {
+		return "https://qatarina.dev"
+	}
+	if strings.HasPrefix(s.FQDN, "http://") || strings.HasPrefix(s.FQDN, "https://") {
+		return strings.TrimSuffix(s.FQDN, "/")
+	}
+	return "https://" + strings.TrimSuffix(s.FQDN, "/")
+}
+
+// defaultSecretKey is the JwtSecretKey when none is configured
+const defaultSecretKey = "default"
+
+// CheckSecrets refuses an empty or default JwtSecretKey outside dev mode, the
+// key still protects short-lived tokens like email verification links
+func (c *Config) CheckSecrets() error {
+	if c.DevMode {
+		return nil
+	}
+	if c.Auth.JwtSecretKey == "" || c.Auth.JwtSecretKey == defaultSecretKey {
+		return errors.New("auth.jwt_secret_key must be set to a random secret, the default is only allowed with dev_mode enabled")
+	}
+	return nil
+}
+
 func (c *Config) OpenDB() *sqlx.DB {
 	db, err := sqlx.Open("pgx", c.GetDatabaseURL())
 	if err != nil {
@@ -128,9 +209,11 @@ var DefaultConfig = Config{
 		SignupEnabled:           true,
 		MaxLoginAttempts:        3,
 		RequireVerifiedAccounts: false,
-		JwtSecretKey:            "default",
+		JwtSecretKey:            defaultSecretKey,
 		JwtIssuer:               "qatarina.example.com",
 		JwtExpiryTimeout:        7200,
+		RefreshTokenTimeout:     2592000,
+		SigningAlgorithm:        "RS256",
 	},
 	Database: DatabaseConfiguration{
 		Host:               "",
@@ -167,4 +250,9 @@ var DefaultConfig = Config{
 		S3Bucket:  "",
 		S3Region:  "",
 	},
+	OIDC: OIDCConfiguration{
+		Enabled:     false,
+		Scopes:      []string{"openid", "profile", "email"},
+		GroupsClaim: "groups",
+	},
 }
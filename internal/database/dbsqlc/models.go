@@ -7,6 +7,7 @@ package dbsqlc
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -107,6 +108,66 @@ func (ns NullTestRunState) Value() (driver.Value, error) {
 	return string(ns.TestRunState), nil
 }
 
+type ApiToken struct {
+	ID int32
+	// User the token acts as, either the creator or a bot account created for the token
+	UserID      int32
+	CreatedByID int32
+	Name        string
+	// First characters of the token so users can tell their tokens apart
+	TokenPrefix string
+	// SHA-256 hash of the token, the raw token is only shown once on creation
+	TokenHash string
+	// Allowed resource actions, for example test-runs:write
+	Scopes []string
+	// When set the token can only access this project
+	ProjectID  sql.NullInt32
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
+}
+
+type AuditLog struct {
+	ID int64
+	// User who performed the action, null for actions taken by the system
+	ActorID sql.NullInt32
+	// What happened, for example auth.lockout
+	Action      string
+	SubjectType string
+	SubjectID   string
+	IpAddress   sql.NullString
+	Details     sql.NullString
+	CreatedAt   time.Time
+}
+
+type CustomField struct {
+	ID        int32
+	ProjectID int32
+	// What the field is defined on, test_case or test_run
+	Entity string
+	// Key of the field in requests, filters and import/export columns
+	Name  string
+	Label string
+	// One of text, number, enum, multi_select, date or user
+	FieldType string
+	// Allowed values of enum and multi_select fields
+	Options      []string
+	IsRequired   bool
+	DefaultValue pqtype.NullRawMessage
+	Position     int32
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type CustomFieldValue struct {
+	FieldID int32
+	// ID of the test case or test run the value is set on
+	EntityID  uuid.UUID
+	Value     json.RawMessage
+	UpdatedAt time.Time
+}
+
 type Environment struct {
 	ID          int32
 	ProjectID   sql.NullInt32
@@ -121,8 +182,34 @@ type Invite struct {
 	ID            int32
 	SenderEmail   string
 	ReceiverEmail string
-	Token         string
-	ExpiresAt     sql.NullTime
+	// SHA-256 hash of the token in the invitation link, the raw token is only sent by email
+	Token       string
+	ExpiresAt   sql.NullTime
+	InvitedByID sql.NullInt32
+	// Org the invited user joins on acceptance
+	OrgID sql.NullInt32
+	// Project the invited user is added to as a tester on acceptance
+	ProjectID sql.NullInt32
+	// Org role, or the tester role when the invite is for a project
+	Role         string
+	AcceptedAt   sql.NullTime
+	AcceptedByID sql.NullInt32
+	RevokedAt    sql.NullTime
+	CreatedAt    time.Time
+}
+
+type LoginThrottle struct {
+	ID int32
+	// What the attempts are counted against, either account or ip
+	Scope string
+	// Lower-cased email for account throttles or the client IP address
+	ThrottleKey    string
+	FailedAttempts int32
+	// Number of consecutive lockouts, used to back off exponentially
+	Lockouts     int32
+	LockedUntil  sql.NullTime
+	LastFailedAt sql.NullTime
+	UpdatedAt    time.Time
 }
 
 type Module struct {
@@ -153,6 +240,8 @@ type Org struct {
 	CreatedByID int32
 	CreatedAt   time.Time
 	UpdatedAt   sql.NullTime
+	// Whether members of the org must use two-factor authentication to log in
+	RequireTwoFactor bool
 }
 
 type OrgMember struct {
@@ -199,6 +288,18 @@ type Page struct {
 	DeletedAt    sql.NullTime
 }
 
+type PasswordResetToken struct {
+	ID     int32
+	UserID int32
+	// SHA-256 hash of the reset token sent to the user, the raw token is never stored
+	TokenHash string
+	// When the reset token stops being accepted
+	ExpiresAt time.Time
+	// When the token was consumed or invalidated, tokens are single-use
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
 type Project struct {
 	ID int32
 	// Title of the project
@@ -231,6 +332,10 @@ type Project struct {
 	TestcaseTemplate        sql.NullString
 	AutomatedTestingEnabled bool
 	SupportedRunners        []string
+	// Organization the project belongs to
+	OrgID int32
+	// Pattern of generated test case codes, tokens are {project}, {module}, {kind} and {seq:N}
+	TestcaseCodePattern sql.NullString
 }
 
 type ProjectTester struct {
@@ -246,6 +351,32 @@ type ProjectTester struct {
 	UpdatedAt sql.NullTime
 }
 
+type RecoveryCode struct {
+	ID     int32
+	UserID int32
+	// SHA-256 hash of the recovery code, the raw codes are only shown once
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	ID     int32
+	UserID int32
+	// Identifies the login session, every rotated token of a session shares the family
+	FamilyID uuid.UUID
+	// SHA-256 hash of the refresh token, the raw token is never stored
+	TokenHash string
+	UserAgent sql.NullString
+	IpAddress sql.NullString
+	ExpiresAt time.Time
+	// When the token was exchanged for a new one, presenting it again is treated as reuse
+	UsedAt sql.NullTime
+	// When the token was revoked through logout or reuse detection
+	RevokedAt sql.NullTime
+	CreatedAt time.Time
+}
+
 type Report struct {
 	ID        uuid.UUID
 	ProjectID int32
@@ -257,6 +388,64 @@ type Report struct {
 	UpdatedAt sql.NullTime
 }
 
+type ScimToken struct {
+	ID    int32
+	OrgID int32
+	// Label of the token, usually the directory it was issued to
+	Name string
+	// SHA-256 hash of the bearer token, the token itself is only shown once
+	TokenHash  string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+type ScimUser struct {
+	OrgID  int32
+	UserID int32
+	// Identifier of the user in the directory
+	ExternalID sql.NullString
+	// userName the directory knows the user by, usually the e-mail address
+	UserName string
+	// Whether the directory created the account, accounts it adopted keep their credentials and only lose their org membership when deprovisioned
+	ManagesAccount bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type SessionOrg struct {
+	// Login session, the family_id of its refresh tokens
+	FamilyID  uuid.UUID
+	UserID    int32
+	OrgID     int32
+	UpdatedAt time.Time
+}
+
+type SharedStep struct {
+	ID          int32
+	ProjectID   int32
+	Name        string
+	Description string
+	// Parameters used as {{name}} in the steps, with their description and default
+	Parameters  json.RawMessage
+	Steps       json.RawMessage
+	CreatedByID sql.NullInt32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type SigningKey struct {
+	Kid       string
+	Algorithm string
+	// PKCS #8 PEM encoded private key
+	PrivateKey string
+	CreatedAt  time.Time
+	// Tokens are signed with the newest key that is active, keys are published before they activate so verifiers can fetch them in time
+	ActivatesAt time.Time
+	// When the key stopped signing, it keeps verifying tokens until they have expired
+	RetiredAt sql.NullTime
+}
+
 type TestCase struct {
 	ID uuid.UUID
 	// The kind of test this case represents
@@ -285,6 +474,39 @@ type TestCase struct {
 	ParentTestCaseID uuid.NullUUID
 }
 
+type TestCaseCodeAlias struct {
+	ProjectID  int32
+	Code       string
+	TestCaseID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type TestCaseDataset struct {
+	TestCaseID uuid.UUID
+	// Parameters of the test case, used as {{name}} in its steps
+	Parameters []string
+	// Values of the parameters by name, one object per row
+	Rows        json.RawMessage
+	UpdatedByID sql.NullInt32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type TestCaseRevision struct {
+	TestCaseID  uuid.UUID
+	Revision    int32
+	Title       string
+	Description string
+	Kind        TestKind
+	Tags        []string
+	Runner      sql.NullString
+	ScriptPath  sql.NullString
+	// Steps of the test case at the revision
+	Steps     json.RawMessage
+	AuthorID  sql.NullInt32
+	CreatedAt time.Time
+}
+
 type TestCaseSequence struct {
 	ProjectID       int32
 	Prefix          string
@@ -292,6 +514,24 @@ type TestCaseSequence struct {
 	LastGeneratedAt sql.NullTime
 }
 
+type TestCaseStep struct {
+	ID         int32
+	TestCaseID uuid.UUID
+	// Number of the step in the test case, starting at 1
+	Position       int32
+	Action         string
+	TestData       string
+	ExpectedResult string
+	// URLs of files needed to perform the step
+	Attachments []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Shared step the step is replaced by, the action of the step is empty
+	SharedStepID sql.NullInt32
+	// Values of the parameters of the shared step
+	Parameters pqtype.NullRawMessage
+}
+
 type TestPlan struct {
 	ID int64
 	// Project which this test plan is under
@@ -370,6 +610,12 @@ type TestRun struct {
 	CreatedAt             sql.NullTime
 	UpdatedAt             sql.NullTime
 	EnvironmentID         sql.NullInt32
+	// Revision of the test case the results were recorded against
+	TestCaseRevision sql.NullInt32
+	// Row of the dataset of the test case the run tests, from 1
+	DataRow sql.NullInt32
+	// Values of the dataset row the run tests
+	DataValues pqtype.NullRawMessage
 }
 
 type TestRunResult struct {
@@ -384,6 +630,22 @@ type TestRunResult struct {
 	UpdatedAt  time.Time
 }
 
+type TestRunStepResult struct {
+	TestRunID uuid.UUID
+	Position  int32
+	StepID    sql.NullInt32
+	// Copy of the step when the run started, so later edits of the test case do not change recorded results
+	Action         string
+	TestData       string
+	ExpectedResult string
+	Status         TestRunState
+	ActualResult   string
+	Notes          string
+	ExecutedByID   sql.NullInt32
+	ExecutedAt     sql.NullTime
+	UpdatedAt      time.Time
+}
+
 type TestRunsComment struct {
 	ID        int64
 	TestRunID uuid.UUID
@@ -392,6 +654,18 @@ type TestRunsComment struct {
 	MediaUrls pqtype.NullRawMessage
 }
 
+type TotpCredential struct {
+	UserID int32
+	// TOTP secret encrypted with AES-GCM, see services/two_factor.go
+	Secret string
+	// When the user proved the authenticator works, two-factor is only enforced after confirmation
+	ConfirmedAt sql.NullTime
+	// Time step of the last accepted code, codes from this step or earlier are rejected to prevent replay
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 type User struct {
 	ID int32
 	// Firstname of the user
@@ -425,3 +699,16 @@ type User struct {
 	UpdatedAt        sql.NullTime
 	DeletedAt        sql.NullTime
 }
+
+type UserIdentity struct {
+	ID     int32
+	UserID int32
+	// Issuer URL of the identity provider the user signed in with
+	Provider string
+	// Identifier of the user at the identity provider, the sub claim of the ID token
+	Subject string
+	// E-mail address the identity provider last reported for the user
+	Email       sql.NullString
+	LastLoginAt sql.NullTime
+	CreatedAt   time.Time
+}
@@ -8,12 +8,48 @@ package dbsqlc
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
 )
 
+const acceptInvite = `-- name: AcceptInvite :execrows
+UPDATE invites SET accepted_at = now(), accepted_by_id = $2
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+`
+
+type AcceptInviteParams struct {
+	ID           int32
+	AcceptedByID sql.NullInt32
+}
+
+func (q *Queries) AcceptInvite(ctx context.Context, arg AcceptInviteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, acceptInvite, arg.ID, arg.AcceptedByID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addOrgMember = `-- name: AddOrgMember :exec
+INSERT INTO org_members (org_id, user_id, role, created_at)
+VALUES ($1, $2, $3, now())
+`
+
+type AddOrgMemberParams struct {
+	OrgID  int32
+	UserID int32
+	Role   string
+}
+
+func (q *Queries) AddOrgMember(ctx context.Context, arg AddOrgMemberParams) error {
+	_, err := q.db.ExecContext(ctx, addOrgMember, arg.OrgID, arg.UserID, arg.Role)
+	return err
+}
+
 const addProjectTestCaseTemplate = `-- name: AddProjectTestCaseTemplate :exec
 UPDATE projects
 SET testcase_template = $2,
@@ -48,11 +84,29 @@ func (q *Queries) AddTestCaseToPlan(ctx context.Context, arg AddTestCaseToPlanPa
 	return err
 }
 
+const advanceTestCaseSequence = `-- name: AdvanceTestCaseSequence :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, prefix) DO UPDATE
+SET current_val = GREATEST(test_case_sequences.current_val, EXCLUDED.current_val)
+`
+
+type AdvanceTestCaseSequenceParams struct {
+	ProjectID  int32
+	Prefix     string
+	CurrentVal int32
+}
+
+func (q *Queries) AdvanceTestCaseSequence(ctx context.Context, arg AdvanceTestCaseSequenceParams) error {
+	_, err := q.db.ExecContext(ctx, advanceTestCaseSequence, arg.ProjectID, arg.Prefix, arg.CurrentVal)
+	return err
+}
+
 const archiveProject = `-- name: ArchiveProject :one
 UPDATE projects
 SET is_active = false
 WHERE id = $1
-RETURNING id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners
+RETURNING id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern
 `
 
 func (q *Queries) ArchiveProject(ctx context.Context, id int32) (Project, error) {
@@ -79,6 +133,8 @@ func (q *Queries) ArchiveProject(ctx context.Context, id int32) (Project, error)
 		&i.TestcaseTemplate,
 		&i.AutomatedTestingEnabled,
 		pq.Array(&i.SupportedRunners),
+		&i.OrgID,
+		&i.TestcaseCodePattern,
 	)
 	return i, err
 }
@@ -145,6 +201,23 @@ func (q *Queries) ChangeUserPassword(ctx context.Context, arg ChangeUserPassword
 	return err
 }
 
+const clearUserDefaultOrg = `-- name: ClearUserDefaultOrg :execrows
+UPDATE users SET org_id = NULL, updated_at = now() WHERE id = $1 AND org_id = $2
+`
+
+type ClearUserDefaultOrgParams struct {
+	ID    int32
+	OrgID sql.NullInt32
+}
+
+func (q *Queries) ClearUserDefaultOrg(ctx context.Context, arg ClearUserDefaultOrgParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, clearUserDefaultOrg, arg.ID, arg.OrgID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const closeTestPlan = `-- name: CloseTestPlan :execrows
 UPDATE test_plans
 SET is_complete = TRUE,
@@ -224,6 +297,58 @@ func (q *Queries) CommitTestRunResult(ctx context.Context, arg CommitTestRunResu
 	return id, err
 }
 
+const confirmTotpCredential = `-- name: ConfirmTotpCredential :execrows
+UPDATE totp_credentials
+SET confirmed_at = now(), last_used_step = $2, updated_at = now()
+WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+type ConfirmTotpCredentialParams struct {
+	UserID       int32
+	LastUsedStep int64
+}
+
+func (q *Queries) ConfirmTotpCredential(ctx context.Context, arg ConfirmTotpCredentialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, confirmTotpCredential, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const confirmUserEmail = `-- name: ConfirmUserEmail :execrows
+UPDATE users
+SET is_verified = true, email_confirmed_at = COALESCE(email_confirmed_at, now()), updated_at = now()
+WHERE id = $1 AND email = $2 AND is_activated AND deleted_at IS NULL
+`
+
+type ConfirmUserEmailParams struct {
+	ID    int32
+	Email string
+}
+
+func (q *Queries) ConfirmUserEmail(ctx context.Context, arg ConfirmUserEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, confirmUserEmail, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const consumePasswordResetToken = `-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+RETURNING user_id
+`
+
+func (q *Queries) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int32, error) {
+	row := q.db.QueryRowContext(ctx, consumePasswordResetToken, tokenHash)
+	var userID int32
+	err := row.Scan(&userID)
+	return userID, err
+}
+
 const convertCommentToTestCase = `-- name: ConvertCommentToTestCase :one
 INSERT INTO test_cases (
     id, project_id, created_by_id, kind, code, title, description,
@@ -258,6 +383,48 @@ func (q *Queries) ConvertCommentToTestCase(ctx context.Context, arg ConvertComme
 	return id, err
 }
 
+const copyTestCaseSequences = `-- name: CopyTestCaseSequences :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+SELECT $1::int, prefix, current_val, now()
+FROM test_case_sequences
+WHERE project_id = $2
+ON CONFLICT (project_id, prefix) DO NOTHING
+`
+
+type CopyTestCaseSequencesParams struct {
+	TargetProjectID int32
+	SourceProjectID int32
+}
+
+func (q *Queries) CopyTestCaseSequences(ctx context.Context, arg CopyTestCaseSequencesParams) error {
+	_, err := q.db.ExecContext(ctx, copyTestCaseSequences, arg.TargetProjectID, arg.SourceProjectID)
+	return err
+}
+
+const countOrgOwners = `-- name: CountOrgOwners :one
+SELECT COUNT(DISTINCT m.user_id) FROM org_members m
+INNER JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1 AND m.role = 'owner' AND m.removed_at IS NULL AND u.is_activated AND u.deleted_at IS NULL
+`
+
+func (q *Queries) CountOrgOwners(ctx context.Context, orgID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrgOwners, orgID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSuperAdmins = `-- name: CountSuperAdmins :one
+SELECT COUNT(*) FROM users WHERE is_super_admin AND is_activated AND deleted_at IS NULL
+`
+
+func (q *Queries) CountSuperAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSuperAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTestCasesNotLinkedToProject = `-- name: CountTestCasesNotLinkedToProject :one
 SELECT COUNT(*) FROM test_cases
 RIGHT OUTER JOIN test_plans p ON p.test_case_id = test_cases.id
@@ -271,6 +438,90 @@ func (q *Queries) CountTestCasesNotLinkedToProject(ctx context.Context) (int64,
 	return count, err
 }
 
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createApiToken = `-- name: CreateApiToken :one
+INSERT INTO api_tokens (
+    user_id, created_by_id, name, token_prefix, token_hash, scopes, project_id, expires_at, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+RETURNING id, user_id, created_by_id, name, token_prefix, token_hash, scopes, project_id, expires_at, last_used_at, revoked_at, created_at
+`
+
+type CreateApiTokenParams struct {
+	UserID      int32
+	CreatedByID int32
+	Name        string
+	TokenPrefix string
+	TokenHash   string
+	Scopes      []string
+	ProjectID   sql.NullInt32
+	ExpiresAt   sql.NullTime
+}
+
+func (q *Queries) CreateApiToken(ctx context.Context, arg CreateApiTokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createApiToken,
+		arg.UserID,
+		arg.CreatedByID,
+		arg.Name,
+		arg.TokenPrefix,
+		arg.TokenHash,
+		pq.Array(arg.Scopes),
+		arg.ProjectID,
+		arg.ExpiresAt,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedByID,
+		&i.Name,
+		&i.TokenPrefix,
+		&i.TokenHash,
+		pq.Array(&i.Scopes),
+		&i.ProjectID,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAuditLog = `-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (actor_id, action, subject_type, subject_id, ip_address, details, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+`
+
+type CreateAuditLogParams struct {
+	ActorID     sql.NullInt32
+	Action      string
+	SubjectType string
+	SubjectID   string
+	IpAddress   sql.NullString
+	Details     sql.NullString
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.SubjectType,
+		arg.SubjectID,
+		arg.IpAddress,
+		arg.Details,
+	)
+	return err
+}
+
 const createComment = `-- name: CreateComment :one
 INSERT INTO test_plan_comments (id, test_plan_id, user_id, content, created_at, updated_at)
 VALUES ($1, $2, $3, $4, NOW(), NOW())
@@ -309,6 +560,98 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (T
 	return i, err
 }
 
+const createCustomField = `-- name: CreateCustomField :one
+INSERT INTO custom_fields (project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+RETURNING id, project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at
+`
+
+type CreateCustomFieldParams struct {
+	ProjectID    int32
+	Entity       string
+	Name         string
+	Label        string
+	FieldType    string
+	Options      []string
+	IsRequired   bool
+	DefaultValue pqtype.NullRawMessage
+	Position     int32
+}
+
+func (q *Queries) CreateCustomField(ctx context.Context, arg CreateCustomFieldParams) (CustomField, error) {
+	row := q.db.QueryRowContext(ctx, createCustomField,
+		arg.ProjectID,
+		arg.Entity,
+		arg.Name,
+		arg.Label,
+		arg.FieldType,
+		pq.Array(arg.Options),
+		arg.IsRequired,
+		arg.DefaultValue,
+		arg.Position,
+	)
+	var i CustomField
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Entity,
+		&i.Name,
+		&i.Label,
+		&i.FieldType,
+		pq.Array(&i.Options),
+		&i.IsRequired,
+		&i.DefaultValue,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createDataDrivenTestRun = `-- name: CreateDataDrivenTestRun :execrows
+INSERT INTO test_runs (
+id, project_id, test_plan_id, test_case_id, owner_id, assigned_to_id, code, created_at, updated_at,
+result_state, is_closed, assignee_can_change_code, notes, reactions, tested_on, expected_result, environment_id, data_row, data_values
+)
+VALUES (
+$1, $2, $3, $4, $5, $6, $7, now(), now(),
+'pending', false, false, 'None', '{}'::jsonb, now(), 'Test to Pass', $8, $9, $10
+)
+ON CONFLICT (test_plan_id, test_case_id, assigned_to_id, data_values) WHERE data_values IS NOT NULL DO NOTHING
+`
+
+type CreateDataDrivenTestRunParams struct {
+	ID            uuid.UUID
+	ProjectID     int32
+	TestPlanID    sql.NullInt32
+	TestCaseID    uuid.UUID
+	OwnerID       int32
+	AssignedToID  sql.NullInt32
+	Code          string
+	EnvironmentID sql.NullInt32
+	DataRow       sql.NullInt32
+	DataValues    pqtype.NullRawMessage
+}
+
+func (q *Queries) CreateDataDrivenTestRun(ctx context.Context, arg CreateDataDrivenTestRunParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createDataDrivenTestRun,
+		arg.ID,
+		arg.ProjectID,
+		arg.TestPlanID,
+		arg.TestCaseID,
+		arg.OwnerID,
+		arg.AssignedToID,
+		arg.Code,
+		arg.EnvironmentID,
+		arg.DataRow,
+		arg.DataValues,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createEnvironment = `-- name: CreateEnvironment :one
 INSERT INTO environments (
     project_id, name, description, base_url, created_at, updated_at
@@ -344,9 +687,13 @@ func (q *Queries) CreateEnvironment(ctx context.Context, arg CreateEnvironmentPa
 	return i, err
 }
 
-const createInvite = `-- name: CreateInvite :exec
-INSERT INTO invites (sender_email, receiver_email, token, expires_at)
-VALUES ($1, $2, $3, $4)
+const createInvite = `-- name: CreateInvite :one
+INSERT INTO invites (
+    sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, now()
+)
+RETURNING id, sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, accepted_at, accepted_by_id, revoked_at, created_at
 `
 
 type CreateInviteParams struct {
@@ -354,16 +701,40 @@ type CreateInviteParams struct {
 	ReceiverEmail string
 	Token         string
 	ExpiresAt     sql.NullTime
+	InvitedByID   sql.NullInt32
+	OrgID         sql.NullInt32
+	ProjectID     sql.NullInt32
+	Role          string
 }
 
-func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) error {
-	_, err := q.db.ExecContext(ctx, createInvite,
+func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, createInvite,
 		arg.SenderEmail,
 		arg.ReceiverEmail,
 		arg.Token,
 		arg.ExpiresAt,
+		arg.InvitedByID,
+		arg.OrgID,
+		arg.ProjectID,
+		arg.Role,
 	)
-	return err
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.SenderEmail,
+		&i.ReceiverEmail,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.InvitedByID,
+		&i.OrgID,
+		&i.ProjectID,
+		&i.Role,
+		&i.AcceptedAt,
+		&i.AcceptedByID,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
 const createNewTestRun = `-- name: CreateNewTestRun :one
@@ -412,18 +783,19 @@ func (q *Queries) CreateNewTestRun(ctx context.Context, arg CreateNewTestRunPara
 }
 
 const createOrg = `-- name: CreateOrg :one
-INSERT INTO orgs (  name, address, country, github_url, website_url, created_by_id, created_at, updated_at
-) VALUES ($1, $2, $3, $4, $5, $6, now(), now())
-RETURNING id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at
+INSERT INTO orgs (  name, address, country, github_url, website_url, created_by_id, require_two_factor, created_at, updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())
+RETURNING id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor
 `
 
 type CreateOrgParams struct {
-	Name        string
-	Address     sql.NullString
-	Country     sql.NullString
-	GithubUrl   sql.NullString
-	WebsiteUrl  sql.NullString
-	CreatedByID int32
+	Name             string
+	Address          sql.NullString
+	Country          sql.NullString
+	GithubUrl        sql.NullString
+	WebsiteUrl       sql.NullString
+	CreatedByID      int32
+	RequireTwoFactor bool
 }
 
 func (q *Queries) CreateOrg(ctx context.Context, arg CreateOrgParams) (Org, error) {
@@ -434,6 +806,7 @@ func (q *Queries) CreateOrg(ctx context.Context, arg CreateOrgParams) (Org, erro
 		arg.GithubUrl,
 		arg.WebsiteUrl,
 		arg.CreatedByID,
+		arg.RequireTwoFactor,
 	)
 	var i Org
 	err := row.Scan(
@@ -446,6 +819,7 @@ func (q *Queries) CreateOrg(ctx context.Context, arg CreateOrgParams) (Org, erro
 		&i.CreatedByID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTwoFactor,
 	)
 	return i, err
 }
@@ -516,18 +890,57 @@ func (q *Queries) CreatePage(ctx context.Context, arg CreatePageParams) (Page, e
 	return i, err
 }
 
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+VALUES ($1, $2, $3, now())
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    int32
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const createPendingTotpCredential = `-- name: CreatePendingTotpCredential :execrows
+INSERT INTO totp_credentials (user_id, secret, created_at, updated_at)
+VALUES ($1, $2, now(), now())
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = now()
+WHERE totp_credentials.confirmed_at IS NULL
+`
+
+type CreatePendingTotpCredentialParams struct {
+	UserID int32
+	Secret string
+}
+
+func (q *Queries) CreatePendingTotpCredential(ctx context.Context, arg CreatePendingTotpCredentialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPendingTotpCredential, arg.UserID, arg.Secret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createProject = `-- name: CreateProject :one
 INSERT INTO projects (
     title, code, description, version, is_active, is_public, website_url,
     github_url, trello_url, jira_url, monday_url,
     owner_user_id, created_at, updated_at, deleted_at,
-    automated_testing_enabled, supported_runners
+    automated_testing_enabled, supported_runners, org_id,
+    parent_project_id, testcase_template
 )
 VALUES (
     $1, $2, $3, $4, $5, $6, $7,
     $8, $9, $10, $11,
     $12, $13, $14, $15,
-    $16, $17
+    $16, $17, $18,
+    $19, $20
 )
 RETURNING id
 `
@@ -550,6 +963,9 @@ type CreateProjectParams struct {
 	DeletedAt               sql.NullTime
 	AutomatedTestingEnabled bool
 	SupportedRunners        []string
+	OrgID                   int32
+	ParentProjectID         sql.NullInt32
+	TestcaseTemplate        sql.NullString
 }
 
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (int32, error) {
@@ -571,6 +987,9 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (i
 		arg.DeletedAt,
 		arg.AutomatedTestingEnabled,
 		pq.Array(arg.SupportedRunners),
+		arg.OrgID,
+		arg.ParentProjectID,
+		arg.TestcaseTemplate,
 	)
 	var id int32
 	err := row.Scan(&id)
@@ -618,6 +1037,48 @@ func (q *Queries) CreateProjectModules(ctx context.Context, arg CreateProjectMod
 	return i, err
 }
 
+const createRecoveryCodes = `-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+SELECT $1::integer, unnest($2::text[]), now()
+`
+
+type CreateRecoveryCodesParams struct {
+	UserID     int32
+	CodeHashes []string
+}
+
+func (q *Queries) CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCodes, arg.UserID, pq.Array(arg.CodeHashes))
+	return err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (
+    user_id, family_id, token_hash, user_agent, ip_address, expires_at, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, now())
+`
+
+type CreateRefreshTokenParams struct {
+	UserID    int32
+	FamilyID  uuid.UUID
+	TokenHash string
+	UserAgent sql.NullString
+	IpAddress sql.NullString
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createRefreshToken,
+		arg.UserID,
+		arg.FamilyID,
+		arg.TokenHash,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
 const createReport = `-- name: CreateReport :one
 INSERT INTO reports (id, project_id, name, type, status, created_at, file_path)
 VALUES ($1, $2, $3, $4, $5, NOW(), $6)
@@ -656,35 +1117,115 @@ func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Rep
 	return i, err
 }
 
-const createTestCase = `-- name: CreateTestCase :one
-INSERT INTO test_cases (
-    id, kind, code, feature_or_module, title, description, parent_test_case_id,
-    is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path
-)
-VALUES (
-    $1, $2, $3, $4, $5, $6, $7,
-    $8, $9, $10, $11, $12, $13, $14, $15, $16
-)
+const createSCIMToken = `-- name: CreateSCIMToken :one
+INSERT INTO scim_tokens (org_id, name, token_hash, created_at)
+VALUES ($1, $2, $3, now())
 RETURNING id
 `
 
-type CreateTestCaseParams struct {
-	ID               uuid.UUID
-	Kind             TestKind
-	Code             string
-	FeatureOrModule  sql.NullString
-	Title            string
-	Description      string
-	ParentTestCaseID uuid.NullUUID
-	IsDraft          sql.NullBool
-	Tags             []string
-	CreatedByID      int32
-	CreatedAt        sql.NullTime
-	UpdatedAt        sql.NullTime
-	ProjectID        sql.NullInt32
-	Suggested        sql.NullBool
-	Runner           sql.NullString
-	ScriptPath       sql.NullString
+type CreateSCIMTokenParams struct {
+	OrgID     int32
+	Name      string
+	TokenHash string
+}
+
+func (q *Queries) CreateSCIMToken(ctx context.Context, arg CreateSCIMTokenParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createSCIMToken, arg.OrgID, arg.Name, arg.TokenHash)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSharedStep = `-- name: CreateSharedStep :one
+INSERT INTO shared_steps (project_id, name, description, parameters, steps, created_by_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now(), now())
+RETURNING id, project_id, name, description, parameters, steps, created_by_id, created_at, updated_at
+`
+
+type CreateSharedStepParams struct {
+	ProjectID   int32
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Steps       json.RawMessage
+	CreatedByID sql.NullInt32
+}
+
+func (q *Queries) CreateSharedStep(ctx context.Context, arg CreateSharedStepParams) (SharedStep, error) {
+	row := q.db.QueryRowContext(ctx, createSharedStep,
+		arg.ProjectID,
+		arg.Name,
+		arg.Description,
+		arg.Parameters,
+		arg.Steps,
+		arg.CreatedByID,
+	)
+	var i SharedStep
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.Parameters,
+		&i.Steps,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createSigningKey = `-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, private_key, created_at, activates_at)
+VALUES ($1, $2, $3, now(), $4)
+`
+
+type CreateSigningKeyParams struct {
+	Kid         string
+	Algorithm   string
+	PrivateKey  string
+	ActivatesAt time.Time
+}
+
+func (q *Queries) CreateSigningKey(ctx context.Context, arg CreateSigningKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createSigningKey,
+		arg.Kid,
+		arg.Algorithm,
+		arg.PrivateKey,
+		arg.ActivatesAt,
+	)
+	return err
+}
+
+const createTestCase = `-- name: CreateTestCase :one
+INSERT INTO test_cases (
+    id, kind, code, feature_or_module, title, description, parent_test_case_id,
+    is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7,
+    $8, $9, $10, $11, $12, $13, $14, $15, $16
+)
+RETURNING id
+`
+
+type CreateTestCaseParams struct {
+	ID               uuid.UUID
+	Kind             TestKind
+	Code             string
+	FeatureOrModule  sql.NullString
+	Title            string
+	Description      string
+	ParentTestCaseID uuid.NullUUID
+	IsDraft          sql.NullBool
+	Tags             []string
+	CreatedByID      int32
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	ProjectID        sql.NullInt32
+	Suggested        sql.NullBool
+	Runner           sql.NullString
+	ScriptPath       sql.NullString
 }
 
 func (q *Queries) CreateTestCase(ctx context.Context, arg CreateTestCaseParams) (uuid.UUID, error) {
@@ -711,6 +1252,92 @@ func (q *Queries) CreateTestCase(ctx context.Context, arg CreateTestCaseParams)
 	return id, err
 }
 
+const createTestCaseCodeAlias = `-- name: CreateTestCaseCodeAlias :exec
+INSERT INTO test_case_code_aliases (project_id, code, test_case_id, created_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, code) DO UPDATE SET test_case_id = EXCLUDED.test_case_id, created_at = now()
+`
+
+type CreateTestCaseCodeAliasParams struct {
+	ProjectID  int32
+	Code       string
+	TestCaseID uuid.UUID
+}
+
+func (q *Queries) CreateTestCaseCodeAlias(ctx context.Context, arg CreateTestCaseCodeAliasParams) error {
+	_, err := q.db.ExecContext(ctx, createTestCaseCodeAlias, arg.ProjectID, arg.Code, arg.TestCaseID)
+	return err
+}
+
+const createTestCaseRevision = `-- name: CreateTestCaseRevision :one
+INSERT INTO test_case_revisions (test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at)
+VALUES (
+    $1,
+    (SELECT COALESCE(MAX(revision), 0) + 1 FROM test_case_revisions WHERE test_case_id = $1),
+    $2, $3, $4, $5, $6, $7, $8, $9, now()
+)
+RETURNING revision
+`
+
+type CreateTestCaseRevisionParams struct {
+	TestCaseID  uuid.UUID
+	Title       string
+	Description string
+	Kind        TestKind
+	Tags        []string
+	Runner      sql.NullString
+	ScriptPath  sql.NullString
+	Steps       json.RawMessage
+	AuthorID    sql.NullInt32
+}
+
+func (q *Queries) CreateTestCaseRevision(ctx context.Context, arg CreateTestCaseRevisionParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, createTestCaseRevision,
+		arg.TestCaseID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		pq.Array(arg.Tags),
+		arg.Runner,
+		arg.ScriptPath,
+		arg.Steps,
+		arg.AuthorID,
+	)
+	var revision int32
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const createTestCaseStep = `-- name: CreateTestCaseStep :exec
+INSERT INTO test_case_steps (test_case_id, position, action, test_data, expected_result, attachments, shared_step_id, parameters, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now(), now())
+`
+
+type CreateTestCaseStepParams struct {
+	TestCaseID     uuid.UUID
+	Position       int32
+	Action         string
+	TestData       string
+	ExpectedResult string
+	Attachments    []string
+	SharedStepID   sql.NullInt32
+	Parameters     pqtype.NullRawMessage
+}
+
+func (q *Queries) CreateTestCaseStep(ctx context.Context, arg CreateTestCaseStepParams) error {
+	_, err := q.db.ExecContext(ctx, createTestCaseStep,
+		arg.TestCaseID,
+		arg.Position,
+		arg.Action,
+		arg.TestData,
+		arg.ExpectedResult,
+		pq.Array(arg.Attachments),
+		arg.SharedStepID,
+		arg.Parameters,
+	)
+	return err
+}
+
 const createTestPlan = `-- name: CreateTestPlan :one
 INSERT INTO test_plans (
     project_id, assigned_to_id, created_by_id, updated_by_id,
@@ -770,6 +1397,33 @@ func (q *Queries) CreateTestPlan(ctx context.Context, arg CreateTestPlanParams)
 	return id, err
 }
 
+const createTestRunStepResult = `-- name: CreateTestRunStepResult :exec
+INSERT INTO test_run_step_results (test_run_id, position, step_id, action, test_data, expected_result, status, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, 'pending', now())
+ON CONFLICT (test_run_id, position) DO NOTHING
+`
+
+type CreateTestRunStepResultParams struct {
+	TestRunID      uuid.UUID
+	Position       int32
+	StepID         sql.NullInt32
+	Action         string
+	TestData       string
+	ExpectedResult string
+}
+
+func (q *Queries) CreateTestRunStepResult(ctx context.Context, arg CreateTestRunStepResultParams) error {
+	_, err := q.db.ExecContext(ctx, createTestRunStepResult,
+		arg.TestRunID,
+		arg.Position,
+		arg.StepID,
+		arg.Action,
+		arg.TestData,
+		arg.ExpectedResult,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     first_name, last_name, display_name, email, password, phone,
@@ -835,6 +1489,41 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int32,
 	return id, err
 }
 
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+    user_id, provider, subject, email, last_login_at, created_at
+) VALUES ($1, $2, $3, $4, now(), now())
+`
+
+type CreateUserIdentityParams struct {
+	UserID   int32
+	Provider string
+	Subject  string
+	Email    sql.NullString
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Provider,
+		arg.Subject,
+		arg.Email,
+	)
+	return err
+}
+
+const deactivateUser = `-- name: DeactivateUser :execrows
+UPDATE users SET is_activated = false, updated_at = now() WHERE id = $1 AND is_activated
+`
+
+func (q *Queries) DeactivateUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deactivateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteAllTestPlansInProject = `-- name: DeleteAllTestPlansInProject :execrows
 DELETE FROM test_plans WHERE project_id = $1
 `
@@ -871,6 +1560,57 @@ func (q *Queries) DeleteComment(ctx context.Context, id uuid.UUID) (int64, error
 	return result.RowsAffected()
 }
 
+const deleteCustomField = `-- name: DeleteCustomField :execrows
+DELETE FROM custom_fields WHERE id = $1
+`
+
+func (q *Queries) DeleteCustomField(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCustomField, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCustomFieldValuesByEntity = `-- name: DeleteCustomFieldValuesByEntity :exec
+DELETE FROM custom_field_values WHERE entity_id = $1
+`
+
+func (q *Queries) DeleteCustomFieldValuesByEntity(ctx context.Context, entityID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomFieldValuesByEntity, entityID)
+	return err
+}
+
+const deleteDuplicatePlanCaseAssignments = `-- name: DeleteDuplicatePlanCaseAssignments :execrows
+DELETE FROM test_plan_cases pc
+USING test_plans tp
+WHERE tp.id = pc.test_plan_id
+AND pc.assigned_to_id = $1
+AND EXISTS (
+    SELECT 1 FROM test_plan_cases other
+    WHERE other.test_plan_id = pc.test_plan_id AND other.test_case_id = pc.test_case_id
+    AND other.assigned_to_id = $2
+)
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+)
+`
+
+type DeleteDuplicatePlanCaseAssignmentsParams struct {
+	FromUserID int64
+	ToUserID   int64
+}
+
+func (q *Queries) DeleteDuplicatePlanCaseAssignments(ctx context.Context, arg DeleteDuplicatePlanCaseAssignmentsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDuplicatePlanCaseAssignments, arg.FromUserID, arg.ToUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteEnvironment = `-- name: DeleteEnvironment :exec
 DELETE FROM environments WHERE id = $1 AND project_id = $2
 `
@@ -931,17 +1671,31 @@ func (q *Queries) DeleteProjectModule(ctx context.Context, id int32) (int64, err
 }
 
 const deleteProjectTester = `-- name: DeleteProjectTester :execrows
-DELETE FROM project_testers WHERE user_id = $1
+DELETE FROM project_testers WHERE project_id = $1 AND user_id = $2
 `
 
-func (q *Queries) DeleteProjectTester(ctx context.Context, userID int32) (int64, error) {
-	result, err := q.db.ExecContext(ctx, deleteProjectTester, userID)
+type DeleteProjectTesterParams struct {
+	ProjectID int32
+	UserID    int32
+}
+
+func (q *Queries) DeleteProjectTester(ctx context.Context, arg DeleteProjectTesterParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProjectTester, arg.ProjectID, arg.UserID)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
 const deleteReport = `-- name: DeleteReport :execrows
 DELETE FROM reports WHERE id = $1
 `
@@ -954,6 +1708,30 @@ func (q *Queries) DeleteReport(ctx context.Context, id uuid.UUID) (int64, error)
 	return result.RowsAffected()
 }
 
+const deleteSharedStep = `-- name: DeleteSharedStep :execrows
+DELETE FROM shared_steps WHERE id = $1
+`
+
+func (q *Queries) DeleteSharedStep(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSharedStep, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSigningKey = `-- name: DeleteSigningKey :execrows
+DELETE FROM signing_keys WHERE kid = $1
+`
+
+func (q *Queries) DeleteSigningKey(ctx context.Context, kid string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSigningKey, kid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteTestCase = `-- name: DeleteTestCase :execrows
 DELETE FROM test_cases WHERE id = $1
 `
@@ -966,6 +1744,50 @@ func (q *Queries) DeleteTestCase(ctx context.Context, id uuid.UUID) (int64, erro
 	return result.RowsAffected()
 }
 
+const deleteTestCaseCodeAlias = `-- name: DeleteTestCaseCodeAlias :exec
+DELETE FROM test_case_code_aliases WHERE project_id = $1 AND code = $2
+`
+
+type DeleteTestCaseCodeAliasParams struct {
+	ProjectID int32
+	Code      string
+}
+
+func (q *Queries) DeleteTestCaseCodeAlias(ctx context.Context, arg DeleteTestCaseCodeAliasParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTestCaseCodeAlias, arg.ProjectID, arg.Code)
+	return err
+}
+
+const deleteTestCaseDataset = `-- name: DeleteTestCaseDataset :execrows
+DELETE FROM test_case_datasets WHERE test_case_id = $1
+`
+
+func (q *Queries) DeleteTestCaseDataset(ctx context.Context, testCaseID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTestCaseDataset, testCaseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTestCaseSequences = `-- name: DeleteTestCaseSequences :exec
+DELETE FROM test_case_sequences WHERE project_id = $1
+`
+
+func (q *Queries) DeleteTestCaseSequences(ctx context.Context, projectID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteTestCaseSequences, projectID)
+	return err
+}
+
+const deleteTestCaseSteps = `-- name: DeleteTestCaseSteps :exec
+DELETE FROM test_case_steps WHERE test_case_id = $1
+`
+
+func (q *Queries) DeleteTestCaseSteps(ctx context.Context, testCaseID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTestCaseSteps, testCaseID)
+	return err
+}
+
 const deleteTestPlan = `-- name: DeleteTestPlan :execrows
 DELETE FROM test_plans WHERE id = $1
 `
@@ -990,6 +1812,18 @@ func (q *Queries) DeleteTestRun(ctx context.Context, id uuid.UUID) (int64, error
 	return result.RowsAffected()
 }
 
+const deleteTotpCredential = `-- name: DeleteTotpCredential :execrows
+DELETE FROM totp_credentials WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTotpCredential(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTotpCredential, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteUser = `-- name: DeleteUser :execrows
 DELETE FROM users WHERE id=$1
 `
@@ -1182,7 +2016,7 @@ func (q *Queries) FindTestCasesByProjectID(ctx context.Context, arg FindTestCase
 }
 
 const findUserLoginByEmail = `-- name: FindUserLoginByEmail :one
-SELECT id, display_name, email, password, last_login_at FROM users WHERE email = $1 AND is_activated AND deleted_at IS NULL
+SELECT id, display_name, email, password, last_login_at, is_verified FROM users WHERE email = $1 AND is_activated AND deleted_at IS NULL
 `
 
 type FindUserLoginByEmailRow struct {
@@ -1191,6 +2025,7 @@ type FindUserLoginByEmailRow struct {
 	Email       string
 	Password    string
 	LastLoginAt sql.NullTime
+	IsVerified  sql.NullBool
 }
 
 func (q *Queries) FindUserLoginByEmail(ctx context.Context, email string) (FindUserLoginByEmailRow, error) {
@@ -1202,17 +2037,38 @@ func (q *Queries) FindUserLoginByEmail(ctx context.Context, email string) (FindU
 		&i.Email,
 		&i.Password,
 		&i.LastLoginAt,
+		&i.IsVerified,
+	)
+	return i, err
+}
+
+const getActiveSCIMToken = `-- name: GetActiveSCIMToken :one
+SELECT id, org_id, name, token_hash, created_at, last_used_at, revoked_at FROM scim_tokens WHERE token_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) GetActiveSCIMToken(ctx context.Context, tokenHash string) (ScimToken, error) {
+	row := q.db.QueryRowContext(ctx, getActiveSCIMToken, tokenHash)
+	var i ScimToken
+	err := row.Scan(
+		&i.ID,
+		&i.OrgID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
 
 const getAllModules = `-- name: GetAllModules :many
 SELECT id, project_id, name, code, priority, type, description, created_at, updated_at FROM modules
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
 ORDER BY created_at DESC
 `
 
-func (q *Queries) GetAllModules(ctx context.Context) ([]Module, error) {
-	rows, err := q.db.QueryContext(ctx, getAllModules)
+func (q *Queries) GetAllModules(ctx context.Context, orgID int32) ([]Module, error) {
+	rows, err := q.db.QueryContext(ctx, getAllModules, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -1246,11 +2102,12 @@ func (q *Queries) GetAllModules(ctx context.Context) ([]Module, error) {
 
 const getAllPages = `-- name: GetAllPages :many
 SELECT id, parent_page_id, page_version, org_id, project_id, code, title, file_path, content, page_type, mime_type, has_embedded_media, external_content_url, notion_url, last_edited_by, created_by, created_at, updated_at, deleted_at FROM pages
+WHERE org_id = $1
 ORDER BY created_at DESC
 `
 
-func (q *Queries) GetAllPages(ctx context.Context) ([]Page, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPages)
+func (q *Queries) GetAllPages(ctx context.Context, orgID int32) ([]Page, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPages, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -1302,6 +2159,7 @@ SELECT
 FROM project_testers pt
 INNER JOIN users u ON u.id = pt.user_id
 INNER JOIN projects p ON p.id = pt.project_id
+WHERE p.org_id = $1
 ORDER BY pt.created_at DESC
 `
 
@@ -1319,8 +2177,8 @@ type GetAllProjectTestersRow struct {
 	TesterLastLoginAt sql.NullTime
 }
 
-func (q *Queries) GetAllProjectTesters(ctx context.Context) ([]GetAllProjectTestersRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllProjectTesters)
+func (q *Queries) GetAllProjectTesters(ctx context.Context, orgID int32) ([]GetAllProjectTestersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllProjectTesters, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -1354,6 +2212,39 @@ func (q *Queries) GetAllProjectTesters(ctx context.Context) ([]GetAllProjectTest
 	return items, nil
 }
 
+const getApiTokenByHash = `-- name: GetApiTokenByHash :one
+SELECT t.id, t.user_id, t.scopes, t.project_id, u.email, u.display_name
+FROM api_tokens t
+INNER JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1
+AND t.revoked_at IS NULL
+AND (t.expires_at IS NULL OR t.expires_at > now())
+AND u.is_activated AND u.deleted_at IS NULL
+`
+
+type GetApiTokenByHashRow struct {
+	ID          int32
+	UserID      int32
+	Scopes      []string
+	ProjectID   sql.NullInt32
+	Email       string
+	DisplayName sql.NullString
+}
+
+func (q *Queries) GetApiTokenByHash(ctx context.Context, tokenHash string) (GetApiTokenByHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getApiTokenByHash, tokenHash)
+	var i GetApiTokenByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		pq.Array(&i.Scopes),
+		&i.ProjectID,
+		&i.Email,
+		&i.DisplayName,
+	)
+	return i, err
+}
+
 const getComment = `-- name: GetComment :one
 SELECT 
     c.id,
@@ -1393,26 +2284,100 @@ func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (GetCommentRow,
 	return i, err
 }
 
-const getEnvironment = `-- name: GetEnvironment :one
-SELECT id, project_id, name, description, base_url, created_at, updated_at FROM environments WHERE id = $1
+const getCustomField = `-- name: GetCustomField :one
+SELECT id, project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at FROM custom_fields WHERE id = $1
 `
 
-func (q *Queries) GetEnvironment(ctx context.Context, id int32) (Environment, error) {
-	row := q.db.QueryRowContext(ctx, getEnvironment, id)
-	var i Environment
+func (q *Queries) GetCustomField(ctx context.Context, id int32) (CustomField, error) {
+	row := q.db.QueryRowContext(ctx, getCustomField, id)
+	var i CustomField
 	err := row.Scan(
 		&i.ID,
 		&i.ProjectID,
+		&i.Entity,
 		&i.Name,
-		&i.Description,
-		&i.BaseUrl,
+		&i.Label,
+		&i.FieldType,
+		pq.Array(&i.Options),
+		&i.IsRequired,
+		&i.DefaultValue,
+		&i.Position,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getLatestCodeByPrefix = `-- name: GetLatestCodeByPrefix :one
+const getEnvironment = `-- name: GetEnvironment :one
+SELECT id, project_id, name, description, base_url, created_at, updated_at FROM environments WHERE id = $1
+`
+
+func (q *Queries) GetEnvironment(ctx context.Context, id int32) (Environment, error) {
+	row := q.db.QueryRowContext(ctx, getEnvironment, id)
+	var i Environment
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.BaseUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getInvite = `-- name: GetInvite :one
+SELECT id, sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, accepted_at, accepted_by_id, revoked_at, created_at FROM invites WHERE id = $1
+`
+
+func (q *Queries) GetInvite(ctx context.Context, id int32) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, getInvite, id)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.SenderEmail,
+		&i.ReceiverEmail,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.InvitedByID,
+		&i.OrgID,
+		&i.ProjectID,
+		&i.Role,
+		&i.AcceptedAt,
+		&i.AcceptedByID,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInviteByToken = `-- name: GetInviteByToken :one
+SELECT id, sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, accepted_at, accepted_by_id, revoked_at, created_at FROM invites WHERE token = $1
+`
+
+func (q *Queries) GetInviteByToken(ctx context.Context, token string) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, getInviteByToken, token)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.SenderEmail,
+		&i.ReceiverEmail,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.InvitedByID,
+		&i.OrgID,
+		&i.ProjectID,
+		&i.Role,
+		&i.AcceptedAt,
+		&i.AcceptedByID,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestCodeByPrefix = `-- name: GetLatestCodeByPrefix :one
 SELECT code FROM test_cases
 WHERE code LIKE $1 || '%'
 ORDER BY code DESC
@@ -1427,6 +2392,54 @@ func (q *Queries) GetLatestCodeByPrefix(ctx context.Context, dollar_1 sql.NullSt
 	return code, err
 }
 
+const getLatestTestCaseRevision = `-- name: GetLatestTestCaseRevision :one
+SELECT test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at FROM test_case_revisions WHERE test_case_id = $1 ORDER BY revision DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestTestCaseRevision(ctx context.Context, testCaseID uuid.UUID) (TestCaseRevision, error) {
+	row := q.db.QueryRowContext(ctx, getLatestTestCaseRevision, testCaseID)
+	var i TestCaseRevision
+	err := row.Scan(
+		&i.TestCaseID,
+		&i.Revision,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		pq.Array(&i.Tags),
+		&i.Runner,
+		&i.ScriptPath,
+		&i.Steps,
+		&i.AuthorID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+SELECT id, scope, throttle_key, failed_attempts, lockouts, locked_until, last_failed_at, updated_at FROM login_throttles WHERE scope = $1 AND throttle_key = $2
+`
+
+type GetLoginThrottleParams struct {
+	Scope       string
+	ThrottleKey string
+}
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, getLoginThrottle, arg.Scope, arg.ThrottleKey)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.ID,
+		&i.Scope,
+		&i.ThrottleKey,
+		&i.FailedAttempts,
+		&i.Lockouts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getNextTestCaseSequence = `-- name: GetNextTestCaseSequence :one
 UPDATE test_case_sequences
 SET current_val = current_val +1,
@@ -1470,7 +2483,7 @@ func (q *Queries) GetOneModule(ctx context.Context, id int32) (Module, error) {
 }
 
 const getOrgByID = `-- name: GetOrgByID :one
-SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at
+SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor
 FROM orgs
 WHERE id = $1
 `
@@ -1488,10 +2501,29 @@ func (q *Queries) GetOrgByID(ctx context.Context, id int32) (Org, error) {
 		&i.CreatedByID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RequireTwoFactor,
 	)
 	return i, err
 }
 
+const getOrgMemberRole = `-- name: GetOrgMemberRole :one
+SELECT role FROM org_members
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL
+LIMIT 1
+`
+
+type GetOrgMemberRoleParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) GetOrgMemberRole(ctx context.Context, arg GetOrgMemberRoleParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getOrgMemberRole, arg.OrgID, arg.UserID)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
 const getPage = `-- name: GetPage :one
 SELECT id, parent_page_id, page_version, org_id, project_id, code, title, file_path, content, page_type, mime_type, has_embedded_media, external_content_url, notion_url, last_edited_by, created_by, created_at, updated_at, deleted_at FROM pages WHERE id = $1
 `
@@ -1524,7 +2556,7 @@ func (q *Queries) GetPage(ctx context.Context, id int32) (Page, error) {
 }
 
 const getProject = `-- name: GetProject :one
-SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners FROM projects WHERE id = $1
+SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern FROM projects WHERE id = $1
 `
 
 func (q *Queries) GetProject(ctx context.Context, id int32) (Project, error) {
@@ -1551,16 +2583,59 @@ func (q *Queries) GetProject(ctx context.Context, id int32) (Project, error) {
 		&i.TestcaseTemplate,
 		&i.AutomatedTestingEnabled,
 		pq.Array(&i.SupportedRunners),
+		&i.OrgID,
+		&i.TestcaseCodePattern,
+	)
+	return i, err
+}
+
+const getProjectAccess = `-- name: GetProjectAccess :one
+SELECT
+    p.owner_user_id,
+    p.org_id,
+    COALESCE((SELECT u.is_super_admin FROM users u WHERE u.id = $2), false)::boolean AS is_super_admin,
+    COALESCE((SELECT pt.role FROM project_testers pt WHERE pt.project_id = p.id AND pt.user_id = $2 AND pt.is_active), '')::text AS role
+FROM projects p
+WHERE p.id = $1 AND p.deleted_at IS NULL
+`
+
+type GetProjectAccessParams struct {
+	ID     int32
+	UserID int32
+}
+
+type GetProjectAccessRow struct {
+	OwnerUserID  int32
+	OrgID        int32
+	IsSuperAdmin bool
+	Role         string
+}
+
+func (q *Queries) GetProjectAccess(ctx context.Context, arg GetProjectAccessParams) (GetProjectAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getProjectAccess, arg.ID, arg.UserID)
+	var i GetProjectAccessRow
+	err := row.Scan(
+		&i.OwnerUserID,
+		&i.OrgID,
+		&i.IsSuperAdmin,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getProjectCount = `-- name: GetProjectCount :one
 SELECT COUNT(*) FROM projects
+WHERE org_id = $1
+AND ($2::int[] IS NULL OR id = ANY($2::int[]))
 `
 
-func (q *Queries) GetProjectCount(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getProjectCount)
+type GetProjectCountParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
+func (q *Queries) GetProjectCount(ctx context.Context, arg GetProjectCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getProjectCount, arg.OrgID, pq.Array(arg.ProjectIds))
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -1604,6 +2679,49 @@ func (q *Queries) GetProjectModules(ctx context.Context, projectID int32) ([]Mod
 	return items, nil
 }
 
+const getProjectOrgID = `-- name: GetProjectOrgID :one
+SELECT org_id FROM projects WHERE id = $1
+`
+
+func (q *Queries) GetProjectOrgID(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getProjectOrgID, id)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
+}
+
+const getProjectSubtreeIDs = `-- name: GetProjectSubtreeIDs :many
+WITH RECURSIVE subtree AS (
+    SELECT p.id FROM projects p WHERE p.id = $1
+    UNION
+    SELECT c.id FROM projects c INNER JOIN subtree s ON c.parent_project_id = s.id
+)
+SELECT id FROM subtree
+`
+
+func (q *Queries) GetProjectSubtreeIDs(ctx context.Context, id int32) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getProjectSubtreeIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProjectTestCaseTemplate = `-- name: GetProjectTestCaseTemplate :one
 SELECT testcase_template FROM projects WHERE id = $1
 `
@@ -1618,18 +2736,25 @@ func (q *Queries) GetProjectTestCaseTemplate(ctx context.Context, id int32) (sql
 const getRecentProjects = `-- name: GetRecentProjects :many
 SELECT id, title AS name, updated_at
 FROM projects
+WHERE org_id = $1
+AND ($2::int[] IS NULL OR id = ANY($2::int[]))
 ORDER BY updated_at DESC
 LIMIT 5
 `
 
+type GetRecentProjectsParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
 type GetRecentProjectsRow struct {
 	ID        int32
 	Name      string
 	UpdatedAt time.Time
 }
 
-func (q *Queries) GetRecentProjects(ctx context.Context) ([]GetRecentProjectsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getRecentProjects)
+func (q *Queries) GetRecentProjects(ctx context.Context, arg GetRecentProjectsParams) ([]GetRecentProjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentProjects, arg.OrgID, pq.Array(arg.ProjectIds))
 	if err != nil {
 		return nil, err
 	}
@@ -1651,6 +2776,28 @@ func (q *Queries) GetRecentProjects(ctx context.Context) ([]GetRecentProjectsRow
 	return items, nil
 }
 
+const getRefreshTokenByHash = `-- name: GetRefreshTokenByHash :one
+SELECT id, user_id, family_id, token_hash, user_agent, ip_address, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1
+`
+
+func (q *Queries) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenByHash, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.TokenHash,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getReport = `-- name: GetReport :one
 SELECT id, project_id, name, type, status, created_at, file_path, updated_at FROM reports WHERE id = $1
 `
@@ -1699,6 +2846,96 @@ func (q *Queries) GetReportCountSummary(ctx context.Context, projectID int32) (G
 	return i, err
 }
 
+const getSCIMUser = `-- name: GetSCIMUser :one
+SELECT u.id, u.first_name, u.last_name, u.display_name, u.email, u.is_activated, u.created_at, u.updated_at,
+    s.external_id, s.user_name, s.manages_account,
+    COALESCE((
+        SELECT m.role FROM org_members m
+        WHERE m.org_id = s.org_id AND m.user_id = s.user_id AND m.removed_at IS NULL
+        ORDER BY m.id DESC LIMIT 1
+    ), '')::text AS role
+FROM scim_users s
+INNER JOIN users u ON u.id = s.user_id
+WHERE s.org_id = $1 AND s.user_id = $2 AND u.deleted_at IS NULL
+`
+
+type GetSCIMUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+type GetSCIMUserRow struct {
+	ID             int32
+	FirstName      string
+	LastName       string
+	DisplayName    sql.NullString
+	Email          string
+	IsActivated    sql.NullBool
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	ExternalID     sql.NullString
+	UserName       string
+	ManagesAccount bool
+	Role           string
+}
+
+func (q *Queries) GetSCIMUser(ctx context.Context, arg GetSCIMUserParams) (GetSCIMUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getSCIMUser, arg.OrgID, arg.UserID)
+	var i GetSCIMUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.Email,
+		&i.IsActivated,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExternalID,
+		&i.UserName,
+		&i.ManagesAccount,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getSessionOrg = `-- name: GetSessionOrg :one
+SELECT org_id FROM session_orgs WHERE family_id = $1 AND user_id = $2
+`
+
+type GetSessionOrgParams struct {
+	FamilyID uuid.UUID
+	UserID   int32
+}
+
+func (q *Queries) GetSessionOrg(ctx context.Context, arg GetSessionOrgParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getSessionOrg, arg.FamilyID, arg.UserID)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
+}
+
+const getSharedStep = `-- name: GetSharedStep :one
+SELECT id, project_id, name, description, parameters, steps, created_by_id, created_at, updated_at FROM shared_steps WHERE id = $1
+`
+
+func (q *Queries) GetSharedStep(ctx context.Context, id int32) (SharedStep, error) {
+	row := q.db.QueryRowContext(ctx, getSharedStep, id)
+	var i SharedStep
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.Parameters,
+		&i.Steps,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTestCase = `-- name: GetTestCase :one
 SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases WHERE id = $1
 `
@@ -1763,15 +3000,40 @@ func (q *Queries) GetTestCaseByCode(ctx context.Context, arg GetTestCaseByCodePa
 
 const getTestCaseCount = `-- name: GetTestCaseCount :one
 SELECT COUNT(*) FROM test_cases
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+AND ($2::int[] IS NULL OR project_id = ANY($2::int[]))
 `
 
-func (q *Queries) GetTestCaseCount(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getTestCaseCount)
+type GetTestCaseCountParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
+func (q *Queries) GetTestCaseCount(ctx context.Context, arg GetTestCaseCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTestCaseCount, arg.OrgID, pq.Array(arg.ProjectIds))
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const getTestCaseDataset = `-- name: GetTestCaseDataset :one
+SELECT test_case_id, parameters, rows, updated_by_id, created_at, updated_at FROM test_case_datasets WHERE test_case_id = $1
+`
+
+func (q *Queries) GetTestCaseDataset(ctx context.Context, testCaseID uuid.UUID) (TestCaseDataset, error) {
+	row := q.db.QueryRowContext(ctx, getTestCaseDataset, testCaseID)
+	var i TestCaseDataset
+	err := row.Scan(
+		&i.TestCaseID,
+		pq.Array(&i.Parameters),
+		&i.Rows,
+		&i.UpdatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTestCaseExecutionSummary = `-- name: GetTestCaseExecutionSummary :many
 SELECT
     tr.test_case_id,
@@ -1781,9 +3043,15 @@ SELECT
 FROM test_run_results trr
 INNER JOIN test_runs tr ON tr.id = trr.test_run_id
 WHERE trr.executed_by = $1
+AND tr.project_id IN (SELECT id FROM projects WHERE org_id = $2)
 GROUP BY tr.test_case_id
 `
 
+type GetTestCaseExecutionSummaryParams struct {
+	ExecutedBy sql.NullInt32
+	OrgID      int32
+}
+
 type GetTestCaseExecutionSummaryRow struct {
 	TestCaseID   uuid.UUID
 	UsageCount   int64
@@ -1791,8 +3059,8 @@ type GetTestCaseExecutionSummaryRow struct {
 	FailureCount int64
 }
 
-func (q *Queries) GetTestCaseExecutionSummary(ctx context.Context, executedBy sql.NullInt32) ([]GetTestCaseExecutionSummaryRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTestCaseExecutionSummary, executedBy)
+func (q *Queries) GetTestCaseExecutionSummary(ctx context.Context, arg GetTestCaseExecutionSummaryParams) ([]GetTestCaseExecutionSummaryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTestCaseExecutionSummary, arg.ExecutedBy, arg.OrgID)
 	if err != nil {
 		return nil, err
 	}
@@ -1819,6 +3087,59 @@ func (q *Queries) GetTestCaseExecutionSummary(ctx context.Context, executedBy sq
 	return items, nil
 }
 
+const getTestCaseOrgID = `-- name: GetTestCaseOrgID :one
+SELECT p.org_id
+FROM test_cases tc
+INNER JOIN projects p ON p.id = tc.project_id
+WHERE tc.id = $1
+`
+
+func (q *Queries) GetTestCaseOrgID(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestCaseOrgID, id)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
+}
+
+const getTestCaseProjectID = `-- name: GetTestCaseProjectID :one
+SELECT project_id FROM test_cases WHERE id = $1
+`
+
+func (q *Queries) GetTestCaseProjectID(ctx context.Context, id uuid.UUID) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, getTestCaseProjectID, id)
+	var projectID sql.NullInt32
+	err := row.Scan(&projectID)
+	return projectID, err
+}
+
+const getTestCaseRevision = `-- name: GetTestCaseRevision :one
+SELECT test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at FROM test_case_revisions WHERE test_case_id = $1 AND revision = $2
+`
+
+type GetTestCaseRevisionParams struct {
+	TestCaseID uuid.UUID
+	Revision   int32
+}
+
+func (q *Queries) GetTestCaseRevision(ctx context.Context, arg GetTestCaseRevisionParams) (TestCaseRevision, error) {
+	row := q.db.QueryRowContext(ctx, getTestCaseRevision, arg.TestCaseID, arg.Revision)
+	var i TestCaseRevision
+	err := row.Scan(
+		&i.TestCaseID,
+		&i.Revision,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		pq.Array(&i.Tags),
+		&i.Runner,
+		&i.ScriptPath,
+		&i.Steps,
+		&i.AuthorID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getTestCaseWithParent = `-- name: GetTestCaseWithParent :one
 SELECT
   tc.id,
@@ -2075,17 +3396,64 @@ func (q *Queries) GetTestPlan(ctx context.Context, id int64) (GetTestPlanRow, er
 	return i, err
 }
 
+const getTestPlanCommentOrgID = `-- name: GetTestPlanCommentOrgID :one
+SELECT p.org_id
+FROM test_plan_comments c
+INNER JOIN test_plans tp ON tp.id = c.test_plan_id
+INNER JOIN projects p ON p.id = tp.project_id
+WHERE c.id = $1
+`
+
+func (q *Queries) GetTestPlanCommentOrgID(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestPlanCommentOrgID, id)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
+}
+
 const getTestPlanCount = `-- name: GetTestPlanCount :one
 SELECT COUNT(*) FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+AND ($2::int[] IS NULL OR project_id = ANY($2::int[]))
 `
 
-func (q *Queries) GetTestPlanCount(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getTestPlanCount)
+type GetTestPlanCountParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
+func (q *Queries) GetTestPlanCount(ctx context.Context, arg GetTestPlanCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTestPlanCount, arg.OrgID, pq.Array(arg.ProjectIds))
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const getTestPlanOrgID = `-- name: GetTestPlanOrgID :one
+SELECT p.org_id
+FROM test_plans tp
+INNER JOIN projects p ON p.id = tp.project_id
+WHERE tp.id = $1
+`
+
+func (q *Queries) GetTestPlanOrgID(ctx context.Context, id int64) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestPlanOrgID, id)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
+}
+
+const getTestPlanProjectID = `-- name: GetTestPlanProjectID :one
+SELECT project_id FROM test_plans WHERE id = $1
+`
+
+func (q *Queries) GetTestPlanProjectID(ctx context.Context, id int64) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestPlanProjectID, id)
+	var projectID int32
+	err := row.Scan(&projectID)
+	return projectID, err
+}
+
 const getTestPlanRunStats = `-- name: GetTestPlanRunStats :one
 SELECT
     COUNT(*) FILTER (WHERE result_state = 'passed') AS passed_count,
@@ -2120,22 +3488,29 @@ SELECT
 COUNT(*) FILTER (WHERE is_complete = true) AS closed,
 COUNT(*) FILTER (WHERE is_complete = false) AS open
 FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+AND ($2::int[] IS NULL OR project_id = ANY($2::int[]))
 `
 
+type GetTestPlanStatusRatioParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
 type GetTestPlanStatusRatioRow struct {
 	Closed int64
 	Open   int64
 }
 
-func (q *Queries) GetTestPlanStatusRatio(ctx context.Context) (GetTestPlanStatusRatioRow, error) {
-	row := q.db.QueryRowContext(ctx, getTestPlanStatusRatio)
+func (q *Queries) GetTestPlanStatusRatio(ctx context.Context, arg GetTestPlanStatusRatioParams) (GetTestPlanStatusRatioRow, error) {
+	row := q.db.QueryRowContext(ctx, getTestPlanStatusRatio, arg.OrgID, pq.Array(arg.ProjectIds))
 	var i GetTestPlanStatusRatioRow
 	err := row.Scan(&i.Closed, &i.Open)
 	return i, err
 }
 
 const getTestRun = `-- name: GetTestRun :one
-SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id FROM test_runs WHERE id = $1
+SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, test_case_revision, data_row, data_values FROM test_runs WHERE id = $1
 `
 
 func (q *Queries) GetTestRun(ctx context.Context, id uuid.UUID) (TestRun, error) {
@@ -2162,28 +3537,84 @@ func (q *Queries) GetTestRun(ctx context.Context, id uuid.UUID) (TestRun, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.EnvironmentID,
+		&i.TestCaseRevision,
+		&i.DataRow,
+		&i.DataValues,
 	)
 	return i, err
 }
 
-const getTestRunStatesForPlan = `-- name: GetTestRunStatesForPlan :many
-SELECT result_state, is_closed FROM test_runs WHERE test_plan_id = $1
+const getTestRunOrgID = `-- name: GetTestRunOrgID :one
+SELECT p.org_id
+FROM test_runs tr
+INNER JOIN projects p ON p.id = tr.project_id
+WHERE tr.id = $1
 `
 
-type GetTestRunStatesForPlanRow struct {
-	ResultState TestRunState
-	IsClosed    sql.NullBool
+func (q *Queries) GetTestRunOrgID(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestRunOrgID, id)
+	var orgID int32
+	err := row.Scan(&orgID)
+	return orgID, err
 }
 
-func (q *Queries) GetTestRunStatesForPlan(ctx context.Context, testPlanID sql.NullInt32) ([]GetTestRunStatesForPlanRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTestRunStatesForPlan, testPlanID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetTestRunStatesForPlanRow
-	for rows.Next() {
-		var i GetTestRunStatesForPlanRow
+const getTestRunProjectID = `-- name: GetTestRunProjectID :one
+SELECT project_id FROM test_runs WHERE id = $1
+`
+
+func (q *Queries) GetTestRunProjectID(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getTestRunProjectID, id)
+	var projectID int32
+	err := row.Scan(&projectID)
+	return projectID, err
+}
+
+const getTestRunResultCounts = `-- name: GetTestRunResultCounts :one
+SELECT
+    COUNT(*) FILTER (WHERE result_state = 'passed') AS passed_count,
+    COUNT(*) FILTER (WHERE result_state = 'failed') AS failed_count,
+    COUNT(*) FILTER (WHERE result_state = 'pending') AS pending_count
+FROM test_runs
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+AND ($2::int[] IS NULL OR project_id = ANY($2::int[]))
+`
+
+type GetTestRunResultCountsParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
+type GetTestRunResultCountsRow struct {
+	PassedCount  int64
+	FailedCount  int64
+	PendingCount int64
+}
+
+func (q *Queries) GetTestRunResultCounts(ctx context.Context, arg GetTestRunResultCountsParams) (GetTestRunResultCountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getTestRunResultCounts, arg.OrgID, pq.Array(arg.ProjectIds))
+	var i GetTestRunResultCountsRow
+	err := row.Scan(&i.PassedCount, &i.FailedCount, &i.PendingCount)
+	return i, err
+}
+
+const getTestRunStatesForPlan = `-- name: GetTestRunStatesForPlan :many
+SELECT result_state, is_closed FROM test_runs WHERE test_plan_id = $1
+`
+
+type GetTestRunStatesForPlanRow struct {
+	ResultState TestRunState
+	IsClosed    sql.NullBool
+}
+
+func (q *Queries) GetTestRunStatesForPlan(ctx context.Context, testPlanID sql.NullInt32) ([]GetTestRunStatesForPlanRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTestRunStatesForPlan, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTestRunStatesForPlanRow
+	for rows.Next() {
+		var i GetTestRunStatesForPlanRow
 		if err := rows.Scan(&i.ResultState, &i.IsClosed); err != nil {
 			return nil, err
 		}
@@ -2245,11 +3676,20 @@ func (q *Queries) GetTesterByID(ctx context.Context, userID int32) (GetTesterByI
 }
 
 const getTesterCount = `-- name: GetTesterCount :one
-SELECT COUNT(DISTINCT user_id) FROM project_testers WHERE is_active = true
+SELECT COUNT(DISTINCT pt.user_id)
+FROM project_testers pt
+INNER JOIN projects p ON p.id = pt.project_id
+WHERE pt.is_active = true AND p.org_id = $1
+AND ($2::int[] IS NULL OR p.id = ANY($2::int[]))
 `
 
-func (q *Queries) GetTesterCount(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getTesterCount)
+type GetTesterCountParams struct {
+	OrgID      int32
+	ProjectIds []int32
+}
+
+func (q *Queries) GetTesterCount(ctx context.Context, arg GetTesterCountParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTesterCount, arg.OrgID, pq.Array(arg.ProjectIds))
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -2328,6 +3768,24 @@ func (q *Queries) GetTestersByProject(ctx context.Context, projectID int32) ([]G
 	return items, nil
 }
 
+const getTotpCredential = `-- name: GetTotpCredential :one
+SELECT user_id, secret, confirmed_at, last_used_step, created_at, updated_at FROM totp_credentials WHERE user_id = $1
+`
+
+func (q *Queries) GetTotpCredential(ctx context.Context, userID int32) (TotpCredential, error) {
+	row := q.db.QueryRowContext(ctx, getTotpCredential, userID)
+	var i TotpCredential
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
 SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users WHERE id = $1
 `
@@ -2360,6 +3818,237 @@ func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
 	return i, err
 }
 
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users WHERE email = $1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.FirstName,
+		&i.LastName,
+		&i.DisplayName,
+		&i.Email,
+		&i.Password,
+		&i.Phone,
+		&i.OrgID,
+		&i.CountryIso,
+		&i.City,
+		&i.Address,
+		&i.IsActivated,
+		&i.IsReviewed,
+		&i.IsSuperAdmin,
+		&i.IsVerified,
+		&i.LastLoginAt,
+		&i.EmailConfirmedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT i.user_id, u.email, u.display_name
+FROM user_identities i
+INNER JOIN users u ON u.id = i.user_id
+WHERE i.provider = $1 AND i.subject = $2
+AND u.is_activated AND u.deleted_at IS NULL
+`
+
+type GetUserIdentityParams struct {
+	Provider string
+	Subject  string
+}
+
+type GetUserIdentityRow struct {
+	UserID      int32
+	Email       string
+	DisplayName sql.NullString
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (GetUserIdentityRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserIdentity, arg.Provider, arg.Subject)
+	var i GetUserIdentityRow
+	err := row.Scan(&i.UserID, &i.Email, &i.DisplayName)
+	return i, err
+}
+
+const importPage = `-- name: ImportPage :one
+INSERT INTO pages (
+    parent_page_id, page_version, org_id, project_id, code, title, file_path, content, page_type, mime_type, has_embedded_media, external_content_url, notion_url, last_edited_by, created_by, created_at, updated_at, deleted_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+)
+RETURNING id
+`
+
+type ImportPageParams struct {
+	ParentPageID       sql.NullInt32
+	PageVersion        string
+	OrgID              int32
+	ProjectID          int32
+	Code               string
+	Title              string
+	FilePath           sql.NullString
+	Content            string
+	PageType           string
+	MimeType           string
+	HasEmbeddedMedia   bool
+	ExternalContentUrl sql.NullString
+	NotionUrl          sql.NullString
+	LastEditedBy       int32
+	CreatedBy          int32
+	CreatedAt          sql.NullTime
+	UpdatedAt          sql.NullTime
+	DeletedAt          sql.NullTime
+}
+
+func (q *Queries) ImportPage(ctx context.Context, arg ImportPageParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, importPage,
+		arg.ParentPageID,
+		arg.PageVersion,
+		arg.OrgID,
+		arg.ProjectID,
+		arg.Code,
+		arg.Title,
+		arg.FilePath,
+		arg.Content,
+		arg.PageType,
+		arg.MimeType,
+		arg.HasEmbeddedMedia,
+		arg.ExternalContentUrl,
+		arg.NotionUrl,
+		arg.LastEditedBy,
+		arg.CreatedBy,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.DeletedAt,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const importReport = `-- name: ImportReport :exec
+INSERT INTO reports (id, project_id, name, type, status, created_at, file_path, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type ImportReportParams struct {
+	ID        uuid.UUID
+	ProjectID int32
+	Name      string
+	Type      string
+	Status    string
+	CreatedAt sql.NullTime
+	FilePath  sql.NullString
+	UpdatedAt sql.NullTime
+}
+
+func (q *Queries) ImportReport(ctx context.Context, arg ImportReportParams) error {
+	_, err := q.db.ExecContext(ctx, importReport,
+		arg.ID,
+		arg.ProjectID,
+		arg.Name,
+		arg.Type,
+		arg.Status,
+		arg.CreatedAt,
+		arg.FilePath,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const importTestPlanComment = `-- name: ImportTestPlanComment :exec
+INSERT INTO test_plan_comments (id, test_plan_id, user_id, content, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type ImportTestPlanCommentParams struct {
+	ID         uuid.UUID
+	TestPlanID int64
+	UserID     int64
+	Content    string
+	CreatedAt  sql.NullTime
+	UpdatedAt  sql.NullTime
+}
+
+func (q *Queries) ImportTestPlanComment(ctx context.Context, arg ImportTestPlanCommentParams) error {
+	_, err := q.db.ExecContext(ctx, importTestPlanComment,
+		arg.ID,
+		arg.TestPlanID,
+		arg.UserID,
+		arg.Content,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const importTestRun = `-- name: ImportTestRun :exec
+INSERT INTO test_runs (
+    id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, data_row, data_values
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
+)
+`
+
+type ImportTestRunParams struct {
+	ID                    uuid.UUID
+	ProjectID             int32
+	TestPlanID            sql.NullInt32
+	TestCaseID            uuid.UUID
+	OwnerID               int32
+	TestedByID            sql.NullInt32
+	AssignedToID          sql.NullInt32
+	AssigneeCanChangeCode sql.NullBool
+	Code                  string
+	ExternalIssueID       sql.NullString
+	ResultState           TestRunState
+	IsClosed              sql.NullBool
+	Notes                 string
+	ActualResult          sql.NullString
+	ExpectedResult        sql.NullString
+	Reactions             pqtype.NullRawMessage
+	TestedOn              time.Time
+	CreatedAt             sql.NullTime
+	UpdatedAt             sql.NullTime
+	EnvironmentID         sql.NullInt32
+	DataRow               sql.NullInt32
+	DataValues            pqtype.NullRawMessage
+}
+
+func (q *Queries) ImportTestRun(ctx context.Context, arg ImportTestRunParams) error {
+	_, err := q.db.ExecContext(ctx, importTestRun,
+		arg.ID,
+		arg.ProjectID,
+		arg.TestPlanID,
+		arg.TestCaseID,
+		arg.OwnerID,
+		arg.TestedByID,
+		arg.AssignedToID,
+		arg.AssigneeCanChangeCode,
+		arg.Code,
+		arg.ExternalIssueID,
+		arg.ResultState,
+		arg.IsClosed,
+		arg.Notes,
+		arg.ActualResult,
+		arg.ExpectedResult,
+		arg.Reactions,
+		arg.TestedOn,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.EnvironmentID,
+		arg.DataRow,
+		arg.DataValues,
+	)
+	return err
+}
+
 const initTestCaseSequence = `-- name: InitTestCaseSequence :exec
 INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
 VALUES ($1, $2, 0, now())
@@ -2414,6 +4103,20 @@ func (q *Queries) InsertTestRunResult(ctx context.Context, arg InsertTestRunResu
 	return id, err
 }
 
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :execrows
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, invalidatePasswordResetTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const isTestCaseActive = `-- name: IsTestCaseActive :one
 SELECT is_draft FROM test_cases WHERE id = $1
 `
@@ -2482,48 +4185,70 @@ func (q *Queries) IsTestPlanActive(ctx context.Context, id int64) (IsTestPlanAct
 	return i, err
 }
 
-const listCommentsByTestPlan = `-- name: ListCommentsByTestPlan :many
-SELECT 
-    c.id,
-    c.test_plan_id,
-    c.user_id,
-    u.display_name,   
-    c.content,
-    c.created_at,
-    c.updated_at
-FROM test_plan_comments c
-JOIN users u ON u.id = c.user_id
-WHERE c.test_plan_id = $1
-ORDER BY c.created_at DESC
+const isUserSuperAdmin = `-- name: IsUserSuperAdmin :one
+SELECT COALESCE(is_super_admin, false)::boolean AS is_super_admin FROM users WHERE id = $1
 `
 
-type ListCommentsByTestPlanRow struct {
-	ID          uuid.UUID
-	TestPlanID  int64
-	UserID      int64
-	DisplayName sql.NullString
-	Content     string
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+func (q *Queries) IsUserSuperAdmin(ctx context.Context, id int32) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUserSuperAdmin, id)
+	var isSuperAdmin bool
+	err := row.Scan(&isSuperAdmin)
+	return isSuperAdmin, err
 }
 
-func (q *Queries) ListCommentsByTestPlan(ctx context.Context, testPlanID int64) ([]ListCommentsByTestPlanRow, error) {
-	rows, err := q.db.QueryContext(ctx, listCommentsByTestPlan, testPlanID)
+const linkSCIMUser = `-- name: LinkSCIMUser :exec
+INSERT INTO scim_users (org_id, user_id, external_id, user_name, manages_account, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, now(), now())
+ON CONFLICT (org_id, user_id) DO UPDATE SET external_id = EXCLUDED.external_id, user_name = EXCLUDED.user_name, updated_at = now()
+`
+
+type LinkSCIMUserParams struct {
+	OrgID          int32
+	UserID         int32
+	ExternalID     sql.NullString
+	UserName       string
+	ManagesAccount bool
+}
+
+func (q *Queries) LinkSCIMUser(ctx context.Context, arg LinkSCIMUserParams) error {
+	_, err := q.db.ExecContext(ctx, linkSCIMUser,
+		arg.OrgID,
+		arg.UserID,
+		arg.ExternalID,
+		arg.UserName,
+		arg.ManagesAccount,
+	)
+	return err
+}
+
+const listApiTokensByCreator = `-- name: ListApiTokensByCreator :many
+SELECT id, user_id, created_by_id, name, token_prefix, token_hash, scopes, project_id, expires_at, last_used_at, revoked_at, created_at FROM api_tokens
+WHERE created_by_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListApiTokensByCreator(ctx context.Context, createdByID int32) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, listApiTokensByCreator, createdByID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListCommentsByTestPlanRow
+	var items []ApiToken
 	for rows.Next() {
-		var i ListCommentsByTestPlanRow
+		var i ApiToken
 		if err := rows.Scan(
 			&i.ID,
-			&i.TestPlanID,
 			&i.UserID,
-			&i.DisplayName,
-			&i.Content,
+			&i.CreatedByID,
+			&i.Name,
+			&i.TokenPrefix,
+			&i.TokenHash,
+			pq.Array(&i.Scopes),
+			&i.ProjectID,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -2538,27 +4263,42 @@ func (q *Queries) ListCommentsByTestPlan(ctx context.Context, testPlanID int64)
 	return items, nil
 }
 
-const listEnvironmentsByProject = `-- name: ListEnvironmentsByProject :many
-SELECT id, project_id, name, description, base_url, created_at, updated_at FROM environments WHERE project_id = $1 ORDER BY name
+const listChildProjects = `-- name: ListChildProjects :many
+SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern FROM projects WHERE parent_project_id = $1 ORDER BY title
 `
 
-func (q *Queries) ListEnvironmentsByProject(ctx context.Context, projectID sql.NullInt32) ([]Environment, error) {
-	rows, err := q.db.QueryContext(ctx, listEnvironmentsByProject, projectID)
+func (q *Queries) ListChildProjects(ctx context.Context, parentProjectID sql.NullInt32) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listChildProjects, parentProjectID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Environment
+	var items []Project
 	for rows.Next() {
-		var i Environment
+		var i Project
 		if err := rows.Scan(
 			&i.ID,
-			&i.ProjectID,
-			&i.Name,
+			&i.Title,
 			&i.Description,
-			&i.BaseUrl,
+			&i.Version,
+			&i.IsActive,
+			&i.IsPublic,
+			&i.WebsiteUrl,
+			&i.GithubUrl,
+			&i.TrelloUrl,
+			&i.JiraUrl,
+			&i.MondayUrl,
+			&i.OwnerUserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Code,
+			&i.ParentProjectID,
+			&i.TestcaseTemplate,
+			&i.AutomatedTestingEnabled,
+			pq.Array(&i.SupportedRunners),
+			&i.OrgID,
+			&i.TestcaseCodePattern,
 		); err != nil {
 			return nil, err
 		}
@@ -2573,29 +4313,46 @@ func (q *Queries) ListEnvironmentsByProject(ctx context.Context, projectID sql.N
 	return items, nil
 }
 
-const listOrgs = `-- name: ListOrgs :many
-SELECT id, name, address, country, github_url, website_url, created_by_id,  created_at, updated_at
-FROM orgs
-ORDER BY name
+const listCommentsByTestPlan = `-- name: ListCommentsByTestPlan :many
+SELECT 
+    c.id,
+    c.test_plan_id,
+    c.user_id,
+    u.display_name,   
+    c.content,
+    c.created_at,
+    c.updated_at
+FROM test_plan_comments c
+JOIN users u ON u.id = c.user_id
+WHERE c.test_plan_id = $1
+ORDER BY c.created_at DESC
 `
 
-func (q *Queries) ListOrgs(ctx context.Context) ([]Org, error) {
-	rows, err := q.db.QueryContext(ctx, listOrgs)
+type ListCommentsByTestPlanRow struct {
+	ID          uuid.UUID
+	TestPlanID  int64
+	UserID      int64
+	DisplayName sql.NullString
+	Content     string
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
+
+func (q *Queries) ListCommentsByTestPlan(ctx context.Context, testPlanID int64) ([]ListCommentsByTestPlanRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentsByTestPlan, testPlanID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Org
+	var items []ListCommentsByTestPlanRow
 	for rows.Next() {
-		var i Org
+		var i ListCommentsByTestPlanRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Address,
-			&i.Country,
-			&i.GithubUrl,
-			&i.WebsiteUrl,
-			&i.CreatedByID,
+			&i.TestPlanID,
+			&i.UserID,
+			&i.DisplayName,
+			&i.Content,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -2612,41 +4369,30 @@ func (q *Queries) ListOrgs(ctx context.Context) ([]Org, error) {
 	return items, nil
 }
 
-const listProjects = `-- name: ListProjects :many
-SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners FROM projects ORDER BY created_at DESC
+const listCustomFieldValues = `-- name: ListCustomFieldValues :many
+SELECT v.entity_id, f.name, v.value
+FROM custom_field_values v
+INNER JOIN custom_fields f ON f.id = v.field_id
+WHERE v.entity_id = ANY($1::uuid[])
+ORDER BY f.position, f.id
 `
 
-func (q *Queries) ListProjects(ctx context.Context) ([]Project, error) {
-	rows, err := q.db.QueryContext(ctx, listProjects)
+type ListCustomFieldValuesRow struct {
+	EntityID uuid.UUID
+	Name     string
+	Value    json.RawMessage
+}
+
+func (q *Queries) ListCustomFieldValues(ctx context.Context, entityIds []uuid.UUID) ([]ListCustomFieldValuesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomFieldValues, pq.Array(entityIds))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Project
+	var items []ListCustomFieldValuesRow
 	for rows.Next() {
-		var i Project
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.Version,
-			&i.IsActive,
-			&i.IsPublic,
-			&i.WebsiteUrl,
-			&i.GithubUrl,
-			&i.TrelloUrl,
-			&i.JiraUrl,
-			&i.MondayUrl,
-			&i.OwnerUserID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DeletedAt,
-			&i.Code,
-			&i.ParentProjectID,
-			&i.TestcaseTemplate,
-			&i.AutomatedTestingEnabled,
-			pq.Array(&i.SupportedRunners),
-		); err != nil {
+		var i ListCustomFieldValuesRow
+		if err := rows.Scan(&i.EntityID, &i.Name, &i.Value); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -2660,27 +4406,36 @@ func (q *Queries) ListProjects(ctx context.Context) ([]Project, error) {
 	return items, nil
 }
 
-const listReportsByProject = `-- name: ListReportsByProject :many
-SELECT id, project_id, name, type, status, created_at, file_path, updated_at FROM reports WHERE project_id = $1 ORDER BY created_at DESC
+const listCustomFieldsByEntity = `-- name: ListCustomFieldsByEntity :many
+SELECT id, project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at FROM custom_fields WHERE project_id = $1 AND entity = $2 ORDER BY position, id
 `
 
-func (q *Queries) ListReportsByProject(ctx context.Context, projectID int32) ([]Report, error) {
-	rows, err := q.db.QueryContext(ctx, listReportsByProject, projectID)
+type ListCustomFieldsByEntityParams struct {
+	ProjectID int32
+	Entity    string
+}
+
+func (q *Queries) ListCustomFieldsByEntity(ctx context.Context, arg ListCustomFieldsByEntityParams) ([]CustomField, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomFieldsByEntity, arg.ProjectID, arg.Entity)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Report
+	var items []CustomField
 	for rows.Next() {
-		var i Report
+		var i CustomField
 		if err := rows.Scan(
 			&i.ID,
 			&i.ProjectID,
+			&i.Entity,
 			&i.Name,
-			&i.Type,
-			&i.Status,
+			&i.Label,
+			&i.FieldType,
+			pq.Array(&i.Options),
+			&i.IsRequired,
+			&i.DefaultValue,
+			&i.Position,
 			&i.CreatedAt,
-			&i.FilePath,
 			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -2696,41 +4451,32 @@ func (q *Queries) ListReportsByProject(ctx context.Context, projectID int32) ([]
 	return items, nil
 }
 
-const listScriptTestCasesByPlan = `-- name: ListScriptTestCasesByPlan :many
-SELECT tc.id, tc.kind, tc.code, tc.feature_or_module, tc.title, tc.description, tc.is_draft, tc.tags, tc.created_by_id, tc.created_at, tc.updated_at, tc.project_id, tc.suggested, tc.runner, tc.script_path, tc.parent_test_case_id
-FROM test_cases tc
-INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
-WHERE pc.test_plan_id = $1
-  AND tc.script_path IS NOT NULL
-  AND tc.runner IS NOT NULL
+const listCustomFieldsByProject = `-- name: ListCustomFieldsByProject :many
+SELECT id, project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at FROM custom_fields WHERE project_id = $1 ORDER BY entity, position, id
 `
 
-func (q *Queries) ListScriptTestCasesByPlan(ctx context.Context, testPlanID int64) ([]TestCase, error) {
-	rows, err := q.db.QueryContext(ctx, listScriptTestCasesByPlan, testPlanID)
+func (q *Queries) ListCustomFieldsByProject(ctx context.Context, projectID int32) ([]CustomField, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomFieldsByProject, projectID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestCase
+	var items []CustomField
 	for rows.Next() {
-		var i TestCase
+		var i CustomField
 		if err := rows.Scan(
 			&i.ID,
-			&i.Kind,
-			&i.Code,
-			&i.FeatureOrModule,
-			&i.Title,
-			&i.Description,
-			&i.IsDraft,
-			pq.Array(&i.Tags),
-			&i.CreatedByID,
+			&i.ProjectID,
+			&i.Entity,
+			&i.Name,
+			&i.Label,
+			&i.FieldType,
+			pq.Array(&i.Options),
+			&i.IsRequired,
+			&i.DefaultValue,
+			&i.Position,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.ProjectID,
-			&i.Suggested,
-			&i.Runner,
-			&i.ScriptPath,
-			&i.ParentTestCaseID,
 		); err != nil {
 			return nil, err
 		}
@@ -2745,36 +4491,27 @@ func (q *Queries) ListScriptTestCasesByPlan(ctx context.Context, testPlanID int6
 	return items, nil
 }
 
-const listTestCases = `-- name: ListTestCases :many
-SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases ORDER BY created_at DESC
+const listEnvironmentsByProject = `-- name: ListEnvironmentsByProject :many
+SELECT id, project_id, name, description, base_url, created_at, updated_at FROM environments WHERE project_id = $1 ORDER BY name
 `
 
-func (q *Queries) ListTestCases(ctx context.Context) ([]TestCase, error) {
-	rows, err := q.db.QueryContext(ctx, listTestCases)
+func (q *Queries) ListEnvironmentsByProject(ctx context.Context, projectID sql.NullInt32) ([]Environment, error) {
+	rows, err := q.db.QueryContext(ctx, listEnvironmentsByProject, projectID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestCase
+	var items []Environment
 	for rows.Next() {
-		var i TestCase
+		var i Environment
 		if err := rows.Scan(
 			&i.ID,
-			&i.Kind,
-			&i.Code,
-			&i.FeatureOrModule,
-			&i.Title,
+			&i.ProjectID,
+			&i.Name,
 			&i.Description,
-			&i.IsDraft,
-			pq.Array(&i.Tags),
-			&i.CreatedByID,
+			&i.BaseUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.ProjectID,
-			&i.Suggested,
-			&i.Runner,
-			&i.ScriptPath,
-			&i.ParentTestCaseID,
 		); err != nil {
 			return nil, err
 		}
@@ -2789,95 +4526,33 @@ func (q *Queries) ListTestCases(ctx context.Context) ([]TestCase, error) {
 	return items, nil
 }
 
-const listTestCasesByAssignedUser = `-- name: ListTestCasesByAssignedUser :many
-SELECT
-  tc.id AS test_case_id,
-  tc.kind,
-  tc.code,
-  tc.feature_or_module,
-  tc.title,
-  tc.description,
-  tc.is_draft,
-  tc.tags,
-  tc.created_by_id,
-  tc.created_at AS test_case_created_at,
-  tc.updated_at AS test_case_updated_at,
-  tc.project_id,
-  pc.test_plan_id,
-  pc.assigned_to_id,
-  tp.environment_id,
-  COALESCE(BOOL_OR(tr.is_closed), false)::boolean AS is_closed
-FROM test_cases tc
-INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
-INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
-LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
-WHERE pc.assigned_to_id = $1
-  AND (
-    $4::bool = true
-    OR COALESCE(tr.is_closed, false) = false
-  )
-GROUP BY tc.id, pc.test_plan_id, pc.assigned_to_id, tp.environment_id
-ORDER BY tc.created_at DESC
-LIMIT $2 OFFSET $3
+const listInvitesBySender = `-- name: ListInvitesBySender :many
+SELECT id, sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, accepted_at, accepted_by_id, revoked_at, created_at FROM invites WHERE invited_by_id = $1 ORDER BY created_at DESC
 `
 
-type ListTestCasesByAssignedUserParams struct {
-	AssignedToID  int64
-	Limit         int32
-	Offset        int32
-	IncludeClosed bool
-}
-
-type ListTestCasesByAssignedUserRow struct {
-	TestCaseID        uuid.UUID
-	Kind              TestKind
-	Code              string
-	FeatureOrModule   sql.NullString
-	Title             string
-	Description       string
-	IsDraft           sql.NullBool
-	Tags              []string
-	CreatedByID       int32
-	TestCaseCreatedAt sql.NullTime
-	TestCaseUpdatedAt sql.NullTime
-	ProjectID         sql.NullInt32
-	TestPlanID        int64
-	AssignedToID      int64
-	EnvironmentID     sql.NullInt32
-	IsClosed          bool
-}
-
-func (q *Queries) ListTestCasesByAssignedUser(ctx context.Context, arg ListTestCasesByAssignedUserParams) ([]ListTestCasesByAssignedUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTestCasesByAssignedUser,
-		arg.AssignedToID,
-		arg.Limit,
-		arg.Offset,
-		arg.IncludeClosed,
-	)
+func (q *Queries) ListInvitesBySender(ctx context.Context, invitedByID sql.NullInt32) ([]Invite, error) {
+	rows, err := q.db.QueryContext(ctx, listInvitesBySender, invitedByID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListTestCasesByAssignedUserRow
+	var items []Invite
 	for rows.Next() {
-		var i ListTestCasesByAssignedUserRow
+		var i Invite
 		if err := rows.Scan(
-			&i.TestCaseID,
-			&i.Kind,
-			&i.Code,
-			&i.FeatureOrModule,
-			&i.Title,
-			&i.Description,
-			&i.IsDraft,
-			pq.Array(&i.Tags),
-			&i.CreatedByID,
-			&i.TestCaseCreatedAt,
-			&i.TestCaseUpdatedAt,
+			&i.ID,
+			&i.SenderEmail,
+			&i.ReceiverEmail,
+			&i.Token,
+			&i.ExpiresAt,
+			&i.InvitedByID,
+			&i.OrgID,
 			&i.ProjectID,
-			&i.TestPlanID,
-			&i.AssignedToID,
-			&i.EnvironmentID,
-			&i.IsClosed,
+			&i.Role,
+			&i.AcceptedAt,
+			&i.AcceptedByID,
+			&i.RevokedAt,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -2892,36 +4567,41 @@ func (q *Queries) ListTestCasesByAssignedUser(ctx context.Context, arg ListTestC
 	return items, nil
 }
 
-const listTestCasesByCreator = `-- name: ListTestCasesByCreator :many
-SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases WHERE created_by_id = $1
+const listOpenPlanCasesAssignedTo = `-- name: ListOpenPlanCasesAssignedTo :many
+SELECT pc.test_plan_id, pc.test_case_id, tp.project_id, tc.title
+FROM test_plan_cases pc
+INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
+INNER JOIN test_cases tc ON tc.id = pc.test_case_id
+WHERE pc.assigned_to_id = $1
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+)
+ORDER BY tp.project_id, pc.test_plan_id, tc.title
 `
 
-func (q *Queries) ListTestCasesByCreator(ctx context.Context, createdByID int32) ([]TestCase, error) {
-	rows, err := q.db.QueryContext(ctx, listTestCasesByCreator, createdByID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []TestCase
+type ListOpenPlanCasesAssignedToRow struct {
+	TestPlanID int64
+	TestCaseID uuid.UUID
+	ProjectID  int32
+	Title      string
+}
+
+func (q *Queries) ListOpenPlanCasesAssignedTo(ctx context.Context, assignedToID int64) ([]ListOpenPlanCasesAssignedToRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenPlanCasesAssignedTo, assignedToID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenPlanCasesAssignedToRow
 	for rows.Next() {
-		var i TestCase
+		var i ListOpenPlanCasesAssignedToRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Kind,
-			&i.Code,
-			&i.FeatureOrModule,
-			&i.Title,
-			&i.Description,
-			&i.IsDraft,
-			pq.Array(&i.Tags),
-			&i.CreatedByID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.TestPlanID,
+			&i.TestCaseID,
 			&i.ProjectID,
-			&i.Suggested,
-			&i.Runner,
-			&i.ScriptPath,
-			&i.ParentTestCaseID,
+			&i.Title,
 		); err != nil {
 			return nil, err
 		}
@@ -2936,33 +4616,28 @@ func (q *Queries) ListTestCasesByCreator(ctx context.Context, createdByID int32)
 	return items, nil
 }
 
-const listTestCasesByPlan = `-- name: ListTestCasesByPlan :many
-SELECT
-  tc.id,
-  tc.title,
-  array_agg(pc.assigned_to_id)::bigint[] AS assigned_tester_ids
-FROM test_cases tc
-INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
-WHERE pc.test_plan_id = $1
-GROUP BY tc.id, tc.title
+const listOpenTestPlansAssignedTo = `-- name: ListOpenTestPlansAssignedTo :many
+SELECT id, project_id, description FROM test_plans
+WHERE assigned_to_id = $1 AND NOT COALESCE(is_complete, false) AND closed_at IS NULL
+ORDER BY project_id, id
 `
 
-type ListTestCasesByPlanRow struct {
-	ID                uuid.UUID
-	Title             string
-	AssignedTesterIds []int64
+type ListOpenTestPlansAssignedToRow struct {
+	ID          int64
+	ProjectID   int32
+	Description sql.NullString
 }
 
-func (q *Queries) ListTestCasesByPlan(ctx context.Context, testPlanID int64) ([]ListTestCasesByPlanRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTestCasesByPlan, testPlanID)
+func (q *Queries) ListOpenTestPlansAssignedTo(ctx context.Context, assignedToID int32) ([]ListOpenTestPlansAssignedToRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenTestPlansAssignedTo, assignedToID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListTestCasesByPlanRow
+	var items []ListOpenTestPlansAssignedToRow
 	for rows.Next() {
-		var i ListTestCasesByPlanRow
-		if err := rows.Scan(&i.ID, &i.Title, pq.Array(&i.AssignedTesterIds)); err != nil {
+		var i ListOpenTestPlansAssignedToRow
+		if err := rows.Scan(&i.ID, &i.ProjectID, &i.Description); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -2976,36 +4651,33 @@ func (q *Queries) ListTestCasesByPlan(ctx context.Context, testPlanID int64) ([]
 	return items, nil
 }
 
-const listTestCasesByProject = `-- name: ListTestCasesByProject :many
-SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases WHERE project_id = $1
+const listOpenTestRunsAssignedTo = `-- name: ListOpenTestRunsAssignedTo :many
+SELECT id, project_id, test_plan_id, code FROM test_runs
+WHERE assigned_to_id = $1 AND NOT COALESCE(is_closed, false)
+ORDER BY project_id, test_plan_id, code
 `
 
-func (q *Queries) ListTestCasesByProject(ctx context.Context, projectID sql.NullInt32) ([]TestCase, error) {
-	rows, err := q.db.QueryContext(ctx, listTestCasesByProject, projectID)
+type ListOpenTestRunsAssignedToRow struct {
+	ID         uuid.UUID
+	ProjectID  int32
+	TestPlanID sql.NullInt32
+	Code       string
+}
+
+func (q *Queries) ListOpenTestRunsAssignedTo(ctx context.Context, assignedToID sql.NullInt32) ([]ListOpenTestRunsAssignedToRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenTestRunsAssignedTo, assignedToID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestCase
+	var items []ListOpenTestRunsAssignedToRow
 	for rows.Next() {
-		var i TestCase
+		var i ListOpenTestRunsAssignedToRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Kind,
-			&i.Code,
-			&i.FeatureOrModule,
-			&i.Title,
-			&i.Description,
-			&i.IsDraft,
-			pq.Array(&i.Tags),
-			&i.CreatedByID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.ProjectID,
-			&i.Suggested,
-			&i.Runner,
-			&i.ScriptPath,
-			&i.ParentTestCaseID,
+			&i.TestPlanID,
+			&i.Code,
 		); err != nil {
 			return nil, err
 		}
@@ -3020,38 +4692,44 @@ func (q *Queries) ListTestCasesByProject(ctx context.Context, projectID sql.Null
 	return items, nil
 }
 
-const listTestPlans = `-- name: ListTestPlans :many
-SELECT id, project_id, assigned_to_id, created_by_id, updated_by_id, kind, description, start_at, closed_at, scheduled_end_at, num_test_cases, num_failures, is_complete, is_locked, has_report, created_at, updated_at, environment_id FROM test_plans ORDER BY created_at DESC
+const listOrgMembers = `-- name: ListOrgMembers :many
+SELECT DISTINCT ON (m.user_id) m.user_id, m.role, m.created_at,
+    u.first_name, u.last_name, u.display_name, u.email, u.is_activated
+FROM org_members m
+INNER JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1 AND m.removed_at IS NULL AND u.deleted_at IS NULL
+ORDER BY m.user_id, m.id DESC
 `
 
-func (q *Queries) ListTestPlans(ctx context.Context) ([]TestPlan, error) {
-	rows, err := q.db.QueryContext(ctx, listTestPlans)
+type ListOrgMembersRow struct {
+	UserID      int32
+	Role        string
+	CreatedAt   time.Time
+	FirstName   string
+	LastName    string
+	DisplayName sql.NullString
+	Email       string
+	IsActivated sql.NullBool
+}
+
+func (q *Queries) ListOrgMembers(ctx context.Context, orgID int32) ([]ListOrgMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrgMembers, orgID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestPlan
+	var items []ListOrgMembersRow
 	for rows.Next() {
-		var i TestPlan
+		var i ListOrgMembersRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.ProjectID,
-			&i.AssignedToID,
-			&i.CreatedByID,
-			&i.UpdatedByID,
-			&i.Kind,
-			&i.Description,
-			&i.StartAt,
-			&i.ClosedAt,
-			&i.ScheduledEndAt,
-			&i.NumTestCases,
-			&i.NumFailures,
-			&i.IsComplete,
-			&i.IsLocked,
-			&i.HasReport,
+			&i.UserID,
+			&i.Role,
 			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.EnvironmentID,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.Email,
+			&i.IsActivated,
 		); err != nil {
 			return nil, err
 		}
@@ -3066,38 +4744,32 @@ func (q *Queries) ListTestPlans(ctx context.Context) ([]TestPlan, error) {
 	return items, nil
 }
 
-const listTestPlansByProject = `-- name: ListTestPlansByProject :many
-SELECT id, project_id, assigned_to_id, created_by_id, updated_by_id, kind, description, start_at, closed_at, scheduled_end_at, num_test_cases, num_failures, is_complete, is_locked, has_report, created_at, updated_at, environment_id FROM test_plans WHERE project_id = $1
+const listOrgs = `-- name: ListOrgs :many
+SELECT id, name, address, country, github_url, website_url, created_by_id,  created_at, updated_at, require_two_factor
+FROM orgs
+ORDER BY name
 `
 
-func (q *Queries) ListTestPlansByProject(ctx context.Context, projectID int32) ([]TestPlan, error) {
-	rows, err := q.db.QueryContext(ctx, listTestPlansByProject, projectID)
+func (q *Queries) ListOrgs(ctx context.Context) ([]Org, error) {
+	rows, err := q.db.QueryContext(ctx, listOrgs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestPlan
+	var items []Org
 	for rows.Next() {
-		var i TestPlan
+		var i Org
 		if err := rows.Scan(
 			&i.ID,
-			&i.ProjectID,
-			&i.AssignedToID,
+			&i.Name,
+			&i.Address,
+			&i.Country,
+			&i.GithubUrl,
+			&i.WebsiteUrl,
 			&i.CreatedByID,
-			&i.UpdatedByID,
-			&i.Kind,
-			&i.Description,
-			&i.StartAt,
-			&i.ClosedAt,
-			&i.ScheduledEndAt,
-			&i.NumTestCases,
-			&i.NumFailures,
-			&i.IsComplete,
-			&i.IsLocked,
-			&i.HasReport,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.EnvironmentID,
+			&i.RequireTwoFactor,
 		); err != nil {
 			return nil, err
 		}
@@ -3112,40 +4784,33 @@ func (q *Queries) ListTestPlansByProject(ctx context.Context, projectID int32) (
 	return items, nil
 }
 
-const listTestRuns = `-- name: ListTestRuns :many
-SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id FROM test_runs ORDER BY created_at DESC
+const listOrgsByMember = `-- name: ListOrgsByMember :many
+SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor
+FROM orgs o
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.org_id = o.id AND m.user_id = $1 AND m.removed_at IS NULL)
+ORDER BY name
 `
 
-func (q *Queries) ListTestRuns(ctx context.Context) ([]TestRun, error) {
-	rows, err := q.db.QueryContext(ctx, listTestRuns)
+func (q *Queries) ListOrgsByMember(ctx context.Context, userID int32) ([]Org, error) {
+	rows, err := q.db.QueryContext(ctx, listOrgsByMember, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestRun
+	var items []Org
 	for rows.Next() {
-		var i TestRun
+		var i Org
 		if err := rows.Scan(
 			&i.ID,
-			&i.ProjectID,
-			&i.TestPlanID,
-			&i.TestCaseID,
-			&i.OwnerID,
-			&i.TestedByID,
-			&i.AssignedToID,
-			&i.AssigneeCanChangeCode,
-			&i.Code,
-			&i.ExternalIssueID,
-			&i.ResultState,
-			&i.IsClosed,
-			&i.Notes,
-			&i.ActualResult,
-			&i.ExpectedResult,
-			&i.Reactions,
-			&i.TestedOn,
+			&i.Name,
+			&i.Address,
+			&i.Country,
+			&i.GithubUrl,
+			&i.WebsiteUrl,
+			&i.CreatedByID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.EnvironmentID,
+			&i.RequireTwoFactor,
 		); err != nil {
 			return nil, err
 		}
@@ -3160,40 +4825,39 @@ func (q *Queries) ListTestRuns(ctx context.Context) ([]TestRun, error) {
 	return items, nil
 }
 
-const listTestRunsAssignedToUser = `-- name: ListTestRunsAssignedToUser :many
-SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id FROM test_runs WHERE assigned_to_id = $1
+const listPagesByProject = `-- name: ListPagesByProject :many
+SELECT id, parent_page_id, page_version, org_id, project_id, code, title, file_path, content, page_type, mime_type, has_embedded_media, external_content_url, notion_url, last_edited_by, created_by, created_at, updated_at, deleted_at FROM pages WHERE project_id = $1 ORDER BY id
 `
 
-func (q *Queries) ListTestRunsAssignedToUser(ctx context.Context, assignedToID sql.NullInt32) ([]TestRun, error) {
-	rows, err := q.db.QueryContext(ctx, listTestRunsAssignedToUser, assignedToID)
+func (q *Queries) ListPagesByProject(ctx context.Context, projectID int32) ([]Page, error) {
+	rows, err := q.db.QueryContext(ctx, listPagesByProject, projectID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestRun
+	var items []Page
 	for rows.Next() {
-		var i TestRun
+		var i Page
 		if err := rows.Scan(
 			&i.ID,
+			&i.ParentPageID,
+			&i.PageVersion,
+			&i.OrgID,
 			&i.ProjectID,
-			&i.TestPlanID,
-			&i.TestCaseID,
-			&i.OwnerID,
-			&i.TestedByID,
-			&i.AssignedToID,
-			&i.AssigneeCanChangeCode,
 			&i.Code,
-			&i.ExternalIssueID,
-			&i.ResultState,
-			&i.IsClosed,
-			&i.Notes,
-			&i.ActualResult,
-			&i.ExpectedResult,
-			&i.Reactions,
-			&i.TestedOn,
+			&i.Title,
+			&i.FilePath,
+			&i.Content,
+			&i.PageType,
+			&i.MimeType,
+			&i.HasEmbeddedMedia,
+			&i.ExternalContentUrl,
+			&i.NotionUrl,
+			&i.LastEditedBy,
+			&i.CreatedBy,
 			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.EnvironmentID,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -3208,40 +4872,33 @@ func (q *Queries) ListTestRunsAssignedToUser(ctx context.Context, assignedToID s
 	return items, nil
 }
 
-const listTestRunsByOwner = `-- name: ListTestRunsByOwner :many
-SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id FROM test_runs WHERE owner_id = $1
+const listProjectInvites = `-- name: ListProjectInvites :many
+SELECT id, sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, accepted_at, accepted_by_id, revoked_at, created_at FROM invites WHERE project_id = $1 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListTestRunsByOwner(ctx context.Context, ownerID int32) ([]TestRun, error) {
-	rows, err := q.db.QueryContext(ctx, listTestRunsByOwner, ownerID)
+func (q *Queries) ListProjectInvites(ctx context.Context, projectID sql.NullInt32) ([]Invite, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectInvites, projectID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TestRun
+	var items []Invite
 	for rows.Next() {
-		var i TestRun
+		var i Invite
 		if err := rows.Scan(
 			&i.ID,
+			&i.SenderEmail,
+			&i.ReceiverEmail,
+			&i.Token,
+			&i.ExpiresAt,
+			&i.InvitedByID,
+			&i.OrgID,
 			&i.ProjectID,
-			&i.TestPlanID,
-			&i.TestCaseID,
-			&i.OwnerID,
-			&i.TestedByID,
-			&i.AssignedToID,
-			&i.AssigneeCanChangeCode,
-			&i.Code,
-			&i.ExternalIssueID,
-			&i.ResultState,
-			&i.IsClosed,
-			&i.Notes,
-			&i.ActualResult,
-			&i.ExpectedResult,
-			&i.Reactions,
-			&i.TestedOn,
+			&i.Role,
+			&i.AcceptedAt,
+			&i.AcceptedByID,
+			&i.RevokedAt,
 			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.EnvironmentID,
 		); err != nil {
 			return nil, err
 		}
@@ -3256,203 +4913,2108 @@ func (q *Queries) ListTestRunsByOwner(ctx context.Context, ownerID int32) ([]Tes
 	return items, nil
 }
 
-const listTestRunsByPlan = `-- name: ListTestRunsByPlan :many
-SELECT
-    tr.id,
-    tr.project_id,
-    tr.test_plan_id,
-    tr.test_case_id,
-    tr.owner_id,
-    tr.tested_by_id,
-    tr.assigned_to_id,
-    tr.code,
-    tr.result_state,
-    tr.is_closed,
-    tr.notes,
-    tr.actual_result,
-    tr.expected_result,
-    tr.tested_on,
-    tr.created_at,
-    tr.updated_at,
-    tr.environment_id,
-    tc.title AS test_case_title,
-    u.display_name AS executed_by
-FROM test_runs tr
-JOIN test_cases tc ON tr.test_case_id = tc.id
-JOIN users u ON tr.tested_by_id = u.id
-WHERE tr.test_plan_id = $1
+const listProjects = `-- name: ListProjects :many
+SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern FROM projects ORDER BY created_at DESC
+`
+
+func (q *Queries) ListProjects(ctx context.Context) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Version,
+			&i.IsActive,
+			&i.IsPublic,
+			&i.WebsiteUrl,
+			&i.GithubUrl,
+			&i.TrelloUrl,
+			&i.JiraUrl,
+			&i.MondayUrl,
+			&i.OwnerUserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Code,
+			&i.ParentProjectID,
+			&i.TestcaseTemplate,
+			&i.AutomatedTestingEnabled,
+			pq.Array(&i.SupportedRunners),
+			&i.OrgID,
+			&i.TestcaseCodePattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectsByOrg = `-- name: ListProjectsByOrg :many
+SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern FROM projects WHERE org_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListProjectsByOrg(ctx context.Context, orgID int32) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByOrg, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Version,
+			&i.IsActive,
+			&i.IsPublic,
+			&i.WebsiteUrl,
+			&i.GithubUrl,
+			&i.TrelloUrl,
+			&i.JiraUrl,
+			&i.MondayUrl,
+			&i.OwnerUserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Code,
+			&i.ParentProjectID,
+			&i.TestcaseTemplate,
+			&i.AutomatedTestingEnabled,
+			pq.Array(&i.SupportedRunners),
+			&i.OrgID,
+			&i.TestcaseCodePattern,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReportsByProject = `-- name: ListReportsByProject :many
+SELECT id, project_id, name, type, status, created_at, file_path, updated_at FROM reports WHERE project_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) ListReportsByProject(ctx context.Context, projectID int32) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReportsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Type,
+			&i.Status,
+			&i.CreatedAt,
+			&i.FilePath,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSCIMTokens = `-- name: ListSCIMTokens :many
+SELECT id, org_id, name, token_hash, created_at, last_used_at, revoked_at FROM scim_tokens ORDER BY org_id, created_at DESC
+`
+
+func (q *Queries) ListSCIMTokens(ctx context.Context) ([]ScimToken, error) {
+	rows, err := q.db.QueryContext(ctx, listSCIMTokens)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScimToken
+	for rows.Next() {
+		var i ScimToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrgID,
+			&i.Name,
+			&i.TokenHash,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSCIMUsers = `-- name: ListSCIMUsers :many
+SELECT u.id, u.first_name, u.last_name, u.display_name, u.email, u.is_activated, u.created_at, u.updated_at,
+    s.external_id, s.user_name, s.manages_account,
+    COALESCE((
+        SELECT m.role FROM org_members m
+        WHERE m.org_id = s.org_id AND m.user_id = s.user_id AND m.removed_at IS NULL
+        ORDER BY m.id DESC LIMIT 1
+    ), '')::text AS role
+FROM scim_users s
+INNER JOIN users u ON u.id = s.user_id
+WHERE s.org_id = $1 AND u.deleted_at IS NULL
+ORDER BY u.id
+`
+
+type ListSCIMUsersRow struct {
+	ID             int32
+	FirstName      string
+	LastName       string
+	DisplayName    sql.NullString
+	Email          string
+	IsActivated    sql.NullBool
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	ExternalID     sql.NullString
+	UserName       string
+	ManagesAccount bool
+	Role           string
+}
+
+func (q *Queries) ListSCIMUsers(ctx context.Context, orgID int32) ([]ListSCIMUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSCIMUsers, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSCIMUsersRow
+	for rows.Next() {
+		var i ListSCIMUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.Email,
+			&i.IsActivated,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExternalID,
+			&i.UserName,
+			&i.ManagesAccount,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScriptTestCasesByPlan = `-- name: ListScriptTestCasesByPlan :many
+SELECT tc.id, tc.kind, tc.code, tc.feature_or_module, tc.title, tc.description, tc.is_draft, tc.tags, tc.created_by_id, tc.created_at, tc.updated_at, tc.project_id, tc.suggested, tc.runner, tc.script_path, tc.parent_test_case_id
+FROM test_cases tc
+INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
+WHERE pc.test_plan_id = $1
+  AND tc.script_path IS NOT NULL
+  AND tc.runner IS NOT NULL
+`
+
+func (q *Queries) ListScriptTestCasesByPlan(ctx context.Context, testPlanID int64) ([]TestCase, error) {
+	rows, err := q.db.QueryContext(ctx, listScriptTestCasesByPlan, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Code,
+			&i.FeatureOrModule,
+			&i.Title,
+			&i.Description,
+			&i.IsDraft,
+			pq.Array(&i.Tags),
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProjectID,
+			&i.Suggested,
+			&i.Runner,
+			&i.ScriptPath,
+			&i.ParentTestCaseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedStepReferences = `-- name: ListSharedStepReferences :many
+SELECT id, test_case_id, position, action, test_data, expected_result, attachments, created_at, updated_at, shared_step_id, parameters FROM test_case_steps WHERE shared_step_id = $1 ORDER BY test_case_id, position
+`
+
+func (q *Queries) ListSharedStepReferences(ctx context.Context, sharedStepID sql.NullInt32) ([]TestCaseStep, error) {
+	rows, err := q.db.QueryContext(ctx, listSharedStepReferences, sharedStepID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCaseStep
+	for rows.Next() {
+		var i TestCaseStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.TestCaseID,
+			&i.Position,
+			&i.Action,
+			&i.TestData,
+			&i.ExpectedResult,
+			pq.Array(&i.Attachments),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SharedStepID,
+			&i.Parameters,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedStepUsages = `-- name: ListSharedStepUsages :many
+SELECT tc.id AS test_case_id, tc.code, tc.title, COUNT(*) AS usages
+FROM test_case_steps s
+INNER JOIN test_cases tc ON tc.id = s.test_case_id
+WHERE s.shared_step_id = $1
+GROUP BY tc.id, tc.code, tc.title
+ORDER BY tc.code
+`
+
+type ListSharedStepUsagesRow struct {
+	TestCaseID uuid.UUID
+	Code       string
+	Title      string
+	Usages     int64
+}
+
+func (q *Queries) ListSharedStepUsages(ctx context.Context, sharedStepID sql.NullInt32) ([]ListSharedStepUsagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSharedStepUsages, sharedStepID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSharedStepUsagesRow
+	for rows.Next() {
+		var i ListSharedStepUsagesRow
+		if err := rows.Scan(
+			&i.TestCaseID,
+			&i.Code,
+			&i.Title,
+			&i.Usages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedStepsByProject = `-- name: ListSharedStepsByProject :many
+SELECT id, project_id, name, description, parameters, steps, created_by_id, created_at, updated_at FROM shared_steps WHERE project_id = $1 ORDER BY name
+`
+
+func (q *Queries) ListSharedStepsByProject(ctx context.Context, projectID int32) ([]SharedStep, error) {
+	rows, err := q.db.QueryContext(ctx, listSharedStepsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SharedStep
+	for rows.Next() {
+		var i SharedStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Description,
+			&i.Parameters,
+			&i.Steps,
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSigningKeys = `-- name: ListSigningKeys :many
+SELECT kid, algorithm, private_key, created_at, activates_at, retired_at FROM signing_keys ORDER BY activates_at DESC, created_at DESC
+`
+
+func (q *Queries) ListSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	rows, err := q.db.QueryContext(ctx, listSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SigningKey
+	for rows.Next() {
+		var i SigningKey
+		if err := rows.Scan(
+			&i.Kid,
+			&i.Algorithm,
+			&i.PrivateKey,
+			&i.CreatedAt,
+			&i.ActivatesAt,
+			&i.RetiredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCaseCodeAliases = `-- name: ListTestCaseCodeAliases :many
+SELECT project_id, code, test_case_id, created_at FROM test_case_code_aliases WHERE project_id = $1 ORDER BY code
+`
+
+func (q *Queries) ListTestCaseCodeAliases(ctx context.Context, projectID int32) ([]TestCaseCodeAlias, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCaseCodeAliases, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCaseCodeAlias
+	for rows.Next() {
+		var i TestCaseCodeAlias
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Code,
+			&i.TestCaseID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCaseRevisions = `-- name: ListTestCaseRevisions :many
+SELECT test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at FROM test_case_revisions WHERE test_case_id = $1 ORDER BY revision DESC
+`
+
+func (q *Queries) ListTestCaseRevisions(ctx context.Context, testCaseID uuid.UUID) ([]TestCaseRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCaseRevisions, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCaseRevision
+	for rows.Next() {
+		var i TestCaseRevision
+		if err := rows.Scan(
+			&i.TestCaseID,
+			&i.Revision,
+			&i.Title,
+			&i.Description,
+			&i.Kind,
+			pq.Array(&i.Tags),
+			&i.Runner,
+			&i.ScriptPath,
+			&i.Steps,
+			&i.AuthorID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCaseSteps = `-- name: ListTestCaseSteps :many
+SELECT id, test_case_id, position, action, test_data, expected_result, attachments, created_at, updated_at, shared_step_id, parameters FROM test_case_steps WHERE test_case_id = $1 ORDER BY position
+`
+
+func (q *Queries) ListTestCaseSteps(ctx context.Context, testCaseID uuid.UUID) ([]TestCaseStep, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCaseSteps, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCaseStep
+	for rows.Next() {
+		var i TestCaseStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.TestCaseID,
+			&i.Position,
+			&i.Action,
+			&i.TestData,
+			&i.ExpectedResult,
+			pq.Array(&i.Attachments),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SharedStepID,
+			&i.Parameters,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCases = `-- name: ListTestCases :many
+SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListTestCases(ctx context.Context, orgID int32) ([]TestCase, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCases, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Code,
+			&i.FeatureOrModule,
+			&i.Title,
+			&i.Description,
+			&i.IsDraft,
+			pq.Array(&i.Tags),
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProjectID,
+			&i.Suggested,
+			&i.Runner,
+			&i.ScriptPath,
+			&i.ParentTestCaseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCasesByAssignedUser = `-- name: ListTestCasesByAssignedUser :many
+SELECT
+  tc.id AS test_case_id,
+  tc.kind,
+  tc.code,
+  tc.feature_or_module,
+  tc.title,
+  tc.description,
+  tc.is_draft,
+  tc.tags,
+  tc.created_by_id,
+  tc.created_at AS test_case_created_at,
+  tc.updated_at AS test_case_updated_at,
+  tc.project_id,
+  pc.test_plan_id,
+  pc.assigned_to_id,
+  tp.environment_id,
+  COALESCE(BOOL_OR(tr.is_closed), false)::boolean AS is_closed
+FROM test_cases tc
+INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
+INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
+LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
+WHERE pc.assigned_to_id = $1
+  AND (
+    $4::bool = true
+    OR COALESCE(tr.is_closed, false) = false
+  )
+GROUP BY tc.id, pc.test_plan_id, pc.assigned_to_id, tp.environment_id
+ORDER BY tc.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListTestCasesByAssignedUserParams struct {
+	AssignedToID  int64
+	Limit         int32
+	Offset        int32
+	IncludeClosed bool
+}
+
+type ListTestCasesByAssignedUserRow struct {
+	TestCaseID        uuid.UUID
+	Kind              TestKind
+	Code              string
+	FeatureOrModule   sql.NullString
+	Title             string
+	Description       string
+	IsDraft           sql.NullBool
+	Tags              []string
+	CreatedByID       int32
+	TestCaseCreatedAt sql.NullTime
+	TestCaseUpdatedAt sql.NullTime
+	ProjectID         sql.NullInt32
+	TestPlanID        int64
+	AssignedToID      int64
+	EnvironmentID     sql.NullInt32
+	IsClosed          bool
+}
+
+func (q *Queries) ListTestCasesByAssignedUser(ctx context.Context, arg ListTestCasesByAssignedUserParams) ([]ListTestCasesByAssignedUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCasesByAssignedUser,
+		arg.AssignedToID,
+		arg.Limit,
+		arg.Offset,
+		arg.IncludeClosed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTestCasesByAssignedUserRow
+	for rows.Next() {
+		var i ListTestCasesByAssignedUserRow
+		if err := rows.Scan(
+			&i.TestCaseID,
+			&i.Kind,
+			&i.Code,
+			&i.FeatureOrModule,
+			&i.Title,
+			&i.Description,
+			&i.IsDraft,
+			pq.Array(&i.Tags),
+			&i.CreatedByID,
+			&i.TestCaseCreatedAt,
+			&i.TestCaseUpdatedAt,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.AssignedToID,
+			&i.EnvironmentID,
+			&i.IsClosed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCasesByCreator = `-- name: ListTestCasesByCreator :many
+SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases
+WHERE created_by_id = $1
+AND project_id IN (SELECT id FROM projects WHERE org_id = $2)
+`
+
+type ListTestCasesByCreatorParams struct {
+	CreatedByID int32
+	OrgID       int32
+}
+
+func (q *Queries) ListTestCasesByCreator(ctx context.Context, arg ListTestCasesByCreatorParams) ([]TestCase, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCasesByCreator, arg.CreatedByID, arg.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Code,
+			&i.FeatureOrModule,
+			&i.Title,
+			&i.Description,
+			&i.IsDraft,
+			pq.Array(&i.Tags),
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProjectID,
+			&i.Suggested,
+			&i.Runner,
+			&i.ScriptPath,
+			&i.ParentTestCaseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCasesByPlan = `-- name: ListTestCasesByPlan :many
+SELECT
+  tc.id,
+  tc.title,
+  array_agg(pc.assigned_to_id)::bigint[] AS assigned_tester_ids
+FROM test_cases tc
+INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
+WHERE pc.test_plan_id = $1
+GROUP BY tc.id, tc.title
+`
+
+type ListTestCasesByPlanRow struct {
+	ID                uuid.UUID
+	Title             string
+	AssignedTesterIds []int64
+}
+
+func (q *Queries) ListTestCasesByPlan(ctx context.Context, testPlanID int64) ([]ListTestCasesByPlanRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCasesByPlan, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTestCasesByPlanRow
+	for rows.Next() {
+		var i ListTestCasesByPlanRow
+		if err := rows.Scan(&i.ID, &i.Title, pq.Array(&i.AssignedTesterIds)); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestCasesByProject = `-- name: ListTestCasesByProject :many
+SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases WHERE project_id = $1
+`
+
+func (q *Queries) ListTestCasesByProject(ctx context.Context, projectID sql.NullInt32) ([]TestCase, error) {
+	rows, err := q.db.QueryContext(ctx, listTestCasesByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestCase
+	for rows.Next() {
+		var i TestCase
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Code,
+			&i.FeatureOrModule,
+			&i.Title,
+			&i.Description,
+			&i.IsDraft,
+			pq.Array(&i.Tags),
+			&i.CreatedByID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProjectID,
+			&i.Suggested,
+			&i.Runner,
+			&i.ScriptPath,
+			&i.ParentTestCaseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestPlanCases = `-- name: ListTestPlanCases :many
+SELECT test_plan_id, test_case_id, assigned_to_id FROM test_plan_cases WHERE test_plan_id = $1
+`
+
+func (q *Queries) ListTestPlanCases(ctx context.Context, testPlanID int64) ([]TestPlanCase, error) {
+	rows, err := q.db.QueryContext(ctx, listTestPlanCases, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestPlanCase
+	for rows.Next() {
+		var i TestPlanCase
+		if err := rows.Scan(&i.TestPlanID, &i.TestCaseID, &i.AssignedToID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestPlanCasesByProject = `-- name: ListTestPlanCasesByProject :many
+SELECT pc.test_plan_id, pc.test_case_id, pc.assigned_to_id
+FROM test_plan_cases pc
+INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
+WHERE tp.project_id = $1
+`
+
+func (q *Queries) ListTestPlanCasesByProject(ctx context.Context, projectID int32) ([]TestPlanCase, error) {
+	rows, err := q.db.QueryContext(ctx, listTestPlanCasesByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestPlanCase
+	for rows.Next() {
+		var i TestPlanCase
+		if err := rows.Scan(&i.TestPlanID, &i.TestCaseID, &i.AssignedToID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestPlanCommentsByProject = `-- name: ListTestPlanCommentsByProject :many
+SELECT c.id, c.test_plan_id, c.user_id, c.content, c.created_at, c.updated_at
+FROM test_plan_comments c
+INNER JOIN test_plans tp ON tp.id = c.test_plan_id
+WHERE tp.project_id = $1
+ORDER BY c.created_at
+`
+
+func (q *Queries) ListTestPlanCommentsByProject(ctx context.Context, projectID int32) ([]TestPlanComment, error) {
+	rows, err := q.db.QueryContext(ctx, listTestPlanCommentsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestPlanComment
+	for rows.Next() {
+		var i TestPlanComment
+		if err := rows.Scan(
+			&i.ID,
+			&i.TestPlanID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestPlans = `-- name: ListTestPlans :many
+SELECT id, project_id, assigned_to_id, created_by_id, updated_by_id, kind, description, start_at, closed_at, scheduled_end_at, num_test_cases, num_failures, is_complete, is_locked, has_report, created_at, updated_at, environment_id FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListTestPlans(ctx context.Context, orgID int32) ([]TestPlan, error) {
+	rows, err := q.db.QueryContext(ctx, listTestPlans, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestPlan
+	for rows.Next() {
+		var i TestPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.AssignedToID,
+			&i.CreatedByID,
+			&i.UpdatedByID,
+			&i.Kind,
+			&i.Description,
+			&i.StartAt,
+			&i.ClosedAt,
+			&i.ScheduledEndAt,
+			&i.NumTestCases,
+			&i.NumFailures,
+			&i.IsComplete,
+			&i.IsLocked,
+			&i.HasReport,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestPlansByProject = `-- name: ListTestPlansByProject :many
+SELECT id, project_id, assigned_to_id, created_by_id, updated_by_id, kind, description, start_at, closed_at, scheduled_end_at, num_test_cases, num_failures, is_complete, is_locked, has_report, created_at, updated_at, environment_id FROM test_plans WHERE project_id = $1
+`
+
+func (q *Queries) ListTestPlansByProject(ctx context.Context, projectID int32) ([]TestPlan, error) {
+	rows, err := q.db.QueryContext(ctx, listTestPlansByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestPlan
+	for rows.Next() {
+		var i TestPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.AssignedToID,
+			&i.CreatedByID,
+			&i.UpdatedByID,
+			&i.Kind,
+			&i.Description,
+			&i.StartAt,
+			&i.ClosedAt,
+			&i.ScheduledEndAt,
+			&i.NumTestCases,
+			&i.NumFailures,
+			&i.IsComplete,
+			&i.IsLocked,
+			&i.HasReport,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunResultsByProject = `-- name: ListTestRunResultsByProject :many
+SELECT trr.id, trr.test_run_id, trr.status, trr.result, trr.notes, trr.executed_by, trr.executed_at, trr.created_at, trr.updated_at
+FROM test_run_results trr
+INNER JOIN test_runs tr ON tr.id = trr.test_run_id
+WHERE tr.project_id = $1
+ORDER BY trr.executed_at
+`
+
+func (q *Queries) ListTestRunResultsByProject(ctx context.Context, projectID int32) ([]TestRunResult, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunResultsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRunResult
+	for rows.Next() {
+		var i TestRunResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.TestRunID,
+			&i.Status,
+			&i.Result,
+			&i.Notes,
+			&i.ExecutedBy,
+			&i.ExecutedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunStepResults = `-- name: ListTestRunStepResults :many
+SELECT test_run_id, position, step_id, action, test_data, expected_result, status, actual_result, notes, executed_by_id, executed_at, updated_at FROM test_run_step_results WHERE test_run_id = $1 ORDER BY position
+`
+
+func (q *Queries) ListTestRunStepResults(ctx context.Context, testRunID uuid.UUID) ([]TestRunStepResult, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunStepResults, testRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRunStepResult
+	for rows.Next() {
+		var i TestRunStepResult
+		if err := rows.Scan(
+			&i.TestRunID,
+			&i.Position,
+			&i.StepID,
+			&i.Action,
+			&i.TestData,
+			&i.ExpectedResult,
+			&i.Status,
+			&i.ActualResult,
+			&i.Notes,
+			&i.ExecutedByID,
+			&i.ExecutedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRuns = `-- name: ListTestRuns :many
+SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, test_case_revision, data_row, data_values FROM test_runs
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListTestRuns(ctx context.Context, orgID int32) ([]TestRun, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRuns, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRun
+	for rows.Next() {
+		var i TestRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.TestCaseID,
+			&i.OwnerID,
+			&i.TestedByID,
+			&i.AssignedToID,
+			&i.AssigneeCanChangeCode,
+			&i.Code,
+			&i.ExternalIssueID,
+			&i.ResultState,
+			&i.IsClosed,
+			&i.Notes,
+			&i.ActualResult,
+			&i.ExpectedResult,
+			&i.Reactions,
+			&i.TestedOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+			&i.TestCaseRevision,
+			&i.DataRow,
+			&i.DataValues,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunsAssignedToUser = `-- name: ListTestRunsAssignedToUser :many
+SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, test_case_revision, data_row, data_values FROM test_runs WHERE assigned_to_id = $1
+`
+
+func (q *Queries) ListTestRunsAssignedToUser(ctx context.Context, assignedToID sql.NullInt32) ([]TestRun, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunsAssignedToUser, assignedToID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRun
+	for rows.Next() {
+		var i TestRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.TestCaseID,
+			&i.OwnerID,
+			&i.TestedByID,
+			&i.AssignedToID,
+			&i.AssigneeCanChangeCode,
+			&i.Code,
+			&i.ExternalIssueID,
+			&i.ResultState,
+			&i.IsClosed,
+			&i.Notes,
+			&i.ActualResult,
+			&i.ExpectedResult,
+			&i.Reactions,
+			&i.TestedOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+			&i.TestCaseRevision,
+			&i.DataRow,
+			&i.DataValues,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunsByOwner = `-- name: ListTestRunsByOwner :many
+SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, test_case_revision, data_row, data_values FROM test_runs WHERE owner_id = $1
+`
+
+func (q *Queries) ListTestRunsByOwner(ctx context.Context, ownerID int32) ([]TestRun, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunsByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRun
+	for rows.Next() {
+		var i TestRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.TestCaseID,
+			&i.OwnerID,
+			&i.TestedByID,
+			&i.AssignedToID,
+			&i.AssigneeCanChangeCode,
+			&i.Code,
+			&i.ExternalIssueID,
+			&i.ResultState,
+			&i.IsClosed,
+			&i.Notes,
+			&i.ActualResult,
+			&i.ExpectedResult,
+			&i.Reactions,
+			&i.TestedOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+			&i.TestCaseRevision,
+			&i.DataRow,
+			&i.DataValues,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunsByPlan = `-- name: ListTestRunsByPlan :many
+SELECT
+    tr.id,
+    tr.project_id,
+    tr.test_plan_id,
+    tr.test_case_id,
+    tr.owner_id,
+    tr.tested_by_id,
+    tr.assigned_to_id,
+    tr.code,
+    tr.result_state,
+    tr.is_closed,
+    tr.notes,
+    tr.actual_result,
+    tr.expected_result,
+    tr.tested_on,
+    tr.created_at,
+    tr.updated_at,
+    tr.environment_id,
+    tr.test_case_revision,
+    tr.data_row,
+    tr.data_values,
+    tc.title AS test_case_title,
+    u.display_name AS executed_by
+FROM test_runs tr
+JOIN test_cases tc ON tr.test_case_id = tc.id
+JOIN users u ON tr.tested_by_id = u.id
+WHERE tr.test_plan_id = $1
 ORDER BY tr.created_at DESC
 `
 
-type ListTestRunsByPlanRow struct {
-	ID             uuid.UUID
-	ProjectID      int32
-	TestPlanID     sql.NullInt32
-	TestCaseID     uuid.UUID
-	OwnerID        int32
-	TestedByID     sql.NullInt32
-	AssignedToID   sql.NullInt32
-	Code           string
-	ResultState    TestRunState
-	IsClosed       sql.NullBool
-	Notes          string
-	ActualResult   sql.NullString
-	ExpectedResult sql.NullString
-	TestedOn       time.Time
-	CreatedAt      sql.NullTime
-	UpdatedAt      sql.NullTime
-	EnvironmentID  sql.NullInt32
-	TestCaseTitle  string
-	ExecutedBy     sql.NullString
+type ListTestRunsByPlanRow struct {
+	ID               uuid.UUID
+	ProjectID        int32
+	TestPlanID       sql.NullInt32
+	TestCaseID       uuid.UUID
+	OwnerID          int32
+	TestedByID       sql.NullInt32
+	AssignedToID     sql.NullInt32
+	Code             string
+	ResultState      TestRunState
+	IsClosed         sql.NullBool
+	Notes            string
+	ActualResult     sql.NullString
+	ExpectedResult   sql.NullString
+	TestedOn         time.Time
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	EnvironmentID    sql.NullInt32
+	TestCaseRevision sql.NullInt32
+	DataRow          sql.NullInt32
+	DataValues       pqtype.NullRawMessage
+	TestCaseTitle    string
+	ExecutedBy       sql.NullString
+}
+
+func (q *Queries) ListTestRunsByPlan(ctx context.Context, testPlanID sql.NullInt32) ([]ListTestRunsByPlanRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunsByPlan, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTestRunsByPlanRow
+	for rows.Next() {
+		var i ListTestRunsByPlanRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.TestCaseID,
+			&i.OwnerID,
+			&i.TestedByID,
+			&i.AssignedToID,
+			&i.Code,
+			&i.ResultState,
+			&i.IsClosed,
+			&i.Notes,
+			&i.ActualResult,
+			&i.ExpectedResult,
+			&i.TestedOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+			&i.TestCaseRevision,
+			&i.DataRow,
+			&i.DataValues,
+			&i.TestCaseTitle,
+			&i.ExecutedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestRunsByProject = `-- name: ListTestRunsByProject :many
+SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, test_case_revision, data_row, data_values FROM test_runs WHERE project_id = $1
+`
+
+func (q *Queries) ListTestRunsByProject(ctx context.Context, projectID int32) ([]TestRun, error) {
+	rows, err := q.db.QueryContext(ctx, listTestRunsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TestRun
+	for rows.Next() {
+		var i TestRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TestPlanID,
+			&i.TestCaseID,
+			&i.OwnerID,
+			&i.TestedByID,
+			&i.AssignedToID,
+			&i.AssigneeCanChangeCode,
+			&i.Code,
+			&i.ExternalIssueID,
+			&i.ResultState,
+			&i.IsClosed,
+			&i.Notes,
+			&i.ActualResult,
+			&i.ExpectedResult,
+			&i.Reactions,
+			&i.TestedOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.EnvironmentID,
+			&i.TestCaseRevision,
+			&i.DataRow,
+			&i.DataValues,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserOrgs = `-- name: ListUserOrgs :many
+SELECT DISTINCT ON (o.id) o.id, o.name, m.role
+FROM org_members m
+INNER JOIN orgs o ON o.id = m.org_id
+WHERE m.user_id = $1 AND m.removed_at IS NULL
+ORDER BY o.id, m.id DESC
+`
+
+type ListUserOrgsRow struct {
+	ID   int32
+	Name string
+	Role string
+}
+
+func (q *Queries) ListUserOrgs(ctx context.Context, userID int32) ([]ListUserOrgsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserOrgs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserOrgsRow
+	for rows.Next() {
+		var i ListUserOrgsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT
+    r.family_id, r.user_agent, r.ip_address, r.created_at, r.expires_at,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = r.family_id)::timestamp AS started_at
+FROM refresh_tokens r
+WHERE r.user_id = $1 AND r.used_at IS NULL AND r.revoked_at IS NULL AND r.expires_at > now()
+ORDER BY r.created_at DESC
+`
+
+type ListUserSessionsRow struct {
+	FamilyID  uuid.UUID
+	UserAgent sql.NullString
+	IpAddress sql.NullString
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	StartedAt time.Time
+}
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID int32) ([]ListUserSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.StartedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.Email,
+			&i.Password,
+			&i.Phone,
+			&i.OrgID,
+			&i.CountryIso,
+			&i.City,
+			&i.Address,
+			&i.IsActivated,
+			&i.IsReviewed,
+			&i.IsSuperAdmin,
+			&i.IsVerified,
+			&i.LastLoginAt,
+			&i.EmailConfirmedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByOrg = `-- name: ListUsersByOrg :many
+SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users u
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.user_id = u.id AND m.org_id = $1 AND m.removed_at IS NULL)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUsersByOrg(ctx context.Context, orgID int32) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByOrg, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.Email,
+			&i.Password,
+			&i.Phone,
+			&i.OrgID,
+			&i.CountryIso,
+			&i.City,
+			&i.Address,
+			&i.IsActivated,
+			&i.IsReviewed,
+			&i.IsSuperAdmin,
+			&i.IsVerified,
+			&i.LastLoginAt,
+			&i.EmailConfirmedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByIDs = `-- name: ListUsersByIDs :many
+SELECT id, email, display_name FROM users WHERE id = ANY($1::int[])
+`
+
+type ListUsersByIDsRow struct {
+	ID          int32
+	Email       string
+	DisplayName sql.NullString
+}
+
+func (q *Queries) ListUsersByIDs(ctx context.Context, ids []int32) ([]ListUsersByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersByIDsRow
+	for rows.Next() {
+		var i ListUsersByIDsRow
+		if err := rows.Scan(&i.ID, &i.Email, &i.DisplayName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLoginThrottle = `-- name: LockLoginThrottle :exec
+UPDATE login_throttles
+SET locked_until = $2, lockouts = lockouts + 1, failed_attempts = 0, updated_at = now()
+WHERE id = $1
+`
+
+type LockLoginThrottleParams struct {
+	ID          int32
+	LockedUntil sql.NullTime
+}
+
+func (q *Queries) LockLoginThrottle(ctx context.Context, arg LockLoginThrottleParams) error {
+	_, err := q.db.ExecContext(ctx, lockLoginThrottle, arg.ID, arg.LockedUntil)
+	return err
+}
+
+const markRefreshTokenUsed = `-- name: MarkRefreshTokenUsed :execrows
+UPDATE refresh_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+`
+
+func (q *Queries) MarkRefreshTokenUsed(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markRefreshTokenUsed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reactivateUser = `-- name: ReactivateUser :execrows
+UPDATE users SET is_activated = true, updated_at = now() WHERE id = $1 AND NOT is_activated AND deleted_at IS NULL
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reactivateUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reassignOpenPlanCases = `-- name: ReassignOpenPlanCases :execrows
+UPDATE test_plan_cases pc SET assigned_to_id = $1
+FROM test_plans tp
+WHERE tp.id = pc.test_plan_id
+AND pc.assigned_to_id = $2
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+)
+`
+
+type ReassignOpenPlanCasesParams struct {
+	ToUserID   int64
+	FromUserID int64
+}
+
+func (q *Queries) ReassignOpenPlanCases(ctx context.Context, arg ReassignOpenPlanCasesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reassignOpenPlanCases, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reassignOpenTestPlans = `-- name: ReassignOpenTestPlans :execrows
+UPDATE test_plans SET assigned_to_id = $1, updated_at = now()
+WHERE assigned_to_id = $2 AND NOT COALESCE(is_complete, false) AND closed_at IS NULL
+`
+
+type ReassignOpenTestPlansParams struct {
+	ToUserID   int32
+	FromUserID int32
+}
+
+func (q *Queries) ReassignOpenTestPlans(ctx context.Context, arg ReassignOpenTestPlansParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reassignOpenTestPlans, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const reassignOpenTestRuns = `-- name: ReassignOpenTestRuns :execrows
+UPDATE test_runs SET assigned_to_id = $1, updated_at = now()
+WHERE assigned_to_id = $2 AND NOT COALESCE(is_closed, false)
+`
+
+type ReassignOpenTestRunsParams struct {
+	ToUserID   sql.NullInt32
+	FromUserID sql.NullInt32
+}
+
+func (q *Queries) ReassignOpenTestRuns(ctx context.Context, arg ReassignOpenTestRunsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reassignOpenTestRuns, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, throttle_key, failed_attempts, last_failed_at, updated_at)
+VALUES ($1, $2, 1, now(), now())
+ON CONFLICT (scope, throttle_key) DO UPDATE SET
+    failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < now() - interval '15 minutes' THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    lockouts = CASE
+        WHEN login_throttles.last_failed_at < now() - interval '24 hours' THEN 0
+        ELSE login_throttles.lockouts
+    END,
+    last_failed_at = now(),
+    updated_at = now()
+RETURNING id, scope, throttle_key, failed_attempts, lockouts, locked_until, last_failed_at, updated_at
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string
+	ThrottleKey string
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Scope, arg.ThrottleKey)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.ID,
+		&i.Scope,
+		&i.ThrottleKey,
+		&i.FailedAttempts,
+		&i.Lockouts,
+		&i.LockedUntil,
+		&i.LastFailedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const recordTestRunStepResult = `-- name: RecordTestRunStepResult :execrows
+UPDATE test_run_step_results SET
+    status = $3,
+    actual_result = $4,
+    notes = $5,
+    executed_by_id = $6,
+    executed_at = $7,
+    updated_at = now()
+WHERE test_run_id = $1 AND position = $2
+`
+
+type RecordTestRunStepResultParams struct {
+	TestRunID    uuid.UUID
+	Position     int32
+	Status       TestRunState
+	ActualResult string
+	Notes        string
+	ExecutedByID sql.NullInt32
+	ExecutedAt   sql.NullTime
+}
+
+func (q *Queries) RecordTestRunStepResult(ctx context.Context, arg RecordTestRunStepResultParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, recordTestRunStepResult,
+		arg.TestRunID,
+		arg.Position,
+		arg.Status,
+		arg.ActualResult,
+		arg.Notes,
+		arg.ExecutedByID,
+		arg.ExecutedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeOrgMember = `-- name: RemoveOrgMember :execrows
+UPDATE org_members SET removed_at = now()
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL
+`
+
+type RemoveOrgMemberParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) RemoveOrgMember(ctx context.Context, arg RemoveOrgMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeOrgMember, arg.OrgID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const renameTestRunCodes = `-- name: RenameTestRunCodes :execrows
+UPDATE test_runs SET code = $1::text || substr(code, length($2::text) + 1), updated_at = now()
+WHERE test_case_id = $3
+AND (code = $2::text
+    OR (left(code, length($2::text) + 1) = $2::text || '#'
+        AND substr(code, length($2::text) + 2) ~ '^[0-9]+$'))
+`
+
+type RenameTestRunCodesParams struct {
+	NewCode    string
+	OldCode    string
+	TestCaseID uuid.UUID
+}
+
+// Runs of data-driven test cases are coded <code>#<row> and keep their row suffix
+func (q *Queries) RenameTestRunCodes(ctx context.Context, arg RenameTestRunCodesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameTestRunCodes, arg.NewCode, arg.OldCode, arg.TestCaseID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const renewInvite = `-- name: RenewInvite :execrows
+UPDATE invites SET token = $2, expires_at = $3
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+`
+
+type RenewInviteParams struct {
+	ID        int32
+	Token     string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) RenewInvite(ctx context.Context, arg RenewInviteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renewInvite, arg.ID, arg.Token, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const replaceCodeInTestPlans = `-- name: ReplaceCodeInTestPlans :execrows
+UPDATE test_plans SET description = regexp_replace(description, $1::text, $2::text, 'g')
+WHERE project_id = $3 AND description ~ $1::text
+`
+
+type ReplaceCodeInTestPlansParams struct {
+	Pattern     string
+	Replacement string
+	ProjectID   int32
+}
+
+func (q *Queries) ReplaceCodeInTestPlans(ctx context.Context, arg ReplaceCodeInTestPlansParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, replaceCodeInTestPlans, arg.Pattern, arg.Replacement, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const replaceCodeInTestRuns = `-- name: ReplaceCodeInTestRuns :execrows
+UPDATE test_runs SET notes = regexp_replace(notes, $1::text, $2::text, 'g')
+WHERE project_id = $3 AND notes ~ $1::text
+`
+
+type ReplaceCodeInTestRunsParams struct {
+	Pattern     string
+	Replacement string
+	ProjectID   int32
+}
+
+func (q *Queries) ReplaceCodeInTestRuns(ctx context.Context, arg ReplaceCodeInTestRunsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, replaceCodeInTestRuns, arg.Pattern, arg.Replacement, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resetLoginThrottle = `-- name: ResetLoginThrottle :execrows
+UPDATE login_throttles
+SET failed_attempts = 0, lockouts = 0, locked_until = NULL, updated_at = now()
+WHERE scope = $1 AND throttle_key = $2
+`
+
+type ResetLoginThrottleParams struct {
+	Scope       string
+	ThrottleKey string
+}
+
+func (q *Queries) ResetLoginThrottle(ctx context.Context, arg ResetLoginThrottleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resetLoginThrottle, arg.Scope, arg.ThrottleKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreTestCaseContent = `-- name: RestoreTestCaseContent :exec
+UPDATE test_cases SET
+    title = $2,
+    description = $3,
+    kind = $4,
+    tags = $5,
+    runner = $6,
+    script_path = $7,
+    updated_at = now()
+WHERE id = $1
+`
+
+type RestoreTestCaseContentParams struct {
+	ID          uuid.UUID
+	Title       string
+	Description string
+	Kind        TestKind
+	Tags        []string
+	Runner      sql.NullString
+	ScriptPath  sql.NullString
+}
+
+func (q *Queries) RestoreTestCaseContent(ctx context.Context, arg RestoreTestCaseContentParams) error {
+	_, err := q.db.ExecContext(ctx, restoreTestCaseContent,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		pq.Array(arg.Tags),
+		arg.Runner,
+		arg.ScriptPath,
+	)
+	return err
+}
+
+const retireOtherSigningKeys = `-- name: RetireOtherSigningKeys :execrows
+UPDATE signing_keys SET retired_at = $2
+WHERE kid <> $1 AND (retired_at IS NULL OR retired_at > $2)
+`
+
+type RetireOtherSigningKeysParams struct {
+	Kid       string
+	RetiredAt sql.NullTime
 }
 
-func (q *Queries) ListTestRunsByPlan(ctx context.Context, testPlanID sql.NullInt32) ([]ListTestRunsByPlanRow, error) {
-	rows, err := q.db.QueryContext(ctx, listTestRunsByPlan, testPlanID)
+func (q *Queries) RetireOtherSigningKeys(ctx context.Context, arg RetireOtherSigningKeysParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retireOtherSigningKeys, arg.Kid, arg.RetiredAt)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListTestRunsByPlanRow
-	for rows.Next() {
-		var i ListTestRunsByPlanRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProjectID,
-			&i.TestPlanID,
-			&i.TestCaseID,
-			&i.OwnerID,
-			&i.TestedByID,
-			&i.AssignedToID,
-			&i.Code,
-			&i.ResultState,
-			&i.IsClosed,
-			&i.Notes,
-			&i.ActualResult,
-			&i.ExpectedResult,
-			&i.TestedOn,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.EnvironmentID,
-			&i.TestCaseTitle,
-			&i.ExecutedBy,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listTestRunsByProject = `-- name: ListTestRunsByProject :many
-SELECT id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id FROM test_runs WHERE project_id = $1
+const retireSigningKey = `-- name: RetireSigningKey :execrows
+UPDATE signing_keys SET retired_at = $2
+WHERE kid = $1 AND (retired_at IS NULL OR retired_at > $2)
 `
 
-func (q *Queries) ListTestRunsByProject(ctx context.Context, projectID int32) ([]TestRun, error) {
-	rows, err := q.db.QueryContext(ctx, listTestRunsByProject, projectID)
+type RetireSigningKeyParams struct {
+	Kid       string
+	RetiredAt sql.NullTime
+}
+
+func (q *Queries) RetireSigningKey(ctx context.Context, arg RetireSigningKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retireSigningKey, arg.Kid, arg.RetiredAt)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []TestRun
-	for rows.Next() {
-		var i TestRun
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProjectID,
-			&i.TestPlanID,
-			&i.TestCaseID,
-			&i.OwnerID,
-			&i.TestedByID,
-			&i.AssignedToID,
-			&i.AssigneeCanChangeCode,
-			&i.Code,
-			&i.ExternalIssueID,
-			&i.ResultState,
-			&i.IsClosed,
-			&i.Notes,
-			&i.ActualResult,
-			&i.ExpectedResult,
-			&i.Reactions,
-			&i.TestedOn,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.EnvironmentID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const revokeAllUserRefreshTokens = `-- name: RevokeAllUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAllUserRefreshTokens(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAllUserRefreshTokens, userID)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users
-ORDER BY created_at DESC
+const revokeApiToken = `-- name: RevokeApiToken :execrows
+UPDATE api_tokens
+SET revoked_at = now()
+WHERE id = $1 AND created_by_id = $2 AND revoked_at IS NULL
 `
 
-func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers)
+type RevokeApiTokenParams struct {
+	ID          int32
+	CreatedByID int32
+}
+
+func (q *Queries) RevokeApiToken(ctx context.Context, arg RevokeApiTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeApiToken, arg.ID, arg.CreatedByID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []User
-	for rows.Next() {
-		var i User
-		if err := rows.Scan(
-			&i.ID,
-			&i.FirstName,
-			&i.LastName,
-			&i.DisplayName,
-			&i.Email,
-			&i.Password,
-			&i.Phone,
-			&i.OrgID,
-			&i.CountryIso,
-			&i.City,
-			&i.Address,
-			&i.IsActivated,
-			&i.IsReviewed,
-			&i.IsSuperAdmin,
-			&i.IsVerified,
-			&i.LastLoginAt,
-			&i.EmailConfirmedAt,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DeletedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected()
+}
+
+const revokeInvite = `-- name: RevokeInvite :execrows
+UPDATE invites SET revoked_at = now()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeInvite(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeInvite, id)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const revokePendingInvites = `-- name: RevokePendingInvites :exec
+UPDATE invites SET revoked_at = now()
+WHERE receiver_email = $1
+  AND org_id IS NOT DISTINCT FROM $2
+  AND project_id IS NOT DISTINCT FROM $3
+  AND accepted_at IS NULL AND revoked_at IS NULL
+`
+
+type RevokePendingInvitesParams struct {
+	ReceiverEmail string
+	OrgID         sql.NullInt32
+	ProjectID     sql.NullInt32
+}
+
+func (q *Queries) RevokePendingInvites(ctx context.Context, arg RevokePendingInvitesParams) error {
+	_, err := q.db.ExecContext(ctx, revokePendingInvites, arg.ReceiverEmail, arg.OrgID, arg.ProjectID)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenFamilyParams struct {
+	UserID   int32
+	FamilyID uuid.UUID
+}
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, arg RevokeRefreshTokenFamilyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, arg.UserID, arg.FamilyID)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const revokeSCIMToken = `-- name: RevokeSCIMToken :execrows
+UPDATE scim_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeSCIMToken(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSCIMToken, id)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
 const searchProject = `-- name: SearchProject :many
-SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners FROM projects
-WHERE title ILIKE '%' || $1 || '%'
+SELECT id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern FROM projects
+WHERE org_id = $1 AND title ILIKE '%' || $2 || '%'
 `
 
-func (q *Queries) SearchProject(ctx context.Context, dollar_1 sql.NullString) ([]Project, error) {
-	rows, err := q.db.QueryContext(ctx, searchProject, dollar_1)
+type SearchProjectParams struct {
+	OrgID   int32
+	Column2 sql.NullString
+}
+
+func (q *Queries) SearchProject(ctx context.Context, arg SearchProjectParams) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, searchProject, arg.OrgID, arg.Column2)
 	if err != nil {
 		return nil, err
 	}
@@ -3481,6 +7043,8 @@ func (q *Queries) SearchProject(ctx context.Context, dollar_1 sql.NullString) ([
 			&i.TestcaseTemplate,
 			&i.AutomatedTestingEnabled,
 			pq.Array(&i.SupportedRunners),
+			&i.OrgID,
+			&i.TestcaseCodePattern,
 		); err != nil {
 			return nil, err
 		}
@@ -3549,12 +7113,19 @@ func (q *Queries) SearchProjectTesters(ctx context.Context, dollar_1 sql.NullStr
 
 const searchTestCases = `-- name: SearchTestCases :many
 SELECT id, kind, code, feature_or_module, title, description, is_draft, tags, created_by_id, created_at, updated_at, project_id, suggested, runner, script_path, parent_test_case_id FROM test_cases
-WHERE title ILIKE '%' || $1 || '%'
-OR code ILIKE '%' || $1 || '%'
+WHERE project_id = $1
+AND (title ILIKE '%' || $2 || '%'
+OR code ILIKE '%' || $2 || '%'
+OR id IN (SELECT test_case_id FROM test_case_code_aliases WHERE code ILIKE '%' || $2 || '%'))
 `
 
-func (q *Queries) SearchTestCases(ctx context.Context, dollar_1 sql.NullString) ([]TestCase, error) {
-	rows, err := q.db.QueryContext(ctx, searchTestCases, dollar_1)
+type SearchTestCasesParams struct {
+	ProjectID sql.NullInt32
+	Column2   sql.NullString
+}
+
+func (q *Queries) SearchTestCases(ctx context.Context, arg SearchTestCasesParams) ([]TestCase, error) {
+	rows, err := q.db.QueryContext(ctx, searchTestCases, arg.ProjectID, arg.Column2)
 	if err != nil {
 		return nil, err
 	}
@@ -3642,7 +7213,143 @@ func (q *Queries) SearchUsers(ctx context.Context, dollar_1 sql.NullString) ([]U
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return items, nil
+	return items, nil
+}
+
+const searchUsersByOrg = `-- name: SearchUsersByOrg :many
+SELECT id, first_name, last_name, display_name, email, password, phone, org_id, country_iso, city, address, is_activated, is_reviewed, is_super_admin, is_verified, last_login_at, email_confirmed_at, created_at, updated_at, deleted_at FROM users u
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.user_id = u.id AND m.org_id = $1 AND m.removed_at IS NULL)
+AND (first_name ILIKE '%' || $2 || '%'
+OR last_name ILIKE '%' || $2 || '%'
+OR display_name ILIKE '%' || $2 || '%'
+OR email ILIKE '%' || $2 || '%')
+`
+
+type SearchUsersByOrgParams struct {
+	OrgID   int32
+	Column2 sql.NullString
+}
+
+func (q *Queries) SearchUsersByOrg(ctx context.Context, arg SearchUsersByOrgParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsersByOrg, arg.OrgID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.FirstName,
+			&i.LastName,
+			&i.DisplayName,
+			&i.Email,
+			&i.Password,
+			&i.Phone,
+			&i.OrgID,
+			&i.CountryIso,
+			&i.City,
+			&i.Address,
+			&i.IsActivated,
+			&i.IsReviewed,
+			&i.IsSuperAdmin,
+			&i.IsVerified,
+			&i.LastLoginAt,
+			&i.EmailConfirmedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setOrgMemberRole = `-- name: SetOrgMemberRole :execrows
+UPDATE org_members SET role = $3
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL
+`
+
+type SetOrgMemberRoleParams struct {
+	OrgID  int32
+	UserID int32
+	Role   string
+}
+
+func (q *Queries) SetOrgMemberRole(ctx context.Context, arg SetOrgMemberRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setOrgMemberRole, arg.OrgID, arg.UserID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setPageParent = `-- name: SetPageParent :exec
+UPDATE pages SET parent_page_id = $2 WHERE id = $1
+`
+
+type SetPageParentParams struct {
+	ID           int32
+	ParentPageID sql.NullInt32
+}
+
+func (q *Queries) SetPageParent(ctx context.Context, arg SetPageParentParams) error {
+	_, err := q.db.ExecContext(ctx, setPageParent, arg.ID, arg.ParentPageID)
+	return err
+}
+
+const setProjectParent = `-- name: SetProjectParent :execrows
+UPDATE projects SET parent_project_id = $2, updated_at = now() WHERE id = $1
+`
+
+type SetProjectParentParams struct {
+	ID              int32
+	ParentProjectID sql.NullInt32
+}
+
+func (q *Queries) SetProjectParent(ctx context.Context, arg SetProjectParentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setProjectParent, arg.ID, arg.ParentProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setProjectTestCaseCodePattern = `-- name: SetProjectTestCaseCodePattern :exec
+UPDATE projects SET testcase_code_pattern = $2, updated_at = now() WHERE id = $1
+`
+
+type SetProjectTestCaseCodePatternParams struct {
+	ID                  int32
+	TestcaseCodePattern sql.NullString
+}
+
+func (q *Queries) SetProjectTestCaseCodePattern(ctx context.Context, arg SetProjectTestCaseCodePatternParams) error {
+	_, err := q.db.ExecContext(ctx, setProjectTestCaseCodePattern, arg.ID, arg.TestcaseCodePattern)
+	return err
+}
+
+const setTestCaseCode = `-- name: SetTestCaseCode :exec
+UPDATE test_cases SET code = $2, updated_at = now() WHERE id = $1
+`
+
+type SetTestCaseCodeParams struct {
+	ID   uuid.UUID
+	Code string
+}
+
+func (q *Queries) SetTestCaseCode(ctx context.Context, arg SetTestCaseCodeParams) error {
+	_, err := q.db.ExecContext(ctx, setTestCaseCode, arg.ID, arg.Code)
+	return err
 }
 
 const setTestCaseDraftStatus = `-- name: SetTestCaseDraftStatus :exec
@@ -3661,6 +7368,86 @@ func (q *Queries) SetTestCaseDraftStatus(ctx context.Context, arg SetTestCaseDra
 	return err
 }
 
+const setTestCaseParent = `-- name: SetTestCaseParent :exec
+UPDATE test_cases SET parent_test_case_id = $2 WHERE id = $1
+`
+
+type SetTestCaseParentParams struct {
+	ID               uuid.UUID
+	ParentTestCaseID uuid.NullUUID
+}
+
+func (q *Queries) SetTestCaseParent(ctx context.Context, arg SetTestCaseParentParams) error {
+	_, err := q.db.ExecContext(ctx, setTestCaseParent, arg.ID, arg.ParentTestCaseID)
+	return err
+}
+
+const setTestCaseSequence = `-- name: SetTestCaseSequence :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, prefix) DO UPDATE
+SET current_val = EXCLUDED.current_val, last_generated_at = now()
+`
+
+type SetTestCaseSequenceParams struct {
+	ProjectID  int32
+	Prefix     string
+	CurrentVal int32
+}
+
+func (q *Queries) SetTestCaseSequence(ctx context.Context, arg SetTestCaseSequenceParams) error {
+	_, err := q.db.ExecContext(ctx, setTestCaseSequence, arg.ProjectID, arg.Prefix, arg.CurrentVal)
+	return err
+}
+
+const setTestRunTestCaseRevision = `-- name: SetTestRunTestCaseRevision :exec
+UPDATE test_runs SET test_case_revision = $2 WHERE id = $1
+`
+
+type SetTestRunTestCaseRevisionParams struct {
+	ID               uuid.UUID
+	TestCaseRevision sql.NullInt32
+}
+
+func (q *Queries) SetTestRunTestCaseRevision(ctx context.Context, arg SetTestRunTestCaseRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, setTestRunTestCaseRevision, arg.ID, arg.TestCaseRevision)
+	return err
+}
+
+const setUserDefaultOrg = `-- name: SetUserDefaultOrg :execrows
+UPDATE users SET org_id = $2, updated_at = now() WHERE id = $1
+`
+
+type SetUserDefaultOrgParams struct {
+	ID    int32
+	OrgID sql.NullInt32
+}
+
+func (q *Queries) SetUserDefaultOrg(ctx context.Context, arg SetUserDefaultOrgParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserDefaultOrg, arg.ID, arg.OrgID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserSuperAdmin = `-- name: SetUserSuperAdmin :execrows
+UPDATE users SET is_super_admin = $2, updated_at = now() WHERE id = $1
+`
+
+type SetUserSuperAdminParams struct {
+	ID           int32
+	IsSuperAdmin sql.NullBool
+}
+
+func (q *Queries) SetUserSuperAdmin(ctx context.Context, arg SetUserSuperAdminParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserSuperAdmin, arg.ID, arg.IsSuperAdmin)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const testCaseCountByAssignedUser = `-- name: TestCaseCountByAssignedUser :one
 SELECT COUNT(*)
 FROM (
@@ -3669,18 +7456,20 @@ FROM (
   INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
   LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
   WHERE pc.assigned_to_id = $1
+    AND tc.project_id IN (SELECT id FROM projects WHERE org_id = $2)
   GROUP BY tc.id
-  HAVING $2::bool = true OR COALESCE(BOOL_OR(tr.is_closed), false)::boolean = false
+  HAVING $3::bool = true OR COALESCE(BOOL_OR(tr.is_closed), false)::boolean = false
 ) sub
 `
 
 type TestCaseCountByAssignedUserParams struct {
 	UserID        int64
+	OrgID         int32
 	IncludeClosed bool
 }
 
 func (q *Queries) TestCaseCountByAssignedUser(ctx context.Context, arg TestCaseCountByAssignedUserParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, testCaseCountByAssignedUser, arg.UserID, arg.IncludeClosed)
+	row := q.db.QueryRowContext(ctx, testCaseCountByAssignedUser, arg.UserID, arg.OrgID, arg.IncludeClosed)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -3724,16 +7513,18 @@ INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
 LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
 WHERE pc.assigned_to_id = $1
   AND ($2::bool = true OR COALESCE(tr.is_closed, false) = false)
+  AND tc.project_id IN (SELECT id FROM projects WHERE org_id = $3)
 GROUP BY tc.id
 ORDER BY tc.created_at DESC
-LIMIT $4::int OFFSET $3::int
+LIMIT $4::int OFFSET $5::int
 `
 
 type TestCaseListByAssignedUserParams struct {
 	UserID        int64
 	IncludeClosed bool
-	RowOffset     int32
+	OrgID         int32
 	RowLimit      int32
+	RowOffset     int32
 }
 
 type TestCaseListByAssignedUserRow struct {
@@ -3759,8 +7550,9 @@ func (q *Queries) TestCaseListByAssignedUser(ctx context.Context, arg TestCaseLi
 	rows, err := q.db.QueryContext(ctx, testCaseListByAssignedUser,
 		arg.UserID,
 		arg.IncludeClosed,
-		arg.RowOffset,
+		arg.OrgID,
 		arg.RowLimit,
+		arg.RowOffset,
 	)
 	if err != nil {
 		return nil, err
@@ -3855,11 +7647,46 @@ func (q *Queries) TestCaseListByProjectPaged(ctx context.Context, arg TestCaseLi
 	return items, nil
 }
 
+const touchApiToken = `-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = now() WHERE id = $1
+`
+
+func (q *Queries) TouchApiToken(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, touchApiToken, id)
+	return err
+}
+
+const touchSCIMToken = `-- name: TouchSCIMToken :exec
+UPDATE scim_tokens SET last_used_at = now() WHERE id = $1
+`
+
+func (q *Queries) TouchSCIMToken(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, touchSCIMToken, id)
+	return err
+}
+
+const touchUserIdentity = `-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $3, last_login_at = now()
+WHERE provider = $1 AND subject = $2
+`
+
+type TouchUserIdentityParams struct {
+	Provider string
+	Subject  string
+	Email    sql.NullString
+}
+
+func (q *Queries) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, touchUserIdentity, arg.Provider, arg.Subject, arg.Email)
+	return err
+}
+
 const unarchiveProject = `-- name: UnarchiveProject :one
 UPDATE projects
 SET is_active = true
 WHERE id = $1
-RETURNING id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners
+RETURNING id, title, description, version, is_active, is_public, website_url, github_url, trello_url, jira_url, monday_url, owner_user_id, created_at, updated_at, deleted_at, code, parent_project_id, testcase_template, automated_testing_enabled, supported_runners, org_id, testcase_code_pattern
 `
 
 func (q *Queries) UnarchiveProject(ctx context.Context, id int32) (Project, error) {
@@ -3886,10 +7713,29 @@ func (q *Queries) UnarchiveProject(ctx context.Context, id int32) (Project, erro
 		&i.TestcaseTemplate,
 		&i.AutomatedTestingEnabled,
 		pq.Array(&i.SupportedRunners),
+		&i.OrgID,
+		&i.TestcaseCodePattern,
 	)
 	return i, err
 }
 
+const unlinkSCIMUser = `-- name: UnlinkSCIMUser :execrows
+DELETE FROM scim_users WHERE org_id = $1 AND user_id = $2
+`
+
+type UnlinkSCIMUserParams struct {
+	OrgID  int32
+	UserID int32
+}
+
+func (q *Queries) UnlinkSCIMUser(ctx context.Context, arg UnlinkSCIMUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unlinkSCIMUser, arg.OrgID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAutomatedTesting = `-- name: UpdateAutomatedTesting :exec
 UPDATE projects
 SET
@@ -3910,6 +7756,48 @@ func (q *Queries) UpdateAutomatedTesting(ctx context.Context, arg UpdateAutomate
 	return err
 }
 
+const updateCustomField = `-- name: UpdateCustomField :one
+UPDATE custom_fields SET label = $2, options = $3, is_required = $4, default_value = $5, position = $6, updated_at = now()
+WHERE id = $1
+RETURNING id, project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at
+`
+
+type UpdateCustomFieldParams struct {
+	ID           int32
+	Label        string
+	Options      []string
+	IsRequired   bool
+	DefaultValue pqtype.NullRawMessage
+	Position     int32
+}
+
+func (q *Queries) UpdateCustomField(ctx context.Context, arg UpdateCustomFieldParams) (CustomField, error) {
+	row := q.db.QueryRowContext(ctx, updateCustomField,
+		arg.ID,
+		arg.Label,
+		pq.Array(arg.Options),
+		arg.IsRequired,
+		arg.DefaultValue,
+		arg.Position,
+	)
+	var i CustomField
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Entity,
+		&i.Name,
+		&i.Label,
+		&i.FieldType,
+		pq.Array(&i.Options),
+		&i.IsRequired,
+		&i.DefaultValue,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateEnvironment = `-- name: UpdateEnvironment :exec
 UPDATE environments SET name = $1, base_url = $2, description = $3 WHERE id = $4 AND project_id = $5
 `
@@ -3934,17 +7822,18 @@ func (q *Queries) UpdateEnvironment(ctx context.Context, arg UpdateEnvironmentPa
 }
 
 const updateOrg = `-- name: UpdateOrg :exec
-UPDATE orgs SET name = $2, address = $3, country = $4, github_url = $5, website_url = $6, updated_at = now()
+UPDATE orgs SET name = $2, address = $3, country = $4, github_url = $5, website_url = $6, require_two_factor = $7, updated_at = now()
 WHERE id = $1
 `
 
 type UpdateOrgParams struct {
-	ID         int32
-	Name       string
-	Address    sql.NullString
-	Country    sql.NullString
-	GithubUrl  sql.NullString
-	WebsiteUrl sql.NullString
+	ID               int32
+	Name             string
+	Address          sql.NullString
+	Country          sql.NullString
+	GithubUrl        sql.NullString
+	WebsiteUrl       sql.NullString
+	RequireTwoFactor bool
 }
 
 func (q *Queries) UpdateOrg(ctx context.Context, arg UpdateOrgParams) error {
@@ -3955,6 +7844,7 @@ func (q *Queries) UpdateOrg(ctx context.Context, arg UpdateOrgParams) error {
 		arg.Country,
 		arg.GithubUrl,
 		arg.WebsiteUrl,
+		arg.RequireTwoFactor,
 	)
 	return err
 }
@@ -4081,17 +7971,35 @@ func (q *Queries) UpdateProjectModule(ctx context.Context, arg UpdateProjectModu
 	return err
 }
 
+const updateProjectOwner = `-- name: UpdateProjectOwner :execrows
+UPDATE projects SET owner_user_id = $2, updated_at = now() WHERE id = $1 AND deleted_at IS NULL
+`
+
+type UpdateProjectOwnerParams struct {
+	ID          int32
+	OwnerUserID int32
+}
+
+func (q *Queries) UpdateProjectOwner(ctx context.Context, arg UpdateProjectOwnerParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateProjectOwner, arg.ID, arg.OwnerUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateProjectTesterRole = `-- name: UpdateProjectTesterRole :execrows
-UPDATE project_testers SET role = $2 WHERE user_id = $1
+UPDATE project_testers SET role = $3, updated_at = now() WHERE project_id = $1 AND user_id = $2
 `
 
 type UpdateProjectTesterRoleParams struct {
-	UserID int32
-	Role   string
+	ProjectID int32
+	UserID    int32
+	Role      string
 }
 
 func (q *Queries) UpdateProjectTesterRole(ctx context.Context, arg UpdateProjectTesterRoleParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, updateProjectTesterRole, arg.UserID, arg.Role)
+	result, err := q.db.ExecContext(ctx, updateProjectTesterRole, arg.ProjectID, arg.UserID, arg.Role)
 	if err != nil {
 		return 0, err
 	}
@@ -4126,6 +8034,48 @@ func (q *Queries) UpdateReportStatus(ctx context.Context, arg UpdateReportStatus
 	return err
 }
 
+const updateSharedStep = `-- name: UpdateSharedStep :one
+UPDATE shared_steps SET
+    name = $2,
+    description = $3,
+    parameters = $4,
+    steps = $5,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, project_id, name, description, parameters, steps, created_by_id, created_at, updated_at
+`
+
+type UpdateSharedStepParams struct {
+	ID          int32
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Steps       json.RawMessage
+}
+
+func (q *Queries) UpdateSharedStep(ctx context.Context, arg UpdateSharedStepParams) (SharedStep, error) {
+	row := q.db.QueryRowContext(ctx, updateSharedStep,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Parameters,
+		arg.Steps,
+	)
+	var i SharedStep
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.Parameters,
+		&i.Steps,
+		&i.CreatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateSuggestedFlag = `-- name: UpdateSuggestedFlag :exec
 UPDATE test_cases SET suggested = $2 WHERE id = $1
 `
@@ -4293,6 +8243,162 @@ func (q *Queries) UpdateUserLastLogin(ctx context.Context, arg UpdateUserLastLog
 	return result.RowsAffected()
 }
 
+const updateUserNames = `-- name: UpdateUserNames :execrows
+UPDATE users SET first_name = $2, last_name = $3, display_name = $4, email = $5, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type UpdateUserNamesParams struct {
+	ID          int32
+	FirstName   string
+	LastName    string
+	DisplayName sql.NullString
+	Email       string
+}
+
+func (q *Queries) UpdateUserNames(ctx context.Context, arg UpdateUserNamesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserNames,
+		arg.ID,
+		arg.FirstName,
+		arg.LastName,
+		arg.DisplayName,
+		arg.Email,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertCustomFieldValue = `-- name: UpsertCustomFieldValue :exec
+INSERT INTO custom_field_values (field_id, entity_id, value, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (field_id, entity_id) DO UPDATE SET value = EXCLUDED.value, updated_at = now()
+`
+
+type UpsertCustomFieldValueParams struct {
+	FieldID  int32
+	EntityID uuid.UUID
+	Value    json.RawMessage
+}
+
+func (q *Queries) UpsertCustomFieldValue(ctx context.Context, arg UpsertCustomFieldValueParams) error {
+	_, err := q.db.ExecContext(ctx, upsertCustomFieldValue, arg.FieldID, arg.EntityID, arg.Value)
+	return err
+}
+
+const upsertProjectTester = `-- name: UpsertProjectTester :exec
+INSERT INTO project_testers (
+    project_id, user_id, role, is_active, created_at, updated_at
+) VALUES (
+    $1, $2, $3, true, now(), now()
+)
+ON CONFLICT (project_id, user_id) DO UPDATE
+SET role = EXCLUDED.role, is_active = true, updated_at = now()
+`
+
+type UpsertProjectTesterParams struct {
+	ProjectID int32
+	UserID    int32
+	Role      string
+}
+
+func (q *Queries) UpsertProjectTester(ctx context.Context, arg UpsertProjectTesterParams) error {
+	_, err := q.db.ExecContext(ctx, upsertProjectTester, arg.ProjectID, arg.UserID, arg.Role)
+	return err
+}
+
+const upsertSessionOrg = `-- name: UpsertSessionOrg :exec
+INSERT INTO session_orgs (family_id, user_id, org_id, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (family_id) DO UPDATE SET org_id = EXCLUDED.org_id, updated_at = now()
+`
+
+type UpsertSessionOrgParams struct {
+	FamilyID uuid.UUID
+	UserID   int32
+	OrgID    int32
+}
+
+func (q *Queries) UpsertSessionOrg(ctx context.Context, arg UpsertSessionOrgParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSessionOrg, arg.FamilyID, arg.UserID, arg.OrgID)
+	return err
+}
+
+const upsertTestCaseDataset = `-- name: UpsertTestCaseDataset :one
+INSERT INTO test_case_datasets (test_case_id, parameters, rows, updated_by_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, now(), now())
+ON CONFLICT (test_case_id) DO UPDATE SET
+    parameters = EXCLUDED.parameters,
+    rows = EXCLUDED.rows,
+    updated_by_id = EXCLUDED.updated_by_id,
+    updated_at = now()
+RETURNING test_case_id, parameters, rows, updated_by_id, created_at, updated_at
+`
+
+type UpsertTestCaseDatasetParams struct {
+	TestCaseID  uuid.UUID
+	Parameters  []string
+	Rows        json.RawMessage
+	UpdatedByID sql.NullInt32
+}
+
+func (q *Queries) UpsertTestCaseDataset(ctx context.Context, arg UpsertTestCaseDatasetParams) (TestCaseDataset, error) {
+	row := q.db.QueryRowContext(ctx, upsertTestCaseDataset,
+		arg.TestCaseID,
+		pq.Array(arg.Parameters),
+		arg.Rows,
+		arg.UpdatedByID,
+	)
+	var i TestCaseDataset
+	err := row.Scan(
+		&i.TestCaseID,
+		pq.Array(&i.Parameters),
+		&i.Rows,
+		&i.UpdatedByID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32
+	CodeHash string
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTotpStep = `-- name: UseTotpStep :execrows
+UPDATE totp_credentials
+SET last_used_step = $2, updated_at = now()
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+`
+
+type UseTotpStepParams struct {
+	UserID       int32
+	LastUsedStep int64
+}
+
+func (q *Queries) UseTotpStep(ctx context.Context, arg UseTotpStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTotpStep, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const userExists = `-- name: UserExists :one
 SELECT EXISTS(SELECT id FROM users WHERE id = $1)
 `
@@ -4303,3 +8409,22 @@ func (q *Queries) UserExists(ctx context.Context, id int32) (bool, error) {
 	err := row.Scan(&exists)
 	return exists, err
 }
+
+const userRequiresTwoFactor = `-- name: UserRequiresTwoFactor :one
+SELECT EXISTS(
+    SELECT 1 FROM org_members m
+    INNER JOIN orgs o ON o.id = m.org_id
+    WHERE m.user_id = $1 AND m.removed_at IS NULL AND o.require_two_factor
+) OR EXISTS(
+    SELECT 1 FROM users u
+    INNER JOIN orgs o ON o.id = u.org_id
+    WHERE u.id = $1 AND o.require_two_factor
+) AS requires_two_factor
+`
+
+func (q *Queries) UserRequiresTwoFactor(ctx context.Context, userID int32) (bool, error) {
+	row := q.db.QueryRowContext(ctx, userRequiresTwoFactor, userID)
+	var requiresTwoFactor bool
+	err := row.Scan(&requiresTwoFactor)
+	return requiresTwoFactor, err
+}
@@ -18,4 +18,9 @@ const (
 	ApiDashboard    Name = "apiv1:dashboard"
 	ApiOrgs         Name = "apiv1:orgs"
 	ApiEnvironments Name = "apiv1:environments"
+	ApiTokens       Name = "apiv1:api-tokens"
+	ApiInvites      Name = "apiv1:invites"
+	ApiSCIM         Name = "apiv1:scim"
+	ApiCustomFields Name = "apiv1:custom-fields"
+	ApiSharedSteps  Name = "apiv1:shared-steps"
 )
@@ -0,0 +1,33 @@
+package schema
+
+// CreateAPITokenRequest request to create a personal access token or, when
+// ServiceAccount is set, an API key for a new bot account
+type CreateAPITokenRequest struct {
+	Name           string   `json:"name" validate:"required"`
+	Scopes         []string `json:"scopes" validate:"required,min=1"`
+	ProjectID      int64    `json:"project_id" validate:"-"`
+	ExpiresInDays  int      `json:"expires_in_days" validate:"gte=0"`
+	ServiceAccount string   `json:"service_account" validate:"-"`
+}
+
+type APIToken struct {
+	ID         int64    `json:"id"`
+	UserID     int64    `json:"user_id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ProjectID  int64    `json:"project_id,omitempty"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateAPITokenResponse contains the raw token which is only shown once
+type CreateAPITokenResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+type APITokenListResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+)
+
+type CreateCustomFieldRequest struct {
+	ProjectID int64  `json:"-"`
+	Entity    string `json:"entity" validate:"required,oneof=test_case test_run"`
+	Name      string `json:"name" validate:"required"`
+	Label     string `json:"label" validate:"required"`
+	// Type is one of text, number, enum, multi_select, date or user
+	Type         string   `json:"type" validate:"required"`
+	Options      []string `json:"options,omitempty"`
+	IsRequired   bool     `json:"is_required"`
+	DefaultValue any      `json:"default_value,omitempty"`
+	Position     int32    `json:"position"`
+}
+
+// UpdateCustomFieldRequest changes a custom field, the name, type and entity
+// of a field cannot change once values are stored for it
+type UpdateCustomFieldRequest struct {
+	ProjectID    int64    `json:"-"`
+	FieldID      int64    `json:"-"`
+	Label        string   `json:"label" validate:"required"`
+	Options      []string `json:"options,omitempty"`
+	IsRequired   bool     `json:"is_required"`
+	DefaultValue any      `json:"default_value,omitempty"`
+	Position     int32    `json:"position"`
+}
+
+type CustomFieldResponse struct {
+	ID           int32     `json:"id"`
+	ProjectID    int32     `json:"project_id"`
+	Entity       string    `json:"entity"`
+	Name         string    `json:"name"`
+	Label        string    `json:"label"`
+	Type         string    `json:"type"`
+	Options      []string  `json:"options"`
+	IsRequired   bool      `json:"is_required"`
+	DefaultValue any       `json:"default_value,omitempty"`
+	Position     int32     `json:"position"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func NewCustomFieldResponse(e *dbsqlc.CustomField) CustomFieldResponse {
+	var defaultValue any
+	if e.DefaultValue.Valid {
+		_ = json.Unmarshal(e.DefaultValue.RawMessage, &defaultValue)
+	}
+	options := e.Options
+	if options == nil {
+		options = []string{}
+	}
+	return CustomFieldResponse{
+		ID:           e.ID,
+		ProjectID:    e.ProjectID,
+		Entity:       e.Entity,
+		Name:         e.Name,
+		Label:        e.Label,
+		Type:         e.FieldType,
+		Options:      options,
+		IsRequired:   e.IsRequired,
+		DefaultValue: defaultValue,
+		Position:     e.Position,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+	}
+}
+
+type CustomFieldListResponse struct {
+	CustomFields []CustomFieldResponse `json:"custom_fields"`
+}
+
+func NewCustomFieldListResponse(items []dbsqlc.CustomField) CustomFieldListResponse {
+	res := make([]CustomFieldResponse, 0, len(items))
+	for _, item := range items {
+		res = append(res, NewCustomFieldResponse(&item))
+	}
+	return CustomFieldListResponse{CustomFields: res}
+}
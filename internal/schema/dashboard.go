@@ -16,3 +16,19 @@ type DashboardProjectRecord struct {
 	Name      string    `json:"name"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// ProjectRollupResponse is the dashboard of a project aggregated over the
+// project and all of its sub-projects
+type ProjectRollupResponse struct {
+	DashboardSummaryResponse
+	ProjectID         int64   `json:"project_id"`
+	SubProjectIDs     []int64 `json:"sub_project_ids"`
+	OpenTestPlanCount int64   `json:"open_test_plan_count"`
+	PassedCount       int64   `json:"passed_count"`
+	FailedCount       int64   `json:"failed_count"`
+	PendingCount      int64   `json:"pending_count"`
+	// PassRate and FailRate are the shares of executed test runs which
+	// passed or failed, pending runs are not counted
+	PassRate float64 `json:"pass_rate"`
+	FailRate float64 `json:"fail_rate"`
+}
@@ -0,0 +1,75 @@
+package schema
+
+// CreateInviteRequest request to invite someone by email to an org or, when
+// ProjectID is set, to a project as a tester with the given role
+type CreateInviteRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	OrgID     int32  `json:"org_id" validate:"gte=0"`
+	ProjectID int32  `json:"project_id" validate:"gte=0"`
+	Role      string `json:"role" validate:"-"`
+}
+
+// Invite statuses
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusAccepted = "accepted"
+	InviteStatusRevoked  = "revoked"
+	InviteStatusExpired  = "expired"
+)
+
+type Invite struct {
+	ID          int64  `json:"id"`
+	Email       string `json:"email"`
+	InvitedByID int64  `json:"invited_by_id,omitempty"`
+	InvitedBy   string `json:"invited_by"`
+	OrgID       int64  `json:"org_id,omitempty"`
+	ProjectID   int64  `json:"project_id,omitempty"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+	ExpiresAt   string `json:"expires_at"`
+	AcceptedAt  string `json:"accepted_at,omitempty"`
+	AcceptedBy  int64  `json:"accepted_by,omitempty"`
+	RevokedAt   string `json:"revoked_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type InviteListResponse struct {
+	Invites []Invite `json:"invites"`
+}
+
+// InvitePreview what an invitation link is for, shown before it is accepted
+type InvitePreview struct {
+	Email     string `json:"email"`
+	InvitedBy string `json:"invited_by"`
+	OrgName   string `json:"org_name,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+	// AccountExists tells the client to ask for the password of the existing
+	// account rather than the details of a new one
+	AccountExists bool `json:"account_exists"`
+}
+
+// AcceptInviteRequest request to accept an invitation, the names are only
+// used when a new account is created for the invited email
+type AcceptInviteRequest struct {
+	Token       string `json:"token" validate:"required"`
+	FirstName   string `json:"first_name" validate:"-"`
+	LastName    string `json:"last_name" validate:"-"`
+	DisplayName string `json:"display_name" validate:"-"`
+	Password    string `json:"password" validate:"required"`
+	UserAgent   string `json:"-" validate:"-"`
+	IPAddress   string `json:"-" validate:"-"`
+}
+
+// AcceptInviteResponse the account the invitation was accepted with and the
+// login for it, which may ask for a second factor like a regular login
+type AcceptInviteResponse struct {
+	UserID    int64          `json:"user_id"`
+	Email     string         `json:"email"`
+	OrgID     int64          `json:"org_id,omitempty"`
+	ProjectID int64          `json:"project_id,omitempty"`
+	Role      string         `json:"role"`
+	Created   bool           `json:"created"`
+	Login     *LoginResponse `json:"login"`
+}
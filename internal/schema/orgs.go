@@ -1,35 +1,80 @@
 package schema
 
 type Org struct {
-	ID         int32  `json:"id"`
-	Name       string `json:"name"`
-	Address    string `json:"address,omitempty"`
-	Country    string `json:"country,omitempty"`
-	GithubURL  string `json:"github_url,omitempty"`
-	WebsiteURL string `json:"website_url,omitempty"`
-	CreatedBy  int64  `json:"created_by,omitempty"`
-	CreatedAt  string `json:"created_at,omitempty"`
-	UpdatedAt  string `json:"updated_at,omitempty"`
+	ID               int32  `json:"id"`
+	Name             string `json:"name"`
+	Address          string `json:"address,omitempty"`
+	Country          string `json:"country,omitempty"`
+	GithubURL        string `json:"github_url,omitempty"`
+	WebsiteURL       string `json:"website_url,omitempty"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
+	CreatedBy        int64  `json:"created_by,omitempty"`
+	CreatedAt        string `json:"created_at,omitempty"`
+	UpdatedAt        string `json:"updated_at,omitempty"`
 }
 
 type CreateOrgRequest struct {
-	Name       string `json:"name" validate:"required"`
-	Address    string `json:"address,omitempty"`
-	Country    string `json:"country,omitempty"`
-	GithubURL  string `json:"github_url,omitempty"`
-	WebsiteURL string `json:"website_url,omitempty"`
+	Name             string `json:"name" validate:"required"`
+	Address          string `json:"address,omitempty"`
+	Country          string `json:"country,omitempty"`
+	GithubURL        string `json:"github_url,omitempty"`
+	WebsiteURL       string `json:"website_url,omitempty"`
+	RequireTwoFactor bool   `json:"require_two_factor,omitempty"`
 }
 
 type UpdateOrgRequest struct {
-	ID         int32  `json:"id" validate:"required"`
-	Name       string `json:"name" validate:"required"`
-	Address    string `json:"address,omitempty"`
-	Country    string `json:"country,omitempty"`
-	GithubURL  string `json:"github_url,omitempty"`
-	WebsiteURL string `json:"website_url,omitempty"`
+	ID               int32  `json:"id" validate:"required"`
+	Name             string `json:"name" validate:"required"`
+	Address          string `json:"address,omitempty"`
+	Country          string `json:"country,omitempty"`
+	GithubURL        string `json:"github_url,omitempty"`
+	WebsiteURL       string `json:"website_url,omitempty"`
+	RequireTwoFactor bool   `json:"require_two_factor,omitempty"`
 }
 
 type OrgListResponse struct {
 	Total int   `json:"total"`
 	Orgs  []Org `json:"orgs"`
 }
+
+// OrgMember is a user belonging to an org with their role in it
+type OrgMember struct {
+	UserID      int64  `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	IsActive    bool   `json:"is_active"`
+	JoinedAt    string `json:"joined_at"`
+}
+
+type OrgMemberListResponse struct {
+	Members []OrgMember `json:"members"`
+}
+
+// AddOrgMemberRequest adds an existing user to the org, Role defaults to member
+type AddOrgMemberRequest struct {
+	UserID int64  `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"omitempty,oneof=owner admin member"`
+}
+
+type UpdateOrgMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+// TransferOrgOwnershipRequest makes the user an owner of the org, the owner
+// transferring it becomes an admin
+type TransferOrgOwnershipRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
+// UserOrg is an org the user belongs to
+type UserOrg struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Current bool   `json:"current"`
+}
+
+type UserOrgListResponse struct {
+	Orgs []UserOrg `json:"orgs"`
+}
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/validation"
 )
 
 // NewProjectRequest a request representing creation of a new project on the platform
@@ -52,6 +53,7 @@ type ProjectResponse struct {
 	JiraUrl                 string   `json:"jira_url"`
 	MondayUrl               string   `json:"monday_url"`
 	OwnerUserID             int32    `json:"owner_user_id"`
+	OrgID                   int32    `json:"org_id"`
 	CreatedAt               string   `json:"created_at"`
 	UpdatedAt               string   `json:"updated_at"`
 	ParentProjectID         int32    `json:"parent_project_id"`
@@ -74,6 +76,7 @@ func NewProjectResponse(data *dbsqlc.Project, owner *dbsqlc.User) ProjectRespons
 		JiraUrl:                 data.JiraUrl.String,
 		MondayUrl:               data.MondayUrl.String,
 		OwnerUserID:             data.OwnerUserID,
+		OrgID:                   data.OrgID,
 		CreatedAt:               formatDateTime(data.CreatedAt),
 		UpdatedAt:               formatDateTime(data.UpdatedAt),
 		ParentProjectID:         data.ParentProjectID.Int32,
@@ -90,16 +93,19 @@ func NewProjectResponseList(projects []dbsqlc.Project) []ProjectResponse {
 	return res
 }
 
-type ImportProjectRequest struct {
-}
-
+// AddProjectTestCaseTemplateRequest sets the sections, variables and per kind
+// layouts test cases of the project are checked against
 type AddProjectTestCaseTemplateRequest struct {
-	ProjectID        int64  `json:"project_id" validate:"required"`
-	TestCaseTemplate string `json:"test_case_template" validate:"required"`
+	ProjectID        int64                        `json:"project_id" validate:"required"`
+	TestCaseTemplate *validation.TestCaseTemplate `json:"test_case_template" validate:"required"`
 }
 
 type ProjectTestCaseTemplateResponse struct {
-	TestCaseTemplate string `json:"test_case_template"`
+	TestCaseTemplate *validation.TestCaseTemplate `json:"test_case_template"`
+	// Kind is the test kind the skeleton was rendered for
+	Kind string `json:"kind,omitempty"`
+	// Skeleton is the rendered description of a new test case
+	Skeleton string `json:"skeleton"`
 }
 
 type UpdateAutomatedTestingRequest struct {
@@ -107,3 +113,37 @@ type UpdateAutomatedTestingRequest struct {
 	AutomatedTestingEnabled bool     `json:"automated_testing_enabled"`
 	SupportedRunners        []string `json:"supported_runners" validate:"dive,oneof=basi playwright cypress browseruse"`
 }
+
+// CloneProjectRequest creates a copy of a project for example for a new product
+// version, the copy records the source as its parent project
+type CloneProjectRequest struct {
+	Name           string `json:"name" validate:"required"`
+	Code           string `json:"code" validate:"required,min=3,max=10"`
+	Version        string `json:"version,omitempty"`
+	ProjectOwnerID int64  `json:"-"`
+	// KeepTestCaseCodes copies the codes of the test cases instead of
+	// generating fresh codes for the new project
+	KeepTestCaseCodes    bool `json:"keep_test_case_codes"`
+	IncludeTesters       bool `json:"include_testers"`
+	IncludeOpenTestPlans bool `json:"include_open_test_plans"`
+}
+
+// CloneProjectCounts is the number of entities copied into the clone
+type CloneProjectCounts struct {
+	Modules      int `json:"modules"`
+	TestCases    int `json:"test_cases"`
+	Environments int `json:"environments"`
+	CustomFields int `json:"custom_fields"`
+	SharedSteps  int `json:"shared_steps"`
+	Testers      int `json:"testers"`
+	TestPlans    int `json:"test_plans"`
+}
+
+type CloneProjectResponse struct {
+	Project ProjectResponse    `json:"project"`
+	Counts  CloneProjectCounts `json:"counts"`
+}
+
+type SetParentProjectRequest struct {
+	ParentProjectID int64 `json:"parent_project_id"`
+}
@@ -0,0 +1,275 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/validation"
+)
+
+// ProjectBundleFormatVersion is the version of the project bundle format
+// written by this release, bundles with a newer version are rejected
+const ProjectBundleFormatVersion = 1
+
+// ProjectBundleManifest is the manifest.json of a project bundle, the archive
+// also holds the stored scripts and report files under files/. IDs are the
+// IDs on the exporting instance and are only used to link the entities in
+// the bundle, users are referenced by ID and matched by email on import
+type ProjectBundleManifest struct {
+	FormatVersion  int                     `json:"format_version"`
+	ExportedAt     time.Time               `json:"exported_at"`
+	SourceInstance string                  `json:"source_instance,omitempty"`
+	Project        BundleProject           `json:"project"`
+	Users          []BundleUser            `json:"users"`
+	Modules        []BundleModule          `json:"modules"`
+	Environments   []BundleEnvironment     `json:"environments"`
+	CustomFields   []BundleCustomField     `json:"custom_fields,omitempty"`
+	SharedSteps    []BundleSharedStep      `json:"shared_steps,omitempty"`
+	TestCases      []BundleTestCase        `json:"test_cases"`
+	TestPlans      []BundleTestPlan        `json:"test_plans"`
+	TestPlanCases  []BundleTestPlanCase    `json:"test_plan_cases"`
+	TestRuns       []BundleTestRun         `json:"test_runs"`
+	TestRunResults []BundleTestRunResult   `json:"test_run_results"`
+	Comments       []BundleTestPlanComment `json:"comments"`
+	Pages          []BundlePage            `json:"pages"`
+	Reports        []BundleReport          `json:"reports"`
+}
+
+type BundleProject struct {
+	ID                      int32    `json:"id"`
+	Title                   string   `json:"title"`
+	Code                    string   `json:"code"`
+	Description             string   `json:"description"`
+	Version                 string   `json:"version,omitempty"`
+	IsActive                bool     `json:"is_active"`
+	IsPublic                bool     `json:"is_public"`
+	WebsiteURL              string   `json:"website_url,omitempty"`
+	GithubURL               string   `json:"github_url,omitempty"`
+	TrelloURL               string   `json:"trello_url,omitempty"`
+	JiraURL                 string   `json:"jira_url,omitempty"`
+	MondayURL               string   `json:"monday_url,omitempty"`
+	OwnerUserID             int32    `json:"owner_user_id"`
+	TestcaseTemplate        string   `json:"testcase_template,omitempty"`
+	TestcaseCodePattern     string   `json:"testcase_code_pattern,omitempty"`
+	AutomatedTestingEnabled bool     `json:"automated_testing_enabled"`
+	SupportedRunners        []string `json:"supported_runners,omitempty"`
+}
+
+type BundleUser struct {
+	ID          int32  `json:"id"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+type BundleModule struct {
+	Name        string `json:"name"`
+	Code        string `json:"code"`
+	Priority    int32  `json:"priority"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type BundleEnvironment struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	BaseURL     string `json:"base_url,omitempty"`
+}
+
+type BundleCustomField struct {
+	Entity       string          `json:"entity"`
+	Name         string          `json:"name"`
+	Label        string          `json:"label"`
+	Type         string          `json:"type"`
+	Options      []string        `json:"options,omitempty"`
+	IsRequired   bool            `json:"is_required"`
+	DefaultValue json.RawMessage `json:"default_value,omitempty"`
+	Position     int32           `json:"position"`
+}
+
+type BundleSharedStep struct {
+	ID          int32                            `json:"id"`
+	Name        string                           `json:"name"`
+	Description string                           `json:"description,omitempty"`
+	Parameters  []validation.SharedStepParameter `json:"parameters,omitempty"`
+	Steps       []TestCaseStepRequest            `json:"steps"`
+	CreatedByID int32                            `json:"created_by_id,omitempty"`
+}
+
+type BundleTestCase struct {
+	ID               string     `json:"id"`
+	Kind             string     `json:"kind"`
+	Code             string     `json:"code"`
+	FeatureOrModule  string     `json:"feature_or_module,omitempty"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	IsDraft          bool       `json:"is_draft"`
+	Tags             []string   `json:"tags,omitempty"`
+	CreatedByID      int32      `json:"created_by_id"`
+	CreatedAt        *time.Time `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+	Suggested        bool       `json:"suggested"`
+	Runner           string     `json:"runner,omitempty"`
+	ScriptPath       string     `json:"script_path,omitempty"`
+	ParentTestCaseID string     `json:"parent_test_case_id,omitempty"`
+	// Steps are the ordered steps of the test case
+	Steps []TestCaseStepRequest `json:"steps,omitempty"`
+	// Dataset is the dataset of a data-driven test case
+	Dataset *BundleTestCaseDataset `json:"dataset,omitempty"`
+	// CustomFields holds the values of the custom fields of the test case by name
+	CustomFields map[string]json.RawMessage `json:"custom_fields,omitempty"`
+}
+
+type BundleTestCaseDataset struct {
+	Parameters []string            `json:"parameters"`
+	Rows       []map[string]string `json:"rows"`
+}
+
+type BundleTestPlan struct {
+	ID             int64      `json:"id"`
+	AssignedToID   int32      `json:"assigned_to_id"`
+	CreatedByID    int32      `json:"created_by_id"`
+	UpdatedByID    int32      `json:"updated_by_id"`
+	Kind           string     `json:"kind"`
+	Description    string     `json:"description,omitempty"`
+	EnvironmentID  int32      `json:"environment_id,omitempty"`
+	StartAt        *time.Time `json:"start_at,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	ScheduledEndAt *time.Time `json:"scheduled_end_at,omitempty"`
+	NumTestCases   int32      `json:"num_test_cases"`
+	NumFailures    int32      `json:"num_failures"`
+	IsComplete     bool       `json:"is_complete"`
+	IsLocked       bool       `json:"is_locked"`
+	HasReport      bool       `json:"has_report"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+type BundleTestPlanCase struct {
+	TestPlanID   int64  `json:"test_plan_id"`
+	TestCaseID   string `json:"test_case_id"`
+	AssignedToID int64  `json:"assigned_to_id"`
+}
+
+type BundleTestRun struct {
+	ID                    string          `json:"id"`
+	TestPlanID            int32           `json:"test_plan_id,omitempty"`
+	TestCaseID            string          `json:"test_case_id"`
+	OwnerID               int32           `json:"owner_id"`
+	TestedByID            int32           `json:"tested_by_id,omitempty"`
+	AssignedToID          int32           `json:"assigned_to_id,omitempty"`
+	AssigneeCanChangeCode bool            `json:"assignee_can_change_code"`
+	Code                  string          `json:"code"`
+	ExternalIssueID       string          `json:"external_issue_id,omitempty"`
+	ResultState           string          `json:"result_state"`
+	IsClosed              bool            `json:"is_closed"`
+	Notes                 string          `json:"notes"`
+	ActualResult          string          `json:"actual_result,omitempty"`
+	ExpectedResult        string          `json:"expected_result,omitempty"`
+	Reactions             json.RawMessage `json:"reactions,omitempty"`
+	TestedOn              time.Time       `json:"tested_on"`
+	CreatedAt             *time.Time      `json:"created_at,omitempty"`
+	UpdatedAt             *time.Time      `json:"updated_at,omitempty"`
+	EnvironmentID         int32           `json:"environment_id,omitempty"`
+	// DataRow and DataValues are the dataset row a run of a data-driven test
+	// case tests
+	DataRow    int32             `json:"data_row,omitempty"`
+	DataValues map[string]string `json:"data_values,omitempty"`
+	// CustomFields holds the values of the custom fields of the test run by name
+	CustomFields map[string]json.RawMessage `json:"custom_fields,omitempty"`
+}
+
+type BundleTestRunResult struct {
+	ID         string    `json:"id"`
+	TestRunID  string    `json:"test_run_id"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result"`
+	Notes      string    `json:"notes,omitempty"`
+	ExecutedBy int32     `json:"executed_by,omitempty"`
+	ExecutedAt time.Time `json:"executed_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type BundleTestPlanComment struct {
+	TestPlanID int64      `json:"test_plan_id"`
+	UserID     int64      `json:"user_id"`
+	Content    string     `json:"content"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+type BundlePage struct {
+	ID                 int32      `json:"id"`
+	ParentPageID       int32      `json:"parent_page_id,omitempty"`
+	PageVersion        string     `json:"page_version"`
+	Code               string     `json:"code"`
+	Title              string     `json:"title"`
+	FilePath           string     `json:"file_path,omitempty"`
+	Content            string     `json:"content"`
+	PageType           string     `json:"page_type"`
+	MimeType           string     `json:"mime_type"`
+	HasEmbeddedMedia   bool       `json:"has_embedded_media"`
+	ExternalContentURL string     `json:"external_content_url,omitempty"`
+	NotionURL          string     `json:"notion_url,omitempty"`
+	LastEditedBy       int32      `json:"last_edited_by"`
+	CreatedBy          int32      `json:"created_by"`
+	CreatedAt          *time.Time `json:"created_at,omitempty"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"`
+}
+
+type BundleReport struct {
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Status    string     `json:"status"`
+	FilePath  string     `json:"file_path,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// ProjectImportOptions controls how a project bundle is imported
+type ProjectImportOptions struct {
+	// ImportedByID is the user who owns the imported project and who
+	// entities of users missing on this instance are attributed to
+	ImportedByID int64 `json:"-"`
+	// Name and Code override the title and code of the bundled project
+	Name   string `json:"name,omitempty" form:"name"`
+	Code   string `json:"code,omitempty" form:"code"`
+	DryRun bool   `json:"dry_run" form:"dry_run"`
+}
+
+// ProjectBundleCounts is the number of entities in a project bundle
+type ProjectBundleCounts struct {
+	Modules        int `json:"modules"`
+	Environments   int `json:"environments"`
+	CustomFields   int `json:"custom_fields"`
+	SharedSteps    int `json:"shared_steps"`
+	TestCases      int `json:"test_cases"`
+	TestPlans      int `json:"test_plans"`
+	TestPlanCases  int `json:"test_plan_cases"`
+	TestRuns       int `json:"test_runs"`
+	TestRunResults int `json:"test_run_results"`
+	Comments       int `json:"comments"`
+	Pages          int `json:"pages"`
+	Reports        int `json:"reports"`
+	Files          int `json:"files"`
+}
+
+// ProjectImportConflict is something in a bundle which cannot be imported as
+// is, the import resolves it as described in the message
+type ProjectImportConflict struct {
+	Kind    string `json:"kind"`
+	Ref     string `json:"ref"`
+	Message string `json:"message"`
+}
+
+// ProjectImportReport is the outcome of a project import, for a dry run it
+// reports what would be imported and nothing is written
+type ProjectImportReport struct {
+	DryRun        bool                    `json:"dry_run"`
+	FormatVersion int                     `json:"format_version"`
+	ProjectID     int64                   `json:"project_id,omitempty"`
+	Counts        ProjectBundleCounts     `json:"counts"`
+	Conflicts     []ProjectImportConflict `json:"conflicts"`
+}
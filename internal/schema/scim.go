@@ -0,0 +1,17 @@
+package schema
+
+// SCIMToken authenticates an org's directory on the SCIM endpoint
+type SCIMToken struct {
+	ID         int64  `json:"id"`
+	OrgID      int64  `json:"org_id"`
+	Name       string `json:"name"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	RevokedAt  string `json:"revoked_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CreateSCIMTokenResponse contains the raw token which is only shown once
+type CreateSCIMTokenResponse struct {
+	SCIMToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/validation"
+)
+
+type CreateSharedStepRequest struct {
+	ProjectID   int64                            `json:"-"`
+	CreatedByID int64                            `json:"-"`
+	Name        string                           `json:"name" validate:"required,max=200"`
+	Description string                           `json:"description"`
+	Parameters  []validation.SharedStepParameter `json:"parameters,omitempty"`
+	// Steps use the parameters as {{name}}, they cannot reference other shared steps
+	Steps []TestCaseStepRequest `json:"steps" validate:"required,min=1,max=200,dive"`
+}
+
+// UpdateSharedStepRequest replaces the content of a shared step, the change
+// applies to every test case referencing the shared step
+type UpdateSharedStepRequest struct {
+	ProjectID    int64                            `json:"-"`
+	SharedStepID int64                            `json:"-"`
+	UpdatedByID  int64                            `json:"-"`
+	Name         string                           `json:"name" validate:"required,max=200"`
+	Description  string                           `json:"description"`
+	Parameters   []validation.SharedStepParameter `json:"parameters,omitempty"`
+	Steps        []TestCaseStepRequest            `json:"steps" validate:"required,min=1,max=200,dive"`
+}
+
+type SharedStepResponse struct {
+	ID          int32                            `json:"id"`
+	ProjectID   int32                            `json:"project_id"`
+	Name        string                           `json:"name"`
+	Description string                           `json:"description"`
+	Parameters  []validation.SharedStepParameter `json:"parameters"`
+	Steps       []TestCaseStepRequest            `json:"steps"`
+	CreatedByID int32                            `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time                        `json:"created_at"`
+	UpdatedAt   time.Time                        `json:"updated_at"`
+}
+
+// SharedStepParameters reads the parameters of a shared step
+func SharedStepParameters(e *dbsqlc.SharedStep) []validation.SharedStepParameter {
+	parameters := []validation.SharedStepParameter{}
+	if len(e.Parameters) > 0 {
+		_ = json.Unmarshal(e.Parameters, &parameters)
+	}
+	return parameters
+}
+
+// SharedStepSteps reads the steps of a shared step
+func SharedStepSteps(e *dbsqlc.SharedStep) []TestCaseStepRequest {
+	steps := []TestCaseStepRequest{}
+	if len(e.Steps) > 0 {
+		_ = json.Unmarshal(e.Steps, &steps)
+	}
+	return steps
+}
+
+func NewSharedStepResponse(e *dbsqlc.SharedStep) SharedStepResponse {
+	return SharedStepResponse{
+		ID:          e.ID,
+		ProjectID:   e.ProjectID,
+		Name:        e.Name,
+		Description: e.Description,
+		Parameters:  SharedStepParameters(e),
+		Steps:       SharedStepSteps(e),
+		CreatedByID: e.CreatedByID.Int32,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+type SharedStepListResponse struct {
+	SharedSteps []SharedStepResponse `json:"shared_steps"`
+}
+
+func NewSharedStepListResponse(items []dbsqlc.SharedStep) SharedStepListResponse {
+	res := make([]SharedStepResponse, 0, len(items))
+	for _, item := range items {
+		res = append(res, NewSharedStepResponse(&item))
+	}
+	return SharedStepListResponse{SharedSteps: res}
+}
+
+// SharedStepUsage is a test case referencing a shared step, References is
+// the number of its steps which reference the shared step
+type SharedStepUsage struct {
+	TestCaseID string `json:"test_case_id"`
+	Code       string `json:"code"`
+	Title      string `json:"title"`
+	References int64  `json:"references"`
+}
+
+// SharedStepUsageResponse lists the test cases a change of a shared step applies to
+type SharedStepUsageResponse struct {
+	SharedStepID  int32             `json:"shared_step_id"`
+	Name          string            `json:"name"`
+	TestCaseCount int               `json:"test_case_count"`
+	TestCases     []SharedStepUsage `json:"test_cases"`
+}
+
+func NewSharedStepUsageResponse(e *dbsqlc.SharedStep, rows []dbsqlc.ListSharedStepUsagesRow) SharedStepUsageResponse {
+	res := SharedStepUsageResponse{
+		SharedStepID:  e.ID,
+		Name:          e.Name,
+		TestCaseCount: len(rows),
+		TestCases:     make([]SharedStepUsage, 0, len(rows)),
+	}
+	for _, row := range rows {
+		res.TestCases = append(res.TestCases, SharedStepUsage{
+			TestCaseID: row.TestCaseID.String(),
+			Code:       row.Code,
+			Title:      row.Title,
+			References: row.Usages,
+		})
+	}
+	return res
+}
@@ -0,0 +1,19 @@
+package schema
+
+// Signing key states, a key is published in the JWKS in every state but retired
+const (
+	SigningKeyPending  = "pending"
+	SigningKeyActive   = "active"
+	SigningKeyRetiring = "retiring"
+	SigningKeyRetired  = "retired"
+)
+
+// SigningKey describes a key access tokens are signed with, the private key is never exposed
+type SigningKey struct {
+	Kid         string `json:"kid"`
+	Algorithm   string `json:"algorithm"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+	ActivatesAt string `json:"activates_at"`
+	RetiredAt   string `json:"retired_at,omitempty"`
+}
@@ -0,0 +1,156 @@
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/validation"
+)
+
+// TestCaseStepRequest is one step of a test case, steps are numbered in the
+// order they are listed. A step referencing a shared step has no action of
+// its own and stands for every step of the shared step.
+type TestCaseStepRequest struct {
+	Action         string   `json:"action" validate:"required_without=SharedStepID,excluded_with=SharedStepID"`
+	TestData       string   `json:"test_data,omitempty" validate:"excluded_with=SharedStepID"`
+	ExpectedResult string   `json:"expected_result,omitempty" validate:"excluded_with=SharedStepID"`
+	Attachments    []string `json:"attachments,omitempty" validate:"omitempty,excluded_with=SharedStepID,dive,url"`
+	SharedStepID   int32    `json:"shared_step_id,omitempty"`
+	// Parameters are the values passed to the parameters of the shared step
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+type TestCaseStepResponse struct {
+	ID             int32    `json:"id"`
+	Position       int32    `json:"position"`
+	Action         string   `json:"action"`
+	TestData       string   `json:"test_data,omitempty"`
+	ExpectedResult string   `json:"expected_result,omitempty"`
+	Attachments    []string `json:"attachments,omitempty"`
+	// SharedStepID is set on steps expanded from a shared step, their ID is
+	// the ID of the step referencing the shared step
+	SharedStepID   int32             `json:"shared_step_id,omitempty"`
+	SharedStepName string            `json:"shared_step_name,omitempty"`
+	Parameters     map[string]string `json:"parameters,omitempty"`
+}
+
+// StepParameters reads the values a step passes to its shared step
+func StepParameters(step *dbsqlc.TestCaseStep) map[string]string {
+	if !step.Parameters.Valid {
+		return nil
+	}
+	var values map[string]string
+	_ = json.Unmarshal(step.Parameters.RawMessage, &values)
+	return values
+}
+
+// NewTestCaseStepListResponse lists the steps of a test case with the steps
+// referencing a shared step replaced by the steps of the shared step, the
+// steps are numbered after the expansion
+func NewTestCaseStepListResponse(steps []dbsqlc.TestCaseStep, sharedSteps map[int32]*dbsqlc.SharedStep) []TestCaseStepResponse {
+	res := make([]TestCaseStepResponse, 0, len(steps))
+	for _, step := range steps {
+		if !step.SharedStepID.Valid {
+			res = append(res, TestCaseStepResponse{
+				ID:             step.ID,
+				Position:       int32(len(res) + 1),
+				Action:         step.Action,
+				TestData:       step.TestData,
+				ExpectedResult: step.ExpectedResult,
+				Attachments:    step.Attachments,
+			})
+			continue
+		}
+		shared, ok := sharedSteps[step.SharedStepID.Int32]
+		if !ok {
+			continue
+		}
+		values := StepParameters(&step)
+		parameters := SharedStepParameters(shared)
+		for _, item := range SharedStepSteps(shared) {
+			res = append(res, TestCaseStepResponse{
+				ID:             step.ID,
+				Position:       int32(len(res) + 1),
+				Action:         validation.ExpandSharedStepText(item.Action, parameters, values),
+				TestData:       validation.ExpandSharedStepText(item.TestData, parameters, values),
+				ExpectedResult: validation.ExpandSharedStepText(item.ExpectedResult, parameters, values),
+				Attachments:    item.Attachments,
+				SharedStepID:   shared.ID,
+				SharedStepName: shared.Name,
+				Parameters:     values,
+			})
+		}
+	}
+	return res
+}
+
+// CommitTestStepResult is the outcome of one step of a test run
+type CommitTestStepResult struct {
+	// Position is the number of the step, starting at 1
+	Position     int32               `json:"position" validate:"required,min=1"`
+	Status       dbsqlc.TestRunState `json:"status" validate:"required,oneof=pending passed failed blocked"`
+	ActualResult string              `json:"actual_result,omitempty"`
+	Notes        string              `json:"notes,omitempty"`
+}
+
+type TestRunStepResultResponse struct {
+	Position       int32  `json:"position"`
+	StepID         int32  `json:"step_id,omitempty"`
+	Action         string `json:"action"`
+	TestData       string `json:"test_data,omitempty"`
+	ExpectedResult string `json:"expected_result,omitempty"`
+	Status         string `json:"status"`
+	ActualResult   string `json:"actual_result,omitempty"`
+	Notes          string `json:"notes,omitempty"`
+	ExecutedByID   int32  `json:"executed_by_id,omitempty"`
+	ExecutedAt     string `json:"executed_at,omitempty"`
+}
+
+// TestRunProgressResponse lists the step results of a test run and the step
+// to continue from
+type TestRunProgressResponse struct {
+	TestRunID   string                      `json:"test_run_id"`
+	ResultState string                      `json:"result_state"`
+	IsClosed    bool                        `json:"is_closed"`
+	Steps       []TestRunStepResultResponse `json:"steps"`
+	Executed    int                         `json:"executed"`
+	Total       int                         `json:"total"`
+	// NextStep is the position of the first step without a result, 0 when
+	// every step has a result
+	NextStep int32 `json:"next_step"`
+}
+
+func NewTestRunProgressResponse(testRun *dbsqlc.TestRun, results []dbsqlc.TestRunStepResult) *TestRunProgressResponse {
+	res := &TestRunProgressResponse{
+		TestRunID:   testRun.ID.String(),
+		ResultState: string(testRun.ResultState),
+		IsClosed:    testRun.IsClosed.Bool,
+		Steps:       make([]TestRunStepResultResponse, 0, len(results)),
+		Total:       len(results),
+	}
+	for _, result := range results {
+		step := TestRunStepResultResponse{
+			Position:       result.Position,
+			StepID:         result.StepID.Int32,
+			Action:         result.Action,
+			TestData:       result.TestData,
+			ExpectedResult: result.ExpectedResult,
+			Status:         string(result.Status),
+			ActualResult:   result.ActualResult,
+			Notes:          result.Notes,
+			ExecutedByID:   result.ExecutedByID.Int32,
+		}
+		if result.ExecutedAt.Valid {
+			step.ExecutedAt = formatDateTime(result.ExecutedAt.Time)
+		}
+		res.Steps = append(res.Steps, step)
+		if result.Status == dbsqlc.TestRunStatePending {
+			if res.NextStep == 0 {
+				res.NextStep = result.Position
+			}
+			continue
+		}
+		res.Executed++
+	}
+	return res
+}
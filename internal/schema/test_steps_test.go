@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommitTestRunSteps(t *testing.T) {
+	request := CommitTestRunResult{
+		TestRunID: "0192a1b2-0000-7000-8000-000000000001",
+		TestedOn:  time.Now(),
+		Steps:     []CommitTestStepResult{{Position: 17, Status: dbsqlc.TestRunStateFailed}},
+	}
+	assert.Nil(t, validation.ValidateStruct(request))
+
+	request.Steps[0].Status = "skipped"
+	assert.ErrorContains(t, validation.ValidateStruct(request), "oneof")
+
+	request.Steps = nil
+	assert.ErrorContains(t, validation.ValidateStruct(request), "required_without")
+}
+
+func TestNewTestRunProgressResponse(t *testing.T) {
+	res := NewTestRunProgressResponse(&dbsqlc.TestRun{ResultState: dbsqlc.TestRunStatePending}, []dbsqlc.TestRunStepResult{
+		{Position: 1, Status: dbsqlc.TestRunStatePassed},
+		{Position: 2, Status: dbsqlc.TestRunStatePending},
+		{Position: 3, Status: dbsqlc.TestRunStatePending},
+	})
+	assert.Equal(t, 1, res.Executed)
+	assert.Equal(t, 3, res.Total)
+	assert.Equal(t, int32(2), res.NextStep)
+}
+
+func TestValidateSharedStepReference(t *testing.T) {
+	assert.Nil(t, validation.ValidateStruct(TestCaseStepRequest{SharedStepID: 3, Parameters: map[string]string{"username": "admin"}}))
+	assert.ErrorContains(t, validation.ValidateStruct(TestCaseStepRequest{}), "required_without")
+	assert.ErrorContains(t, validation.ValidateStruct(TestCaseStepRequest{Action: "Sign in", SharedStepID: 3}), "excluded_with")
+}
+
+func TestNewTestCaseStepListResponseExpandsSharedSteps(t *testing.T) {
+	login := &dbsqlc.SharedStep{
+		ID:         3,
+		Name:       "Login",
+		Parameters: []byte(`[{"name":"username"},{"name":"url","default":"https://staging.example.com"}]`),
+		Steps:      []byte(`[{"action":"Open {{url}}"},{"action":"Sign in as {{username}}","expected_result":"{{username}} sees the dashboard"}]`),
+	}
+	steps := []dbsqlc.TestCaseStep{
+		{ID: 10, Position: 1, SharedStepID: sql.NullInt32{Int32: 3, Valid: true}, Parameters: pqtype.NullRawMessage{RawMessage: []byte(`{"username":"admin"}`), Valid: true}},
+		{ID: 11, Position: 2, Action: "Open the cart"},
+	}
+
+	res := NewTestCaseStepListResponse(steps, map[int32]*dbsqlc.SharedStep{3: login})
+	assert.Len(t, res, 3)
+	assert.Equal(t, TestCaseStepResponse{
+		ID: 10, Position: 1, Action: "Open https://staging.example.com",
+		SharedStepID: 3, SharedStepName: "Login", Parameters: map[string]string{"username": "admin"},
+	}, res[0])
+	assert.Equal(t, "Sign in as admin", res[1].Action)
+	assert.Equal(t, "admin sees the dashboard", res[1].ExpectedResult)
+	assert.Equal(t, TestCaseStepResponse{ID: 11, Position: 3, Action: "Open the cart"}, res[2])
+}
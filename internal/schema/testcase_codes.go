@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+)
+
+// TestCaseCodePatternRequest sets the pattern of the generated codes of the
+// test cases of a project, a blank pattern restores the default
+type TestCaseCodePatternRequest struct {
+	ProjectID int64  `json:"-"`
+	Pattern   string `json:"pattern"`
+}
+
+type TestCaseCodePatternResponse struct {
+	Pattern string `json:"pattern"`
+	// Example is the code the pattern generates for the first test case
+	Example string `json:"example"`
+}
+
+// ResequenceTestCaseCodesRequest renames the given codes, or re-sequences
+// the codes of every test case of the project with the code pattern when no
+// renames are given
+type ResequenceTestCaseCodesRequest struct {
+	ProjectID int64             `json:"-"`
+	Renames   map[string]string `json:"renames,omitempty"`
+	DryRun    bool              `json:"dry_run"`
+}
+
+type TestCaseCodeChange struct {
+	TestCaseID string `json:"test_case_id"`
+	OldCode    string `json:"old_code"`
+	NewCode    string `json:"new_code"`
+}
+
+type ResequenceTestCaseCodesResponse struct {
+	DryRun  bool                 `json:"dry_run"`
+	Changes []TestCaseCodeChange `json:"changes"`
+	// TestRuns is the number of test runs whose code or notes were rewritten
+	TestRuns int64 `json:"test_runs"`
+	// TestPlans is the number of test plans whose description was rewritten
+	TestPlans int64 `json:"test_plans"`
+}
+
+type TestCaseCodeAliasResponse struct {
+	Code       string `json:"code"`
+	TestCaseID string `json:"test_case_id"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func NewTestCaseCodeAliasListResponse(items []dbsqlc.TestCaseCodeAlias) []TestCaseCodeAliasResponse {
+	res := make([]TestCaseCodeAliasResponse, 0, len(items))
+	for _, item := range items {
+		res = append(res, TestCaseCodeAliasResponse{
+			Code:       item.Code,
+			TestCaseID: item.TestCaseID.String(),
+			CreatedAt:  formatDateTime(item.CreatedAt),
+		})
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// SetTestCaseDatasetRequest replaces the dataset of a test case, the test
+// case is assigned to plans as one test run per row
+type SetTestCaseDatasetRequest struct {
+	TestCaseID  string `json:"-"`
+	UpdatedByID int64  `json:"-"`
+	// Parameters are used as {{name}} in the steps of the test case
+	Parameters []string `json:"parameters" validate:"required,min=1,max=50"`
+	// Rows set the values of the parameters by name
+	Rows []map[string]string `json:"rows" validate:"required,min=1"`
+}
+
+type TestCaseDatasetResponse struct {
+	TestCaseID  string              `json:"test_case_id"`
+	Parameters  []string            `json:"parameters"`
+	Rows        []map[string]string `json:"rows"`
+	RowCount    int                 `json:"row_count"`
+	UpdatedByID int32               `json:"updated_by_id,omitempty"`
+	CreatedAt   string              `json:"created_at"`
+	UpdatedAt   string              `json:"updated_at"`
+}
+
+// DatasetRows reads the rows of the dataset of a test case
+func DatasetRows(dataset *dbsqlc.TestCaseDataset) []map[string]string {
+	rows := []map[string]string{}
+	if len(dataset.Rows) > 0 {
+		_ = json.Unmarshal(dataset.Rows, &rows)
+	}
+	return rows
+}
+
+// DataValues reads the values of the dataset row a test run tests, nil for
+// test runs of test cases without a dataset
+func DataValues(raw pqtype.NullRawMessage) map[string]string {
+	if !raw.Valid || len(raw.RawMessage) == 0 {
+		return nil
+	}
+	values := map[string]string{}
+	_ = json.Unmarshal(raw.RawMessage, &values)
+	return values
+}
+
+func NewTestCaseDatasetResponse(dataset *dbsqlc.TestCaseDataset) TestCaseDatasetResponse {
+	rows := DatasetRows(dataset)
+	parameters := dataset.Parameters
+	if parameters == nil {
+		parameters = []string{}
+	}
+	return TestCaseDatasetResponse{
+		TestCaseID:  dataset.TestCaseID.String(),
+		Parameters:  parameters,
+		Rows:        rows,
+		RowCount:    len(rows),
+		UpdatedByID: dataset.UpdatedByID.Int32,
+		CreatedAt:   formatDateTime(dataset.CreatedAt),
+		UpdatedAt:   formatDateTime(dataset.UpdatedAt),
+	}
+}
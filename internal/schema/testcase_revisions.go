@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/validation"
+)
+
+type TestCaseRevisionResponse struct {
+	TestCaseID  string                 `json:"test_case_id"`
+	Revision    int32                  `json:"revision"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Kind        string                 `json:"kind"`
+	Tags        []string               `json:"tags"`
+	Runner      string                 `json:"runner,omitempty"`
+	ScriptPath  string                 `json:"script_path,omitempty"`
+	Steps       []TestCaseRevisionStep `json:"steps"`
+	AuthorID    int32                  `json:"author_id,omitempty"`
+	CreatedAt   string                 `json:"created_at"`
+}
+
+// TestCaseRevisionStep is a step saved with a revision. A step referencing a
+// shared step keeps a copy of the steps of the shared step at the revision,
+// with the values of its parameters filled in, so later changes to the
+// shared step leave the revision as it was.
+type TestCaseRevisionStep struct {
+	TestCaseStepRequest
+	SharedStepName string                `json:"shared_step_name,omitempty"`
+	SharedSteps    []TestCaseStepRequest `json:"shared_steps,omitempty"`
+}
+
+// RevisionSteps reads the steps saved with a revision
+func RevisionSteps(revision *dbsqlc.TestCaseRevision) []TestCaseRevisionStep {
+	steps := []TestCaseRevisionStep{}
+	if len(revision.Steps) > 0 {
+		_ = json.Unmarshal(revision.Steps, &steps)
+	}
+	return steps
+}
+
+// NewTestCaseRevisionSteps copies the steps of a test case for a revision,
+// the shared steps referenced by the steps are looked up in sharedSteps
+func NewTestCaseRevisionSteps(steps []dbsqlc.TestCaseStep, sharedSteps map[int32]*dbsqlc.SharedStep) []TestCaseRevisionStep {
+	res := make([]TestCaseRevisionStep, 0, len(steps))
+	for _, step := range steps {
+		item := TestCaseRevisionStep{
+			TestCaseStepRequest: TestCaseStepRequest{
+				Action:         step.Action,
+				TestData:       step.TestData,
+				ExpectedResult: step.ExpectedResult,
+				Attachments:    step.Attachments,
+				SharedStepID:   step.SharedStepID.Int32,
+				Parameters:     StepParameters(&step),
+			},
+		}
+		if shared := sharedSteps[step.SharedStepID.Int32]; step.SharedStepID.Valid && shared != nil {
+			parameters := SharedStepParameters(shared)
+			item.SharedStepName = shared.Name
+			item.SharedSteps = []TestCaseStepRequest{}
+			for _, sharedItem := range SharedStepSteps(shared) {
+				item.SharedSteps = append(item.SharedSteps, TestCaseStepRequest{
+					Action:         validation.ExpandSharedStepText(sharedItem.Action, parameters, item.Parameters),
+					TestData:       validation.ExpandSharedStepText(sharedItem.TestData, parameters, item.Parameters),
+					ExpectedResult: validation.ExpandSharedStepText(sharedItem.ExpectedResult, parameters, item.Parameters),
+					Attachments:    sharedItem.Attachments,
+				})
+			}
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func NewTestCaseRevisionResponse(revision *dbsqlc.TestCaseRevision) TestCaseRevisionResponse {
+	return TestCaseRevisionResponse{
+		TestCaseID:  revision.TestCaseID.String(),
+		Revision:    revision.Revision,
+		Title:       revision.Title,
+		Description: revision.Description,
+		Kind:        string(revision.Kind),
+		Tags:        revision.Tags,
+		Runner:      revision.Runner.String,
+		ScriptPath:  revision.ScriptPath.String,
+		Steps:       RevisionSteps(revision),
+		AuthorID:    revision.AuthorID.Int32,
+		CreatedAt:   formatDateTime(revision.CreatedAt),
+	}
+}
+
+func NewTestCaseRevisionListResponse(revisions []dbsqlc.TestCaseRevision) []TestCaseRevisionResponse {
+	res := make([]TestCaseRevisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		res = append(res, NewTestCaseRevisionResponse(&revision))
+	}
+	return res
+}
+
+// TestCaseRevisionChange is a field which differs between two revisions.
+// Lines is a line diff of multi-line fields, lines start with "+ " when
+// added, "- " when removed and two spaces when unchanged.
+type TestCaseRevisionChange struct {
+	Field   string   `json:"field"`
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Lines   []string `json:"lines,omitempty"`
+}
+
+type TestCaseRevisionDiffResponse struct {
+	TestCaseID string                   `json:"test_case_id"`
+	From       int32                    `json:"from"`
+	To         int32                    `json:"to"`
+	Changes    []TestCaseRevisionChange `json:"changes"`
+}
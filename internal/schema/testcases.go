@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/google/uuid"
 )
 
 type CreateTestCaseRequest struct {
@@ -19,6 +20,10 @@ type CreateTestCaseRequest struct {
 	Runner           string   `json:"runner"`
 	ScriptPath       string   `json:"script_path,omitempty"`
 	ParentTestCaseID string   `json:"parent_test_case_id,omitempty"`
+	// CustomFields holds the values of the custom fields of the project by name
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// Steps are the ordered steps of the test case
+	Steps []TestCaseStepRequest `json:"steps,omitempty" validate:"omitempty,max=200,dive"`
 }
 
 type UpdateTestCaseRequest struct {
@@ -32,8 +37,14 @@ type UpdateTestCaseRequest struct {
 	IsDraft         bool     `json:"is_draft" validate:"-"`
 	Tags            []string `json:"tags,omitempty"`
 	CreatedByID     string   `json:"-" validate:"-"`
+	UpdatedByID     int64    `json:"-" validate:"-"`
 	Runner          string   `json:"runner"`
 	ScriptPath      string   `json:"script_path"`
+	// CustomFields changes the values of the named custom fields, null clears a value
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// Steps replaces the steps of the test case when set, an empty list
+	// removes every step
+	Steps []TestCaseStepRequest `json:"steps,omitempty" validate:"omitempty,max=200,dive"`
 }
 
 type BulkCreateTestCases struct {
@@ -54,28 +65,35 @@ type ImportFromGithubRequest struct {
 }
 
 type TestCaseResponse struct {
-	ID               string   `json:"id"`
-	ProjectID        int64    `json:"project_id"`
-	CreatedByID      int64    `json:"created_by"`
-	Kind             string   `json:"kind"`
-	Code             string   `json:"code"`
-	FeatureOrModule  string   `json:"feature_or_module"`
-	Title            string   `json:"title"`
-	Description      string   `json:"description"`
-	IsDraft          bool     `json:"is_draft"`
-	Tags             []string `json:"tags"`
-	CreatedAt        string   `json:"created_at"`
-	UpdatedAt        string   `json:"updated_at"`
-	Status           string   `json:"status,omitempty"`
-	Result           string   `json:"result,omitempty"`
-	ExecutedBy       int64    `json:"executed_by,omitempty"`
-	Notes            string   `json:"notes,omitempty"`
-	Suggested        bool     `json:"suggested"`
-	Runner           string   `json:"runner"`
-	ScriptPath       string   `json:"script_path,omitempty"`
-	ParentTestCaseID string   `json:"parent_test_case_id,omitempty"`
-	ParentCode       string   `json:"parent_code,omitempty"`
-	ParentTitle      string   `json:"parent_title,omitempty"`
+	ID               string         `json:"id"`
+	ProjectID        int64          `json:"project_id"`
+	CreatedByID      int64          `json:"created_by"`
+	Kind             string         `json:"kind"`
+	Code             string         `json:"code"`
+	FeatureOrModule  string         `json:"feature_or_module"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	IsDraft          bool           `json:"is_draft"`
+	Tags             []string       `json:"tags"`
+	CreatedAt        string         `json:"created_at"`
+	UpdatedAt        string         `json:"updated_at"`
+	Status           string         `json:"status,omitempty"`
+	Result           string         `json:"result,omitempty"`
+	ExecutedBy       int64          `json:"executed_by,omitempty"`
+	Notes            string         `json:"notes,omitempty"`
+	Suggested        bool           `json:"suggested"`
+	Runner           string         `json:"runner"`
+	ScriptPath       string         `json:"script_path,omitempty"`
+	ParentTestCaseID string         `json:"parent_test_case_id,omitempty"`
+	ParentCode       string         `json:"parent_code,omitempty"`
+	ParentTitle      string         `json:"parent_title,omitempty"`
+	CustomFields     map[string]any `json:"custom_fields,omitempty"`
+	// Steps are the ordered steps of the test case with the shared steps it
+	// references expanded
+	Steps []TestCaseStepResponse `json:"steps,omitempty"`
+	// StepDefinitions are the steps as saved, one per reference to a shared
+	// step, they are what to send back when updating the steps
+	StepDefinitions []TestCaseStepRequest `json:"step_definitions,omitempty"`
 }
 
 // For detail view (with parent join)
@@ -198,3 +216,14 @@ type SugestedTestCaseResponse struct {
 	TestCaseResponse
 	Suggested bool `json:"suggested"`
 }
+
+// SetTestCaseCustomFields fills in the custom field values of test cases by their ID
+func SetTestCaseCustomFields(items []TestCaseResponse, values map[uuid.UUID]map[string]any) {
+	for i := range items {
+		id, err := uuid.Parse(items[i].ID)
+		if err != nil {
+			continue
+		}
+		items[i].CustomFields = values[id]
+	}
+}
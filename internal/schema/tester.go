@@ -41,5 +41,5 @@ type TesterListResponse struct {
 }
 
 type UpdateTesterRoleRequest struct {
-	Role string `json:"role"`
+	Role string `json:"role" validate:"required,oneof=lead engineer client bot ai_agent"`
 }
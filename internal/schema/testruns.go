@@ -40,11 +40,17 @@ type CommitTestRunResult struct {
 	Notes          string    `json:"notes,omitempty"`
 	IsClosed       bool      `json:"is_closed"`
 	TestedOn       time.Time `json:"tested_on" validate:"required"`
-	ActualResult   string    `json:"actual_result" validate:"required"`
+	ActualResult   string    `json:"actual_result" validate:"required_without=Steps"`
 	ExpectedResult string    `json:"expected_result"`
-	// State is the result of the test run
-	State         dbsqlc.TestRunState `json:"result_state" validate:"required"`
+	// State is the result of the test run, it is derived from the outcomes
+	// of the steps when Steps is set
+	State         dbsqlc.TestRunState `json:"result_state" validate:"required_without=Steps"`
 	EnvironmentID int32               `json:"environment_id,omitempty"`
+	// CustomFields changes the values of the named custom fields, null clears a value
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+	// Steps records the outcomes of steps of the test case, steps which are
+	// not listed keep their earlier outcome
+	Steps []CommitTestStepResult `json:"steps,omitempty" validate:"omitempty,dive"`
 }
 
 // NewFoundIssuesRequest contains list of issues which basically translates to "failed test cases/runs"
@@ -64,7 +70,6 @@ func ParseIssuesFromMarkdownList(userID int64, testDate time.Time, content strin
 
 	testRuns := make([]CommitTestRunResult, 0)
 	for _, entry := range items {
-		
 
 		entryNormalized := strings.Replace(entry, "-", "", 1)
 		entryNormalized = strings.Replace(entryNormalized, "*", "", 1)
@@ -104,6 +109,8 @@ type ExecuteTestRunRequest struct {
 	Notes          string `json:"notes,omitempty"`
 	ExpectedResult string `json:"expected_result"`
 	EnvironmentID  int32  `json:"environment_id"`
+	// CustomFields changes the values of the named custom fields, null clears a value
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
 }
 
 type TestRunResponse struct {
@@ -122,43 +129,56 @@ type TestRunResponse struct {
 	TestCaseTitle  string `json:"test_case_title"`
 	ExecutedBy     string `json:"executed_by"`
 	EnvironmentID  int32  `json:"environment_id"`
+	// TestCaseRevision is the revision of the test case the results were
+	// recorded against
+	TestCaseRevision int32 `json:"test_case_revision,omitempty"`
+	// DataRow is the row of the dataset of a data-driven test case the run
+	// tests, from 1, with its values in DataValues
+	DataRow    int32             `json:"data_row,omitempty"`
+	DataValues map[string]string `json:"data_values,omitempty"`
 }
 
 func NewTestRunResponseFromRow(tr dbsqlc.ListTestRunsByPlanRow) TestRunResponse {
 	return TestRunResponse{
-		ID:             tr.ID.String(),
-		ProjectID:      int64(tr.ProjectID),
-		TestPlanID:     int64(tr.TestPlanID.Int32),
-		TestCaseID:     tr.TestCaseID.String(),
-		TestedByID:     tr.TestedByID.Int32,
-		Code:           tr.Code,
-		ResultState:    string(tr.ResultState),
-		IsClosed:       tr.IsClosed.Valid && tr.IsClosed.Bool,
-		Notes:          tr.Notes,
-		ActualResult:   tr.ActualResult.String,
-		ExpectedResult: tr.ExpectedResult.String,
-		TestedOn:       tr.TestedOn.Format(time.DateTime),
-		TestCaseTitle:  tr.TestCaseTitle,
-		ExecutedBy:     tr.ExecutedBy.String,
-		EnvironmentID:  tr.EnvironmentID.Int32,
+		ID:               tr.ID.String(),
+		ProjectID:        int64(tr.ProjectID),
+		TestPlanID:       int64(tr.TestPlanID.Int32),
+		TestCaseID:       tr.TestCaseID.String(),
+		TestedByID:       tr.TestedByID.Int32,
+		Code:             tr.Code,
+		ResultState:      string(tr.ResultState),
+		IsClosed:         tr.IsClosed.Valid && tr.IsClosed.Bool,
+		Notes:            tr.Notes,
+		ActualResult:     tr.ActualResult.String,
+		ExpectedResult:   tr.ExpectedResult.String,
+		TestedOn:         tr.TestedOn.Format(time.DateTime),
+		TestCaseTitle:    tr.TestCaseTitle,
+		ExecutedBy:       tr.ExecutedBy.String,
+		EnvironmentID:    tr.EnvironmentID.Int32,
+		TestCaseRevision: tr.TestCaseRevision.Int32,
+		DataRow:          tr.DataRow.Int32,
+		DataValues:       DataValues(tr.DataValues),
 	}
 }
 
 func NewTestRunResponseFromEntity(tr *dbsqlc.TestRun) TestRunResponse {
 	return TestRunResponse{
-		ID:             tr.ID.String(),
-		ProjectID:      int64(tr.ProjectID),
-		TestPlanID:     int64(tr.TestPlanID.Int32),
-		TestCaseID:     tr.TestCaseID.String(),
-		Code:           tr.Code,
-		ResultState:    string(tr.ResultState),
-		IsClosed:       tr.IsClosed.Valid && tr.IsClosed.Bool,
-		Notes:          tr.Notes,
-		ActualResult:   tr.ActualResult.String,
-		ExpectedResult: tr.ExpectedResult.String,
-		TestedOn:       tr.TestedOn.Format(time.DateTime),
-		TestCaseTitle:  "",
-		ExecutedBy:     "",
-		EnvironmentID:  tr.EnvironmentID.Int32,
+		ID:               tr.ID.String(),
+		ProjectID:        int64(tr.ProjectID),
+		TestPlanID:       int64(tr.TestPlanID.Int32),
+		TestCaseID:       tr.TestCaseID.String(),
+		Code:             tr.Code,
+		ResultState:      string(tr.ResultState),
+		IsClosed:         tr.IsClosed.Valid && tr.IsClosed.Bool,
+		Notes:            tr.Notes,
+		ActualResult:     tr.ActualResult.String,
+		ExpectedResult:   tr.ExpectedResult.String,
+		TestedOn:         tr.TestedOn.Format(time.DateTime),
+		TestCaseTitle:    "",
+		ExecutedBy:       "",
+		EnvironmentID:    tr.EnvironmentID.Int32,
+		TestCaseRevision: tr.TestCaseRevision.Int32,
+		DataRow:          tr.DataRow.Int32,
+		DataValues:       DataValues(tr.DataValues),
 	}
 }
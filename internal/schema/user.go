@@ -19,6 +19,8 @@ type SignUpRequest struct {
 	DisplayName string `json:"display_name" validate:"required"`
 	Email       string `json:"email" validate:"required"`
 	Password    string `json:"password" validate:"required"`
+	UserAgent   string `json:"-" validate:"-"`
+	IPAddress   string `json:"-" validate:"-"`
 }
 
 type NewUserRequest struct {
@@ -32,17 +34,67 @@ type NewUserRequest struct {
 
 // LoginRequest request to authenticate a user on the platform
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Email     string `json:"email" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+	UserAgent string `json:"-" validate:"-"`
+	IPAddress string `json:"-" validate:"-"`
 }
 
 // LoginResponse response from a login request
 type LoginResponse struct {
-	UserID      int64  `json:"user_id"`
-	DisplayName string `json:"displayName"`
-	Email       string `json:"email"`
-	Token       string `json:"token"`
-	ExpiresAt   int64  `json:"expires_at"`
+	UserID       int64  `json:"user_id"`
+	DisplayName  string `json:"displayName"`
+	Email        string `json:"email"`
+	Token        string `json:"token"`
+	ExpiresAt    int64  `json:"expires_at"`
+	RefreshToken string `json:"refresh_token"`
+	// OrgID is the org the session currently works in, it is carried in the
+	// access token and can be changed with a switch-org request
+	OrgID int64 `json:"org_id,omitempty"`
+	// TwoFactorRequired is set when the password was correct but a TOTP or
+	// recovery code has to be sent with the challenge token to finish the login
+	TwoFactorRequired bool `json:"two_factor_required,omitempty"`
+	// TwoFactorSetupRequired is set when an org of the user requires two-factor
+	// authentication and the user has to enrol before the login can finish
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required,omitempty"`
+	ChallengeToken         string   `json:"challenge_token,omitempty"`
+	RecoveryCodes          []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorLoginRequest request to finish a login with a second factor
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+	UserAgent      string `json:"-" validate:"-"`
+	IPAddress      string `json:"-" validate:"-"`
+}
+
+// TwoFactorChallengeRequest request to start two-factor enrolment during login
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// TwoFactorCodeRequest request carrying a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorEnrolment secret to add to an authenticator app, the provisioning URI can be shown as a QR code
+type TwoFactorEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	PendingConfirmation    bool  `json:"pending_confirmation"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// RecoveryCodesResponse single-use codes to log in when the authenticator is lost, only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 // ChangePasswordRequest request to change a password
@@ -53,6 +105,40 @@ type ChangePasswordRequest struct {
 	ConfirmPassword string `json:"confirm_password" validate:"required"`
 }
 
+// PasswordResetRequest request to send a password reset link to an email
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ConfirmPasswordResetRequest request to set a new password using a reset token
+type ConfirmPasswordResetRequest struct {
+	Token           string `json:"token" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required"`
+}
+
+// VerifyEmailRequest request to confirm an email address using the token from a verification link
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationEmailRequest request to send a new verification link
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// OIDCCallbackRequest the authorization response the identity provider redirected the browser back with
+type OIDCCallbackRequest struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+	// LoginState is the signed state stored in a cookie when the login started
+	LoginState string `json:"-" validate:"-"`
+	UserAgent  string `json:"-" validate:"-"`
+	IPAddress  string `json:"-" validate:"-"`
+}
+
 type UpdateUserRequest struct {
 	ID          int32  `json:"id" validate:"-"`
 	FirstName   string `json:"first_name" validate:"required"`
@@ -68,10 +154,47 @@ type UpdateUserRequest struct {
 type InviteUserRequest struct {
 }
 
+// RefreshTokenRequest request to exchange a refresh token for new tokens
 type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	UserAgent    string `json:"-" validate:"-"`
+	IPAddress    string `json:"-" validate:"-"`
 }
 
+// RefreshTokenResponse new access token and the refresh token that replaces the one used
 type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	ExpiresAt    int64  `json:"expires_at"`
+	RefreshToken string `json:"refresh_token"`
+	OrgID        int64  `json:"org_id,omitempty"`
+}
+
+// SwitchOrgRequest request to move the session to another org, the refresh
+// token of the session is exchanged for tokens carrying the new org
+type SwitchOrgRequest struct {
+	OrgID        int64  `json:"org_id" validate:"required"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
+	UserAgent    string `json:"-" validate:"-"`
+	IPAddress    string `json:"-" validate:"-"`
+}
+
+// LogoutRequest request to end the session the refresh token belongs to
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// UserSession an active login session of a user
+type UserSession struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	StartedAt  string `json:"started_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+type UserSessionListResponse struct {
+	Sessions []UserSession `json:"sessions"`
 }
 
 type User struct {
@@ -92,3 +215,60 @@ type User struct {
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
 }
+
+// OffboardUserRequest request to hand the open work of a user over to another tester
+type OffboardUserRequest struct {
+	ReassignToID int64 `json:"reassign_to_id" validate:"required"`
+	// Deactivate also deactivates the user once their work has been handed over
+	Deactivate bool `json:"deactivate" validate:"-"`
+}
+
+// OffboardingTestPlan an open test plan assigned to the user
+type OffboardingTestPlan struct {
+	ID          int64  `json:"id"`
+	ProjectID   int64  `json:"project_id"`
+	Description string `json:"description"`
+}
+
+// OffboardingPlanCase an open test case of a test plan assigned to the user
+type OffboardingPlanCase struct {
+	TestPlanID int64  `json:"test_plan_id"`
+	TestCaseID string `json:"test_case_id"`
+	ProjectID  int64  `json:"project_id"`
+	Title      string `json:"title"`
+}
+
+// OffboardingTestRun an open test run assigned to the user
+type OffboardingTestRun struct {
+	ID         string `json:"id"`
+	ProjectID  int64  `json:"project_id"`
+	TestPlanID int64  `json:"test_plan_id"`
+	Code       string `json:"code"`
+}
+
+// OffboardingProjectAccess a project the new assignee joins as a tester so they can work on what they receive
+type OffboardingProjectAccess struct {
+	ProjectID int64  `json:"project_id"`
+	Role      string `json:"role"`
+}
+
+// OffboardingPreview lists the work that moves when a user is offboarded
+type OffboardingPreview struct {
+	UserID          int64                      `json:"user_id"`
+	ReassignToID    int64                      `json:"reassign_to_id"`
+	TestPlans       []OffboardingTestPlan      `json:"test_plans"`
+	PlanCases       []OffboardingPlanCase      `json:"plan_cases"`
+	TestRuns        []OffboardingTestRun       `json:"test_runs"`
+	GrantedProjects []OffboardingProjectAccess `json:"granted_projects"`
+}
+
+// OffboardingResult counts the work that was handed over
+type OffboardingResult struct {
+	UserID          int64                      `json:"user_id"`
+	ReassignToID    int64                      `json:"reassign_to_id"`
+	TestPlans       int64                      `json:"test_plans"`
+	PlanCases       int64                      `json:"plan_cases"`
+	TestRuns        int64                      `json:"test_runs"`
+	GrantedProjects []OffboardingProjectAccess `json:"granted_projects"`
+	Deactivated     bool                       `json:"deactivated"`
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+)
+
+var ErrLastSuperAdmin = errors.New("cannot remove the last active super admin")
+var ErrUserNotActive = errors.New("user account is not active")
+var ErrAdminEmailTaken = errors.New("an account with the admin email already exists, promote it with `qatarina admin promote` instead")
+
+// Audit log actions
+const (
+	AuditAdminBootstrap     = "admin.bootstrap"
+	AuditAdminPromote       = "admin.promote"
+	AuditAdminDemote        = "admin.demote"
+	AuditAdminPasswordReset = "admin.password_reset"
+	AuditAdminDeactivate    = "admin.deactivate"
+	AuditAdminReactivate    = "admin.reactivate"
+	AuditProjectOwnerChange = "project.owner_change"
+)
+
+// AdminService holds the operator tasks of the admin command, they work
+// directly against the database and are recorded as system actions
+type AdminService interface {
+	// BootstrapAdmin creates the configured admin when there is no active super
+	// admin yet, it reports whether anything was changed. An existing account with
+	// the admin email is never promoted since anyone could have registered it
+	BootstrapAdmin(ctx context.Context, adminConfig *config.AdminConfiguration) (bool, error)
+	// FindUser finds a user by ID or email
+	FindUser(ctx context.Context, userRef string) (*dbsqlc.User, error)
+	// ListUsers lists all users, including inactive ones
+	ListUsers(ctx context.Context) ([]dbsqlc.User, error)
+	// ListProjects lists all projects
+	ListProjects(ctx context.Context) ([]dbsqlc.Project, error)
+	// SetSuperAdmin promotes or demotes a user, the last active super admin cannot be demoted
+	SetSuperAdmin(ctx context.Context, userRef string, superAdmin bool) error
+	// ResetPassword sets a new password and ends all sessions of the user, a
+	// random password is generated and returned when none is given
+	ResetPassword(ctx context.Context, userRef, password string) (string, error)
+	// DeactivateUser blocks the user from logging in and ends all their sessions
+	DeactivateUser(ctx context.Context, userRef string) error
+	// ReactivateUser lets a deactivated user log in again
+	ReactivateUser(ctx context.Context, userRef string) error
+	// ReassignProjectOwner makes another active user the owner of the project
+	ReassignProjectOwner(ctx context.Context, projectID int64, userRef string) error
+}
+
+type adminServiceImpl struct {
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewAdminService(queries *dbsqlc.Queries, logger logging.Logger) AdminService {
+	return &adminServiceImpl{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *adminServiceImpl) BootstrapAdmin(ctx context.Context, adminConfig *config.AdminConfiguration) (bool, error) {
+	if !adminConfig.Enabled || adminConfig.Email == "" || adminConfig.Password == "" {
+		return false, nil
+	}
+
+	count, err := s.queries.CountSuperAdmins(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to count super admins: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	user, err := s.queries.GetUserByEmail(ctx, adminConfig.Email)
+	switch {
+	case err == nil:
+		return false, fmt.Errorf("admin account %s: %w", adminConfig.Email, ErrAdminEmailTaken)
+	case errors.Is(err, sql.ErrNoRows):
+		now := time.Now()
+		displayName := cmp.Or(adminConfig.Username, "Administrator")
+		user.ID, err = s.queries.CreateUser(ctx, dbsqlc.CreateUserParams{
+			FirstName:        displayName,
+			LastName:         "",
+			DisplayName:      common.NullString(displayName),
+			Email:            adminConfig.Email,
+			Password:         common.MustHashPassword(adminConfig.Password),
+			IsActivated:      common.TrueNullBool(),
+			IsReviewed:       common.TrueNullBool(),
+			IsSuperAdmin:     common.TrueNullBool(),
+			IsVerified:       common.TrueNullBool(),
+			EmailConfirmedAt: common.NewNullTime(now),
+			CreatedAt:        common.NewNullTime(now),
+			UpdatedAt:        common.NewNullTime(now),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to create admin: %w", err)
+		}
+	default:
+		return false, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	s.logger.Info("admin-service", "bootstrapped super admin", "user_id", user.ID, "email", adminConfig.Email)
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditAdminBootstrap,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return true, nil
+}
+
+func (s *adminServiceImpl) FindUser(ctx context.Context, userRef string) (*dbsqlc.User, error) {
+	var user dbsqlc.User
+	var err error
+	if id, parseErr := strconv.ParseInt(userRef, 10, 32); parseErr == nil {
+		user, err = s.queries.GetUser(ctx, int32(id))
+	} else {
+		user, err = s.queries.GetUserByEmail(ctx, strings.TrimSpace(userRef))
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", userRef, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *adminServiceImpl) ListUsers(ctx context.Context) ([]dbsqlc.User, error) {
+	users, err := s.queries.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *adminServiceImpl) ListProjects(ctx context.Context) ([]dbsqlc.Project, error) {
+	projects, err := s.queries.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	return projects, nil
+}
+
+func (s *adminServiceImpl) SetSuperAdmin(ctx context.Context, userRef string, superAdmin bool) error {
+	user, err := s.FindUser(ctx, userRef)
+	if err != nil {
+		return err
+	}
+	if user.IsSuperAdmin.Bool == superAdmin {
+		return nil
+	}
+
+	action := AuditAdminPromote
+	if !superAdmin {
+		if err := ensureNotLastSuperAdmin(ctx, s.queries, user); err != nil {
+			return err
+		}
+		action = AuditAdminDemote
+	}
+
+	_, err = s.queries.SetUserSuperAdmin(ctx, dbsqlc.SetUserSuperAdminParams{
+		ID:           user.ID,
+		IsSuperAdmin: sql.NullBool{Bool: superAdmin, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      action,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return nil
+}
+
+func (s *adminServiceImpl) ResetPassword(ctx context.Context, userRef, password string) (string, error) {
+	user, err := s.FindUser(ctx, userRef)
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		password, _, err = generateSecureToken()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+	}
+
+	err = s.queries.ChangeUserPassword(ctx, dbsqlc.ChangeUserPasswordParams{
+		ID:        user.ID,
+		Password:  common.MustHashPassword(password),
+		UpdatedAt: common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to change password: %w", err)
+	}
+	if err := endSessions(ctx, s.queries, user.ID); err != nil {
+		return "", err
+	}
+	if _, err := s.queries.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return "", fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditAdminPasswordReset,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return password, nil
+}
+
+func (s *adminServiceImpl) DeactivateUser(ctx context.Context, userRef string) error {
+	user, err := s.FindUser(ctx, userRef)
+	if err != nil {
+		return err
+	}
+	if err := deactivateUser(ctx, s.queries, user); err != nil {
+		return err
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditAdminDeactivate,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return nil
+}
+
+func (s *adminServiceImpl) ReactivateUser(ctx context.Context, userRef string) error {
+	user, err := s.FindUser(ctx, userRef)
+	if err != nil {
+		return err
+	}
+
+	affected, err := s.queries.ReactivateUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("user %s is not deactivated", userRef)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditAdminReactivate,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return nil
+}
+
+func (s *adminServiceImpl) ReassignProjectOwner(ctx context.Context, projectID int64, userRef string) error {
+	user, err := s.FindUser(ctx, userRef)
+	if err != nil {
+		return err
+	}
+	if !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return ErrUserNotActive
+	}
+
+	project, err := s.queries.GetProject(ctx, int32(projectID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("project %d: %w", projectID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	affected, err := s.queries.UpdateProjectOwner(ctx, dbsqlc.UpdateProjectOwnerParams{
+		ID:          project.ID,
+		OwnerUserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update project owner: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("project %d: %w", projectID, ErrNotFound)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditProjectOwnerChange,
+		SubjectType: "project",
+		SubjectID:   fmt.Sprint(project.ID),
+		Details:     fmt.Sprintf("owner changed from user %d to user %d", project.OwnerUserID, user.ID),
+	})
+	return nil
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/google/uuid"
+)
+
+// APITokenPrefix is prepended to every API token so they can be told apart from JWTs
+const APITokenPrefix = "qat_"
+
+var ErrInvalidAPIToken = errors.New("api token is invalid, expired or revoked")
+var ErrInvalidAPITokenScope = errors.New("invalid api token scope")
+var ErrServiceAccountNeedsProject = errors.New("tokens for service accounts must be limited to a project")
+
+// apiTokenResources lists the resources a scope can refer to, scopes take the
+// form "<resource>:read" or "<resource>:write", write also allows reading
+var apiTokenResources = []string{
+	"projects",
+	"modules",
+	"pages",
+	"test-cases",
+	"test-plans",
+	"test-runs",
+	"testers",
+	"environments",
+	"dashboard",
+}
+
+type APITokenService interface {
+	// Create creates a token for the user, or for a new bot account when the request names a service account.
+	// Bot accounts are added as testers of the project of the token, which needs a user who can manage its testers
+	Create(ctx context.Context, userID int64, request *schema.CreateAPITokenRequest) (*schema.CreateAPITokenResponse, error)
+	// List lists the active tokens created by the user
+	List(ctx context.Context, userID int64) ([]schema.APIToken, error)
+	// Revoke revokes a token created by the user
+	Revoke(ctx context.Context, userID int64, tokenID int64) error
+	// Authenticate resolves a raw token to the account it acts as and records its use
+	Authenticate(ctx context.Context, token string) (*dbsqlc.GetApiTokenByHashRow, error)
+}
+
+type apiTokenServiceImpl struct {
+	db                *sql.DB
+	queries           *dbsqlc.Queries
+	permissionService PermissionService
+	logger            logging.Logger
+}
+
+func NewAPITokenService(db *sql.DB, queries *dbsqlc.Queries, permissionService PermissionService, logger logging.Logger) APITokenService {
+	return &apiTokenServiceImpl{
+		db:                db,
+		queries:           queries,
+		permissionService: permissionService,
+		logger:            logger,
+	}
+}
+
+// APITokenScopeAllows checks whether the scopes allow reading or writing the resource
+func APITokenScopeAllows(scopes []string, resource string, write bool) bool {
+	if slices.Contains(scopes, resource+":write") {
+		return true
+	}
+	return !write && slices.Contains(scopes, resource+":read")
+}
+
+func validateAPITokenScopes(scopes []string) error {
+	for _, scope := range scopes {
+		resource, action, ok := strings.Cut(scope, ":")
+		if !ok || !slices.Contains(apiTokenResources, resource) || (action != "read" && action != "write") {
+			return fmt.Errorf("%w: %s", ErrInvalidAPITokenScope, scope)
+		}
+	}
+	return nil
+}
+
+func (s *apiTokenServiceImpl) Create(ctx context.Context, userID int64, request *schema.CreateAPITokenRequest) (*schema.CreateAPITokenResponse, error) {
+	if err := validateAPITokenScopes(request.Scopes); err != nil {
+		return nil, err
+	}
+	// a token can only be limited to a project its creator can access
+	if request.ProjectID != 0 {
+		if err := s.permissionService.Authorize(ctx, userID, request.ProjectID, ActionViewProject); err != nil {
+			return nil, err
+		}
+	}
+	if request.ServiceAccount != "" {
+		if request.ProjectID == 0 {
+			return nil, ErrServiceAccountNeedsProject
+		}
+		if err := s.permissionService.Authorize(ctx, userID, request.ProjectID, ActionManageTesters); err != nil {
+			return nil, err
+		}
+	}
+
+	secret, _, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api token: %w", err)
+	}
+	token := APITokenPrefix + secret
+
+	expiresAt := sql.NullTime{}
+	if request.ExpiresInDays > 0 {
+		expiresAt = common.NewNullTime(time.Now().AddDate(0, 0, request.ExpiresInDays))
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	ownerID := int32(userID)
+	if request.ServiceAccount != "" {
+		botID, err := createBotAccount(ctx, tx, request.ServiceAccount, request.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		ownerID = botID
+	}
+
+	created, err := tx.CreateApiToken(ctx, dbsqlc.CreateApiTokenParams{
+		UserID:      ownerID,
+		CreatedByID: int32(userID),
+		Name:        request.Name,
+		TokenPrefix: token[:len(APITokenPrefix)+6],
+		TokenHash:   hashToken(token),
+		Scopes:      request.Scopes,
+		ProjectID:   common.NewNullInt32(int32(request.ProjectID)),
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api token: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &schema.CreateAPITokenResponse{
+		APIToken: apiTokenToSchema(created),
+		Token:    token,
+	}, nil
+}
+
+// createBotAccount creates a user that can only authenticate with API tokens,
+// its password is random and never disclosed. The bot joins the org of the
+// project as a member and the project as a tester with the bot role
+func createBotAccount(ctx context.Context, tx *dbsqlc.Queries, name string, projectID int64) (int32, error) {
+	project, err := tx.GetProject(ctx, int32(projectID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	password, _, err := generateSecureToken()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate bot password: %w", err)
+	}
+
+	botID, err := tx.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:    name,
+		LastName:     "Bot",
+		DisplayName:  common.NullString(name),
+		Email:        fmt.Sprintf("bot-%s@bots.qatarina.invalid", uuid.NewString()),
+		Password:     common.MustHashPassword(password),
+		OrgID:        common.NewNullInt32(project.OrgID),
+		IsActivated:  common.TrueNullBool(),
+		IsReviewed:   common.TrueNullBool(),
+		IsSuperAdmin: common.FalseNullBool(),
+		IsVerified:   common.TrueNullBool(),
+		CreatedAt:    common.NewNullTime(time.Now()),
+		UpdatedAt:    common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create bot account: %w", err)
+	}
+	if err := ensureOrgMember(ctx, tx, project.OrgID, botID, OrgRoleMember); err != nil {
+		return 0, fmt.Errorf("failed to add bot account to org: %w", err)
+	}
+	_, err = tx.AssignTesterToProject(ctx, dbsqlc.AssignTesterToProjectParams{
+		ProjectID: project.ID,
+		UserID:    botID,
+		Role:      RoleBot,
+		IsActive:  true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add bot account to project: %w", err)
+	}
+	return botID, nil
+}
+
+func (s *apiTokenServiceImpl) List(ctx context.Context, userID int64) ([]schema.APIToken, error) {
+	rows, err := s.queries.ListApiTokensByCreator(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []schema.APIToken{}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch api tokens: %w", err)
+	}
+
+	tokens := make([]schema.APIToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, apiTokenToSchema(row))
+	}
+	return tokens, nil
+}
+
+func (s *apiTokenServiceImpl) Revoke(ctx context.Context, userID int64, tokenID int64) error {
+	affected, err := s.queries.RevokeApiToken(ctx, dbsqlc.RevokeApiTokenParams{
+		ID:          int32(tokenID),
+		CreatedByID: int32(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *apiTokenServiceImpl) Authenticate(ctx context.Context, token string) (*dbsqlc.GetApiTokenByHashRow, error) {
+	row, err := s.queries.GetApiTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, fmt.Errorf("failed to fetch api token: %w", err)
+	}
+
+	if err := s.queries.TouchApiToken(ctx, row.ID); err != nil {
+		// failing to record usage should not fail the request
+		s.logger.Error("api-token-service", "failed to record api token usage", "error", err)
+	}
+
+	return &row, nil
+}
+
+func apiTokenToSchema(token dbsqlc.ApiToken) schema.APIToken {
+	return schema.APIToken{
+		ID:         int64(token.ID),
+		UserID:     int64(token.UserID),
+		Name:       token.Name,
+		Prefix:     token.TokenPrefix,
+		Scopes:     token.Scopes,
+		ProjectID:  int64(token.ProjectID.Int32),
+		ExpiresAt:  common.FormatNullTime(token.ExpiresAt),
+		LastUsedAt: common.FormatNullTime(token.LastUsedAt),
+		CreatedAt:  common.FormatSqlDateTime(token.CreatedAt),
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenScopeAllows(t *testing.T) {
+	scopes := []string{"test-runs:write", "projects:read"}
+
+	assert.True(t, APITokenScopeAllows(scopes, "test-runs", true))
+	assert.True(t, APITokenScopeAllows(scopes, "test-runs", false))
+	assert.True(t, APITokenScopeAllows(scopes, "projects", false))
+	assert.False(t, APITokenScopeAllows(scopes, "projects", true))
+	assert.False(t, APITokenScopeAllows(scopes, "test-cases", false))
+	assert.False(t, APITokenScopeAllows(scopes, "me", false))
+}
+
+func TestValidateAPITokenScopes(t *testing.T) {
+	assert.NoError(t, validateAPITokenScopes([]string{"test-runs:write", "test-cases:read"}))
+	assert.ErrorIs(t, validateAPITokenScopes([]string{"test-runs"}), ErrInvalidAPITokenScope)
+	assert.ErrorIs(t, validateAPITokenScopes([]string{"users:read"}), ErrInvalidAPITokenScope)
+	assert.ErrorIs(t, validateAPITokenScopes([]string{"test-runs:delete"}), ErrInvalidAPITokenScope)
+}
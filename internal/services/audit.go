@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+)
+
+// Audit log actions
+const (
+	AuditLoginLockout = "auth.lockout"
+	AuditLoginUnlock  = "auth.unlock"
+)
+
+// AuditEvent describes an action to record in the audit log, ActorID is 0
+// for actions the system takes on its own
+type AuditEvent struct {
+	ActorID     int64
+	Action      string
+	SubjectType string
+	SubjectID   string
+	IPAddress   string
+	Details     string
+}
+
+// recordAuditEvent writes the event to the audit log, failures are logged
+// rather than returned so auditing never fails the operation being audited
+func recordAuditEvent(ctx context.Context, queries *dbsqlc.Queries, logger logging.Logger, event AuditEvent) {
+	err := queries.CreateAuditLog(ctx, dbsqlc.CreateAuditLogParams{
+		ActorID:     common.NewNullInt32(int32(event.ActorID)),
+		Action:      event.Action,
+		SubjectType: event.SubjectType,
+		SubjectID:   event.SubjectID,
+		IpAddress:   common.NullString(event.IPAddress),
+		Details:     common.NullString(event.Details),
+	})
+	if err != nil {
+		logger.Error("audit", "failed to record audit event", "action", event.Action, "error", err)
+	}
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -13,77 +17,257 @@ import (
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/google/uuid"
 )
 
 var ErrUserAlreadyExists = errors.New("user with given email already exists")
+var ErrInvalidResetToken = errors.New("password reset token is invalid or has expired")
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid, expired or revoked")
+var ErrRefreshTokenReused = errors.New("refresh token was already used, session has been revoked")
+
+// passwordResetTokenTTL is how long a password reset link stays valid
+const passwordResetTokenTTL = time.Hour
 
 type AuthService interface {
 	SignIn(*schema.LoginRequest) (*schema.LoginResponse, error)
 	SignUp(*schema.SignUpRequest) (*schema.LoginResponse, error)
+	// SignInUser starts a session for a user who was authenticated by an external identity provider,
+	// the account still has to be verified and pass two-factor authentication like any other login
+	SignInUser(ctx context.Context, userID int32, userAgent, ipAddress string) (*schema.LoginResponse, error)
+	// ResetPassword sends a single-use password reset link to the user with the given email
 	ResetPassword(ctx context.Context, email string) error
+	// ConfirmPasswordReset sets a new password using a token from a password reset link
+	ConfirmPasswordReset(ctx context.Context, request *schema.ConfirmPasswordResetRequest) error
 	ChangePassword(ctx context.Context, request *schema.ChangePasswordRequest) error
+	// RefreshToken exchanges a refresh token for a new access token and a new refresh token,
+	// presenting a refresh token that was already exchanged revokes the whole session
+	RefreshToken(ctx context.Context, request *schema.RefreshTokenRequest) (*schema.RefreshTokenResponse, error)
+	// SwitchOrg exchanges the refresh token of the session for tokens that carry another
+	// org the user belongs to, the org also becomes the default for new sessions
+	SwitchOrg(ctx context.Context, userID int64, request *schema.SwitchOrgRequest) (*schema.RefreshTokenResponse, error)
+	// Logout revokes the session the given refresh token belongs to
+	Logout(ctx context.Context, userID int64, refreshToken string) error
+	// LogoutAll revokes all sessions of the user
+	LogoutAll(ctx context.Context, userID int64) error
+	// ListSessions lists the active sessions of the user
+	ListSessions(ctx context.Context, userID int64) ([]schema.UserSession, error)
+	// RevokeSession revokes one session of the user by its ID
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	// UnlockAccount clears a login lockout on the user's account
+	UnlockAccount(ctx context.Context, actorID, userID int64) error
+	// VerifyEmail confirms the email address of the account using a token from a verification link
+	VerifyEmail(ctx context.Context, token string) error
+	// ResendVerificationEmail sends a new verification link if the account is not verified yet
+	ResendVerificationEmail(ctx context.Context, email string) error
+	// VerifyTwoFactorLogin completes a login that needs a second factor using the challenge
+	// token from the password step and a TOTP or recovery code
+	VerifyTwoFactorLogin(ctx context.Context, request *schema.TwoFactorLoginRequest) (*schema.LoginResponse, error)
+	// BeginChallengeEnrolment starts two-factor enrolment during a login that requires it
+	BeginChallengeEnrolment(ctx context.Context, challengeToken string) (*schema.TwoFactorEnrolment, error)
+	// TwoFactorStatus reports whether two-factor authentication is enabled or required for the user
+	TwoFactorStatus(ctx context.Context, userID int64) (*schema.TwoFactorStatus, error)
+	// BeginTwoFactorEnrolment creates a new TOTP secret, it is only used for logins once confirmed
+	BeginTwoFactorEnrolment(ctx context.Context, userID int64) (*schema.TwoFactorEnrolment, error)
+	// ConfirmTwoFactorEnrolment enables two-factor authentication with a code from the authenticator
+	ConfirmTwoFactorEnrolment(ctx context.Context, userID int64, code string) (*schema.RecoveryCodesResponse, error)
+	// RegenerateRecoveryCodes replaces the recovery codes of the user
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*schema.RecoveryCodesResponse, error)
+	// DisableTwoFactor turns off two-factor authentication unless an org of the user requires it
+	DisableTwoFactor(ctx context.Context, userID int64, code string) error
+	// ResetTwoFactor removes two-factor authentication from an account on behalf of an administrator
+	ResetTwoFactor(ctx context.Context, actorID, userID int64) error
 }
 
 type authServiceImpl struct {
-	authConfig *config.AuthConfiguration
-	queries    *dbsqlc.Queries
-	logger     logging.Logger
+	authConfig   *config.AuthConfiguration
+	serverConfig *config.HTTPServerConfiguration
+	smtpCfg      config.SMTPConfiguration
+	db           *sql.DB
+	queries      *dbsqlc.Queries
+	signingKeys  SigningKeyService
+	logger       logging.Logger
 }
 
-func NewAuthService(authConfig *config.AuthConfiguration, db *dbsqlc.Queries, logger logging.Logger) AuthService {
+func NewAuthService(cfg *config.Config, db *sql.DB, queries *dbsqlc.Queries, signingKeys SigningKeyService, logger logging.Logger) AuthService {
 	return &authServiceImpl{
-		authConfig: authConfig,
-		queries:    db,
-		logger:     logger,
+		authConfig:   &cfg.Auth,
+		serverConfig: &cfg.Server,
+		smtpCfg:      cfg.SMTP,
+		db:           db,
+		queries:      queries,
+		signingKeys:  signingKeys,
+		logger:       logger,
 	}
 }
 
-func generateJWTToken(res *schema.LoginResponse, expireAfter int64) *jwt.Token {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["UserID"] = res.UserID
-	claims["Email"] = res.Email
-	claims["Name"] = res.DisplayName
-	claims["exp"] = expireAfter
+// issueTokens creates a signed access token and a refresh token for the given
+// session family, the access token lifetime comes from JwtExpiryTimeout
+func (a *authServiceImpl) issueTokens(ctx context.Context, res *schema.LoginResponse, familyID uuid.UUID, userAgent, ipAddress string) (*schema.RefreshTokenResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(a.authConfig.JwtExpiryTimeout) * time.Second).Unix()
+	tokenStr, err := a.signingKeys.SignAccessToken(ctx, a.accessTokenClaims(res, now.Unix(), expiresAt))
+	if err != nil {
+		a.logger.Error("auth-service", "failed to create a token", "error", err)
+		return nil, fmt.Errorf("failed to generate auth token, got: %v", err)
+	}
+
+	refreshToken, refreshTokenHash, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	err = a.queries.CreateRefreshToken(ctx, dbsqlc.CreateRefreshTokenParams{
+		UserID:    int32(res.UserID),
+		FamilyID:  familyID,
+		TokenHash: refreshTokenHash,
+		UserAgent: common.NullString(userAgent),
+		IpAddress: common.NullString(ipAddress),
+		ExpiresAt: time.Now().Add(time.Duration(a.authConfig.RefreshTokenTimeout) * time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	if res.OrgID != 0 {
+		err = a.queries.UpsertSessionOrg(ctx, dbsqlc.UpsertSessionOrgParams{
+			FamilyID: familyID,
+			UserID:   int32(res.UserID),
+			OrgID:    int32(res.OrgID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to store session org: %w", err)
+		}
+	}
 
-	return token
+	return &schema.RefreshTokenResponse{
+		Token:        tokenStr,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+		OrgID:        res.OrgID,
+	}, nil
+}
+
+// accessTokenClaims builds the claims of an access token, UserID, Email,
+// Name and OrgID are what the API reads, the registered claims are for other
+// services verifying the token with the published keys
+func (a *authServiceImpl) accessTokenClaims(res *schema.LoginResponse, issuedAt, expireAfter int64) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"UserID": res.UserID,
+		"Email":  res.Email,
+		"Name":   res.DisplayName,
+		"sub":    fmt.Sprint(res.UserID),
+		"iat":    issuedAt,
+		"exp":    expireAfter,
+	}
+	if res.OrgID != 0 {
+		claims["OrgID"] = res.OrgID
+	}
+	if a.authConfig.JwtIssuer != "" {
+		claims["iss"] = a.authConfig.JwtIssuer
+	}
+	return claims
 }
 
 func (a *authServiceImpl) SignIn(request *schema.LoginRequest) (*schema.LoginResponse, error) {
-	user, err := a.queries.FindUserLoginByEmail(context.Background(), request.Email)
+	ctx := context.Background()
+	if err := a.checkLoginLockout(ctx, request.Email, request.IPAddress); err != nil {
+		return nil, err
+	}
+
+	user, err := a.queries.FindUserLoginByEmail(ctx, request.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			a.logger.Debug("auth-service", "failed to find user from login request", "email", request.Email)
+			a.recordLoginFailure(ctx, request.Email, request.IPAddress)
 			return nil, ErrInvalidCredentials
 		}
 		a.logger.Error("auth-service", "failed to process login request", "error", err)
 		return nil, err
 	}
-	a.logger.Info("auth-service", "handling request data", "user", user, "request", request)
+	a.logger.Info("auth-service", "handling login request", "email", request.Email)
 
 	if !common.CheckPasswordHash(request.Password, user.Password) {
 		a.logger.Debug("auth-service", "invalid password provided", "email", request.Email, "error", err)
+		a.recordLoginFailure(ctx, request.Email, request.IPAddress)
 		return nil, ErrInvalidCredentials
 	}
 
+	a.clearLoginFailures(ctx, request.Email)
+
 	res := &schema.LoginResponse{
 		UserID:      int64(user.ID),
 		DisplayName: user.DisplayName.String,
 		Email:       user.Email,
 		ExpiresAt:   0,
 	}
+	return a.authenticated(ctx, res, user.IsVerified.Bool, request.UserAgent, request.IPAddress)
+}
+
+// authenticated applies the checks shared by every way of signing in once the
+// user has been identified, it asks for a second factor when the account or one
+// of the orgs of the user requires it and otherwise completes the login
+func (a *authServiceImpl) authenticated(ctx context.Context, res *schema.LoginResponse, verified bool, userAgent, ipAddress string) (*schema.LoginResponse, error) {
+	if a.authConfig.RequireVerifiedAccounts && !verified {
+		return nil, ErrAccountNotVerified
+	}
 
-	token := generateJWTToken(res, time.Now().Add(time.Hour*6).Unix())
-	tokenStr, err := token.SignedString([]byte(a.authConfig.JwtSecretKey))
+	challenge, err := a.twoFactorChallenge(ctx, int32(res.UserID))
 	if err != nil {
-		a.logger.Error("auth-service", "failed to create a token", "error", err)
-		return nil, fmt.Errorf("failed to generate auth token, got: %v", err)
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+	return a.completeLogin(ctx, res, userAgent, ipAddress)
+}
+
+// completeLogin records the login and starts a new session for the user
+func (a *authServiceImpl) completeLogin(ctx context.Context, res *schema.LoginResponse, userAgent, ipAddress string) (*schema.LoginResponse, error) {
+	_, err := a.queries.UpdateUserLastLogin(ctx, dbsqlc.UpdateUserLastLoginParams{
+		LastLoginAt: common.NewNullTime(time.Now()),
+		ID:          int32(res.UserID),
+	})
+	if err != nil {
+		a.logger.Error("auth-service", "failed to record last login time", "error", err)
 	}
 
-	res.Token = tokenStr
+	orgID, err := defaultOrgID(ctx, a.queries, int32(res.UserID))
+	if err != nil {
+		return nil, err
+	}
+	res.OrgID = int64(orgID)
+
+	tokens, err := a.issueTokens(ctx, res, uuid.New(), userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Token = tokens.Token
+	res.ExpiresAt = tokens.ExpiresAt
+	res.RefreshToken = tokens.RefreshToken
 	return res, nil
 }
 
+func (a *authServiceImpl) SignInUser(ctx context.Context, userID int32, userAgent, ipAddress string) (*schema.LoginResponse, error) {
+	user, err := a.queries.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return nil, ErrInvalidCredentials
+	}
+
+	res := &schema.LoginResponse{
+		UserID:      int64(user.ID),
+		DisplayName: user.DisplayName.String,
+		Email:       user.Email,
+	}
+	return a.authenticated(ctx, res, user.IsVerified.Bool, userAgent, ipAddress)
+}
+
 func (a *authServiceImpl) SignUp(request *schema.SignUpRequest) (*schema.LoginResponse, error) {
 	_, err := a.queries.FindUserLoginByEmail(context.Background(), request.Email)
 	// TODO: make this error handling better - this is clunky
@@ -92,8 +276,6 @@ func (a *authServiceImpl) SignUp(request *schema.SignUpRequest) (*schema.LoginRe
 		return nil, ErrUserAlreadyExists
 	}
 
-	// TODO: create organization for the user
-
 	userParams := dbsqlc.CreateUserParams{
 		FirstName:    request.FirstName,
 		LastName:     request.LastName,
@@ -108,31 +290,171 @@ func (a *authServiceImpl) SignUp(request *schema.SignUpRequest) (*schema.LoginRe
 		UpdatedAt:    sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	userID, err := a.queries.CreateUser(context.Background(), userParams)
+	// the user, their personal org and its ownership are created together so a
+	// failure cannot leave a user without an org
+	sqlTx, err := a.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := a.queries.WithTx(sqlTx)
+
+	userID, err := tx.CreateUser(context.Background(), userParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user got %v", err)
 	}
 
+	// every user starts out owning a personal org
+	org, err := createOrgWithOwner(context.Background(), tx, dbsqlc.CreateOrgParams{
+		Name:        personalOrgName(request.FirstName, request.DisplayName),
+		CreatedByID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.SetUserDefaultOrg(context.Background(), dbsqlc.SetUserDefaultOrgParams{
+		ID:    userID,
+		OrgID: common.NewNullInt32(org.ID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set default org: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := a.sendVerificationEmail(userID, request.Email); err != nil {
+		// the user can request a new link so this should not fail the signup
+		a.logger.Error("auth-service", "failed to send verification email", "error", err)
+	}
+
 	res := &schema.LoginResponse{
 		UserID:      int64(userID),
 		DisplayName: request.DisplayName,
 		Email:       request.Email,
 		ExpiresAt:   0,
+		OrgID:       int64(org.ID),
+	}
+
+	// accounts have to be verified before they can be used
+	if a.authConfig.RequireVerifiedAccounts {
+		return res, nil
 	}
 
-	token := generateJWTToken(res, time.Now().Add(time.Hour*6).Unix())
-	tokenStr, err := token.SignedString([]byte(a.authConfig.JwtSecretKey))
+	tokens, err := a.issueTokens(context.Background(), res, uuid.New(), request.UserAgent, request.IPAddress)
 	if err != nil {
-		a.logger.Error("auth-service", "failed to create a token", "error", err)
-		return nil, fmt.Errorf("failed to generate auth token, got: %v", err)
+		return nil, err
 	}
 
-	res.Token = tokenStr
+	res.Token = tokens.Token
+	res.ExpiresAt = tokens.ExpiresAt
+	res.RefreshToken = tokens.RefreshToken
 	return res, nil
 }
 
+// generateSecureToken creates a random token to hand out to the user along
+// with the hash of the token which is what gets stored in the database
+func generateSecureToken() (token string, tokenHash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (a *authServiceImpl) ResetPassword(ctx context.Context, email string) error {
-	panic("not implemented")
+	user, err := a.queries.FindUserLoginByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// do not reveal whether an account exists for the email
+			a.logger.Debug("auth-service", "password reset requested for unknown email", "email", email)
+			return nil
+		}
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	token, tokenHash, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	// only the most recently requested link should work
+	_, err = a.queries.InvalidatePasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate previous reset tokens: %w", err)
+	}
+
+	expiresAt := time.Now().Add(passwordResetTokenTTL)
+	err = a.queries.CreatePasswordResetToken(ctx, dbsqlc.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	data := struct {
+		BaseURL   string
+		Token     string
+		ExpiresAt string
+	}{
+		BaseURL:   a.serverConfig.BaseURL(),
+		Token:     token,
+		ExpiresAt: expiresAt.Format("Jan 2, 2006 15:04 MST"),
+	}
+
+	err = sendTemplatedEmail(a.smtpCfg, user.Email, "Qatarina password reset", "internal/templates/password_reset_email.html", data)
+	if err != nil {
+		// failing only for existing accounts would reveal which emails are
+		// registered, the user can request another link
+		a.logger.Error("auth-service", "failed to send password reset email", "error", err)
+		return nil
+	}
+
+	return nil
+}
+
+func (a *authServiceImpl) ConfirmPasswordReset(ctx context.Context, request *schema.ConfirmPasswordResetRequest) error {
+	if request.NewPassword != request.ConfirmPassword {
+		return fmt.Errorf("new password and confirmation do not match")
+	}
+
+	userID, err := a.queries.ConsumePasswordResetToken(ctx, hashToken(request.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+
+	err = a.queries.ChangeUserPassword(ctx, dbsqlc.ChangeUserPasswordParams{
+		ID:        userID,
+		Password:  common.MustHashPassword(request.NewPassword),
+		UpdatedAt: common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	_, err = a.queries.InvalidatePasswordResetTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	// whoever had access to the account before the reset should not keep it
+	_, err = a.queries.RevokeAllUserRefreshTokens(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
 }
 
 func (a *authServiceImpl) ChangePassword(ctx context.Context, request *schema.ChangePasswordRequest) error {
@@ -163,6 +485,224 @@ func (a *authServiceImpl) ChangePassword(ctx context.Context, request *schema.Ch
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	// a password change makes any outstanding reset links obsolete
+	_, err = a.queries.InvalidatePasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	// as with a reset, sessions opened with the old password are ended
+	_, err = a.queries.RevokeAllUserRefreshTokens(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
 	return nil
 
 }
+
+func (a *authServiceImpl) RefreshToken(ctx context.Context, request *schema.RefreshTokenRequest) (*schema.RefreshTokenResponse, error) {
+	current, user, err := a.consumeRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := a.sessionOrgID(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.LoginResponse{
+		UserID:      int64(user.ID),
+		DisplayName: user.DisplayName.String,
+		Email:       user.Email,
+		OrgID:       int64(orgID),
+	}
+
+	return a.issueTokens(ctx, res, current.FamilyID, request.UserAgent, request.IPAddress)
+}
+
+func (a *authServiceImpl) SwitchOrg(ctx context.Context, userID int64, request *schema.SwitchOrgRequest) (*schema.RefreshTokenResponse, error) {
+	role, err := orgRoleOf(ctx, a.queries, int32(request.OrgID), userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrNotOrgMember
+	}
+
+	current, user, err := a.consumeRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if int64(user.ID) != userID {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	_, err = a.queries.SetUserDefaultOrg(ctx, dbsqlc.SetUserDefaultOrgParams{
+		ID:    user.ID,
+		OrgID: common.NewNullInt32(int32(request.OrgID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set default org: %w", err)
+	}
+
+	res := &schema.LoginResponse{
+		UserID:      int64(user.ID),
+		DisplayName: user.DisplayName.String,
+		Email:       user.Email,
+		OrgID:       request.OrgID,
+	}
+
+	return a.issueTokens(ctx, res, current.FamilyID, request.UserAgent, request.IPAddress)
+}
+
+// consumeRefreshToken marks the refresh token as used and returns it along
+// with its user, the caller issues the tokens that replace it
+func (a *authServiceImpl) consumeRefreshToken(ctx context.Context, refreshToken string) (*dbsqlc.RefreshToken, *dbsqlc.User, error) {
+	current, err := a.queries.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, fmt.Errorf("failed to fetch refresh token: %w", err)
+	}
+
+	if current.RevokedAt.Valid || current.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if current.UsedAt.Valid {
+		return nil, nil, a.revokeReusedFamily(ctx, current)
+	}
+
+	// marking the token as used only succeeds once, which guards against
+	// two concurrent requests rotating the same token
+	affected, err := a.queries.MarkRefreshTokenUsed(ctx, current.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if affected == 0 {
+		return nil, nil, a.revokeReusedFamily(ctx, current)
+	}
+
+	user, err := a.queries.GetUser(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	return &current, &user, nil
+}
+
+// sessionOrgID returns the org the session switched to while the user still
+// belongs to it, otherwise the default org of the user
+func (a *authServiceImpl) sessionOrgID(ctx context.Context, token *dbsqlc.RefreshToken) (int32, error) {
+	orgID, err := a.queries.GetSessionOrg(ctx, dbsqlc.GetSessionOrgParams{
+		FamilyID: token.FamilyID,
+		UserID:   token.UserID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch session org: %w", err)
+	}
+	if err == nil {
+		role, err := orgRoleOf(ctx, a.queries, orgID, int64(token.UserID))
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+		if role != "" {
+			return orgID, nil
+		}
+	}
+	return defaultOrgID(ctx, a.queries, token.UserID)
+}
+
+// revokeReusedFamily revokes the whole session when a refresh token is presented
+// after it was already exchanged, since either the user or an attacker holds a stolen copy
+func (a *authServiceImpl) revokeReusedFamily(ctx context.Context, token dbsqlc.RefreshToken) error {
+	a.logger.Info("auth-service", "refresh token reuse detected, revoking session", "user_id", token.UserID, "family_id", token.FamilyID)
+	_, err := a.queries.RevokeRefreshTokenFamily(ctx, dbsqlc.RevokeRefreshTokenFamilyParams{
+		UserID:   token.UserID,
+		FamilyID: token.FamilyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+func (a *authServiceImpl) Logout(ctx context.Context, userID int64, refreshToken string) error {
+	token, err := a.queries.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidRefreshToken
+		}
+		return fmt.Errorf("failed to fetch refresh token: %w", err)
+	}
+	if int64(token.UserID) != userID {
+		return ErrInvalidRefreshToken
+	}
+
+	_, err = a.queries.RevokeRefreshTokenFamily(ctx, dbsqlc.RevokeRefreshTokenFamilyParams{
+		UserID:   token.UserID,
+		FamilyID: token.FamilyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (a *authServiceImpl) LogoutAll(ctx context.Context, userID int64) error {
+	_, err := a.queries.RevokeAllUserRefreshTokens(ctx, int32(userID))
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func (a *authServiceImpl) ListSessions(ctx context.Context, userID int64) ([]schema.UserSession, error) {
+	rows, err := a.queries.ListUserSessions(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []schema.UserSession{}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	sessions := make([]schema.UserSession, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, schema.UserSession{
+			ID:         row.FamilyID.String(),
+			UserAgent:  row.UserAgent.String,
+			IPAddress:  row.IpAddress.String,
+			StartedAt:  row.StartedAt.Format(time.DateTime),
+			LastUsedAt: row.CreatedAt.Format(time.DateTime),
+			ExpiresAt:  row.ExpiresAt.Format(time.DateTime),
+		})
+	}
+	return sessions, nil
+}
+
+func (a *authServiceImpl) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	familyID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrNotFound
+	}
+
+	affected, err := a.queries.RevokeRefreshTokenFamily(ctx, dbsqlc.RevokeRefreshTokenFamilyParams{
+		UserID:   int32(userID),
+		FamilyID: familyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
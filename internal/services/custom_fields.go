@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Entities custom fields are defined on
+const (
+	CustomFieldEntityTestCase = "test_case"
+	CustomFieldEntityTestRun  = "test_run"
+)
+
+// ErrInvalidCustomField is returned for custom field definitions which are not valid
+var ErrInvalidCustomField = errors.New("invalid custom field")
+
+// ErrCustomFieldExists is returned when a project already has a field with the name
+var ErrCustomFieldExists = errors.New("custom field already exists")
+
+// CustomFieldService manages the custom fields projects define on their test
+// cases and test runs
+type CustomFieldService interface {
+	// FindAllByProjectID lists the fields of a project, all of them when entity is empty
+	FindAllByProjectID(ctx context.Context, projectID int64, entity string) ([]dbsqlc.CustomField, error)
+	Create(ctx context.Context, request *schema.CreateCustomFieldRequest) (*dbsqlc.CustomField, error)
+	Update(ctx context.Context, request *schema.UpdateCustomFieldRequest) (*dbsqlc.CustomField, error)
+	// Delete removes a field with every value stored for it
+	Delete(ctx context.Context, projectID, fieldID int64) error
+	// FindValues returns the custom field values of test cases or test runs by their ID
+	FindValues(ctx context.Context, entityIDs ...uuid.UUID) (map[uuid.UUID]map[string]any, error)
+}
+
+var _ CustomFieldService = &customFieldServiceImpl{}
+
+type customFieldServiceImpl struct {
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewCustomFieldService(queries *dbsqlc.Queries, logger logging.Logger) CustomFieldService {
+	return &customFieldServiceImpl{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *customFieldServiceImpl) FindAllByProjectID(ctx context.Context, projectID int64, entity string) ([]dbsqlc.CustomField, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	if entity == "" {
+		return s.queries.ListCustomFieldsByProject(ctx, int32(projectID))
+	}
+	return s.queries.ListCustomFieldsByEntity(ctx, dbsqlc.ListCustomFieldsByEntityParams{
+		ProjectID: int32(projectID),
+		Entity:    entity,
+	})
+}
+
+func (s *customFieldServiceImpl) Create(ctx context.Context, request *schema.CreateCustomFieldRequest) (*dbsqlc.CustomField, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
+	defaultValue, err := customFieldDefault(validation.CustomField{
+		Name:    request.Name,
+		Type:    request.Type,
+		Options: request.Options,
+		Default: request.DefaultValue,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.queries.ListCustomFieldsByEntity(ctx, dbsqlc.ListCustomFieldsByEntityParams{
+		ProjectID: int32(request.ProjectID),
+		Entity:    request.Entity,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	if slices.ContainsFunc(existing, func(f dbsqlc.CustomField) bool { return f.Name == request.Name }) {
+		return nil, ErrCustomFieldExists
+	}
+
+	field, err := s.queries.CreateCustomField(ctx, dbsqlc.CreateCustomFieldParams{
+		ProjectID:    int32(request.ProjectID),
+		Entity:       request.Entity,
+		Name:         request.Name,
+		Label:        request.Label,
+		FieldType:    request.Type,
+		Options:      nonNilStrings(request.Options),
+		IsRequired:   request.IsRequired,
+		DefaultValue: defaultValue,
+		Position:     request.Position,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+	return &field, nil
+}
+
+func (s *customFieldServiceImpl) Update(ctx context.Context, request *schema.UpdateCustomFieldRequest) (*dbsqlc.CustomField, error) {
+	field, err := s.findField(ctx, request.ProjectID, request.FieldID)
+	if err != nil {
+		return nil, err
+	}
+	defaultValue, err := customFieldDefault(validation.CustomField{
+		Name:    field.Name,
+		Type:    field.FieldType,
+		Options: request.Options,
+		Default: request.DefaultValue,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.queries.UpdateCustomField(ctx, dbsqlc.UpdateCustomFieldParams{
+		ID:           field.ID,
+		Label:        request.Label,
+		Options:      nonNilStrings(request.Options),
+		IsRequired:   request.IsRequired,
+		DefaultValue: defaultValue,
+		Position:     request.Position,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+	return &updated, nil
+}
+
+func (s *customFieldServiceImpl) Delete(ctx context.Context, projectID, fieldID int64) error {
+	field, err := s.findField(ctx, projectID, fieldID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.queries.DeleteCustomField(ctx, field.ID); err != nil {
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	return nil
+}
+
+func (s *customFieldServiceImpl) FindValues(ctx context.Context, entityIDs ...uuid.UUID) (map[uuid.UUID]map[string]any, error) {
+	return customFieldValues(ctx, s.queries, entityIDs)
+}
+
+// findField fetches a field of a project in the org of the context
+func (s *customFieldServiceImpl) findField(ctx context.Context, projectID, fieldID int64) (*dbsqlc.CustomField, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	field, err := s.queries.GetCustomField(ctx, int32(fieldID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch custom field: %w", err)
+	}
+	if int64(field.ProjectID) != projectID {
+		return nil, ErrNotFound
+	}
+	return &field, nil
+}
+
+// customFieldDefault validates the definition of a field and encodes its default value
+func customFieldDefault(field validation.CustomField) (pqtype.NullRawMessage, error) {
+	value, err := validation.ValidateCustomFieldDefinition(field)
+	if err != nil {
+		return pqtype.NullRawMessage{}, fmt.Errorf("%w: %v", ErrInvalidCustomField, err)
+	}
+	if value == nil {
+		return pqtype.NullRawMessage{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+	return pqtype.NullRawMessage{RawMessage: data, Valid: true}, nil
+}
+
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+// validationFields converts the fields of a project to the definitions values
+// are validated against
+func validationFields(fields []dbsqlc.CustomField) []validation.CustomField {
+	res := make([]validation.CustomField, 0, len(fields))
+	for _, field := range fields {
+		var defaultValue any
+		if field.DefaultValue.Valid {
+			_ = json.Unmarshal(field.DefaultValue.RawMessage, &defaultValue)
+		}
+		res = append(res, validation.CustomField{
+			Name:     field.Name,
+			Type:     field.FieldType,
+			Options:  field.Options,
+			Required: field.IsRequired,
+			Default:  defaultValue,
+		})
+	}
+	return res
+}
+
+// saveCustomFieldValues validates the custom field values of a test case or
+// test run and stores them on top of its existing values, for new entities
+// the defaults are applied
+func saveCustomFieldValues(ctx context.Context, db *dbsqlc.Queries, projectID int32, entity string, entityID uuid.UUID, values map[string]any, isNew bool) error {
+	fields, err := db.ListCustomFieldsByEntity(ctx, dbsqlc.ListCustomFieldsByEntityParams{
+		ProjectID: projectID,
+		Entity:    entity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	if len(fields) == 0 && len(values) == 0 {
+		return nil
+	}
+
+	var existing map[string]any
+	if !isNew {
+		stored, err := customFieldValues(ctx, db, []uuid.UUID{entityID})
+		if err != nil {
+			return err
+		}
+		existing = stored[entityID]
+	}
+	result, err := validation.ValidateCustomFields(validationFields(fields), values, existing)
+	if err != nil {
+		return err
+	}
+	if err := checkCustomFieldUsers(ctx, db, projectID, fields, result); err != nil {
+		return err
+	}
+
+	if err := db.DeleteCustomFieldValuesByEntity(ctx, entityID); err != nil {
+		return fmt.Errorf("failed to clear custom field values: %w", err)
+	}
+	for _, field := range fields {
+		value, ok := result[field.Name]
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		err = db.UpsertCustomFieldValue(ctx, dbsqlc.UpsertCustomFieldValueParams{
+			FieldID:  field.ID,
+			EntityID: entityID,
+			Value:    data,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store value of custom field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkCustomFieldUsers ensures the values of user fields refer to existing
+// users in the org of the project
+func checkCustomFieldUsers(ctx context.Context, db *dbsqlc.Queries, projectID int32, fields []dbsqlc.CustomField, values map[string]any) error {
+	userIDs := map[string]int32{}
+	for _, field := range fields {
+		if field.FieldType != validation.CustomFieldUser {
+			continue
+		}
+		switch id := values[field.Name].(type) {
+		case int64:
+			userIDs[field.Name] = int32(id)
+		case float64:
+			userIDs[field.Name] = int32(id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]int32, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, id)
+	}
+	users, err := db.ListUsersByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	project, err := db.GetProject(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch project: %w", err)
+	}
+	var errs validation.CustomFieldErrors
+	for name, id := range userIDs {
+		if !slices.ContainsFunc(users, func(u dbsqlc.ListUsersByIDsRow) bool { return u.ID == id }) {
+			errs = append(errs, validation.CustomFieldError{Field: name, Message: fmt.Sprintf("user %d does not exist", id)})
+			continue
+		}
+		_, err := db.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: project.OrgID, UserID: id})
+		if errors.Is(err, sql.ErrNoRows) {
+			errs = append(errs, validation.CustomFieldError{Field: name, Message: fmt.Sprintf("user %d is not a member of the org", id)})
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check org membership: %w", err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// customFieldValues fetches the custom field values of test cases or test runs by their ID
+func customFieldValues(ctx context.Context, db *dbsqlc.Queries, entityIDs []uuid.UUID) (map[uuid.UUID]map[string]any, error) {
+	res := make(map[uuid.UUID]map[string]any, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return res, nil
+	}
+	rows, err := db.ListCustomFieldValues(ctx, entityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom field values: %w", err)
+	}
+	for _, row := range rows {
+		var value any
+		if err := json.Unmarshal(row.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid value of custom field %s: %w", row.Name, err)
+		}
+		if res[row.EntityID] == nil {
+			res[row.EntityID] = map[string]any{}
+		}
+		res[row.EntityID][row.Name] = value
+	}
+	return res, nil
+}
+
+// copyCustomFields creates copies of the fields in another project and
+// returns the copies
+func copyCustomFields(ctx context.Context, db *dbsqlc.Queries, projectID int32, fields []dbsqlc.CustomField) ([]dbsqlc.CustomField, error) {
+	copies := make([]dbsqlc.CustomField, 0, len(fields))
+	for _, field := range fields {
+		created, err := db.CreateCustomField(ctx, dbsqlc.CreateCustomFieldParams{
+			ProjectID:    projectID,
+			Entity:       field.Entity,
+			Name:         field.Name,
+			Label:        field.Label,
+			FieldType:    field.FieldType,
+			Options:      nonNilStrings(field.Options),
+			IsRequired:   field.IsRequired,
+			DefaultValue: field.DefaultValue,
+			Position:     field.Position,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy custom field %s: %w", field.Name, err)
+		}
+		copies = append(copies, created)
+	}
+	return copies, nil
+}
+
+// rawCustomFieldValues fetches the stored custom field values of test cases or
+// test runs by their ID without decoding them
+func rawCustomFieldValues(ctx context.Context, db *dbsqlc.Queries, entityIDs []uuid.UUID) (map[uuid.UUID]map[string]json.RawMessage, error) {
+	res := make(map[uuid.UUID]map[string]json.RawMessage, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return res, nil
+	}
+	rows, err := db.ListCustomFieldValues(ctx, entityIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom field values: %w", err)
+	}
+	for _, row := range rows {
+		if res[row.EntityID] == nil {
+			res[row.EntityID] = map[string]json.RawMessage{}
+		}
+		res[row.EntityID][row.Name] = row.Value
+	}
+	return res, nil
+}
+
+// storeCustomFieldValues stores already validated values by the name of the
+// fields of the entity, values of fields the project does not have are dropped
+func storeCustomFieldValues(ctx context.Context, db *dbsqlc.Queries, fields []dbsqlc.CustomField, entity string, entityID uuid.UUID, values map[string]json.RawMessage) error {
+	for _, field := range fields {
+		value, ok := values[field.Name]
+		if field.Entity != entity || !ok {
+			continue
+		}
+		err := db.UpsertCustomFieldValue(ctx, dbsqlc.UpsertCustomFieldValueParams{
+			FieldID:  field.ID,
+			EntityID: entityID,
+			Value:    value,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store value of custom field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// copyCustomFieldValues copies the custom field values of the entities in ids
+// to their copies, fields are the fields of the project of the copies
+func copyCustomFieldValues(ctx context.Context, db *dbsqlc.Queries, fields []dbsqlc.CustomField, entity string, ids map[uuid.UUID]uuid.UUID) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	values, err := rawCustomFieldValues(ctx, db, slices.Collect(maps.Keys(ids)))
+	if err != nil {
+		return err
+	}
+	for id, copyID := range ids {
+		if err := storeCustomFieldValues(ctx, db, fields, entity, copyID, values[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -11,6 +11,9 @@ import (
 
 type DashboardService interface {
 	GetDashboardSummary(ctx context.Context) (*schema.DashboardSummaryResponse, error)
+	// GetProjectRollup aggregates the dashboard metrics of a project and all
+	// of its sub-projects
+	GetProjectRollup(ctx context.Context, projectID int64) (*schema.ProjectRollupResponse, error)
 }
 
 type dashboardServiceImpl struct {
@@ -26,37 +29,97 @@ func NewDashboardService(queries *dbsqlc.Queries, logger logging.Logger) Dashboa
 }
 
 func (d *dashboardServiceImpl) GetDashboardSummary(ctx context.Context) (*schema.DashboardSummaryResponse, error) {
-	projectCount, err := d.queries.GetProjectCount(ctx)
+	return d.summary(ctx, nil)
+}
+
+func (d *dashboardServiceImpl) GetProjectRollup(ctx context.Context, projectID int64) (*schema.ProjectRollupResponse, error) {
+	if err := ensureProjectInOrg(ctx, d.queries, projectID); err != nil {
+		return nil, err
+	}
+	projectIDs, err := d.queries.GetProjectSubtreeIDs(ctx, int32(projectID))
+	if err != nil {
+		d.logger.Error("failed to get sub-projects", "projectID", projectID, "error", err)
+		return nil, fmt.Errorf("failed to get sub-projects: %w", err)
+	}
+
+	summary, err := d.summary(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	statusRatio, err := d.queries.GetTestPlanStatusRatio(ctx, dbsqlc.GetTestPlanStatusRatioParams{OrgID: contextOrgID(ctx), ProjectIds: projectIDs})
+	if err != nil {
+		d.logger.Error("failed to get test plan status ratio", "error", err)
+		return nil, fmt.Errorf("failed to get test plan status ratio: %w", err)
+	}
+
+	results, err := d.queries.GetTestRunResultCounts(ctx, dbsqlc.GetTestRunResultCountsParams{OrgID: contextOrgID(ctx), ProjectIds: projectIDs})
+	if err != nil {
+		d.logger.Error("failed to get test run results", "error", err)
+		return nil, fmt.Errorf("failed to get test run results: %w", err)
+	}
+
+	var passRate, failRate float64
+	if executed := results.PassedCount + results.FailedCount; executed > 0 {
+		passRate = float64(results.PassedCount) / float64(executed)
+		failRate = float64(results.FailedCount) / float64(executed)
+	}
+
+	subProjectIDs := make([]int64, 0, len(projectIDs))
+	for _, id := range projectIDs {
+		if int64(id) != projectID {
+			subProjectIDs = append(subProjectIDs, int64(id))
+		}
+	}
+
+	return &schema.ProjectRollupResponse{
+		DashboardSummaryResponse: *summary,
+		ProjectID:                projectID,
+		SubProjectIDs:            subProjectIDs,
+		OpenTestPlanCount:        statusRatio.Open,
+		PassedCount:              results.PassedCount,
+		FailedCount:              results.FailedCount,
+		PendingCount:             results.PendingCount,
+		PassRate:                 passRate,
+		FailRate:                 failRate,
+	}, nil
+}
+
+// summary computes the dashboard metrics of the org of the context, limited
+// to the given projects unless projectIDs is nil
+func (d *dashboardServiceImpl) summary(ctx context.Context, projectIDs []int32) (*schema.DashboardSummaryResponse, error) {
+	orgID := contextOrgID(ctx)
+	projectCount, err := d.queries.GetProjectCount(ctx, dbsqlc.GetProjectCountParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to get project count", "error", err)
 		return nil, fmt.Errorf("failed to get project count: %w", err)
 	}
 
-	testerCount, err := d.queries.GetTesterCount(ctx)
+	testerCount, err := d.queries.GetTesterCount(ctx, dbsqlc.GetTesterCountParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to get tester count", "error", err)
 		return nil, fmt.Errorf("failed to get tester count: %w", err)
 	}
 
-	testCaseCount, err := d.queries.GetTestCaseCount(ctx)
+	testCaseCount, err := d.queries.GetTestCaseCount(ctx, dbsqlc.GetTestCaseCountParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to get test case count", "error", err)
 		return nil, fmt.Errorf("failed to get test case count: %w", err)
 	}
 
-	testPlanCount, err := d.queries.GetTestPlanCount(ctx)
+	testPlanCount, err := d.queries.GetTestPlanCount(ctx, dbsqlc.GetTestPlanCountParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to get test plan count", "error", err)
 		return nil, fmt.Errorf("failed to get test plan count: %w", err)
 	}
 
-	statusRatio, err := d.queries.GetTestPlanStatusRatio(ctx)
+	statusRatio, err := d.queries.GetTestPlanStatusRatio(ctx, dbsqlc.GetTestPlanStatusRatioParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to get test plan status ratio", "error", err)
 		return nil, fmt.Errorf("failed to get test plan status ratio: %w", err)
 	}
 
-	recentProjects, err := d.queries.GetRecentProjects(ctx)
+	recentProjects, err := d.queries.GetRecentProjects(ctx, dbsqlc.GetRecentProjectsParams{OrgID: orgID, ProjectIds: projectIDs})
 	if err != nil {
 		d.logger.Error("failed to gete recent projects", "error", err)
 		return nil, fmt.Errorf("failed to get recent projects: %w", err)
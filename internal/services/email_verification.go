@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+)
+
+var ErrAccountNotVerified = errors.New("email address of the account has not been verified")
+var ErrInvalidVerificationToken = errors.New("email verification link is invalid or has expired")
+
+const (
+	emailVerificationPurpose = "verify-email"
+	emailVerificationTTL     = 48 * time.Hour
+)
+
+// emailVerificationKey derives the key used to sign verification links so
+// that they can never be accepted as access tokens
+func (a *authServiceImpl) emailVerificationKey() []byte {
+	return []byte(a.authConfig.JwtSecretKey + ":" + emailVerificationPurpose)
+}
+
+// generateEmailVerificationToken creates a signed token bound to the user and
+// their current email so the link stops working if the email changes
+func (a *authServiceImpl) generateEmailVerificationToken(userID int32, email string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": emailVerificationPurpose,
+		"sub":     fmt.Sprint(userID),
+		"email":   email,
+		"exp":     expiresAt.Unix(),
+	})
+	return token.SignedString(a.emailVerificationKey())
+}
+
+func (a *authServiceImpl) parseEmailVerificationToken(tokenStr string) (int32, string, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidVerificationToken
+		}
+		return a.emailVerificationKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != emailVerificationPurpose {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	email, _ := claims["email"].(string)
+	subject, _ := claims["sub"].(string)
+	var userID int32
+	if _, err := fmt.Sscan(subject, &userID); err != nil || email == "" {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	return userID, email, nil
+}
+
+func (a *authServiceImpl) sendVerificationEmail(userID int32, email string) error {
+	expiresAt := time.Now().Add(emailVerificationTTL)
+	token, err := a.generateEmailVerificationToken(userID, email, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	data := struct {
+		BaseURL   string
+		Token     string
+		ExpiresAt string
+	}{
+		BaseURL:   a.serverConfig.BaseURL(),
+		Token:     token,
+		ExpiresAt: expiresAt.Format("Jan 2, 2006 15:04 MST"),
+	}
+
+	err = sendTemplatedEmail(a.smtpCfg, email, "Verify your Qatarina account", "internal/templates/verify_email.html", data)
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+func (a *authServiceImpl) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := a.parseEmailVerificationToken(token)
+	if err != nil {
+		return err
+	}
+
+	affected, err := a.queries.ConfirmUserEmail(ctx, dbsqlc.ConfirmUserEmailParams{
+		ID:    userID,
+		Email: email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to confirm email: %w", err)
+	}
+	if affected == 0 {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+func (a *authServiceImpl) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := a.queries.FindUserLoginByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// do not reveal whether an account exists for the email
+			return nil
+		}
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	if user.IsVerified.Bool {
+		return nil
+	}
+	return a.sendVerificationEmail(user.ID, user.Email)
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerificationToken(t *testing.T) {
+	a := &authServiceImpl{authConfig: &config.AuthConfiguration{JwtSecretKey: "secret"}}
+
+	token, err := a.generateEmailVerificationToken(42, "user@example.com", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	userID, email, err := a.parseEmailVerificationToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(42), userID)
+	assert.Equal(t, "user@example.com", email)
+
+	// verification links must not be usable as access tokens
+	_, err = jwt.Parse(token, func(t *jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	assert.Error(t, err)
+
+	expired, err := a.generateEmailVerificationToken(42, "user@example.com", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	_, _, err = a.parseEmailVerificationToken(expired)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -32,6 +34,9 @@ func NewEnvironmentService(db *dbsqlc.Queries) EnvironmentService {
 }
 
 func (s *environmentServiceImpl) FindByProjectID(ctx context.Context, projectID int64) (*schema.EnvironmentListResponse, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
 	envs, err := s.queries.ListEnvironmentsByProject(ctx, common.NewNullInt32(int32(projectID)))
 	if err != nil {
 		return nil, err
@@ -55,6 +60,12 @@ func (s *environmentServiceImpl) FindByProjectID(ctx context.Context, projectID
 func (s *environmentServiceImpl) FindByID(ctx context.Context, envID int64) (*schema.EnvironmentResponse, error) {
 	env, err := s.queries.GetEnvironment(ctx, int32(envID))
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err := ensureProjectInOrg(ctx, s.queries, int64(env.ProjectID.Int32)); err != nil {
 		return nil, err
 	}
 
@@ -71,6 +82,9 @@ func (s *environmentServiceImpl) FindByID(ctx context.Context, envID int64) (*sc
 }
 
 func (s *environmentServiceImpl) Create(ctx context.Context, projectID int64, req *schema.EnvironmentRequest) (*schema.EnvironmentResponse, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
 	policy := bluemonday.StrictPolicy()
 
 	name := policy.Sanitize(strings.TrimSpace(req.Name))
@@ -106,6 +120,12 @@ func (s *environmentServiceImpl) Create(ctx context.Context, projectID int64, re
 }
 
 func (s *environmentServiceImpl) Update(ctx context.Context, req *schema.UpdateEnvironmentRequest) (*schema.EnvironmentResponse, error) {
+	if _, err := s.FindByID(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	if err := ensureProjectInOrg(ctx, s.queries, req.ProjectID); err != nil {
+		return nil, err
+	}
 	policy := bluemonday.StrictPolicy()
 
 	name := policy.Sanitize(strings.TrimSpace(req.Name))
@@ -134,6 +154,9 @@ func (s *environmentServiceImpl) Update(ctx context.Context, req *schema.UpdateE
 }
 
 func (s *environmentServiceImpl) Delete(ctx context.Context, projectID, environmentID int64) error {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return err
+	}
 	err := s.queries.DeleteEnvironment(ctx, dbsqlc.DeleteEnvironmentParams{
 		ID:        int32(environmentID),
 		ProjectID: common.NewNullInt32(int32(projectID)),
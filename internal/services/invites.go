@@ -0,0 +1,597 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+)
+
+var ErrInvalidInvite = errors.New("invitation is invalid, has expired or was already used")
+var ErrInviteNotPending = errors.New("invitation was already accepted or revoked")
+var ErrInvalidInviteRole = errors.New("role is not valid for the invitation")
+var ErrInviteTargetRequired = errors.New("invitation must be for either an org or a project")
+var ErrInviteNameRequired = errors.New("first and last name are required to create the account")
+
+// inviteTTL is how long an invitation link stays valid
+const inviteTTL = 7 * 24 * time.Hour
+
+// Audit log actions
+const (
+	AuditInviteCreated  = "invite.created"
+	AuditInviteRevoked  = "invite.revoked"
+	AuditInviteAccepted = "invite.accepted"
+)
+
+type InviteService interface {
+	// Create invites someone by email to an org or a project and emails them the link,
+	// pending invitations for the same email and org or project are replaced
+	Create(ctx context.Context, inviterID int64, request *schema.CreateInviteRequest) (*schema.Invite, error)
+	// ListSentBy lists the invitations the user sent
+	ListSentBy(ctx context.Context, userID int64) (*schema.InviteListResponse, error)
+	// ListByProject lists the invitations to a project
+	ListByProject(ctx context.Context, projectID int64) (*schema.InviteListResponse, error)
+	// Revoke invalidates a pending invitation
+	Revoke(ctx context.Context, actorID, inviteID int64) error
+	// Resend emails a new link for a pending invitation, the previous link stops working
+	Resend(ctx context.Context, actorID, inviteID int64) (*schema.Invite, error)
+	// Lookup describes the invitation of a link so the user knows what they are accepting
+	Lookup(ctx context.Context, token string) (*schema.InvitePreview, error)
+	// Accept joins the invited user to the org or project, creating an account when
+	// none exists for the invited email, and logs them in
+	Accept(ctx context.Context, request *schema.AcceptInviteRequest) (*schema.AcceptInviteResponse, error)
+}
+
+type inviteServiceImpl struct {
+	db                *sql.DB
+	queries           *dbsqlc.Queries
+	serverConfig      *config.HTTPServerConfiguration
+	smtpCfg           config.SMTPConfiguration
+	permissionService PermissionService
+	authService       AuthService
+	logger            logging.Logger
+}
+
+func NewInviteService(cfg *config.Config, db *sql.DB, queries *dbsqlc.Queries, permissionService PermissionService, authService AuthService, logger logging.Logger) InviteService {
+	return &inviteServiceImpl{
+		db:                db,
+		queries:           queries,
+		serverConfig:      &cfg.Server,
+		smtpCfg:           cfg.SMTP,
+		permissionService: permissionService,
+		authService:       authService,
+		logger:            logger,
+	}
+}
+
+// inviteStatus derives the status of the invitation at the given time
+func inviteStatus(invite dbsqlc.Invite, now time.Time) string {
+	switch {
+	case invite.AcceptedAt.Valid:
+		return schema.InviteStatusAccepted
+	case invite.RevokedAt.Valid:
+		return schema.InviteStatusRevoked
+	case !invite.ExpiresAt.Valid || !invite.ExpiresAt.Time.After(now):
+		return schema.InviteStatusExpired
+	}
+	return schema.InviteStatusPending
+}
+
+func newInviteResponse(invite dbsqlc.Invite) schema.Invite {
+	return schema.Invite{
+		ID:          int64(invite.ID),
+		Email:       invite.ReceiverEmail,
+		InvitedByID: int64(invite.InvitedByID.Int32),
+		InvitedBy:   invite.SenderEmail,
+		OrgID:       int64(invite.OrgID.Int32),
+		ProjectID:   int64(invite.ProjectID.Int32),
+		Role:        invite.Role,
+		Status:      inviteStatus(invite, time.Now()),
+		ExpiresAt:   common.FormatSqlDateTime(invite.ExpiresAt),
+		AcceptedAt:  common.FormatSqlDateTime(invite.AcceptedAt),
+		AcceptedBy:  int64(invite.AcceptedByID.Int32),
+		RevokedAt:   common.FormatSqlDateTime(invite.RevokedAt),
+		CreatedAt:   common.FormatSqlDateTime(invite.CreatedAt),
+	}
+}
+
+// inviteRole validates the role for the target of the invitation and fills in the default
+func inviteRole(orgID, projectID int32, role string) (string, error) {
+	switch {
+	case projectID > 0 && orgID > 0:
+		return "", ErrInviteTargetRequired
+	case projectID > 0:
+		role = cmp.Or(role, RoleEngineer)
+		if _, ok := rolePermissions[role]; !ok {
+			return "", ErrInvalidInviteRole
+		}
+	case orgID > 0:
+		role = cmp.Or(role, OrgRoleMember)
+		if !slices.Contains(orgRoles, role) {
+			return "", ErrInvalidInviteRole
+		}
+	}
+	return role, nil
+}
+
+// orgRole returns the role the user has in the org, the creator of the org is
+// treated as an owner and super admins as owners of every org
+func (s *inviteServiceImpl) orgRole(ctx context.Context, orgID int32, userID int64) (string, error) {
+	isSuperAdmin, err := s.permissionService.IsSuperAdmin(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if isSuperAdmin {
+		return OrgRoleOwner, nil
+	}
+
+	org, err := s.queries.GetOrgByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to fetch org: %w", err)
+	}
+	if int64(org.CreatedByID) == userID {
+		return OrgRoleOwner, nil
+	}
+
+	role, err := s.queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{
+		OrgID:  orgID,
+		UserID: int32(userID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to fetch org membership: %w", err)
+	}
+	return role, nil
+}
+
+// authorizeTarget checks that the user may invite people to the org or project
+// with the role, only owners can invite other owners
+func (s *inviteServiceImpl) authorizeTarget(ctx context.Context, userID int64, orgID, projectID int32, role string) error {
+	switch {
+	case projectID > 0:
+		return s.permissionService.Authorize(ctx, userID, int64(projectID), ActionManageTesters)
+	case orgID > 0:
+		actorRole, err := s.orgRole(ctx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		if actorRole == OrgRoleOwner || (actorRole == OrgRoleAdmin && role != OrgRoleOwner) {
+			return nil
+		}
+		return ErrForbidden
+	}
+
+	// an invitation without an org or project only creates an account
+	isSuperAdmin, err := s.permissionService.IsSuperAdmin(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !isSuperAdmin {
+		return ErrInviteTargetRequired
+	}
+	return nil
+}
+
+// authorizeInvite checks that the user may manage an existing invitation
+func (s *inviteServiceImpl) authorizeInvite(ctx context.Context, userID int64, invite dbsqlc.Invite) error {
+	if int64(invite.InvitedByID.Int32) == userID {
+		return nil
+	}
+	return s.authorizeTarget(ctx, userID, invite.OrgID.Int32, invite.ProjectID.Int32, invite.Role)
+}
+
+func (s *inviteServiceImpl) Create(ctx context.Context, inviterID int64, request *schema.CreateInviteRequest) (*schema.Invite, error) {
+	inviter, err := s.queries.GetUser(ctx, int32(inviterID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch inviter: %w", err)
+	}
+
+	orgID, projectID := request.OrgID, request.ProjectID
+	if orgID == 0 && projectID == 0 && inviter.OrgID.Valid {
+		orgID = inviter.OrgID.Int32
+	}
+	role, err := inviteRole(orgID, projectID, request.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeTarget(ctx, inviterID, orgID, projectID, role); err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(request.Email)
+	err = s.queries.RevokePendingInvites(ctx, dbsqlc.RevokePendingInvitesParams{
+		ReceiverEmail: email,
+		OrgID:         common.NewNullInt32(orgID),
+		ProjectID:     common.NewNullInt32(projectID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke previous invitations: %w", err)
+	}
+
+	token, tokenHash, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	expiresAt := time.Now().Add(inviteTTL)
+
+	invite, err := s.queries.CreateInvite(ctx, dbsqlc.CreateInviteParams{
+		SenderEmail:   inviter.Email,
+		ReceiverEmail: email,
+		Token:         tokenHash,
+		ExpiresAt:     common.NewNullTime(expiresAt),
+		InvitedByID:   common.NewNullInt32(inviter.ID),
+		OrgID:         common.NewNullInt32(orgID),
+		ProjectID:     common.NewNullInt32(projectID),
+		Role:          role,
+	})
+	if err != nil {
+		s.logger.Error("invite-service", "failed to create invite", "error", err)
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		ActorID:     inviterID,
+		Action:      AuditInviteCreated,
+		SubjectType: "invite",
+		SubjectID:   fmt.Sprint(invite.ID),
+		Details:     fmt.Sprintf("invited %s as %s", email, role),
+	})
+
+	if err := s.sendInviteEmail(ctx, invite, token, expiresAt); err != nil {
+		return nil, err
+	}
+
+	res := newInviteResponse(invite)
+	return &res, nil
+}
+
+// sendInviteEmail emails the invitation link, the raw token only exists here
+// as the invite stores its hash
+func (s *inviteServiceImpl) sendInviteEmail(ctx context.Context, invite dbsqlc.Invite, token string, expiresAt time.Time) error {
+	target := "Qatarina"
+	if invite.ProjectID.Valid {
+		project, err := s.queries.GetProject(ctx, invite.ProjectID.Int32)
+		if err != nil {
+			return fmt.Errorf("failed to fetch project: %w", err)
+		}
+		target = "the " + project.Title + " project on Qatarina"
+	} else if invite.OrgID.Valid {
+		org, err := s.queries.GetOrgByID(ctx, invite.OrgID.Int32)
+		if err != nil {
+			return fmt.Errorf("failed to fetch org: %w", err)
+		}
+		target = org.Name + " on Qatarina"
+	}
+
+	data := struct {
+		BaseURL   string
+		Token     string
+		ExpiresAt string
+		InvitedBy string
+		Target    string
+		Role      string
+	}{
+		BaseURL:   s.serverConfig.BaseURL(),
+		Token:     token,
+		ExpiresAt: expiresAt.Format("Jan 2, 2006 15:04 MST"),
+		InvitedBy: invite.SenderEmail,
+		Target:    target,
+		Role:      invite.Role,
+	}
+
+	err := sendTemplatedEmail(s.smtpCfg, invite.ReceiverEmail, "Qatarina invitation", "internal/templates/invite_email.html", data)
+	if err != nil {
+		s.logger.Error("invite-service", "failed to send invite email", "error", err)
+		return fmt.Errorf("failed to send the invite to email: %w", err)
+	}
+	return nil
+}
+
+func newInviteListResponse(invites []dbsqlc.Invite) *schema.InviteListResponse {
+	response := &schema.InviteListResponse{Invites: make([]schema.Invite, 0, len(invites))}
+	for _, invite := range invites {
+		response.Invites = append(response.Invites, newInviteResponse(invite))
+	}
+	return response
+}
+
+func (s *inviteServiceImpl) ListSentBy(ctx context.Context, userID int64) (*schema.InviteListResponse, error) {
+	invites, err := s.queries.ListInvitesBySender(ctx, common.NewNullInt32(int32(userID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch invites: %w", err)
+	}
+	return newInviteListResponse(invites), nil
+}
+
+func (s *inviteServiceImpl) ListByProject(ctx context.Context, projectID int64) (*schema.InviteListResponse, error) {
+	invites, err := s.queries.ListProjectInvites(ctx, common.NewNullInt32(int32(projectID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project invites: %w", err)
+	}
+	return newInviteListResponse(invites), nil
+}
+
+func (s *inviteServiceImpl) getInvite(ctx context.Context, actorID, inviteID int64) (dbsqlc.Invite, error) {
+	invite, err := s.queries.GetInvite(ctx, int32(inviteID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invite, ErrNotFound
+		}
+		return invite, fmt.Errorf("failed to fetch invite: %w", err)
+	}
+	if err := s.authorizeInvite(ctx, actorID, invite); err != nil {
+		if errors.Is(err, ErrForbidden) || errors.Is(err, ErrInviteTargetRequired) {
+			// do not reveal invitations the user cannot manage
+			return invite, ErrNotFound
+		}
+		return invite, err
+	}
+	return invite, nil
+}
+
+func (s *inviteServiceImpl) Revoke(ctx context.Context, actorID, inviteID int64) error {
+	invite, err := s.getInvite(ctx, actorID, inviteID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := s.queries.RevokeInvite(ctx, invite.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite: %w", err)
+	}
+	if affected == 0 {
+		return ErrInviteNotPending
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditInviteRevoked,
+		SubjectType: "invite",
+		SubjectID:   fmt.Sprint(invite.ID),
+	})
+	return nil
+}
+
+func (s *inviteServiceImpl) Resend(ctx context.Context, actorID, inviteID int64) (*schema.Invite, error) {
+	invite, err := s.getInvite(ctx, actorID, inviteID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, tokenHash, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	expiresAt := time.Now().Add(inviteTTL)
+
+	affected, err := s.queries.RenewInvite(ctx, dbsqlc.RenewInviteParams{
+		ID:        invite.ID,
+		Token:     tokenHash,
+		ExpiresAt: common.NewNullTime(expiresAt),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew invite: %w", err)
+	}
+	if affected == 0 {
+		return nil, ErrInviteNotPending
+	}
+	invite.Token = tokenHash
+	invite.ExpiresAt = common.NewNullTime(expiresAt)
+
+	if err := s.sendInviteEmail(ctx, invite, token, expiresAt); err != nil {
+		return nil, err
+	}
+
+	res := newInviteResponse(invite)
+	return &res, nil
+}
+
+// pendingInvite finds the invitation of a link, only pending invitations can be used
+func (s *inviteServiceImpl) pendingInvite(ctx context.Context, token string) (dbsqlc.Invite, error) {
+	invite, err := s.queries.GetInviteByToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invite, ErrInvalidInvite
+		}
+		return invite, fmt.Errorf("failed to fetch invite: %w", err)
+	}
+	if inviteStatus(invite, time.Now()) != schema.InviteStatusPending {
+		return invite, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+func (s *inviteServiceImpl) Lookup(ctx context.Context, token string) (*schema.InvitePreview, error) {
+	invite, err := s.pendingInvite(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &schema.InvitePreview{
+		Email:     invite.ReceiverEmail,
+		InvitedBy: invite.SenderEmail,
+		Role:      invite.Role,
+		ExpiresAt: common.FormatSqlDateTime(invite.ExpiresAt),
+	}
+	if invite.OrgID.Valid {
+		org, err := s.queries.GetOrgByID(ctx, invite.OrgID.Int32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch org: %w", err)
+		}
+		preview.OrgName = org.Name
+	}
+	if invite.ProjectID.Valid {
+		project, err := s.queries.GetProject(ctx, invite.ProjectID.Int32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch project: %w", err)
+		}
+		preview.Project = project.Title
+	}
+
+	_, err = s.queries.FindUserLoginByEmail(ctx, invite.ReceiverEmail)
+	switch {
+	case err == nil:
+		preview.AccountExists = true
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return preview, nil
+}
+
+func (s *inviteServiceImpl) Accept(ctx context.Context, request *schema.AcceptInviteRequest) (*schema.AcceptInviteResponse, error) {
+	invite, err := s.pendingInvite(ctx, request.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	loginRequest := &schema.LoginRequest{
+		Email:     invite.ReceiverEmail,
+		Password:  request.Password,
+		UserAgent: request.UserAgent,
+		IPAddress: request.IPAddress,
+	}
+	res := &schema.AcceptInviteResponse{
+		Email:     invite.ReceiverEmail,
+		OrgID:     int64(invite.OrgID.Int32),
+		ProjectID: int64(invite.ProjectID.Int32),
+		Role:      invite.Role,
+	}
+
+	existing, err := s.queries.FindUserLoginByEmail(ctx, invite.ReceiverEmail)
+	switch {
+	case err == nil:
+		// the link was sent to the address so it proves the user owns it
+		if !existing.IsVerified.Bool {
+			if _, err := s.queries.ConfirmUserEmail(ctx, dbsqlc.ConfirmUserEmailParams{ID: existing.ID, Email: existing.Email}); err != nil {
+				return nil, fmt.Errorf("failed to confirm email: %w", err)
+			}
+		}
+		// an existing account has to prove it is theirs with a regular login
+		// before it is joined, the login may still ask for a second factor
+		res.Login, err = s.authService.SignIn(loginRequest)
+		if err != nil {
+			return nil, err
+		}
+		res.UserID = int64(existing.ID)
+	case errors.Is(err, sql.ErrNoRows):
+		if request.FirstName == "" || request.LastName == "" {
+			return nil, ErrInviteNameRequired
+		}
+		res.Created = true
+	default:
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := dbsqlc.New(sqlTx)
+
+	if res.Created {
+		userID, err := s.createInvitedUser(ctx, tx, invite, request)
+		if err != nil {
+			return nil, err
+		}
+		res.UserID = int64(userID)
+	}
+
+	affected, err := tx.AcceptInvite(ctx, dbsqlc.AcceptInviteParams{
+		ID:           invite.ID,
+		AcceptedByID: common.NewNullInt32(int32(res.UserID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invite: %w", err)
+	}
+	if affected == 0 {
+		// accepted, revoked or renewed since it was looked up
+		return nil, ErrInvalidInvite
+	}
+
+	if invite.OrgID.Valid {
+		if err := ensureOrgMember(ctx, tx, invite.OrgID.Int32, int32(res.UserID), invite.Role); err != nil {
+			return nil, fmt.Errorf("failed to add org member: %w", err)
+		}
+	}
+	if invite.ProjectID.Valid {
+		// projects are only visible inside their org so the tester joins it too
+		projectOrgID, err := tx.GetProjectOrgID(ctx, invite.ProjectID.Int32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch project org: %w", err)
+		}
+		if err := ensureOrgMember(ctx, tx, projectOrgID, int32(res.UserID), OrgRoleMember); err != nil {
+			return nil, fmt.Errorf("failed to add org member: %w", err)
+		}
+		err = tx.UpsertProjectTester(ctx, dbsqlc.UpsertProjectTesterParams{
+			ProjectID: invite.ProjectID.Int32,
+			UserID:    int32(res.UserID),
+			Role:      invite.Role,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add project tester: %w", err)
+		}
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit invite acceptance: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		ActorID:     res.UserID,
+		Action:      AuditInviteAccepted,
+		SubjectType: "invite",
+		SubjectID:   fmt.Sprint(invite.ID),
+		IPAddress:   request.IPAddress,
+	})
+
+	if res.Created {
+		res.Login, err = s.authService.SignIn(loginRequest)
+		if err != nil {
+			// the account exists now, the user can still log in normally
+			s.logger.Error("invite-service", "failed to log in invited user", "user_id", res.UserID, "error", err)
+		}
+	}
+	return res, nil
+}
+
+func (s *inviteServiceImpl) createInvitedUser(ctx context.Context, tx *dbsqlc.Queries, invite dbsqlc.Invite, request *schema.AcceptInviteRequest) (int32, error) {
+	now := time.Now()
+	userID, err := tx.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:    request.FirstName,
+		LastName:     request.LastName,
+		DisplayName:  common.NullString(cmp.Or(request.DisplayName, request.FirstName+" "+request.LastName)),
+		Email:        invite.ReceiverEmail,
+		Password:     common.MustHashPassword(request.Password),
+		OrgID:        invite.OrgID,
+		IsActivated:  sql.NullBool{Bool: true, Valid: true},
+		IsReviewed:   sql.NullBool{Bool: false, Valid: true},
+		IsSuperAdmin: sql.NullBool{Bool: false, Valid: true},
+		// the link was sent to the address so it is verified
+		IsVerified:       sql.NullBool{Bool: true, Valid: true},
+		EmailConfirmedAt: common.NewNullTime(now),
+		CreatedAt:        common.NewNullTime(now),
+		UpdatedAt:        common.NewNullTime(now),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+	s.logger.Info("invite-service", "created account from invitation", "user_id", userID, "invite_id", invite.ID)
+	return userID, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteStatus(t *testing.T) {
+	now := time.Now()
+	pending := dbsqlc.Invite{ExpiresAt: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}
+	assert.Equal(t, schema.InviteStatusPending, inviteStatus(pending, now))
+	assert.Equal(t, schema.InviteStatusExpired, inviteStatus(pending, now.Add(2*time.Hour)))
+
+	accepted := pending
+	accepted.AcceptedAt = sql.NullTime{Time: now, Valid: true}
+	assert.Equal(t, schema.InviteStatusAccepted, inviteStatus(accepted, now))
+
+	revoked := pending
+	revoked.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	assert.Equal(t, schema.InviteStatusRevoked, inviteStatus(revoked, now))
+
+	assert.Equal(t, schema.InviteStatusExpired, inviteStatus(dbsqlc.Invite{}, now))
+}
+
+func TestInviteRole(t *testing.T) {
+	role, err := inviteRole(0, 7, "")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleEngineer, role)
+
+	role, err = inviteRole(3, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, OrgRoleMember, role)
+
+	_, err = inviteRole(3, 0, RoleLead)
+	assert.ErrorIs(t, err, ErrInvalidInviteRole)
+
+	_, err = inviteRole(0, 7, OrgRoleOwner)
+	assert.ErrorIs(t, err, ErrInvalidInviteRole)
+
+	_, err = inviteRole(3, 7, "")
+	assert.ErrorIs(t, err, ErrInviteTargetRequired)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts")
+
+const (
+	throttleScopeAccount = "account"
+	throttleScopeIP      = "ip"
+
+	// ipAttemptsMultiplier allows more failures per IP than per account since
+	// many users can share an address behind NAT
+	ipAttemptsMultiplier = 5
+
+	baseLockoutDuration = time.Minute
+	maxLockoutDuration  = 24 * time.Hour
+)
+
+// lockoutDuration doubles the lockout for every consecutive lockout up to a maximum
+func lockoutDuration(lockouts int32) time.Duration {
+	if lockouts >= 11 {
+		return maxLockoutDuration
+	}
+	return min(baseLockoutDuration<<lockouts, maxLockoutDuration)
+}
+
+func accountThrottleKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (a *authServiceImpl) loginThrottlingEnabled() bool {
+	return a.authConfig.MaxLoginAttempts > 0
+}
+
+// checkLoginLockout returns ErrLoginLocked when either the account or the
+// client IP is currently locked out
+func (a *authServiceImpl) checkLoginLockout(ctx context.Context, email, ipAddress string) error {
+	if !a.loginThrottlingEnabled() {
+		return nil
+	}
+
+	keys := map[string]string{throttleScopeAccount: accountThrottleKey(email)}
+	if ipAddress != "" {
+		keys[throttleScopeIP] = ipAddress
+	}
+
+	for scope, key := range keys {
+		throttle, err := a.queries.GetLoginThrottle(ctx, dbsqlc.GetLoginThrottleParams{
+			Scope:       scope,
+			ThrottleKey: key,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("failed to check login throttle: %w", err)
+		}
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(time.Now()) {
+			return fmt.Errorf("%w, try again after %s", ErrLoginLocked, throttle.LockedUntil.Time.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt against the account and the
+// client IP, locking them out once the configured maximum is reached
+func (a *authServiceImpl) recordLoginFailure(ctx context.Context, email, ipAddress string) {
+	if !a.loginThrottlingEnabled() {
+		return
+	}
+
+	a.recordThrottleFailure(ctx, throttleScopeAccount, accountThrottleKey(email), a.authConfig.MaxLoginAttempts, ipAddress)
+	if ipAddress != "" {
+		a.recordThrottleFailure(ctx, throttleScopeIP, ipAddress, a.authConfig.MaxLoginAttempts*ipAttemptsMultiplier, ipAddress)
+	}
+}
+
+func (a *authServiceImpl) recordThrottleFailure(ctx context.Context, scope, key string, maxAttempts int, ipAddress string) {
+	throttle, err := a.queries.RecordLoginFailure(ctx, dbsqlc.RecordLoginFailureParams{
+		Scope:       scope,
+		ThrottleKey: key,
+	})
+	if err != nil {
+		a.logger.Error("auth-service", "failed to record failed login attempt", "scope", scope, "error", err)
+		return
+	}
+
+	if int(throttle.FailedAttempts) < maxAttempts {
+		return
+	}
+
+	lockedUntil := time.Now().Add(lockoutDuration(throttle.Lockouts))
+	err = a.queries.LockLoginThrottle(ctx, dbsqlc.LockLoginThrottleParams{
+		ID:          throttle.ID,
+		LockedUntil: common.NewNullTime(lockedUntil),
+	})
+	if err != nil {
+		a.logger.Error("auth-service", "failed to lock out login", "scope", scope, "error", err)
+		return
+	}
+
+	recordAuditEvent(ctx, a.queries, a.logger, AuditEvent{
+		Action:      AuditLoginLockout,
+		SubjectType: scope,
+		SubjectID:   key,
+		IPAddress:   ipAddress,
+		Details:     fmt.Sprintf("locked until %s after %d failed attempts", lockedUntil.Format(time.RFC3339), throttle.FailedAttempts),
+	})
+}
+
+// clearLoginFailures resets the account throttle after a successful login
+func (a *authServiceImpl) clearLoginFailures(ctx context.Context, email string) {
+	if !a.loginThrottlingEnabled() {
+		return
+	}
+
+	_, err := a.queries.ResetLoginThrottle(ctx, dbsqlc.ResetLoginThrottleParams{
+		Scope:       throttleScopeAccount,
+		ThrottleKey: accountThrottleKey(email),
+	})
+	if err != nil {
+		a.logger.Error("auth-service", "failed to reset login throttle", "error", err)
+	}
+}
+
+func (a *authServiceImpl) UnlockAccount(ctx context.Context, actorID, userID int64) error {
+	user, err := a.queries.GetUser(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	_, err = a.queries.ResetLoginThrottle(ctx, dbsqlc.ResetLoginThrottleParams{
+		Scope:       throttleScopeAccount,
+		ThrottleKey: accountThrottleKey(user.Email),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	recordAuditEvent(ctx, a.queries, a.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditLoginUnlock,
+		SubjectType: throttleScopeAccount,
+		SubjectID:   accountThrottleKey(user.Email),
+	})
+	return nil
+}
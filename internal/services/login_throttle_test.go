@@ -0,0 +1,16 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Minute, lockoutDuration(0))
+	assert.Equal(t, 2*time.Minute, lockoutDuration(1))
+	assert.Equal(t, 8*time.Minute, lockoutDuration(3))
+	assert.Equal(t, 24*time.Hour, lockoutDuration(11))
+	assert.Equal(t, 24*time.Hour, lockoutDuration(100))
+}
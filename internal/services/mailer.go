@@ -0,0 +1,41 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/smtp"
+
+	"github.com/golang-malawi/qatarina/internal/config"
+)
+
+// sendTemplatedEmail renders the given html template file with data and sends
+// the result to the receiver using the configured SMTP server
+func sendTemplatedEmail(smtpCfg config.SMTPConfiguration, receiverEmail, subject, templateFile string, data any) error {
+	t, err := template.ParseFiles(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to load email template: %v", err)
+	}
+
+	var body bytes.Buffer
+	if err := t.Execute(&body, data); err != nil {
+		return fmt.Errorf("failed to excecute email template: %v", err)
+	}
+
+	msg := []byte(fmt.Sprintf(
+		"To: %s\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: text/html; charset=\"UTF-8\"\r\n"+
+			"Subject: %s\r\n\r\n"+
+			"%s", receiverEmail, subject, body.String()))
+
+	addr := fmt.Sprintf("%s:%d", smtpCfg.Host, smtpCfg.Port)
+
+	auth := smtp.PlainAuth("", smtpCfg.Username, smtpCfg.Password, smtpCfg.Host)
+
+	err = smtp.SendMail(addr, auth, smtpCfg.From, []string{receiverEmail}, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
@@ -10,6 +12,7 @@ import (
 
 type ModuleService interface {
 	Create(
+		context.Context,
 		*schema.CreateProjectModuleRequest,
 	) (bool, error)
 	// GetOne retrieves one module in the context
@@ -35,12 +38,16 @@ type moduleServiceImpl struct {
 }
 
 func (m *moduleServiceImpl) Create(
+	ctx context.Context,
 	request *schema.CreateProjectModuleRequest,
 ) (bool, error) {
 	if request.ProjectID == 0 || request.Name == "" || request.Code == "" || request.Priority == 0 {
 		return false, fmt.Errorf("empty field(s) found")
 	}
-	_, err := m.db.CreateProjectModules(context.Background(), dbsqlc.CreateProjectModulesParams{
+	if err := ensureProjectInOrg(ctx, m.db, int64(request.ProjectID)); err != nil {
+		return false, err
+	}
+	_, err := m.db.CreateProjectModules(ctx, dbsqlc.CreateProjectModulesParams{
 		ProjectID:   request.ProjectID,
 		Name:        request.Name,
 		Code:        request.Code,
@@ -59,7 +66,7 @@ func (m *moduleServiceImpl) Create(
 
 // Implement the Get method to retrive modules from the table
 func (m *moduleServiceImpl) GetOne(ctx context.Context, id int32) (dbsqlc.Module, error) {
-	module, err := m.db.GetOneModule(ctx, id)
+	module, err := m.getModule(ctx, id)
 	if err != nil {
 		return dbsqlc.Module{}, err
 	}
@@ -68,7 +75,7 @@ func (m *moduleServiceImpl) GetOne(ctx context.Context, id int32) (dbsqlc.Module
 }
 
 func (m *moduleServiceImpl) GetAll(ctx context.Context) ([]dbsqlc.Module, error) {
-	if modules, err := m.db.GetAllModules(context.Background()); err != nil {
+	if modules, err := m.db.GetAllModules(ctx, contextOrgID(ctx)); err != nil {
 		return nil, err
 	} else {
 		return modules, nil
@@ -77,6 +84,9 @@ func (m *moduleServiceImpl) GetAll(ctx context.Context) ([]dbsqlc.Module, error)
 
 // Implement the Update to change field in table
 func (m *moduleServiceImpl) Update(ctx context.Context, request schema.UpdateProjectModuleRequest) (bool, error) {
+	if _, err := m.getModule(ctx, request.ID); err != nil {
+		return false, err
+	}
 	err := m.db.UpdateProjectModule(ctx, dbsqlc.UpdateProjectModuleParams{
 		ID:          request.ID,
 		Name:        request.Name,
@@ -95,6 +105,9 @@ func (m *moduleServiceImpl) Update(ctx context.Context, request schema.UpdatePro
 
 // Implement the Delete to remove a module in the table
 func (m *moduleServiceImpl) Delete(ctx context.Context, id int32) error {
+	if _, err := m.getModule(ctx, id); err != nil {
+		return err
+	}
 	_, err := m.db.DeleteProjectModule(ctx, id)
 
 	if err != nil {
@@ -105,6 +118,9 @@ func (m *moduleServiceImpl) Delete(ctx context.Context, id int32) error {
 }
 
 func (m *moduleServiceImpl) GetProjectModules(ctx context.Context, projectID int32) ([]dbsqlc.Module, error) {
+	if err := ensureProjectInOrg(ctx, m.db, int64(projectID)); err != nil {
+		return nil, err
+	}
 	modules, err := m.db.GetProjectModules(ctx, projectID)
 
 	if err != nil {
@@ -114,3 +130,18 @@ func (m *moduleServiceImpl) GetProjectModules(ctx context.Context, projectID int
 	return modules, nil
 
 }
+
+// getModule fetches a module of a project in the org of the context
+func (m *moduleServiceImpl) getModule(ctx context.Context, id int32) (dbsqlc.Module, error) {
+	module, err := m.db.GetOneModule(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbsqlc.Module{}, ErrNotFound
+		}
+		return dbsqlc.Module{}, err
+	}
+	if err := ensureProjectInOrg(ctx, m.db, int64(module.ProjectID)); err != nil {
+		return dbsqlc.Module{}, err
+	}
+	return module, nil
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+)
+
+var ErrReassignToSameUser = errors.New("work cannot be reassigned to the user being offboarded")
+
+// Audit log actions
+const (
+	AuditUserDeactivate = "user.deactivate"
+	AuditUserReactivate = "user.reactivate"
+	AuditUserOffboard   = "user.offboard"
+)
+
+// ensureNotLastSuperAdmin keeps at least one active super admin around so
+// nobody is left to administer the system
+func ensureNotLastSuperAdmin(ctx context.Context, queries *dbsqlc.Queries, user *dbsqlc.User) error {
+	if !user.IsSuperAdmin.Bool || !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return nil
+	}
+	count, err := queries.CountSuperAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count super admins: %w", err)
+	}
+	if count <= 1 {
+		return ErrLastSuperAdmin
+	}
+	return nil
+}
+
+// endSessions revokes the refresh tokens of the user, access tokens that were
+// already issued stay valid until they expire
+func endSessions(ctx context.Context, queries *dbsqlc.Queries, userID int32) error {
+	if _, err := queries.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// deactivateUser blocks the user from logging in, refreshing tokens and using
+// API tokens. The account is kept so the work they authored stays attributed to them
+func deactivateUser(ctx context.Context, queries *dbsqlc.Queries, user *dbsqlc.User) error {
+	if err := ensureNotLastSuperAdmin(ctx, queries, user); err != nil {
+		return err
+	}
+
+	affected, err := queries.DeactivateUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+	if affected == 0 {
+		return ErrUserNotActive
+	}
+	return endSessions(ctx, queries, user.ID)
+}
+
+// activeUser fetches a user that can be assigned work
+func activeUser(ctx context.Context, queries *dbsqlc.Queries, userID int64) (*dbsqlc.User, error) {
+	user, err := queries.GetUser(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %d: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return nil, fmt.Errorf("user %d: %w", userID, ErrUserNotActive)
+	}
+	return &user, nil
+}
+
+func (u *userServiceImpl) Deactivate(ctx context.Context, actorID, userID int64) error {
+	if actorID != userID {
+		actor, err := u.queries.GetUser(ctx, int32(actorID))
+		if err != nil {
+			return fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if !actor.IsSuperAdmin.Bool {
+			return ErrForbidden
+		}
+	}
+
+	user, err := u.queries.GetUser(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %d: %w", userID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if err := deactivateUser(ctx, u.queries, &user); err != nil {
+		return err
+	}
+
+	recordAuditEvent(ctx, u.queries, u.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditUserDeactivate,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(user.ID),
+	})
+	return nil
+}
+
+func (u *userServiceImpl) Reactivate(ctx context.Context, actorID, userID int64) error {
+	affected, err := u.queries.ReactivateUser(ctx, int32(userID))
+	if err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("deactivated user %d: %w", userID, ErrNotFound)
+	}
+
+	recordAuditEvent(ctx, u.queries, u.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditUserReactivate,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(userID),
+	})
+	return nil
+}
+
+func (u *userServiceImpl) PreviewOffboarding(ctx context.Context, userID, reassignToID int64) (*schema.OffboardingPreview, error) {
+	return previewOffboarding(ctx, u.queries, userID, reassignToID)
+}
+
+// previewOffboarding collects the open work of the user, deactivated users
+// can be offboarded too so work left behind can still be handed over
+func previewOffboarding(ctx context.Context, queries *dbsqlc.Queries, userID, reassignToID int64) (*schema.OffboardingPreview, error) {
+	if userID == reassignToID {
+		return nil, ErrReassignToSameUser
+	}
+	if _, err := queries.GetUser(ctx, int32(userID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %d: %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if _, err := activeUser(ctx, queries, reassignToID); err != nil {
+		return nil, err
+	}
+
+	preview := &schema.OffboardingPreview{
+		UserID:          userID,
+		ReassignToID:    reassignToID,
+		TestPlans:       make([]schema.OffboardingTestPlan, 0),
+		PlanCases:       make([]schema.OffboardingPlanCase, 0),
+		TestRuns:        make([]schema.OffboardingTestRun, 0),
+		GrantedProjects: make([]schema.OffboardingProjectAccess, 0),
+	}
+	// projects in the order they are first seen
+	var projectIDs []int32
+	seen := map[int32]bool{}
+	addProject := func(projectID int32) {
+		if !seen[projectID] {
+			seen[projectID] = true
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+
+	plans, err := queries.ListOpenTestPlansAssignedTo(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch test plans: %w", err)
+	}
+	for _, plan := range plans {
+		addProject(plan.ProjectID)
+		preview.TestPlans = append(preview.TestPlans, schema.OffboardingTestPlan{
+			ID:          plan.ID,
+			ProjectID:   int64(plan.ProjectID),
+			Description: plan.Description.String,
+		})
+	}
+
+	planCases, err := queries.ListOpenPlanCasesAssignedTo(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch test plan cases: %w", err)
+	}
+	for _, planCase := range planCases {
+		addProject(planCase.ProjectID)
+		preview.PlanCases = append(preview.PlanCases, schema.OffboardingPlanCase{
+			TestPlanID: planCase.TestPlanID,
+			TestCaseID: planCase.TestCaseID.String(),
+			ProjectID:  int64(planCase.ProjectID),
+			Title:      planCase.Title,
+		})
+	}
+
+	runs, err := queries.ListOpenTestRunsAssignedTo(ctx, sql.NullInt32{Int32: int32(userID), Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch test runs: %w", err)
+	}
+	for _, run := range runs {
+		addProject(run.ProjectID)
+		preview.TestRuns = append(preview.TestRuns, schema.OffboardingTestRun{
+			ID:         run.ID.String(),
+			ProjectID:  int64(run.ProjectID),
+			TestPlanID: int64(run.TestPlanID.Int32),
+			Code:       run.Code,
+		})
+	}
+
+	for _, projectID := range projectIDs {
+		access, err := queries.GetProjectAccess(ctx, dbsqlc.GetProjectAccessParams{ID: projectID, UserID: int32(reassignToID)})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch project access: %w", err)
+		}
+		if access.Role != "" || access.IsSuperAdmin || access.OwnerUserID == int32(reassignToID) {
+			continue
+		}
+
+		// the new assignee takes over the role of the user whose work they receive
+		role := RoleEngineer
+		previous, err := queries.GetProjectAccess(ctx, dbsqlc.GetProjectAccessParams{ID: projectID, UserID: int32(userID)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch project access: %w", err)
+		}
+		if previous.Role != "" {
+			role = previous.Role
+		}
+		preview.GrantedProjects = append(preview.GrantedProjects, schema.OffboardingProjectAccess{
+			ProjectID: int64(projectID),
+			Role:      role,
+		})
+	}
+	return preview, nil
+}
+
+func (u *userServiceImpl) Offboard(ctx context.Context, actorID, userID int64, request *schema.OffboardUserRequest) (*schema.OffboardingResult, error) {
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	preview, err := previewOffboarding(ctx, tx, userID, request.ReassignToID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, grant := range preview.GrantedProjects {
+		err := tx.UpsertProjectTester(ctx, dbsqlc.UpsertProjectTesterParams{
+			ProjectID: int32(grant.ProjectID),
+			UserID:    int32(request.ReassignToID),
+			Role:      grant.Role,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add tester to project: %w", err)
+		}
+	}
+
+	result := &schema.OffboardingResult{
+		UserID:          userID,
+		ReassignToID:    request.ReassignToID,
+		GrantedProjects: preview.GrantedProjects,
+	}
+
+	result.TestPlans, err = tx.ReassignOpenTestPlans(ctx, dbsqlc.ReassignOpenTestPlansParams{
+		ToUserID:   int32(request.ReassignToID),
+		FromUserID: int32(userID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign test plans: %w", err)
+	}
+
+	// test cases already assigned to the new assignee in the same plan are
+	// merged, the plan case key includes the assignee
+	merged, err := tx.DeleteDuplicatePlanCaseAssignments(ctx, dbsqlc.DeleteDuplicatePlanCaseAssignmentsParams{
+		FromUserID: userID,
+		ToUserID:   request.ReassignToID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge test plan cases: %w", err)
+	}
+	moved, err := tx.ReassignOpenPlanCases(ctx, dbsqlc.ReassignOpenPlanCasesParams{
+		ToUserID:   request.ReassignToID,
+		FromUserID: userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign test plan cases: %w", err)
+	}
+	result.PlanCases = merged + moved
+
+	result.TestRuns, err = tx.ReassignOpenTestRuns(ctx, dbsqlc.ReassignOpenTestRunsParams{
+		ToUserID:   sql.NullInt32{Int32: int32(request.ReassignToID), Valid: true},
+		FromUserID: sql.NullInt32{Int32: int32(userID), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign test runs: %w", err)
+	}
+
+	if request.Deactivate {
+		user, err := tx.GetUser(ctx, int32(userID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if user.IsActivated.Bool {
+			if err := deactivateUser(ctx, tx, &user); err != nil {
+				return nil, err
+			}
+			result.Deactivated = true
+		}
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit offboarding: %w", err)
+	}
+
+	u.logger.Info("user-service", "offboarded user", "user_id", userID, "reassign_to_id", request.ReassignToID,
+		"test_plans", result.TestPlans, "plan_cases", result.PlanCases, "test_runs", result.TestRuns)
+	recordAuditEvent(ctx, u.queries, u.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditUserOffboard,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(userID),
+		Details: fmt.Sprintf("reassigned %d test plans, %d test plan cases and %d test runs to user %d",
+			result.TestPlans, result.PlanCases, result.TestRuns, request.ReassignToID),
+	})
+	if result.Deactivated {
+		recordAuditEvent(ctx, u.queries, u.logger, AuditEvent{
+			ActorID:     actorID,
+			Action:      AuditUserDeactivate,
+			SubjectType: "user",
+			SubjectID:   fmt.Sprint(userID),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/pkg/oidc"
+)
+
+var ErrSSODisabled = errors.New("single sign-on is not enabled")
+var ErrInvalidSSOState = errors.New("single sign-on login is invalid or has expired, start the login again")
+var ErrSSOLoginFailed = errors.New("identity provider did not complete the login")
+var ErrSSOEmailNotVerified = errors.New("identity provider did not return a verified email address for the account")
+
+const (
+	oidcLoginStatePurpose = "oidc-login"
+	// oidcLoginStateTTL is how long the user has to complete the login at the identity provider
+	oidcLoginStateTTL = 10 * time.Minute
+)
+
+type OIDCService interface {
+	// Enabled checks whether single sign-on is configured
+	Enabled() bool
+	// BeginLogin returns the URL of the identity provider to send the browser to,
+	// along with the signed login state that has to be presented on the callback
+	BeginLogin(ctx context.Context) (authURL string, loginState string, err error)
+	// CompleteLogin validates the response of the identity provider, provisions
+	// the user on their first login and signs them in
+	CompleteLogin(ctx context.Context, request *schema.OIDCCallbackRequest) (*schema.LoginResponse, error)
+}
+
+type oidcServiceImpl struct {
+	oidcConfig   *config.OIDCConfiguration
+	serverConfig *config.HTTPServerConfiguration
+	stateKey     []byte
+	authService  AuthService
+	db           *sql.DB
+	queries      *dbsqlc.Queries
+	logger       logging.Logger
+
+	mu     sync.Mutex
+	client *oidc.Client
+}
+
+func NewOIDCService(cfg *config.Config, db *sql.DB, queries *dbsqlc.Queries, authService AuthService, logger logging.Logger) OIDCService {
+	return &oidcServiceImpl{
+		oidcConfig:   &cfg.OIDC,
+		serverConfig: &cfg.Server,
+		stateKey:     []byte(cfg.Auth.JwtSecretKey + ":" + oidcLoginStatePurpose),
+		authService:  authService,
+		db:           db,
+		queries:      queries,
+		logger:       logger,
+	}
+}
+
+func (o *oidcServiceImpl) Enabled() bool {
+	return o.oidcConfig.Enabled && o.oidcConfig.IssuerURL != "" && o.oidcConfig.ClientID != ""
+}
+
+// getClient discovers the identity provider on first use so that the server
+// still starts when the provider is unreachable
+func (o *oidcServiceImpl) getClient(ctx context.Context) (*oidc.Client, error) {
+	if !o.Enabled() {
+		return nil, ErrSSODisabled
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.client != nil {
+		return o.client, nil
+	}
+
+	client, err := oidc.NewClient(ctx, oidc.Config{
+		IssuerURL:    o.oidcConfig.IssuerURL,
+		ClientID:     o.oidcConfig.ClientID,
+		ClientSecret: o.oidcConfig.ClientSecret,
+		RedirectURL:  o.oidcConfig.CallbackURL(o.serverConfig),
+		Scopes:       o.oidcConfig.Scopes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up identity provider: %w", err)
+	}
+	o.client = client
+	return client, nil
+}
+
+func (o *oidcServiceImpl) BeginLogin(ctx context.Context) (string, string, error) {
+	client, err := o.getClient(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := oidc.RandomString(16)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	nonce, err := oidc.RandomString(16)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	verifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+
+	loginState, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose":  oidcLoginStatePurpose,
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+		"exp":      time.Now().Add(oidcLoginStateTTL).Unix(),
+	}).SignedString(o.stateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign login state: %w", err)
+	}
+
+	return client.AuthCodeURL(state, nonce, oidc.PKCEChallenge(verifier)), loginState, nil
+}
+
+// parseLoginState checks the signed login state and returns the nonce and code verifier
+func (o *oidcServiceImpl) parseLoginState(loginState, state string) (string, string, error) {
+	token, err := jwt.Parse(loginState, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSSOState
+		}
+		return o.stateKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", ErrInvalidSSOState
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != oidcLoginStatePurpose {
+		return "", "", ErrInvalidSSOState
+	}
+	expected, _ := claims["state"].(string)
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		return "", "", ErrInvalidSSOState
+	}
+	return nonce, verifier, nil
+}
+
+func (o *oidcServiceImpl) CompleteLogin(ctx context.Context, request *schema.OIDCCallbackRequest) (*schema.LoginResponse, error) {
+	client, err := o.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, verifier, err := o.parseLoginState(request.LoginState, request.State)
+	if err != nil {
+		return nil, err
+	}
+	if request.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrSSOLoginFailed, request.Error, request.ErrorDescription)
+	}
+
+	tokens, err := client.Exchange(ctx, request.Code, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOLoginFailed, err)
+	}
+	idToken, err := client.VerifyIDToken(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOLoginFailed, err)
+	}
+
+	userID, err := o.provisionUser(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	groupsClaim := cmp.Or(o.oidcConfig.GroupsClaim, "groups")
+	o.applyGroupMappings(ctx, userID, idToken.StringsClaim(groupsClaim))
+
+	return o.authService.SignInUser(ctx, userID, request.UserAgent, request.IPAddress)
+}
+
+// provisionUser finds the user linked to the identity, linking an existing
+// account with the same verified email or creating a new account on first login
+func (o *oidcServiceImpl) provisionUser(ctx context.Context, idToken *oidc.IDToken) (int32, error) {
+	identity, err := o.queries.GetUserIdentity(ctx, dbsqlc.GetUserIdentityParams{
+		Provider: idToken.Issuer,
+		Subject:  idToken.Subject,
+	})
+	if err == nil {
+		err = o.queries.TouchUserIdentity(ctx, dbsqlc.TouchUserIdentityParams{
+			Provider: idToken.Issuer,
+			Subject:  idToken.Subject,
+			Email:    common.NullString(idToken.Email),
+		})
+		if err != nil {
+			o.logger.Error("oidc-service", "failed to record identity login", "error", err)
+		}
+		return identity.UserID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch user identity: %w", err)
+	}
+
+	if idToken.Email == "" {
+		return 0, ErrSSOEmailNotVerified
+	}
+
+	// a new account is only kept together with its personal org and identity
+	sqlTx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := o.queries.WithTx(sqlTx)
+
+	var userID int32
+	existing, err := tx.FindUserLoginByEmail(ctx, idToken.Email)
+	switch {
+	case err == nil:
+		// an unverified email could be set by anyone at the identity provider,
+		// linking on it would hand them the existing account
+		if !idToken.EmailVerified {
+			return 0, ErrSSOEmailNotVerified
+		}
+		userID = existing.ID
+	case errors.Is(err, sql.ErrNoRows):
+		userID, err = o.createUser(ctx, tx, idToken)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	err = tx.CreateUserIdentity(ctx, dbsqlc.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: idToken.Issuer,
+		Subject:  idToken.Subject,
+		Email:    common.NullString(idToken.Email),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to link user identity: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
+
+// createUser creates the account of a user signing in for the first time
+// with a personal org, as a signup does
+func (o *oidcServiceImpl) createUser(ctx context.Context, tx *dbsqlc.Queries, idToken *oidc.IDToken) (int32, error) {
+	displayName := idToken.DisplayName()
+	firstName, lastName := idToken.GivenName, idToken.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(displayName, " ")
+	}
+
+	// the account signs in through the identity provider, the password can
+	// only be used after the user sets one through a password reset
+	password, _, err := generateSecureToken()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	now := time.Now()
+	userParams := dbsqlc.CreateUserParams{
+		FirstName:    firstName,
+		LastName:     lastName,
+		DisplayName:  common.NullString(displayName),
+		Email:        idToken.Email,
+		Password:     common.MustHashPassword(password),
+		IsActivated:  sql.NullBool{Bool: true, Valid: true},
+		IsReviewed:   sql.NullBool{Bool: false, Valid: true},
+		IsSuperAdmin: sql.NullBool{Bool: false, Valid: true},
+		IsVerified:   sql.NullBool{Bool: idToken.EmailVerified, Valid: true},
+		CreatedAt:    common.NewNullTime(now),
+		UpdatedAt:    common.NewNullTime(now),
+	}
+	if idToken.EmailVerified {
+		userParams.EmailConfirmedAt = common.NewNullTime(now)
+	}
+
+	userID, err := tx.CreateUser(ctx, userParams)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	org, err := createOrgWithOwner(ctx, tx, dbsqlc.CreateOrgParams{
+		Name:        personalOrgName(firstName, displayName),
+		CreatedByID: userID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.SetUserDefaultOrg(ctx, dbsqlc.SetUserDefaultOrgParams{
+		ID:    userID,
+		OrgID: common.NewNullInt32(org.ID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to set default org: %w", err)
+	}
+	o.logger.Info("oidc-service", "provisioned user from identity provider", "user_id", userID, "issuer", idToken.Issuer)
+	return userID, nil
+}
+
+// applyGroupMappings grants the org memberships and project roles configured
+// for the groups of the user. Mappings only grant access, removing a user from
+// a group at the identity provider does not revoke what was granted before
+func (o *oidcServiceImpl) applyGroupMappings(ctx context.Context, userID int32, groups []string) {
+	for _, mapping := range o.oidcConfig.GroupMappings {
+		if !slices.Contains(groups, mapping.Group) {
+			continue
+		}
+
+		if mapping.OrgID > 0 {
+			if err := ensureOrgMember(ctx, o.queries, mapping.OrgID, userID, cmp.Or(mapping.OrgRole, OrgRoleMember)); err != nil {
+				o.logger.Error("oidc-service", "failed to apply org group mapping", "group", mapping.Group, "org_id", mapping.OrgID, "error", err)
+			}
+		}
+
+		if mapping.ProjectID > 0 {
+			role := cmp.Or(mapping.ProjectRole, RoleEngineer)
+			if _, ok := rolePermissions[role]; !ok {
+				o.logger.Error("oidc-service", "invalid project role in group mapping", "group", mapping.Group, "role", role)
+				continue
+			}
+			err := o.queries.UpsertProjectTester(ctx, dbsqlc.UpsertProjectTesterParams{
+				ProjectID: mapping.ProjectID,
+				UserID:    userID,
+				Role:      role,
+			})
+			if err != nil {
+				o.logger.Error("oidc-service", "failed to apply project group mapping", "group", mapping.Group, "project_id", mapping.ProjectID, "error", err)
+			}
+		}
+	}
+}
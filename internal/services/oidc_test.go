@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/pkg/oidc"
+	"github.com/golang-malawi/qatarina/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCLoginState(t *testing.T) {
+	provider := oidctest.NewProvider("qatarina", "secret")
+	defer provider.Close()
+
+	cfg := &config.Config{
+		Auth: config.AuthConfiguration{JwtSecretKey: "secret"},
+		OIDC: config.OIDCConfiguration{
+			Enabled:   true,
+			IssuerURL: provider.URL,
+			ClientID:  provider.ClientID,
+		},
+	}
+	o := NewOIDCService(cfg, nil, nil, nil, nil).(*oidcServiceImpl)
+
+	authURL, loginState, err := o.BeginLogin(context.Background())
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "https://qatarina.dev/v1/auth/oidc/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	nonce, verifier, err := o.parseLoginState(loginState, query.Get("state"))
+	require.NoError(t, err)
+	assert.Equal(t, query.Get("nonce"), nonce)
+	assert.Equal(t, query.Get("code_challenge"), oidc.PKCEChallenge(verifier))
+
+	_, _, err = o.parseLoginState(loginState, "another-state")
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+
+	other := NewOIDCService(&config.Config{Auth: config.AuthConfiguration{JwtSecretKey: "other"}}, nil, nil, nil, nil).(*oidcServiceImpl)
+	_, _, err = other.parseLoginState(loginState, query.Get("state"))
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	o := NewOIDCService(&config.Config{}, nil, nil, nil, nil)
+	assert.False(t, o.Enabled())
+
+	_, _, err := o.BeginLogin(context.Background())
+	assert.ErrorIs(t, err, ErrSSODisabled)
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+)
+
+var (
+	ErrLastOrgOwner     = errors.New("an org must keep at least one active owner")
+	ErrInvalidOrgRole   = errors.New("org role must be one of owner, admin or member")
+	ErrAlreadyOrgMember = errors.New("user is already a member of the org")
+	ErrNotOrgMember     = errors.New("user is not a member of the org")
+)
+
+// Audit log actions
+const (
+	AuditOrgMemberAdd         = "org.member.add"
+	AuditOrgMemberRole        = "org.member.role"
+	AuditOrgMemberRemove      = "org.member.remove"
+	AuditOrgOwnershipTransfer = "org.ownership.transfer"
+)
+
+// orgRoleOf returns the role the user has in the org, super admins are
+// treated as owners of every org. It is empty for users outside the org
+func orgRoleOf(ctx context.Context, queries *dbsqlc.Queries, orgID int32, userID int64) (string, error) {
+	isSuperAdmin, err := queries.IsUserSuperAdmin(ctx, int32(userID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if _, err := queries.GetOrgByID(ctx, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("org %d: %w", orgID, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to fetch org: %w", err)
+	}
+	if isSuperAdmin {
+		return OrgRoleOwner, nil
+	}
+
+	role, err := queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{
+		OrgID:  orgID,
+		UserID: int32(userID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to fetch org membership: %w", err)
+	}
+	return role, nil
+}
+
+// canManageOrgMember tells whether a user with actorRole may change the role
+// of a member from targetRole to newRole, an empty role stands for someone
+// outside the org. Admins manage members and admins, owners are managed by owners
+func canManageOrgMember(actorRole, targetRole, newRole string) bool {
+	switch actorRole {
+	case OrgRoleOwner:
+		return true
+	case OrgRoleAdmin:
+		return targetRole != OrgRoleOwner && newRole != OrgRoleOwner
+	}
+	return false
+}
+
+// ensureNotLastOrgOwner keeps at least one active owner in the org when a
+// user with role stops being an owner
+func ensureNotLastOrgOwner(ctx context.Context, queries *dbsqlc.Queries, orgID int32, role string) error {
+	if role != OrgRoleOwner {
+		return nil
+	}
+	count, err := queries.CountOrgOwners(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to count org owners: %w", err)
+	}
+	if count <= 1 {
+		return ErrLastOrgOwner
+	}
+	return nil
+}
+
+// createOrgWithOwner creates the org with the user as its first owner
+func createOrgWithOwner(ctx context.Context, queries *dbsqlc.Queries, params dbsqlc.CreateOrgParams) (dbsqlc.Org, error) {
+	org, err := queries.CreateOrg(ctx, params)
+	if err != nil {
+		return org, fmt.Errorf("failed to create org: %w", err)
+	}
+	err = queries.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{
+		OrgID:  org.ID,
+		UserID: params.CreatedByID,
+		Role:   OrgRoleOwner,
+	})
+	if err != nil {
+		return org, fmt.Errorf("failed to add org owner: %w", err)
+	}
+	return org, nil
+}
+
+// personalOrgName names the org created for a user on signup
+func personalOrgName(firstName, displayName string) string {
+	name := cmp.Or(strings.TrimSpace(displayName), strings.TrimSpace(firstName))
+	if name == "" {
+		return "Personal"
+	}
+	return name + "'s org"
+}
+
+// defaultOrgID returns the org a new session of the user starts in: the
+// default org of the user while they still belong to it, otherwise the
+// first org they belong to. It is 0 for users without an org
+func defaultOrgID(ctx context.Context, queries *dbsqlc.Queries, userID int32) (int32, error) {
+	user, err := queries.GetUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	orgs, err := queries.ListUserOrgs(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list orgs of user: %w", err)
+	}
+	if user.OrgID.Valid && slices.ContainsFunc(orgs, func(org dbsqlc.ListUserOrgsRow) bool { return org.ID == user.OrgID.Int32 }) {
+		return user.OrgID.Int32, nil
+	}
+	if len(orgs) > 0 {
+		return orgs[0].ID, nil
+	}
+	return 0, nil
+}
+
+func (o *orgServiceImpl) ListMembers(ctx context.Context, actorID, orgID int64) ([]schema.OrgMember, error) {
+	role, err := orgRoleOf(ctx, o.queries, int32(orgID), actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, ErrForbidden
+	}
+
+	rows, err := o.queries.ListOrgMembers(ctx, int32(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list org members: %w", err)
+	}
+	members := make([]schema.OrgMember, 0, len(rows))
+	for _, row := range rows {
+		displayName := row.DisplayName.String
+		if displayName == "" {
+			displayName = strings.TrimSpace(row.FirstName + " " + row.LastName)
+		}
+		members = append(members, schema.OrgMember{
+			UserID:      int64(row.UserID),
+			DisplayName: displayName,
+			Email:       row.Email,
+			Role:        row.Role,
+			IsActive:    row.IsActivated.Bool,
+			JoinedAt:    common.FormatSqlDateTime(row.CreatedAt),
+		})
+	}
+	return members, nil
+}
+
+func (o *orgServiceImpl) AddMember(ctx context.Context, actorID, orgID int64, request *schema.AddOrgMemberRequest) error {
+	role := request.Role
+	if role == "" {
+		role = OrgRoleMember
+	}
+	if !slices.Contains(orgRoles, role) {
+		return ErrInvalidOrgRole
+	}
+
+	actorRole, err := orgRoleOf(ctx, o.queries, int32(orgID), actorID)
+	if err != nil {
+		return err
+	}
+	if !canManageOrgMember(actorRole, "", role) {
+		return ErrForbidden
+	}
+
+	if _, err := activeUser(ctx, o.queries, request.UserID); err != nil {
+		return err
+	}
+	_, err = o.queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: int32(orgID), UserID: int32(request.UserID)})
+	if err == nil {
+		return ErrAlreadyOrgMember
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to fetch org membership: %w", err)
+	}
+
+	err = o.queries.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{
+		OrgID:  int32(orgID),
+		UserID: int32(request.UserID),
+		Role:   role,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add org member: %w", err)
+	}
+
+	recordAuditEvent(ctx, o.queries, o.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditOrgMemberAdd,
+		SubjectType: "org",
+		SubjectID:   fmt.Sprint(orgID),
+		Details:     fmt.Sprintf("user %d added as %s", request.UserID, role),
+	})
+	return nil
+}
+
+func (o *orgServiceImpl) UpdateMemberRole(ctx context.Context, actorID, orgID, userID int64, role string) error {
+	if !slices.Contains(orgRoles, role) {
+		return ErrInvalidOrgRole
+	}
+
+	sqlTx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	actorRole, targetRole, err := orgMemberRoles(ctx, tx, orgID, actorID, userID)
+	if err != nil {
+		return err
+	}
+	if !canManageOrgMember(actorRole, targetRole, role) {
+		return ErrForbidden
+	}
+	if targetRole == role {
+		return nil
+	}
+	if err := ensureNotLastOrgOwner(ctx, tx, int32(orgID), targetRole); err != nil {
+		return err
+	}
+
+	_, err = tx.SetOrgMemberRole(ctx, dbsqlc.SetOrgMemberRoleParams{OrgID: int32(orgID), UserID: int32(userID), Role: role})
+	if err != nil {
+		return fmt.Errorf("failed to set org role: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	recordAuditEvent(ctx, o.queries, o.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditOrgMemberRole,
+		SubjectType: "org",
+		SubjectID:   fmt.Sprint(orgID),
+		Details:     fmt.Sprintf("user %d changed from %s to %s", userID, targetRole, role),
+	})
+	return nil
+}
+
+func (o *orgServiceImpl) RemoveMember(ctx context.Context, actorID, orgID, userID int64) error {
+	sqlTx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	actorRole, targetRole, err := orgMemberRoles(ctx, tx, orgID, actorID, userID)
+	if err != nil {
+		return err
+	}
+	// members can always leave an org
+	if actorID != userID && !canManageOrgMember(actorRole, targetRole, "") {
+		return ErrForbidden
+	}
+	if err := ensureNotLastOrgOwner(ctx, tx, int32(orgID), targetRole); err != nil {
+		return err
+	}
+
+	if _, err := tx.RemoveOrgMember(ctx, dbsqlc.RemoveOrgMemberParams{OrgID: int32(orgID), UserID: int32(userID)}); err != nil {
+		return fmt.Errorf("failed to remove org member: %w", err)
+	}
+	_, err = tx.ClearUserDefaultOrg(ctx, dbsqlc.ClearUserDefaultOrgParams{ID: int32(userID), OrgID: common.NewNullInt32(int32(orgID))})
+	if err != nil {
+		return fmt.Errorf("failed to clear default org: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	recordAuditEvent(ctx, o.queries, o.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditOrgMemberRemove,
+		SubjectType: "org",
+		SubjectID:   fmt.Sprint(orgID),
+		Details:     fmt.Sprintf("user %d removed, was %s", userID, targetRole),
+	})
+	return nil
+}
+
+func (o *orgServiceImpl) TransferOwnership(ctx context.Context, actorID, orgID, userID int64) error {
+	sqlTx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	actorRole, targetRole, err := orgMemberRoles(ctx, tx, orgID, actorID, userID)
+	if err != nil {
+		return err
+	}
+	if actorRole != OrgRoleOwner {
+		return ErrForbidden
+	}
+	if actorID == userID {
+		return nil
+	}
+	if _, err := activeUser(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if targetRole != OrgRoleOwner {
+		_, err = tx.SetOrgMemberRole(ctx, dbsqlc.SetOrgMemberRoleParams{OrgID: int32(orgID), UserID: int32(userID), Role: OrgRoleOwner})
+		if err != nil {
+			return fmt.Errorf("failed to set org role: %w", err)
+		}
+	}
+	// super admins act as owners without holding the role, only an owner
+	// membership is handed over
+	_, err = tx.SetOrgMemberRole(ctx, dbsqlc.SetOrgMemberRoleParams{OrgID: int32(orgID), UserID: int32(actorID), Role: OrgRoleAdmin})
+	if err != nil {
+		return fmt.Errorf("failed to set org role: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	recordAuditEvent(ctx, o.queries, o.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditOrgOwnershipTransfer,
+		SubjectType: "org",
+		SubjectID:   fmt.Sprint(orgID),
+		Details:     fmt.Sprintf("ownership transferred from user %d to user %d", actorID, userID),
+	})
+	return nil
+}
+
+func (o *orgServiceImpl) ListUserOrgs(ctx context.Context, userID, currentOrgID int64) ([]schema.UserOrg, error) {
+	rows, err := o.queries.ListUserOrgs(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orgs of user: %w", err)
+	}
+	orgs := make([]schema.UserOrg, 0, len(rows))
+	for _, row := range rows {
+		orgs = append(orgs, schema.UserOrg{
+			ID:      row.ID,
+			Name:    row.Name,
+			Role:    row.Role,
+			Current: int64(row.ID) == currentOrgID,
+		})
+	}
+	return orgs, nil
+}
+
+// orgMemberRoles returns the role of the actor and of the member they act
+// on, it fails with ErrNotOrgMember when the user is not in the org
+func orgMemberRoles(ctx context.Context, queries *dbsqlc.Queries, orgID, actorID, userID int64) (string, string, error) {
+	actorRole, err := orgRoleOf(ctx, queries, int32(orgID), actorID)
+	if err != nil {
+		return "", "", err
+	}
+	targetRole, err := queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: int32(orgID), UserID: int32(userID)})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if actorRole == "" {
+				// do not reveal the members of orgs the actor is not part of
+				return "", "", ErrForbidden
+			}
+			return "", "", ErrNotOrgMember
+		}
+		return "", "", fmt.Errorf("failed to fetch org membership: %w", err)
+	}
+	return actorRole, targetRole, nil
+}
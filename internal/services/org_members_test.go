@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanManageOrgMember(t *testing.T) {
+	tests := []struct {
+		actorRole  string
+		targetRole string
+		newRole    string
+		allowed    bool
+	}{
+		{OrgRoleOwner, OrgRoleOwner, OrgRoleAdmin, true},
+		{OrgRoleOwner, "", OrgRoleOwner, true},
+		{OrgRoleAdmin, "", OrgRoleMember, true},
+		{OrgRoleAdmin, OrgRoleMember, OrgRoleAdmin, true},
+		{OrgRoleAdmin, OrgRoleAdmin, "", true},
+		{OrgRoleAdmin, OrgRoleMember, OrgRoleOwner, false},
+		{OrgRoleAdmin, OrgRoleOwner, OrgRoleMember, false},
+		{OrgRoleAdmin, OrgRoleOwner, "", false},
+		{OrgRoleMember, "", OrgRoleMember, false},
+		{OrgRoleMember, OrgRoleMember, "", false},
+		{"", "", OrgRoleMember, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, canManageOrgMember(tt.actorRole, tt.targetRole, tt.newRole),
+			"%q changing %q to %q", tt.actorRole, tt.targetRole, tt.newRole)
+	}
+}
+
+func TestPersonalOrgName(t *testing.T) {
+	assert.Equal(t, "Bess's org", personalOrgName("Bess", ""))
+	assert.Equal(t, "bjensen's org", personalOrgName("Barbara", " bjensen "))
+	assert.Equal(t, "Personal", personalOrgName(" ", ""))
+}
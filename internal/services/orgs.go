@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/golang-malawi/qatarina/internal/common"
@@ -10,99 +12,166 @@ import (
 	"github.com/golang-malawi/qatarina/internal/schema"
 )
 
+// Roles a user can have in an org through org_members, members get
+// OrgRoleMember unless another one is set
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// orgRoles lists the valid org roles
+var orgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+
 type OrgService interface {
 	Create(ctx context.Context, req schema.CreateOrgRequest, userID int64) (*schema.Org, error)
-	GetOne(ctx context.Context, id int64) (*schema.Org, error)
-	ListAll(ctx context.Context) ([]schema.Org, error)
-	Update(ctx context.Context, req schema.UpdateOrgRequest) error
-	DeleteOrg(ctx context.Context, id int64) error
+	// GetOne fetches an org the actor belongs to, other orgs are not found
+	GetOne(ctx context.Context, actorID, id int64) (*schema.Org, error)
+	// ListAll lists the orgs the actor belongs to, super admins see every org
+	ListAll(ctx context.Context, actorID int64) ([]schema.Org, error)
+	// Update changes the details of an org, only its owners and admins may update it
+	Update(ctx context.Context, actorID int64, req schema.UpdateOrgRequest) error
+	// DeleteOrg deletes an org, only its owners may delete it
+	DeleteOrg(ctx context.Context, actorID, id int64) error
+	// ListMembers lists the members of an org, the actor must belong to the org
+	ListMembers(ctx context.Context, actorID, orgID int64) ([]schema.OrgMember, error)
+	// AddMember adds an existing user to an org
+	AddMember(ctx context.Context, actorID, orgID int64, request *schema.AddOrgMemberRequest) error
+	// UpdateMemberRole changes the role of a member of an org
+	UpdateMemberRole(ctx context.Context, actorID, orgID, userID int64, role string) error
+	// RemoveMember removes a user from an org, members may remove themselves
+	RemoveMember(ctx context.Context, actorID, orgID, userID int64) error
+	// TransferOwnership makes the user an owner of the org and the acting owner an admin
+	TransferOwnership(ctx context.Context, actorID, orgID, userID int64) error
+	// ListUserOrgs lists the orgs the user belongs to
+	ListUserOrgs(ctx context.Context, userID, currentOrgID int64) ([]schema.UserOrg, error)
 }
 
 type orgServiceImpl struct {
+	db      *sql.DB
 	queries *dbsqlc.Queries
 	logger  logging.Logger
 }
 
-func NewOrgService(queries *dbsqlc.Queries, logger logging.Logger) OrgService {
+func NewOrgService(db *sql.DB, queries *dbsqlc.Queries, logger logging.Logger) OrgService {
 	return &orgServiceImpl{
+		db:      db,
 		queries: queries,
 		logger:  logger,
 	}
 }
 
 func (o *orgServiceImpl) Create(ctx context.Context, req schema.CreateOrgRequest, userID int64) (*schema.Org, error) {
-	org, err := o.queries.CreateOrg(ctx, dbsqlc.CreateOrgParams{
-		Name:        req.Name,
-		Address:     common.NullString(req.Address),
-		Country:     common.NullString(req.Country),
-		GithubUrl:   common.NullString(req.GithubURL),
-		WebsiteUrl:  common.NullString(req.WebsiteURL),
-		CreatedByID: int32(userID),
+	sqlTx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	org, err := createOrgWithOwner(ctx, dbsqlc.New(sqlTx), dbsqlc.CreateOrgParams{
+		Name:             req.Name,
+		Address:          common.NullString(req.Address),
+		Country:          common.NullString(req.Country),
+		GithubUrl:        common.NullString(req.GithubURL),
+		WebsiteUrl:       common.NullString(req.WebsiteURL),
+		CreatedByID:      int32(userID),
+		RequireTwoFactor: req.RequireTwoFactor,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create org: %w", err)
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return &schema.Org{
-		ID:         org.ID,
-		Name:       org.Name,
-		Address:    org.Address.String,
-		Country:    org.Country.String,
-		GithubURL:  org.GithubUrl.String,
-		WebsiteURL: org.WebsiteUrl.String,
-		CreatedBy:  int64(org.CreatedByID),
-		CreatedAt:  common.FormatSqlDateTime(org.CreatedAt),
-		UpdatedAt:  common.FormatSqlDateTime(org.UpdatedAt),
+		ID:               org.ID,
+		Name:             org.Name,
+		Address:          org.Address.String,
+		Country:          org.Country.String,
+		GithubURL:        org.GithubUrl.String,
+		WebsiteURL:       org.WebsiteUrl.String,
+		RequireTwoFactor: org.RequireTwoFactor,
+		CreatedBy:        int64(org.CreatedByID),
+		CreatedAt:        common.FormatSqlDateTime(org.CreatedAt),
+		UpdatedAt:        common.FormatSqlDateTime(org.UpdatedAt),
 	}, nil
 }
 
-func (o *orgServiceImpl) ListAll(ctx context.Context) ([]schema.Org, error) {
-	orgs, err := o.queries.ListOrgs(ctx)
+func (o *orgServiceImpl) ListAll(ctx context.Context, actorID int64) ([]schema.Org, error) {
+	isSuperAdmin, err := o.queries.IsUserSuperAdmin(ctx, int32(actorID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	var orgs []dbsqlc.Org
+	if isSuperAdmin {
+		orgs, err = o.queries.ListOrgs(ctx)
+	} else {
+		orgs, err = o.queries.ListOrgsByMember(ctx, int32(actorID))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list all orgs: %w", err)
 	}
 	result := make([]schema.Org, len(orgs))
 	for i, org := range orgs {
 		result[i] = schema.Org{
-			ID:         org.ID,
-			Name:       org.Name,
-			Address:    org.Address.String,
-			Country:    org.Country.String,
-			GithubURL:  org.GithubUrl.String,
-			WebsiteURL: org.WebsiteUrl.String,
-			CreatedBy:  int64(org.CreatedByID),
-			CreatedAt:  common.FormatSqlDateTime(org.CreatedAt),
-			UpdatedAt:  common.FormatSqlDateTime(org.UpdatedAt),
+			ID:               org.ID,
+			Name:             org.Name,
+			Address:          org.Address.String,
+			Country:          org.Country.String,
+			GithubURL:        org.GithubUrl.String,
+			WebsiteURL:       org.WebsiteUrl.String,
+			RequireTwoFactor: org.RequireTwoFactor,
+			CreatedBy:        int64(org.CreatedByID),
+			CreatedAt:        common.FormatSqlDateTime(org.CreatedAt),
+			UpdatedAt:        common.FormatSqlDateTime(org.UpdatedAt),
 		}
 	}
 	return result, nil
 }
 
-func (o *orgServiceImpl) GetOne(ctx context.Context, id int64) (*schema.Org, error) {
+func (o *orgServiceImpl) GetOne(ctx context.Context, actorID, id int64) (*schema.Org, error) {
+	role, err := orgRoleOf(ctx, o.queries, int32(id), actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		// do not reveal orgs the actor is not part of
+		return nil, fmt.Errorf("org %d: %w", id, ErrNotFound)
+	}
 	org, err := o.queries.GetOrgByID(ctx, int32(id))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get org with id %d: %w", id, err)
 	}
 	return &schema.Org{
-		ID:         org.ID,
-		Name:       org.Name,
-		Address:    org.Address.String,
-		Country:    org.Country.String,
-		GithubURL:  org.GithubUrl.String,
-		WebsiteURL: org.WebsiteUrl.String,
-		CreatedBy:  int64(org.CreatedByID),
-		CreatedAt:  common.FormatSqlDateTime(org.CreatedAt),
-		UpdatedAt:  common.FormatSqlDateTime(org.UpdatedAt),
+		ID:               org.ID,
+		Name:             org.Name,
+		Address:          org.Address.String,
+		Country:          org.Country.String,
+		GithubURL:        org.GithubUrl.String,
+		WebsiteURL:       org.WebsiteUrl.String,
+		RequireTwoFactor: org.RequireTwoFactor,
+		CreatedBy:        int64(org.CreatedByID),
+		CreatedAt:        common.FormatSqlDateTime(org.CreatedAt),
+		UpdatedAt:        common.FormatSqlDateTime(org.UpdatedAt),
 	}, nil
 }
 
-func (o *orgServiceImpl) Update(ctx context.Context, req schema.UpdateOrgRequest) error {
-	err := o.queries.UpdateOrg(ctx, dbsqlc.UpdateOrgParams{
-		ID:         req.ID,
-		Name:       req.Name,
-		Address:    common.NullString(req.Address),
-		Country:    common.NullString(req.Country),
-		GithubUrl:  common.NullString(req.GithubURL),
-		WebsiteUrl: common.NullString(req.WebsiteURL),
+func (o *orgServiceImpl) Update(ctx context.Context, actorID int64, req schema.UpdateOrgRequest) error {
+	role, err := orgRoleOf(ctx, o.queries, req.ID, actorID)
+	if err != nil {
+		return err
+	}
+	if role != OrgRoleOwner && role != OrgRoleAdmin {
+		return ErrForbidden
+	}
+	err = o.queries.UpdateOrg(ctx, dbsqlc.UpdateOrgParams{
+		ID:               req.ID,
+		Name:             req.Name,
+		Address:          common.NullString(req.Address),
+		Country:          common.NullString(req.Country),
+		GithubUrl:        common.NullString(req.GithubURL),
+		WebsiteUrl:       common.NullString(req.WebsiteURL),
+		RequireTwoFactor: req.RequireTwoFactor,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update org with id %d: %w", req.ID, err)
@@ -110,11 +179,38 @@ func (o *orgServiceImpl) Update(ctx context.Context, req schema.UpdateOrgRequest
 	return nil
 }
 
-func (o *orgServiceImpl) DeleteOrg(ctx context.Context, id int64) error {
-	err := o.queries.DeleteOrg(ctx, int32(id))
+func (o *orgServiceImpl) DeleteOrg(ctx context.Context, actorID, id int64) error {
+	role, err := orgRoleOf(ctx, o.queries, int32(id), actorID)
+	if err != nil {
+		return err
+	}
+	if role != OrgRoleOwner {
+		return ErrForbidden
+	}
+	err = o.queries.DeleteOrg(ctx, int32(id))
 	if err != nil {
 		return fmt.Errorf("failed to delete org with id %d: %w", id, err)
 	}
 
 	return nil
 }
+
+// ensureOrgMember adds the user to the org, the role of existing members is left
+// as is so that owners are not demoted
+func ensureOrgMember(ctx context.Context, queries *dbsqlc.Queries, orgID, userID int32, role string) error {
+	_, err := queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{
+		OrgID:  orgID,
+		UserID: userID,
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return queries.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{
+		OrgID:  orgID,
+		UserID: userID,
+		Role:   role,
+	})
+}
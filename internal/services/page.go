@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/golang-malawi/qatarina/internal/common"
@@ -32,11 +34,20 @@ type pageServiceImp struct {
 
 // Implement the Create method
 func (p *pageServiceImp) Create(ctx context.Context, request *schema.PageRequest) (bool, error) {
+	orgID := contextOrgID(ctx)
+	if orgID == 0 {
+		return false, ErrNoOrg
+	}
+	if request.ProjectID != 0 {
+		if err := ensureProjectInOrg(ctx, p.db, int64(request.ProjectID)); err != nil {
+			return false, err
+		}
+	}
 
 	_, err := p.db.CreatePage(ctx, dbsqlc.CreatePageParams{
 		ParentPageID:       common.NewNullInt32(request.ParentPageID.Int32),
 		PageVersion:        request.PageVersion,
-		OrgID:              request.OrgID,
+		OrgID:              orgID,
 		ProjectID:          request.ProjectID,
 		Code:               request.Code,
 		Title:              request.Title,
@@ -61,15 +72,21 @@ func (p *pageServiceImp) Create(ctx context.Context, request *schema.PageRequest
 func (p *pageServiceImp) GetOnePage(ctx context.Context, id int32) (dbsqlc.Page, error) {
 	page, err := p.db.GetPage(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbsqlc.Page{}, ErrNotFound
+		}
 		p.logger.Error("services-pages", "failed to fetch with id %d: %v", id, err)
 		return dbsqlc.Page{}, err
 	}
+	if page.OrgID != contextOrgID(ctx) {
+		return dbsqlc.Page{}, ErrNotFound
+	}
 
 	return page, nil
 }
 
 func (p *pageServiceImp) GetAllPages(ctx context.Context) ([]dbsqlc.Page, error) {
-	pages, err := p.db.GetAllPages(ctx)
+	pages, err := p.db.GetAllPages(ctx, contextOrgID(ctx))
 	if err != nil {
 		p.logger.Error("failed to fetxh pages", "error", err)
 		return nil, err
@@ -78,11 +95,21 @@ func (p *pageServiceImp) GetAllPages(ctx context.Context) ([]dbsqlc.Page, error)
 }
 
 func (p *pageServiceImp) UpdatePage(ctx context.Context, request schema.UpdatePageRequest) (bool, error) {
-	err := p.db.UpdatePage(ctx, dbsqlc.UpdatePageParams{
+	page, err := p.GetOnePage(ctx, request.ID)
+	if err != nil {
+		return false, err
+	}
+	if request.ProjectID != 0 {
+		if err := ensureProjectInOrg(ctx, p.db, int64(request.ProjectID)); err != nil {
+			return false, err
+		}
+	}
+
+	err = p.db.UpdatePage(ctx, dbsqlc.UpdatePageParams{
 		ID:                 request.ID,
 		ParentPageID:       common.NewNullInt32(request.ParentPageID.Int32),
 		PageVersion:        request.PageVersion,
-		OrgID:              request.OrgID,
+		OrgID:              page.OrgID,
 		ProjectID:          request.ProjectID,
 		Code:               request.Code,
 		Title:              request.Title,
@@ -103,6 +130,9 @@ func (p *pageServiceImp) UpdatePage(ctx context.Context, request schema.UpdatePa
 }
 
 func (p *pageServiceImp) DeletePage(ctx context.Context, id int32) error {
+	if _, err := p.GetOnePage(ctx, id); err != nil {
+		return err
+	}
 	_, err := p.db.DeletePage(ctx, id)
 	if err != nil {
 		return err
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = errors.New("not allowed to perform the action")
+
+// Roles a user can have on a project through project_testers
+const (
+	RoleLead     = "lead"
+	RoleEngineer = "engineer"
+	RoleClient   = "client"
+	RoleBot      = "bot"
+	RoleAIAgent  = "ai_agent"
+)
+
+// Action is something a user can do on a project
+type Action string
+
+const (
+	ActionViewProject     Action = "view project"
+	ActionManageProject   Action = "manage project"
+	ActionDeleteProject   Action = "delete project"
+	ActionManageTesters   Action = "manage testers"
+	ActionCreateTestCase  Action = "create test cases"
+	ActionSuggestTestCase Action = "suggest test cases"
+	ActionReviewTestCase  Action = "review suggested test cases"
+	ActionDeleteTestCase  Action = "delete test cases"
+	ActionManageTestPlan  Action = "manage test plans"
+	ActionCloseTestPlan   Action = "close test plans"
+	ActionDeleteTestPlan  Action = "delete test plans"
+	ActionExecuteTestRun  Action = "execute test runs"
+	ActionDeleteTestRun   Action = "delete test runs"
+	ActionComment         Action = "comment"
+	ActionCreateReport    Action = "create reports"
+)
+
+// rolePermissions maps each project role to the actions it allows, project
+// owners are treated as leads and super admins are allowed everything
+var rolePermissions = map[string][]Action{
+	RoleLead: {
+		ActionViewProject, ActionManageProject, ActionDeleteProject, ActionManageTesters,
+		ActionCreateTestCase, ActionSuggestTestCase, ActionReviewTestCase, ActionDeleteTestCase,
+		ActionManageTestPlan, ActionCloseTestPlan, ActionDeleteTestPlan,
+		ActionExecuteTestRun, ActionDeleteTestRun, ActionComment, ActionCreateReport,
+	},
+	RoleEngineer: {
+		ActionViewProject, ActionCreateTestCase, ActionSuggestTestCase, ActionReviewTestCase,
+		ActionManageTestPlan, ActionExecuteTestRun, ActionComment, ActionCreateReport,
+	},
+	RoleClient: {
+		ActionViewProject, ActionSuggestTestCase, ActionComment, ActionCreateReport,
+	},
+	RoleBot: {
+		ActionViewProject, ActionExecuteTestRun,
+	},
+	RoleAIAgent: {
+		ActionViewProject, ActionSuggestTestCase, ActionExecuteTestRun, ActionComment,
+	},
+}
+
+// RoleAllows checks whether the project role allows the action
+func RoleAllows(role string, action Action) bool {
+	return slices.Contains(rolePermissions[role], action)
+}
+
+type PermissionService interface {
+	// Authorize checks that the user may perform the action on the project,
+	// returns ErrNotFound when the project does not exist or is in another org
+	// than the context and ErrForbidden when not allowed
+	Authorize(ctx context.Context, userID, projectID int64, action Action) error
+	// RequestOrgID resolves the org a request works in, the project of a
+	// project scoped API token, else the org the session switched to while the
+	// user is still a member of it, else the default org of the user
+	RequestOrgID(ctx context.Context, userID, sessionOrgID, tokenProjectID int64) (int64, error)
+	// IsSuperAdmin checks whether the user is a system-wide administrator
+	IsSuperAdmin(ctx context.Context, userID int64) (bool, error)
+	// IsOrgAdmin checks whether the user is an owner or admin of the org of
+	// the context
+	IsOrgAdmin(ctx context.Context, userID int64) (bool, error)
+	// ProjectIDForTestCase finds the project a test case belongs to
+	ProjectIDForTestCase(ctx context.Context, testCaseID string) (int64, error)
+	// ProjectIDForTestPlan finds the project a test plan belongs to
+	ProjectIDForTestPlan(ctx context.Context, testPlanID int64) (int64, error)
+	// ProjectIDForTestRun finds the project a test run belongs to
+	ProjectIDForTestRun(ctx context.Context, testRunID string) (int64, error)
+	// ProjectIDForModule finds the project a module belongs to
+	ProjectIDForModule(ctx context.Context, moduleID int32) (int64, error)
+}
+
+type permissionServiceImpl struct {
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewPermissionService(queries *dbsqlc.Queries, logger logging.Logger) PermissionService {
+	return &permissionServiceImpl{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (p *permissionServiceImpl) Authorize(ctx context.Context, userID, projectID int64, action Action) error {
+	access, err := p.queries.GetProjectAccess(ctx, dbsqlc.GetProjectAccessParams{
+		ID:     int32(projectID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to fetch project access: %w", err)
+	}
+	if access.OrgID != contextOrgID(ctx) {
+		return ErrNotFound
+	}
+
+	if access.IsSuperAdmin || int64(access.OwnerUserID) == userID {
+		return nil
+	}
+
+	if !RoleAllows(access.Role, action) {
+		p.logger.Debug("permission-service", "action denied", "user_id", userID, "project_id", projectID, "role", access.Role, "action", action)
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (p *permissionServiceImpl) RequestOrgID(ctx context.Context, userID, sessionOrgID, tokenProjectID int64) (int64, error) {
+	if tokenProjectID != 0 {
+		orgID, err := p.queries.GetProjectOrgID(ctx, int32(tokenProjectID))
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch project: %w", err)
+		}
+		return int64(orgID), nil
+	}
+
+	if sessionOrgID != 0 {
+		role, err := orgRoleOf(ctx, p.queries, int32(sessionOrgID), userID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return 0, err
+		}
+		if role != "" {
+			return sessionOrgID, nil
+		}
+	}
+
+	orgID, err := defaultOrgID(ctx, p.queries, int32(userID))
+	if err != nil {
+		return 0, err
+	}
+	return int64(orgID), nil
+}
+
+func (p *permissionServiceImpl) IsSuperAdmin(ctx context.Context, userID int64) (bool, error) {
+	isSuperAdmin, err := p.queries.IsUserSuperAdmin(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return isSuperAdmin, nil
+}
+
+func (p *permissionServiceImpl) IsOrgAdmin(ctx context.Context, userID int64) (bool, error) {
+	role, err := orgRoleOf(ctx, p.queries, contextOrgID(ctx), userID)
+	if err != nil {
+		return false, err
+	}
+	return role == OrgRoleOwner || role == OrgRoleAdmin, nil
+}
+
+func (p *permissionServiceImpl) ProjectIDForTestCase(ctx context.Context, testCaseID string) (int64, error) {
+	id, err := uuid.Parse(testCaseID)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	projectID, err := p.queries.GetTestCaseProjectID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch test case: %w", err)
+	}
+	if !projectID.Valid {
+		return 0, ErrNotFound
+	}
+	return int64(projectID.Int32), nil
+}
+
+func (p *permissionServiceImpl) ProjectIDForTestPlan(ctx context.Context, testPlanID int64) (int64, error) {
+	projectID, err := p.queries.GetTestPlanProjectID(ctx, testPlanID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch test plan: %w", err)
+	}
+	return int64(projectID), nil
+}
+
+func (p *permissionServiceImpl) ProjectIDForTestRun(ctx context.Context, testRunID string) (int64, error) {
+	id, err := uuid.Parse(testRunID)
+	if err != nil {
+		return 0, ErrNotFound
+	}
+	projectID, err := p.queries.GetTestRunProjectID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch test run: %w", err)
+	}
+	return int64(projectID), nil
+}
+
+func (p *permissionServiceImpl) ProjectIDForModule(ctx context.Context, moduleID int32) (int64, error) {
+	module, err := p.queries.GetOneModule(ctx, moduleID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch module: %w", err)
+	}
+	return int64(module.ProjectID), nil
+}
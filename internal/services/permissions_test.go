@@ -0,0 +1,18 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAllows(RoleLead, ActionDeleteProject))
+	assert.True(t, RoleAllows(RoleEngineer, ActionCreateTestCase))
+	assert.False(t, RoleAllows(RoleEngineer, ActionCloseTestPlan))
+	assert.True(t, RoleAllows(RoleBot, ActionExecuteTestRun))
+	assert.False(t, RoleAllows(RoleBot, ActionCreateTestCase))
+	assert.False(t, RoleAllows(RoleClient, ActionExecuteTestRun))
+	assert.True(t, RoleAllows(RoleAIAgent, ActionSuggestTestCase))
+	assert.False(t, RoleAllows("", ActionViewProject))
+}
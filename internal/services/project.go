@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 	"unicode"
@@ -14,6 +16,7 @@ import (
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
 	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
 )
 
 type ProjectService interface {
@@ -25,21 +28,37 @@ type ProjectService interface {
 	Search(context.Context, string) ([]dbsqlc.Project, error)
 	ArchiveProject(context.Context, int64) error
 	UnarchiveProject(context.Context, int64) error
-	AddProjectTestCaseTemplate(ctx context.Context, projectID int64, template string) error
-	GetProjectTestCaseTemplate(context.Context, int64) (*string, error)
+	// AddProjectTestCaseTemplate validates and stores the test case template of a project
+	AddProjectTestCaseTemplate(ctx context.Context, projectID int64, template *validation.TestCaseTemplate) error
+	// GetProjectTestCaseTemplate returns the test case template of a project, nil when it has none
+	GetProjectTestCaseTemplate(context.Context, int64) (*validation.TestCaseTemplate, error)
 	UpdateAutomatedTesting(ctx context.Context, req *schema.UpdateAutomatedTestingRequest) error
+	Clone(ctx context.Context, projectID int64, request *schema.CloneProjectRequest) (*dbsqlc.Project, *schema.CloneProjectCounts, error)
+	// ListChildren lists the direct sub-projects of a project
+	ListChildren(ctx context.Context, projectID int64) ([]dbsqlc.Project, error)
+	// SetParent moves a project under another project, a parentID of 0 makes
+	// it a top level project
+	SetParent(ctx context.Context, projectID, parentID int64) error
 }
 
+// ErrInvalidTestCaseTemplate is returned for test case templates which are not valid
+var ErrInvalidTestCaseTemplate = errors.New("invalid test case template")
+
+// ErrProjectCycle is returned when a project would become its own ancestor
+var ErrProjectCycle = errors.New("a project cannot be moved under itself or one of its sub-projects")
+
 type projectServiceImpl struct {
 	name          loggedmodule.Name
+	sqlDB         *sql.DB
 	db            *dbsqlc.Queries
 	logger        logging.Logger
 	moduleService ModuleService
 }
 
-func NewProjectService(db *dbsqlc.Queries, logger logging.Logger, moduleService ModuleService) ProjectService {
+func NewProjectService(sqlDB *sql.DB, db *dbsqlc.Queries, logger logging.Logger, moduleService ModuleService) ProjectService {
 	return &projectServiceImpl{
 		name:          "projects-service",
+		sqlDB:         sqlDB,
 		db:            db,
 		logger:        logger,
 		moduleService: moduleService,
@@ -48,7 +67,11 @@ func NewProjectService(db *dbsqlc.Queries, logger logging.Logger, moduleService
 
 // Create implements ProjectService.
 func (s *projectServiceImpl) Create(ctx context.Context, request *schema.NewProjectRequest) (*dbsqlc.Project, error) {
-	projectID, err := s.db.CreateProject(context.Background(), dbsqlc.CreateProjectParams{
+	orgID := contextOrgID(ctx)
+	if orgID == 0 {
+		return nil, ErrNoOrg
+	}
+	projectID, err := s.db.CreateProject(ctx, dbsqlc.CreateProjectParams{
 		Title:                   request.Name,
 		Code:                    request.Code,
 		Description:             request.Description,
@@ -62,6 +85,7 @@ func (s *projectServiceImpl) Create(ctx context.Context, request *schema.NewProj
 		UpdatedAt:               time.Now(),
 		AutomatedTestingEnabled: request.AutomatedTestingEnabled,
 		SupportedRunners:        request.SupportedRunners,
+		OrgID:                   orgID,
 	})
 	if err != nil {
 		s.logger.Error(s.name, "failed to create project", "error", err)
@@ -78,7 +102,7 @@ func (s *projectServiceImpl) Create(ctx context.Context, request *schema.NewProj
 		Description: "Automatically created default module",
 	}
 
-	_, err = s.moduleService.Create(defaultModule)
+	_, err = s.moduleService.Create(ctx, defaultModule)
 	if err != nil {
 		s.logger.Error(s.name, "failed to create default module", "projectID", projectID, "error", err)
 		return nil, err
@@ -101,13 +125,13 @@ func (s *projectServiceImpl) Create(ctx context.Context, request *schema.NewProj
 			return nil, err
 		}
 	}
-	project, err := s.db.GetProject(context.Background(), projectID)
+	project, err := s.db.GetProject(ctx, projectID)
 	return &project, err
 }
 
 // FindAll implements ProjectService.
 func (s *projectServiceImpl) FindAll(ctx context.Context) ([]dbsqlc.Project, error) {
-	if projects, err := s.db.ListProjects(context.Background()); err != nil {
+	if projects, err := s.db.ListProjectsByOrg(ctx, contextOrgID(ctx)); err != nil {
 		s.logger.Error(s.name, "failed to fetch projects", "error", err)
 		return nil, err
 	} else {
@@ -117,7 +141,10 @@ func (s *projectServiceImpl) FindAll(ctx context.Context) ([]dbsqlc.Project, err
 
 // FindByID implements ProjectService.
 func (s *projectServiceImpl) FindByID(ctx context.Context, projectID int64) (*dbsqlc.Project, error) {
-	if project, err := s.db.GetProject(context.Background(), int32(projectID)); err != nil {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return nil, err
+	}
+	if project, err := s.db.GetProject(ctx, int32(projectID)); err != nil {
 		s.logger.Error(s.name, "failed to fetch project", "projectID", projectID, "error", err)
 		return nil, err
 	} else {
@@ -127,6 +154,14 @@ func (s *projectServiceImpl) FindByID(ctx context.Context, projectID int64) (*db
 
 // Update implements ProjectService.
 func (s *projectServiceImpl) Update(ctx context.Context, request schema.UpdateProjectRequest) (bool, error) {
+	if err := ensureProjectInOrg(ctx, s.db, request.ID); err != nil {
+		return false, err
+	}
+	if request.ParentProjectID != 0 {
+		if err := s.checkParent(ctx, request.ID, request.ParentProjectID); err != nil {
+			return false, err
+		}
+	}
 	_, err := s.db.UpdateProject(ctx, dbsqlc.UpdateProjectParams{
 		ID:                      int32(request.ID),
 		Title:                   request.Name,
@@ -151,7 +186,10 @@ func (s *projectServiceImpl) Update(ctx context.Context, request schema.UpdatePr
 
 // DeleteProject implements ProjectService.
 func (s *projectServiceImpl) DeleteProject(ctx context.Context, projectID int64) error {
-	if _, err := s.db.DeleteProject(context.Background(), int32(projectID)); err != nil {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return err
+	}
+	if _, err := s.db.DeleteProject(ctx, int32(projectID)); err != nil {
 		s.logger.Error(s.name, "failed to delete projects", "projectID", projectID, "error", err)
 		return err
 	}
@@ -159,7 +197,10 @@ func (s *projectServiceImpl) DeleteProject(ctx context.Context, projectID int64)
 }
 
 func (p *projectServiceImpl) Search(ctx context.Context, keyword string) ([]dbsqlc.Project, error) {
-	projects, err := p.db.SearchProject(ctx, common.NullString(keyword))
+	projects, err := p.db.SearchProject(ctx, dbsqlc.SearchProjectParams{
+		OrgID:   contextOrgID(ctx),
+		Column2: common.NullString(keyword),
+	})
 	if err != nil {
 		p.logger.Error("failed to search projects with keyword %q", keyword, err)
 		if errors.Is(err, sql.ErrNoRows) {
@@ -172,6 +213,9 @@ func (p *projectServiceImpl) Search(ctx context.Context, keyword string) ([]dbsq
 }
 
 func (s *projectServiceImpl) ArchiveProject(ctx context.Context, projectID int64) error {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return err
+	}
 	_, err := s.db.ArchiveProject(ctx, int32(projectID))
 	if err != nil {
 		s.logger.Error(s.name, "failed to archive project", "projectID", projectID, "error", err)
@@ -181,6 +225,9 @@ func (s *projectServiceImpl) ArchiveProject(ctx context.Context, projectID int64
 }
 
 func (s *projectServiceImpl) UnarchiveProject(ctx context.Context, projectID int64) error {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return err
+	}
 	_, err := s.db.UnarchiveProject(ctx, int32(projectID))
 	if err != nil {
 		s.logger.Error(s.name, "failed to unarchive project", "projectID", projectID, "error", err)
@@ -189,6 +236,54 @@ func (s *projectServiceImpl) UnarchiveProject(ctx context.Context, projectID int
 	return nil
 }
 
+func (s *projectServiceImpl) ListChildren(ctx context.Context, projectID int64) ([]dbsqlc.Project, error) {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return nil, err
+	}
+	children, err := s.db.ListChildProjects(ctx, common.NewNullInt32(int32(projectID)))
+	if err != nil {
+		s.logger.Error(s.name, "failed to list sub-projects", "projectID", projectID, "error", err)
+		return nil, err
+	}
+	return children, nil
+}
+
+func (s *projectServiceImpl) SetParent(ctx context.Context, projectID, parentID int64) error {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return err
+	}
+	if parentID != 0 {
+		if err := s.checkParent(ctx, projectID, parentID); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.SetProjectParent(ctx, dbsqlc.SetProjectParentParams{
+		ID:              int32(projectID),
+		ParentProjectID: common.NewNullInt32(int32(parentID)),
+	})
+	if err != nil {
+		s.logger.Error(s.name, "failed to set parent project", "projectID", projectID, "parentID", parentID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// checkParent checks that parentID can become the parent of projectID, it has
+// to be in the same org and must not be in the subtree of the project
+func (s *projectServiceImpl) checkParent(ctx context.Context, projectID, parentID int64) error {
+	if err := ensureProjectInOrg(ctx, s.db, parentID); err != nil {
+		return err
+	}
+	subtree, err := s.db.GetProjectSubtreeIDs(ctx, int32(projectID))
+	if err != nil {
+		return fmt.Errorf("failed to fetch sub-projects: %w", err)
+	}
+	if slices.Contains(subtree, int32(parentID)) {
+		return ErrProjectCycle
+	}
+	return nil
+}
+
 func sanitizeEnvName(name string) string {
 	n := strings.TrimSpace(name)
 	n = strings.ToLower(n)
@@ -201,10 +296,20 @@ func sanitizeEnvName(name string) string {
 	return n
 }
 
-func (s *projectServiceImpl) AddProjectTestCaseTemplate(ctx context.Context, projectID int64, template string) error {
-	err := s.db.AddProjectTestCaseTemplate(ctx, dbsqlc.AddProjectTestCaseTemplateParams{
+func (s *projectServiceImpl) AddProjectTestCaseTemplate(ctx context.Context, projectID int64, template *validation.TestCaseTemplate) error {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
+		return err
+	}
+	if err := validation.ValidateTestCaseTemplate(template); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTestCaseTemplate, err)
+	}
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	err = s.db.AddProjectTestCaseTemplate(ctx, dbsqlc.AddProjectTestCaseTemplateParams{
 		ID:               int32(projectID),
-		TestcaseTemplate: common.NullString(template),
+		TestcaseTemplate: common.NullString(string(data)),
 	})
 	if err != nil {
 		s.logger.Error(s.name, "failed to add project test case template", "projectID", projectID, "error", err)
@@ -213,20 +318,42 @@ func (s *projectServiceImpl) AddProjectTestCaseTemplate(ctx context.Context, pro
 	return nil
 }
 
-func (s *projectServiceImpl) GetProjectTestCaseTemplate(ctx context.Context, projectID int64) (*string, error) {
-	tpl, err := s.db.GetProjectTestCaseTemplate(ctx, int32(projectID))
-	if err != nil {
-		s.logger.Error(s.name, "failed to get project test case template", "projectID", projectID, "error", err)
+func (s *projectServiceImpl) GetProjectTestCaseTemplate(ctx context.Context, projectID int64) (*validation.TestCaseTemplate, error) {
+	if err := ensureProjectInOrg(ctx, s.db, projectID); err != nil {
 		return nil, err
 	}
+	return projectTestCaseTemplate(ctx, s.db, int32(projectID))
+}
+
+// projectTestCaseTemplate reads the test case template of a project, nil when it has none
+func projectTestCaseTemplate(ctx context.Context, db *dbsqlc.Queries, projectID int32) (*validation.TestCaseTemplate, error) {
+	tpl, err := db.GetProjectTestCaseTemplate(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project test case template: %w", err)
+	}
+	if !tpl.Valid {
+		return nil, nil
+	}
+	return validation.ParseTestCaseTemplate(tpl.String)
+}
 
-	if tpl.Valid {
-		return &tpl.String, nil
+// checkTestCaseTemplate checks the description of a test case against the
+// template of its project, drafts are not complete yet so are not checked
+func checkTestCaseTemplate(ctx context.Context, db *dbsqlc.Queries, projectID int32, kind, description string, isDraft bool) error {
+	if isDraft {
+		return nil
 	}
-	return nil, nil
+	template, err := projectTestCaseTemplate(ctx, db, projectID)
+	if err != nil {
+		return err
+	}
+	return validation.CheckTestCaseDescription(template, kind, description)
 }
 
 func (s *projectServiceImpl) UpdateAutomatedTesting(ctx context.Context, req *schema.UpdateAutomatedTestingRequest) error {
+	if err := ensureProjectInOrg(ctx, s.db, req.ProjectID); err != nil {
+		return err
+	}
 	err := s.db.UpdateAutomatedTesting(ctx, dbsqlc.UpdateAutomatedTestingParams{
 		ID:                      int32(req.ProjectID),
 		AutomatedTestingEnabled: req.AutomatedTestingEnabled,
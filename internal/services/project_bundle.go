@@ -0,0 +1,1121 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ErrInvalidBundle is returned when an uploaded file is not a project bundle
+var ErrInvalidBundle = errors.New("not a valid project bundle")
+
+// ErrUnsupportedBundleVersion is returned for bundles written by a newer release
+var ErrUnsupportedBundleVersion = errors.New("unsupported project bundle format version")
+
+const (
+	bundleManifestName = "manifest.json"
+	// bundleFilesDir holds the scripts and report files in the archive
+	bundleFilesDir = "files/"
+	// bundleMaxFileSize caps the uncompressed size of each file in a bundle
+	bundleMaxFileSize = 256 << 20
+)
+
+// Conflict kinds reported by a project import
+const (
+	ImportConflictUser      = "user"
+	ImportConflictProject   = "project"
+	ImportConflictFile      = "file"
+	ImportConflictReference = "reference"
+)
+
+// ProjectBundleService moves projects between QATARINA instances as a zip
+// archive with a JSON manifest plus the stored scripts and report files
+type ProjectBundleService interface {
+	// Export writes the bundle of a project to w
+	Export(ctx context.Context, projectID int64, w io.Writer) error
+	// Import creates a new project in the org of the context from a bundle,
+	// with DryRun set it only reports what would be imported
+	Import(ctx context.Context, r io.ReaderAt, size int64, opts *schema.ProjectImportOptions) (*schema.ProjectImportReport, error)
+}
+
+type projectBundleServiceImpl struct {
+	storagePath    string
+	sourceInstance string
+	db             *sql.DB
+	queries        *dbsqlc.Queries
+	logger         logging.Logger
+}
+
+func NewProjectBundleService(cfg *config.Config, db *sql.DB, queries *dbsqlc.Queries, logger logging.Logger) ProjectBundleService {
+	return &projectBundleServiceImpl{
+		storagePath:    cfg.Storage.LocalPath,
+		sourceInstance: cfg.Server.BaseURL(),
+		db:             db,
+		queries:        queries,
+		logger:         logger,
+	}
+}
+
+func (s *projectBundleServiceImpl) Export(ctx context.Context, projectID int64, w io.Writer) error {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return err
+	}
+	manifest, files, err := s.buildManifest(ctx, int32(projectID))
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	mw, err := zw.Create(bundleManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		data, err := os.ReadFile(files[name])
+		if err != nil {
+			// the import reports the file as missing
+			s.logger.Error("project-bundle-service", "failed to read file for bundle", "path", files[name], "error", err)
+			continue
+		}
+		fw, err := zw.Create(bundleFilesDir + name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// buildManifest collects the project and everything in it, it returns the
+// files to bundle by their name in the bundle
+func (s *projectBundleServiceImpl) buildManifest(ctx context.Context, projectID int32) (*schema.ProjectBundleManifest, map[string]string, error) {
+	project, err := s.queries.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	userIDs := map[int32]bool{}
+	ref := func(id int32) int32 {
+		if id != 0 {
+			userIDs[id] = true
+		}
+		return id
+	}
+	files := map[string]string{}
+
+	manifest := &schema.ProjectBundleManifest{
+		FormatVersion:  schema.ProjectBundleFormatVersion,
+		ExportedAt:     time.Now().UTC(),
+		SourceInstance: s.sourceInstance,
+		Project: schema.BundleProject{
+			ID:                      project.ID,
+			Title:                   project.Title,
+			Code:                    project.Code,
+			Description:             project.Description,
+			Version:                 project.Version.String,
+			IsActive:                project.IsActive.Bool,
+			IsPublic:                project.IsPublic.Bool,
+			WebsiteURL:              project.WebsiteUrl.String,
+			GithubURL:               project.GithubUrl.String,
+			TrelloURL:               project.TrelloUrl.String,
+			JiraURL:                 project.JiraUrl.String,
+			MondayURL:               project.MondayUrl.String,
+			OwnerUserID:             ref(project.OwnerUserID),
+			TestcaseTemplate:        project.TestcaseTemplate.String,
+			TestcaseCodePattern:     project.TestcaseCodePattern.String,
+			AutomatedTestingEnabled: project.AutomatedTestingEnabled,
+			SupportedRunners:        project.SupportedRunners,
+		},
+	}
+
+	modules, err := s.queries.GetProjectModules(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list modules: %w", err)
+	}
+	for _, m := range modules {
+		manifest.Modules = append(manifest.Modules, schema.BundleModule{
+			Name:        m.Name,
+			Code:        m.Code,
+			Priority:    m.Priority,
+			Type:        m.Type,
+			Description: m.Description,
+		})
+	}
+
+	environments, err := s.queries.ListEnvironmentsByProject(ctx, common.NewNullInt32(projectID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, env := range environments {
+		manifest.Environments = append(manifest.Environments, schema.BundleEnvironment{
+			ID:          env.ID,
+			Name:        env.Name,
+			Description: env.Description.String,
+			BaseURL:     env.BaseUrl.String,
+		})
+	}
+
+	customFields, err := s.queries.ListCustomFieldsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	for _, field := range customFields {
+		manifest.CustomFields = append(manifest.CustomFields, schema.BundleCustomField{
+			Entity:       field.Entity,
+			Name:         field.Name,
+			Label:        field.Label,
+			Type:         field.FieldType,
+			Options:      field.Options,
+			IsRequired:   field.IsRequired,
+			DefaultValue: field.DefaultValue.RawMessage,
+			Position:     field.Position,
+		})
+	}
+
+	sharedSteps, err := s.queries.ListSharedStepsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list shared steps: %w", err)
+	}
+	for _, shared := range sharedSteps {
+		manifest.SharedSteps = append(manifest.SharedSteps, schema.BundleSharedStep{
+			ID:          shared.ID,
+			Name:        shared.Name,
+			Description: shared.Description,
+			Parameters:  schema.SharedStepParameters(&shared),
+			Steps:       schema.SharedStepSteps(&shared),
+			CreatedByID: ref(shared.CreatedByID.Int32),
+		})
+	}
+
+	testCases, err := s.queries.ListTestCasesByProject(ctx, common.NewNullInt32(projectID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+	testCaseIDs := make([]uuid.UUID, 0, len(testCases))
+	for _, tc := range testCases {
+		testCaseIDs = append(testCaseIDs, tc.ID)
+	}
+	testCaseValues, err := rawCustomFieldValues(ctx, s.queries, testCaseIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tc := range testCases {
+		item := schema.BundleTestCase{
+			ID:              tc.ID.String(),
+			Kind:            string(tc.Kind),
+			Code:            tc.Code,
+			FeatureOrModule: tc.FeatureOrModule.String,
+			Title:           tc.Title,
+			Description:     tc.Description,
+			IsDraft:         tc.IsDraft.Bool,
+			Tags:            tc.Tags,
+			CreatedByID:     ref(tc.CreatedByID),
+			CreatedAt:       common.TimeOrNil(tc.CreatedAt),
+			UpdatedAt:       common.TimeOrNil(tc.UpdatedAt),
+			Suggested:       tc.Suggested.Bool,
+			Runner:          tc.Runner.String,
+		}
+		if tc.ParentTestCaseID.Valid {
+			item.ParentTestCaseID = tc.ParentTestCaseID.UUID.String()
+		}
+		if name, ok := bundleFileName(tc.ScriptPath.String); ok {
+			item.ScriptPath = name
+			files[name] = filepath.Join(s.storagePath, filepath.FromSlash(name))
+		}
+		steps, err := s.queries.ListTestCaseSteps(ctx, tc.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list steps of test case %s: %w", tc.Code, err)
+		}
+		item.Steps = testCaseStepRequests(steps)
+		dataset, err := s.queries.GetTestCaseDataset(ctx, tc.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("failed to fetch dataset of test case %s: %w", tc.Code, err)
+		}
+		if err == nil {
+			item.Dataset = &schema.BundleTestCaseDataset{
+				Parameters: dataset.Parameters,
+				Rows:       schema.DatasetRows(&dataset),
+			}
+		}
+		item.CustomFields = remapCustomFieldUsers(customFields, CustomFieldEntityTestCase, testCaseValues[tc.ID], ref)
+		manifest.TestCases = append(manifest.TestCases, item)
+	}
+
+	plans, err := s.queries.ListTestPlansByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test plans: %w", err)
+	}
+	for _, plan := range plans {
+		manifest.TestPlans = append(manifest.TestPlans, schema.BundleTestPlan{
+			ID:             plan.ID,
+			AssignedToID:   ref(plan.AssignedToID),
+			CreatedByID:    ref(plan.CreatedByID),
+			UpdatedByID:    ref(plan.UpdatedByID),
+			Kind:           string(plan.Kind),
+			Description:    plan.Description.String,
+			EnvironmentID:  plan.EnvironmentID.Int32,
+			StartAt:        common.TimeOrNil(plan.StartAt),
+			ClosedAt:       common.TimeOrNil(plan.ClosedAt),
+			ScheduledEndAt: common.TimeOrNil(plan.ScheduledEndAt),
+			NumTestCases:   plan.NumTestCases,
+			NumFailures:    plan.NumFailures,
+			IsComplete:     plan.IsComplete.Bool,
+			IsLocked:       plan.IsLocked.Bool,
+			HasReport:      plan.HasReport.Bool,
+			CreatedAt:      common.TimeOrNil(plan.CreatedAt),
+			UpdatedAt:      common.TimeOrNil(plan.UpdatedAt),
+		})
+	}
+
+	planCases, err := s.queries.ListTestPlanCasesByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test plan assignments: %w", err)
+	}
+	for _, pc := range planCases {
+		manifest.TestPlanCases = append(manifest.TestPlanCases, schema.BundleTestPlanCase{
+			TestPlanID:   pc.TestPlanID,
+			TestCaseID:   pc.TestCaseID.String(),
+			AssignedToID: int64(ref(int32(pc.AssignedToID))),
+		})
+	}
+
+	runs, err := s.queries.ListTestRunsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+	runIDs := make([]uuid.UUID, 0, len(runs))
+	for _, run := range runs {
+		runIDs = append(runIDs, run.ID)
+	}
+	runValues, err := rawCustomFieldValues(ctx, s.queries, runIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, run := range runs {
+		manifest.TestRuns = append(manifest.TestRuns, schema.BundleTestRun{
+			ID:                    run.ID.String(),
+			TestPlanID:            run.TestPlanID.Int32,
+			TestCaseID:            run.TestCaseID.String(),
+			OwnerID:               ref(run.OwnerID),
+			TestedByID:            ref(run.TestedByID.Int32),
+			AssignedToID:          ref(run.AssignedToID.Int32),
+			AssigneeCanChangeCode: run.AssigneeCanChangeCode.Bool,
+			Code:                  run.Code,
+			ExternalIssueID:       run.ExternalIssueID.String,
+			ResultState:           string(run.ResultState),
+			IsClosed:              run.IsClosed.Bool,
+			Notes:                 run.Notes,
+			ActualResult:          run.ActualResult.String,
+			ExpectedResult:        run.ExpectedResult.String,
+			Reactions:             run.Reactions.RawMessage,
+			TestedOn:              run.TestedOn,
+			CreatedAt:             common.TimeOrNil(run.CreatedAt),
+			UpdatedAt:             common.TimeOrNil(run.UpdatedAt),
+			EnvironmentID:         run.EnvironmentID.Int32,
+			DataRow:               run.DataRow.Int32,
+			DataValues:            schema.DataValues(run.DataValues),
+			CustomFields:          remapCustomFieldUsers(customFields, CustomFieldEntityTestRun, runValues[run.ID], ref),
+		})
+	}
+
+	results, err := s.queries.ListTestRunResultsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test run results: %w", err)
+	}
+	for _, result := range results {
+		manifest.TestRunResults = append(manifest.TestRunResults, schema.BundleTestRunResult{
+			ID:         result.ID.String(),
+			TestRunID:  result.TestRunID.String(),
+			Status:     string(result.Status),
+			Result:     result.Result,
+			Notes:      result.Notes.String,
+			ExecutedBy: ref(result.ExecutedBy.Int32),
+			ExecutedAt: result.ExecutedAt,
+			CreatedAt:  result.CreatedAt,
+			UpdatedAt:  result.UpdatedAt,
+		})
+	}
+
+	comments, err := s.queries.ListTestPlanCommentsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	for _, comment := range comments {
+		manifest.Comments = append(manifest.Comments, schema.BundleTestPlanComment{
+			TestPlanID: comment.TestPlanID,
+			UserID:     int64(ref(int32(comment.UserID))),
+			Content:    comment.Content,
+			CreatedAt:  common.TimeOrNil(comment.CreatedAt),
+			UpdatedAt:  common.TimeOrNil(comment.UpdatedAt),
+		})
+	}
+
+	pages, err := s.queries.ListPagesByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+	for _, page := range pages {
+		manifest.Pages = append(manifest.Pages, schema.BundlePage{
+			ID:                 page.ID,
+			ParentPageID:       page.ParentPageID.Int32,
+			PageVersion:        page.PageVersion,
+			Code:               page.Code,
+			Title:              page.Title,
+			FilePath:           page.FilePath.String,
+			Content:            page.Content,
+			PageType:           page.PageType,
+			MimeType:           page.MimeType,
+			HasEmbeddedMedia:   page.HasEmbeddedMedia,
+			ExternalContentURL: page.ExternalContentUrl.String,
+			NotionURL:          page.NotionUrl.String,
+			LastEditedBy:       ref(page.LastEditedBy),
+			CreatedBy:          ref(page.CreatedBy),
+			CreatedAt:          common.TimeOrNil(page.CreatedAt),
+			UpdatedAt:          common.TimeOrNil(page.UpdatedAt),
+			DeletedAt:          common.TimeOrNil(page.DeletedAt),
+		})
+	}
+
+	reports, err := s.queries.ListReportsByProject(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	for _, report := range reports {
+		item := schema.BundleReport{
+			Name:      report.Name,
+			Type:      report.Type,
+			Status:    report.Status,
+			CreatedAt: common.TimeOrNil(report.CreatedAt),
+			UpdatedAt: common.TimeOrNil(report.UpdatedAt),
+		}
+		if report.FilePath.Valid && report.FilePath.String != "" {
+			item.FilePath = path.Join("reports", report.ID.String()+filepath.Ext(report.FilePath.String))
+			files[item.FilePath] = report.FilePath.String
+		}
+		manifest.Reports = append(manifest.Reports, item)
+	}
+
+	ids := make([]int32, 0, len(userIDs))
+	for id := range userIDs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	users, err := s.queries.ListUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, user := range users {
+		manifest.Users = append(manifest.Users, schema.BundleUser{
+			ID:          user.ID,
+			Email:       user.Email,
+			DisplayName: user.DisplayName.String,
+		})
+	}
+	return manifest, files, nil
+}
+
+// bundleImport is a bundle resolved against this instance, it is computed
+// for dry runs as well so they report the same conflicts as the import
+type bundleImport struct {
+	manifest *schema.ProjectBundleManifest
+	files    map[string]*zip.File
+	orgID    int32
+	// importerID takes over the references to users missing on this instance
+	importerID int32
+	users      map[int32]int32
+	// scripts are the paths bundled scripts are stored at on this instance
+	scripts   map[string]string
+	conflicts []schema.ProjectImportConflict
+}
+
+func (b *bundleImport) user(id int32) int32 {
+	if id == 0 {
+		return 0
+	}
+	if local, ok := b.users[id]; ok {
+		return local
+	}
+	return b.importerID
+}
+
+func (b *bundleImport) conflict(kind, ref, format string, args ...any) {
+	b.conflicts = append(b.conflicts, schema.ProjectImportConflict{Kind: kind, Ref: ref, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *projectBundleServiceImpl) Import(ctx context.Context, r io.ReaderAt, size int64, opts *schema.ProjectImportOptions) (*schema.ProjectImportReport, error) {
+	orgID := contextOrgID(ctx)
+	if orgID == 0 {
+		return nil, ErrNoOrg
+	}
+	b, err := s.resolveBundle(ctx, r, size, orgID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := b.manifest
+	report := &schema.ProjectImportReport{
+		DryRun:        opts.DryRun,
+		FormatVersion: m.FormatVersion,
+		Counts: schema.ProjectBundleCounts{
+			Modules:        len(m.Modules),
+			Environments:   len(m.Environments),
+			CustomFields:   len(m.CustomFields),
+			SharedSteps:    len(m.SharedSteps),
+			TestCases:      len(m.TestCases),
+			TestPlans:      len(m.TestPlans),
+			TestPlanCases:  len(m.TestPlanCases),
+			TestRuns:       len(m.TestRuns),
+			TestRunResults: len(m.TestRunResults),
+			Comments:       len(m.Comments),
+			Pages:          len(m.Pages),
+			Reports:        len(m.Reports),
+			Files:          len(b.files),
+		},
+		Conflicts: b.conflicts,
+	}
+	if report.Conflicts == nil {
+		report.Conflicts = []schema.ProjectImportConflict{}
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	projectID, err := s.importBundle(ctx, b, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.ProjectID = int64(projectID)
+	s.logger.Info("project-bundle-service", "imported project bundle", "projectID", projectID, "source", m.SourceInstance, "conflicts", len(b.conflicts))
+	return report, nil
+}
+
+// resolveBundle reads the bundle and matches its users, project code and
+// files against this instance
+func (s *projectBundleServiceImpl) resolveBundle(ctx context.Context, r io.ReaderAt, size int64, orgID int32, opts *schema.ProjectImportOptions) (*bundleImport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	b := &bundleImport{
+		files:      map[string]*zip.File{},
+		orgID:      orgID,
+		importerID: int32(opts.ImportedByID),
+		users:      map[int32]int32{},
+		scripts:    map[string]string{},
+	}
+	var manifestFile *zip.File
+	for _, f := range zr.File {
+		if f.Name == bundleManifestName {
+			manifestFile = f
+		} else if name, ok := strings.CutPrefix(f.Name, bundleFilesDir); ok && !f.FileInfo().IsDir() {
+			if name, ok := bundleFileName(name); ok {
+				b.files[name] = f
+			}
+		}
+	}
+	if manifestFile == nil {
+		return nil, ErrInvalidBundle
+	}
+	data, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	b.manifest = new(schema.ProjectBundleManifest)
+	if err := json.Unmarshal(data, b.manifest); err != nil {
+		return nil, ErrInvalidBundle
+	}
+	if b.manifest.FormatVersion < 1 || b.manifest.FormatVersion > schema.ProjectBundleFormatVersion {
+		return nil, ErrUnsupportedBundleVersion
+	}
+	m := b.manifest
+
+	// users are only matched among the members of the importing org, work of
+	// anyone else is attributed to the importer
+	members, err := s.queries.ListOrgMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list org members: %w", err)
+	}
+	memberIDs := make(map[string]int32, len(members))
+	for _, member := range members {
+		memberIDs[strings.ToLower(member.Email)] = member.UserID
+	}
+	for _, u := range m.Users {
+		userID, ok := memberIDs[strings.ToLower(u.Email)]
+		if !ok {
+			b.conflict(ImportConflictUser, u.Email, "no member of the org with this email, their work is attributed to the importing user")
+			continue
+		}
+		b.users[u.ID] = userID
+	}
+
+	code := cmp.Or(opts.Code, m.Project.Code)
+	projects, err := s.queries.ListProjectsByOrg(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	for _, p := range projects {
+		if strings.EqualFold(p.Code, code) {
+			b.conflict(ImportConflictProject, code, "project %d already uses this code, test case codes may be ambiguous", p.ID)
+			break
+		}
+	}
+
+	testCaseIDs := map[string]bool{}
+	for _, tc := range m.TestCases {
+		testCaseIDs[tc.ID] = true
+	}
+	for _, tc := range m.TestCases {
+		if tc.ParentTestCaseID != "" && !testCaseIDs[tc.ParentTestCaseID] {
+			b.conflict(ImportConflictReference, tc.Code, "parent test case is not in the bundle, the link is dropped")
+		}
+		if tc.ScriptPath == "" {
+			continue
+		}
+		target, err := s.resolveScript(b, tc)
+		if err != nil {
+			return nil, err
+		}
+		if target != "" {
+			b.scripts[tc.ScriptPath] = target
+		}
+	}
+
+	planIDs := map[int64]bool{}
+	for _, plan := range m.TestPlans {
+		planIDs[plan.ID] = true
+	}
+	for _, pc := range m.TestPlanCases {
+		if !planIDs[pc.TestPlanID] || !testCaseIDs[pc.TestCaseID] {
+			b.conflict(ImportConflictReference, fmt.Sprintf("%d/%s", pc.TestPlanID, pc.TestCaseID), "test plan assignment refers to entities not in the bundle, it is skipped")
+		}
+	}
+	for _, run := range m.TestRuns {
+		if !testCaseIDs[run.TestCaseID] {
+			b.conflict(ImportConflictReference, run.Code, "test run refers to a test case not in the bundle, it is skipped with its results")
+		}
+	}
+	for _, report := range m.Reports {
+		if report.FilePath != "" && b.files[report.FilePath] == nil {
+			b.conflict(ImportConflictFile, report.FilePath, "report file is missing from the bundle, the report is imported without it")
+		}
+	}
+	return b, nil
+}
+
+// resolveScript picks where a bundled script is stored, an identical file
+// already stored at the path is shared and a different one is not replaced
+func (s *projectBundleServiceImpl) resolveScript(b *bundleImport, tc schema.BundleTestCase) (string, error) {
+	f := b.files[tc.ScriptPath]
+	if f == nil {
+		b.conflict(ImportConflictFile, tc.ScriptPath, "script of test case %s is missing from the bundle, it is imported without a script", tc.Code)
+		return "", nil
+	}
+	existing, err := os.ReadFile(filepath.Join(s.storagePath, filepath.FromSlash(tc.ScriptPath)))
+	if errors.Is(err, os.ErrNotExist) {
+		return tc.ScriptPath, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read script: %w", err)
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(existing, data) {
+		return tc.ScriptPath, nil
+	}
+	target := path.Join(path.Dir(tc.ScriptPath), "imported", tc.ID+"-"+path.Base(tc.ScriptPath))
+	b.conflict(ImportConflictFile, tc.ScriptPath, "a different script is stored at this path, the script of test case %s is stored as %s", tc.Code, target)
+	return target, nil
+}
+
+// importBundle creates the project with everything in the bundle in one
+// transaction, the IDs are new and users are mapped by email
+func (s *projectBundleServiceImpl) importBundle(ctx context.Context, b *bundleImport, opts *schema.ProjectImportOptions) (int32, error) {
+	m := b.manifest
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlTx.Rollback()
+	tx := s.queries.WithTx(sqlTx)
+	now := time.Now()
+
+	projectCode := cmp.Or(opts.Code, m.Project.Code)
+	projectID, err := tx.CreateProject(ctx, dbsqlc.CreateProjectParams{
+		Title:                   cmp.Or(opts.Name, m.Project.Title),
+		Code:                    projectCode,
+		Description:             m.Project.Description,
+		Version:                 common.NullString(m.Project.Version),
+		IsActive:                common.NewNullBool(m.Project.IsActive),
+		IsPublic:                common.NewNullBool(m.Project.IsPublic),
+		WebsiteUrl:              common.NullString(m.Project.WebsiteURL),
+		GithubUrl:               common.NullString(m.Project.GithubURL),
+		TrelloUrl:               common.NullString(m.Project.TrelloURL),
+		JiraUrl:                 common.NullString(m.Project.JiraURL),
+		MondayUrl:               common.NullString(m.Project.MondayURL),
+		OwnerUserID:             b.user(m.Project.OwnerUserID),
+		CreatedAt:               now,
+		UpdatedAt:               now,
+		AutomatedTestingEnabled: m.Project.AutomatedTestingEnabled,
+		SupportedRunners:        m.Project.SupportedRunners,
+		OrgID:                   b.orgID,
+		TestcaseTemplate:        common.NullString(m.Project.TestcaseTemplate),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create project: %w", err)
+	}
+	if m.Project.TestcaseCodePattern != "" {
+		err := tx.SetProjectTestCaseCodePattern(ctx, dbsqlc.SetProjectTestCaseCodePatternParams{
+			ID:                  projectID,
+			TestcaseCodePattern: common.NullString(m.Project.TestcaseCodePattern),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to set code pattern: %w", err)
+		}
+	}
+	project, err := tx.GetProject(ctx, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	for _, module := range m.Modules {
+		_, err := tx.CreateProjectModules(ctx, dbsqlc.CreateProjectModulesParams{
+			ProjectID:   projectID,
+			Name:        module.Name,
+			Code:        module.Code,
+			Priority:    module.Priority,
+			Type:        module.Type,
+			Description: module.Description,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import module %q: %w", module.Name, err)
+		}
+	}
+
+	environmentIDs := map[int32]int32{}
+	for _, env := range m.Environments {
+		created, err := tx.CreateEnvironment(ctx, dbsqlc.CreateEnvironmentParams{
+			ProjectID:   common.NewNullInt32(projectID),
+			Name:        env.Name,
+			Description: common.NullString(env.Description),
+			BaseUrl:     common.NullString(env.BaseURL),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import environment %q: %w", env.Name, err)
+		}
+		environmentIDs[env.ID] = created.ID
+	}
+	environment := func(id int32) sql.NullInt32 {
+		return common.NewNullInt32(environmentIDs[id])
+	}
+
+	customFields := make([]dbsqlc.CustomField, 0, len(m.CustomFields))
+	for _, field := range m.CustomFields {
+		created, err := tx.CreateCustomField(ctx, dbsqlc.CreateCustomFieldParams{
+			ProjectID:    projectID,
+			Entity:       field.Entity,
+			Name:         field.Name,
+			Label:        field.Label,
+			FieldType:    field.Type,
+			Options:      nonNilStrings(field.Options),
+			IsRequired:   field.IsRequired,
+			DefaultValue: pqtype.NullRawMessage{RawMessage: field.DefaultValue, Valid: len(field.DefaultValue) > 0},
+			Position:     field.Position,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import custom field %s: %w", field.Name, err)
+		}
+		customFields = append(customFields, created)
+	}
+
+	sharedStepIDs := map[int32]int32{}
+	for _, shared := range m.SharedSteps {
+		parameters, steps, err := sharedStepContent(shared.Parameters, shared.Steps)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import shared step %q: %w", shared.Name, err)
+		}
+		created, err := tx.CreateSharedStep(ctx, dbsqlc.CreateSharedStepParams{
+			ProjectID:   projectID,
+			Name:        shared.Name,
+			Description: shared.Description,
+			Parameters:  parameters,
+			Steps:       steps,
+			CreatedByID: common.NewNullInt32(b.user(shared.CreatedByID)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import shared step %q: %w", shared.Name, err)
+		}
+		sharedStepIDs[shared.ID] = created.ID
+	}
+
+	testCaseIDs := map[string]uuid.UUID{}
+	codes := make([]string, 0, len(m.TestCases))
+	for _, tc := range m.TestCases {
+		id, _ := uuid.NewV7()
+		_, err := tx.CreateTestCase(ctx, dbsqlc.CreateTestCaseParams{
+			ID:              id,
+			Kind:            dbsqlc.TestKind(tc.Kind),
+			Code:            tc.Code,
+			FeatureOrModule: common.NullString(tc.FeatureOrModule),
+			Title:           tc.Title,
+			Description:     tc.Description,
+			IsDraft:         common.NewNullBool(tc.IsDraft),
+			Tags:            tc.Tags,
+			CreatedByID:     b.user(tc.CreatedByID),
+			CreatedAt:       common.NullTime(common.ZeroOrTime(tc.CreatedAt)),
+			UpdatedAt:       common.NullTime(common.ZeroOrTime(tc.UpdatedAt)),
+			ProjectID:       common.NewNullInt32(projectID),
+			Suggested:       common.NewNullBool(tc.Suggested),
+			Runner:          common.NullString(tc.Runner),
+			ScriptPath:      common.NullString(b.scripts[tc.ScriptPath]),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import test case %s: %w", tc.Code, err)
+		}
+		steps := slices.Clone(tc.Steps)
+		for i := range steps {
+			if steps[i].SharedStepID == 0 {
+				continue
+			}
+			sharedStepID, ok := sharedStepIDs[steps[i].SharedStepID]
+			if !ok {
+				return 0, fmt.Errorf("%w: test case %s references shared step %d which is not in the bundle", ErrInvalidSharedStep, tc.Code, steps[i].SharedStepID)
+			}
+			steps[i].SharedStepID = sharedStepID
+		}
+		if err := saveTestCaseSteps(ctx, tx, projectID, id, steps); err != nil {
+			return 0, fmt.Errorf("failed to import steps of test case %s: %w", tc.Code, err)
+		}
+		if tc.Dataset != nil {
+			if _, err := saveTestCaseDataset(ctx, tx, id, tc.Dataset.Parameters, tc.Dataset.Rows, common.NewNullInt32(b.user(tc.CreatedByID))); err != nil {
+				return 0, fmt.Errorf("failed to import dataset of test case %s: %w", tc.Code, err)
+			}
+		}
+		values := remapCustomFieldUsers(customFields, CustomFieldEntityTestCase, tc.CustomFields, b.user)
+		if err := storeCustomFieldValues(ctx, tx, customFields, CustomFieldEntityTestCase, id, values); err != nil {
+			return 0, fmt.Errorf("failed to import custom fields of test case %s: %w", tc.Code, err)
+		}
+		if _, err := recordTestCaseRevision(ctx, tx, id, 0); err != nil {
+			return 0, err
+		}
+		testCaseIDs[tc.ID] = id
+		codes = append(codes, tc.Code)
+	}
+	// generated codes continue after the imported ones
+	if err := advanceTestCaseSequences(ctx, tx, &project, codes); err != nil {
+		return 0, err
+	}
+	for _, tc := range m.TestCases {
+		parentID, ok := testCaseIDs[tc.ParentTestCaseID]
+		if !ok {
+			continue
+		}
+		err := tx.SetTestCaseParent(ctx, dbsqlc.SetTestCaseParentParams{
+			ID:               testCaseIDs[tc.ID],
+			ParentTestCaseID: uuid.NullUUID{UUID: parentID, Valid: true},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to link parent of test case %s: %w", tc.Code, err)
+		}
+	}
+
+	planIDs := map[int64]int64{}
+	for _, plan := range m.TestPlans {
+		id, err := tx.CreateTestPlan(ctx, dbsqlc.CreateTestPlanParams{
+			ProjectID:      projectID,
+			AssignedToID:   b.user(plan.AssignedToID),
+			CreatedByID:    b.user(plan.CreatedByID),
+			UpdatedByID:    b.user(plan.UpdatedByID),
+			Kind:           dbsqlc.TestKind(plan.Kind),
+			Description:    common.NullString(plan.Description),
+			EnvironmentID:  environment(plan.EnvironmentID),
+			StartAt:        common.NullTime(common.ZeroOrTime(plan.StartAt)),
+			ClosedAt:       common.NullTime(common.ZeroOrTime(plan.ClosedAt)),
+			ScheduledEndAt: common.NullTime(common.ZeroOrTime(plan.ScheduledEndAt)),
+			NumTestCases:   plan.NumTestCases,
+			NumFailures:    plan.NumFailures,
+			IsComplete:     common.NewNullBool(plan.IsComplete),
+			IsLocked:       common.NewNullBool(plan.IsLocked),
+			HasReport:      common.NewNullBool(plan.HasReport),
+			CreatedAt:      common.NullTime(common.ZeroOrTime(plan.CreatedAt)),
+			UpdatedAt:      common.NullTime(common.ZeroOrTime(plan.UpdatedAt)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import test plan %d: %w", plan.ID, err)
+		}
+		planIDs[plan.ID] = int64(id)
+	}
+
+	for _, pc := range m.TestPlanCases {
+		planID, planOK := planIDs[pc.TestPlanID]
+		testCaseID, caseOK := testCaseIDs[pc.TestCaseID]
+		if !planOK || !caseOK {
+			continue
+		}
+		err := tx.AddTestCaseToPlan(ctx, dbsqlc.AddTestCaseToPlanParams{
+			TestPlanID:   planID,
+			TestCaseID:   testCaseID,
+			AssignedToID: int64(b.user(int32(pc.AssignedToID))),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import test plan assignment: %w", err)
+		}
+	}
+
+	runIDs := map[string]uuid.UUID{}
+	for _, run := range m.TestRuns {
+		testCaseID, ok := testCaseIDs[run.TestCaseID]
+		if !ok {
+			continue
+		}
+		id, _ := uuid.NewV7()
+		err := tx.ImportTestRun(ctx, dbsqlc.ImportTestRunParams{
+			ID:                    id,
+			ProjectID:             projectID,
+			TestPlanID:            common.NewNullInt32(int32(planIDs[int64(run.TestPlanID)])),
+			TestCaseID:            testCaseID,
+			OwnerID:               b.user(run.OwnerID),
+			TestedByID:            common.NewNullInt32(b.user(run.TestedByID)),
+			AssignedToID:          common.NewNullInt32(b.user(run.AssignedToID)),
+			AssigneeCanChangeCode: common.NewNullBool(run.AssigneeCanChangeCode),
+			Code:                  run.Code,
+			ExternalIssueID:       common.NullString(run.ExternalIssueID),
+			ResultState:           dbsqlc.TestRunState(run.ResultState),
+			IsClosed:              common.NewNullBool(run.IsClosed),
+			Notes:                 run.Notes,
+			ActualResult:          common.NullString(run.ActualResult),
+			ExpectedResult:        common.NullString(run.ExpectedResult),
+			Reactions:             pqtype.NullRawMessage{RawMessage: run.Reactions, Valid: len(run.Reactions) > 0},
+			TestedOn:              run.TestedOn,
+			CreatedAt:             common.NullTime(common.ZeroOrTime(run.CreatedAt)),
+			UpdatedAt:             common.NullTime(common.ZeroOrTime(run.UpdatedAt)),
+			EnvironmentID:         environment(run.EnvironmentID),
+			DataRow:               common.NewNullInt32(run.DataRow),
+			DataValues:            dataValuesJSON(run.DataValues),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import test run %s: %w", run.Code, err)
+		}
+		values := remapCustomFieldUsers(customFields, CustomFieldEntityTestRun, run.CustomFields, b.user)
+		if err := storeCustomFieldValues(ctx, tx, customFields, CustomFieldEntityTestRun, id, values); err != nil {
+			return 0, fmt.Errorf("failed to import custom fields of test run %s: %w", run.Code, err)
+		}
+		runIDs[run.ID] = id
+	}
+
+	for _, result := range m.TestRunResults {
+		runID, ok := runIDs[result.TestRunID]
+		if !ok {
+			continue
+		}
+		id, _ := uuid.NewV7()
+		_, err := tx.InsertTestRunResult(ctx, dbsqlc.InsertTestRunResultParams{
+			ID:         id,
+			TestRunID:  runID,
+			Status:     dbsqlc.TestRunState(result.Status),
+			Result:     result.Result,
+			Notes:      common.NullString(result.Notes),
+			ExecutedBy: common.NewNullInt32(b.user(result.ExecutedBy)),
+			ExecutedAt: result.ExecutedAt,
+			CreatedAt:  result.CreatedAt,
+			UpdatedAt:  result.UpdatedAt,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import test run result: %w", err)
+		}
+	}
+
+	for _, comment := range m.Comments {
+		planID, ok := planIDs[comment.TestPlanID]
+		if !ok {
+			continue
+		}
+		err := tx.ImportTestPlanComment(ctx, dbsqlc.ImportTestPlanCommentParams{
+			ID:         uuid.New(),
+			TestPlanID: planID,
+			UserID:     int64(b.user(int32(comment.UserID))),
+			Content:    comment.Content,
+			CreatedAt:  common.NullTime(common.ZeroOrTime(comment.CreatedAt)),
+			UpdatedAt:  common.NullTime(common.ZeroOrTime(comment.UpdatedAt)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import comment: %w", err)
+		}
+	}
+
+	pageIDs := map[int32]int32{}
+	for _, page := range m.Pages {
+		id, err := tx.ImportPage(ctx, dbsqlc.ImportPageParams{
+			PageVersion:        page.PageVersion,
+			OrgID:              b.orgID,
+			ProjectID:          projectID,
+			Code:               page.Code,
+			Title:              page.Title,
+			FilePath:           common.NullString(page.FilePath),
+			Content:            page.Content,
+			PageType:           page.PageType,
+			MimeType:           page.MimeType,
+			HasEmbeddedMedia:   page.HasEmbeddedMedia,
+			ExternalContentUrl: common.NullString(page.ExternalContentURL),
+			NotionUrl:          common.NullString(page.NotionURL),
+			LastEditedBy:       b.user(page.LastEditedBy),
+			CreatedBy:          b.user(page.CreatedBy),
+			CreatedAt:          common.NullTime(common.ZeroOrTime(page.CreatedAt)),
+			UpdatedAt:          common.NullTime(common.ZeroOrTime(page.UpdatedAt)),
+			DeletedAt:          common.NullTime(common.ZeroOrTime(page.DeletedAt)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import page %q: %w", page.Code, err)
+		}
+		pageIDs[page.ID] = id
+	}
+	for _, page := range m.Pages {
+		parentID, ok := pageIDs[page.ParentPageID]
+		if !ok {
+			continue
+		}
+		err := tx.SetPageParent(ctx, dbsqlc.SetPageParentParams{
+			ID:           pageIDs[page.ID],
+			ParentPageID: common.NewNullInt32(parentID),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to link parent of page %q: %w", page.Code, err)
+		}
+	}
+
+	files := map[string]*zip.File{}
+	for _, report := range m.Reports {
+		id := uuid.New()
+		var filePath string
+		if f := b.files[report.FilePath]; f != nil {
+			filePath = filepath.Join(reportDir, id.String()+path.Ext(report.FilePath))
+			files[filePath] = f
+		}
+		err := tx.ImportReport(ctx, dbsqlc.ImportReportParams{
+			ID:        id,
+			ProjectID: projectID,
+			Name:      report.Name,
+			Type:      report.Type,
+			Status:    report.Status,
+			CreatedAt: common.NullTime(common.ZeroOrTime(report.CreatedAt)),
+			FilePath:  common.NullString(filePath),
+			UpdatedAt: common.NullTime(common.ZeroOrTime(report.UpdatedAt)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to import report %q: %w", report.Name, err)
+		}
+	}
+	for bundled, target := range b.scripts {
+		files[filepath.Join(s.storagePath, filepath.FromSlash(target))] = b.files[bundled]
+	}
+
+	// files are written before the commit so the project never refers to
+	// files which do not exist
+	for target, f := range files {
+		if err := writeZipFile(f, target); err != nil {
+			return 0, err
+		}
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit project import: %w", err)
+	}
+	return projectID, nil
+}
+
+// remapCustomFieldUsers passes the values of the user fields of the entity
+// through remap, it is used to record and resolve the users they refer to
+func remapCustomFieldUsers(fields []dbsqlc.CustomField, entity string, values map[string]json.RawMessage, remap func(int32) int32) map[string]json.RawMessage {
+	for _, field := range fields {
+		if field.Entity != entity || field.FieldType != validation.CustomFieldUser {
+			continue
+		}
+		var id int32
+		if err := json.Unmarshal(values[field.Name], &id); err != nil {
+			continue
+		}
+		values[field.Name], _ = json.Marshal(remap(id))
+	}
+	return values
+}
+
+// bundleFileName cleans a relative file path for use in a bundle, paths
+// leaving the storage directory are refused
+func bundleFileName(name string) (string, bool) {
+	if name == "" || path.IsAbs(name) || filepath.IsAbs(name) {
+		return "", false
+	}
+	name = path.Clean(filepath.ToSlash(name))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// readZipFile reads a file of the bundle, files over bundleMaxFileSize are
+// refused whatever size their header claims
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > bundleMaxFileSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", ErrInvalidBundle, f.Name, bundleMaxFileSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, bundleMaxFileSize+1))
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	if len(data) > bundleMaxFileSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", ErrInvalidBundle, f.Name, bundleMaxFileSize>>20)
+	}
+	return data, nil
+}
+
+func writeZipFile(f *zip.File, target string) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", target, err)
+	}
+	if err := os.WriteFile(target, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
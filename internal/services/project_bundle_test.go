@@ -0,0 +1,83 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"scripts/login.spec.ts":    "scripts/login.spec.ts",
+		"scripts/./a/../login.sh":  "scripts/login.sh",
+		"reports/report.pdf":       "reports/report.pdf",
+		"../etc/passwd":            "",
+		"scripts/../../etc/passwd": "",
+		"/etc/passwd":              "",
+		"..":                       "",
+		"":                         "",
+	} {
+		got, ok := bundleFileName(name)
+		assert.Equal(t, want != "", ok, name)
+		assert.Equal(t, want, got, name)
+	}
+}
+
+func testBundle(t *testing.T, files map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestImportRejectsInvalidBundles(t *testing.T) {
+	svc := NewProjectBundleService(&config.Config{}, nil, nil, logging.NewForTest())
+	ctx := WithOrgID(context.Background(), 1)
+	opts := &schema.ProjectImportOptions{ImportedByID: 1, DryRun: true}
+
+	notZip := bytes.NewReader([]byte("not a zip"))
+	_, err := svc.Import(ctx, notZip, notZip.Size(), opts)
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	noManifest := testBundle(t, map[string]string{"files/scripts/a.sh": "echo"})
+	_, err = svc.Import(ctx, noManifest, noManifest.Size(), opts)
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	badManifest := testBundle(t, map[string]string{"manifest.json": "{"})
+	_, err = svc.Import(ctx, badManifest, badManifest.Size(), opts)
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+
+	newer := testBundle(t, map[string]string{"manifest.json": `{"format_version": 99}`})
+	_, err = svc.Import(ctx, newer, newer.Size(), opts)
+	assert.ErrorIs(t, err, ErrUnsupportedBundleVersion)
+
+	_, err = svc.Import(context.Background(), newer, newer.Size(), opts)
+	assert.ErrorIs(t, err, ErrNoOrg)
+}
+
+func TestReadZipFileRefusesLargeFiles(t *testing.T) {
+	bundle := testBundle(t, map[string]string{"manifest.json": `{"format_version": 1}`})
+	zr, err := zip.NewReader(bundle, bundle.Size())
+	assert.NoError(t, err)
+
+	f := zr.File[0]
+	data, err := readZipFile(f)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"format_version": 1}`, string(data))
+
+	f.UncompressedSize64 = bundleMaxFileSize + 1
+	_, err = readZipFile(f)
+	assert.ErrorIs(t, err, ErrInvalidBundle)
+}
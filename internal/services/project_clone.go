@@ -0,0 +1,317 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/google/uuid"
+)
+
+// Clone implements ProjectService, it copies the project with its modules,
+// environments, custom fields, test cases and test case template in one
+// transaction and optionally its testers and open test plans
+func (s *projectServiceImpl) Clone(ctx context.Context, projectID int64, request *schema.CloneProjectRequest) (*dbsqlc.Project, *schema.CloneProjectCounts, error) {
+	source, err := s.FindByID(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlTx, err := s.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := s.db.WithTx(sqlTx)
+	now := time.Now()
+
+	cloneID, err := tx.CreateProject(ctx, dbsqlc.CreateProjectParams{
+		Title:                   request.Name,
+		Code:                    request.Code,
+		Description:             source.Description,
+		Version:                 common.NullString(cmp.Or(request.Version, source.Version.String)),
+		IsActive:                common.TrueNullBool(),
+		IsPublic:                source.IsPublic,
+		WebsiteUrl:              source.WebsiteUrl,
+		GithubUrl:               source.GithubUrl,
+		TrelloUrl:               source.TrelloUrl,
+		JiraUrl:                 source.JiraUrl,
+		MondayUrl:               source.MondayUrl,
+		OwnerUserID:             int32(request.ProjectOwnerID),
+		CreatedAt:               now,
+		UpdatedAt:               now,
+		AutomatedTestingEnabled: source.AutomatedTestingEnabled,
+		SupportedRunners:        source.SupportedRunners,
+		OrgID:                   source.OrgID,
+		ParentProjectID:         common.NewNullInt32(source.ID),
+		TestcaseTemplate:        source.TestcaseTemplate,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create project: %w", err)
+	}
+	if source.TestcaseCodePattern.Valid {
+		err := tx.SetProjectTestCaseCodePattern(ctx, dbsqlc.SetProjectTestCaseCodePatternParams{
+			ID:                  cloneID,
+			TestcaseCodePattern: source.TestcaseCodePattern,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to copy code pattern: %w", err)
+		}
+	}
+	clone, err := tx.GetProject(ctx, cloneID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	counts := &schema.CloneProjectCounts{}
+
+	modules, err := tx.GetProjectModules(ctx, source.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list modules: %w", err)
+	}
+	for _, module := range modules {
+		_, err := tx.CreateProjectModules(ctx, dbsqlc.CreateProjectModulesParams{
+			ProjectID:   cloneID,
+			Name:        module.Name,
+			Code:        module.Code,
+			Priority:    module.Priority,
+			Type:        module.Type,
+			Description: module.Description,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to copy module %q: %w", module.Name, err)
+		}
+		counts.Modules++
+	}
+
+	environments, err := tx.ListEnvironmentsByProject(ctx, common.NewNullInt32(source.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	environmentIDs := make(map[int32]int32, len(environments))
+	for _, env := range environments {
+		created, err := tx.CreateEnvironment(ctx, dbsqlc.CreateEnvironmentParams{
+			ProjectID:   common.NewNullInt32(cloneID),
+			Name:        env.Name,
+			Description: env.Description,
+			BaseUrl:     env.BaseUrl,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to copy environment %q: %w", env.Name, err)
+		}
+		environmentIDs[env.ID] = created.ID
+		counts.Environments++
+	}
+
+	customFields, err := tx.ListCustomFieldsByProject(ctx, source.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	customFields, err = copyCustomFields(ctx, tx, cloneID, customFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts.CustomFields = len(customFields)
+
+	sharedSteps, err := tx.ListSharedStepsByProject(ctx, source.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list shared steps: %w", err)
+	}
+	sharedStepIDs, err := copySharedSteps(ctx, tx, cloneID, sharedSteps)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts.SharedSteps = len(sharedStepIDs)
+
+	testCaseIDs, err := s.cloneTestCases(ctx, tx, source, &clone, request.KeepTestCaseCodes, sharedStepIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts.TestCases = len(testCaseIDs)
+	if err := copyCustomFieldValues(ctx, tx, customFields, CustomFieldEntityTestCase, testCaseIDs); err != nil {
+		return nil, nil, err
+	}
+
+	if request.IncludeTesters {
+		testers, err := tx.GetTestersByProject(ctx, source.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list testers: %w", err)
+		}
+		for _, tester := range testers {
+			if !tester.IsActive {
+				continue
+			}
+			err := tx.UpsertProjectTester(ctx, dbsqlc.UpsertProjectTesterParams{
+				ProjectID: cloneID,
+				UserID:    tester.UserID,
+				Role:      tester.Role,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to copy tester %d: %w", tester.UserID, err)
+			}
+			counts.Testers++
+		}
+	}
+
+	if request.IncludeOpenTestPlans {
+		counts.TestPlans, err = cloneOpenTestPlans(ctx, tx, source.ID, cloneID, int32(request.ProjectOwnerID), environmentIDs, testCaseIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit project clone: %w", err)
+	}
+	s.logger.Info(s.name, "cloned project", "sourceProjectID", source.ID, "projectID", cloneID)
+	return &clone, counts, nil
+}
+
+// cloneTestCases copies the test cases of source into clone and returns the
+// IDs of the copies by the IDs of the originals, steps reference the copies
+// of the shared steps in sharedStepIDs
+func (s *projectServiceImpl) cloneTestCases(ctx context.Context, tx *dbsqlc.Queries, source, clone *dbsqlc.Project, keepCodes bool, sharedStepIDs map[int32]int32) (map[uuid.UUID]uuid.UUID, error) {
+	if keepCodes {
+		// continue numbering after the kept codes so new cases do not collide
+		err := tx.CopyTestCaseSequences(ctx, dbsqlc.CopyTestCaseSequencesParams{
+			TargetProjectID: clone.ID,
+			SourceProjectID: source.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy test case sequences: %w", err)
+		}
+	}
+
+	testCases, err := tx.ListTestCasesByProject(ctx, common.NewNullInt32(source.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID, len(testCases))
+	for _, tc := range testCases {
+		code := tc.Code
+		if !keepCodes {
+			code, err = GenerateNextCode(ctx, tx, clone, string(tc.Kind), tc.FeatureOrModule.String, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		id, _ := uuid.NewV7()
+		_, err := tx.CreateTestCase(ctx, dbsqlc.CreateTestCaseParams{
+			ID:              id,
+			Kind:            tc.Kind,
+			Code:            code,
+			FeatureOrModule: tc.FeatureOrModule,
+			Title:           tc.Title,
+			Description:     tc.Description,
+			IsDraft:         tc.IsDraft,
+			Tags:            tc.Tags,
+			CreatedByID:     tc.CreatedByID,
+			CreatedAt:       tc.CreatedAt,
+			UpdatedAt:       common.NewNullTime(time.Now()),
+			ProjectID:       common.NewNullInt32(clone.ID),
+			Suggested:       tc.Suggested,
+			Runner:          tc.Runner,
+			ScriptPath:      tc.ScriptPath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy test case %s: %w", tc.Code, err)
+		}
+		if err := copyTestCaseSteps(ctx, tx, clone.ID, tc.ID, id, sharedStepIDs); err != nil {
+			return nil, fmt.Errorf("failed to copy steps of test case %s: %w", tc.Code, err)
+		}
+		if err := copyTestCaseDataset(ctx, tx, tc.ID, id); err != nil {
+			return nil, fmt.Errorf("failed to copy dataset of test case %s: %w", tc.Code, err)
+		}
+		if _, err := recordTestCaseRevision(ctx, tx, id, 0); err != nil {
+			return nil, err
+		}
+		ids[tc.ID] = id
+	}
+
+	// parents are linked once all copies exist, parents in other projects
+	// stay linked to the original
+	for _, tc := range testCases {
+		if !tc.ParentTestCaseID.Valid {
+			continue
+		}
+		parent := tc.ParentTestCaseID
+		if copied, ok := ids[parent.UUID]; ok {
+			parent = uuid.NullUUID{UUID: copied, Valid: true}
+		}
+		err := tx.SetTestCaseParent(ctx, dbsqlc.SetTestCaseParentParams{
+			ID:               ids[tc.ID],
+			ParentTestCaseID: parent,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to link parent of test case %s: %w", tc.Code, err)
+		}
+	}
+	return ids, nil
+}
+
+// cloneOpenTestPlans copies the plans of the source project which are neither
+// complete nor closed, with their test cases but without any results
+func cloneOpenTestPlans(ctx context.Context, tx *dbsqlc.Queries, sourceID, cloneID, createdByID int32, environmentIDs map[int32]int32, testCaseIDs map[uuid.UUID]uuid.UUID) (int, error) {
+	plans, err := tx.ListTestPlansByProject(ctx, sourceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list test plans: %w", err)
+	}
+
+	count := 0
+	for _, plan := range plans {
+		if plan.IsComplete.Bool || plan.ClosedAt.Valid {
+			continue
+		}
+		var environmentID sql.NullInt32
+		if id, ok := environmentIDs[plan.EnvironmentID.Int32]; ok && plan.EnvironmentID.Valid {
+			environmentID = common.NewNullInt32(id)
+		}
+		planID, err := tx.CreateTestPlan(ctx, dbsqlc.CreateTestPlanParams{
+			ProjectID:      cloneID,
+			AssignedToID:   plan.AssignedToID,
+			CreatedByID:    createdByID,
+			UpdatedByID:    createdByID,
+			Kind:           plan.Kind,
+			Description:    plan.Description,
+			EnvironmentID:  environmentID,
+			StartAt:        plan.StartAt,
+			ScheduledEndAt: plan.ScheduledEndAt,
+			IsComplete:     common.FalseNullBool(),
+			IsLocked:       common.FalseNullBool(),
+			HasReport:      common.FalseNullBool(),
+			CreatedAt:      common.NewNullTime(time.Now()),
+			UpdatedAt:      common.NewNullTime(time.Now()),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to copy test plan %d: %w", plan.ID, err)
+		}
+
+		planCases, err := tx.ListTestPlanCases(ctx, plan.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list cases of test plan %d: %w", plan.ID, err)
+		}
+		for _, pc := range planCases {
+			testCaseID, ok := testCaseIDs[pc.TestCaseID]
+			if !ok {
+				continue
+			}
+			err := tx.AddTestCaseToPlan(ctx, dbsqlc.AddTestCaseToPlanParams{
+				TestPlanID:   int64(planID),
+				TestCaseID:   testCaseID,
+				AssignedToID: pc.AssignedToID,
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to copy cases of test plan %d: %w", plan.ID, err)
+			}
+		}
+		count++
+	}
+	return count, nil
+}
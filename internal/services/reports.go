@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,11 +16,15 @@ import (
 	"github.com/jung-kurt/gofpdf"
 )
 
+// reportDir is where the generated report files are stored
+const reportDir = "storage/reports"
+
 type ReportService interface {
 	ListByProject(ctx context.Context, projectID int64) ([]dbsqlc.Report, error)
 	Create(ctx context.Context, req *schema.CreateReportRequest) (*dbsqlc.Report, error)
-	GetByID(ctx context.Context, id string) (*dbsqlc.Report, error)
-	DeleteByID(ctx context.Context, id string) error
+	// GetByID fetches a report of the project, reports of other projects are not found
+	GetByID(ctx context.Context, projectID int64, id string) (*dbsqlc.Report, error)
+	DeleteByID(ctx context.Context, projectID int64, id string) error
 }
 
 type reportServiceImpl struct {
@@ -33,10 +38,28 @@ func NewReportService(db *sql.DB, queries *dbsqlc.Queries, logger logging.Logger
 }
 
 func (s *reportServiceImpl) ListByProject(ctx context.Context, projectID int64) ([]dbsqlc.Report, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
 	return s.queries.ListReportsByProject(ctx, int32(projectID))
 }
 
 func (s *reportServiceImpl) Create(ctx context.Context, req *schema.CreateReportRequest) (*dbsqlc.Report, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, req.ProjectID); err != nil {
+		return nil, err
+	}
+	plan, err := s.queries.GetTestPlan(ctx, req.TestPlanID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		s.logger.Error("reports-service", "failed to fetch test plan", "testPlanID", req.TestPlanID, "error", err)
+		return nil, fmt.Errorf("failed to fetch test plan: %w", err)
+	}
+	if int64(plan.ProjectID) != req.ProjectID {
+		return nil, ErrNotFound
+	}
+
 	id := uuid.New()
 	r, err := s.queries.CreateReport(ctx, dbsqlc.CreateReportParams{
 		ID:        id,
@@ -51,12 +74,6 @@ func (s *reportServiceImpl) Create(ctx context.Context, req *schema.CreateReport
 		return nil, fmt.Errorf("failed to create report: %w", err)
 	}
 
-	plan, err := s.queries.GetTestPlan(ctx, req.TestPlanID)
-	if err != nil {
-		s.logger.Error("reports-service", "failed to fetch test plan", "testPlanID", req.TestPlanID, "error", err)
-		return nil, fmt.Errorf("failed to fetch test plan: %w", err)
-	}
-
 	stats, err := s.queries.GetTestPlanRunStats(ctx, common.NewNullInt32(int32(req.TestPlanID)))
 	if err != nil {
 		s.logger.Error("reports-service", "failed to fetch plan stats", "testPlanID", req.TestPlanID, "error", err)
@@ -70,7 +87,6 @@ func (s *reportServiceImpl) Create(ctx context.Context, req *schema.CreateReport
 	}
 
 	// Ensure storage/reports directory exists
-	reportDir := "storage/reports"
 	if err := os.MkdirAll(reportDir, os.ModePerm); err != nil {
 		s.logger.Error("reports-service", "failed to create reports directory", "dir", reportDir, "error", err)
 		return nil, fmt.Errorf("failed to prepare reports directory: %w", err)
@@ -132,6 +148,9 @@ func (s *reportServiceImpl) Create(ctx context.Context, req *schema.CreateReport
 		}
 		pdf.CellFormat(30, 7, status, "1", 0, "", false, 0, "")
 		pdf.Ln(-1)
+		if run.DataRow.Valid {
+			pdf.MultiCell(160, 7, dataRowLabel(run.DataRow.Int32, schema.DataValues(run.DataValues)), "1", "", false)
+		}
 	}
 
 	// Save PDF
@@ -153,15 +172,32 @@ func (s *reportServiceImpl) Create(ctx context.Context, req *schema.CreateReport
 	return &r, nil
 }
 
-func (s *reportServiceImpl) GetByID(ctx context.Context, id string) (*dbsqlc.Report, error) {
-	r, err := s.queries.GetReport(ctx, uuid.MustParse(id))
+func (s *reportServiceImpl) GetByID(ctx context.Context, projectID int64, id string) (*dbsqlc.Report, error) {
+	reportID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	r, err := s.queries.GetReport(ctx, reportID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if int64(r.ProjectID) != projectID {
+		return nil, ErrNotFound
+	}
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
 		return nil, err
 	}
 	return &r, nil
 }
 
-func (s *reportServiceImpl) DeleteByID(ctx context.Context, id string) error {
-	_, err := s.queries.DeleteReport(ctx, uuid.MustParse(id))
+func (s *reportServiceImpl) DeleteByID(ctx context.Context, projectID int64, id string) error {
+	r, err := s.GetByID(ctx, projectID, id)
+	if err != nil {
+		return err
+	}
+	_, err = s.queries.DeleteReport(ctx, r.ID)
 	return err
 }
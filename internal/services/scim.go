@@ -0,0 +1,1075 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/pkg/scim"
+)
+
+// SCIMTokenPrefix is prepended to every SCIM token so they can be told apart from API tokens
+const SCIMTokenPrefix = "qscim_"
+
+// SCIMMaxResults caps the number of resources returned by a list request
+const SCIMMaxResults = 200
+
+var (
+	ErrInvalidSCIMToken   = errors.New("scim token is invalid or revoked")
+	ErrSCIMInvalidValue   = errors.New("invalid value")
+	ErrSCIMInvalidFilter  = errors.New("invalid filter")
+	ErrSCIMInvalidPath    = errors.New("invalid path")
+	ErrSCIMInvalidSyntax  = errors.New("invalid syntax")
+	ErrSCIMUniqueness     = errors.New("value is already in use")
+	ErrSCIMMutability     = errors.New("attribute cannot be modified")
+	errSCIMUnknownOp      = fmt.Errorf("%w: op must be add, replace or remove", ErrSCIMInvalidSyntax)
+	errSCIMGroupsReadOnly = fmt.Errorf("%w: groups are managed through the Groups endpoint", ErrSCIMMutability)
+)
+
+// Audit log actions
+const (
+	AuditSCIMTokenCreate = "scim.token.create"
+	AuditSCIMTokenRevoke = "scim.token.revoke"
+	AuditSCIMUserCreate  = "scim.user.create"
+	AuditSCIMUserUpdate  = "scim.user.update"
+	AuditSCIMUserDelete  = "scim.user.delete"
+	AuditSCIMGroupUpdate = "scim.group.update"
+)
+
+// SCIMService provisions the users of an org from its directory. Users are
+// the accounts the org's directory created or adopted, groups are the org
+// roles owner, admin and member so directory groups decide who runs the org.
+type SCIMService interface {
+	// CreateToken issues a token for the directory of the org, the raw token is only returned once
+	CreateToken(ctx context.Context, orgID int64, name string) (*schema.CreateSCIMTokenResponse, error)
+	ListTokens(ctx context.Context) ([]schema.SCIMToken, error)
+	RevokeToken(ctx context.Context, tokenID int64) error
+	// Authenticate resolves a raw token to the org it provisions and records its use
+	Authenticate(ctx context.Context, token string) (int32, error)
+
+	ListUsers(ctx context.Context, orgID int32, filter string, startIndex, count int) (*scim.ListResponse, error)
+	GetUser(ctx context.Context, orgID int32, id string) (*scim.User, error)
+	// CreateUser creates the account, or adopts an existing account of an org member with the same email
+	CreateUser(ctx context.Context, orgID int32, user *scim.User) (*scim.User, error)
+	ReplaceUser(ctx context.Context, orgID int32, id string, user *scim.User) (*scim.User, error)
+	PatchUser(ctx context.Context, orgID int32, id string, patch *scim.PatchRequest) (*scim.User, error)
+	// DeleteUser deactivates the account and removes it from the org, the account
+	// is kept so the work the user authored stays attributed to them
+	DeleteUser(ctx context.Context, orgID int32, id string) error
+
+	ListGroups(ctx context.Context, orgID int32, filter string, startIndex, count int) (*scim.ListResponse, error)
+	GetGroup(ctx context.Context, orgID int32, id string) (*scim.Group, error)
+	// CreateGroup resolves the group to the org role of the same name, the
+	// roles are fixed so no group is actually created
+	CreateGroup(ctx context.Context, orgID int32, group *scim.Group) (*scim.Group, error)
+	ReplaceGroup(ctx context.Context, orgID int32, id string, group *scim.Group) (*scim.Group, error)
+	PatchGroup(ctx context.Context, orgID int32, id string, patch *scim.PatchRequest) (*scim.Group, error)
+}
+
+type scimServiceImpl struct {
+	db      *sql.DB
+	queries *dbsqlc.Queries
+	baseURL string
+	logger  logging.Logger
+}
+
+func NewSCIMService(cfg *config.Config, db *sql.DB, queries *dbsqlc.Queries, logger logging.Logger) SCIMService {
+	return &scimServiceImpl{
+		db:      db,
+		queries: queries,
+		baseURL: cfg.Server.BaseURL() + "/scim/v2",
+		logger:  logger,
+	}
+}
+
+func (s *scimServiceImpl) CreateToken(ctx context.Context, orgID int64, name string) (*schema.CreateSCIMTokenResponse, error) {
+	if _, err := s.queries.GetOrgByID(ctx, int32(orgID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("org %d: %w", orgID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch org: %w", err)
+	}
+
+	secret, _, err := generateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := SCIMTokenPrefix + secret
+
+	id, err := s.queries.CreateSCIMToken(ctx, dbsqlc.CreateSCIMTokenParams{
+		OrgID:     int32(orgID),
+		Name:      name,
+		TokenHash: hashToken(token),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scim token: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSCIMTokenCreate,
+		SubjectType: "scim_token",
+		SubjectID:   strconv.Itoa(int(id)),
+		Details:     fmt.Sprintf("token %q for org %d", name, orgID),
+	})
+
+	return &schema.CreateSCIMTokenResponse{
+		SCIMToken: schema.SCIMToken{
+			ID:        int64(id),
+			OrgID:     orgID,
+			Name:      name,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		},
+		Token: token,
+	}, nil
+}
+
+func (s *scimServiceImpl) ListTokens(ctx context.Context) ([]schema.SCIMToken, error) {
+	rows, err := s.queries.ListSCIMTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim tokens: %w", err)
+	}
+	tokens := make([]schema.SCIMToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, schema.SCIMToken{
+			ID:         int64(row.ID),
+			OrgID:      int64(row.OrgID),
+			Name:       row.Name,
+			LastUsedAt: common.FormatNullTime(row.LastUsedAt),
+			RevokedAt:  common.FormatNullTime(row.RevokedAt),
+			CreatedAt:  common.FormatSqlDateTime(row.CreatedAt),
+		})
+	}
+	return tokens, nil
+}
+
+func (s *scimServiceImpl) RevokeToken(ctx context.Context, tokenID int64) error {
+	affected, err := s.queries.RevokeSCIMToken(ctx, int32(tokenID))
+	if err != nil {
+		return fmt.Errorf("failed to revoke scim token: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("scim token %d: %w", tokenID, ErrNotFound)
+	}
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSCIMTokenRevoke,
+		SubjectType: "scim_token",
+		SubjectID:   strconv.Itoa(int(tokenID)),
+	})
+	return nil
+}
+
+func (s *scimServiceImpl) Authenticate(ctx context.Context, token string) (int32, error) {
+	if !strings.HasPrefix(token, SCIMTokenPrefix) {
+		return 0, ErrInvalidSCIMToken
+	}
+	row, err := s.queries.GetActiveSCIMToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidSCIMToken
+		}
+		return 0, fmt.Errorf("failed to fetch scim token: %w", err)
+	}
+	if err := s.queries.TouchSCIMToken(ctx, row.ID); err != nil {
+		// failing to record usage should not fail the request
+		s.logger.Error("scim-service", "failed to record scim token usage", "error", err)
+	}
+	return row.OrgID, nil
+}
+
+func (s *scimServiceImpl) ListUsers(ctx context.Context, orgID int32, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	matches, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim users: %w", err)
+	}
+	users := make([]*scim.User, 0, len(rows))
+	for _, row := range rows {
+		user := s.userResource(dbsqlc.GetSCIMUserRow(row))
+		if matches(userAttributes(user)) {
+			users = append(users, user)
+		}
+	}
+	return scim.NewListResponse(users, startIndex, count), nil
+}
+
+func (s *scimServiceImpl) GetUser(ctx context.Context, orgID int32, id string) (*scim.User, error) {
+	row, err := getSCIMUser(ctx, s.queries, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(*row), nil
+}
+
+func (s *scimServiceImpl) CreateUser(ctx context.Context, orgID int32, in *scim.User) (*scim.User, error) {
+	email, err := scimUserEmail(in)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	var userID int32
+	managesAccount := false
+	existing, err := tx.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// only accounts that already belong to the org can be adopted, the
+		// directory of one org must not take over accounts of another
+		if _, err := tx.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: orgID, UserID: existing.ID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: a user with the email %s already exists", ErrSCIMUniqueness, email)
+			}
+			return nil, fmt.Errorf("failed to fetch org membership: %w", err)
+		}
+		if _, err := tx.GetSCIMUser(ctx, dbsqlc.GetSCIMUserParams{OrgID: orgID, UserID: existing.ID}); err == nil {
+			return nil, fmt.Errorf("%w: the user %s is already provisioned", ErrSCIMUniqueness, email)
+		}
+		userID = existing.ID
+	case errors.Is(err, sql.ErrNoRows):
+		userID, err = createSCIMUser(ctx, tx, orgID, email, in)
+		if err != nil {
+			return nil, err
+		}
+		managesAccount = true
+		// the password was set on the new account already
+		created := *in
+		created.Password = ""
+		in = &created
+	default:
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	if err := linkSCIMUser(ctx, tx, orgID, userID, in, managesAccount); err != nil {
+		return nil, err
+	}
+	row, err := tx.GetSCIMUser(ctx, dbsqlc.GetSCIMUserParams{OrgID: orgID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch scim user: %w", err)
+	}
+	if err := applySCIMUser(ctx, tx, orgID, &row, in); err != nil {
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.logger.Info("scim-service", "provisioned user from directory", "user_id", userID, "org_id", orgID)
+	s.recordUserEvent(ctx, AuditSCIMUserCreate, orgID, userID)
+	return s.GetUser(ctx, orgID, strconv.Itoa(int(userID)))
+}
+
+func (s *scimServiceImpl) ReplaceUser(ctx context.Context, orgID int32, id string, in *scim.User) (*scim.User, error) {
+	if _, err := scimUserEmail(in); err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, orgID, id, func(*scim.User) (*scim.User, error) {
+		return in, nil
+	})
+}
+
+func (s *scimServiceImpl) PatchUser(ctx context.Context, orgID int32, id string, patch *scim.PatchRequest) (*scim.User, error) {
+	return s.updateUser(ctx, orgID, id, func(user *scim.User) (*scim.User, error) {
+		// groups are derived from the org role and the password is never returned,
+		// neither can be patched in place
+		user.Groups = nil
+		for _, op := range patch.Operations {
+			if err := patchSCIMUser(user, op); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	})
+}
+
+// updateUser applies the resource returned by update to the user within a transaction
+func (s *scimServiceImpl) updateUser(ctx context.Context, orgID int32, id string, update func(*scim.User) (*scim.User, error)) (*scim.User, error) {
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	row, err := getSCIMUser(ctx, tx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	in, err := update(s.userResource(*row))
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Groups) > 0 && !slices.Equal(in.Groups, s.userResource(*row).Groups) {
+		return nil, errSCIMGroupsReadOnly
+	}
+	if err := linkSCIMUser(ctx, tx, orgID, row.ID, in, row.ManagesAccount); err != nil {
+		return nil, err
+	}
+	if err := applySCIMUser(ctx, tx, orgID, row, in); err != nil {
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.recordUserEvent(ctx, AuditSCIMUserUpdate, orgID, row.ID)
+	return s.GetUser(ctx, orgID, id)
+}
+
+func (s *scimServiceImpl) DeleteUser(ctx context.Context, orgID int32, id string) error {
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	row, err := getSCIMUser(ctx, tx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if err := ensureNotLastOrgOwner(ctx, tx, orgID, row.Role); err != nil {
+		return err
+	}
+	// accounts the directory adopted may be used in other orgs, deprovisioning
+	// only takes them out of this one
+	if row.ManagesAccount && row.IsActivated.Bool {
+		user, err := tx.GetUser(ctx, row.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if err := deactivateUser(ctx, tx, &user); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.RemoveOrgMember(ctx, dbsqlc.RemoveOrgMemberParams{OrgID: orgID, UserID: row.ID}); err != nil {
+		return fmt.Errorf("failed to remove org member: %w", err)
+	}
+	if _, err := tx.UnlinkSCIMUser(ctx, dbsqlc.UnlinkSCIMUserParams{OrgID: orgID, UserID: row.ID}); err != nil {
+		return fmt.Errorf("failed to unlink scim user: %w", err)
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.recordUserEvent(ctx, AuditSCIMUserDelete, orgID, row.ID)
+	return nil
+}
+
+func (s *scimServiceImpl) ListGroups(ctx context.Context, orgID int32, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	matches, err := parseSCIMFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.queries.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim users: %w", err)
+	}
+	groups := make([]*scim.Group, 0, len(orgRoles))
+	for _, role := range orgRoles {
+		group := s.groupResource(role, rows)
+		if matches(groupAttributes(group)) {
+			groups = append(groups, group)
+		}
+	}
+	return scim.NewListResponse(groups, startIndex, count), nil
+}
+
+func (s *scimServiceImpl) GetGroup(ctx context.Context, orgID int32, id string) (*scim.Group, error) {
+	if !slices.Contains(orgRoles, id) {
+		return nil, fmt.Errorf("group %s: %w", id, ErrNotFound)
+	}
+	rows, err := s.queries.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim users: %w", err)
+	}
+	return s.groupResource(id, rows), nil
+}
+
+func (s *scimServiceImpl) CreateGroup(ctx context.Context, orgID int32, group *scim.Group) (*scim.Group, error) {
+	role := strings.ToLower(strings.TrimSpace(group.DisplayName))
+	if !slices.Contains(orgRoles, role) {
+		return nil, fmt.Errorf("%w: groups map to the org roles %s", ErrSCIMInvalidValue, strings.Join(orgRoles, ", "))
+	}
+	if len(group.Members) == 0 {
+		return s.GetGroup(ctx, orgID, role)
+	}
+	return s.updateGroup(ctx, orgID, role, func(tx *dbsqlc.Queries, members []int32) error {
+		return addSCIMGroupMembers(ctx, tx, orgID, role, group.Members)
+	})
+}
+
+func (s *scimServiceImpl) ReplaceGroup(ctx context.Context, orgID int32, id string, group *scim.Group) (*scim.Group, error) {
+	if group.DisplayName != "" && !strings.EqualFold(group.DisplayName, id) {
+		return nil, fmt.Errorf("%w: displayName of the %s group cannot be changed", ErrSCIMMutability, id)
+	}
+	return s.updateGroup(ctx, orgID, id, func(tx *dbsqlc.Queries, members []int32) error {
+		return replaceSCIMGroupMembers(ctx, tx, orgID, id, members, group.Members)
+	})
+}
+
+func (s *scimServiceImpl) PatchGroup(ctx context.Context, orgID int32, id string, patch *scim.PatchRequest) (*scim.Group, error) {
+	return s.updateGroup(ctx, orgID, id, func(tx *dbsqlc.Queries, members []int32) error {
+		for _, op := range patch.Operations {
+			if err := s.patchGroup(ctx, tx, orgID, id, members, op); err != nil {
+				return err
+			}
+			current, err := scimGroupMembers(ctx, tx, orgID, id)
+			if err != nil {
+				return err
+			}
+			members = current
+		}
+		return nil
+	})
+}
+
+// updateGroup runs update within a transaction with the current members of the group
+func (s *scimServiceImpl) updateGroup(ctx context.Context, orgID int32, role string, update func(tx *dbsqlc.Queries, members []int32) error) (*scim.Group, error) {
+	if !slices.Contains(orgRoles, role) {
+		return nil, fmt.Errorf("group %s: %w", role, ErrNotFound)
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	members, err := scimGroupMembers(ctx, tx, orgID, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(tx, members); err != nil {
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSCIMGroupUpdate,
+		SubjectType: "org",
+		SubjectID:   strconv.Itoa(int(orgID)),
+		Details:     fmt.Sprintf("%s members changed by directory", role),
+	})
+	return s.GetGroup(ctx, orgID, role)
+}
+
+func (s *scimServiceImpl) patchGroup(ctx context.Context, tx *dbsqlc.Queries, orgID int32, role string, members []int32, op scim.PatchOperation) error {
+	if op.Path == "" {
+		// a value without path carries attributes of the group, like the members
+		// or the displayName Okta sends along when it renames nothing
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return fmt.Errorf("%w: value must be an object when no path is given", ErrSCIMInvalidValue)
+		}
+		for name, value := range attributes {
+			if err := s.patchGroup(ctx, tx, orgID, role, members, scim.PatchOperation{Op: op.Op, Path: name, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := scim.ParsePath(op.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMInvalidPath, err)
+	}
+	switch path.Attribute {
+	case "displayname":
+		var name string
+		if op.Operation() == scim.PatchRemove || json.Unmarshal(op.Value, &name) != nil || !strings.EqualFold(name, role) {
+			return fmt.Errorf("%w: displayName of the %s group cannot be changed", ErrSCIMMutability, role)
+		}
+		return nil
+	case "externalid", "id":
+		return nil
+	case "members":
+	default:
+		return fmt.Errorf("%w: %s", ErrSCIMInvalidPath, op.Path)
+	}
+
+	var values []scim.Member
+	if len(op.Value) > 0 {
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return fmt.Errorf("%w: members must be a list of {\"value\": id}", ErrSCIMInvalidValue)
+		}
+	}
+
+	switch op.Operation() {
+	case scim.PatchAdd:
+		return addSCIMGroupMembers(ctx, tx, orgID, role, values)
+	case scim.PatchReplace:
+		return replaceSCIMGroupMembers(ctx, tx, orgID, role, members, values)
+	case scim.PatchRemove:
+		var remove []int32
+		for _, member := range members {
+			id := strconv.Itoa(int(member))
+			matchesPath := path.Filter == nil || path.Filter.Matches(scim.AttributeMap{"value": id})
+			matchesValue := len(values) == 0 || slices.ContainsFunc(values, func(m scim.Member) bool { return m.Value == id })
+			if matchesPath && matchesValue {
+				remove = append(remove, member)
+			}
+		}
+		return removeSCIMGroupMembers(ctx, tx, orgID, role, remove)
+	}
+	return errSCIMUnknownOp
+}
+
+func (s *scimServiceImpl) recordUserEvent(ctx context.Context, action string, orgID, userID int32) {
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      action,
+		SubjectType: "user",
+		SubjectID:   strconv.Itoa(int(userID)),
+		Details:     fmt.Sprintf("by the directory of org %d", orgID),
+	})
+}
+
+func (s *scimServiceImpl) userResource(row dbsqlc.GetSCIMUserRow) *scim.User {
+	id := strconv.Itoa(int(row.ID))
+	active := row.IsActivated.Bool
+	if !row.ManagesAccount {
+		// adopted accounts are active in the org while they are a member of it
+		active = active && row.Role != ""
+	}
+	user := &scim.User{
+		Schemas:    []string{scim.SchemaUser},
+		ID:         id,
+		ExternalID: row.ExternalID.String,
+		UserName:   row.UserName,
+		Name: &scim.Name{
+			Formatted:  strings.TrimSpace(row.FirstName + " " + row.LastName),
+			GivenName:  row.FirstName,
+			FamilyName: row.LastName,
+		},
+		DisplayName: row.DisplayName.String,
+		Emails:      []scim.Email{{Value: row.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      common.FormatNullTime(row.CreatedAt),
+			LastModified: common.FormatNullTime(row.UpdatedAt),
+			Location:     s.baseURL + "/Users/" + id,
+		},
+	}
+	if row.Role != "" {
+		user.Groups = []scim.GroupRef{{Value: row.Role, Ref: s.baseURL + "/Groups/" + row.Role, Display: row.Role}}
+	}
+	return user
+}
+
+func (s *scimServiceImpl) groupResource(role string, rows []dbsqlc.ListSCIMUsersRow) *scim.Group {
+	group := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          role,
+		DisplayName: role,
+		Members:     []scim.Member{},
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     s.baseURL + "/Groups/" + role,
+		},
+	}
+	for _, row := range rows {
+		if row.Role != role {
+			continue
+		}
+		id := strconv.Itoa(int(row.ID))
+		group.Members = append(group.Members, scim.Member{Value: id, Ref: s.baseURL + "/Users/" + id, Display: row.UserName})
+	}
+	return group
+}
+
+// parseSCIMFilter returns a function matching the resources selected by the
+// filter, every resource matches an empty filter
+func parseSCIMFilter(filter string) (func(scim.Attributes) bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(scim.Attributes) bool { return true }, nil
+	}
+	parsed, err := scim.ParseFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSCIMInvalidFilter, err)
+	}
+	return parsed.Matches, nil
+}
+
+func userAttributes(user *scim.User) scim.AttributeMap {
+	emails := make([]scim.AttributeMap, 0, len(user.Emails))
+	for _, email := range user.Emails {
+		emails = append(emails, scim.AttributeMap{"value": email.Value, "type": email.Type, "primary": strconv.FormatBool(email.Primary)})
+	}
+	groups := make([]scim.AttributeMap, 0, len(user.Groups))
+	for _, group := range user.Groups {
+		groups = append(groups, scim.AttributeMap{"value": group.Value, "display": group.Display})
+	}
+	return scim.AttributeMap{
+		"id":                user.ID,
+		"externalid":        user.ExternalID,
+		"username":          user.UserName,
+		"displayname":       user.DisplayName,
+		"name.formatted":    user.Name.Formatted,
+		"name.givenname":    user.Name.GivenName,
+		"name.familyname":   user.Name.FamilyName,
+		"active":            strconv.FormatBool(*user.Active),
+		"emails":            emails,
+		"groups":            groups,
+		"meta.created":      user.Meta.Created,
+		"meta.lastmodified": user.Meta.LastModified,
+	}
+}
+
+func groupAttributes(group *scim.Group) scim.AttributeMap {
+	members := make([]scim.AttributeMap, 0, len(group.Members))
+	for _, member := range group.Members {
+		members = append(members, scim.AttributeMap{"value": member.Value, "display": member.Display})
+	}
+	return scim.AttributeMap{
+		"id":          group.ID,
+		"displayname": group.DisplayName,
+		"members":     members,
+	}
+}
+
+// scimUserEmail returns the email of the account, the primary email or the
+// userName when the directory identifies users by their email
+func scimUserEmail(user *scim.User) (string, error) {
+	if strings.TrimSpace(user.UserName) == "" {
+		return "", fmt.Errorf("%w: userName is required", ErrSCIMInvalidValue)
+	}
+	email := user.PrimaryEmail()
+	if email == "" && strings.Contains(user.UserName, "@") {
+		email = user.UserName
+	}
+	if email == "" {
+		return "", fmt.Errorf("%w: an email is required, set emails or use the email as userName", ErrSCIMInvalidValue)
+	}
+	return strings.TrimSpace(email), nil
+}
+
+// scimUserNames returns the first, last and display name of the user
+func scimUserNames(user *scim.User) (string, string, string) {
+	var firstName, lastName string
+	if user.Name != nil {
+		firstName, lastName = user.Name.GivenName, user.Name.FamilyName
+		if firstName == "" && lastName == "" {
+			firstName, lastName, _ = strings.Cut(user.Name.Formatted, " ")
+		}
+	}
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(user.DisplayName, " ")
+	}
+	return firstName, lastName, user.DisplayName
+}
+
+func getSCIMUser(ctx context.Context, queries *dbsqlc.Queries, orgID int32, id string) (*dbsqlc.GetSCIMUserRow, error) {
+	userID, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+	}
+	row, err := queries.GetSCIMUser(ctx, dbsqlc.GetSCIMUserParams{OrgID: orgID, UserID: int32(userID)})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch scim user: %w", err)
+	}
+	return &row, nil
+}
+
+func createSCIMUser(ctx context.Context, queries *dbsqlc.Queries, orgID int32, email string, in *scim.User) (int32, error) {
+	// users provisioned without a password sign in through single sign-on, or
+	// set a password through a password reset
+	password := in.Password
+	if password == "" {
+		generated, _, err := generateSecureToken()
+		if err != nil {
+			return 0, fmt.Errorf("failed to generate password: %w", err)
+		}
+		password = generated
+	}
+
+	firstName, lastName, displayName := scimUserNames(in)
+	now := time.Now()
+	userID, err := queries.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:        firstName,
+		LastName:         lastName,
+		DisplayName:      common.NullString(displayName),
+		Email:            email,
+		Password:         common.MustHashPassword(password),
+		OrgID:            common.NewNullInt32(orgID),
+		IsActivated:      sql.NullBool{Bool: true, Valid: true},
+		IsReviewed:       sql.NullBool{Bool: false, Valid: true},
+		IsSuperAdmin:     sql.NullBool{Bool: false, Valid: true},
+		IsVerified:       sql.NullBool{Bool: true, Valid: true},
+		EmailConfirmedAt: common.NewNullTime(now),
+		CreatedAt:        common.NewNullTime(now),
+		UpdatedAt:        common.NewNullTime(now),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := ensureOrgMember(ctx, queries, orgID, userID, OrgRoleMember); err != nil {
+		return 0, fmt.Errorf("failed to add org member: %w", err)
+	}
+	return userID, nil
+}
+
+// linkSCIMUser records the userName and externalId the directory knows the
+// user by, managesAccount is only recorded when the user is first linked
+func linkSCIMUser(ctx context.Context, queries *dbsqlc.Queries, orgID, userID int32, in *scim.User, managesAccount bool) error {
+	if in.ExternalID != "" {
+		rows, err := queries.ListSCIMUsers(ctx, orgID)
+		if err != nil {
+			return fmt.Errorf("failed to list scim users: %w", err)
+		}
+		for _, row := range rows {
+			if row.ID != userID && row.ExternalID.String == in.ExternalID {
+				return fmt.Errorf("%w: externalId %s belongs to another user", ErrSCIMUniqueness, in.ExternalID)
+			}
+		}
+	}
+	err := queries.LinkSCIMUser(ctx, dbsqlc.LinkSCIMUserParams{
+		OrgID:          orgID,
+		UserID:         userID,
+		ExternalID:     common.NullString(in.ExternalID),
+		UserName:       strings.TrimSpace(in.UserName),
+		ManagesAccount: managesAccount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link scim user: %w", err)
+	}
+	return nil
+}
+
+// applySCIMUser updates the account of the user to match the resource. Only
+// accounts the directory created follow its names, email, password and
+// active state, adopted accounts existed before and may be used in other orgs
+// so active only decides whether they are a member of the org
+func applySCIMUser(ctx context.Context, queries *dbsqlc.Queries, orgID int32, row *dbsqlc.GetSCIMUserRow, in *scim.User) error {
+	if !row.ManagesAccount {
+		return applySCIMMembership(ctx, queries, orgID, row, in.Active)
+	}
+	email, err := scimUserEmail(in)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(email, row.Email) {
+		other, err := queries.GetUserByEmail(ctx, email)
+		if err == nil && other.ID != row.ID {
+			return fmt.Errorf("%w: a user with the email %s already exists", ErrSCIMUniqueness, email)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to fetch user: %w", err)
+		}
+	}
+
+	firstName, lastName, displayName := scimUserNames(in)
+	_, err = queries.UpdateUserNames(ctx, dbsqlc.UpdateUserNamesParams{
+		ID:          row.ID,
+		FirstName:   firstName,
+		LastName:    lastName,
+		DisplayName: common.NullString(displayName),
+		Email:       email,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if in.Password != "" {
+		hashedPassword, err := common.HashPassword(in.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+		err = queries.ChangeUserPassword(ctx, dbsqlc.ChangeUserPasswordParams{
+			ID:        row.ID,
+			Password:  hashedPassword,
+			UpdatedAt: common.NewNullTime(time.Now()),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to change password: %w", err)
+		}
+		if err := endSessions(ctx, queries, row.ID); err != nil {
+			return err
+		}
+	}
+
+	if in.Active == nil || *in.Active == row.IsActivated.Bool {
+		return nil
+	}
+	if *in.Active {
+		if _, err := queries.ReactivateUser(ctx, row.ID); err != nil {
+			return fmt.Errorf("failed to reactivate user: %w", err)
+		}
+		return nil
+	}
+	if err := ensureNotLastOrgOwner(ctx, queries, orgID, row.Role); err != nil {
+		return err
+	}
+	user, err := queries.GetUser(ctx, row.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return deactivateUser(ctx, queries, &user)
+}
+
+// applySCIMMembership adds or removes an adopted account from the org
+func applySCIMMembership(ctx context.Context, queries *dbsqlc.Queries, orgID int32, row *dbsqlc.GetSCIMUserRow, active *bool) error {
+	isMember := row.Role != ""
+	if active == nil || *active == isMember {
+		return nil
+	}
+	if *active {
+		if err := ensureOrgMember(ctx, queries, orgID, row.ID, OrgRoleMember); err != nil {
+			return fmt.Errorf("failed to add org member: %w", err)
+		}
+		return nil
+	}
+	if err := ensureNotLastOrgOwner(ctx, queries, orgID, row.Role); err != nil {
+		return err
+	}
+	if _, err := queries.RemoveOrgMember(ctx, dbsqlc.RemoveOrgMemberParams{OrgID: orgID, UserID: row.ID}); err != nil {
+		return fmt.Errorf("failed to remove org member: %w", err)
+	}
+	return nil
+}
+
+// patchSCIMUser applies a PATCH operation to the user resource. Attributes
+// qatarina does not store, like enterprise extension attributes, are ignored
+// so directories sending them keep provisioning
+func patchSCIMUser(user *scim.User, op scim.PatchOperation) error {
+	operation := op.Operation()
+	if operation != scim.PatchAdd && operation != scim.PatchReplace && operation != scim.PatchRemove {
+		return errSCIMUnknownOp
+	}
+
+	if op.Path == "" {
+		if operation == scim.PatchRemove {
+			return fmt.Errorf("%w: remove requires a path", ErrSCIMInvalidPath)
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return fmt.Errorf("%w: value must be an object when no path is given", ErrSCIMInvalidValue)
+		}
+		for name, value := range attributes {
+			if err := patchSCIMUser(user, scim.PatchOperation{Op: op.Op, Path: name, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := scim.ParsePath(op.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMInvalidPath, err)
+	}
+	attribute := path.Attribute
+	if path.Filter != nil {
+		// emails[type eq "work"].value, there is only the one email
+		if attribute != "emails" || (path.SubAttribute != "" && path.SubAttribute != "value") {
+			return fmt.Errorf("%w: %s", ErrSCIMInvalidPath, op.Path)
+		}
+		attribute = "emails.value"
+	}
+
+	if operation == scim.PatchRemove {
+		switch attribute {
+		case "externalid":
+			user.ExternalID = ""
+		case "displayname":
+			user.DisplayName = ""
+		case "name":
+			user.Name = &scim.Name{}
+		case "name.givenname":
+			user.Name.GivenName = ""
+		case "name.familyname":
+			user.Name.FamilyName = ""
+		case "name.formatted":
+			user.Name.Formatted = ""
+		case "username", "emails", "emails.value", "active", "id":
+			return fmt.Errorf("%w: %s is required", ErrSCIMMutability, op.Path)
+		case "groups":
+			return errSCIMGroupsReadOnly
+		}
+		return nil
+	}
+
+	var text string
+	stringValue := func() error {
+		if err := json.Unmarshal(op.Value, &text); err != nil {
+			return fmt.Errorf("%w: %s must be a string", ErrSCIMInvalidValue, op.Path)
+		}
+		return nil
+	}
+	switch attribute {
+	case "username":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		user.UserName = text
+	case "externalid":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		user.ExternalID = text
+	case "displayname":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		user.DisplayName = text
+	case "password":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		user.Password = text
+	case "name":
+		var name scim.Name
+		if err := json.Unmarshal(op.Value, &name); err != nil {
+			return fmt.Errorf("%w: name must be an object", ErrSCIMInvalidValue)
+		}
+		// the names are kept as first and last name, formatted is derived from them
+		user.Name = &scim.Name{GivenName: name.GivenName, FamilyName: name.FamilyName}
+		if name.GivenName == "" && name.FamilyName == "" {
+			user.Name.Formatted = name.Formatted
+		}
+	case "name.givenname", "name.familyname", "name.formatted":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		switch attribute {
+		case "name.givenname":
+			user.Name.GivenName = text
+		case "name.familyname":
+			user.Name.FamilyName = text
+		default:
+			user.Name = &scim.Name{Formatted: text}
+		}
+	case "emails":
+		var emails []scim.Email
+		if err := json.Unmarshal(op.Value, &emails); err != nil {
+			return fmt.Errorf("%w: emails must be a list", ErrSCIMInvalidValue)
+		}
+		if len(emails) > 0 {
+			user.Emails = emails
+		}
+	case "emails.value":
+		if err := stringValue(); err != nil {
+			return err
+		}
+		user.Emails = []scim.Email{{Value: text, Type: "work", Primary: true}}
+	case "active":
+		active, err := scim.ParseBool(op.Value)
+		if err != nil {
+			return fmt.Errorf("%w: active must be a boolean", ErrSCIMInvalidValue)
+		}
+		user.Active = &active
+	case "groups":
+		return errSCIMGroupsReadOnly
+	case "id", "meta":
+		return fmt.Errorf("%w: %s is read-only", ErrSCIMMutability, op.Path)
+	}
+	return nil
+}
+
+// scimGroupMembers lists the provisioned users holding the role in the org
+func scimGroupMembers(ctx context.Context, queries *dbsqlc.Queries, orgID int32, role string) ([]int32, error) {
+	rows, err := queries.ListSCIMUsers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scim users: %w", err)
+	}
+	var members []int32
+	for _, row := range rows {
+		if row.Role == role {
+			members = append(members, row.ID)
+		}
+	}
+	return members, nil
+}
+
+// addSCIMGroupMembers gives the users the role, a member holds a single role
+// so adding them to a group moves them out of the one they were in
+func addSCIMGroupMembers(ctx context.Context, queries *dbsqlc.Queries, orgID int32, role string, members []scim.Member) error {
+	for _, member := range members {
+		row, err := getSCIMUser(ctx, queries, orgID, member.Value)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%w: member %s is not a provisioned user", ErrSCIMInvalidValue, member.Value)
+			}
+			return err
+		}
+		if err := setOrgMemberRole(ctx, queries, orgID, row, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSCIMGroupMembers moves the users from the owner and admin groups back
+// to plain members. Leaving the member group does not remove anyone from the
+// org, that happens when the user is deleted
+func removeSCIMGroupMembers(ctx context.Context, queries *dbsqlc.Queries, orgID int32, role string, userIDs []int32) error {
+	if role == OrgRoleMember {
+		return nil
+	}
+	for _, userID := range userIDs {
+		row, err := getSCIMUser(ctx, queries, orgID, strconv.Itoa(int(userID)))
+		if err != nil {
+			return err
+		}
+		if err := setOrgMemberRole(ctx, queries, orgID, row, OrgRoleMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceSCIMGroupMembers(ctx context.Context, queries *dbsqlc.Queries, orgID int32, role string, current []int32, members []scim.Member) error {
+	if err := addSCIMGroupMembers(ctx, queries, orgID, role, members); err != nil {
+		return err
+	}
+	var remove []int32
+	for _, userID := range current {
+		id := strconv.Itoa(int(userID))
+		if !slices.ContainsFunc(members, func(m scim.Member) bool { return m.Value == id }) {
+			remove = append(remove, userID)
+		}
+	}
+	return removeSCIMGroupMembers(ctx, queries, orgID, role, remove)
+}
+
+func setOrgMemberRole(ctx context.Context, queries *dbsqlc.Queries, orgID int32, row *dbsqlc.GetSCIMUserRow, role string) error {
+	if row.Role == role {
+		return nil
+	}
+	if err := ensureNotLastOrgOwner(ctx, queries, orgID, row.Role); err != nil {
+		return err
+	}
+	affected, err := queries.SetOrgMemberRole(ctx, dbsqlc.SetOrgMemberRoleParams{OrgID: orgID, UserID: row.ID, Role: role})
+	if err != nil {
+		return fmt.Errorf("failed to set org role: %w", err)
+	}
+	if affected == 0 {
+		if err := queries.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{OrgID: orgID, UserID: row.ID, Role: role}); err != nil {
+			return fmt.Errorf("failed to add org member: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/pkg/scim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCIMUserEmail(t *testing.T) {
+	email, err := scimUserEmail(&scim.User{UserName: "bjensen@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "bjensen@example.com", email)
+
+	email, err = scimUserEmail(&scim.User{UserName: "bjensen", Emails: []scim.Email{
+		{Value: "babs@example.org"},
+		{Value: "bjensen@example.com", Primary: true},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "bjensen@example.com", email)
+
+	_, err = scimUserEmail(&scim.User{UserName: "bjensen"})
+	assert.ErrorIs(t, err, ErrSCIMInvalidValue)
+	_, err = scimUserEmail(&scim.User{Emails: []scim.Email{{Value: "bjensen@example.com"}}})
+	assert.ErrorIs(t, err, ErrSCIMInvalidValue)
+}
+
+func TestPatchSCIMUser(t *testing.T) {
+	active := true
+	user := &scim.User{
+		UserName: "bjensen@example.com",
+		Name:     &scim.Name{GivenName: "Barbara", FamilyName: "Jensen"},
+		Emails:   []scim.Email{{Value: "bjensen@example.com", Primary: true}},
+		Active:   &active,
+	}
+
+	var patch scim.PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "replace", "path": "name.familyName", "value": "Smith"},
+			{"op": "add", "value": {"externalId": "00u1", "displayName": "Babs", "title": "Engineer"}},
+			{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "bsmith@example.com"},
+			{"op": "add", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "QA"}
+		]
+	}`), &patch))
+
+	for _, op := range patch.Operations {
+		require.NoError(t, patchSCIMUser(user, op), op.Path)
+	}
+	assert.False(t, *user.Active)
+	assert.Equal(t, "Barbara", user.Name.GivenName)
+	assert.Equal(t, "Smith", user.Name.FamilyName)
+	assert.Equal(t, "00u1", user.ExternalID)
+	assert.Equal(t, "Babs", user.DisplayName)
+	assert.Equal(t, "bsmith@example.com", user.PrimaryEmail())
+
+	require.NoError(t, patchSCIMUser(user, scim.PatchOperation{Op: "remove", Path: "externalId"}))
+	assert.Empty(t, user.ExternalID)
+
+	assert.ErrorIs(t, patchSCIMUser(user, scim.PatchOperation{Op: "remove", Path: "userName"}), ErrSCIMMutability)
+	assert.ErrorIs(t, patchSCIMUser(user, scim.PatchOperation{Op: "add", Path: "groups", Value: json.RawMessage(`[{"value":"admin"}]`)}), ErrSCIMMutability)
+	assert.ErrorIs(t, patchSCIMUser(user, scim.PatchOperation{Op: "move", Path: "userName"}), ErrSCIMInvalidSyntax)
+	assert.ErrorIs(t, patchSCIMUser(user, scim.PatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`"maybe"`)}), ErrSCIMInvalidValue)
+	assert.ErrorIs(t, patchSCIMUser(user, scim.PatchOperation{Op: "replace", Path: "members[value eq"}), ErrSCIMInvalidPath)
+}
+
+func TestSCIMUserNames(t *testing.T) {
+	first, last, display := scimUserNames(&scim.User{DisplayName: "Barbara Jensen"})
+	assert.Equal(t, "Barbara", first)
+	assert.Equal(t, "Jensen", last)
+	assert.Equal(t, "Barbara Jensen", display)
+
+	first, last, _ = scimUserNames(&scim.User{Name: &scim.Name{Formatted: "Ms Barbara Jensen"}})
+	assert.Equal(t, "Ms", first)
+	assert.Equal(t, "Barbara Jensen", last)
+}
+
+func TestSCIMUserResourceActive(t *testing.T) {
+	s := &scimServiceImpl{baseURL: "/scim/v2"}
+	row := dbsqlc.GetSCIMUserRow{ID: 7, IsActivated: sql.NullBool{Bool: true, Valid: true}, Role: OrgRoleMember, ManagesAccount: true}
+
+	assert.True(t, *s.userResource(row).Active)
+	row.Role = ""
+	assert.True(t, *s.userResource(row).Active, "accounts the directory created follow the account state")
+
+	row.ManagesAccount = false
+	assert.False(t, *s.userResource(row).Active, "adopted accounts are inactive once they left the org")
+	row.Role = OrgRoleMember
+	assert.True(t, *s.userResource(row).Active)
+	row.IsActivated.Bool = false
+	assert.False(t, *s.userResource(row).Active)
+}
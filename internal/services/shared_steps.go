@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSharedStep is returned for shared steps, and references of test
+// cases to shared steps, which are not valid
+var ErrInvalidSharedStep = errors.New("invalid shared step")
+
+// ErrSharedStepExists is returned when a project already has a shared step with the name
+var ErrSharedStepExists = errors.New("shared step already exists")
+
+// ErrSharedStepInUse is returned when deleting a shared step test cases reference
+var ErrSharedStepInUse = errors.New("shared step is used by test cases")
+
+// SharedStepService manages the blocks of steps the test cases of a project
+// reference instead of repeating them
+type SharedStepService interface {
+	FindAllByProjectID(ctx context.Context, projectID int64) ([]dbsqlc.SharedStep, error)
+	FindByID(ctx context.Context, projectID, sharedStepID int64) (*dbsqlc.SharedStep, error)
+	Create(ctx context.Context, request *schema.CreateSharedStepRequest) (*dbsqlc.SharedStep, error)
+	// Update changes a shared step, the change applies to every test case
+	// referencing it and a new revision is recorded for each of them
+	Update(ctx context.Context, request *schema.UpdateSharedStepRequest) (*dbsqlc.SharedStep, error)
+	// Delete removes a shared step no test case references
+	Delete(ctx context.Context, projectID, sharedStepID int64) error
+	// FindUsages lists the test cases referencing a shared step
+	FindUsages(ctx context.Context, projectID, sharedStepID int64) (*dbsqlc.SharedStep, []dbsqlc.ListSharedStepUsagesRow, error)
+}
+
+var _ SharedStepService = &sharedStepServiceImpl{}
+
+type sharedStepServiceImpl struct {
+	db      *sql.DB
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewSharedStepService(db *sql.DB, queries *dbsqlc.Queries, logger logging.Logger) SharedStepService {
+	return &sharedStepServiceImpl{
+		db:      db,
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *sharedStepServiceImpl) FindAllByProjectID(ctx context.Context, projectID int64) ([]dbsqlc.SharedStep, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	return s.queries.ListSharedStepsByProject(ctx, int32(projectID))
+}
+
+func (s *sharedStepServiceImpl) FindByID(ctx context.Context, projectID, sharedStepID int64) (*dbsqlc.SharedStep, error) {
+	return s.findSharedStep(ctx, projectID, sharedStepID)
+}
+
+func (s *sharedStepServiceImpl) Create(ctx context.Context, request *schema.CreateSharedStepRequest) (*dbsqlc.SharedStep, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(request.Name)
+	if err := s.checkName(ctx, int32(request.ProjectID), 0, name); err != nil {
+		return nil, err
+	}
+	parameters, steps, err := sharedStepContent(request.Parameters, request.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := s.queries.CreateSharedStep(ctx, dbsqlc.CreateSharedStepParams{
+		ProjectID:   int32(request.ProjectID),
+		Name:        name,
+		Description: strings.TrimSpace(request.Description),
+		Parameters:  parameters,
+		Steps:       steps,
+		CreatedByID: common.NewNullInt32(int32(request.CreatedByID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared step: %w", err)
+	}
+	return &shared, nil
+}
+
+func (s *sharedStepServiceImpl) Update(ctx context.Context, request *schema.UpdateSharedStepRequest) (*dbsqlc.SharedStep, error) {
+	shared, err := s.findSharedStep(ctx, request.ProjectID, request.SharedStepID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(request.Name)
+	if err := s.checkName(ctx, shared.ProjectID, shared.ID, name); err != nil {
+		return nil, err
+	}
+	parameters, steps, err := sharedStepContent(request.Parameters, request.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := dbsqlc.New(sqlTx)
+
+	// the values test cases pass must still fit the changed parameters
+	references, err := tx.ListSharedStepReferences(ctx, common.NewNullInt32(shared.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references of shared step: %w", err)
+	}
+	testCaseIDs := []uuid.UUID{}
+	for _, reference := range references {
+		if !slices.Contains(testCaseIDs, reference.TestCaseID) {
+			testCaseIDs = append(testCaseIDs, reference.TestCaseID)
+		}
+		checkErr := validation.CheckSharedStepValues(request.Parameters, schema.StepParameters(&reference))
+		if checkErr == nil {
+			continue
+		}
+		tc, err := tx.GetTestCase(ctx, reference.TestCaseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch test case: %w", err)
+		}
+		return nil, fmt.Errorf("%w: test case %s: %v", ErrInvalidSharedStep, tc.Code, checkErr)
+	}
+
+	// the test cases are saved as they were first, their latest revision may
+	// not have a copy of the shared step yet
+	for _, testCaseID := range testCaseIDs {
+		if _, err := recordTestCaseRevision(ctx, tx, testCaseID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := tx.UpdateSharedStep(ctx, dbsqlc.UpdateSharedStepParams{
+		ID:          shared.ID,
+		Name:        name,
+		Description: strings.TrimSpace(request.Description),
+		Parameters:  parameters,
+		Steps:       steps,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update shared step: %w", err)
+	}
+
+	for _, testCaseID := range testCaseIDs {
+		if _, err := recordTestCaseRevision(ctx, tx, testCaseID, request.UpdatedByID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	s.logger.Info("shared-step-service", "updated shared step", "sharedStepID", shared.ID, "testCases", len(testCaseIDs))
+	return &updated, nil
+}
+
+func (s *sharedStepServiceImpl) Delete(ctx context.Context, projectID, sharedStepID int64) error {
+	shared, err := s.findSharedStep(ctx, projectID, sharedStepID)
+	if err != nil {
+		return err
+	}
+	usages, err := s.queries.ListSharedStepUsages(ctx, common.NewNullInt32(shared.ID))
+	if err != nil {
+		return fmt.Errorf("failed to list usages of shared step: %w", err)
+	}
+	if len(usages) > 0 {
+		return fmt.Errorf("%w: %d test cases reference it", ErrSharedStepInUse, len(usages))
+	}
+	if _, err := s.queries.DeleteSharedStep(ctx, shared.ID); err != nil {
+		return fmt.Errorf("failed to delete shared step: %w", err)
+	}
+	return nil
+}
+
+func (s *sharedStepServiceImpl) FindUsages(ctx context.Context, projectID, sharedStepID int64) (*dbsqlc.SharedStep, []dbsqlc.ListSharedStepUsagesRow, error) {
+	shared, err := s.findSharedStep(ctx, projectID, sharedStepID)
+	if err != nil {
+		return nil, nil, err
+	}
+	usages, err := s.queries.ListSharedStepUsages(ctx, common.NewNullInt32(shared.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list usages of shared step: %w", err)
+	}
+	return shared, usages, nil
+}
+
+// findSharedStep fetches a shared step of a project in the org of the context
+func (s *sharedStepServiceImpl) findSharedStep(ctx context.Context, projectID, sharedStepID int64) (*dbsqlc.SharedStep, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	shared, err := s.queries.GetSharedStep(ctx, int32(sharedStepID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch shared step: %w", err)
+	}
+	if int64(shared.ProjectID) != projectID {
+		return nil, ErrNotFound
+	}
+	return &shared, nil
+}
+
+// checkName checks no other shared step of the project has the name
+func (s *sharedStepServiceImpl) checkName(ctx context.Context, projectID, sharedStepID int32, name string) error {
+	existing, err := s.queries.ListSharedStepsByProject(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to list shared steps: %w", err)
+	}
+	if slices.ContainsFunc(existing, func(e dbsqlc.SharedStep) bool { return e.ID != sharedStepID && e.Name == name }) {
+		return ErrSharedStepExists
+	}
+	return nil
+}
+
+// sharedStepContent validates the parameters and steps of a shared step and
+// encodes them for storage
+func sharedStepContent(parameters []validation.SharedStepParameter, steps []schema.TestCaseStepRequest) (json.RawMessage, json.RawMessage, error) {
+	texts := make([]string, 0, len(steps)*3)
+	items := make([]schema.TestCaseStepRequest, 0, len(steps))
+	for i, step := range steps {
+		if step.SharedStepID != 0 {
+			return nil, nil, fmt.Errorf("%w: step %d references another shared step", ErrInvalidSharedStep, i+1)
+		}
+		item := schema.TestCaseStepRequest{
+			Action:         strings.TrimSpace(step.Action),
+			TestData:       strings.TrimSpace(step.TestData),
+			ExpectedResult: strings.TrimSpace(step.ExpectedResult),
+			Attachments:    step.Attachments,
+		}
+		texts = append(texts, item.Action, item.TestData, item.ExpectedResult)
+		items = append(items, item)
+	}
+	if err := validation.ValidateSharedStepParameters(parameters, texts...); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSharedStep, err)
+	}
+	if parameters == nil {
+		parameters = []validation.SharedStepParameter{}
+	}
+
+	parametersJSON, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+	stepsJSON, err := json.Marshal(items)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parametersJSON, stepsJSON, nil
+}
+
+// copySharedSteps copies shared steps to a project and returns the IDs of
+// the copies by the IDs of the originals
+func copySharedSteps(ctx context.Context, db *dbsqlc.Queries, projectID int32, sharedSteps []dbsqlc.SharedStep) (map[int32]int32, error) {
+	ids := make(map[int32]int32, len(sharedSteps))
+	for _, shared := range sharedSteps {
+		created, err := db.CreateSharedStep(ctx, dbsqlc.CreateSharedStepParams{
+			ProjectID:   projectID,
+			Name:        shared.Name,
+			Description: shared.Description,
+			Parameters:  shared.Parameters,
+			Steps:       shared.Steps,
+			CreatedByID: shared.CreatedByID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy shared step %q: %w", shared.Name, err)
+		}
+		ids[shared.ID] = created.ID
+	}
+	return ids, nil
+}
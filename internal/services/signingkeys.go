@@ -0,0 +1,466 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/pkg/signingkey"
+)
+
+var ErrInvalidAccessToken = errors.New("access token is invalid or expired")
+var ErrUnknownSigningKey = errors.New("token was signed with an unknown or retired key")
+
+const (
+	// signingKeyReloadInterval is how often keys are reloaded so keys managed
+	// with the keys command reach running servers
+	signingKeyReloadInterval = time.Minute
+	// signingKeyMissReloadInterval limits reloads caused by tokens with an unknown kid
+	signingKeyMissReloadInterval = 10 * time.Second
+)
+
+// Audit log actions
+const (
+	AuditSigningKeyCreate = "signing_key.create"
+	AuditSigningKeyRetire = "signing_key.retire"
+	AuditSigningKeyDelete = "signing_key.delete"
+)
+
+// SigningKeyService manages the asymmetric keys access tokens are signed with.
+// Several keys can be published at once: a new key is published before it
+// starts signing and a retired key keeps verifying until the tokens it signed
+// have expired, so keys rotate without logging anyone out
+type SigningKeyService interface {
+	// Generate adds a key that starts signing at activatesAt, it is published right away.
+	// The configured algorithm is used when none is given
+	Generate(ctx context.Context, algorithm string, activatesAt time.Time) (*schema.SigningKey, error)
+	// Rotate adds a key that takes over signing at activatesAt, the other keys retire at that moment
+	Rotate(ctx context.Context, algorithm string, activatesAt time.Time) (*schema.SigningKey, error)
+	// List lists all keys, newest first
+	List(ctx context.Context) ([]schema.SigningKey, error)
+	// Retire stops a key from signing, tokens it signed stay valid until they expire
+	Retire(ctx context.Context, kid string) error
+	// Delete removes a key, tokens it signed are rejected immediately
+	Delete(ctx context.Context, kid string) error
+	// SignAccessToken signs the claims with the current key, a key is generated
+	// with the configured algorithm when there is none
+	SignAccessToken(ctx context.Context, claims jwt.MapClaims) (string, error)
+	// ParseAccessToken verifies an access token against the published keys
+	ParseAccessToken(ctx context.Context, token string) (*jwt.Token, error)
+	// JWKS returns the public keys tokens may be verified with
+	JWKS(ctx context.Context) (*signingkey.JWKS, error)
+}
+
+// loadedSigningKey is a signing key parsed from the database
+type loadedSigningKey struct {
+	*signingkey.Key
+	CreatedAt   time.Time
+	ActivatesAt time.Time
+	RetiredAt   sql.NullTime
+}
+
+type signingKeyServiceImpl struct {
+	algorithm string
+	issuer    string
+	// gracePeriod is how long a retired key keeps verifying, the lifetime of an access token
+	gracePeriod time.Duration
+	// encryptionKey encrypts the private keys stored in the database
+	encryptionKey []byte
+	queries       *dbsqlc.Queries
+	logger        logging.Logger
+
+	mu           sync.RWMutex
+	keys         []loadedSigningKey
+	loadedAt     time.Time
+	missReloadAt time.Time
+	generateMu   sync.Mutex
+}
+
+func NewSigningKeyService(cfg *config.Config, queries *dbsqlc.Queries, logger logging.Logger) SigningKeyService {
+	return &signingKeyServiceImpl{
+		algorithm:     cmp.Or(cfg.Auth.SigningAlgorithm, signingkey.RS256),
+		issuer:        cfg.Auth.JwtIssuer,
+		gracePeriod:   time.Duration(cfg.Auth.JwtExpiryTimeout) * time.Second,
+		encryptionKey: secretKey(cfg.Auth.JwtSecretKey, "signing-key"),
+		queries:       queries,
+		logger:        logger,
+	}
+}
+
+// signingKeyStatus works out the state of a key at the given time
+func signingKeyStatus(activatesAt time.Time, retiredAt sql.NullTime, now time.Time, gracePeriod time.Duration) string {
+	switch {
+	case retiredAt.Valid && !now.Before(retiredAt.Time.Add(gracePeriod)):
+		return schema.SigningKeyRetired
+	case retiredAt.Valid && !now.Before(retiredAt.Time):
+		return schema.SigningKeyRetiring
+	case now.Before(activatesAt):
+		return schema.SigningKeyPending
+	}
+	return schema.SigningKeyActive
+}
+
+// currentSigningKey picks the active key that activated last, keys are ordered newest first
+func currentSigningKey(keys []loadedSigningKey, now time.Time) *loadedSigningKey {
+	for i := range keys {
+		if signingKeyStatus(keys[i].ActivatesAt, keys[i].RetiredAt, now, 0) == schema.SigningKeyActive {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+func (s *signingKeyServiceImpl) toSchema(key dbsqlc.SigningKey, now time.Time) schema.SigningKey {
+	return schema.SigningKey{
+		Kid:         key.Kid,
+		Algorithm:   key.Algorithm,
+		Status:      signingKeyStatus(key.ActivatesAt, key.RetiredAt, now, s.gracePeriod),
+		CreatedAt:   common.FormatSqlDateTime(key.CreatedAt),
+		ActivatesAt: common.FormatSqlDateTime(key.ActivatesAt),
+		RetiredAt:   common.FormatSqlDateTime(key.RetiredAt),
+	}
+}
+
+// load reads the keys from the database, unless they were loaded recently
+func (s *signingKeyServiceImpl) load(ctx context.Context, force bool) error {
+	s.mu.RLock()
+	fresh := !s.loadedAt.IsZero() && time.Since(s.loadedAt) < signingKeyReloadInterval
+	s.mu.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	rows, err := s.queries.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make([]loadedSigningKey, 0, len(rows))
+	for _, row := range rows {
+		privateKey, err := decryptSecret(s.encryptionKey, row.PrivateKey)
+		if err != nil {
+			s.logger.Error("signing-key-service", "failed to decrypt signing key", "kid", row.Kid, "error", err)
+			continue
+		}
+		key, err := signingkey.ParsePEM(row.Algorithm, []byte(privateKey))
+		if err != nil {
+			s.logger.Error("signing-key-service", "failed to parse signing key", "kid", row.Kid, "error", err)
+			continue
+		}
+		if key.ID != row.Kid {
+			s.logger.Error("signing-key-service", "signing key does not match its kid", "kid", row.Kid)
+			continue
+		}
+		keys = append(keys, loadedSigningKey{
+			Key:         key,
+			CreatedAt:   row.CreatedAt,
+			ActivatesAt: row.ActivatesAt,
+			RetiredAt:   row.RetiredAt,
+		})
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// loadedKeys returns the cached keys, falling back to the last loaded keys
+// when the database cannot be reached
+func (s *signingKeyServiceImpl) loadedKeys(ctx context.Context) ([]loadedSigningKey, error) {
+	if err := s.load(ctx, false); err != nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.loadedAt.IsZero() {
+			return nil, err
+		}
+		s.logger.Error("signing-key-service", "using previously loaded signing keys", "error", err)
+		return s.keys, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys, nil
+}
+
+func (s *signingKeyServiceImpl) create(ctx context.Context, algorithm string, activatesAt time.Time) (*signingkey.Key, error) {
+	key, err := signingkey.Generate(cmp.Or(algorithm, s.algorithm))
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := key.MarshalPEM()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptSecret(s.encryptionKey, string(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	err = s.queries.CreateSigningKey(ctx, dbsqlc.CreateSigningKeyParams{
+		Kid:         key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  encrypted,
+		ActivatesAt: activatesAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+
+	s.logger.Info("signing-key-service", "generated signing key", "kid", key.ID, "algorithm", key.Algorithm, "activates_at", activatesAt)
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSigningKeyCreate,
+		SubjectType: "signing_key",
+		SubjectID:   key.ID,
+		Details:     fmt.Sprintf("%s key activating at %s", key.Algorithm, activatesAt.Format(time.RFC3339)),
+	})
+	return key, nil
+}
+
+func (s *signingKeyServiceImpl) describe(ctx context.Context, kid string) (*schema.SigningKey, error) {
+	rows, err := s.queries.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	for _, row := range rows {
+		if row.Kid == kid {
+			key := s.toSchema(row, time.Now())
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %s: %w", kid, ErrNotFound)
+}
+
+func (s *signingKeyServiceImpl) Generate(ctx context.Context, algorithm string, activatesAt time.Time) (*schema.SigningKey, error) {
+	key, err := s.create(ctx, algorithm, activatesAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	return s.describe(ctx, key.ID)
+}
+
+func (s *signingKeyServiceImpl) Rotate(ctx context.Context, algorithm string, activatesAt time.Time) (*schema.SigningKey, error) {
+	key, err := s.create(ctx, algorithm, activatesAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.queries.RetireOtherSigningKeys(ctx, dbsqlc.RetireOtherSigningKeysParams{
+		Kid:       key.ID,
+		RetiredAt: common.NewNullTime(activatesAt),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire previous signing keys: %w", err)
+	}
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSigningKeyRetire,
+		SubjectType: "signing_key",
+		Details:     fmt.Sprintf("keys other than %s retire at %s", key.ID, activatesAt.Format(time.RFC3339)),
+	})
+
+	if err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	return s.describe(ctx, key.ID)
+}
+
+func (s *signingKeyServiceImpl) List(ctx context.Context) ([]schema.SigningKey, error) {
+	rows, err := s.queries.ListSigningKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	now := time.Now()
+	keys := make([]schema.SigningKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, s.toSchema(row, now))
+	}
+	return keys, nil
+}
+
+func (s *signingKeyServiceImpl) Retire(ctx context.Context, kid string) error {
+	affected, err := s.queries.RetireSigningKey(ctx, dbsqlc.RetireSigningKeyParams{
+		Kid:       kid,
+		RetiredAt: common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retire signing key: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("signing key %s: %w", kid, ErrNotFound)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSigningKeyRetire,
+		SubjectType: "signing_key",
+		SubjectID:   kid,
+	})
+	return s.load(ctx, true)
+}
+
+func (s *signingKeyServiceImpl) Delete(ctx context.Context, kid string) error {
+	affected, err := s.queries.DeleteSigningKey(ctx, kid)
+	if err != nil {
+		return fmt.Errorf("failed to delete signing key: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("signing key %s: %w", kid, ErrNotFound)
+	}
+
+	recordAuditEvent(ctx, s.queries, s.logger, AuditEvent{
+		Action:      AuditSigningKeyDelete,
+		SubjectType: "signing_key",
+		SubjectID:   kid,
+	})
+	return s.load(ctx, true)
+}
+
+// currentKey returns the key to sign with, generating one when none is active
+func (s *signingKeyServiceImpl) currentKey(ctx context.Context) (*loadedSigningKey, error) {
+	keys, err := s.loadedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key := currentSigningKey(keys, time.Now()); key != nil {
+		return key, nil
+	}
+
+	s.generateMu.Lock()
+	defer s.generateMu.Unlock()
+	// another request may have generated a key in the meantime
+	if err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	key := currentSigningKey(s.keys, time.Now())
+	s.mu.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+
+	if _, err := s.create(ctx, s.algorithm, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key := currentSigningKey(s.keys, time.Now()); key != nil {
+		return key, nil
+	}
+	return nil, errors.New("no signing key available")
+}
+
+func (s *signingKeyServiceImpl) SignAccessToken(ctx context.Context, claims jwt.MapClaims) (string, error) {
+	key, err := s.currentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return key.Sign(claims)
+}
+
+// verificationKey finds a key that may verify tokens, the keys are reloaded
+// when the kid is unknown in case it was generated by another server
+func (s *signingKeyServiceImpl) verificationKey(ctx context.Context, kid string) (*loadedSigningKey, error) {
+	find := func(keys []loadedSigningKey) *loadedSigningKey {
+		now := time.Now()
+		for i := range keys {
+			if keys[i].ID != kid {
+				continue
+			}
+			switch signingKeyStatus(keys[i].ActivatesAt, keys[i].RetiredAt, now, s.gracePeriod) {
+			case schema.SigningKeyActive, schema.SigningKeyRetiring:
+				return &keys[i]
+			}
+			return nil
+		}
+		return nil
+	}
+
+	keys, err := s.loadedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key := find(keys); key != nil {
+		return key, nil
+	}
+
+	s.mu.Lock()
+	reload := time.Since(s.missReloadAt) >= signingKeyMissReloadInterval
+	if reload {
+		s.missReloadAt = time.Now()
+	}
+	s.mu.Unlock()
+	if !reload {
+		return nil, ErrUnknownSigningKey
+	}
+
+	if err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key := find(s.keys); key != nil {
+		return key, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+func (s *signingKeyServiceImpl) ParseAccessToken(ctx context.Context, tokenStr string) (*jwt.Token, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrUnknownSigningKey
+		}
+		key, err := s.verificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key.PublicKey(), nil
+	}, jwt.WithValidMethods(signingkey.Algorithms()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, ErrInvalidAccessToken
+	}
+	if s.issuer != "" && !claims.VerifyIssuer(s.issuer, true) {
+		return nil, ErrInvalidAccessToken
+	}
+	return token, nil
+}
+
+func (s *signingKeyServiceImpl) JWKS(ctx context.Context) (*signingkey.JWKS, error) {
+	keys, err := s.loadedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	jwks := &signingkey.JWKS{Keys: []signingkey.JWK{}}
+	for _, key := range keys {
+		if signingKeyStatus(key.ActivatesAt, key.RetiredAt, now, s.gracePeriod) == schema.SigningKeyRetired {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, key.PublicJWK())
+	}
+	return jwks, nil
+}
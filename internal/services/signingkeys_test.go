@@ -0,0 +1,78 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/pkg/signingkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeyStatus(t *testing.T) {
+	now := time.Now()
+	grace := time.Hour
+	retired := func(at time.Time) sql.NullTime { return sql.NullTime{Time: at, Valid: true} }
+
+	tests := []struct {
+		name        string
+		activatesAt time.Time
+		retiredAt   sql.NullTime
+		status      string
+	}{
+		{"not active yet", now.Add(time.Minute), sql.NullTime{}, schema.SigningKeyPending},
+		{"active", now.Add(-time.Minute), sql.NullTime{}, schema.SigningKeyActive},
+		{"retiring later", now.Add(-time.Minute), retired(now.Add(time.Minute)), schema.SigningKeyActive},
+		{"retired within grace period", now.Add(-2 * time.Hour), retired(now.Add(-time.Minute)), schema.SigningKeyRetiring},
+		{"retired after grace period", now.Add(-3 * time.Hour), retired(now.Add(-2 * time.Hour)), schema.SigningKeyRetired},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, signingKeyStatus(tt.activatesAt, tt.retiredAt, now, grace), tt.name)
+	}
+}
+
+func TestCurrentSigningKey(t *testing.T) {
+	now := time.Now()
+	key := func(id string, activatesAt time.Time, retiredAt sql.NullTime) loadedSigningKey {
+		return loadedSigningKey{Key: &signingkey.Key{ID: id}, ActivatesAt: activatesAt, RetiredAt: retiredAt}
+	}
+
+	// newest first, as returned by ListSigningKeys
+	keys := []loadedSigningKey{
+		key("pending", now.Add(time.Hour), sql.NullTime{}),
+		key("current", now.Add(-time.Hour), sql.NullTime{Time: now.Add(time.Hour), Valid: true}),
+		key("retired", now.Add(-2*time.Hour), sql.NullTime{Time: now.Add(-time.Hour), Valid: true}),
+	}
+	assert.Equal(t, "current", currentSigningKey(keys, now).ID)
+	assert.Equal(t, "pending", currentSigningKey(keys, now.Add(2*time.Hour)).ID)
+	assert.Nil(t, currentSigningKey(keys[2:], now))
+}
+
+func TestSigningKeyEncryption(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfiguration{JwtSecretKey: "secret"}}
+	s := NewSigningKeyService(cfg, nil, nil).(*signingKeyServiceImpl)
+
+	key, err := signingkey.Generate(signingkey.RS256)
+	require.NoError(t, err)
+	privateKey, err := key.MarshalPEM()
+	require.NoError(t, err)
+
+	encrypted, err := encryptSecret(s.encryptionKey, string(privateKey))
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "PRIVATE KEY")
+
+	decrypted, err := decryptSecret(s.encryptionKey, encrypted)
+	require.NoError(t, err)
+	parsed, err := signingkey.ParsePEM(signingkey.RS256, []byte(decrypted))
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, parsed.ID)
+
+	// TOTP secrets use a different key derived from the same JWT secret
+	a := &authServiceImpl{authConfig: &cfg.Auth}
+	_, err = decryptSecret(a.twoFactorKey(), encrypted)
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/google/uuid"
+)
+
+// ErrNoOrg is returned when creating org owned data without an org to own it
+var ErrNoOrg = errors.New("no organization selected, join or create an organization first")
+
+// orgContextKey is the context key of the org a request works in
+type orgContextKey struct{}
+
+// WithOrgID returns a context scoped to the org, services only see the
+// projects of that org and the test cases, plans and runs in them
+func WithOrgID(ctx context.Context, orgID int64) context.Context {
+	return context.WithValue(ctx, orgContextKey{}, orgID)
+}
+
+// OrgIDFromContext returns the org the context is scoped to, 0 when it is not
+// scoped which matches no org
+func OrgIDFromContext(ctx context.Context) int64 {
+	orgID, _ := ctx.Value(orgContextKey{}).(int64)
+	return orgID
+}
+
+// contextOrgID is OrgIDFromContext as the org_id column type
+func contextOrgID(ctx context.Context) int32 {
+	return int32(OrgIDFromContext(ctx))
+}
+
+// checkOrg turns the result of a Get*OrgID lookup into ErrNotFound when the
+// entity does not exist or belongs to another org than the context, tenants
+// cannot tell those apart
+func checkOrg(ctx context.Context, orgID int32, err error, entity string) error {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to fetch %s: %w", entity, err)
+	}
+	if orgID == 0 || orgID != contextOrgID(ctx) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func ensureProjectInOrg(ctx context.Context, queries *dbsqlc.Queries, projectID int64) error {
+	orgID, err := queries.GetProjectOrgID(ctx, int32(projectID))
+	return checkOrg(ctx, orgID, err, "project")
+}
+
+func ensureTestCaseInOrg(ctx context.Context, queries *dbsqlc.Queries, testCaseID uuid.UUID) error {
+	orgID, err := queries.GetTestCaseOrgID(ctx, testCaseID)
+	return checkOrg(ctx, orgID, err, "test case")
+}
+
+func ensureTestPlanInOrg(ctx context.Context, queries *dbsqlc.Queries, testPlanID int64) error {
+	orgID, err := queries.GetTestPlanOrgID(ctx, testPlanID)
+	return checkOrg(ctx, orgID, err, "test plan")
+}
+
+func ensureTestRunInOrg(ctx context.Context, queries *dbsqlc.Queries, testRunID uuid.UUID) error {
+	orgID, err := queries.GetTestRunOrgID(ctx, testRunID)
+	return checkOrg(ctx, orgID, err, "test run")
+}
+
+func ensureTestPlanCommentInOrg(ctx context.Context, queries *dbsqlc.Queries, commentID uuid.UUID) error {
+	orgID, err := queries.GetTestPlanCommentOrgID(ctx, commentID)
+	return checkOrg(ctx, orgID, err, "comment")
+}
+
+// parseTestCaseID parses the ID of a test case in its org, malformed IDs
+// cannot exist and are not found
+func parseTestCaseID(ctx context.Context, queries *dbsqlc.Queries, testCaseID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(testCaseID)
+	if err != nil {
+		return uuid.Nil, ErrNotFound
+	}
+	return id, ensureTestCaseInOrg(ctx, queries, id)
+}
+
+// parseTestRunID parses the ID of a test run in its org, malformed IDs
+// cannot exist and are not found
+func parseTestRunID(ctx context.Context, queries *dbsqlc.Queries, testRunID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(testRunID)
+	if err != nil {
+		return uuid.Nil, ErrNotFound
+	}
+	return id, ensureTestRunInOrg(ctx, queries, id)
+}
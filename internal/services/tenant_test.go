@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgIDFromContext(t *testing.T) {
+	assert.Equal(t, int64(0), OrgIDFromContext(context.Background()))
+	assert.Equal(t, int64(7), OrgIDFromContext(WithOrgID(context.Background(), 7)))
+}
+
+func TestCheckOrg(t *testing.T) {
+	ctx := WithOrgID(context.Background(), 7)
+
+	assert.NoError(t, checkOrg(ctx, 7, nil, "project"))
+	assert.ErrorIs(t, checkOrg(ctx, 8, nil, "project"), ErrNotFound)
+	assert.ErrorIs(t, checkOrg(ctx, 0, sql.ErrNoRows, "project"), ErrNotFound)
+	assert.ErrorIs(t, checkOrg(context.Background(), 0, nil, "project"), ErrNotFound)
+
+	err := checkOrg(ctx, 0, errors.New("connection reset"), "project")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// ErrInvalidTestRunStep is returned for results of steps a test run does not have
+var ErrInvalidTestRunStep = errors.New("invalid test run step")
+
+// ErrTestRunClosed is returned when resuming a test run which is closed
+var ErrTestRunClosed = errors.New("test run is closed")
+
+// saveTestCaseSteps replaces the steps of a test case, steps referencing a
+// shared step must reference a shared step of the project
+func saveTestCaseSteps(ctx context.Context, db *dbsqlc.Queries, projectID int32, testCaseID uuid.UUID, steps []schema.TestCaseStepRequest) error {
+	if err := db.DeleteTestCaseSteps(ctx, testCaseID); err != nil {
+		return fmt.Errorf("failed to remove test case steps: %w", err)
+	}
+	sharedSteps := map[int32]*dbsqlc.SharedStep{}
+	for i, step := range steps {
+		params := dbsqlc.CreateTestCaseStepParams{
+			TestCaseID:  testCaseID,
+			Position:    int32(i + 1),
+			Attachments: []string{},
+		}
+		if step.SharedStepID != 0 {
+			shared, err := findSharedStep(ctx, db, sharedSteps, step.SharedStepID)
+			if err != nil {
+				return err
+			}
+			if shared == nil || shared.ProjectID != projectID {
+				return fmt.Errorf("%w: step %d references shared step %d which is not in the project", ErrInvalidSharedStep, i+1, step.SharedStepID)
+			}
+			if err := validation.CheckSharedStepValues(schema.SharedStepParameters(shared), step.Parameters); err != nil {
+				return fmt.Errorf("%w: step %d: %v", ErrInvalidSharedStep, i+1, err)
+			}
+			params.SharedStepID = common.NewNullInt32(shared.ID)
+			if len(step.Parameters) > 0 {
+				data, err := json.Marshal(step.Parameters)
+				if err != nil {
+					return err
+				}
+				params.Parameters = pqtype.NullRawMessage{RawMessage: data, Valid: true}
+			}
+		} else {
+			params.Action = strings.TrimSpace(step.Action)
+			params.TestData = strings.TrimSpace(step.TestData)
+			params.ExpectedResult = strings.TrimSpace(step.ExpectedResult)
+			if step.Attachments != nil {
+				params.Attachments = step.Attachments
+			}
+		}
+		if err := db.CreateTestCaseStep(ctx, params); err != nil {
+			return fmt.Errorf("failed to save step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// copyTestCaseSteps copies the steps of a test case to a test case of the
+// project, references to shared steps are changed to the copies in
+// sharedStepIDs
+func copyTestCaseSteps(ctx context.Context, db *dbsqlc.Queries, projectID int32, fromID, toID uuid.UUID, sharedStepIDs map[int32]int32) error {
+	steps, err := db.ListTestCaseSteps(ctx, fromID)
+	if err != nil {
+		return fmt.Errorf("failed to list test case steps: %w", err)
+	}
+	requests := testCaseStepRequests(steps)
+	for i := range requests {
+		if id, ok := sharedStepIDs[requests[i].SharedStepID]; ok {
+			requests[i].SharedStepID = id
+		}
+	}
+	return saveTestCaseSteps(ctx, db, projectID, toID, requests)
+}
+
+func testCaseStepRequests(steps []dbsqlc.TestCaseStep) []schema.TestCaseStepRequest {
+	requests := make([]schema.TestCaseStepRequest, 0, len(steps))
+	for _, step := range steps {
+		requests = append(requests, schema.TestCaseStepRequest{
+			Action:         step.Action,
+			TestData:       step.TestData,
+			ExpectedResult: step.ExpectedResult,
+			Attachments:    step.Attachments,
+			SharedStepID:   step.SharedStepID.Int32,
+			Parameters:     schema.StepParameters(&step),
+		})
+	}
+	return requests
+}
+
+// findSharedStep fetches a shared step through a cache of the shared steps
+// already fetched, it returns nil when the shared step does not exist
+func findSharedStep(ctx context.Context, db *dbsqlc.Queries, cache map[int32]*dbsqlc.SharedStep, id int32) (*dbsqlc.SharedStep, error) {
+	if shared, ok := cache[id]; ok {
+		return shared, nil
+	}
+	shared, err := db.GetSharedStep(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			cache[id] = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch shared step %d: %w", id, err)
+	}
+	cache[id] = &shared
+	return &shared, nil
+}
+
+// listTestCaseSteps lists the steps of a test case and fetches the shared
+// steps they reference by their IDs
+func listTestCaseSteps(ctx context.Context, db *dbsqlc.Queries, testCaseID uuid.UUID) ([]dbsqlc.TestCaseStep, map[int32]*dbsqlc.SharedStep, error) {
+	steps, err := db.ListTestCaseSteps(ctx, testCaseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list test case steps: %w", err)
+	}
+	sharedSteps := map[int32]*dbsqlc.SharedStep{}
+	for _, step := range steps {
+		if !step.SharedStepID.Valid {
+			continue
+		}
+		if _, err := findSharedStep(ctx, db, sharedSteps, step.SharedStepID.Int32); err != nil {
+			return nil, nil, err
+		}
+	}
+	return steps, sharedSteps, nil
+}
+
+// expandTestCaseSteps lists the steps of a test case with the shared steps
+// it references expanded
+func expandTestCaseSteps(ctx context.Context, db *dbsqlc.Queries, testCaseID uuid.UUID) ([]schema.TestCaseStepResponse, error) {
+	steps, sharedSteps, err := listTestCaseSteps(ctx, db, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewTestCaseStepListResponse(steps, sharedSteps), nil
+}
+
+// recordTestRunSteps saves the outcomes of steps of a test run and returns
+// the results of every step. The steps of the test case, with its shared
+// steps expanded and the values of the dataset row of the run filled in, are
+// copied to the run when the first outcome is recorded.
+func recordTestRunSteps(ctx context.Context, db *dbsqlc.Queries, testRun *dbsqlc.TestRun, userID int64, executedAt time.Time, steps []schema.CommitTestStepResult) ([]dbsqlc.TestRunStepResult, error) {
+	started, err := db.ListTestRunStepResults(ctx, testRun.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test run steps: %w", err)
+	}
+	if len(started) == 0 {
+		caseSteps, err := expandTestCaseSteps(ctx, db, testRun.TestCaseID)
+		if err != nil {
+			return nil, err
+		}
+		values := schema.DataValues(testRun.DataValues)
+		for _, step := range caseSteps {
+			err := db.CreateTestRunStepResult(ctx, dbsqlc.CreateTestRunStepResultParams{
+				TestRunID:      testRun.ID,
+				Position:       step.Position,
+				StepID:         common.NewNullInt32(step.ID),
+				Action:         validation.ExpandDatasetText(step.Action, values),
+				TestData:       validation.ExpandDatasetText(step.TestData, values),
+				ExpectedResult: validation.ExpandDatasetText(step.ExpectedResult, values),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to start test run steps: %w", err)
+			}
+		}
+	}
+
+	for _, step := range steps {
+		params := dbsqlc.RecordTestRunStepResultParams{
+			TestRunID:    testRun.ID,
+			Position:     step.Position,
+			Status:       step.Status,
+			ActualResult: strings.TrimSpace(step.ActualResult),
+			Notes:        strings.TrimSpace(step.Notes),
+		}
+		if step.Status != dbsqlc.TestRunStatePending {
+			params.ExecutedByID = common.NewNullInt32(int32(userID))
+			params.ExecutedAt = common.NullTime(executedAt)
+		}
+		n, err := db.RecordTestRunStepResult(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record step %d: %w", step.Position, err)
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("%w: the test run has no step %d", ErrInvalidTestRunStep, step.Position)
+		}
+	}
+
+	return db.ListTestRunStepResults(ctx, testRun.ID)
+}
+
+// deriveTestRunState is the state of a test run from the outcomes of its
+// steps: failed when a step failed, blocked when a step is blocked, pending
+// until every step has an outcome and passed when every step passed
+func deriveTestRunState(results []dbsqlc.TestRunStepResult) dbsqlc.TestRunState {
+	state := dbsqlc.TestRunStatePassed
+	if len(results) == 0 {
+		state = dbsqlc.TestRunStatePending
+	}
+	for _, result := range results {
+		switch result.Status {
+		case dbsqlc.TestRunStateFailed:
+			return dbsqlc.TestRunStateFailed
+		case dbsqlc.TestRunStateBlocked:
+			state = dbsqlc.TestRunStateBlocked
+		case dbsqlc.TestRunStatePending:
+			if state != dbsqlc.TestRunStateBlocked {
+				state = dbsqlc.TestRunStatePending
+			}
+		}
+	}
+	return state
+}
+
+// summarizeStepResults describes the outcome of the steps of a test run, it
+// is used as the actual result of runs committed without one
+func summarizeStepResults(results []dbsqlc.TestRunStepResult) string {
+	state := deriveTestRunState(results)
+	for _, result := range results {
+		if result.Status != state || state == dbsqlc.TestRunStatePassed || state == dbsqlc.TestRunStatePending {
+			continue
+		}
+		if result.ActualResult == "" {
+			return fmt.Sprintf("Step %d %s", result.Position, state)
+		}
+		return fmt.Sprintf("Step %d %s: %s", result.Position, state, result.ActualResult)
+	}
+	executed := 0
+	for _, result := range results {
+		if result.Status != dbsqlc.TestRunStatePending {
+			executed++
+		}
+	}
+	if state == dbsqlc.TestRunStatePassed {
+		return fmt.Sprintf("All %d steps passed", executed)
+	}
+	return fmt.Sprintf("%d of %d steps executed", executed, len(results))
+}
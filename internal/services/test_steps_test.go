@@ -0,0 +1,55 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func stepResults(states ...dbsqlc.TestRunState) []dbsqlc.TestRunStepResult {
+	results := make([]dbsqlc.TestRunStepResult, 0, len(states))
+	for i, state := range states {
+		results = append(results, dbsqlc.TestRunStepResult{Position: int32(i + 1), Status: state})
+	}
+	return results
+}
+
+func TestDeriveTestRunState(t *testing.T) {
+	const (
+		passed  = dbsqlc.TestRunStatePassed
+		failed  = dbsqlc.TestRunStateFailed
+		blocked = dbsqlc.TestRunStateBlocked
+		pending = dbsqlc.TestRunStatePending
+	)
+	assert.Equal(t, pending, deriveTestRunState(nil))
+	assert.Equal(t, passed, deriveTestRunState(stepResults(passed, passed)))
+	assert.Equal(t, pending, deriveTestRunState(stepResults(passed, pending)))
+	assert.Equal(t, blocked, deriveTestRunState(stepResults(pending, blocked, pending)))
+	assert.Equal(t, failed, deriveTestRunState(stepResults(passed, blocked, failed, pending)))
+}
+
+func TestSummarizeStepResults(t *testing.T) {
+	results := stepResults(dbsqlc.TestRunStatePassed, dbsqlc.TestRunStateFailed, dbsqlc.TestRunStatePending)
+	results[1].ActualResult = "Error page is shown"
+	assert.Equal(t, "Step 2 failed: Error page is shown", summarizeStepResults(results))
+
+	assert.Equal(t, "Step 1 blocked", summarizeStepResults(stepResults(dbsqlc.TestRunStateBlocked)))
+	assert.Equal(t, "1 of 2 steps executed", summarizeStepResults(stepResults(dbsqlc.TestRunStatePassed, dbsqlc.TestRunStatePending)))
+	assert.Equal(t, "All 2 steps passed", summarizeStepResults(stepResults(dbsqlc.TestRunStatePassed, dbsqlc.TestRunStatePassed)))
+}
+
+func TestTestCaseStepRequestsKeepSharedStepReferences(t *testing.T) {
+	steps := []dbsqlc.TestCaseStep{
+		{ID: 10, Position: 1, SharedStepID: common.NewNullInt32(3), Parameters: pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"username": "admin"}`), Valid: true}},
+		{ID: 11, Position: 2, Action: "Open the cart", ExpectedResult: "Cart is empty", Attachments: []string{}},
+	}
+	assert.Equal(t, []schema.TestCaseStepRequest{
+		{SharedStepID: 3, Parameters: map[string]string{"username": "admin"}},
+		{Action: "Open the cart", ExpectedResult: "Cart is empty", Attachments: []string{}},
+	}, testCaseStepRequests(steps))
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -38,6 +39,24 @@ type TestCaseService interface {
 	// FindAllCreatedBy retrieves all test cases in the database created by a specific user
 	FindAllCreatedBy(context.Context, int64) ([]dbsqlc.TestCase, error)
 
+	// FindSteps retrieves the ordered steps of a test case with the shared steps it references expanded,
+	// and the steps as saved with the references to shared steps
+	FindSteps(context.Context, string) ([]schema.TestCaseStepResponse, []schema.TestCaseStepRequest, error)
+	// FindRevisions retrieves the revisions of a test case, newest first
+	FindRevisions(context.Context, string) ([]dbsqlc.TestCaseRevision, error)
+	// DiffRevisions lists the fields which differ between two revisions of a test case
+	DiffRevisions(ctx context.Context, testCaseID string, from, to int32) (*schema.TestCaseRevisionDiffResponse, error)
+	// RestoreRevision sets a test case back to an earlier revision, which is saved as a new revision
+	RestoreRevision(ctx context.Context, testCaseID string, revision int32, userID int64) (*dbsqlc.TestCase, error)
+	// FindDataset retrieves the dataset a data-driven test case is run with
+	FindDataset(context.Context, string) (*dbsqlc.TestCaseDataset, error)
+	// SetDataset replaces the parameters and rows of the dataset of a test case
+	SetDataset(context.Context, *schema.SetTestCaseDatasetRequest) (*dbsqlc.TestCaseDataset, error)
+	// ImportDataset replaces the dataset of a test case by the rows of a CSV or Excel file, the first row names the parameters
+	ImportDataset(ctx context.Context, testCaseID string, userID int64, file io.Reader, filename string) (*dbsqlc.TestCaseDataset, error)
+	// DeleteDataset removes the dataset of a test case, runs already created for its rows are kept
+	DeleteDataset(context.Context, string) error
+
 	// Create creates a new test case
 	Create(context.Context, *schema.CreateTestCaseRequest) (*dbsqlc.TestCase, error)
 
@@ -59,13 +78,13 @@ type TestCaseService interface {
 	// BulkDelete deletes multiple test-cases by ID
 	BulkDelete(context.Context, []string) error
 
-	//Search is used to search a test case based on the title or code
-	Search(context.Context, string) ([]dbsqlc.TestCase, error)
+	//Search is used to search the test cases of a project based on the title or code
+	Search(ctx context.Context, projectID int64, keyword string) ([]dbsqlc.TestCase, error)
 	// FindAllAssignedToUser fetches test cases assigned to a logged in user (via test_plan_cases), with option to include/exclude closed runs
 	FindAllAssignedToUser(ctx context.Context, userID int64, limit, offset int32, includeClosed bool) ([]schema.AssignedTestCase, int64, error)
 	// MarkAsDraft is used to mark a test case as draft
 	MarkAsDraft(ctx context.Context, testCaseID string) error
-	// UnMarkAsDraft is used to unmark a draft test case
+	// UnMarkAsDraft is used to unmark a draft test case, its description must follow the project template
 	UnMarkAsDraft(ctx context.Context, testCaseID string) error
 	// GetExecutionSummaryByUser used to dynamically update the counts for 'success', 'failed, and 'test executed'
 	GetExecutionSummaryByUser(ctx context.Context, userID int64) ([]schema.TestCaseExecutionSummary, error)
@@ -102,6 +121,9 @@ type TestCaseQueryParams struct {
 	IsDraft   *bool
 	Suggested *bool
 	Module    string
+	// CustomFields filters on the values of custom fields by name, multi
+	// select fields match when the value is one of the selected options
+	CustomFields map[string]string
 }
 
 var _ TestCaseService = &testCaseServiceImpl{}
@@ -122,6 +144,9 @@ func NewTestCaseService(db *sql.DB, conn *dbsqlc.Queries, logger logging.Logger)
 
 // BulkCreate implements TestCaseService.
 func (t *testCaseServiceImpl) BulkCreate(ctx context.Context, bulkRequest *schema.BulkCreateTestCases) ([]dbsqlc.TestCase, int, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, bulkRequest.ProjectID); err != nil {
+		return nil, 0, err
+	}
 	sqlTx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, 0, err
@@ -140,16 +165,11 @@ func (t *testCaseServiceImpl) BulkCreate(ctx context.Context, bulkRequest *schem
 			return nil, skipped, fmt.Errorf("failed to fetch project: %w", err)
 		}
 
-		// Ensure sequence row exists for this project's item
-		prefixKey := strings.ToLower(project.Code)
-		if err := tx.InitTestCaseSequence(ctx, dbsqlc.InitTestCaseSequenceParams{
-			ProjectID: int32(request.ProjectID),
-			Prefix:    prefixKey,
-		}); err != nil {
-			return nil, skipped, fmt.Errorf("failed to ensure sequence row: %w", err)
+		if err := checkTestCaseTemplate(ctx, tx, project.ID, request.Kind, request.Description, request.IsDraft); err != nil {
+			return nil, skipped, fmt.Errorf("test case %q does not follow the project template: %w", request.Title, err)
 		}
 
-		code, err := GenerateNextCode(ctx, tx, request.ProjectID, &project, &request.Code)
+		code, err := GenerateNextCode(ctx, tx, &project, request.Kind, request.FeatureOrModule, &request.Code)
 		if err != nil {
 			return nil, skipped, err
 		}
@@ -188,6 +208,15 @@ func (t *testCaseServiceImpl) BulkCreate(ctx context.Context, bulkRequest *schem
 		if err != nil {
 			return nil, skipped, err
 		}
+		if err := saveCustomFieldValues(ctx, tx, project.ID, CustomFieldEntityTestCase, createdID, request.CustomFields, true); err != nil {
+			return nil, skipped, fmt.Errorf("invalid custom fields of test case %q: %w", request.Title, err)
+		}
+		if err := saveTestCaseSteps(ctx, tx, project.ID, createdID, request.Steps); err != nil {
+			return nil, skipped, fmt.Errorf("invalid steps of test case %q: %w", request.Title, err)
+		}
+		if _, err := recordTestCaseRevision(ctx, tx, createdID, 0); err != nil {
+			return nil, skipped, err
+		}
 		tc, err := tx.GetTestCase(ctx, createdID)
 		if err != nil {
 			return nil, skipped, fmt.Errorf("failed to fetch test case %q after insert: %w", code, err)
@@ -210,6 +239,9 @@ func (t *testCaseServiceImpl) BulkDelete(context.Context, []string) error {
 
 // Create implements TestCaseService.
 func (t *testCaseServiceImpl) Create(ctx context.Context, request *schema.CreateTestCaseRequest) (*dbsqlc.TestCase, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
 	sqlTx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -223,16 +255,11 @@ func (t *testCaseServiceImpl) Create(ctx context.Context, request *schema.Create
 		return nil, fmt.Errorf("failed to fetch project: %w", err)
 	}
 
-	// Ensure sequence row exists for this project inside the same transaction
-	prefixKey := strings.ToLower(project.Code)
-	if err := tx.InitTestCaseSequence(ctx, dbsqlc.InitTestCaseSequenceParams{
-		ProjectID: int32(request.ProjectID),
-		Prefix:    prefixKey,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to ensure sequence row: %w", err)
+	if err := checkTestCaseTemplate(ctx, tx, project.ID, request.Kind, request.Description, request.IsDraft); err != nil {
+		return nil, err
 	}
 
-	code, err := GenerateNextCode(ctx, tx, request.ProjectID, &project, &request.Code)
+	code, err := GenerateNextCode(ctx, tx, &project, request.Kind, request.FeatureOrModule, &request.Code)
 	if err != nil {
 		return nil, err
 	}
@@ -270,6 +297,15 @@ func (t *testCaseServiceImpl) Create(ctx context.Context, request *schema.Create
 	if err != nil {
 		return nil, err
 	}
+	if err := saveCustomFieldValues(ctx, tx, project.ID, CustomFieldEntityTestCase, createdID, request.CustomFields, true); err != nil {
+		return nil, err
+	}
+	if err := saveTestCaseSteps(ctx, tx, project.ID, createdID, request.Steps); err != nil {
+		return nil, err
+	}
+	if _, err := recordTestCaseRevision(ctx, tx, createdID, int64(userID)); err != nil {
+		return nil, err
+	}
 
 	tc, err := tx.GetTestCase(ctx, createdID)
 	if err != nil {
@@ -285,7 +321,7 @@ func (t *testCaseServiceImpl) Create(ctx context.Context, request *schema.Create
 
 // DeleteByID implements TestCaseService.
 func (t *testCaseServiceImpl) DeleteByID(ctx context.Context, id string) error {
-	uuidID, err := uuid.Parse(id)
+	uuidID, err := parseTestCaseID(ctx, t.queries, id)
 	if err != nil {
 		return err
 	}
@@ -294,7 +330,7 @@ func (t *testCaseServiceImpl) DeleteByID(ctx context.Context, id string) error {
 		return err
 	}
 
-	return nil
+	return t.queries.DeleteCustomFieldValuesByEntity(ctx, uuidID)
 }
 
 // DeleteByProjectID implements TestCaseService.
@@ -309,7 +345,7 @@ func (t *testCaseServiceImpl) DeleteByTestRunID(context.Context, string) error {
 
 // FindAll implements TestCaseService.
 func (t *testCaseServiceImpl) FindAll(ctx context.Context) ([]dbsqlc.TestCase, error) {
-	return t.queries.ListTestCases(ctx)
+	return t.queries.ListTestCases(ctx, contextOrgID(ctx))
 }
 
 // FindAllPaged implements TestCaseService.
@@ -347,13 +383,15 @@ func (t *testCaseServiceImpl) FindAllPaged(ctx context.Context, params TestCaseQ
 		sortOrder = "desc"
 	}
 
-	conditions := []string{"1=1"}
-	args := []interface{}{}
-	argPos := 1
+	conditions := []string{"project_id IN (SELECT id FROM projects WHERE org_id = $1)"}
+	args := []interface{}{contextOrgID(ctx)}
+	argPos := 2
 
 	search := strings.TrimSpace(params.Search)
 	if search != "" {
-		conditions = append(conditions, fmt.Sprintf("(code ILIKE $%d OR title ILIKE $%d OR description ILIKE $%d OR feature_or_module ILIKE $%d)", argPos, argPos, argPos, argPos))
+		// codes a test case had before it was renumbered still find it
+		conditions = append(conditions, fmt.Sprintf(`(code ILIKE $%d OR title ILIKE $%d OR description ILIKE $%d OR feature_or_module ILIKE $%d
+OR EXISTS (SELECT 1 FROM test_case_code_aliases a WHERE a.test_case_id = test_cases.id AND a.code ILIKE $%d))`, argPos, argPos, argPos, argPos, argPos))
 		args = append(args, "%"+search+"%")
 		argPos++
 	}
@@ -379,6 +417,15 @@ func (t *testCaseServiceImpl) FindAllPaged(ctx context.Context, params TestCaseQ
 		conditions = append(conditions, "(suggested IS NULL OR suggested = false)")
 	}
 
+	for name, value := range params.CustomFields {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM custom_field_values v
+INNER JOIN custom_fields f ON f.id = v.field_id
+WHERE v.entity_id = test_cases.id AND f.entity = '%s' AND f.name = $%d
+AND (v.value #>> '{}' = $%d OR (jsonb_typeof(v.value) = 'array' AND v.value ? $%d)))`, CustomFieldEntityTestCase, argPos, argPos+1, argPos+1))
+		args = append(args, name, value)
+		argPos += 2
+	}
+
 	whereClause := strings.Join(conditions, " AND ")
 
 	var total int64
@@ -433,7 +480,10 @@ FROM test_cases WHERE %s ORDER BY %s %s LIMIT $%d OFFSET $%d`, whereClause, sort
 
 // FindAllByID implements TestCaseService.
 func (t *testCaseServiceImpl) FindByID(ctx context.Context, id string) (*dbsqlc.GetTestCaseWithParentRow, error) {
-	uuidID := uuid.MustParse(id)
+	uuidID, err := parseTestCaseID(ctx, t.queries, id)
+	if err != nil {
+		return nil, err
+	}
 	tc, err := t.queries.GetTestCaseWithParent(ctx, uuidID)
 	if err != nil {
 		return nil, err
@@ -441,13 +491,32 @@ func (t *testCaseServiceImpl) FindByID(ctx context.Context, id string) (*dbsqlc.
 	return &tc, nil
 }
 
+// FindSteps implements TestCaseService.
+func (t *testCaseServiceImpl) FindSteps(ctx context.Context, id string) ([]schema.TestCaseStepResponse, []schema.TestCaseStepRequest, error) {
+	uuidID, err := parseTestCaseID(ctx, t.queries, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	steps, sharedSteps, err := listTestCaseSteps(ctx, t.queries, uuidID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema.NewTestCaseStepListResponse(steps, sharedSteps), testCaseStepRequests(steps), nil
+}
+
 // FindAllByProjectID implements TestCaseService.
 func (t *testCaseServiceImpl) FindAllByProjectID(ctx context.Context, projectID int64) ([]dbsqlc.TestCase, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	return t.queries.ListTestCasesByProject(ctx, sql.NullInt32{Int32: int32(projectID), Valid: true})
 }
 
 // FindAllByProjectIDPaged implements TestCaseService.
 func (t *testCaseServiceImpl) FindAllByProjectIDPaged(ctx context.Context, projectID int64, params TestCaseQueryParams) ([]dbsqlc.TestCase, int64, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, 0, err
+	}
 	page := params.Page
 	pageSize := params.PageSize
 	if page < 1 {
@@ -479,6 +548,9 @@ func (t *testCaseServiceImpl) FindAllByProjectIDPaged(ctx context.Context, proje
 
 // FindAllByProjectID implements TestCaseService.
 func (t *testCaseServiceImpl) FindAllByTestPlanID(ctx context.Context, testPlanID int64) ([]schema.TestCaseResponseItem, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, testPlanID); err != nil {
+		return nil, err
+	}
 	rows, err := t.queries.ListTestCasesByPlan(ctx, testPlanID)
 	if err != nil {
 		return nil, err
@@ -512,7 +584,10 @@ func (t *testCaseServiceImpl) FindAllByTestPlanID(ctx context.Context, testPlanI
 
 // FindAllCreatedBy implements TestCaseService.
 func (t *testCaseServiceImpl) FindAllCreatedBy(ctx context.Context, createdByID int64) ([]dbsqlc.TestCase, error) {
-	return t.queries.ListTestCasesByCreator(ctx, int32(createdByID))
+	return t.queries.ListTestCasesByCreator(ctx, dbsqlc.ListTestCasesByCreatorParams{
+		CreatedByID: int32(createdByID),
+		OrgID:       contextOrgID(ctx),
+	})
 }
 
 // Update implements TestCaseService.
@@ -521,6 +596,9 @@ func (t *testCaseServiceImpl) Update(ctx context.Context, req *schema.UpdateTest
 	if err != nil {
 		return nil, fmt.Errorf("invalid UUID: %w", err)
 	}
+	if err := ensureTestCaseInOrg(ctx, t.queries, id); err != nil {
+		return nil, err
+	}
 
 	params := dbsqlc.UpdateTestCaseParams{
 		ID:              id,
@@ -536,52 +614,101 @@ func (t *testCaseServiceImpl) Update(ctx context.Context, req *schema.UpdateTest
 		ScriptPath:      common.NullString(req.ScriptPath),
 	}
 
-	if err := t.queries.UpdateTestCase(ctx, params); err != nil {
+	sqlTx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
+	defer sqlTx.Rollback()
 
-	tc, err := t.queries.GetTestCase(ctx, id)
+	tx := dbsqlc.New(sqlTx)
+	if err := tx.UpdateTestCase(ctx, params); err != nil {
+		return nil, err
+	}
+
+	tc, err := tx.GetTestCase(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkTestCaseTemplate(ctx, tx, tc.ProjectID.Int32, string(tc.Kind), tc.Description, tc.IsDraft.Bool); err != nil {
+		return nil, err
+	}
+	if req.CustomFields != nil {
+		if err := saveCustomFieldValues(ctx, tx, tc.ProjectID.Int32, CustomFieldEntityTestCase, id, req.CustomFields, false); err != nil {
+			return nil, err
+		}
+	}
+	if req.Steps != nil {
+		if err := saveTestCaseSteps(ctx, tx, tc.ProjectID.Int32, id, req.Steps); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := recordTestCaseRevision(ctx, tx, id, req.UpdatedByID); err != nil {
+		return nil, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
 	return &tc, nil
 }
 
-func (t *testCaseServiceImpl) Search(ctx context.Context, keyword string) ([]dbsqlc.TestCase, error) {
-	testCases, err := t.queries.SearchTestCases(ctx, common.NullString(keyword))
+func (t *testCaseServiceImpl) Search(ctx context.Context, projectID int64, keyword string) ([]dbsqlc.TestCase, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
+	testCases, err := t.queries.SearchTestCases(ctx, dbsqlc.SearchTestCasesParams{
+		ProjectID: common.NewNullInt32(int32(projectID)),
+		Column2:   common.NullString(keyword),
+	})
 	if err != nil {
 		t.logger.Error("failed to search test cases with keyword %q", keyword, err)
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
+		return nil, err
 	}
 
 	return testCases, nil
 
 }
 
-func GenerateNextCode(ctx context.Context, db *dbsqlc.Queries, projectID int64, project *dbsqlc.Project, userCode *string) (string, error) {
+// GenerateNextCode returns the code given by the user or the next code of
+// the code pattern of the project for a test case of the kind and module
+func GenerateNextCode(ctx context.Context, db *dbsqlc.Queries, project *dbsqlc.Project, kind, featureOrModule string, userCode *string) (string, error) {
 	if userCode != nil && *userCode != "" {
 		return *userCode, nil
 	}
 
-	prefixKey := strings.ToLower(project.Code)
+	pattern := projectCodePattern(project)
+	values, err := codePatternValues(ctx, db, project, pattern, kind, featureOrModule)
+	if err != nil {
+		return "", err
+	}
+	_, prefixKey := renderCode(pattern, values, 0)
 
+	// Ensure sequence row exists for this combination of the tokens
+	if err := db.InitTestCaseSequence(ctx, dbsqlc.InitTestCaseSequenceParams{
+		ProjectID: project.ID,
+		Prefix:    prefixKey,
+	}); err != nil {
+		return "", fmt.Errorf("failed to ensure sequence row: %w", err)
+	}
 	seq, err := db.GetNextTestCaseSequence(ctx, dbsqlc.GetNextTestCaseSequenceParams{
-		ProjectID: int32(projectID),
+		ProjectID: project.ID,
 		Prefix:    prefixKey,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to get next sequence: %w", err)
 	}
 
-	displayPrefix := strings.ToUpper(prefixKey) // used for code formatting
-	return fmt.Sprintf("%s%03d", displayPrefix, seq), nil
+	code, _ := renderCode(pattern, values, int(seq))
+	return code, nil
 }
 
 func (t *testCaseServiceImpl) FindAllAssignedToUser(ctx context.Context, userID int64, limit, offset int32, includeClosed bool) ([]schema.AssignedTestCase, int64, error) {
 	rows, err := t.queries.TestCaseListByAssignedUser(ctx, dbsqlc.TestCaseListByAssignedUserParams{
 		UserID:        userID,
+		OrgID:         contextOrgID(ctx),
 		RowLimit:      limit,
 		RowOffset:     offset,
 		IncludeClosed: includeClosed,
@@ -618,6 +745,7 @@ func (t *testCaseServiceImpl) FindAllAssignedToUser(ctx context.Context, userID
 	// Count query
 	total, err := t.queries.TestCaseCountByAssignedUser(ctx, dbsqlc.TestCaseCountByAssignedUserParams{
 		UserID:        userID,
+		OrgID:         contextOrgID(ctx),
 		IncludeClosed: includeClosed,
 	})
 	if err != nil {
@@ -628,12 +756,16 @@ func (t *testCaseServiceImpl) FindAllAssignedToUser(ctx context.Context, userID
 }
 
 func (t *testCaseServiceImpl) MarkAsDraft(ctx context.Context, testCaseID string) error {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return err
+	}
 	params := dbsqlc.SetTestCaseDraftStatusParams{
-		ID:      uuid.MustParse(testCaseID),
+		ID:      id,
 		IsDraft: common.TrueNullBool(),
 	}
 
-	err := t.queries.SetTestCaseDraftStatus(ctx, params)
+	err = t.queries.SetTestCaseDraftStatus(ctx, params)
 	if err != nil {
 		t.logger.Error("failed to update draft status", "error", err)
 		return err
@@ -643,12 +775,24 @@ func (t *testCaseServiceImpl) MarkAsDraft(ctx context.Context, testCaseID string
 }
 
 func (t *testCaseServiceImpl) UnMarkAsDraft(ctx context.Context, testCaseID string) error {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return err
+	}
+	tc, err := t.queries.GetTestCase(ctx, id)
+	if err != nil {
+		return err
+	}
+	// drafts skip the template check until they are published
+	if err := checkTestCaseTemplate(ctx, t.queries, tc.ProjectID.Int32, string(tc.Kind), tc.Description, false); err != nil {
+		return err
+	}
 	params := dbsqlc.SetTestCaseDraftStatusParams{
-		ID:      uuid.MustParse(testCaseID),
+		ID:      id,
 		IsDraft: common.FalseNullBool(),
 	}
 
-	err := t.queries.SetTestCaseDraftStatus(ctx, params)
+	err = t.queries.SetTestCaseDraftStatus(ctx, params)
 	if err != nil {
 		t.logger.Error("failed to update draft status", "error", err)
 		return err
@@ -658,7 +802,10 @@ func (t *testCaseServiceImpl) UnMarkAsDraft(ctx context.Context, testCaseID stri
 }
 
 func (t *testCaseServiceImpl) GetExecutionSummaryByUser(ctx context.Context, userID int64) ([]schema.TestCaseExecutionSummary, error) {
-	rows, err := t.queries.GetTestCaseExecutionSummary(ctx, common.NewNullInt32(int32(userID)))
+	rows, err := t.queries.GetTestCaseExecutionSummary(ctx, dbsqlc.GetTestCaseExecutionSummaryParams{
+		ExecutedBy: common.NewNullInt32(int32(userID)),
+		OrgID:      contextOrgID(ctx),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -676,6 +823,9 @@ func (t *testCaseServiceImpl) GetExecutionSummaryByUser(ctx context.Context, use
 }
 
 func (t *testCaseServiceImpl) FindAllClosed(ctx context.Context, projectID int64) ([]schema.TestCaseResponse, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	params := dbsqlc.FindTestCasesByProjectIDParams{
 		ProjectID:    common.NewNullInt32(int32(projectID)),
 		IsClosed:     common.TrueNullBool(),
@@ -693,6 +843,9 @@ func (t *testCaseServiceImpl) FindAllClosed(ctx context.Context, projectID int64
 }
 
 func (t *testCaseServiceImpl) FindAllFailing(ctx context.Context, projectID int64) ([]schema.TestCaseResponse, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	params := dbsqlc.FindTestCasesByProjectIDParams{
 		ProjectID:    common.NewNullInt32(int32(projectID)),
 		IsClosed:     common.TrueNullBool(),
@@ -710,6 +863,9 @@ func (t *testCaseServiceImpl) FindAllFailing(ctx context.Context, projectID int6
 }
 
 func (t *testCaseServiceImpl) FindAllScheduled(ctx context.Context, projectID int64) ([]schema.TestCaseResponse, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	params := dbsqlc.FindTestCasesByProjectIDParams{
 		ProjectID:    common.NewNullInt32(int32(projectID)),
 		IsClosed:     common.FalseNullBool(),
@@ -727,6 +883,9 @@ func (t *testCaseServiceImpl) FindAllScheduled(ctx context.Context, projectID in
 }
 
 func (t *testCaseServiceImpl) FindAllBlocked(ctx context.Context, projectID int64) ([]schema.TestCaseResponse, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	params := dbsqlc.FindTestCasesByProjectIDParams{
 		ProjectID:    common.NewNullInt32(int32(projectID)),
 		IsClosed:     common.FalseNullBool(),
@@ -768,6 +927,9 @@ func toTestCaseResponse(row dbsqlc.FindTestCasesByProjectIDRow) schema.TestCaseR
 }
 
 func (t *testCaseServiceImpl) GetExistingCodes(ctx context.Context, projectID int64) (map[string]bool, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	params := dbsqlc.FindTestCasesByProjectIDParams{
 		ProjectID:    common.NewNullInt32(int32(projectID)),
 		IsClosed:     common.FalseNullBool(),
@@ -789,6 +951,9 @@ func (t *testCaseServiceImpl) GetExistingCodes(ctx context.Context, projectID in
 }
 
 func (t *testCaseServiceImpl) Suggest(ctx context.Context, req *schema.CreateSuggestedTestCaseRequest) (*dbsqlc.TestCase, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, req.ProjectID); err != nil {
+		return nil, err
+	}
 	uuidVal, _ := uuid.NewV7()
 
 	project, err := t.queries.GetProject(ctx, int32(req.ProjectID))
@@ -796,15 +961,7 @@ func (t *testCaseServiceImpl) Suggest(ctx context.Context, req *schema.CreateSug
 		return nil, fmt.Errorf("failed to fetch project: %w", err)
 	}
 
-	prefixKey := strings.ToLower(project.Code)
-	if err := t.queries.InitTestCaseSequence(ctx, dbsqlc.InitTestCaseSequenceParams{
-		ProjectID: int32(req.ProjectID),
-		Prefix:    prefixKey,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to ensure sequence row: %w", err)
-	}
-
-	code, err := GenerateNextCode(ctx, t.queries, req.ProjectID, &project, &req.Code)
+	code, err := GenerateNextCode(ctx, t.queries, &project, req.Kind, req.FeatureOrModule, &req.Code)
 	if err != nil {
 		return nil, err
 	}
@@ -831,6 +988,9 @@ func (t *testCaseServiceImpl) Suggest(ctx context.Context, req *schema.CreateSug
 	if err != nil {
 		return nil, err
 	}
+	if _, err := recordTestCaseRevision(ctx, t.queries, uuidVal, req.CreatedByID); err != nil {
+		return nil, err
+	}
 
 	tc, err := t.queries.GetTestCase(ctx, uuidVal)
 	if err != nil {
@@ -840,6 +1000,9 @@ func (t *testCaseServiceImpl) Suggest(ctx context.Context, req *schema.CreateSug
 }
 
 func (t *testCaseServiceImpl) FindAllSuggested(ctx context.Context, projectID int64) ([]dbsqlc.TestCase, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	return t.queries.FindAllSuggestedByProject(ctx, dbsqlc.FindAllSuggestedByProjectParams{
 		ProjectID: common.NewNullInt32(int32(projectID)),
 		Suggested: common.TrueNullBool(),
@@ -847,18 +1010,27 @@ func (t *testCaseServiceImpl) FindAllSuggested(ctx context.Context, projectID in
 }
 
 func (t *testCaseServiceImpl) AcceptSuggested(ctx context.Context, testCaseID string) error {
-	id := uuid.MustParse(testCaseID)
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return err
+	}
 	return t.queries.UpdateSuggestedFlag(ctx, dbsqlc.UpdateSuggestedFlagParams{
 		ID:        id,
 		Suggested: common.FalseNullBool(),
 	})
 }
 func (t *testCaseServiceImpl) RejectSuggested(ctx context.Context, testCaseID string) error {
-	id := uuid.MustParse(testCaseID)
-	_, err := t.queries.DeleteTestCase(ctx, id)
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return err
+	}
+	_, err = t.queries.DeleteTestCase(ctx, id)
 	return err
 }
 
 func (t *testCaseServiceImpl) FindScriptCasesByPlanID(ctx context.Context, testPlanID int64) ([]dbsqlc.TestCase, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, testPlanID); err != nil {
+		return nil, err
+	}
 	return t.queries.ListScriptTestCasesByPlan(ctx, int64(testPlanID))
 }
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/google/uuid"
+)
+
+// DefaultTestCaseCodePattern generates the codes test cases had before
+// projects could set a pattern e.g. PAY001
+const DefaultTestCaseCodePattern = "{project}{seq:3}"
+
+// ErrInvalidCodePattern is returned for code patterns which are not valid
+var ErrInvalidCodePattern = errors.New("invalid test case code pattern")
+
+// ErrInvalidTestCaseCode is returned when a test case cannot be given a code
+var ErrInvalidTestCaseCode = errors.New("invalid test case code")
+
+// TestCaseCodeRE matches the codes test cases can be renamed to
+var TestCaseCodeRE = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9._-]{0,63}\z`)
+
+var (
+	codePatternTokenRE   = regexp.MustCompile(`\{([a-z]+)(?::([0-9]+))?\}`)
+	codePatternLiteralRE = regexp.MustCompile(`\A[A-Za-z0-9._-]*\z`)
+)
+
+// TestCaseCodeService manages the code pattern of projects and renames the
+// codes of existing test cases
+type TestCaseCodeService interface {
+	GetPattern(ctx context.Context, projectID int64) (*schema.TestCaseCodePatternResponse, error)
+	// SetPattern changes the pattern of the codes generated for new test cases,
+	// existing codes are kept
+	SetPattern(ctx context.Context, request *schema.TestCaseCodePatternRequest) (*schema.TestCaseCodePatternResponse, error)
+	// Resequence renames test case codes, rewriting their references in test
+	// runs and test plans and keeping the old codes as aliases
+	Resequence(ctx context.Context, request *schema.ResequenceTestCaseCodesRequest) (*schema.ResequenceTestCaseCodesResponse, error)
+	ListAliases(ctx context.Context, projectID int64) ([]dbsqlc.TestCaseCodeAlias, error)
+}
+
+var _ TestCaseCodeService = &testCaseCodeServiceImpl{}
+
+type testCaseCodeServiceImpl struct {
+	name    loggedmodule.Name
+	db      *sql.DB
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewTestCaseCodeService(conn *sql.DB, queries *dbsqlc.Queries, logger logging.Logger) TestCaseCodeService {
+	return &testCaseCodeServiceImpl{
+		name:    "testCaseCode-service",
+		db:      conn,
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *testCaseCodeServiceImpl) GetPattern(ctx context.Context, projectID int64) (*schema.TestCaseCodePatternResponse, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	project, err := s.queries.GetProject(ctx, int32(projectID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	return codePatternResponse(&project, projectCodePattern(&project)), nil
+}
+
+func (s *testCaseCodeServiceImpl) SetPattern(ctx context.Context, request *schema.TestCaseCodePatternRequest) (*schema.TestCaseCodePatternResponse, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
+	pattern := strings.TrimSpace(request.Pattern)
+	if pattern == DefaultTestCaseCodePattern {
+		pattern = ""
+	}
+	if pattern != "" {
+		if err := ValidateCodePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	err = tx.SetProjectTestCaseCodePattern(ctx, dbsqlc.SetProjectTestCaseCodePatternParams{
+		ID:                  int32(request.ProjectID),
+		TestcaseCodePattern: common.NullString(pattern),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set code pattern: %w", err)
+	}
+	project, err := tx.GetProject(ctx, int32(request.ProjectID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	// existing codes which already follow the pattern must not be generated again
+	testCases, err := tx.ListTestCasesByProject(ctx, common.NewNullInt32(project.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+	codes := make([]string, 0, len(testCases))
+	for _, tc := range testCases {
+		codes = append(codes, tc.Code)
+	}
+	if err := advanceTestCaseSequences(ctx, tx, &project, codes); err != nil {
+		return nil, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	return codePatternResponse(&project, projectCodePattern(&project)), nil
+}
+
+func (s *testCaseCodeServiceImpl) ListAliases(ctx context.Context, projectID int64) ([]dbsqlc.TestCaseCodeAlias, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
+	return s.queries.ListTestCaseCodeAliases(ctx, int32(projectID))
+}
+
+func (s *testCaseCodeServiceImpl) Resequence(ctx context.Context, request *schema.ResequenceTestCaseCodesRequest) (*schema.ResequenceTestCaseCodesResponse, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
+	project, err := s.queries.GetProject(ctx, int32(request.ProjectID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project: %w", err)
+	}
+
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+	tx := dbsqlc.New(sqlTx)
+
+	testCases, err := tx.ListTestCasesByProject(ctx, common.NewNullInt32(project.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+	var changes []codeChange
+	if len(request.Renames) == 0 {
+		changes, err = resequencedCodes(ctx, tx, &project, testCases)
+	} else {
+		changes, err = renamedCodes(testCases, request.Renames)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &schema.ResequenceTestCaseCodesResponse{
+		DryRun:  request.DryRun,
+		Changes: make([]schema.TestCaseCodeChange, 0, len(changes)),
+	}
+	for _, change := range changes {
+		res.Changes = append(res.Changes, schema.TestCaseCodeChange{
+			TestCaseID: change.id.String(),
+			OldCode:    change.oldCode,
+			NewCode:    change.newCode,
+		})
+	}
+	if request.DryRun || len(changes) == 0 {
+		return res, nil
+	}
+
+	res.TestRuns, res.TestPlans, err = applyCodeChanges(ctx, tx, project.ID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := make(map[uuid.UUID]string, len(changes))
+	for _, change := range changes {
+		renamed[change.id] = change.newCode
+	}
+	codes := make([]string, 0, len(testCases))
+	for _, tc := range testCases {
+		codes = append(codes, cmp.Or(renamed[tc.ID], tc.Code))
+	}
+	if len(request.Renames) == 0 {
+		// counters restart from the re-sequenced codes
+		if err := tx.DeleteTestCaseSequences(ctx, project.ID); err != nil {
+			return nil, fmt.Errorf("failed to reset test case sequences: %w", err)
+		}
+	}
+	if err := advanceTestCaseSequences(ctx, tx, &project, codes); err != nil {
+		return nil, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	s.logger.Info(s.name, "renamed test case codes", "projectID", project.ID, "count", len(changes))
+	return res, nil
+}
+
+type codeChange struct {
+	id      uuid.UUID
+	oldCode string
+	newCode string
+}
+
+// resequencedCodes numbers every test case of a project again with its
+// pattern, oldest first, and returns the codes that change
+func resequencedCodes(ctx context.Context, db *dbsqlc.Queries, project *dbsqlc.Project, testCases []dbsqlc.TestCase) ([]codeChange, error) {
+	testCases = slices.Clone(testCases)
+	slices.SortStableFunc(testCases, func(a, b dbsqlc.TestCase) int {
+		if c := a.CreatedAt.Time.Compare(b.CreatedAt.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Code, b.Code)
+	})
+
+	pattern := projectCodePattern(project)
+	counters := map[string]int{}
+	var changes []codeChange
+	for _, tc := range testCases {
+		values, err := codePatternValues(ctx, db, project, pattern, string(tc.Kind), tc.FeatureOrModule.String)
+		if err != nil {
+			return nil, fmt.Errorf("test case %s: %w", tc.Code, err)
+		}
+		_, key := renderCode(pattern, values, 0)
+		counters[key]++
+		code, _ := renderCode(pattern, values, counters[key])
+		if code != tc.Code {
+			changes = append(changes, codeChange{id: tc.ID, oldCode: tc.Code, newCode: code})
+		}
+	}
+	return changes, nil
+}
+
+// renamedCodes checks the renames of codes of test cases of a project
+func renamedCodes(testCases []dbsqlc.TestCase, renames map[string]string) ([]codeChange, error) {
+	byCode := make(map[string]dbsqlc.TestCase, len(testCases))
+	for _, tc := range testCases {
+		byCode[tc.Code] = tc
+	}
+	changes := make([]codeChange, 0, len(renames))
+	newCodes := map[string]string{}
+	for oldCode, newCode := range renames {
+		tc, ok := byCode[oldCode]
+		if !ok {
+			return nil, fmt.Errorf("%w: no test case has code %s", ErrInvalidTestCaseCode, oldCode)
+		}
+		if !TestCaseCodeRE.MatchString(newCode) {
+			return nil, fmt.Errorf("%w: %s, use letters, digits, dots, dashes and underscores", ErrInvalidTestCaseCode, newCode)
+		}
+		if other, ok := newCodes[newCode]; ok {
+			return nil, fmt.Errorf("%w: %s and %s are both renamed to %s", ErrInvalidTestCaseCode, other, oldCode, newCode)
+		}
+		newCodes[newCode] = oldCode
+		if oldCode != newCode {
+			changes = append(changes, codeChange{id: tc.ID, oldCode: oldCode, newCode: newCode})
+		}
+	}
+	for newCode := range newCodes {
+		if _, taken := byCode[newCode]; taken {
+			if _, renamed := renames[newCode]; !renamed {
+				return nil, fmt.Errorf("%w: %s is already used by another test case", ErrInvalidTestCaseCode, newCode)
+			}
+		}
+	}
+	slices.SortFunc(changes, func(a, b codeChange) int { return cmp.Compare(a.oldCode, b.oldCode) })
+	return changes, nil
+}
+
+// applyCodeChanges renames the codes of test cases, rewrites the codes in
+// test runs and plans and keeps the old codes as aliases. Codes are renamed
+// in two steps so codes can be swapped or shifted without conflicts.
+func applyCodeChanges(ctx context.Context, tx *dbsqlc.Queries, projectID int32, changes []codeChange) (testRuns, testPlans int64, err error) {
+	for _, change := range changes {
+		if err := tx.SetTestCaseCode(ctx, dbsqlc.SetTestCaseCodeParams{ID: change.id, Code: "~" + change.id.String()}); err != nil {
+			return 0, 0, fmt.Errorf("failed to rename test case %s: %w", change.oldCode, err)
+		}
+	}
+	for _, change := range changes {
+		if err := tx.SetTestCaseCode(ctx, dbsqlc.SetTestCaseCodeParams{ID: change.id, Code: change.newCode}); err != nil {
+			return 0, 0, fmt.Errorf("failed to rename test case %s: %w", change.oldCode, err)
+		}
+		n, err := tx.RenameTestRunCodes(ctx, dbsqlc.RenameTestRunCodesParams{
+			NewCode:    change.newCode,
+			TestCaseID: change.id,
+			OldCode:    change.oldCode,
+		})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to rename test runs of %s: %w", change.oldCode, err)
+		}
+		testRuns += n
+	}
+
+	// mentions of codes in free text go through placeholders for the same reason
+	rewrite := func(from, to string) (runs, plans int64, err error) {
+		params := dbsqlc.ReplaceCodeInTestPlansParams{
+			Pattern:     codeReferencePattern(from),
+			Replacement: `\1` + to,
+			ProjectID:   projectID,
+		}
+		if plans, err = tx.ReplaceCodeInTestPlans(ctx, params); err != nil {
+			return 0, 0, fmt.Errorf("failed to rewrite test plans: %w", err)
+		}
+		if runs, err = tx.ReplaceCodeInTestRuns(ctx, dbsqlc.ReplaceCodeInTestRunsParams(params)); err != nil {
+			return 0, 0, fmt.Errorf("failed to rewrite test runs: %w", err)
+		}
+		return runs, plans, nil
+	}
+	placeholder := func(change codeChange) string { return "qatarina-code-" + change.id.String() }
+	for _, change := range changes {
+		runs, plans, err := rewrite(change.oldCode, placeholder(change))
+		if err != nil {
+			return 0, 0, err
+		}
+		testRuns += runs
+		testPlans += plans
+	}
+	for _, change := range changes {
+		if _, _, err := rewrite(placeholder(change), change.newCode); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for _, change := range changes {
+		err := tx.DeleteTestCaseCodeAlias(ctx, dbsqlc.DeleteTestCaseCodeAliasParams{ProjectID: projectID, Code: change.newCode})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to remove alias %s: %w", change.newCode, err)
+		}
+	}
+	for _, change := range changes {
+		if slices.ContainsFunc(changes, func(c codeChange) bool { return c.newCode == change.oldCode }) {
+			continue
+		}
+		err := tx.CreateTestCaseCodeAlias(ctx, dbsqlc.CreateTestCaseCodeAliasParams{
+			ProjectID:  projectID,
+			Code:       change.oldCode,
+			TestCaseID: change.id,
+		})
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to keep alias %s: %w", change.oldCode, err)
+		}
+	}
+	return testRuns, testPlans, nil
+}
+
+// codeReferencePattern matches a code in free text, not as part of a longer code
+func codeReferencePattern(code string) string {
+	return `(^|[^A-Za-z0-9._-])` + regexp.QuoteMeta(code) + `(?![A-Za-z0-9_-]|\.[A-Za-z0-9])`
+}
+
+// ValidateCodePattern checks a pattern has one counter and only known tokens
+func ValidateCodePattern(pattern string) error {
+	seqs := 0
+	for _, match := range codePatternTokenRE.FindAllStringSubmatch(pattern, -1) {
+		switch match[1] {
+		case "project", "module", "kind":
+			if match[2] != "" {
+				return fmt.Errorf("%w: {%s} does not take a width", ErrInvalidCodePattern, match[1])
+			}
+		case "seq":
+			seqs++
+			if width, _ := strconv.Atoi(cmp.Or(match[2], "1")); width < 1 || width > 9 {
+				return fmt.Errorf("%w: the width of {seq} must be between 1 and 9", ErrInvalidCodePattern)
+			}
+		default:
+			return fmt.Errorf("%w: unknown token {%s}, use {project}, {module}, {kind} or {seq:N}", ErrInvalidCodePattern, match[1])
+		}
+	}
+	if seqs != 1 {
+		return fmt.Errorf("%w: the pattern needs exactly one {seq:N} counter", ErrInvalidCodePattern)
+	}
+	literal := codePatternTokenRE.ReplaceAllString(pattern, "")
+	if !codePatternLiteralRE.MatchString(literal) {
+		return fmt.Errorf("%w: use letters, digits, dots, dashes and underscores around the tokens", ErrInvalidCodePattern)
+	}
+	if strings.ContainsAny(pattern[:1], "._-") {
+		return fmt.Errorf("%w: the pattern must start with a letter, a digit or a token", ErrInvalidCodePattern)
+	}
+	return nil
+}
+
+// projectCodePattern returns the code pattern of a project
+func projectCodePattern(project *dbsqlc.Project) string {
+	if project.TestcaseCodePattern.Valid && project.TestcaseCodePattern.String != "" {
+		return project.TestcaseCodePattern.String
+	}
+	return DefaultTestCaseCodePattern
+}
+
+type codeValues struct {
+	project string
+	module  string
+	kind    string
+}
+
+// codePatternValues finds the values of the tokens of a pattern for a test
+// case, the module is matched by name or code
+func codePatternValues(ctx context.Context, db *dbsqlc.Queries, project *dbsqlc.Project, pattern, kind, featureOrModule string) (codeValues, error) {
+	values := codeValues{
+		project: strings.ToUpper(project.Code),
+		kind:    strings.ToUpper(kind),
+	}
+	if !strings.Contains(pattern, "{module}") {
+		return values, nil
+	}
+	modules, err := db.GetProjectModules(ctx, project.ID)
+	if err != nil {
+		return values, fmt.Errorf("failed to list modules: %w", err)
+	}
+	name := strings.TrimSpace(featureOrModule)
+	idx := slices.IndexFunc(modules, func(m dbsqlc.Module) bool {
+		return strings.EqualFold(m.Name, name) || strings.EqualFold(m.Code, name)
+	})
+	if idx < 0 || strings.TrimSpace(modules[idx].Code) == "" {
+		return values, fmt.Errorf("%w: the code pattern needs a module, no module of the project matches %q", ErrInvalidTestCaseCode, featureOrModule)
+	}
+	values.module = strings.ToUpper(strings.TrimSpace(modules[idx].Code))
+	return values, nil
+}
+
+// renderCode builds the code of a pattern and the key of its counter, the
+// key is the lowercase code without the counter so every combination of
+// project, module and kind has its own counter
+func renderCode(pattern string, values codeValues, seq int) (code, key string) {
+	var codeSB, keySB strings.Builder
+	last := 0
+	for _, loc := range codePatternTokenRE.FindAllStringSubmatchIndex(pattern, -1) {
+		codeSB.WriteString(pattern[last:loc[0]])
+		keySB.WriteString(pattern[last:loc[0]])
+		last = loc[1]
+		token := pattern[loc[2]:loc[3]]
+		var value string
+		switch token {
+		case "project":
+			value = values.project
+		case "module":
+			value = values.module
+		case "kind":
+			value = values.kind
+		case "seq":
+			width := 1
+			if loc[4] >= 0 {
+				width, _ = strconv.Atoi(pattern[loc[4]:loc[5]])
+			}
+			codeSB.WriteString(fmt.Sprintf("%0*d", width, seq))
+			continue
+		}
+		codeSB.WriteString(value)
+		keySB.WriteString(value)
+	}
+	codeSB.WriteString(pattern[last:])
+	keySB.WriteString(pattern[last:])
+	return codeSB.String(), strings.ToLower(keySB.String())
+}
+
+// parseCode reads the counter key and value of a code generated from a pattern
+func parseCode(pattern string, project *dbsqlc.Project, code string) (key string, seq int, ok bool) {
+	var sb strings.Builder
+	sb.WriteString(`(?i)\A`)
+	last := 0
+	for _, loc := range codePatternTokenRE.FindAllStringSubmatchIndex(pattern, -1) {
+		sb.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		last = loc[1]
+		switch pattern[loc[2]:loc[3]] {
+		case "project":
+			sb.WriteString(regexp.QuoteMeta(project.Code))
+		case "module":
+			sb.WriteString(`[A-Za-z0-9._-]+?`)
+		case "kind":
+			sb.WriteString(`[A-Za-z_]+?`)
+		case "seq":
+			sb.WriteString(`([0-9]+)`)
+		}
+	}
+	sb.WriteString(regexp.QuoteMeta(pattern[last:]))
+	sb.WriteString(`\z`)
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return "", 0, false
+	}
+	loc := re.FindStringSubmatchIndex(code)
+	if loc == nil {
+		return "", 0, false
+	}
+	seq, err = strconv.Atoi(code[loc[2]:loc[3]])
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.ToLower(code[:loc[2]] + code[loc[3]:]), seq, true
+}
+
+// advanceTestCaseSequences moves the counters of a project past the codes
+// which follow its pattern, so generated codes do not clash with them
+func advanceTestCaseSequences(ctx context.Context, db *dbsqlc.Queries, project *dbsqlc.Project, codes []string) error {
+	pattern := projectCodePattern(project)
+	counters := map[string]int{}
+	for _, code := range codes {
+		if key, seq, ok := parseCode(pattern, project, code); ok {
+			counters[key] = max(counters[key], seq)
+		}
+	}
+	for key, seq := range counters {
+		err := db.AdvanceTestCaseSequence(ctx, dbsqlc.AdvanceTestCaseSequenceParams{
+			ProjectID:  project.ID,
+			Prefix:     key,
+			CurrentVal: int32(seq),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set test case sequence: %w", err)
+		}
+	}
+	return nil
+}
+
+func codePatternResponse(project *dbsqlc.Project, pattern string) *schema.TestCaseCodePatternResponse {
+	example, _ := renderCode(pattern, codeValues{
+		project: strings.ToUpper(project.Code),
+		module:  "MOD",
+		kind:    strings.ToUpper(string(dbsqlc.TestKindGeneral)),
+	}, 1)
+	return &schema.TestCaseCodePatternResponse{
+		Pattern: pattern,
+		Example: example,
+	}
+}
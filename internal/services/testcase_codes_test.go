@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCodePattern(t *testing.T) {
+	for _, pattern := range []string{DefaultTestCaseCodePattern, "{project}-{module}-{seq:4}", "TC.{kind}_{seq}"} {
+		assert.NoError(t, ValidateCodePattern(pattern), pattern)
+	}
+	for _, pattern := range []string{"", "{project}", "{seq:2}{seq:3}", "{seq:0}", "{project:2}{seq}", "{owner}{seq}", "{project} {seq}", "-{seq}"} {
+		assert.ErrorIs(t, ValidateCodePattern(pattern), ErrInvalidCodePattern, pattern)
+	}
+}
+
+func TestRenderAndParseCode(t *testing.T) {
+	project := &dbsqlc.Project{Code: "abc"}
+	values := codeValues{project: "ABC", module: "AUTH", kind: "SECURITY"}
+
+	code, key := renderCode(DefaultTestCaseCodePattern, values, 7)
+	assert.Equal(t, "ABC007", code)
+	assert.Equal(t, "abc", key)
+
+	key, seq, ok := parseCode(DefaultTestCaseCodePattern, project, "ABC1042")
+	assert.True(t, ok)
+	assert.Equal(t, "abc", key)
+	assert.Equal(t, 1042, seq)
+
+	pattern := "{project}-{module}-{kind}-{seq:4}"
+	code, key = renderCode(pattern, values, 12)
+	assert.Equal(t, "ABC-AUTH-SECURITY-0012", code)
+	assert.Equal(t, "abc-auth-security-", key)
+
+	key, seq, ok = parseCode(pattern, project, code)
+	assert.True(t, ok)
+	assert.Equal(t, "abc-auth-security-", key)
+	assert.Equal(t, 12, seq)
+
+	_, _, ok = parseCode(pattern, project, "XYZ-AUTH-SECURITY-0012")
+	assert.False(t, ok)
+}
+
+func TestRenamedCodes(t *testing.T) {
+	testCases := []dbsqlc.TestCase{
+		{ID: uuid.New(), Code: "ABC001"},
+		{ID: uuid.New(), Code: "ABC002"},
+		{ID: uuid.New(), Code: "ABC003"},
+	}
+
+	changes, err := renamedCodes(testCases, map[string]string{"ABC001": "ABC002", "ABC002": "ABC001", "ABC003": "ABC003"})
+	assert.NoError(t, err)
+	assert.Equal(t, []codeChange{
+		{id: testCases[0].ID, oldCode: "ABC001", newCode: "ABC002"},
+		{id: testCases[1].ID, oldCode: "ABC002", newCode: "ABC001"},
+	}, changes)
+
+	for _, renames := range []map[string]string{
+		{"ABC009": "ABC010"},
+		{"ABC001": "ABC 1"},
+		{"ABC001": "ABC003"},
+		{"ABC001": "NEW", "ABC002": "NEW"},
+	} {
+		_, err := renamedCodes(testCases, renames)
+		assert.ErrorIs(t, err, ErrInvalidTestCaseCode, "%v", renames)
+	}
+}
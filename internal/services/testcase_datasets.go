@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrInvalidDataset is returned for datasets of test cases which are not valid
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// FindDataset implements TestCaseService.
+func (t *testCaseServiceImpl) FindDataset(ctx context.Context, testCaseID string) (*dbsqlc.TestCaseDataset, error) {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	dataset, err := t.queries.GetTestCaseDataset(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch dataset: %w", err)
+	}
+	return &dataset, nil
+}
+
+// SetDataset implements TestCaseService.
+func (t *testCaseServiceImpl) SetDataset(ctx context.Context, request *schema.SetTestCaseDatasetRequest) (*dbsqlc.TestCaseDataset, error) {
+	id, err := parseTestCaseID(ctx, t.queries, request.TestCaseID)
+	if err != nil {
+		return nil, err
+	}
+	dataset, err := saveTestCaseDataset(ctx, t.queries, id, request.Parameters, request.Rows, common.NewNullInt32(int32(request.UpdatedByID)))
+	if err != nil {
+		return nil, err
+	}
+	t.logger.Info("test-case-service", "saved test case dataset", "testCaseID", id, "rows", len(request.Rows))
+	return dataset, nil
+}
+
+// ImportDataset implements TestCaseService.
+func (t *testCaseServiceImpl) ImportDataset(ctx context.Context, testCaseID string, userID int64, file io.Reader, filename string) (*dbsqlc.TestCaseDataset, error) {
+	records, err := readDatasetFile(file, filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+	}
+	parameters, rows, err := datasetFromRecords(records)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+	}
+	return t.SetDataset(ctx, &schema.SetTestCaseDatasetRequest{
+		TestCaseID:  testCaseID,
+		UpdatedByID: userID,
+		Parameters:  parameters,
+		Rows:        rows,
+	})
+}
+
+// DeleteDataset implements TestCaseService.
+func (t *testCaseServiceImpl) DeleteDataset(ctx context.Context, testCaseID string) error {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return err
+	}
+	n, err := t.queries.DeleteTestCaseDataset(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dataset: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// saveTestCaseDataset validates the parameters and rows of the dataset of a
+// test case and replaces its dataset with them, blank values are dropped
+func saveTestCaseDataset(ctx context.Context, db *dbsqlc.Queries, testCaseID uuid.UUID, parameters []string, rows []map[string]string, updatedByID sql.NullInt32) (*dbsqlc.TestCaseDataset, error) {
+	names := make([]string, 0, len(parameters))
+	for _, name := range parameters {
+		names = append(names, strings.TrimSpace(name))
+	}
+	items := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		values := make(map[string]string, len(row))
+		for name, value := range row {
+			if value = strings.TrimSpace(value); value != "" {
+				values[strings.TrimSpace(name)] = value
+			}
+		}
+		items = append(items, values)
+	}
+	if err := validation.ValidateDataset(names, items); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+	}
+	rowsJSON, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, err := db.UpsertTestCaseDataset(ctx, dbsqlc.UpsertTestCaseDatasetParams{
+		TestCaseID:  testCaseID,
+		Parameters:  names,
+		Rows:        rowsJSON,
+		UpdatedByID: updatedByID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save dataset: %w", err)
+	}
+	return &dataset, nil
+}
+
+// copyTestCaseDataset copies the dataset of a test case, if it has one, to
+// another test case
+func copyTestCaseDataset(ctx context.Context, db *dbsqlc.Queries, fromID, toID uuid.UUID) error {
+	dataset, err := db.GetTestCaseDataset(ctx, fromID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch dataset: %w", err)
+	}
+	_, err = db.UpsertTestCaseDataset(ctx, dbsqlc.UpsertTestCaseDatasetParams{
+		TestCaseID:  toID,
+		Parameters:  dataset.Parameters,
+		Rows:        dataset.Rows,
+		UpdatedByID: dataset.UpdatedByID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy dataset: %w", err)
+	}
+	return nil
+}
+
+// dataValuesJSON encodes the values of a dataset row for a test run, null
+// for runs of test cases without a dataset
+func dataValuesJSON(values map[string]string) pqtype.NullRawMessage {
+	if values == nil {
+		return pqtype.NullRawMessage{}
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return pqtype.NullRawMessage{}
+	}
+	return pqtype.NullRawMessage{RawMessage: raw, Valid: true}
+}
+
+// readDatasetFile reads the records of a CSV file or of the first sheet of an
+// Excel file
+func readDatasetFile(file io.Reader, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV file: %w", err)
+		}
+		return records, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Excel file: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("Excel file has no sheets")
+		}
+		records, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Excel rows: %w", err)
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type, use a .csv or .xlsx file")
+	}
+}
+
+// datasetFromRecords reads a dataset from the records of a file, the first
+// record which is not blank names the parameters and the records after it
+// are the rows. Blank records are skipped.
+func datasetFromRecords(records [][]string) ([]string, []map[string]string, error) {
+	var parameters []string
+	var rows []map[string]string
+	for i, record := range records {
+		if isBlankRecord(record) {
+			continue
+		}
+		if parameters == nil {
+			for _, cell := range record {
+				name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff")))
+				parameters = append(parameters, name)
+			}
+			// spreadsheets often save empty trailing columns
+			for parameters[len(parameters)-1] == "" {
+				parameters = parameters[:len(parameters)-1]
+			}
+			continue
+		}
+		if len(record) > len(parameters) && !isBlankRecord(record[len(parameters):]) {
+			return nil, nil, fmt.Errorf("line %d has more values than the header has parameters", i+1)
+		}
+		row := map[string]string{}
+		for j, cell := range record[:min(len(record), len(parameters))] {
+			if value := strings.TrimSpace(cell); value != "" {
+				row[parameters[j]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	if parameters == nil {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	return parameters, rows, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// createDataDrivenTestRuns creates a test run for every row of the dataset of
+// a test case assigned to a plan, with the values of the row. Runs are told
+// apart by the values they test, runs already created for the values of a row
+// are kept wherever the row has moved, so assigning the test case again only
+// adds runs for rows added or changed since. It returns the number of runs
+// created.
+func createDataDrivenTestRuns(ctx context.Context, db *dbsqlc.Queries, plan *dbsqlc.GetTestPlanRow, testCaseID uuid.UUID, assignedToID int64) (int64, error) {
+	dataset, err := db.GetTestCaseDataset(ctx, testCaseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to fetch dataset: %w", err)
+	}
+	rows := schema.DatasetRows(&dataset)
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	tc, err := db.GetTestCase(ctx, testCaseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch test case: %w", err)
+	}
+
+	var created int64
+	for i, row := range rows {
+		n, err := db.CreateDataDrivenTestRun(ctx, dbsqlc.CreateDataDrivenTestRunParams{
+			ID:            uuid.New(),
+			ProjectID:     plan.ProjectID,
+			TestPlanID:    common.NewNullInt32(int32(plan.ID)),
+			TestCaseID:    testCaseID,
+			OwnerID:       plan.CreatedByID,
+			AssignedToID:  common.NewNullInt32(int32(assignedToID)),
+			Code:          dataDrivenTestRunCode(tc.Code, i+1),
+			EnvironmentID: plan.EnvironmentID,
+			DataRow:       common.NewNullInt32(int32(i + 1)),
+			DataValues:    dataValuesJSON(row),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to create test run for row %d: %w", i+1, err)
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// dataDrivenTestRunCode is the code of the test run of a row of the dataset
+// of a test case
+func dataDrivenTestRunCode(testCaseCode string, row int) string {
+	return fmt.Sprintf("%s#%d", testCaseCode, row)
+}
+
+// dataRowLabel describes the values of a dataset row, ordered by name
+func dataRowLabel(row int32, values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+values[name])
+	}
+	return fmt.Sprintf("Data row %d: %s", row, strings.Join(parts, ", "))
+}
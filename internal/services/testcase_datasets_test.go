@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetFromRecords(t *testing.T) {
+	records := [][]string{
+		{},
+		{"\ufeffCurrency", " Locale ", "account_type", ""},
+		{"MWK", "en_MW", "savings"},
+		{"", "", ""},
+		{"USD", " ", "current", ""},
+	}
+	parameters, rows, err := datasetFromRecords(records)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"currency", "locale", "account_type"}, parameters)
+	assert.Equal(t, []map[string]string{
+		{"currency": "MWK", "locale": "en_MW", "account_type": "savings"},
+		{"currency": "USD", "account_type": "current"},
+	}, rows)
+
+	_, _, err = datasetFromRecords([][]string{{"currency"}, {"MWK", "en_MW"}})
+	assert.ErrorContains(t, err, "line 2")
+	_, _, err = datasetFromRecords([][]string{{" "}})
+	assert.ErrorContains(t, err, "empty")
+}
+
+func TestReadDatasetFile(t *testing.T) {
+	records, err := readDatasetFile(strings.NewReader("currency,locale\nMWK\n"), "Rates.CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"currency", "locale"}, {"MWK"}}, records)
+
+	_, err = readDatasetFile(strings.NewReader(""), "rates.txt")
+	assert.ErrorContains(t, err, "unsupported")
+}
+
+func TestDataRowLabel(t *testing.T) {
+	assert.Equal(t, "TC-001#3", dataDrivenTestRunCode("TC-001", 3))
+	assert.Equal(t, "Data row 2: currency=MWK, locale=en_MW", dataRowLabel(2, map[string]string{"locale": "en_MW", "currency": "MWK"}))
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/logging/loggedmodule"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestCaseExportColumns are the test case columns of import and export files,
+// the custom fields of the project follow them
+var TestCaseExportColumns = []string{"title", "description", "kind", "code", "feature_or_module", "tags", "is_draft"}
+
+// ErrUnsupportedExportFormat is returned for export formats other than csv and xlsx
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+type TestCaseExportService interface {
+	// Export writes the test cases of a project as a csv or xlsx file in the
+	// layout accepted by the import
+	Export(ctx context.Context, projectID int64, format string, w io.Writer) error
+}
+
+type testCaseExportServiceImpl struct {
+	name    loggedmodule.Name
+	queries *dbsqlc.Queries
+	logger  logging.Logger
+}
+
+func NewTestCaseExportService(queries *dbsqlc.Queries, logger logging.Logger) TestCaseExportService {
+	return &testCaseExportServiceImpl{
+		name:    "exportTestcasesToFile-service",
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+func (s *testCaseExportServiceImpl) Export(ctx context.Context, projectID int64, format string, w io.Writer) error {
+	if format != "csv" && format != "xlsx" {
+		return ErrUnsupportedExportFormat
+	}
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return err
+	}
+
+	testCases, err := s.queries.ListTestCasesByProject(ctx, sql.NullInt32{Int32: int32(projectID), Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to list test cases: %w", err)
+	}
+	fields, err := s.queries.ListCustomFieldsByEntity(ctx, dbsqlc.ListCustomFieldsByEntityParams{
+		ProjectID: int32(projectID),
+		Entity:    CustomFieldEntityTestCase,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	ids := make([]uuid.UUID, 0, len(testCases))
+	for _, tc := range testCases {
+		ids = append(ids, tc.ID)
+	}
+	values, err := customFieldValues(ctx, s.queries, ids)
+	if err != nil {
+		return err
+	}
+
+	header := append([]string{}, TestCaseExportColumns...)
+	for _, field := range fields {
+		header = append(header, field.Name)
+	}
+	rows := [][]string{header}
+	for _, tc := range testCases {
+		row := []string{
+			tc.Title,
+			tc.Description,
+			string(tc.Kind),
+			tc.Code,
+			tc.FeatureOrModule.String,
+			strings.Join(tc.Tags, ","),
+			strconv.FormatBool(tc.IsDraft.Bool),
+		}
+		for _, field := range fields {
+			row = append(row, exportCustomFieldValue(values[tc.ID][field.Name]))
+		}
+		rows = append(rows, row)
+	}
+
+	if format == "csv" {
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			s.logger.Error(s.name, "failed to write CSV file", "projectID", projectID, "error", err)
+			return fmt.Errorf("failed to write CSV file: %w", err)
+		}
+		return nil
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			s.logger.Error(s.name, "failed to write Excel row", "projectID", projectID, "error", err)
+			return fmt.Errorf("failed to write Excel row: %w", err)
+		}
+	}
+	if _, err := f.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write Excel file: %w", err)
+	}
+	return nil
+}
+
+// exportCustomFieldValue formats a custom field value the way the import reads it
+func exportCustomFieldValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
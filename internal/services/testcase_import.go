@@ -72,9 +72,11 @@ func (s *testCaseImportServiceImpl) FromFile(ctx context.Context, projectID int6
 
 	// Detect header row
 	start := 1
+	var header []string
 	for i, row := range rows {
 		if len(row) >= 7 && strings.ToLower(row[0]) == "title" && strings.ToLower(row[2]) == "kind" {
 			start = i + 1
+			header = row
 			break
 		}
 	}
@@ -107,6 +109,7 @@ func (s *testCaseImportServiceImpl) FromFile(ctx context.Context, projectID int6
 			FeatureOrModule: row[4],
 			Tags:            tags,
 			IsDraft:         strings.ToLower(row[6]) == "true",
+			CustomFields:    importCustomFields(header, row),
 		})
 	}
 
@@ -117,3 +120,21 @@ func (s *testCaseImportServiceImpl) FromFile(ctx context.Context, projectID int6
 
 	return testCases, nil
 }
+
+// importCustomFields reads the custom field values of a row, the columns after
+// the test case columns are named after the custom fields in the header row.
+// Blank cells are left out so the defaults of the fields apply.
+func importCustomFields(header, row []string) map[string]any {
+	values := map[string]any{}
+	for i := len(TestCaseExportColumns); i < len(header) && i < len(row); i++ {
+		name := strings.ToLower(strings.TrimSpace(header[i]))
+		if name == "" || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		values[name] = row[i]
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/google/uuid"
+)
+
+// recordTestCaseRevision saves a revision of a test case when it differs from
+// its latest revision and returns the number of the current revision
+func recordTestCaseRevision(ctx context.Context, db *dbsqlc.Queries, testCaseID uuid.UUID, authorID int64) (int32, error) {
+	tc, err := db.GetTestCase(ctx, testCaseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch test case: %w", err)
+	}
+	steps, sharedSteps, err := listTestCaseSteps(ctx, db, testCaseID)
+	if err != nil {
+		return 0, err
+	}
+	stepsJSON, err := json.Marshal(schema.NewTestCaseRevisionSteps(steps, sharedSteps))
+	if err != nil {
+		return 0, err
+	}
+	if tc.Tags == nil {
+		tc.Tags = []string{}
+	}
+
+	latest, err := db.GetLatestTestCaseRevision(ctx, testCaseID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch latest revision: %w", err)
+	}
+	if err == nil && sameRevision(&latest, &tc, stepsJSON) {
+		return latest.Revision, nil
+	}
+
+	revision, err := db.CreateTestCaseRevision(ctx, dbsqlc.CreateTestCaseRevisionParams{
+		TestCaseID:  testCaseID,
+		Title:       tc.Title,
+		Description: tc.Description,
+		Kind:        tc.Kind,
+		Tags:        tc.Tags,
+		Runner:      tc.Runner,
+		ScriptPath:  tc.ScriptPath,
+		Steps:       stepsJSON,
+		AuthorID:    common.NewNullInt32(int32(authorID)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save test case revision: %w", err)
+	}
+	return revision, nil
+}
+
+// recordTestRunRevision sets the revision of the test case a test run records
+// its results against
+func recordTestRunRevision(ctx context.Context, db *dbsqlc.Queries, testRun *dbsqlc.TestRun) error {
+	revision, err := recordTestCaseRevision(ctx, db, testRun.TestCaseID, 0)
+	if err != nil {
+		return err
+	}
+	err = db.SetTestRunTestCaseRevision(ctx, dbsqlc.SetTestRunTestCaseRevisionParams{
+		ID:               testRun.ID,
+		TestCaseRevision: common.NewNullInt32(revision),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set test case revision of test run: %w", err)
+	}
+	return nil
+}
+
+// sameRevision reports whether a test case and its steps are unchanged since a revision
+func sameRevision(revision *dbsqlc.TestCaseRevision, tc *dbsqlc.TestCase, stepsJSON []byte) bool {
+	// steps are compared after a round trip as jsonb does not keep the
+	// formatting of the stored document
+	revisionSteps, err := json.Marshal(schema.RevisionSteps(revision))
+	if err != nil {
+		return false
+	}
+	return revision.Title == tc.Title &&
+		revision.Description == tc.Description &&
+		revision.Kind == tc.Kind &&
+		slices.Equal(revision.Tags, tc.Tags) &&
+		revision.Runner.String == tc.Runner.String &&
+		revision.ScriptPath.String == tc.ScriptPath.String &&
+		string(revisionSteps) == string(stepsJSON)
+}
+
+// FindRevisions implements TestCaseService.
+func (t *testCaseServiceImpl) FindRevisions(ctx context.Context, testCaseID string) ([]dbsqlc.TestCaseRevision, error) {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	return t.queries.ListTestCaseRevisions(ctx, id)
+}
+
+// DiffRevisions implements TestCaseService.
+func (t *testCaseServiceImpl) DiffRevisions(ctx context.Context, testCaseID string, from, to int32) (*schema.TestCaseRevisionDiffResponse, error) {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+	fromRevision, err := t.getRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := t.getRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	return &schema.TestCaseRevisionDiffResponse{
+		TestCaseID: id.String(),
+		From:       from,
+		To:         to,
+		Changes:    diffRevisions(fromRevision, toRevision),
+	}, nil
+}
+
+// RestoreRevision implements TestCaseService.
+func (t *testCaseServiceImpl) RestoreRevision(ctx context.Context, testCaseID string, revision int32, userID int64) (*dbsqlc.TestCase, error) {
+	id, err := parseTestCaseID(ctx, t.queries, testCaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := dbsqlc.New(sqlTx)
+
+	current, err := tx.GetTestCase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	old, err := tx.GetTestCaseRevision(ctx, dbsqlc.GetTestCaseRevisionParams{TestCaseID: id, Revision: revision})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	err = tx.RestoreTestCaseContent(ctx, dbsqlc.RestoreTestCaseContentParams{
+		ID:          id,
+		Title:       old.Title,
+		Description: old.Description,
+		Kind:        old.Kind,
+		Tags:        old.Tags,
+		Runner:      old.Runner,
+		ScriptPath:  old.ScriptPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore test case: %w", err)
+	}
+	steps, err := restoredSteps(ctx, tx, current.ProjectID.Int32, schema.RevisionSteps(&old))
+	if err != nil {
+		return nil, err
+	}
+	if err := saveTestCaseSteps(ctx, tx, current.ProjectID.Int32, id, steps); err != nil {
+		return nil, err
+	}
+	if _, err := recordTestCaseRevision(ctx, tx, id, userID); err != nil {
+		return nil, err
+	}
+
+	tc, err := tx.GetTestCase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	return &tc, nil
+}
+
+// restoredSteps are the steps of a revision to save as the steps of the test
+// case again. Steps keep referencing their shared step, the copy of a shared
+// step saved with the revision takes its place when the shared step has been
+// deleted since.
+func restoredSteps(ctx context.Context, db *dbsqlc.Queries, projectID int32, steps []schema.TestCaseRevisionStep) ([]schema.TestCaseStepRequest, error) {
+	sharedSteps := map[int32]*dbsqlc.SharedStep{}
+	requests := make([]schema.TestCaseStepRequest, 0, len(steps))
+	for _, step := range steps {
+		if step.SharedStepID == 0 {
+			requests = append(requests, step.TestCaseStepRequest)
+			continue
+		}
+		shared, err := findSharedStep(ctx, db, sharedSteps, step.SharedStepID)
+		if err != nil {
+			return nil, err
+		}
+		if (shared == nil || shared.ProjectID != projectID) && step.SharedSteps != nil {
+			requests = append(requests, step.SharedSteps...)
+			continue
+		}
+		requests = append(requests, step.TestCaseStepRequest)
+	}
+	return requests, nil
+}
+
+func (t *testCaseServiceImpl) getRevision(ctx context.Context, id uuid.UUID, revision int32) (*dbsqlc.TestCaseRevision, error) {
+	res, err := t.queries.GetTestCaseRevision(ctx, dbsqlc.GetTestCaseRevisionParams{TestCaseID: id, Revision: revision})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch revision %d: %w", revision, err)
+	}
+	return &res, nil
+}
+
+// diffRevisions lists the fields which differ between two revisions
+func diffRevisions(from, to *dbsqlc.TestCaseRevision) []schema.TestCaseRevisionChange {
+	changes := []schema.TestCaseRevisionChange{}
+	scalar := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, schema.TestCaseRevisionChange{Field: field, From: a, To: b})
+		}
+	}
+	scalar("title", from.Title, to.Title)
+	if from.Description != to.Description {
+		changes = append(changes, schema.TestCaseRevisionChange{
+			Field: "description",
+			Lines: diffLines(strings.Split(from.Description, "\n"), strings.Split(to.Description, "\n")),
+		})
+	}
+	fromSteps, toSteps := stepLines(schema.RevisionSteps(from)), stepLines(schema.RevisionSteps(to))
+	if !slices.Equal(fromSteps, toSteps) {
+		changes = append(changes, schema.TestCaseRevisionChange{Field: "steps", Lines: diffLines(fromSteps, toSteps)})
+	}
+	if !slices.Equal(from.Tags, to.Tags) {
+		change := schema.TestCaseRevisionChange{Field: "tags"}
+		for _, tag := range to.Tags {
+			if !slices.Contains(from.Tags, tag) {
+				change.Added = append(change.Added, tag)
+			}
+		}
+		for _, tag := range from.Tags {
+			if !slices.Contains(to.Tags, tag) {
+				change.Removed = append(change.Removed, tag)
+			}
+		}
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	scalar("kind", string(from.Kind), string(to.Kind))
+	scalar("runner", from.Runner.String, to.Runner.String)
+	scalar("script_path", from.ScriptPath.String, to.ScriptPath.String)
+	return changes
+}
+
+// stepLines writes each step on one line so steps can be diffed like text,
+// the copied steps of a shared step follow the step referencing it
+func stepLines(steps []schema.TestCaseRevisionStep) []string {
+	lines := make([]string, 0, len(steps))
+	for i, step := range steps {
+		if step.SharedStepID != 0 {
+			line := fmt.Sprintf("%d. shared step %d", i+1, step.SharedStepID)
+			if step.SharedStepName != "" {
+				line += " " + step.SharedStepName
+			}
+			if len(step.Parameters) > 0 {
+				values := make([]string, 0, len(step.Parameters))
+				for _, name := range slices.Sorted(maps.Keys(step.Parameters)) {
+					values = append(values, name+"="+step.Parameters[name])
+				}
+				line += " | parameters: " + strings.Join(values, ", ")
+			}
+			lines = append(lines, line)
+			for j, item := range step.SharedSteps {
+				lines = append(lines, stepLine(fmt.Sprintf("%d.%d.", i+1, j+1), &item))
+			}
+			continue
+		}
+		lines = append(lines, stepLine(fmt.Sprintf("%d.", i+1), &step.TestCaseStepRequest))
+	}
+	return lines
+}
+
+func stepLine(number string, step *schema.TestCaseStepRequest) string {
+	line := number + " " + step.Action
+	if step.TestData != "" {
+		line += " | data: " + step.TestData
+	}
+	if step.ExpectedResult != "" {
+		line += " | expected: " + step.ExpectedResult
+	}
+	if len(step.Attachments) > 0 {
+		line += " | attachments: " + strings.Join(step.Attachments, ", ")
+	}
+	return line
+}
+
+// diffLines is a line diff of a and b from their longest common subsequence
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+	return lines
+}
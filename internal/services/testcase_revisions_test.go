@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t,
+		[]string{"  Open login", "- Enter password", "+ Enter passphrase", "  Submit", "+ See dashboard"},
+		diffLines([]string{"Open login", "Enter password", "Submit"}, []string{"Open login", "Enter passphrase", "Submit", "See dashboard"}))
+	assert.Equal(t, []string{"- a"}, diffLines([]string{"a"}, nil))
+}
+
+func TestDiffRevisions(t *testing.T) {
+	steps := func(actions ...string) json.RawMessage {
+		requests := []schema.TestCaseStepRequest{}
+		for _, action := range actions {
+			requests = append(requests, schema.TestCaseStepRequest{Action: action})
+		}
+		data, _ := json.Marshal(requests)
+		return data
+	}
+	from := &dbsqlc.TestCaseRevision{
+		Revision:    1,
+		Title:       "Login",
+		Description: "Log in",
+		Kind:        dbsqlc.TestKindGeneral,
+		Tags:        []string{"auth", "smoke"},
+		Steps:       steps("Open login", "Submit"),
+	}
+	to := &dbsqlc.TestCaseRevision{
+		Revision:    2,
+		Title:       "Login with password",
+		Description: "Log in",
+		Kind:        dbsqlc.TestKindSecurity,
+		Tags:        []string{"auth", "regression"},
+		Runner:      common.NullString("playwright"),
+		Steps:       steps("Open login", "Enter password", "Submit"),
+	}
+
+	assert.Equal(t, []schema.TestCaseRevisionChange{
+		{Field: "title", From: "Login", To: "Login with password"},
+		{Field: "steps", Lines: []string{"  1. Open login", "- 2. Submit", "+ 2. Enter password", "+ 3. Submit"}},
+		{Field: "tags", Added: []string{"regression"}, Removed: []string{"smoke"}},
+		{Field: "kind", From: "general", To: "security"},
+		{Field: "runner", To: "playwright"},
+	}, diffRevisions(from, to))
+	assert.Empty(t, diffRevisions(from, from))
+}
+
+func TestSameRevision(t *testing.T) {
+	revision := &dbsqlc.TestCaseRevision{
+		Title: "Login",
+		Kind:  dbsqlc.TestKindGeneral,
+		Tags:  []string{},
+		Steps: json.RawMessage(`[{"action": "Open login"}]`),
+	}
+	tc := &dbsqlc.TestCase{Title: "Login", Kind: dbsqlc.TestKindGeneral}
+	stepsJSON, _ := json.Marshal([]schema.TestCaseStepRequest{{Action: "Open login", Attachments: []string{}}})
+
+	assert.True(t, sameRevision(revision, tc, stepsJSON))
+	tc.Description = "Log in"
+	assert.False(t, sameRevision(revision, tc, stepsJSON))
+}
+
+func TestStepLinesOfSharedSteps(t *testing.T) {
+	assert.Equal(t,
+		[]string{"1. shared step 3 | parameters: url=https://example.com, username=admin", "2. Open the cart | expected: Cart is empty"},
+		stepLines([]schema.TestCaseRevisionStep{
+			{TestCaseStepRequest: schema.TestCaseStepRequest{SharedStepID: 3, Parameters: map[string]string{"username": "admin", "url": "https://example.com"}}},
+			{TestCaseStepRequest: schema.TestCaseStepRequest{Action: "Open the cart", ExpectedResult: "Cart is empty"}},
+		}))
+	assert.Equal(t,
+		[]string{"1. shared step 3 Log in | parameters: username=admin", "1.1. Open https://example.com", "1.2. Sign in as admin | expected: Dashboard is shown"},
+		stepLines([]schema.TestCaseRevisionStep{
+			{
+				TestCaseStepRequest: schema.TestCaseStepRequest{SharedStepID: 3, Parameters: map[string]string{"username": "admin"}},
+				SharedStepName:      "Log in",
+				SharedSteps: []schema.TestCaseStepRequest{
+					{Action: "Open https://example.com"},
+					{Action: "Sign in as admin", ExpectedResult: "Dashboard is shown"},
+				},
+			},
+		}))
+}
+
+func TestRevisionStepsKeepSharedStepContent(t *testing.T) {
+	shared := &dbsqlc.SharedStep{
+		ID:         3,
+		Name:       "Log in",
+		Parameters: json.RawMessage(`[{"name": "username"}]`),
+		Steps:      json.RawMessage(`[{"action": "Sign in as {{username}}", "expected_result": "Dashboard is shown"}]`),
+	}
+	steps := []dbsqlc.TestCaseStep{
+		{SharedStepID: common.NewNullInt32(3), Parameters: pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"username": "admin"}`), Valid: true}},
+		{Action: "Open the cart"},
+	}
+	before := schema.NewTestCaseRevisionSteps(steps, map[int32]*dbsqlc.SharedStep{3: shared})
+	assert.Equal(t, "Log in", before[0].SharedStepName)
+	assert.Equal(t, []schema.TestCaseStepRequest{{Action: "Sign in as admin", ExpectedResult: "Dashboard is shown"}}, before[0].SharedSteps)
+	assert.Nil(t, before[1].SharedSteps)
+
+	// a change to the shared step changes the steps of the next revision
+	shared.Steps = json.RawMessage(`[{"action": "Sign in as {{username}} with a passkey"}]`)
+	after := schema.NewTestCaseRevisionSteps(steps, map[int32]*dbsqlc.SharedStep{3: shared})
+	beforeJSON, _ := json.Marshal(before)
+	afterJSON, _ := json.Marshal(after)
+	revision := &dbsqlc.TestCaseRevision{Tags: []string{}, Steps: beforeJSON}
+	tc := &dbsqlc.TestCase{Tags: []string{}}
+	assert.True(t, sameRevision(revision, tc, beforeJSON))
+	assert.False(t, sameRevision(revision, tc, afterJSON))
+}
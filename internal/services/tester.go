@@ -17,10 +17,9 @@ type TesterService interface {
 	AssignBulk(ctx context.Context, projectID int64, request *schema.BulkAssignTesters) error
 	FindAll(context.Context) ([]schema.Tester, error)
 	FindByProjectID(context.Context, int64) ([]schema.Tester, error)
-	Invite(context.Context, any) (any, error)
 	FindByID(context.Context, int32) (*schema.Tester, error)
-	DeleteTester(ctx context.Context, testerID int32) error
-	UpdateRole(ctx context.Context, userID int32, role string) error
+	DeleteTester(ctx context.Context, projectID int64, userID int32) error
+	UpdateRole(ctx context.Context, projectID int64, userID int32, role string) error
 }
 
 type testerServiceImpl struct {
@@ -36,7 +35,7 @@ func NewTesterService(db *dbsqlc.Queries, logger logging.Logger) TesterService {
 }
 
 func (s *testerServiceImpl) FindAll(ctx context.Context) ([]schema.Tester, error) {
-	projectTesters, err := s.queries.GetAllProjectTesters(ctx)
+	projectTesters, err := s.queries.GetAllProjectTesters(ctx, contextOrgID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch project testers: %w", err)
 	}
@@ -65,11 +64,10 @@ func (s *testerServiceImpl) FindAll(ctx context.Context) ([]schema.Tester, error
 	return testers, nil
 }
 
-func (s *testerServiceImpl) Invite(context.Context, any) (any, error) {
-	return nil, fmt.Errorf("not implemented")
-}
-
 func (s *testerServiceImpl) Assign(ctx context.Context, projectID, userID int64, role string) error {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return err
+	}
 	_, err := s.queries.AssignTesterToProject(ctx, dbsqlc.AssignTesterToProjectParams{
 		ProjectID: int32(projectID),
 		UserID:    int32(userID),
@@ -97,6 +95,9 @@ func (s *testerServiceImpl) AssignBulk(ctx context.Context, projectID int64, req
 }
 
 func (s *testerServiceImpl) FindByProjectID(ctx context.Context, projectID int64) ([]schema.Tester, error) {
+	if err := ensureProjectInOrg(ctx, s.queries, projectID); err != nil {
+		return nil, err
+	}
 	projectTesters, err := s.queries.GetTestersByProject(ctx, int32(projectID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -135,6 +136,9 @@ func (t *testerServiceImpl) FindByID(ctx context.Context, id int32) (*schema.Tes
 		t.logger.Error("failed to find the project tester", "error", err)
 		return nil, err
 	}
+	if err := ensureProjectInOrg(ctx, t.queries, int64(dbTester.ProjectID)); err != nil {
+		return nil, err
+	}
 
 	tester := &schema.Tester{
 		UserID:      int64(dbTester.UserID),
@@ -150,28 +154,42 @@ func (t *testerServiceImpl) FindByID(ctx context.Context, id int32) (*schema.Tes
 	return tester, nil
 }
 
-func (t *testerServiceImpl) DeleteTester(ctx context.Context, testerID int32) error {
-	rowsAffected, err := t.queries.DeleteProjectTester(ctx, testerID)
+// DeleteTester removes the user from the testers of a project in the org of
+// the context, their assignments on other projects are left alone
+func (t *testerServiceImpl) DeleteTester(ctx context.Context, projectID int64, userID int32) error {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return err
+	}
+	rowsAffected, err := t.queries.DeleteProjectTester(ctx, dbsqlc.DeleteProjectTesterParams{
+		ProjectID: int32(projectID),
+		UserID:    userID,
+	})
 	if err != nil {
 		t.logger.Error("tester-service", "failed to delete tester", "error", err)
 		return err
 	}
 	if rowsAffected == 0 {
-		return sql.ErrNoRows
+		return ErrNotFound
 	}
 	return nil
 }
 
-func (t *testerServiceImpl) UpdateRole(ctx context.Context, userID int32, role string) error {
+// UpdateRole changes the role of the user on a project in the org of the
+// context
+func (t *testerServiceImpl) UpdateRole(ctx context.Context, projectID int64, userID int32, role string) error {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return err
+	}
 	rowsAffected, err := t.queries.UpdateProjectTesterRole(ctx, dbsqlc.UpdateProjectTesterRoleParams{
-		UserID: userID,
-		Role:   role,
+		ProjectID: int32(projectID),
+		UserID:    userID,
+		Role:      role,
 	})
 	if err != nil {
 		return err
 	}
 	if rowsAffected == 0 {
-		return sql.ErrNoRows
+		return ErrNotFound
 	}
 	return nil
 }
@@ -36,12 +36,14 @@ type TestPlanService interface {
 var _ TestPlanService = &testPlanService{}
 
 type testPlanService struct {
+	db      *sql.DB
 	queries *dbsqlc.Queries
 	logger  logging.Logger
 }
 
-func NewTestPlanService(conn *dbsqlc.Queries, logger logging.Logger) TestPlanService {
+func NewTestPlanService(db *sql.DB, conn *dbsqlc.Queries, logger logging.Logger) TestPlanService {
 	return &testPlanService{
+		db:      db,
 		queries: conn,
 		logger:  logger,
 	}
@@ -49,6 +51,20 @@ func NewTestPlanService(conn *dbsqlc.Queries, logger logging.Logger) TestPlanSer
 
 // Create implements TestPlanService.
 func (t *testPlanService) Create(ctx context.Context, request *schema.CreateTestPlan) (*dbsqlc.GetTestPlanRow, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, request.ProjectID); err != nil {
+		return nil, err
+	}
+	var assignments []testCaseAssignment
+	for _, pt := range request.PlannedTests {
+		tcID, err := parseTestCaseID(ctx, t.queries, pt.TestCaseID)
+		if err != nil {
+			return nil, err
+		}
+		for _, uid := range pt.UserIDs {
+			assignments = append(assignments, testCaseAssignment{TestCaseID: tcID, AssignedToID: uid})
+		}
+	}
+
 	testPlanParams := dbsqlc.CreateTestPlanParams{
 		ProjectID:     int32(request.ProjectID),
 		AssignedToID:  int32(request.AssignedToID),
@@ -70,30 +86,44 @@ func (t *testPlanService) Create(ctx context.Context, request *schema.CreateTest
 		UpdatedAt:      common.NewNullTime(time.Now()),
 	}
 
-	testPlanID, err := t.queries.CreateTestPlan(ctx, testPlanParams)
+	sqlTx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer sqlTx.Rollback()
 
-	for _, assignedTestCase := range request.PlannedTests {
-		for _, uid := range assignedTestCase.UserIDs {
-			if err := t.queries.AddTestCaseToPlan(ctx, dbsqlc.AddTestCaseToPlanParams{
-				TestPlanID:   int64(testPlanID),
-				TestCaseID:   uuid.MustParse(assignedTestCase.TestCaseID),
-				AssignedToID: uid,
-			}); err != nil {
+	tx := dbsqlc.New(sqlTx)
+
+	testPlanID, err := tx.CreateTestPlan(ctx, testPlanParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assignments) > 0 {
+		testPlan, err := tx.GetTestPlan(ctx, testPlanID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range assignments {
+			if err := t.assignTestCase(ctx, tx, &testPlan, a); err != nil {
 				return nil, err
 			}
 		}
 	}
 
-	createdTestPlan, err := t.queries.GetTestPlan(ctx, testPlanID)
-	return &createdTestPlan, err
+	createdTestPlan, err := tx.GetTestPlan(ctx, testPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	return &createdTestPlan, nil
 }
 
 // FindAll implements TestPlanService.
 func (t *testPlanService) FindAll(ctx context.Context) ([]schema.TestPlanResponseItem, error) {
-	plans, err := t.queries.ListTestPlans(ctx)
+	plans, err := t.queries.ListTestPlans(ctx, contextOrgID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +162,9 @@ func (t *testPlanService) FindAll(ctx context.Context) ([]schema.TestPlanRespons
 }
 
 func (t *testPlanService) FindAllByProjectID(ctx context.Context, projectID int64) ([]schema.TestPlanResponseItem, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	plans, err := t.queries.ListTestPlansByProject(ctx, int32(projectID))
 	if err != nil {
 		return nil, err
@@ -172,6 +205,9 @@ func (t *testPlanService) FindAllByProjectID(ctx context.Context, projectID int6
 
 // FindAllByTestPlanID implements TestPlanService
 func (t *testPlanService) FindAllByTestPlanID(ctx context.Context, testPlanID int32) ([]dbsqlc.ListTestRunsByPlanRow, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, int64(testPlanID)); err != nil {
+		return nil, err
+	}
 	return t.queries.ListTestRunsByPlan(ctx, sql.NullInt32{Int32: testPlanID, Valid: true})
 }
 
@@ -180,30 +216,66 @@ type testCaseAssignment struct {
 	AssignedToID int64
 }
 
+// assignTestCases adds the test cases to a plan, either every assignment is
+// saved or none is
 func (t *testPlanService) assignTestCases(ctx context.Context, planID int64, assignments []testCaseAssignment) (*dbsqlc.GetTestPlanRow, error) {
-	testPlan, err := t.queries.GetTestPlan(ctx, planID)
+	if err := ensureTestPlanInOrg(ctx, t.queries, planID); err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := dbsqlc.New(sqlTx)
+
+	testPlan, err := tx.GetTestPlan(ctx, planID)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, a := range assignments {
-		if err := t.queries.AddTestCaseToPlan(ctx, dbsqlc.AddTestCaseToPlanParams{
-			TestPlanID:   planID,
-			TestCaseID:   a.TestCaseID,
-			AssignedToID: a.AssignedToID,
-		}); err != nil {
+		if err := t.assignTestCase(ctx, tx, &testPlan, a); err != nil {
 			return nil, err
 		}
 	}
 
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
 	return &testPlan, nil
 }
 
+// assignTestCase adds a test case to a plan for a tester, data-driven test
+// cases are expanded into one test run per row of their dataset
+func (t *testPlanService) assignTestCase(ctx context.Context, db *dbsqlc.Queries, testPlan *dbsqlc.GetTestPlanRow, a testCaseAssignment) error {
+	if err := db.AddTestCaseToPlan(ctx, dbsqlc.AddTestCaseToPlanParams{
+		TestPlanID:   testPlan.ID,
+		TestCaseID:   a.TestCaseID,
+		AssignedToID: a.AssignedToID,
+	}); err != nil {
+		return err
+	}
+	created, err := createDataDrivenTestRuns(ctx, db, testPlan, a.TestCaseID, a.AssignedToID)
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		t.logger.Info("test-plan-service", "created test runs for dataset rows", "testPlanID", testPlan.ID, "testCaseID", a.TestCaseID, "runs", created)
+	}
+	return nil
+}
+
 // AddTestCaseToPlan implements TestPlanService.
 func (t *testPlanService) AddTestCaseToPlan(ctx context.Context, request *schema.AssignTestsToPlanRequest) (*dbsqlc.GetTestPlanRow, error) {
 	var assignments []testCaseAssignment
 	for _, pt := range request.PlannedTests {
-		tcID := uuid.MustParse(pt.TestCaseID)
+		tcID, err := parseTestCaseID(ctx, t.queries, pt.TestCaseID)
+		if err != nil {
+			return nil, err
+		}
 		for _, uid := range pt.UserIDs {
 			assignments = append(assignments, testCaseAssignment{TestCaseID: tcID, AssignedToID: uid})
 		}
@@ -215,7 +287,7 @@ func (t *testPlanService) AddTestCaseToPlan(ctx context.Context, request *schema
 func (t *testPlanService) BatchAssignTestCasesToPlan(ctx context.Context, request *schema.BatchAssignTestCasesToPlanRequest) (*dbsqlc.GetTestPlanRow, error) {
 	var assignments []testCaseAssignment
 	for _, tcIDStr := range request.TestCaseIDs {
-		tcID, err := uuid.Parse(tcIDStr)
+		tcID, err := parseTestCaseID(ctx, t.queries, tcIDStr)
 		if err != nil {
 			return nil, err
 		}
@@ -227,6 +299,9 @@ func (t *testPlanService) BatchAssignTestCasesToPlan(ctx context.Context, reques
 }
 
 func (t *testPlanService) DeleteByID(ctx context.Context, id int64) error {
+	if err := ensureTestPlanInOrg(ctx, t.queries, id); err != nil {
+		return err
+	}
 	_, err := t.queries.DeleteTestPlan(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete test plan %d:%w", id, err)
@@ -235,6 +310,9 @@ func (t *testPlanService) DeleteByID(ctx context.Context, id int64) error {
 }
 
 func (t *testPlanService) GetOneTestPlan(ctx context.Context, id int64) (*schema.TestPlanResponseItem, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, id); err != nil {
+		return nil, err
+	}
 	plan, err := t.queries.GetTestPlan(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -296,6 +374,9 @@ func (t *testPlanService) GetOneTestPlan(ctx context.Context, id int64) (*schema
 }
 
 func (t *testPlanService) Update(ctx context.Context, request schema.UpdateTestPlan) (bool, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, request.ProjectID); err != nil {
+		return false, err
+	}
 	err := t.queries.UpdateTestPlan(ctx, dbsqlc.UpdateTestPlanParams{
 		ProjectID:      int32(request.ProjectID),
 		Kind:           dbsqlc.TestKind(request.Kind),
@@ -314,6 +395,9 @@ func (t *testPlanService) Update(ctx context.Context, request schema.UpdateTestP
 
 // CloseTestPlan implements TestRunService
 func (t *testPlanService) CloseTestPlan(ctx context.Context, testPlanID int32) error {
+	if err := ensureTestPlanInOrg(ctx, t.queries, int64(testPlanID)); err != nil {
+		return err
+	}
 	testRuns, err := t.queries.ListTestRunsByPlan(ctx, sql.NullInt32{Int32: testPlanID, Valid: true})
 	if err != nil {
 		t.logger.Error("error listing test runs", "error", err)
@@ -342,6 +426,9 @@ func (t *testPlanService) CloseTestPlan(ctx context.Context, testPlanID int32) e
 }
 
 func (t *testPlanService) ChangeEnvironment(ctx context.Context, testPlanID, envID int64) error {
+	if err := ensureTestPlanInOrg(ctx, t.queries, testPlanID); err != nil {
+		return err
+	}
 	params := dbsqlc.ChangeEnvironmentParams{
 		ID:            testPlanID,
 		EnvironmentID: common.NewNullInt32(int32(envID)),
@@ -350,6 +437,9 @@ func (t *testPlanService) ChangeEnvironment(ctx context.Context, testPlanID, env
 }
 
 func (t *testPlanService) ListComments(ctx context.Context, testPlanID int64) ([]schema.CommentResponseItem, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, testPlanID); err != nil {
+		return nil, err
+	}
 	rows, err := t.queries.ListCommentsByTestPlan(ctx, testPlanID)
 	if err != nil {
 		return nil, err
@@ -370,6 +460,9 @@ func (t *testPlanService) ListComments(ctx context.Context, testPlanID int64) ([
 }
 
 func (t *testPlanService) CreateComment(ctx context.Context, req *schema.CreateComment) (*schema.CommentResponseItem, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, req.TestPlanID); err != nil {
+		return nil, err
+	}
 	id := uuid.New()
 	row, err := t.queries.CreateComment(ctx, dbsqlc.CreateCommentParams{
 		ID:         id,
@@ -395,6 +488,9 @@ func (t *testPlanService) DeleteComment(ctx context.Context, commentID string) e
 	if err != nil {
 		return err
 	}
+	if err := ensureTestPlanCommentInOrg(ctx, t.queries, id); err != nil {
+		return err
+	}
 	_, err = t.queries.DeleteComment(ctx, id)
 	return err
 }
@@ -404,6 +500,9 @@ func (t *testPlanService) ConvertCommentToTestCase(ctx context.Context, commentI
 	if err != nil {
 		return "", err
 	}
+	if err := ensureTestPlanCommentInOrg(ctx, t.queries, cid); err != nil {
+		return "", err
+	}
 	newID := uuid.New()
 	id, err := t.queries.ConvertCommentToTestCase(ctx, dbsqlc.ConvertCommentToTestCaseParams{
 		ID:        cid,
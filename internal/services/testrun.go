@@ -35,6 +35,9 @@ type TestRunService interface {
 	IsTestPlanActive(ctx context.Context, planID int64) (bool, error)
 	IsTestCaseActive(ctx context.Context, caseID string) (bool, error)
 	CloseTestRun(ctx context.Context, testRunID string) (*dbsqlc.TestRun, error)
+	// Resume returns the step results of a test run which is not closed and
+	// the step to continue from
+	Resume(ctx context.Context, testRunID string) (*schema.TestRunProgressResponse, error)
 	SubscribeToLogs(runID string) (<-chan schema.RunnerMessage, error)
 	PublishLog(runID string, msg schema.RunnerMessage) error
 }
@@ -58,6 +61,19 @@ func NewTestRunService(db *sql.DB, conn *dbsqlc.Queries, logger logging.Logger)
 
 // Create implements TestRunService
 func (t *testRunService) Create(ctx context.Context, request *schema.TestRunRequest) (*dbsqlc.TestRun, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, int64(request.ProjectID)); err != nil {
+		return nil, err
+	}
+	testCaseID, err := parseTestCaseID(ctx, t.queries, request.TestCaseID)
+	if err != nil {
+		return nil, err
+	}
+	if request.TestPlanID > 0 {
+		if err := ensureTestPlanInOrg(ctx, t.queries, int64(request.TestPlanID)); err != nil {
+			return nil, err
+		}
+	}
+
 	id := uuid.New()
 
 	// Determine result state: use provided state, or default to pending if no feedback provided
@@ -73,11 +89,11 @@ func (t *testRunService) Create(ctx context.Context, request *schema.TestRunRequ
 		planID = sql.NullInt32{Valid: false}
 	}
 
-	_, err := t.queries.CreateNewTestRun(ctx, dbsqlc.CreateNewTestRunParams{
+	_, err = t.queries.CreateNewTestRun(ctx, dbsqlc.CreateNewTestRunParams{
 		ID:            id,
 		ProjectID:     request.ProjectID,
 		TestPlanID:    planID,
-		TestCaseID:    uuid.MustParse(request.TestCaseID),
+		TestCaseID:    testCaseID,
 		OwnerID:       request.OwnerID,
 		TestedByID:    common.NewNullInt32(request.TestedByID),
 		AssignedToID:  common.NewNullInt32(request.AssignedToID),
@@ -141,23 +157,54 @@ func (t *testRunService) Create(ctx context.Context, request *schema.TestRunRequ
 
 // FindAll implements TestRunService.
 func (t *testRunService) FindAll(ctx context.Context) ([]dbsqlc.TestRun, error) {
-	return t.queries.ListTestRuns(ctx)
+	return t.queries.ListTestRuns(ctx, contextOrgID(ctx))
 }
 
 // FindAllByProjectID implements TestRunService.
 func (t *testRunService) FindAllByProjectID(ctx context.Context, projectID int64) ([]dbsqlc.TestRun, error) {
+	if err := ensureProjectInOrg(ctx, t.queries, projectID); err != nil {
+		return nil, err
+	}
 	return t.queries.ListTestRunsByProject(ctx, int32(projectID))
 }
 
 // Commit implements TestRunService.
 func (t *testRunService) Commit(ctx context.Context, request *schema.CommitTestRunResult) (*dbsqlc.TestRun, error) {
-	testRun, err := t.queries.GetTestRun(ctx, uuid.MustParse(request.TestRunID))
+	id, err := parseTestRunID(ctx, t.queries, request.TestRunID)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = t.queries.CommitTestRunResult(ctx, dbsqlc.CommitTestRunResultParams{
-		ID:             uuid.MustParse(request.TestRunID),
+	sqlTx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+
+	tx := dbsqlc.New(sqlTx)
+
+	testRun, err := tx.GetTestRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// results recorded by testers have to fill in the required fields, runner
+	// results leave the custom fields alone
+	if request.CustomFields != nil {
+		if err := saveCustomFieldValues(ctx, tx, testRun.ProjectID, CustomFieldEntityTestRun, id, request.CustomFields, false); err != nil {
+			return nil, err
+		}
+	}
+	if len(request.Steps) > 0 {
+		results, err := recordTestRunSteps(ctx, tx, &testRun, request.UserID, request.TestedOn, request.Steps)
+		if err != nil {
+			return nil, err
+		}
+		request.State = deriveTestRunState(results)
+		request.ActualResult = cmp.Or(request.ActualResult, summarizeStepResults(results))
+	}
+
+	_, err = tx.CommitTestRunResult(ctx, dbsqlc.CommitTestRunResultParams{
+		ID:             id,
 		TestedByID:     common.NewNullInt32(int32(request.UserID)),
 		Notes:          request.Notes,
 		UpdatedAt:      common.NullTime(time.Now()),
@@ -174,9 +221,9 @@ func (t *testRunService) Commit(ctx context.Context, request *schema.CommitTestR
 	}
 
 	// Update the test_runs_results for historical logging
-	_, err = t.queries.InsertTestRunResult(ctx, dbsqlc.InsertTestRunResultParams{
+	_, err = tx.InsertTestRunResult(ctx, dbsqlc.InsertTestRunResultParams{
 		ID:         uuid.New(),
-		TestRunID:  uuid.MustParse(request.TestRunID),
+		TestRunID:  id,
 		Status:     request.State,
 		Result:     request.ActualResult,
 		Notes:      common.NullString(request.Notes),
@@ -188,11 +235,17 @@ func (t *testRunService) Commit(ctx context.Context, request *schema.CommitTestR
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert to test_run_results: %w", err)
 	}
+	if err := recordTestRunRevision(ctx, tx, &testRun); err != nil {
+		return nil, err
+	}
 
-	testRun, err = t.queries.GetTestRun(ctx, uuid.MustParse(request.TestRunID))
+	testRun, err = tx.GetTestRun(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
 	return &testRun, nil
 }
 
@@ -210,7 +263,11 @@ func (t *testRunService) CommitBulk(ctx context.Context, bulkRequest *schema.Bul
 
 // GetOneTestRun implements TestRunService
 func (t *testRunService) GetOneTestRun(ctx context.Context, testRunID string) (*dbsqlc.TestRun, error) {
-	testRun, err := t.queries.GetTestRun(ctx, uuid.MustParse(testRunID))
+	id, err := parseTestRunID(ctx, t.queries, testRunID)
+	if err != nil {
+		return nil, err
+	}
+	testRun, err := t.queries.GetTestRun(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test run %s: %w", testRunID, err)
 	}
@@ -218,10 +275,37 @@ func (t *testRunService) GetOneTestRun(ctx context.Context, testRunID string) (*
 	return &testRun, nil
 }
 
+// Resume implements TestRunService.
+func (t *testRunService) Resume(ctx context.Context, testRunID string) (*schema.TestRunProgressResponse, error) {
+	id, err := parseTestRunID(ctx, t.queries, testRunID)
+	if err != nil {
+		return nil, err
+	}
+	testRun, err := t.queries.GetTestRun(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test run %s: %w", testRunID, err)
+	}
+	if testRun.IsClosed.Bool {
+		return nil, ErrTestRunClosed
+	}
+
+	results, err := recordTestRunSteps(ctx, t.queries, &testRun, 0, time.Now(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewTestRunProgressResponse(&testRun, results), nil
+}
+
 // DeleteByID implements TestRunService.
 func (t *testRunService) DeleteByID(ctx context.Context, testRunID string) error {
-	_, err := t.queries.DeleteTestRun(ctx, uuid.MustParse(testRunID))
-	return err
+	id, err := parseTestRunID(ctx, t.queries, testRunID)
+	if err != nil {
+		return err
+	}
+	if _, err = t.queries.DeleteTestRun(ctx, id); err != nil {
+		return err
+	}
+	return t.queries.DeleteCustomFieldValuesByEntity(ctx, id)
 }
 
 // CreateFromFoundIssues implements TestRunService.
@@ -234,6 +318,9 @@ func (t *testRunService) Execute(ctx context.Context, request *schema.ExecuteTes
 	if err != nil {
 		return nil, fmt.Errorf("invalid test run ID: %w", err)
 	}
+	if err := ensureTestRunInOrg(ctx, t.queries, runUUID); err != nil {
+		return nil, err
+	}
 
 	sqlTx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -243,6 +330,16 @@ func (t *testRunService) Execute(ctx context.Context, request *schema.ExecuteTes
 
 	tx := dbsqlc.New(sqlTx)
 
+	testRun, err := tx.GetTestRun(ctx, runUUID)
+	if err != nil {
+		return nil, err
+	}
+	if request.CustomFields != nil {
+		if err := saveCustomFieldValues(ctx, tx, testRun.ProjectID, CustomFieldEntityTestRun, runUUID, request.CustomFields, false); err != nil {
+			return nil, err
+		}
+	}
+
 	err = tx.ExecuteTestRun(ctx, dbsqlc.ExecuteTestRunParams{
 		ID:             runUUID,
 		ResultState:    dbsqlc.TestRunState(request.Status),
@@ -271,6 +368,9 @@ func (t *testRunService) Execute(ctx context.Context, request *schema.ExecuteTes
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert test run result: %w", err)
 	}
+	if err := recordTestRunRevision(ctx, tx, &testRun); err != nil {
+		return nil, err
+	}
 
 	tr, err := tx.GetTestRun(ctx, runUUID)
 	if err != nil {
@@ -285,6 +385,12 @@ func (t *testRunService) Execute(ctx context.Context, request *schema.ExecuteTes
 }
 
 func (t *testRunService) IsTestPlanActive(ctx context.Context, planID int64) (bool, error) {
+	if err := ensureTestPlanInOrg(ctx, t.queries, planID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
 	plan, err := t.queries.IsTestPlanActive(ctx, planID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
@@ -296,7 +402,14 @@ func (t *testRunService) IsTestPlanActive(ctx context.Context, planID int64) (bo
 }
 
 func (t *testRunService) IsTestCaseActive(ctx context.Context, caseID string) (bool, error) {
-	isDraft, err := t.queries.IsTestCaseActive(ctx, uuid.MustParse(caseID))
+	id, err := parseTestCaseID(ctx, t.queries, caseID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	isDraft, err := t.queries.IsTestCaseActive(ctx, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
@@ -310,7 +423,10 @@ func (t *testRunService) IsTestCaseActive(ctx context.Context, caseID string) (b
 }
 
 func (t *testRunService) CloseTestRun(ctx context.Context, testRunID string) (*dbsqlc.TestRun, error) {
-	id := uuid.MustParse(testRunID)
+	id, err := parseTestRunID(ctx, t.queries, testRunID)
+	if err != nil {
+		return nil, err
+	}
 	tr, err := t.queries.GetTestRun(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch test run: %w", err)
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/pkg/totp"
+)
+
+var ErrInvalidTwoFactorCode = errors.New("two-factor code is invalid or was already used")
+var ErrInvalidTwoFactorChallenge = errors.New("two-factor login challenge is invalid or has expired, log in again")
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrTwoFactorRequired = errors.New("two-factor authentication is required by your organization")
+
+const (
+	twoFactorChallengePurpose = "mfa-challenge"
+	// twoFactorChallengeTTL is how long the user has to enter a code after the password step
+	twoFactorChallengeTTL = 5 * time.Minute
+	totpIssuer            = "QATARINA"
+	recoveryCodeCount     = 10
+)
+
+// Audit log actions for two-factor authentication
+const (
+	AuditTwoFactorEnabled  = "auth.2fa_enabled"
+	AuditTwoFactorDisabled = "auth.2fa_disabled"
+	AuditTwoFactorReset    = "auth.2fa_reset"
+)
+
+// twoFactorKey derives the key TOTP secrets are encrypted with, the secrets
+// have to be readable to check codes so they cannot be hashed
+func (a *authServiceImpl) twoFactorKey() []byte {
+	return secretKey(a.authConfig.JwtSecretKey, "totp-secret")
+}
+
+func (a *authServiceImpl) encryptTOTPSecret(secret string) (string, error) {
+	return encryptSecret(a.twoFactorKey(), secret)
+}
+
+func (a *authServiceImpl) decryptTOTPSecret(encrypted string) (string, error) {
+	return decryptSecret(a.twoFactorKey(), encrypted)
+}
+
+// generateRecoveryCodes creates the codes shown to the user once along with the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func (a *authServiceImpl) replaceRecoveryCodes(ctx context.Context, userID int32) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := a.queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove recovery codes: %w", err)
+	}
+	err = a.queries.CreateRecoveryCodes(ctx, dbsqlc.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: hashes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// twoFactorChallenge returns the response for the password step when the
+// user has to provide a second factor, or nil when the login can finish
+func (a *authServiceImpl) twoFactorChallenge(ctx context.Context, userID int32) (*schema.LoginResponse, error) {
+	credential, err := a.queries.GetTotpCredential(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+	enabled := err == nil && credential.ConfirmedAt.Valid
+
+	setupRequired := false
+	if !enabled {
+		setupRequired, err = a.queries.UserRequiresTwoFactor(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check two-factor policy: %w", err)
+		}
+		if !setupRequired {
+			return nil, nil
+		}
+	}
+
+	challengeToken, err := a.generateTwoFactorChallenge(userID, setupRequired)
+	if err != nil {
+		return nil, err
+	}
+	return &schema.LoginResponse{
+		UserID:                 int64(userID),
+		TwoFactorRequired:      enabled,
+		TwoFactorSetupRequired: setupRequired,
+		ChallengeToken:         challengeToken,
+	}, nil
+}
+
+func (a *authServiceImpl) twoFactorChallengeKey() []byte {
+	return []byte(a.authConfig.JwtSecretKey + ":" + twoFactorChallengePurpose)
+}
+
+func (a *authServiceImpl) generateTwoFactorChallenge(userID int32, setup bool) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": twoFactorChallengePurpose,
+		"sub":     fmt.Sprint(userID),
+		"setup":   setup,
+		"exp":     time.Now().Add(twoFactorChallengeTTL).Unix(),
+	})
+	challenge, err := token.SignedString(a.twoFactorChallengeKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign two-factor challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// parseTwoFactorChallenge returns the user the challenge was issued for and
+// whether the user has to enrol before logging in
+func (a *authServiceImpl) parseTwoFactorChallenge(challenge string) (int32, bool, error) {
+	token, err := jwt.Parse(challenge, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidTwoFactorChallenge
+		}
+		return a.twoFactorChallengeKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, false, ErrInvalidTwoFactorChallenge
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != twoFactorChallengePurpose {
+		return 0, false, ErrInvalidTwoFactorChallenge
+	}
+	subject, _ := claims["sub"].(string)
+	setup, _ := claims["setup"].(bool)
+	var userID int32
+	if _, err := fmt.Sscan(subject, &userID); err != nil {
+		return 0, false, ErrInvalidTwoFactorChallenge
+	}
+	return userID, setup, nil
+}
+
+// checkTwoFactorCode accepts a TOTP code, or a recovery code when allowed,
+// both can only be used once
+func (a *authServiceImpl) checkTwoFactorCode(ctx context.Context, credential dbsqlc.TotpCredential, code string, allowRecovery bool) error {
+	secret, err := a.decryptTOTPSecret(credential.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		if !credential.ConfirmedAt.Valid {
+			// pending enrolments record the step when they are confirmed
+			return nil
+		}
+		affected, err := a.queries.UseTotpStep(ctx, dbsqlc.UseTotpStepParams{
+			UserID:       credential.UserID,
+			LastUsedStep: step,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record two-factor code: %w", err)
+		}
+		if affected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	if !allowRecovery || !credential.ConfirmedAt.Valid {
+		return ErrInvalidTwoFactorCode
+	}
+	affected, err := a.queries.UseRecoveryCode(ctx, dbsqlc.UseRecoveryCodeParams{
+		UserID:   credential.UserID,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if affected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	a.logger.Info("auth-service", "recovery code used to log in", "user_id", credential.UserID)
+	return nil
+}
+
+func (a *authServiceImpl) VerifyTwoFactorLogin(ctx context.Context, request *schema.TwoFactorLoginRequest) (*schema.LoginResponse, error) {
+	userID, setup, err := a.parseTwoFactorChallenge(request.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.queries.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidTwoFactorChallenge
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if !user.IsActivated.Bool || user.DeletedAt.Valid {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	// wrong codes count towards the same lockout as wrong passwords so codes cannot be guessed
+	if err := a.checkLoginLockout(ctx, user.Email, request.IPAddress); err != nil {
+		return nil, err
+	}
+
+	credential, err := a.queries.GetTotpCredential(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+	if !credential.ConfirmedAt.Valid && !setup {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+
+	if err := a.checkTwoFactorCode(ctx, credential, request.Code, true); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			a.recordLoginFailure(ctx, user.Email, request.IPAddress)
+		}
+		return nil, err
+	}
+	a.clearLoginFailures(ctx, user.Email)
+
+	res := &schema.LoginResponse{
+		UserID:      int64(user.ID),
+		DisplayName: user.DisplayName.String,
+		Email:       user.Email,
+	}
+
+	// a login that required enrolment finishes by confirming the new authenticator
+	if !credential.ConfirmedAt.Valid {
+		recoveryCodes, err := a.confirmEnrolment(ctx, credential, request.Code, request.IPAddress)
+		if err != nil {
+			return nil, err
+		}
+		res.RecoveryCodes = recoveryCodes
+	}
+
+	return a.completeLogin(ctx, res, request.UserAgent, request.IPAddress)
+}
+
+func (a *authServiceImpl) BeginChallengeEnrolment(ctx context.Context, challengeToken string) (*schema.TwoFactorEnrolment, error) {
+	userID, setup, err := a.parseTwoFactorChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !setup {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return a.BeginTwoFactorEnrolment(ctx, int64(userID))
+}
+
+func (a *authServiceImpl) TwoFactorStatus(ctx context.Context, userID int64) (*schema.TwoFactorStatus, error) {
+	status := &schema.TwoFactorStatus{}
+
+	credential, err := a.queries.GetTotpCredential(ctx, int32(userID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+	if err == nil {
+		status.Enabled = credential.ConfirmedAt.Valid
+		status.PendingConfirmation = !credential.ConfirmedAt.Valid
+	}
+
+	status.Required, err = a.queries.UserRequiresTwoFactor(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor policy: %w", err)
+	}
+
+	if status.Enabled {
+		status.RecoveryCodesRemaining, err = a.queries.CountUnusedRecoveryCodes(ctx, int32(userID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+	}
+	return status, nil
+}
+
+func (a *authServiceImpl) BeginTwoFactorEnrolment(ctx context.Context, userID int64) (*schema.TwoFactorEnrolment, error) {
+	user, err := a.queries.GetUser(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+	encrypted, err := a.encryptTOTPSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+
+	// replaces a pending enrolment but never a confirmed one
+	affected, err := a.queries.CreatePendingTotpCredential(ctx, dbsqlc.CreatePendingTotpCredentialParams{
+		UserID: user.ID,
+		Secret: encrypted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+	if affected == 0 {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &schema.TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, totpIssuer, user.Email),
+	}, nil
+}
+
+func (a *authServiceImpl) ConfirmTwoFactorEnrolment(ctx context.Context, userID int64, code string) (*schema.RecoveryCodesResponse, error) {
+	credential, err := a.queries.GetTotpCredential(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+	if credential.ConfirmedAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := a.checkTwoFactorCode(ctx, credential, code, false); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := a.confirmEnrolment(ctx, credential, code, "")
+	if err != nil {
+		return nil, err
+	}
+	return &schema.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// confirmEnrolment enables the pending credential and issues fresh recovery codes,
+// the code must already have been checked
+func (a *authServiceImpl) confirmEnrolment(ctx context.Context, credential dbsqlc.TotpCredential, code, ipAddress string) ([]string, error) {
+	secret, err := a.decryptTOTPSecret(credential.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, err := a.replaceRecoveryCodes(ctx, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := a.queries.ConfirmTotpCredential(ctx, dbsqlc.ConfirmTotpCredentialParams{
+		UserID:       credential.UserID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm two-factor credential: %w", err)
+	}
+	if affected == 0 {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	recordAuditEvent(ctx, a.queries, a.logger, AuditEvent{
+		ActorID:     int64(credential.UserID),
+		Action:      AuditTwoFactorEnabled,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(credential.UserID),
+		IPAddress:   ipAddress,
+	})
+	return recoveryCodes, nil
+}
+
+// enabledCredential returns the confirmed credential of the user
+func (a *authServiceImpl) enabledCredential(ctx context.Context, userID int64) (dbsqlc.TotpCredential, error) {
+	credential, err := a.queries.GetTotpCredential(ctx, int32(userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return credential, ErrTwoFactorNotEnabled
+		}
+		return credential, fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+	if !credential.ConfirmedAt.Valid {
+		return credential, ErrTwoFactorNotEnabled
+	}
+	return credential, nil
+}
+
+func (a *authServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*schema.RecoveryCodesResponse, error) {
+	credential, err := a.enabledCredential(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkTwoFactorCode(ctx, credential, code, false); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := a.replaceRecoveryCodes(ctx, credential.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &schema.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (a *authServiceImpl) DisableTwoFactor(ctx context.Context, userID int64, code string) error {
+	credential, err := a.enabledCredential(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	required, err := a.queries.UserRequiresTwoFactor(ctx, int32(userID))
+	if err != nil {
+		return fmt.Errorf("failed to check two-factor policy: %w", err)
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := a.checkTwoFactorCode(ctx, credential, code, true); err != nil {
+		return err
+	}
+	if err := a.removeTwoFactor(ctx, int32(userID)); err != nil {
+		return err
+	}
+
+	recordAuditEvent(ctx, a.queries, a.logger, AuditEvent{
+		ActorID:     userID,
+		Action:      AuditTwoFactorDisabled,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(userID),
+	})
+	return nil
+}
+
+func (a *authServiceImpl) ResetTwoFactor(ctx context.Context, actorID, userID int64) error {
+	if _, err := a.queries.GetTotpCredential(ctx, int32(userID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return fmt.Errorf("failed to fetch two-factor credential: %w", err)
+	}
+
+	if err := a.removeTwoFactor(ctx, int32(userID)); err != nil {
+		return err
+	}
+
+	// the reset is usually for a lost device, whoever holds it should not keep a session
+	if _, err := a.queries.RevokeAllUserRefreshTokens(ctx, int32(userID)); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	recordAuditEvent(ctx, a.queries, a.logger, AuditEvent{
+		ActorID:     actorID,
+		Action:      AuditTwoFactorReset,
+		SubjectType: "user",
+		SubjectID:   fmt.Sprint(userID),
+	})
+	return nil
+}
+
+func (a *authServiceImpl) removeTwoFactor(ctx context.Context, userID int32) error {
+	if _, err := a.queries.DeleteTotpCredential(ctx, userID); err != nil {
+		return fmt.Errorf("failed to remove two-factor credential: %w", err)
+	}
+	if err := a.queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("failed to remove recovery codes: %w", err)
+	}
+	return nil
+}
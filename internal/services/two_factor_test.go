@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPSecretEncryption(t *testing.T) {
+	a := &authServiceImpl{authConfig: &config.AuthConfiguration{JwtSecretKey: "secret"}}
+
+	encrypted, err := a.encryptTOTPSecret("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP")
+
+	secret, err := a.decryptTOTPSecret(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	other := &authServiceImpl{authConfig: &config.AuthConfiguration{JwtSecretKey: "other"}}
+	_, err = other.decryptTOTPSecret(encrypted)
+	assert.Error(t, err)
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	a := &authServiceImpl{authConfig: &config.AuthConfiguration{JwtSecretKey: "secret"}}
+
+	challenge, err := a.generateTwoFactorChallenge(7, true)
+	require.NoError(t, err)
+
+	userID, setup, err := a.parseTwoFactorChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), userID)
+	assert.True(t, setup)
+
+	// a challenge is not a verification link and the other way round
+	verification, err := a.generateEmailVerificationToken(7, "user@example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, _, err = a.parseTwoFactorChallenge(verification)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorChallenge)
+	_, _, err = a.parseEmailVerificationToken(challenge)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	for i, code := range codes {
+		assert.Len(t, code, 11)
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(code)))
+		// codes are accepted however the user types them
+		assert.Equal(t, hashes[i], hashToken(normalizeRecoveryCode(" "+code[:5]+code[6:]+" ")))
+	}
+}
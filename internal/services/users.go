@@ -1,13 +1,10 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"html/template"
-	"net/smtp"
 	"time"
 
 	"github.com/golang-malawi/qatarina/internal/common"
@@ -15,7 +12,6 @@ import (
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/schema"
-	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
@@ -24,23 +20,30 @@ var (
 )
 
 type UserService interface {
-	// FindAll finds all users in the system
+	// FindAll finds all members of the current org
 	FindAll(context.Context) (*schema.CompactUserListResponse, error)
 	// Create creates a new user in the system with the given information
 	// provided that the user's email is not already in use and that the information is valid
 	Create(context.Context, *schema.NewUserRequest) (*dbsqlc.User, error)
-	// GetOne retrives one user from system
+	// GetOne retrives one member of the current org, users of other orgs are not found
 	GetOne(ctx context.Context, id int32) (schema.User, error)
-	// SearchUser searches the user in the system based on typed keywords
+	// SearchUser searches the members of the current org based on typed keywords
 	Search(ctx context.Context, keyword string) ([]dbsqlc.User, error)
-	//Update updates the user
-	Update(context.Context, schema.UpdateUserRequest) (bool, error)
-	//Delete used to delete user from the system
-	Delete(ctx context.Context, id int32) error
-	// Invite used to invite a user
-	// SignIn allows user to access the system with given credentials
-	// Invite used to invite user by email
-	Invite(context.Context, string, string) error
+	// Update updates the user, users can update themselves while updating others needs a super admin.
+	// The org of the user must be one they belong to
+	Update(ctx context.Context, actorID int64, request schema.UpdateUserRequest) (bool, error)
+	// Deactivate blocks the user from logging in and ends their sessions, users can
+	// deactivate themselves while deactivating others needs a super admin. Accounts
+	// are kept so the work they authored stays attributed to them
+	Deactivate(ctx context.Context, actorID, userID int64) error
+	// Reactivate lets a deactivated user log in again
+	Reactivate(ctx context.Context, actorID, userID int64) error
+	// PreviewOffboarding lists the open test plans, test plan cases and test runs
+	// that Offboard would hand over to another user
+	PreviewOffboarding(ctx context.Context, userID, reassignToID int64) (*schema.OffboardingPreview, error)
+	// Offboard hands the open work of the user over to another user, adding them
+	// as a tester where needed, and optionally deactivates the user
+	Offboard(ctx context.Context, actorID, userID int64, request *schema.OffboardUserRequest) (*schema.OffboardingResult, error)
 }
 
 type OrganizationUserService interface {
@@ -49,13 +52,15 @@ type OrganizationUserService interface {
 }
 
 type userServiceImpl struct {
+	db      *sql.DB
 	queries *dbsqlc.Queries
 	logger  logging.Logger
 	smtpCfg config.SMTPConfiguration
 }
 
-func NewUserService(conn *dbsqlc.Queries, logger logging.Logger, smtpCfg config.SMTPConfiguration) UserService {
+func NewUserService(db *sql.DB, conn *dbsqlc.Queries, logger logging.Logger, smtpCfg config.SMTPConfiguration) UserService {
 	return &userServiceImpl{
+		db:      db,
 		queries: conn,
 		logger:  logger,
 		smtpCfg: smtpCfg,
@@ -64,7 +69,7 @@ func NewUserService(conn *dbsqlc.Queries, logger logging.Logger, smtpCfg config.
 
 func (s *userServiceImpl) FindAll(ctx context.Context) (*schema.CompactUserListResponse, error) {
 	response := &schema.CompactUserListResponse{}
-	users, err := s.queries.ListUsers(ctx)
+	users, err := s.queries.ListUsersByOrg(ctx, contextOrgID(ctx))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return response, nil
@@ -122,6 +127,13 @@ func (s *userServiceImpl) Create(ctx context.Context, request *schema.NewUserReq
 }
 
 func (u *userServiceImpl) GetOne(ctx context.Context, id int32) (schema.User, error) {
+	_, err := u.queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: contextOrgID(ctx), UserID: id})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return schema.User{}, ErrNotFound
+		}
+		return schema.User{}, fmt.Errorf("failed to fetch org membership: %w", err)
+	}
 	user, err := u.queries.GetUser(ctx, id)
 	if err != nil {
 		u.logger.Error("failed to find the user", "error", err)
@@ -148,7 +160,10 @@ func (u *userServiceImpl) GetOne(ctx context.Context, id int32) (schema.User, er
 }
 
 func (u *userServiceImpl) Search(ctx context.Context, keyword string) ([]dbsqlc.User, error) {
-	users, err := u.queries.SearchUsers(ctx, common.NullString(keyword))
+	users, err := u.queries.SearchUsersByOrg(ctx, dbsqlc.SearchUsersByOrgParams{
+		OrgID:   contextOrgID(ctx),
+		Column2: common.NullString(keyword),
+	})
 	if err != nil {
 		u.logger.Error("failed to search users with keyword %q: %w", keyword, err)
 		return nil, err
@@ -159,8 +174,24 @@ func (u *userServiceImpl) Search(ctx context.Context, keyword string) ([]dbsqlc.
 	return users, nil
 }
 
-func (u *userServiceImpl) Update(ctx context.Context, request schema.UpdateUserRequest) (bool, error) {
-	err := u.queries.UpdateUser(ctx, dbsqlc.UpdateUserParams{
+func (u *userServiceImpl) Update(ctx context.Context, actorID int64, request schema.UpdateUserRequest) (bool, error) {
+	if actorID != int64(request.ID) {
+		isSuperAdmin, err := u.queries.IsUserSuperAdmin(ctx, int32(actorID))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if !isSuperAdmin {
+			return false, ErrForbidden
+		}
+	}
+	_, err := u.queries.GetOrgMemberRole(ctx, dbsqlc.GetOrgMemberRoleParams{OrgID: request.OrgID, UserID: request.ID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNotOrgMember
+		}
+		return false, fmt.Errorf("failed to fetch org membership: %w", err)
+	}
+	err = u.queries.UpdateUser(ctx, dbsqlc.UpdateUserParams{
 		ID:          request.ID,
 		FirstName:   request.FirstName,
 		LastName:    request.LastName,
@@ -178,72 +209,3 @@ func (u *userServiceImpl) Update(ctx context.Context, request schema.UpdateUserR
 	}
 	return true, nil
 }
-
-func (u *userServiceImpl) Delete(ctx context.Context, id int32) error {
-	_, err := u.queries.DeleteUser(ctx, id)
-	if err != nil {
-		u.logger.Error("failed to delete user", "error", err)
-		return err
-	}
-	return nil
-}
-
-func (u *userServiceImpl) Invite(ctx context.Context, senderEmail, receiverEmail string) error {
-	token := uuid.New().String()
-	expiresAt := time.Now().Add(24 * time.Hour)
-
-	err := u.queries.CreateInvite(ctx, dbsqlc.CreateInviteParams{
-		SenderEmail:   senderEmail,
-		ReceiverEmail: receiverEmail,
-		Token:         token,
-		ExpiresAt:     common.NewNullTime(expiresAt),
-	})
-	if err != nil {
-		u.logger.Error("failed to create invite", "error", err)
-		return fmt.Errorf("failed to create invite: %v", err)
-	}
-
-	subject := "Qatarina invitation"
-
-	// Load the invite email template from html file
-	t, err := template.ParseFiles("internal/templates/invite_email.html")
-	if err != nil {
-		u.logger.Error("failed to load email template from file", "error", err)
-		return fmt.Errorf("failed to load email template: %v", err)
-	}
-
-	// Data to inject into template
-	data := struct {
-		BaseURL   string
-		Token     string
-		ExpiresAt string
-	}{
-		BaseURL:   "https://qatarina.dev", // TODO: get this from configuration
-		Token:     token,
-		ExpiresAt: expiresAt.Format("Jan 2, 2006 15:04 MST"),
-	}
-
-	var body bytes.Buffer
-	if err := t.Execute(&body, data); err != nil {
-		u.logger.Error("failed to excecute email template", "error", err)
-		return fmt.Errorf("failed to excecute email template: %v", err)
-	}
-
-	msg := []byte(fmt.Sprintf(
-		"To: %s\r\n"+
-			"MIME-Version: 1.0\r\n"+
-			"Content-Type: text/html; charset=\"UTF-8\"\r\n"+
-			"Subject: %s\r\n\r\n"+
-			"%s", receiverEmail, subject, body.String()))
-
-	addr := fmt.Sprintf("%s:%d", u.smtpCfg.Host, u.smtpCfg.Port)
-
-	auth := smtp.PlainAuth("", u.smtpCfg.Username, u.smtpCfg.Password, u.smtpCfg.Host)
-
-	err = smtp.SendMail(addr, auth, u.smtpCfg.From, []string{receiverEmail}, msg)
-	if err != nil {
-		u.logger.Error("SMTP send failed", "error", err)
-		return fmt.Errorf("failed to send the invite to email: %w", err)
-	}
-	return nil
-}
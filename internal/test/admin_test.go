@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+// withoutSuperAdmins starts a transaction in which no user is a super admin,
+// it is rolled back when the test ends
+func withoutSuperAdmins(t *testing.T) *dbsqlc.Queries {
+	t.Helper()
+	tx, err := openTestDB().BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to start transaction: %v", err)
+	}
+	t.Cleanup(func() { _ = tx.Rollback() })
+	if _, err := tx.ExecContext(context.Background(), "UPDATE users SET is_super_admin = false WHERE is_super_admin"); err != nil {
+		t.Fatalf("failed to demote super admins: %v", err)
+	}
+	return dbsqlc.New(tx)
+}
+
+func createAdminTestUser(t *testing.T, conn *dbsqlc.Queries, superAdmin, active bool) dbsqlc.User {
+	t.Helper()
+	ctx := context.Background()
+	userID, err := conn.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:    "Admin",
+		LastName:     "Tester",
+		DisplayName:  common.NullString("Admin"),
+		Email:        fmt.Sprintf("admin-%s@example.com", uuid.NewString()),
+		Password:     common.MustHashPassword(uuid.NewString()),
+		IsActivated:  sql.NullBool{Bool: active, Valid: true},
+		IsReviewed:   common.TrueNullBool(),
+		IsSuperAdmin: sql.NullBool{Bool: superAdmin, Valid: true},
+		IsVerified:   common.TrueNullBool(),
+		CreatedAt:    common.NewNullTime(time.Now()),
+		UpdatedAt:    common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	user, err := conn.GetUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("failed to fetch user: %v", err)
+	}
+	return user
+}
+
+func TestSetSuperAdminKeepsTheLastSuperAdmin(t *testing.T) {
+	conn := withoutSuperAdmins(t)
+	svc := services.NewAdminService(conn, logging.NewForTest())
+	ctx := context.Background()
+
+	admin := createAdminTestUser(t, conn, true, true)
+	if err := svc.SetSuperAdmin(ctx, admin.Email, false); !errors.Is(err, services.ErrLastSuperAdmin) {
+		t.Fatalf("expected ErrLastSuperAdmin, got %v", err)
+	}
+
+	// a deactivated super admin does not count
+	inactive := createAdminTestUser(t, conn, true, false)
+	if err := svc.SetSuperAdmin(ctx, admin.Email, false); !errors.Is(err, services.ErrLastSuperAdmin) {
+		t.Fatalf("expected ErrLastSuperAdmin with only a deactivated super admin besides, got %v", err)
+	}
+	if err := svc.SetSuperAdmin(ctx, inactive.Email, false); err != nil {
+		t.Fatalf("failed to demote deactivated super admin: %v", err)
+	}
+
+	other := createAdminTestUser(t, conn, false, true)
+	if err := svc.SetSuperAdmin(ctx, fmt.Sprint(other.ID), true); err != nil {
+		t.Fatalf("failed to promote user: %v", err)
+	}
+	if err := svc.SetSuperAdmin(ctx, admin.Email, false); err != nil {
+		t.Fatalf("failed to demote super admin with another one left: %v", err)
+	}
+	if err := svc.SetSuperAdmin(ctx, other.Email, false); !errors.Is(err, services.ErrLastSuperAdmin) {
+		t.Errorf("expected ErrLastSuperAdmin for the remaining super admin, got %v", err)
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	adminConfig := func(email string) *config.AdminConfiguration {
+		return &config.AdminConfiguration{Enabled: true, Email: email, Password: uuid.NewString(), Username: "Bootstrap"}
+	}
+	bootstrap := func(t *testing.T, conn *dbsqlc.Queries, adminConfig *config.AdminConfiguration) (bool, error) {
+		t.Helper()
+		return services.NewAdminService(conn, logging.NewForTest()).BootstrapAdmin(ctx, adminConfig)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		conn := withoutSuperAdmins(t)
+		disabled := adminConfig(fmt.Sprintf("bootstrap-%s@example.com", uuid.NewString()))
+		disabled.Enabled = false
+		created, err := bootstrap(t, conn, disabled)
+		if err != nil || created {
+			t.Errorf("expected no change, got %t %v", created, err)
+		}
+	})
+
+	t.Run("creates the admin", func(t *testing.T) {
+		conn := withoutSuperAdmins(t)
+		email := fmt.Sprintf("bootstrap-%s@example.com", uuid.NewString())
+		created, err := bootstrap(t, conn, adminConfig(email))
+		if err != nil || !created {
+			t.Fatalf("expected the admin to be created, got %t %v", created, err)
+		}
+		user, err := conn.GetUserByEmail(ctx, email)
+		if err != nil {
+			t.Fatalf("failed to fetch admin: %v", err)
+		}
+		if !user.IsSuperAdmin.Bool || !user.IsActivated.Bool {
+			t.Errorf("expected an active super admin, got %+v", user)
+		}
+
+		// nothing changes once a super admin exists
+		created, err = bootstrap(t, conn, adminConfig(fmt.Sprintf("bootstrap-%s@example.com", uuid.NewString())))
+		if err != nil || created {
+			t.Errorf("expected no change with a super admin, got %t %v", created, err)
+		}
+	})
+
+	t.Run("refuses an existing user", func(t *testing.T) {
+		conn := withoutSuperAdmins(t)
+		user := createAdminTestUser(t, conn, false, true)
+		created, err := bootstrap(t, conn, adminConfig(user.Email))
+		if !errors.Is(err, services.ErrAdminEmailTaken) || created {
+			t.Fatalf("expected ErrAdminEmailTaken, got %t %v", created, err)
+		}
+		user, err = conn.GetUser(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("failed to fetch user: %v", err)
+		}
+		if user.IsSuperAdmin.Bool {
+			t.Errorf("expected the existing user not to be promoted")
+		}
+	})
+
+	t.Run("refuses a deactivated user", func(t *testing.T) {
+		conn := withoutSuperAdmins(t)
+		user := createAdminTestUser(t, conn, false, false)
+		created, err := bootstrap(t, conn, adminConfig(user.Email))
+		if !errors.Is(err, services.ErrAdminEmailTaken) || created {
+			t.Errorf("expected ErrAdminEmailTaken, got %t %v", created, err)
+		}
+	})
+}
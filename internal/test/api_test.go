@@ -0,0 +1,118 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang-malawi/qatarina/internal/api"
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/google/uuid"
+)
+
+// newTestAPI builds the API from the test configuration with its routes
+// registered, requests are sent to the app without listening
+func newTestAPI() (*api.API, *fiber.App) {
+	a := api.NewAPI(loadTestConfig())
+	return a, a.App()
+}
+
+// accessToken signs an access token for a user working in an org
+func accessToken(t *testing.T, a *api.API, userID, orgID int32) string {
+	t.Helper()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"UserID": userID,
+		"OrgID":  orgID,
+		"sub":    fmt.Sprint(userID),
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Hour).Unix(),
+	}
+	if a.Config.Auth.JwtIssuer != "" {
+		claims["iss"] = a.Config.Auth.JwtIssuer
+	}
+	token, err := a.SigningKeyService.SignAccessToken(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("failed to sign access token: %v", err)
+	}
+	return token
+}
+
+// sendRequest sends a request with an optional JSON body as the holder of
+// the access token and returns the status code and body of the response
+func sendRequest(t *testing.T, app *fiber.App, method, path, token string, body any) (int, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+	return res.StatusCode, data
+}
+
+// createOrgUser creates an org with a user whose only org it is, the org and
+// user are removed when the test ends
+func createOrgUser(t *testing.T, conn *dbsqlc.Queries, name string) (dbsqlc.Org, int32) {
+	t.Helper()
+	ctx := context.Background()
+	userID, err := conn.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:    name,
+		LastName:     "Tester",
+		DisplayName:  common.NullString(name),
+		Email:        fmt.Sprintf("%s-%s@example.com", name, uuid.NewString()),
+		Password:     common.MustHashPassword(uuid.NewString()),
+		IsActivated:  common.TrueNullBool(),
+		IsReviewed:   common.TrueNullBool(),
+		IsSuperAdmin: common.FalseNullBool(),
+		IsVerified:   common.TrueNullBool(),
+		CreatedAt:    common.NewNullTime(time.Now()),
+		UpdatedAt:    common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	org, err := conn.CreateOrg(ctx, dbsqlc.CreateOrgParams{
+		Name:        name + " org",
+		CreatedByID: userID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create org: %v", err)
+	}
+	err = conn.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{OrgID: org.ID, UserID: userID, Role: "owner"})
+	if err != nil {
+		t.Fatalf("failed to add org member: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.DeleteOrg(context.Background(), org.ID)
+	})
+	return org, userID
+}
+
+func assertStatus(t *testing.T, want int, got int, body []byte, request string) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: expected status %d, got %d: %s", request, want, got, body)
+	}
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+)
+
+func TestProjectScopedAPITokens(t *testing.T) {
+	projectID := int32(2)
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ownCtx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	request := &schema.CreateAPITokenRequest{
+		Name:      "Project scoped token test",
+		Scopes:    []string{"projects:read", "test-cases:read", "test-plans:read", "test-runs:read", "testers:read"},
+		ProjectID: int64(projectID),
+	}
+	created, err := a.APITokenService.Create(ownCtx, int64(project.OwnerUserID), request)
+	if err != nil {
+		t.Fatalf("failed to create api token: %v", err)
+	}
+	defer a.APITokenService.Revoke(ownCtx, int64(project.OwnerUserID), created.ID)
+
+	status, body := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/v1/projects/%d", projectID), created.Token, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/projects/:id with a token for the project")
+	status, body = sendRequest(t, app, http.MethodGet, fmt.Sprintf("/v1/test-runs/query?q=%d", projectID), created.Token, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/test-runs/query for the project of the token")
+
+	// listings span the projects of the org
+	for _, path := range []string{
+		"/v1/projects",
+		"/v1/projects/query?keyword=a",
+		"/v1/test-cases",
+		"/v1/test-plans",
+		"/v1/test-runs",
+		"/v1/testers",
+	} {
+		status, body := sendRequest(t, app, http.MethodGet, path, created.Token, nil)
+		assertStatus(t, http.StatusForbidden, status, body, "GET "+path+" with a project scoped token")
+	}
+
+	// a token cannot be limited to a project of another org
+	otherOrg, otherUserID := createOrgUser(t, conn, "apitokens")
+	otherToken := accessToken(t, a, otherUserID, otherOrg.ID)
+	status, body = sendRequest(t, app, http.MethodPost, "/v1/me/tokens", otherToken, request)
+	assertStatus(t, http.StatusNotFound, status, body, "POST /v1/me/tokens for a project of another org")
+}
+
+func TestServiceAccountAPITokens(t *testing.T) {
+	projectID := int32(2)
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ownCtx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	request := &schema.CreateAPITokenRequest{
+		Name:           "Service account token test",
+		Scopes:         []string{"projects:read", "test-runs:read"},
+		ProjectID:      int64(projectID),
+		ServiceAccount: "CI",
+	}
+	created, err := a.APITokenService.Create(ownCtx, int64(project.OwnerUserID), request)
+	if err != nil {
+		t.Fatalf("failed to create api token: %v", err)
+	}
+	defer a.APITokenService.Revoke(ownCtx, int64(project.OwnerUserID), created.ID)
+
+	role, err := conn.GetOrgMemberRole(context.Background(), dbsqlc.GetOrgMemberRoleParams{OrgID: project.OrgID, UserID: int32(created.UserID)})
+	if err != nil || role != services.OrgRoleMember {
+		t.Errorf("expected the bot to be a member of the org, got %q %v", role, err)
+	}
+	status, body := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/v1/projects/%d", projectID), created.Token, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/projects/:id with a service account token")
+
+	// service accounts need a project
+	unscoped := *request
+	unscoped.ProjectID = 0
+	if _, err := a.APITokenService.Create(ownCtx, int64(project.OwnerUserID), &unscoped); !errors.Is(err, services.ErrServiceAccountNeedsProject) {
+		t.Errorf("expected ErrServiceAccountNeedsProject, got %v", err)
+	}
+
+	// members who cannot manage the testers of the project cannot add bots to it
+	_, memberID := createOrgUser(t, conn, "serviceaccounts")
+	err = conn.AddOrgMember(context.Background(), dbsqlc.AddOrgMemberParams{OrgID: project.OrgID, UserID: memberID, Role: services.OrgRoleMember})
+	if err != nil {
+		t.Fatalf("failed to add org member: %v", err)
+	}
+	if _, err := a.APITokenService.Create(ownCtx, int64(memberID), request); !errors.Is(err, services.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestSignInUserAppliesLoginChecks(t *testing.T) {
+	a, _ := newTestAPI()
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	ctx := context.Background()
+
+	cfg := *a.Config
+	cfg.Auth.RequireVerifiedAccounts = true
+	authService := services.NewAuthService(&cfg, db, conn, a.SigningKeyService, logging.NewForTest())
+
+	org, userID := createOrgUser(t, conn, "sso")
+	res, err := authService.SignInUser(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+	if res.Token == "" {
+		t.Errorf("expected the login to complete with a token")
+	}
+
+	// an org requiring two-factor authentication holds back the tokens
+	err = conn.UpdateOrg(ctx, dbsqlc.UpdateOrgParams{ID: org.ID, Name: org.Name, RequireTwoFactor: true})
+	if err != nil {
+		t.Fatalf("failed to update org: %v", err)
+	}
+	res, err = authService.SignInUser(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+	if res.Token != "" || res.ChallengeToken == "" || !res.TwoFactorSetupRequired {
+		t.Errorf("expected a two-factor setup challenge, got %+v", res)
+	}
+
+	unverifiedID, err := conn.CreateUser(ctx, dbsqlc.CreateUserParams{
+		FirstName:    "Unverified",
+		LastName:     "Tester",
+		DisplayName:  common.NullString("Unverified"),
+		Email:        fmt.Sprintf("unverified-%s@example.com", uuid.NewString()),
+		Password:     common.MustHashPassword(uuid.NewString()),
+		IsActivated:  common.TrueNullBool(),
+		IsReviewed:   common.TrueNullBool(),
+		IsSuperAdmin: common.FalseNullBool(),
+		IsVerified:   common.FalseNullBool(),
+		CreatedAt:    common.NewNullTime(time.Now()),
+		UpdatedAt:    common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	_, err = authService.SignInUser(ctx, unverifiedID, "test", "127.0.0.1")
+	if !errors.Is(err, services.ErrAccountNotVerified) {
+		t.Errorf("expected ErrAccountNotVerified, got %v", err)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	a, _ := newTestAPI()
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	ctx := context.Background()
+	authService := services.NewAuthService(a.Config, db, conn, a.SigningKeyService, logging.NewForTest())
+
+	_, userID := createOrgUser(t, conn, "rotation")
+	res, err := authService.SignInUser(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+
+	rotated, err := authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+	if err != nil {
+		t.Fatalf("failed to refresh token: %v", err)
+	}
+	if rotated.RefreshToken == "" || rotated.RefreshToken == res.RefreshToken {
+		t.Fatalf("expected a new refresh token, got %q", rotated.RefreshToken)
+	}
+
+	// presenting the exchanged token again revokes the whole session
+	_, err = authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+	if !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	_, err = authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: rotated.RefreshToken})
+	if !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected the rotated token to be revoked with its session, got %v", err)
+	}
+}
+
+func TestLogoutAndRevokeSession(t *testing.T) {
+	a, _ := newTestAPI()
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	ctx := context.Background()
+	authService := services.NewAuthService(a.Config, db, conn, a.SigningKeyService, logging.NewForTest())
+
+	_, userID := createOrgUser(t, conn, "sessions")
+	_, otherID := createOrgUser(t, conn, "other")
+	first, err := authService.SignInUser(ctx, userID, "first", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+	second, err := authService.SignInUser(ctx, userID, "second", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+
+	if err := authService.Logout(ctx, int64(otherID), first.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected another user's logout to be refused, got %v", err)
+	}
+	if err := authService.Logout(ctx, int64(userID), first.RefreshToken); err != nil {
+		t.Fatalf("failed to log out: %v", err)
+	}
+	_, err = authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected the logged out session to be revoked, got %v", err)
+	}
+
+	sessions, err := authService.ListSessions(ctx, int64(userID))
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].UserAgent != "second" {
+		t.Fatalf("expected only the second session, got %+v", sessions)
+	}
+
+	if err := authService.RevokeSession(ctx, int64(otherID), sessions[0].ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking another user's session, got %v", err)
+	}
+	if err := authService.RevokeSession(ctx, int64(userID), sessions[0].ID); err != nil {
+		t.Fatalf("failed to revoke session: %v", err)
+	}
+	if err := authService.RevokeSession(ctx, int64(userID), sessions[0].ID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking the session twice, got %v", err)
+	}
+	_, err = authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: second.RefreshToken})
+	if !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected the revoked session to be refused, got %v", err)
+	}
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	a, _ := newTestAPI()
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	ctx := context.Background()
+	authService := services.NewAuthService(a.Config, db, conn, a.SigningKeyService, logging.NewForTest())
+
+	_, userID := createOrgUser(t, conn, "password")
+	err := conn.ChangeUserPassword(ctx, dbsqlc.ChangeUserPasswordParams{
+		ID:        userID,
+		Password:  common.MustHashPassword("old-password"),
+		UpdatedAt: common.NewNullTime(time.Now()),
+	})
+	if err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+	res, err := authService.SignInUser(ctx, userID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to sign in user: %v", err)
+	}
+
+	err = authService.ChangePassword(ctx, &schema.ChangePasswordRequest{
+		UserID:          int64(userID),
+		OldPassword:     "old-password",
+		NewPassword:     "new-password",
+		ConfirmPassword: "new-password",
+	})
+	if err != nil {
+		t.Fatalf("failed to change password: %v", err)
+	}
+	_, err = authService.RefreshToken(ctx, &schema.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+	if !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Errorf("expected sessions to be revoked by the password change, got %v", err)
+	}
+}
+
+func TestPasswordResetTokens(t *testing.T) {
+	a, _ := newTestAPI()
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	ctx := context.Background()
+
+	cfg := *a.Config
+	// nothing listens here, so sending the reset email fails
+	cfg.SMTP.Host = "127.0.0.1"
+	cfg.SMTP.Port = 1
+	authService := services.NewAuthService(&cfg, db, conn, a.SigningKeyService, logging.NewForTest())
+
+	_, userID := createOrgUser(t, conn, "reset")
+	user, err := conn.GetUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("failed to fetch user: %v", err)
+	}
+	resetToken := func(expiresAt time.Time) string {
+		token := uuid.NewString()
+		sum := sha256.Sum256([]byte(token))
+		err := conn.CreatePasswordResetToken(ctx, dbsqlc.CreatePasswordResetTokenParams{
+			UserID:    userID,
+			TokenHash: hex.EncodeToString(sum[:]),
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			t.Fatalf("failed to create reset token: %v", err)
+		}
+		return token
+	}
+	confirm := func(token string) error {
+		return authService.ConfirmPasswordReset(ctx, &schema.ConfirmPasswordResetRequest{
+			Token:           token,
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		})
+	}
+
+	t.Run("mail failures are not reported", func(t *testing.T) {
+		if err := authService.ResetPassword(ctx, user.Email); err != nil {
+			t.Errorf("expected a registered email to be answered like an unknown one, got %v", err)
+		}
+		if err := authService.ResetPassword(ctx, "unknown-"+uuid.NewString()+"@example.com"); err != nil {
+			t.Errorf("expected no error for an unknown email, got %v", err)
+		}
+	})
+
+	t.Run("single use", func(t *testing.T) {
+		token := resetToken(time.Now().Add(time.Hour))
+		if err := confirm(token); err != nil {
+			t.Fatalf("failed to reset password: %v", err)
+		}
+		if err := confirm(token); !errors.Is(err, services.ErrInvalidResetToken) {
+			t.Errorf("expected ErrInvalidResetToken using the token twice, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		token := resetToken(time.Now().Add(-time.Minute))
+		if err := confirm(token); !errors.Is(err, services.ErrInvalidResetToken) {
+			t.Errorf("expected ErrInvalidResetToken for an expired token, got %v", err)
+		}
+	})
+
+	t.Run("superseded", func(t *testing.T) {
+		token := resetToken(time.Now().Add(time.Hour))
+		if err := authService.ResetPassword(ctx, user.Email); err != nil {
+			t.Fatalf("failed to request a new reset link: %v", err)
+		}
+		if err := confirm(token); !errors.Is(err, services.ErrInvalidResetToken) {
+			t.Errorf("expected ErrInvalidResetToken for a superseded token, got %v", err)
+		}
+	})
+}
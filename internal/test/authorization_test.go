@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestRoutesRequireProjectPermissions(t *testing.T) {
+	projectID := int32(2)
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+
+	// a member of the org without a role on the project
+	_, memberID := createOrgUser(t, conn, "authorization")
+	err = conn.AddOrgMember(context.Background(), dbsqlc.AddOrgMemberParams{OrgID: project.OrgID, UserID: memberID, Role: services.OrgRoleMember})
+	if err != nil {
+		t.Fatalf("failed to add org member: %v", err)
+	}
+	token := accessToken(t, a, memberID, project.OrgID)
+
+	for _, request := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodPost, "/v1/modules", schema.CreateProjectModuleRequest{ProjectID: projectID, Name: "Checkout", Code: "CHK", Priority: 1, Type: "feature", Description: "Checkout"}},
+		{http.MethodGet, fmt.Sprintf("/v1/test-cases/query?project_id=%d&keyword=a", projectID), nil},
+		{http.MethodGet, fmt.Sprintf("/v1/test-plans/query?q=%d", projectID), nil},
+		{http.MethodGet, fmt.Sprintf("/v1/test-runs/query?q=%d", projectID), nil},
+		{http.MethodPost, "/v1/testers/invite", schema.CreateInviteRequest{Email: "invitee@example.com", ProjectID: projectID, Role: services.RoleEngineer}},
+		{http.MethodPost, fmt.Sprintf("/v1/projects/%d/testers/%d/update-role", projectID, project.OwnerUserID), schema.UpdateTesterRoleRequest{Role: services.RoleClient}},
+		{http.MethodDelete, fmt.Sprintf("/v1/projects/%d/testers/%d", projectID, project.OwnerUserID), nil},
+	} {
+		status, body := sendRequest(t, app, request.method, request.path, token, request.body)
+		assertStatus(t, http.StatusForbidden, status, body, request.method+" "+request.path)
+	}
+
+	ownerToken := accessToken(t, a, project.OwnerUserID, project.OrgID)
+	status, body := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/v1/test-cases/query?project_id=%d&keyword=a", projectID), ownerToken, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/test-cases/query as the project owner")
+}
+
+func TestReportsAreScopedToTheirProject(t *testing.T) {
+	projectID := int32(2)
+	ctx := context.Background()
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(ctx, projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+
+	// another project in the same org with a plan and a report of its own
+	otherID, err := conn.CreateProject(ctx, dbsqlc.CreateProjectParams{
+		Title:       "Report scoping test",
+		Code:        "RPT",
+		Description: "Holds a report the fixture project must not reach",
+		OwnerUserID: project.OwnerUserID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		OrgID:       project.OrgID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	defer conn.DeleteProject(ctx, otherID)
+	planID, err := conn.CreateTestPlan(ctx, dbsqlc.CreateTestPlanParams{
+		ProjectID:    otherID,
+		AssignedToID: project.OwnerUserID,
+		CreatedByID:  project.OwnerUserID,
+		UpdatedByID:  project.OwnerUserID,
+		Kind:         dbsqlc.TestKindGeneral,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test plan: %v", err)
+	}
+	defer conn.DeleteTestPlan(ctx, planID)
+	reportID := uuid.New()
+	_, err = conn.CreateReport(ctx, dbsqlc.CreateReportParams{
+		ID:        reportID,
+		ProjectID: otherID,
+		Name:      "Other project report",
+		Type:      "summary",
+		Status:    "generated",
+		FilePath:  sql.NullString{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create report: %v", err)
+	}
+
+	token := accessToken(t, a, project.OwnerUserID, project.OrgID)
+	reportPath := fmt.Sprintf("/v1/projects/%d/reports/%s", projectID, reportID)
+	for _, request := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, reportPath + "/download", nil},
+		{http.MethodGet, reportPath + "/view", nil},
+		{http.MethodDelete, reportPath, nil},
+		{http.MethodPost, fmt.Sprintf("/v1/projects/%d/reports", projectID), schema.CreateReportRequest{ProjectID: int64(otherID), TestPlanID: planID, Name: "Cross project", Type: "summary", Status: "generated"}},
+	} {
+		status, body := sendRequest(t, app, request.method, request.path, token, request.body)
+		assertStatus(t, http.StatusNotFound, status, body, request.method+" "+request.path)
+	}
+
+	if _, err := conn.GetReport(ctx, reportID); err != nil {
+		t.Errorf("expected the report of the other project to remain, got %v", err)
+	}
+}
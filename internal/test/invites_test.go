@@ -0,0 +1,49 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestCreateProjectInviteOverHTTP(t *testing.T) {
+	projectID := int32(2)
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	token := accessToken(t, a, project.OwnerUserID, project.OrgID)
+
+	request := schema.CreateInviteRequest{
+		Email:     fmt.Sprintf("invitee-%s@example.com", uuid.NewString()),
+		ProjectID: projectID,
+		Role:      services.RoleEngineer,
+	}
+	status, body := sendRequest(t, app, http.MethodPost, "/v1/invites", token, request)
+	assertStatus(t, http.StatusOK, status, body, "POST /v1/invites")
+
+	var invite schema.Invite
+	if err := json.Unmarshal(body, &invite); err != nil {
+		t.Fatalf("failed to decode invite: %v", err)
+	}
+	if invite.Email != request.Email || invite.ProjectID != int64(projectID) {
+		t.Errorf("unexpected invite %+v", invite)
+	}
+
+	// the project of another org cannot be invited to
+	otherOrg, otherUserID := createOrgUser(t, conn, "invites")
+	otherToken := accessToken(t, a, otherUserID, otherOrg.ID)
+	request.Email = fmt.Sprintf("invitee-%s@example.com", uuid.NewString())
+	status, body = sendRequest(t, app, http.MethodPost, "/v1/invites", otherToken, request)
+	assertStatus(t, http.StatusNotFound, status, body, "POST /v1/invites from another org")
+}
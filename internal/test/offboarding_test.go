@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestOffboardReassignsOpenWork(t *testing.T) {
+	projectID := int32(2)
+
+	a, _ := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	_, leaverID := createOrgUser(t, conn, "leaver")
+	_, successorID := createOrgUser(t, conn, "successor")
+	if err := a.TesterService.Assign(ctx, int64(projectID), int64(leaverID), services.RoleLead); err != nil {
+		t.Fatalf("failed to assign tester: %v", err)
+	}
+	defer a.TesterService.DeleteTester(ctx, int64(projectID), leaverID)
+	defer a.TesterService.DeleteTester(ctx, int64(projectID), successorID)
+
+	createTestCase := func(title string) uuid.UUID {
+		t.Helper()
+		id, err := conn.CreateTestCase(context.Background(), dbsqlc.CreateTestCaseParams{
+			ID:          uuid.New(),
+			Kind:        dbsqlc.TestKindGeneral,
+			Code:        "OFF-" + uuid.NewString()[:8],
+			Title:       title,
+			Description: "Handed over when offboarding",
+			IsDraft:     common.FalseNullBool(),
+			Tags:        []string{},
+			CreatedByID: project.OwnerUserID,
+			CreatedAt:   common.NewNullTime(time.Now()),
+			UpdatedAt:   common.NewNullTime(time.Now()),
+			ProjectID:   common.NewNullInt32(projectID),
+		})
+		if err != nil {
+			t.Fatalf("failed to create test case: %v", err)
+		}
+		t.Cleanup(func() { _ = a.TestCasesService.DeleteByID(ctx, id.String()) })
+		return id
+	}
+	shared := createTestCase("Assigned to both")
+	own := createTestCase("Assigned to the leaver")
+
+	// the successor already has one of the test cases of the plan
+	plan, err := a.TestPlansService.Create(ctx, &schema.CreateTestPlan{
+		ProjectID:      int64(projectID),
+		Kind:           string(dbsqlc.TestKindGeneral),
+		Description:    "Offboarding test plan",
+		StartAt:        time.Now(),
+		ScheduledEndAt: time.Now().Add(24 * time.Hour),
+		AssignedToID:   int64(leaverID),
+		CreatedByID:    int64(project.OwnerUserID),
+		UpdatedByID:    int64(project.OwnerUserID),
+		PlannedTests: []schema.TestCaseAssignment{
+			{TestCaseID: shared.String(), UserIDs: []int64{int64(leaverID), int64(successorID)}},
+			{TestCaseID: own.String(), UserIDs: []int64{int64(leaverID)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create test plan: %v", err)
+	}
+	defer a.TestPlansService.DeleteByID(ctx, plan.ID)
+
+	run, err := a.TestRunsService.Create(ctx, &schema.TestRunRequest{
+		ProjectID:    projectID,
+		TestPlanID:   int32(plan.ID),
+		TestCaseID:   own.String(),
+		OwnerID:      project.OwnerUserID,
+		TestedByID:   leaverID,
+		AssignedToID: leaverID,
+		Code:         "OFF-RUN",
+	})
+	if err != nil {
+		t.Fatalf("failed to create test run: %v", err)
+	}
+	defer a.TestRunsService.DeleteByID(ctx, run.ID.String())
+
+	request := &schema.OffboardUserRequest{ReassignToID: int64(successorID), Deactivate: true}
+	if _, err := a.UserService.Offboard(ctx, int64(project.OwnerUserID), int64(leaverID), &schema.OffboardUserRequest{ReassignToID: int64(leaverID)}); !errors.Is(err, services.ErrReassignToSameUser) {
+		t.Errorf("expected ErrReassignToSameUser, got %v", err)
+	}
+
+	result, err := a.UserService.Offboard(ctx, int64(project.OwnerUserID), int64(leaverID), request)
+	if err != nil {
+		t.Fatalf("failed to offboard user: %v", err)
+	}
+	if result.TestPlans != 1 || result.PlanCases != 2 || result.TestRuns != 1 || !result.Deactivated {
+		t.Errorf("unexpected offboarding result %+v", result)
+	}
+	if len(result.GrantedProjects) != 1 || result.GrantedProjects[0].ProjectID != int64(projectID) || result.GrantedProjects[0].Role != services.RoleLead {
+		t.Errorf("expected the successor to take over the lead role on project %d, got %+v", projectID, result.GrantedProjects)
+	}
+
+	// nothing is left with the leaver
+	preview, err := a.UserService.PreviewOffboarding(ctx, int64(leaverID), int64(successorID))
+	if err != nil {
+		t.Fatalf("failed to preview offboarding: %v", err)
+	}
+	if len(preview.TestPlans)+len(preview.PlanCases)+len(preview.TestRuns) != 0 {
+		t.Errorf("expected no open work left, got %+v", preview)
+	}
+
+	// the duplicate assignment is merged into the one the successor had
+	planCases, err := conn.ListOpenPlanCasesAssignedTo(context.Background(), int64(successorID))
+	if err != nil {
+		t.Fatalf("failed to list plan cases: %v", err)
+	}
+	cases := map[uuid.UUID]int{}
+	for _, planCase := range planCases {
+		if planCase.TestPlanID == plan.ID {
+			cases[planCase.TestCaseID]++
+		}
+	}
+	if len(cases) != 2 || cases[shared] != 1 || cases[own] != 1 {
+		t.Errorf("expected each test case once for the successor, got %v", cases)
+	}
+
+	reassigned, err := a.TestRunsService.GetOneTestRun(ctx, run.ID.String())
+	if err != nil {
+		t.Fatalf("failed to fetch test run: %v", err)
+	}
+	if reassigned.AssignedToID.Int32 != successorID {
+		t.Errorf("expected the test run to be assigned to %d, got %d", successorID, reassigned.AssignedToID.Int32)
+	}
+
+	leaver, err := conn.GetUser(context.Background(), leaverID)
+	if err != nil {
+		t.Fatalf("failed to fetch user: %v", err)
+	}
+	if leaver.IsActivated.Bool {
+		t.Errorf("expected the leaver to be deactivated")
+	}
+}
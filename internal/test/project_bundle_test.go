@@ -0,0 +1,101 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/config"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+)
+
+func TestExportImportProjectBundle(t *testing.T) {
+	projectID := int64(2)
+
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	logger := logging.NewForTest()
+	cfg := &config.Config{}
+	cfg.Storage.LocalPath = t.TempDir()
+	svc := services.NewProjectBundleService(cfg, db, conn, logger)
+	projectService := services.NewProjectService(db, conn, logger, services.NewModuleService(conn))
+
+	source, err := conn.GetProject(context.Background(), int32(projectID))
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(source.OrgID))
+
+	var buf bytes.Buffer
+	if err := svc.Export(ctx, projectID, &buf); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	bundle := bytes.NewReader(buf.Bytes())
+	opts := &schema.ProjectImportOptions{
+		ImportedByID: int64(source.OwnerUserID),
+		Name:         source.Title + " (imported)",
+		Code:         "IMP",
+		DryRun:       true,
+	}
+
+	preview, err := svc.Import(ctx, bundle, bundle.Size(), opts)
+	if err != nil {
+		t.Fatalf("dry run Import failed: %v", err)
+	}
+	if preview.ProjectID != 0 {
+		t.Errorf("expected dry run to import nothing, got project %d", preview.ProjectID)
+	}
+
+	opts.DryRun = false
+	report, err := svc.Import(ctx, bundle, bundle.Size(), opts)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	defer projectService.DeleteProject(ctx, report.ProjectID)
+
+	if report.Counts != preview.Counts {
+		t.Errorf("expected dry run counts %+v to match import counts %+v", preview.Counts, report.Counts)
+	}
+	imported, err := conn.GetProject(context.Background(), int32(report.ProjectID))
+	if err != nil {
+		t.Fatalf("failed to fetch imported project: %v", err)
+	}
+	if imported.OrgID != source.OrgID || imported.Code != "IMP" {
+		t.Errorf("expected project IMP in org %d, got %s in org %d", source.OrgID, imported.Code, imported.OrgID)
+	}
+
+	sourceCases, err := conn.ListTestCasesByProject(context.Background(), common.NewNullInt32(source.ID))
+	if err != nil {
+		t.Fatalf("failed to list test cases: %v", err)
+	}
+	importedCases, err := conn.ListTestCasesByProject(context.Background(), common.NewNullInt32(imported.ID))
+	if err != nil {
+		t.Fatalf("failed to list test cases: %v", err)
+	}
+	if len(importedCases) != len(sourceCases) || report.Counts.TestCases != len(sourceCases) {
+		t.Errorf("expected %d test cases, reported %d and imported %d", len(sourceCases), report.Counts.TestCases, len(importedCases))
+	}
+
+	importedFields, err := conn.ListCustomFieldsByProject(context.Background(), imported.ID)
+	if err != nil {
+		t.Fatalf("failed to list custom fields: %v", err)
+	}
+	if len(importedFields) != report.Counts.CustomFields {
+		t.Errorf("expected %d custom fields, imported %d", report.Counts.CustomFields, len(importedFields))
+	}
+	sourceValues, err := conn.ListCustomFieldValues(context.Background(), testCaseIDs(sourceCases))
+	if err != nil {
+		t.Fatalf("failed to list custom field values: %v", err)
+	}
+	importedValues, err := conn.ListCustomFieldValues(context.Background(), testCaseIDs(importedCases))
+	if err != nil {
+		t.Fatalf("failed to list custom field values: %v", err)
+	}
+	if len(importedValues) != len(sourceValues) {
+		t.Errorf("expected %d custom field values, imported %d", len(sourceValues), len(importedValues))
+	}
+}
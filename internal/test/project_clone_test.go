@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestCloneProject(t *testing.T) {
+	projectID := int64(2)
+
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	logger := logging.NewForTest()
+	svc := services.NewProjectService(db, conn, logger, services.NewModuleService(conn))
+
+	source, err := conn.GetProject(context.Background(), int32(projectID))
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(source.OrgID))
+
+	clone, counts, err := svc.Clone(ctx, projectID, &schema.CloneProjectRequest{
+		Name:                 source.Title + " (next)",
+		Code:                 "CLN",
+		ProjectOwnerID:       int64(source.OwnerUserID),
+		IncludeOpenTestPlans: true,
+	})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer svc.DeleteProject(ctx, int64(clone.ID))
+
+	if clone.ParentProjectID.Int32 != source.ID {
+		t.Errorf("expected parent project %d, got %d", source.ID, clone.ParentProjectID.Int32)
+	}
+	if clone.OrgID != source.OrgID {
+		t.Errorf("expected clone in org %d, got %d", source.OrgID, clone.OrgID)
+	}
+
+	sourceCases, err := conn.ListTestCasesByProject(context.Background(), common.NewNullInt32(source.ID))
+	if err != nil {
+		t.Fatalf("failed to list test cases: %v", err)
+	}
+	clonedCases, err := conn.ListTestCasesByProject(context.Background(), common.NewNullInt32(clone.ID))
+	if err != nil {
+		t.Fatalf("failed to list test cases: %v", err)
+	}
+	if counts.TestCases != len(sourceCases) || len(clonedCases) != len(sourceCases) {
+		t.Errorf("expected %d test cases, reported %d and copied %d", len(sourceCases), counts.TestCases, len(clonedCases))
+	}
+	for _, tc := range clonedCases {
+		if !strings.HasPrefix(tc.Code, "CLN") {
+			t.Errorf("expected a fresh code for test case %s, got %s", tc.ID, tc.Code)
+		}
+	}
+
+	sourceFields, err := conn.ListCustomFieldsByProject(context.Background(), source.ID)
+	if err != nil {
+		t.Fatalf("failed to list custom fields: %v", err)
+	}
+	if counts.CustomFields != len(sourceFields) {
+		t.Errorf("expected %d custom fields, copied %d", len(sourceFields), counts.CustomFields)
+	}
+	sourceValues, err := conn.ListCustomFieldValues(context.Background(), testCaseIDs(sourceCases))
+	if err != nil {
+		t.Fatalf("failed to list custom field values: %v", err)
+	}
+	clonedValues, err := conn.ListCustomFieldValues(context.Background(), testCaseIDs(clonedCases))
+	if err != nil {
+		t.Fatalf("failed to list custom field values: %v", err)
+	}
+	if len(clonedValues) != len(sourceValues) {
+		t.Errorf("expected %d custom field values, copied %d", len(sourceValues), len(clonedValues))
+	}
+}
+
+func testCaseIDs(testCases []dbsqlc.TestCase) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(testCases))
+	for _, tc := range testCases {
+		ids = append(ids, tc.ID)
+	}
+	return ids
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+)
+
+func TestProjectHierarchy(t *testing.T) {
+	projectID := int64(2)
+
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	logger := logging.NewForTest()
+	svc := services.NewProjectService(db, conn, logger, services.NewModuleService(conn))
+	dashboard := services.NewDashboardService(conn, logger)
+
+	source, err := conn.GetProject(context.Background(), int32(projectID))
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(source.OrgID))
+
+	child, _, err := svc.Clone(ctx, projectID, &schema.CloneProjectRequest{
+		Name:           source.Title + " (child)",
+		Code:           "SUB",
+		ProjectOwnerID: int64(source.OwnerUserID),
+	})
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	defer svc.DeleteProject(ctx, int64(child.ID))
+
+	children, err := svc.ListChildren(ctx, projectID)
+	if err != nil {
+		t.Fatalf("ListChildren failed: %v", err)
+	}
+	found := false
+	for _, c := range children {
+		found = found || c.ID == child.ID
+	}
+	if !found {
+		t.Errorf("expected project %d among the children of %d", child.ID, projectID)
+	}
+
+	if err := svc.SetParent(ctx, projectID, int64(child.ID)); !errors.Is(err, services.ErrProjectCycle) {
+		t.Errorf("expected ErrProjectCycle, got %v", err)
+	}
+	if err := svc.SetParent(ctx, projectID, projectID); !errors.Is(err, services.ErrProjectCycle) {
+		t.Errorf("expected ErrProjectCycle for its own parent, got %v", err)
+	}
+
+	rollup, err := dashboard.GetProjectRollup(ctx, projectID)
+	if err != nil {
+		t.Fatalf("GetProjectRollup failed: %v", err)
+	}
+	if rollup.ProjectCount < 2 {
+		t.Errorf("expected the roll-up to include the sub-project, got %d projects", rollup.ProjectCount)
+	}
+}
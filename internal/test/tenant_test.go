@@ -0,0 +1,305 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/logging"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestProjectsAreIsolatedByOrg(t *testing.T) {
+	projectID := int64(2)
+
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	logger := logging.NewForTest()
+	svc := services.NewProjectService(db, conn, logger, services.NewModuleService(conn))
+
+	project, err := conn.GetProject(context.Background(), int32(projectID))
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	otherOrg, err := conn.CreateOrg(context.Background(), dbsqlc.CreateOrgParams{
+		Name:        "Tenant isolation test",
+		CreatedByID: project.OwnerUserID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create org: %v", err)
+	}
+	defer conn.DeleteOrg(context.Background(), otherOrg.ID)
+
+	ownCtx := services.WithOrgID(context.Background(), int64(project.OrgID))
+	otherCtx := services.WithOrgID(context.Background(), int64(otherOrg.ID))
+
+	if _, err := svc.FindByID(ownCtx, projectID); err != nil {
+		t.Errorf("expected project in its own org, got %v", err)
+	}
+	if _, err := svc.FindByID(otherCtx, projectID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from another org, got %v", err)
+	}
+	if _, err := svc.FindByID(context.Background(), projectID); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound without an org, got %v", err)
+	}
+
+	projects, err := svc.FindAll(otherCtx)
+	if err != nil {
+		t.Fatalf("FindAll failed: %v", err)
+	}
+	for _, p := range projects {
+		if int64(p.ID) == projectID {
+			t.Errorf("project %d listed in another org", projectID)
+		}
+	}
+}
+
+func TestProjectDataIsIsolatedByOrg(t *testing.T) {
+	projectID := int32(2)
+
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ownCtx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	testCaseID, err := conn.CreateTestCase(context.Background(), dbsqlc.CreateTestCaseParams{
+		ID:          uuid.New(),
+		Kind:        dbsqlc.TestKindGeneral,
+		Code:        "TNT-" + uuid.NewString()[:8],
+		Title:       "Tenant isolation test case",
+		Description: "Only visible in the org of its project",
+		IsDraft:     common.FalseNullBool(),
+		Tags:        []string{},
+		CreatedByID: project.OwnerUserID,
+		CreatedAt:   common.NewNullTime(time.Now()),
+		UpdatedAt:   common.NewNullTime(time.Now()),
+		ProjectID:   common.NewNullInt32(projectID),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test case: %v", err)
+	}
+	defer a.TestCasesService.DeleteByID(ownCtx, testCaseID.String())
+
+	plan, err := a.TestPlansService.Create(ownCtx, &schema.CreateTestPlan{
+		ProjectID:      int64(projectID),
+		Kind:           string(dbsqlc.TestKindGeneral),
+		Description:    "Tenant isolation test plan",
+		StartAt:        time.Now(),
+		ScheduledEndAt: time.Now().Add(24 * time.Hour),
+		AssignedToID:   int64(project.OwnerUserID),
+		CreatedByID:    int64(project.OwnerUserID),
+		UpdatedByID:    int64(project.OwnerUserID),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test plan: %v", err)
+	}
+	defer a.TestPlansService.DeleteByID(ownCtx, plan.ID)
+
+	run, err := a.TestRunsService.Create(ownCtx, &schema.TestRunRequest{
+		ProjectID:    projectID,
+		TestPlanID:   int32(plan.ID),
+		TestCaseID:   testCaseID.String(),
+		OwnerID:      project.OwnerUserID,
+		TestedByID:   project.OwnerUserID,
+		AssignedToID: project.OwnerUserID,
+		Code:         "TNT-RUN",
+	})
+	if err != nil {
+		t.Fatalf("failed to create test run: %v", err)
+	}
+	defer a.TestRunsService.DeleteByID(ownCtx, run.ID.String())
+
+	if err := a.TesterService.Assign(ownCtx, int64(projectID), int64(project.OwnerUserID), services.RoleLead); err != nil {
+		t.Fatalf("failed to assign tester: %v", err)
+	}
+	_, err = a.InviteService.Create(ownCtx, int64(project.OwnerUserID), &schema.CreateInviteRequest{
+		Email:     fmt.Sprintf("tenant-%s@example.com", uuid.NewString()),
+		ProjectID: projectID,
+		Role:      services.RoleEngineer,
+	})
+	if err != nil {
+		t.Fatalf("failed to create invite: %v", err)
+	}
+
+	otherOrg, otherUserID := createOrgUser(t, conn, "tenant")
+	otherCtx := services.WithOrgID(context.Background(), int64(otherOrg.ID))
+
+	// the services treat data of another org as missing
+	expectNotFound := func(what string, err error) {
+		t.Helper()
+		if !errors.Is(err, services.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for %s from another org, got %v", what, err)
+		}
+	}
+	_, err = a.TestCasesService.FindByID(otherCtx, testCaseID.String())
+	expectNotFound("test case", err)
+	_, err = a.TestPlansService.GetOneTestPlan(otherCtx, plan.ID)
+	expectNotFound("test plan", err)
+	_, err = a.TestRunsService.GetOneTestRun(otherCtx, run.ID.String())
+	expectNotFound("test run", err)
+	_, err = a.InviteService.ListByProject(otherCtx, int64(projectID))
+	expectNotFound("project invites", err)
+	_, err = a.TesterService.FindByProjectID(otherCtx, int64(projectID))
+	expectNotFound("project testers", err)
+	expectNotFound("tester removal", a.TesterService.DeleteTester(otherCtx, int64(projectID), project.OwnerUserID))
+	expectNotFound("tester role change", a.TesterService.UpdateRole(otherCtx, int64(projectID), project.OwnerUserID, services.RoleClient))
+
+	runs, err := a.TestRunsService.FindAll(otherCtx)
+	if err != nil {
+		t.Fatalf("FindAll test runs failed: %v", err)
+	}
+	for _, r := range runs {
+		if r.ID == run.ID {
+			t.Errorf("test run %s listed in another org", run.ID)
+		}
+	}
+	testers, err := a.TesterService.FindAll(otherCtx)
+	if err != nil {
+		t.Fatalf("FindAll testers failed: %v", err)
+	}
+	for _, tester := range testers {
+		if tester.ProjectID == int64(projectID) {
+			t.Errorf("tester of project %d listed in another org", projectID)
+		}
+	}
+
+	// the routes answer the same way for a user of another org
+	otherToken := accessToken(t, a, otherUserID, otherOrg.ID)
+	for _, request := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, "/v1/test-cases/" + testCaseID.String(), nil},
+		{http.MethodGet, fmt.Sprintf("/v1/test-plans/%d", plan.ID), nil},
+		{http.MethodGet, "/v1/test-runs/" + run.ID.String(), nil},
+		{http.MethodGet, fmt.Sprintf("/v1/projects/%d/invites", projectID), nil},
+		{http.MethodGet, fmt.Sprintf("/v1/projects/%d/testers", projectID), nil},
+		{http.MethodPost, fmt.Sprintf("/v1/projects/%d/testers/%d/update-role", projectID, project.OwnerUserID), schema.UpdateTesterRoleRequest{Role: services.RoleClient}},
+		{http.MethodDelete, fmt.Sprintf("/v1/projects/%d/testers/%d", projectID, project.OwnerUserID), nil},
+	} {
+		status, body := sendRequest(t, app, request.method, request.path, otherToken, request.body)
+		assertStatus(t, http.StatusNotFound, status, body, request.method+" "+request.path)
+	}
+
+	// and still serve the project's own org
+	ownToken := accessToken(t, a, project.OwnerUserID, project.OrgID)
+	status, body := sendRequest(t, app, http.MethodGet, "/v1/test-runs/"+run.ID.String(), ownToken, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/test-runs/:id from its own org")
+	testers, err = a.TesterService.FindByProjectID(ownCtx, int64(projectID))
+	if err != nil {
+		t.Fatalf("failed to list project testers: %v", err)
+	}
+	for _, tester := range testers {
+		if tester.UserID == int64(project.OwnerUserID) && tester.Role != services.RoleLead {
+			t.Errorf("expected the tester role to be unchanged, got %s", tester.Role)
+		}
+	}
+}
+
+func TestOrgsAreLimitedToTheirMembers(t *testing.T) {
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+
+	org, ownerID := createOrgUser(t, conn, "orgowner")
+	otherOrg, outsiderID := createOrgUser(t, conn, "orgoutsider")
+	_, memberID := createOrgUser(t, conn, "orgmember")
+	err := conn.AddOrgMember(context.Background(), dbsqlc.AddOrgMemberParams{OrgID: org.ID, UserID: memberID, Role: services.OrgRoleMember})
+	if err != nil {
+		t.Fatalf("failed to add org member: %v", err)
+	}
+
+	orgPath := fmt.Sprintf("/v1/orgs/%d", org.ID)
+	update := schema.UpdateOrgRequest{ID: org.ID, Name: "Renamed"}
+
+	outsiderToken := accessToken(t, a, outsiderID, otherOrg.ID)
+	status, body := sendRequest(t, app, http.MethodGet, "/v1/orgs", outsiderToken, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/orgs")
+	var listed schema.OrgListResponse
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatalf("failed to decode orgs: %v", err)
+	}
+	for _, o := range listed.Orgs {
+		if o.ID == org.ID {
+			t.Errorf("org %d listed for a user outside of it", org.ID)
+		}
+	}
+	status, body = sendRequest(t, app, http.MethodGet, orgPath, outsiderToken, nil)
+	assertStatus(t, http.StatusNotFound, status, body, "GET "+orgPath+" as an outsider")
+	status, body = sendRequest(t, app, http.MethodPut, orgPath, outsiderToken, update)
+	assertStatus(t, http.StatusForbidden, status, body, "PUT "+orgPath+" as an outsider")
+	status, body = sendRequest(t, app, http.MethodDelete, orgPath, outsiderToken, nil)
+	assertStatus(t, http.StatusForbidden, status, body, "DELETE "+orgPath+" as an outsider")
+
+	memberToken := accessToken(t, a, memberID, org.ID)
+	status, body = sendRequest(t, app, http.MethodGet, orgPath, memberToken, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET "+orgPath+" as a member")
+	status, body = sendRequest(t, app, http.MethodPut, orgPath, memberToken, update)
+	assertStatus(t, http.StatusForbidden, status, body, "PUT "+orgPath+" as a member")
+	status, body = sendRequest(t, app, http.MethodDelete, orgPath, memberToken, nil)
+	assertStatus(t, http.StatusForbidden, status, body, "DELETE "+orgPath+" as a member")
+
+	ownerToken := accessToken(t, a, ownerID, org.ID)
+	status, body = sendRequest(t, app, http.MethodPut, orgPath, ownerToken, update)
+	assertStatus(t, http.StatusOK, status, body, "PUT "+orgPath+" as the owner")
+	if _, err := conn.GetOrgByID(context.Background(), org.ID); err != nil {
+		t.Errorf("expected the org to remain, got %v", err)
+	}
+}
+
+func TestUsersAreIsolatedByOrg(t *testing.T) {
+	a, app := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+
+	org, userID := createOrgUser(t, conn, "usersinside")
+	_, outsiderID := createOrgUser(t, conn, "usersoutside")
+	token := accessToken(t, a, userID, org.ID)
+
+	status, body := sendRequest(t, app, http.MethodGet, "/v1/users", token, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/users")
+	var listed schema.CompactUserListResponse
+	if err := json.Unmarshal(body, &listed); err != nil {
+		t.Fatalf("failed to decode users: %v", err)
+	}
+	found := false
+	for _, u := range listed.Users {
+		if u.ID == int64(outsiderID) {
+			t.Errorf("user %d of another org listed", outsiderID)
+		}
+		found = found || u.ID == int64(userID)
+	}
+	if !found {
+		t.Errorf("expected user %d in the users of their org", userID)
+	}
+
+	status, body = sendRequest(t, app, http.MethodGet, "/v1/users/query?keyword=usersoutside", token, nil)
+	assertStatus(t, http.StatusOK, status, body, "GET /v1/users/query")
+	var searched []dbsqlc.User
+	_ = json.Unmarshal(body, &searched)
+	for _, u := range searched {
+		if u.ID == outsiderID {
+			t.Errorf("user %d of another org found by search", outsiderID)
+		}
+	}
+
+	outsiderPath := fmt.Sprintf("/v1/users/%d", outsiderID)
+	status, body = sendRequest(t, app, http.MethodGet, outsiderPath, token, nil)
+	assertStatus(t, http.StatusNotFound, status, body, "GET "+outsiderPath)
+
+	update := schema.UpdateUserRequest{FirstName: "Renamed", LastName: "Tester", DisplayName: "Renamed", Phone: "+265991000000", OrgID: org.ID, CountryIso: "MW", City: "Lilongwe"}
+	status, body = sendRequest(t, app, http.MethodPost, outsiderPath, token, update)
+	assertStatus(t, http.StatusForbidden, status, body, "POST "+outsiderPath)
+	status, body = sendRequest(t, app, http.MethodPost, fmt.Sprintf("/v1/users/%d", userID), token, update)
+	assertStatus(t, http.StatusOK, status, body, "POST /v1/users/<self>")
+}
@@ -3,16 +3,20 @@ package test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang-malawi/qatarina/internal/common"
 	"github.com/golang-malawi/qatarina/internal/config"
 	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
 	"github.com/golang-malawi/qatarina/internal/logging"
 	"github.com/golang-malawi/qatarina/internal/schema"
 	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/golang-malawi/qatarina/internal/validation"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
@@ -58,7 +62,13 @@ func TestConcurrentBulkCreateByProject(t *testing.T) {
 		TestCases: testCases,
 	}
 
-	created, skipped, err := svc.BulkCreate(context.Background(), bulkReq)
+	orgID, err := conn.GetProjectOrgID(context.Background(), int32(projectID))
+	if err != nil {
+		t.Fatalf("failed to fetch project org: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(orgID))
+
+	created, skipped, err := svc.BulkCreate(ctx, bulkReq)
 	if err != nil {
 		t.Fatalf("BulkCreate failed: %v", err)
 	}
@@ -89,7 +99,64 @@ func TestConcurrentBulkCreateByProject(t *testing.T) {
 
 }
 
+func TestFindAllPagedSearchesCodeAliases(t *testing.T) {
+	projectID := int32(2)
+
+	db := openTestDB()
+	conn := dbsqlc.New(db)
+	svc := services.NewTestCaseService(db, conn, logging.NewForTest())
+
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	testCaseID, err := conn.CreateTestCase(context.Background(), dbsqlc.CreateTestCaseParams{
+		ID:          uuid.New(),
+		Kind:        dbsqlc.TestKindGeneral,
+		Code:        "ALS-" + uuid.NewString()[:8],
+		Title:       "Renumbered test case",
+		Description: "Found by its old code",
+		IsDraft:     common.FalseNullBool(),
+		Tags:        []string{},
+		CreatedByID: project.OwnerUserID,
+		CreatedAt:   common.NewNullTime(time.Now()),
+		UpdatedAt:   common.NewNullTime(time.Now()),
+		ProjectID:   common.NewNullInt32(projectID),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test case: %v", err)
+	}
+	defer svc.DeleteByID(ctx, testCaseID.String())
+
+	alias := "OLD-" + uuid.NewString()[:8]
+	err = conn.CreateTestCaseCodeAlias(context.Background(), dbsqlc.CreateTestCaseCodeAliasParams{
+		ProjectID:  projectID,
+		Code:       alias,
+		TestCaseID: testCaseID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create code alias: %v", err)
+	}
+	defer conn.DeleteTestCaseCodeAlias(context.Background(), dbsqlc.DeleteTestCaseCodeAliasParams{ProjectID: projectID, Code: alias})
+
+	testCases, total, err := svc.FindAllPaged(ctx, services.TestCaseQueryParams{Page: 1, PageSize: 10, Search: alias})
+	if err != nil {
+		t.Fatalf("FindAllPaged failed: %v", err)
+	}
+	if total != 1 || len(testCases) != 1 || testCases[0].ID != testCaseID {
+		t.Errorf("expected the test case with the alias, got %d of %d", len(testCases), total)
+	}
+}
+
 func openTestDB() *sql.DB {
+	// small wait to ensure DB is ready in test environments that start DB dynamically
+	time.Sleep(50 * time.Millisecond)
+	return loadTestConfig().OpenDB().DB
+}
+
+func loadTestConfig() *config.Config {
 	viper.SetConfigFile("../../qatarina.yaml")
 	viper.SetConfigType("yaml")
 
@@ -100,8 +167,131 @@ func openTestDB() *sql.DB {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		panic(fmt.Errorf("failed to unmarshal config: %w", err))
 	}
+	return &cfg
+}
 
-	// small wait to ensure DB is ready in test environments that start DB dynamically
-	time.Sleep(50 * time.Millisecond)
-	return cfg.OpenDB().DB
+func TestPublishingDraftChecksTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	a, _ := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	source, err := conn.GetProject(ctx, 2)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	orgCtx := services.WithOrgID(ctx, int64(source.OrgID))
+
+	projectID, err := conn.CreateProject(ctx, dbsqlc.CreateProjectParams{
+		Title:            "Draft template test",
+		Code:             "DRT",
+		Description:      "Has a template with a required section",
+		OwnerUserID:      source.OwnerUserID,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		OrgID:            source.OrgID,
+		TestcaseTemplate: common.NullString(`{"sections":[{"name":"Steps","required":true}]}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	defer conn.DeleteProject(ctx, projectID)
+
+	testCaseID, err := conn.CreateTestCase(ctx, dbsqlc.CreateTestCaseParams{
+		ID:          uuid.New(),
+		Kind:        dbsqlc.TestKindGeneral,
+		Code:        "DRT-" + uuid.NewString()[:8],
+		Title:       "Draft without steps",
+		Description: "Not written yet",
+		IsDraft:     common.TrueNullBool(),
+		Tags:        []string{},
+		CreatedByID: source.OwnerUserID,
+		CreatedAt:   common.NewNullTime(time.Now()),
+		UpdatedAt:   common.NewNullTime(time.Now()),
+		ProjectID:   common.NewNullInt32(projectID),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test case: %v", err)
+	}
+	defer a.TestCasesService.DeleteByID(orgCtx, testCaseID.String())
+
+	var templateErrs validation.TestCaseTemplateErrors
+	if err := a.TestCasesService.UnMarkAsDraft(orgCtx, testCaseID.String()); !errors.As(err, &templateErrs) {
+		t.Fatalf("expected template errors, got %v", err)
+	}
+	tc, err := conn.GetTestCase(ctx, testCaseID)
+	if err != nil {
+		t.Fatalf("failed to fetch test case: %v", err)
+	}
+	if !tc.IsDraft.Bool {
+		t.Errorf("expected the test case to stay a draft")
+	}
+}
+
+func TestCustomFieldUsersMustBeOrgMembers(t *testing.T) {
+	ctx := context.Background()
+
+	a, _ := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	source, err := conn.GetProject(ctx, 2)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	orgCtx := services.WithOrgID(ctx, int64(source.OrgID))
+
+	projectID, err := conn.CreateProject(ctx, dbsqlc.CreateProjectParams{
+		Title:       "Custom field users test",
+		Code:        "CFU",
+		Description: "Has a user custom field",
+		OwnerUserID: source.OwnerUserID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		OrgID:       source.OrgID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	defer conn.DeleteProject(ctx, projectID)
+
+	_, err = conn.CreateCustomField(ctx, dbsqlc.CreateCustomFieldParams{
+		ProjectID: projectID,
+		Entity:    services.CustomFieldEntityTestCase,
+		Name:      "reviewer",
+		Label:     "Reviewer",
+		FieldType: validation.CustomFieldUser,
+		Options:   []string{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create custom field: %v", err)
+	}
+
+	_, memberID := createOrgUser(t, conn, "reviewer")
+	err = conn.AddOrgMember(ctx, dbsqlc.AddOrgMemberParams{OrgID: source.OrgID, UserID: memberID, Role: services.OrgRoleMember})
+	if err != nil {
+		t.Fatalf("failed to add org member: %v", err)
+	}
+	defer conn.RemoveOrgMember(ctx, dbsqlc.RemoveOrgMemberParams{OrgID: source.OrgID, UserID: memberID})
+	_, outsiderID := createOrgUser(t, conn, "outsider")
+
+	create := func(reviewerID int32) (*dbsqlc.TestCase, error) {
+		return a.TestCasesService.Create(orgCtx, &schema.CreateTestCaseRequest{
+			Kind:            "general",
+			FeatureOrModule: "review",
+			Title:           fmt.Sprintf("Reviewed by %d", reviewerID),
+			Description:     "Has a reviewer",
+			Tags:            []string{},
+			CreatedByID:     fmt.Sprint(source.OwnerUserID),
+			ProjectID:       int64(projectID),
+			CustomFields:    map[string]any{"reviewer": reviewerID},
+		})
+	}
+
+	var fieldErrs validation.CustomFieldErrors
+	if _, err := create(outsiderID); !errors.As(err, &fieldErrs) {
+		t.Fatalf("expected custom field errors for a user of another org, got %v", err)
+	}
+	tc, err := create(memberID)
+	if err != nil {
+		t.Fatalf("expected a member of the org to be accepted, got %v", err)
+	}
+	defer a.TestCasesService.DeleteByID(orgCtx, tc.ID.String())
 }
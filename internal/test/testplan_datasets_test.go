@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/internal/common"
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+	"github.com/golang-malawi/qatarina/internal/schema"
+	"github.com/golang-malawi/qatarina/internal/services"
+	"github.com/google/uuid"
+)
+
+func TestDataDrivenRunsFollowTheirRowValues(t *testing.T) {
+	projectID := int32(2)
+
+	a, _ := newTestAPI()
+	conn := dbsqlc.New(openTestDB())
+	project, err := conn.GetProject(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("failed to fetch project: %v", err)
+	}
+	ctx := services.WithOrgID(context.Background(), int64(project.OrgID))
+
+	code := "DDT-" + uuid.NewString()[:8]
+	testCaseID, err := conn.CreateTestCase(context.Background(), dbsqlc.CreateTestCaseParams{
+		ID:          uuid.New(),
+		Kind:        dbsqlc.TestKindGeneral,
+		Code:        code,
+		Title:       "Pay in {{currency}}",
+		Description: "Data-driven test case",
+		IsDraft:     common.FalseNullBool(),
+		Tags:        []string{},
+		CreatedByID: project.OwnerUserID,
+		CreatedAt:   common.NewNullTime(time.Now()),
+		UpdatedAt:   common.NewNullTime(time.Now()),
+		ProjectID:   common.NewNullInt32(projectID),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test case: %v", err)
+	}
+	defer a.TestCasesService.DeleteByID(ctx, testCaseID.String())
+
+	setRows := func(currencies ...string) {
+		t.Helper()
+		rows := []map[string]string{}
+		for _, currency := range currencies {
+			rows = append(rows, map[string]string{"currency": currency})
+		}
+		_, err := a.TestCasesService.SetDataset(ctx, &schema.SetTestCaseDatasetRequest{
+			TestCaseID: testCaseID.String(),
+			Parameters: []string{"currency"},
+			Rows:       rows,
+		})
+		if err != nil {
+			t.Fatalf("failed to set dataset: %v", err)
+		}
+	}
+	runsByCurrency := func(planID int64) map[string]uuid.UUID {
+		t.Helper()
+		runs, err := a.TestPlansService.FindAllByTestPlanID(ctx, int32(planID))
+		if err != nil {
+			t.Fatalf("failed to list test runs: %v", err)
+		}
+		res := map[string]uuid.UUID{}
+		for _, run := range runs {
+			res[schema.DataValues(run.DataValues)["currency"]] = run.ID
+		}
+		return res
+	}
+
+	setRows("MWK", "USD")
+	plan, err := a.TestPlansService.Create(ctx, &schema.CreateTestPlan{
+		ProjectID:      int64(projectID),
+		Kind:           string(dbsqlc.TestKindGeneral),
+		Description:    "Data-driven test plan",
+		StartAt:        time.Now(),
+		ScheduledEndAt: time.Now().Add(24 * time.Hour),
+		AssignedToID:   int64(project.OwnerUserID),
+		CreatedByID:    int64(project.OwnerUserID),
+		UpdatedByID:    int64(project.OwnerUserID),
+		PlannedTests:   []schema.TestCaseAssignment{{TestCaseID: testCaseID.String(), UserIDs: []int64{int64(project.OwnerUserID)}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create test plan: %v", err)
+	}
+	defer a.TestPlansService.DeleteByID(ctx, plan.ID)
+
+	before := runsByCurrency(plan.ID)
+	if len(before) != 2 {
+		t.Fatalf("expected a run per row, got %v", before)
+	}
+
+	// reordering the rows keeps each run with its values
+	setRows("ZAR", "USD", "MWK")
+	_, err = a.TestPlansService.AddTestCaseToPlan(ctx, &schema.AssignTestsToPlanRequest{
+		ProjectID:    int64(projectID),
+		PlanID:       plan.ID,
+		PlannedTests: []schema.TestCaseAssignment{{TestCaseID: testCaseID.String(), UserIDs: []int64{int64(project.OwnerUserID)}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to assign test case: %v", err)
+	}
+	after := runsByCurrency(plan.ID)
+	if len(after) != 3 {
+		t.Fatalf("expected a run for the new row, got %v", after)
+	}
+	for currency, id := range before {
+		if after[currency] != id {
+			t.Errorf("run of %s changed from %s to %s", currency, id, after[currency])
+		}
+	}
+
+	// renaming the test case keeps the row suffix of its runs
+	renamed := code + "R"
+	_, err = a.TestCaseCodeService.Resequence(ctx, &schema.ResequenceTestCaseCodesRequest{
+		ProjectID: int64(projectID),
+		Renames:   map[string]string{code: renamed},
+	})
+	if err != nil {
+		t.Fatalf("failed to rename test case: %v", err)
+	}
+	runs, err := a.TestPlansService.FindAllByTestPlanID(ctx, int32(plan.ID))
+	if err != nil {
+		t.Fatalf("failed to list test runs: %v", err)
+	}
+	for _, run := range runs {
+		if run.TestCaseID == testCaseID && !strings.HasPrefix(run.Code, renamed+"#") {
+			t.Errorf("expected run %s to be renamed to %s#<row>, got %s", run.ID, renamed, run.Code)
+		}
+	}
+}
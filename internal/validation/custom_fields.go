@@ -0,0 +1,257 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of custom fields
+const (
+	CustomFieldText        = "text"
+	CustomFieldNumber      = "number"
+	CustomFieldEnum        = "enum"
+	CustomFieldMultiSelect = "multi_select"
+	CustomFieldDate        = "date"
+	CustomFieldUser        = "user"
+)
+
+// CustomFieldTypes are the types a custom field can have
+var CustomFieldTypes = []string{CustomFieldText, CustomFieldNumber, CustomFieldEnum, CustomFieldMultiSelect, CustomFieldDate, CustomFieldUser}
+
+// CustomFieldNameRE matches the names of custom fields, names are used as
+// JSON keys, query parameters and import/export columns
+var CustomFieldNameRE = regexp.MustCompile(`\A[a-z][a-z0-9_]{0,62}\z`)
+
+// CustomFieldDateLayout is the format of the values of date fields
+const CustomFieldDateLayout = time.DateOnly
+
+// CustomField is the definition of a custom field values are validated against
+type CustomField struct {
+	Name     string
+	Type     string
+	Options  []string
+	Required bool
+	Default  any
+}
+
+type CustomFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// CustomFieldErrors lists every custom field which failed validation
+type CustomFieldErrors []CustomFieldError
+
+func (e CustomFieldErrors) Error() string {
+	arr := make([]string, 0, len(e))
+	for _, entry := range e {
+		arr = append(arr, fmt.Sprintf("%s: %s", entry.Field, entry.Message))
+	}
+	return strings.Join(arr, ",")
+}
+
+// ValidateCustomFieldDefinition checks the type, name and options of a field
+// and returns its default value in the form values are stored
+func ValidateCustomFieldDefinition(field CustomField) (any, error) {
+	if !CustomFieldNameRE.MatchString(field.Name) {
+		return nil, fmt.Errorf("name '%s' is invalid, use lowercase letters, digits and underscores", field.Name)
+	}
+	if !slices.Contains(CustomFieldTypes, field.Type) {
+		return nil, fmt.Errorf("type '%s' is invalid, expected one of %s", field.Type, strings.Join(CustomFieldTypes, ", "))
+	}
+	hasOptions := field.Type == CustomFieldEnum || field.Type == CustomFieldMultiSelect
+	if hasOptions && len(field.Options) == 0 {
+		return nil, fmt.Errorf("%s fields need at least one option", field.Type)
+	}
+	if !hasOptions && len(field.Options) > 0 {
+		return nil, fmt.Errorf("%s fields do not have options", field.Type)
+	}
+	for i, option := range field.Options {
+		if strings.TrimSpace(option) == "" || strings.Contains(option, ",") {
+			return nil, fmt.Errorf("option '%s' is invalid, options cannot be blank or contain commas", option)
+		}
+		if slices.Contains(field.Options[:i], option) {
+			return nil, fmt.Errorf("option '%s' is listed twice", option)
+		}
+	}
+	if field.Default == nil {
+		return nil, nil
+	}
+	value, present, err := normalizeCustomFieldValue(field, field.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default value is invalid: %w", err)
+	}
+	if !present {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// ValidateCustomFields validates the custom field values of a test case or
+// test run against the fields of its project and returns every value to
+// store. The values are applied on top of the existing values, a null value
+// clears a field. Fields without a value get their default and required
+// fields without either fail.
+func ValidateCustomFields(fields []CustomField, values, existing map[string]any) (map[string]any, error) {
+	var errs CustomFieldErrors
+	result := make(map[string]any, len(fields))
+	for name := range values {
+		if !slices.ContainsFunc(fields, func(f CustomField) bool { return f.Name == name }) {
+			errs = append(errs, CustomFieldError{Field: name, Message: "is not a field of this project"})
+		}
+	}
+
+	for _, field := range fields {
+		value, provided := values[field.Name]
+		if current, ok := existing[field.Name]; !provided && ok && current != nil {
+			// kept as stored, even if the options of the field changed since
+			result[field.Name] = current
+			continue
+		}
+		normalized, present, err := normalizeCustomFieldValue(field, value)
+		if err != nil {
+			errs = append(errs, CustomFieldError{Field: field.Name, Message: err.Error()})
+			continue
+		}
+		if !present && !provided && field.Default != nil {
+			normalized, present = field.Default, true
+		}
+		if !present {
+			if field.Required {
+				errs = append(errs, CustomFieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+		result[field.Name] = normalized
+	}
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b CustomFieldError) int { return strings.Compare(a.Field, b.Field) })
+		return nil, errs
+	}
+	return result, nil
+}
+
+// normalizeCustomFieldValue converts a value from JSON, a query parameter or
+// an import file to the form stored for the type of the field, blank values
+// are reported as not present
+func normalizeCustomFieldValue(field CustomField, value any) (any, bool, error) {
+	if value == nil {
+		return nil, false, nil
+	}
+	if s, ok := value.(string); ok {
+		value = strings.TrimSpace(s)
+		if value == "" {
+			return nil, false, nil
+		}
+	}
+
+	switch field.Type {
+	case CustomFieldText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("expected text")
+		}
+		return s, true, nil
+
+	case CustomFieldNumber:
+		n, err := customFieldNumber(value)
+		if err != nil {
+			return nil, false, err
+		}
+		return n, true, nil
+
+	case CustomFieldEnum:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("expected one of %s", strings.Join(field.Options, ", "))
+		}
+		if !slices.Contains(field.Options, s) {
+			return nil, false, fmt.Errorf("'%s' is not one of %s", s, strings.Join(field.Options, ", "))
+		}
+		return s, true, nil
+
+	case CustomFieldMultiSelect:
+		var items []string
+		switch v := value.(type) {
+		case string:
+			items = strings.Split(v, ",")
+		case []string:
+			items = v
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, false, fmt.Errorf("expected a list of options")
+				}
+				items = append(items, s)
+			}
+		default:
+			return nil, false, fmt.Errorf("expected a list of options")
+		}
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if item == "" || slices.Contains(selected, item) {
+				continue
+			}
+			if !slices.Contains(field.Options, item) {
+				return nil, false, fmt.Errorf("'%s' is not one of %s", item, strings.Join(field.Options, ", "))
+			}
+			selected = append(selected, item)
+		}
+		if len(selected) == 0 {
+			return nil, false, nil
+		}
+		return selected, true, nil
+
+	case CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, false, fmt.Errorf("expected a date like 2006-01-02")
+		}
+		date, err := time.Parse(CustomFieldDateLayout, s)
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, s)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("expected a date like 2006-01-02")
+		}
+		return date.Format(CustomFieldDateLayout), true, nil
+
+	case CustomFieldUser:
+		n, err := customFieldNumber(value)
+		if err != nil || n < 1 || n != math.Trunc(n) {
+			return nil, false, fmt.Errorf("expected the ID of a user")
+		}
+		return int64(n), true, nil
+	}
+	return nil, false, fmt.Errorf("unknown field type '%s'", field.Type)
+}
+
+func customFieldNumber(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("expected a number")
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("expected a number")
+}
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCustomFields = []CustomField{
+	{Name: "platform", Type: CustomFieldEnum, Options: []string{"android", "ios", "web"}, Required: true},
+	{Name: "devices", Type: CustomFieldMultiSelect, Options: []string{"phone", "tablet"}},
+	{Name: "clause", Type: CustomFieldText, Default: "n/a"},
+	{Name: "estimate", Type: CustomFieldNumber},
+	{Name: "due_on", Type: CustomFieldDate},
+	{Name: "owner", Type: CustomFieldUser},
+}
+
+func TestValidateCustomFieldDefinition(t *testing.T) {
+	value, err := ValidateCustomFieldDefinition(CustomField{Name: "due_on", Type: CustomFieldDate, Default: "2026-01-02T10:00:00Z"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-01-02", value)
+
+	value, err = ValidateCustomFieldDefinition(CustomField{Name: "devices", Type: CustomFieldMultiSelect, Options: []string{"phone", "tablet"}, Default: "tablet, phone"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tablet", "phone"}, value)
+
+	for _, field := range []CustomField{
+		{Name: "Platform", Type: CustomFieldText},
+		{Name: "platform", Type: "color"},
+		{Name: "platform", Type: CustomFieldEnum},
+		{Name: "platform", Type: CustomFieldText, Options: []string{"a"}},
+		{Name: "platform", Type: CustomFieldEnum, Options: []string{"a", "a"}},
+		{Name: "platform", Type: CustomFieldEnum, Options: []string{"a,b"}},
+		{Name: "platform", Type: CustomFieldEnum, Options: []string{"a"}, Default: "b"},
+	} {
+		_, err := ValidateCustomFieldDefinition(field)
+		assert.Error(t, err, "%+v", field)
+	}
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	values, err := ValidateCustomFields(testCustomFields, map[string]any{
+		"platform": "web",
+		"devices":  []any{"phone", "phone"},
+		"estimate": "2.5",
+		"due_on":   "2026-03-01",
+		"owner":    float64(4),
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"platform": "web",
+		"devices":  []string{"phone"},
+		"clause":   "n/a",
+		"estimate": 2.5,
+		"due_on":   "2026-03-01",
+		"owner":    int64(4),
+	}, values)
+
+	_, err = ValidateCustomFields(testCustomFields, map[string]any{
+		"devices":  "laptop",
+		"estimate": "many",
+		"unknown":  "x",
+	}, nil)
+	errs, ok := err.(CustomFieldErrors)
+	assert.True(t, ok)
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	assert.Equal(t, []string{"devices", "estimate", "platform", "unknown"}, fields)
+}
+
+func TestValidateCustomFieldsUpdatesExisting(t *testing.T) {
+	existing := map[string]any{"platform": "ios", "clause": "4.2", "owner": float64(4)}
+	values, err := ValidateCustomFields(testCustomFields, map[string]any{"clause": nil, "estimate": 3}, existing)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"platform": "ios", "estimate": float64(3), "owner": float64(4)}, values)
+
+	_, err = ValidateCustomFields(testCustomFields, map[string]any{"platform": nil}, existing)
+	assert.EqualError(t, err, "platform: is required")
+}
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MaxDatasetRows is the number of rows the dataset of a test case can have,
+// each row becomes a test run for every tester the test case is assigned to
+const MaxDatasetRows = 500
+
+// ValidateDataset checks the parameter names of the dataset of a data-driven
+// test case and that every row sets a value and only sets declared parameters
+func ValidateDataset(parameters []string, rows []map[string]string) error {
+	if len(parameters) == 0 {
+		return fmt.Errorf("dataset has no parameters")
+	}
+	for i, name := range parameters {
+		if !TemplateVariableRE.MatchString(name) {
+			return fmt.Errorf("parameter name '%s' is invalid, use lowercase letters, digits and underscores", name)
+		}
+		if slices.Contains(parameters[:i], name) {
+			return fmt.Errorf("parameter '%s' is listed twice", name)
+		}
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("dataset has no rows")
+	}
+	if len(rows) > MaxDatasetRows {
+		return fmt.Errorf("dataset has %d rows, at most %d are allowed", len(rows), MaxDatasetRows)
+	}
+	for i, row := range rows {
+		names := make([]string, 0, len(row))
+		for name := range row {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		empty := true
+		for _, name := range names {
+			if !slices.Contains(parameters, name) {
+				return fmt.Errorf("row %d sets '%s' which is not a parameter of the dataset", i+1, name)
+			}
+			if strings.TrimSpace(row[name]) != "" {
+				empty = false
+			}
+		}
+		if empty {
+			return fmt.Errorf("row %d has no values", i+1)
+		}
+		// test runs of a row are told apart by its values
+		if j := slices.IndexFunc(rows[:i], func(other map[string]string) bool { return maps.Equal(other, row) }); j >= 0 {
+			return fmt.Errorf("row %d repeats the values of row %d", i+1, j+1)
+		}
+	}
+	return nil
+}
+
+// ExpandDatasetText replaces the parameters in a text of a data-driven test
+// case by the values of a dataset row, parameters without a value are left
+// as {{name}}
+func ExpandDatasetText(text string, values map[string]string) string {
+	return ExpandSharedStepText(text, nil, values)
+}
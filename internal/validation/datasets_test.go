@@ -0,0 +1,29 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDataset(t *testing.T) {
+	parameters := []string{"currency", "locale"}
+	assert.NoError(t, ValidateDataset(parameters, []map[string]string{
+		{"currency": "MWK", "locale": "en_MW"},
+		{"currency": "USD"},
+	}))
+
+	assert.Error(t, ValidateDataset(nil, []map[string]string{{"currency": "MWK"}}))
+	assert.Error(t, ValidateDataset([]string{"Currency"}, []map[string]string{{"Currency": "MWK"}}))
+	assert.ErrorContains(t, ValidateDataset([]string{"currency", "currency"}, nil), "twice")
+	assert.ErrorContains(t, ValidateDataset(parameters, nil), "no rows")
+	assert.ErrorContains(t, ValidateDataset(parameters, []map[string]string{{"currency": "MWK"}, {"account": "x"}}), "row 2")
+	assert.ErrorContains(t, ValidateDataset(parameters, []map[string]string{{"currency": " "}}), "no values")
+	assert.ErrorContains(t, ValidateDataset(parameters, make([]map[string]string, MaxDatasetRows+1)), "at most")
+	assert.ErrorContains(t, ValidateDataset(parameters, []map[string]string{{"currency": "MWK"}, {"currency": "USD"}, {"currency": "MWK"}}), "row 3 repeats the values of row 1")
+}
+
+func TestExpandDatasetText(t *testing.T) {
+	assert.Equal(t, "Pay 100 MWK as {{account_type}}",
+		ExpandDatasetText("Pay 100 {{ currency }} as {{account_type}}", map[string]string{"currency": "MWK"}))
+}
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SharedStepParameter is a value test cases pass to a shared step, it is
+// written as {{name}} in the steps of the shared step
+type SharedStepParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when a test case does not pass the parameter, test
+	// cases must pass the parameters without a default
+	Default string `json:"default,omitempty"`
+}
+
+// ValidateSharedStepParameters checks the names of the parameters of a shared
+// step and that the texts of its steps only use declared parameters
+func ValidateSharedStepParameters(parameters []SharedStepParameter, texts ...string) error {
+	names := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		if !TemplateVariableRE.MatchString(parameter.Name) {
+			return fmt.Errorf("parameter name '%s' is invalid, use lowercase letters, digits and underscores", parameter.Name)
+		}
+		if slices.Contains(names, parameter.Name) {
+			return fmt.Errorf("parameter '%s' is listed twice", parameter.Name)
+		}
+		names = append(names, parameter.Name)
+	}
+	for _, text := range texts {
+		for _, match := range templatePlaceholderRE.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(names, match[1]) {
+				return fmt.Errorf("steps use undeclared parameter '%s'", match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// CheckSharedStepValues checks the values a test case passes to a shared
+// step are parameters of the shared step and that every parameter without a
+// default has a value
+func CheckSharedStepValues(parameters []SharedStepParameter, values map[string]string) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.ContainsFunc(parameters, func(p SharedStepParameter) bool { return p.Name == name }) {
+			return fmt.Errorf("'%s' is not a parameter of the shared step", name)
+		}
+	}
+	for _, parameter := range parameters {
+		if parameter.Default == "" && strings.TrimSpace(values[parameter.Name]) == "" {
+			return fmt.Errorf("parameter '%s' needs a value", parameter.Name)
+		}
+	}
+	return nil
+}
+
+// ExpandSharedStepText replaces the parameters in a text of a shared step by
+// the values passed by a test case or their defaults, parameters without
+// either are left as {{name}}
+func ExpandSharedStepText(text string, parameters []SharedStepParameter, values map[string]string) string {
+	return templatePlaceholderRE.ReplaceAllStringFunc(text, func(s string) string {
+		name := templatePlaceholderRE.FindStringSubmatch(s)[1]
+		if value, ok := values[name]; ok && value != "" {
+			return value
+		}
+		for _, parameter := range parameters {
+			if parameter.Name == name && parameter.Default != "" {
+				return parameter.Default
+			}
+		}
+		return s
+	})
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var loginParameters = []SharedStepParameter{
+	{Name: "username"},
+	{Name: "url", Default: "https://staging.example.com"},
+}
+
+func TestValidateSharedStepParameters(t *testing.T) {
+	assert.NoError(t, ValidateSharedStepParameters(loginParameters, "Open {{url}}", "Sign in as {{ username }}"))
+
+	assert.Error(t, ValidateSharedStepParameters([]SharedStepParameter{{Name: "User"}}))
+	assert.Error(t, ValidateSharedStepParameters([]SharedStepParameter{{Name: "url"}, {Name: "url"}}))
+	assert.ErrorContains(t, ValidateSharedStepParameters(loginParameters, "Enter {{password}}"), "password")
+}
+
+func TestCheckSharedStepValues(t *testing.T) {
+	assert.NoError(t, CheckSharedStepValues(loginParameters, map[string]string{"username": "admin"}))
+	assert.ErrorContains(t, CheckSharedStepValues(loginParameters, nil), "username")
+	assert.ErrorContains(t, CheckSharedStepValues(loginParameters, map[string]string{"username": " "}), "username")
+	assert.ErrorContains(t, CheckSharedStepValues(loginParameters, map[string]string{"username": "admin", "role": "x"}), "role")
+}
+
+func TestExpandSharedStepText(t *testing.T) {
+	values := map[string]string{"username": "admin"}
+	assert.Equal(t, "Sign in to https://staging.example.com as admin",
+		ExpandSharedStepText("Sign in to {{url}} as {{ username }}", loginParameters, values))
+	assert.Equal(t, "Sign in to https://example.com as {{username}}",
+		ExpandSharedStepText("Sign in to {{url}} as {{username}}", loginParameters, map[string]string{"url": "https://example.com"}))
+}
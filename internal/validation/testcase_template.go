@@ -0,0 +1,262 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/golang-malawi/qatarina/internal/database/dbsqlc"
+)
+
+// testKinds are the kinds a template can have a layout for
+var testKinds = []dbsqlc.TestKind{
+	dbsqlc.TestKindGeneral,
+	dbsqlc.TestKindAdhoc,
+	dbsqlc.TestKindTriage,
+	dbsqlc.TestKindIntegration,
+	dbsqlc.TestKindUserAcceptance,
+	dbsqlc.TestKindRegression,
+	dbsqlc.TestKindSecurity,
+	dbsqlc.TestKindUserInterface,
+	dbsqlc.TestKindScenario,
+}
+
+// TemplateVariableRE matches the names of template variables, variables are
+// written as {{name}} in the placeholders of sections
+var TemplateVariableRE = regexp.MustCompile(`\A[a-z][a-z0-9_]{0,62}\z`)
+
+var (
+	templatePlaceholderRE = regexp.MustCompile(`{{\s*([a-z][a-z0-9_]*)\s*}}`)
+	markdownHeadingRE     = regexp.MustCompile(`\A#{1,6}\s+(.+?)\s*#*\s*\z`)
+)
+
+// TestCaseTemplateSection is a named part of the description of a test case,
+// sections are written as markdown headings e.g. "## Steps"
+type TestCaseTemplateSection struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	// Placeholder is the text of the section in the rendered skeleton
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+// TestCaseTemplateVariable is a placeholder filled in when the skeleton is rendered
+type TestCaseTemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// TestCaseTemplateLayout lists the sections of the description of test cases
+type TestCaseTemplateLayout struct {
+	Sections  []TestCaseTemplateSection  `json:"sections"`
+	Variables []TestCaseTemplateVariable `json:"variables,omitempty"`
+}
+
+// TestCaseTemplate is the template of the test cases of a project, Kinds
+// replaces the default layout for test cases of a kind
+type TestCaseTemplate struct {
+	TestCaseTemplateLayout
+	Kinds map[string]TestCaseTemplateLayout `json:"kinds,omitempty"`
+}
+
+type TestCaseTemplateError struct {
+	Section string `json:"section"`
+	Message string `json:"message"`
+}
+
+// TestCaseTemplateErrors lists every section of a description which does not
+// follow the template
+type TestCaseTemplateErrors []TestCaseTemplateError
+
+func (e TestCaseTemplateErrors) Error() string {
+	arr := make([]string, 0, len(e))
+	for _, entry := range e {
+		arr = append(arr, fmt.Sprintf("%s: %s", entry.Section, entry.Message))
+	}
+	return strings.Join(arr, ",")
+}
+
+// ParseTestCaseTemplate reads a template as stored on a project. Templates
+// saved as free text before templates had sections are read as a layout of
+// optional sections named after their headings.
+func ParseTestCaseTemplate(text string) (*TestCaseTemplate, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(text, "{") {
+		var template TestCaseTemplate
+		if err := json.Unmarshal([]byte(text), &template); err != nil {
+			return nil, fmt.Errorf("invalid test case template: %w", err)
+		}
+		return &template, nil
+	}
+
+	template := &TestCaseTemplate{}
+	for _, section := range parseDescriptionSections(text) {
+		template.Sections = append(template.Sections, TestCaseTemplateSection{
+			Name:        section.name,
+			Placeholder: section.body,
+		})
+	}
+	if len(template.Sections) == 0 {
+		template.Sections = []TestCaseTemplateSection{{Name: "Description", Placeholder: text}}
+	}
+	return template, nil
+}
+
+// ValidateTestCaseTemplate checks the sections, variables and kinds of a template
+func ValidateTestCaseTemplate(template *TestCaseTemplate) error {
+	if err := validateTemplateLayout(template.TestCaseTemplateLayout); err != nil {
+		return err
+	}
+	for kind, layout := range template.Kinds {
+		if !slices.Contains(testKinds, dbsqlc.TestKind(kind)) {
+			return fmt.Errorf("kind '%s' is not a test kind", kind)
+		}
+		if len(layout.Sections) == 0 {
+			return fmt.Errorf("kind '%s' needs at least one section", kind)
+		}
+		if err := validateTemplateLayout(layout); err != nil {
+			return fmt.Errorf("kind '%s': %w", kind, err)
+		}
+	}
+	return nil
+}
+
+func validateTemplateLayout(layout TestCaseTemplateLayout) error {
+	variables := make([]string, 0, len(layout.Variables))
+	for _, variable := range layout.Variables {
+		if !TemplateVariableRE.MatchString(variable.Name) {
+			return fmt.Errorf("variable name '%s' is invalid, use lowercase letters, digits and underscores", variable.Name)
+		}
+		if slices.Contains(variables, variable.Name) {
+			return fmt.Errorf("variable '%s' is listed twice", variable.Name)
+		}
+		variables = append(variables, variable.Name)
+	}
+
+	names := make([]string, 0, len(layout.Sections))
+	for _, section := range layout.Sections {
+		name := strings.TrimSpace(section.Name)
+		if name == "" || strings.ContainsAny(name, "\r\n#") {
+			return fmt.Errorf("section name '%s' is invalid", section.Name)
+		}
+		if slices.Contains(names, strings.ToLower(name)) {
+			return fmt.Errorf("section '%s' is listed twice", name)
+		}
+		names = append(names, strings.ToLower(name))
+		for _, match := range templatePlaceholderRE.FindAllStringSubmatch(section.Placeholder, -1) {
+			if !slices.Contains(variables, match[1]) {
+				return fmt.Errorf("section '%s' uses undeclared variable '%s'", name, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Layout returns the layout of the test cases of a kind
+func (t *TestCaseTemplate) Layout(kind string) TestCaseTemplateLayout {
+	if layout, ok := t.Kinds[kind]; ok {
+		return layout
+	}
+	return t.TestCaseTemplateLayout
+}
+
+// Render builds the skeleton description of a new test case, variables
+// without a value or default are left as {{name}}
+func (l TestCaseTemplateLayout) Render(values map[string]string) string {
+	var sb strings.Builder
+	for i, section := range l.Sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("## ")
+		sb.WriteString(strings.TrimSpace(section.Name))
+		sb.WriteString("\n")
+		placeholder := templatePlaceholderRE.ReplaceAllStringFunc(section.Placeholder, func(s string) string {
+			name := templatePlaceholderRE.FindStringSubmatch(s)[1]
+			if value, ok := values[name]; ok && value != "" {
+				return value
+			}
+			for _, variable := range l.Variables {
+				if variable.Name == name && variable.Default != "" {
+					return variable.Default
+				}
+			}
+			return s
+		})
+		if placeholder = strings.TrimSpace(placeholder); placeholder != "" {
+			sb.WriteString(placeholder)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// CheckTestCaseDescription checks the description of a test case of the
+// kind has the required sections of the template filled in, and that no
+// section still holds a variable of the template
+func CheckTestCaseDescription(template *TestCaseTemplate, kind, description string) error {
+	if template == nil {
+		return nil
+	}
+	layout := template.Layout(kind)
+	sections := parseDescriptionSections(description)
+
+	var errs TestCaseTemplateErrors
+	for _, section := range layout.Sections {
+		name := strings.TrimSpace(section.Name)
+		idx := slices.IndexFunc(sections, func(s descriptionSection) bool { return strings.EqualFold(s.name, name) })
+		if idx < 0 {
+			if section.Required {
+				errs = append(errs, TestCaseTemplateError{Section: name, Message: "is required"})
+			}
+			continue
+		}
+		body := sections[idx].body
+		if section.Required && body == "" {
+			errs = append(errs, TestCaseTemplateError{Section: name, Message: "cannot be empty"})
+			continue
+		}
+		for _, match := range templatePlaceholderRE.FindAllStringSubmatch(body, -1) {
+			if slices.ContainsFunc(layout.Variables, func(v TestCaseTemplateVariable) bool { return v.Name == match[1] }) {
+				errs = append(errs, TestCaseTemplateError{Section: name, Message: fmt.Sprintf("placeholder %s is not filled in", match[0])})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type descriptionSection struct {
+	name string
+	body string
+}
+
+// parseDescriptionSections splits a description into sections at its
+// markdown headings, text before the first heading is ignored
+func parseDescriptionSections(description string) []descriptionSection {
+	var sections []descriptionSection
+	var body []string
+	flush := func() {
+		if len(sections) > 0 {
+			sections[len(sections)-1].body = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		if match := markdownHeadingRE.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			flush()
+			sections = append(sections, descriptionSection{name: match[1]})
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTemplate = &TestCaseTemplate{
+	TestCaseTemplateLayout: TestCaseTemplateLayout{
+		Sections: []TestCaseTemplateSection{
+			{Name: "Preconditions", Placeholder: "Logged in to {{url}}"},
+			{Name: "Steps", Required: true, Placeholder: "1. "},
+			{Name: "Expected Result", Required: true},
+		},
+		Variables: []TestCaseTemplateVariable{{Name: "url", Default: "https://staging.example.com"}},
+	},
+	Kinds: map[string]TestCaseTemplateLayout{
+		"security": {Sections: []TestCaseTemplateSection{{Name: "Threat", Required: true}}},
+	},
+}
+
+func TestValidateTestCaseTemplate(t *testing.T) {
+	assert.NoError(t, ValidateTestCaseTemplate(testTemplate))
+
+	for _, template := range []*TestCaseTemplate{
+		{TestCaseTemplateLayout: TestCaseTemplateLayout{Sections: []TestCaseTemplateSection{{Name: " "}}}},
+		{TestCaseTemplateLayout: TestCaseTemplateLayout{Sections: []TestCaseTemplateSection{{Name: "Steps"}, {Name: "steps"}}}},
+		{TestCaseTemplateLayout: TestCaseTemplateLayout{Sections: []TestCaseTemplateSection{{Name: "Steps", Placeholder: "{{user}}"}}}},
+		{TestCaseTemplateLayout: TestCaseTemplateLayout{Variables: []TestCaseTemplateVariable{{Name: "User"}}}},
+		{Kinds: map[string]TestCaseTemplateLayout{"performance": {Sections: []TestCaseTemplateSection{{Name: "Steps"}}}}},
+		{Kinds: map[string]TestCaseTemplateLayout{"security": {}}},
+	} {
+		assert.Error(t, ValidateTestCaseTemplate(template), "%+v", template)
+	}
+}
+
+func TestRenderTestCaseTemplate(t *testing.T) {
+	assert.Equal(t, "## Preconditions\nLogged in to https://staging.example.com\n\n## Steps\n1.\n\n## Expected Result\n",
+		testTemplate.Layout("general").Render(nil))
+	assert.Equal(t, "## Preconditions\nLogged in to http://localhost\n\n## Steps\n1.\n\n## Expected Result\n",
+		testTemplate.Layout("").Render(map[string]string{"url": "http://localhost"}))
+	assert.Equal(t, "## Threat\n", testTemplate.Layout("security").Render(nil))
+}
+
+func TestCheckTestCaseDescription(t *testing.T) {
+	assert.NoError(t, CheckTestCaseDescription(nil, "general", "anything"))
+	assert.NoError(t, CheckTestCaseDescription(testTemplate, "general", "## Steps\n1. Login\n\n### expected result\nDashboard is shown"))
+
+	err := CheckTestCaseDescription(testTemplate, "general", "Intro\n## Preconditions\nLogged in to {{url}}\n## Steps\n")
+	assert.Equal(t, TestCaseTemplateErrors{
+		{Section: "Preconditions", Message: "placeholder {{url}} is not filled in"},
+		{Section: "Steps", Message: "cannot be empty"},
+		{Section: "Expected Result", Message: "is required"},
+	}, err)
+
+	assert.EqualError(t, CheckTestCaseDescription(testTemplate, "security", "## Steps\n1. Login"), "Threat: is required")
+}
+
+func TestParseTestCaseTemplate(t *testing.T) {
+	template, err := ParseTestCaseTemplate("")
+	assert.NoError(t, err)
+	assert.Nil(t, template)
+
+	template, err = ParseTestCaseTemplate("## Steps\n1.\n## Expected Result\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []TestCaseTemplateSection{{Name: "Steps", Placeholder: "1."}, {Name: "Expected Result"}}, template.Sections)
+
+	template, err = ParseTestCaseTemplate("Describe the test")
+	assert.NoError(t, err)
+	assert.Equal(t, []TestCaseTemplateSection{{Name: "Description", Placeholder: "Describe the test"}}, template.Sections)
+
+	template, err = ParseTestCaseTemplate(`{"sections":[{"name":"Steps","required":true}],"kinds":{"security":{"sections":[{"name":"Threat"}]}}}`)
+	assert.NoError(t, err)
+	assert.True(t, template.Sections[0].Required)
+	assert.Equal(t, "Threat", template.Layout("security").Sections[0].Name)
+}
@@ -0,0 +1,146 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingAlgorithms are the asymmetric algorithms accepted for ID tokens, HMAC
+// and "none" are refused so a token can never be signed with something public
+var signingAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// IDToken holds the validated claims of an ID token
+type IDToken struct {
+	Issuer            string
+	Subject           string
+	Audience          []string
+	ExpiresAt         time.Time
+	Nonce             string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	GivenName         string
+	FamilyName        string
+	PreferredUsername string
+	// Claims has every claim of the token, including provider specific ones like groups
+	Claims map[string]any
+}
+
+// StringsClaim returns the values of a claim that holds either a single string or a list of strings
+func (t *IDToken) StringsClaim(name string) []string {
+	switch value := t.Claims[name].(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// VerifyIDToken checks the signature of the ID token against the keys of the
+// provider and validates the issuer, audience, expiry and nonce
+func (c *Client) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods(signingAlgorithms), jwt.WithoutClaimsValidation())
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.signingKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	token := &IDToken{Claims: claims}
+	token.Issuer, _ = claims["iss"].(string)
+	token.Subject, _ = claims["sub"].(string)
+	token.Nonce, _ = claims["nonce"].(string)
+	token.Email, _ = claims["email"].(string)
+	token.Name, _ = claims["name"].(string)
+	token.GivenName, _ = claims["given_name"].(string)
+	token.FamilyName, _ = claims["family_name"].(string)
+	token.PreferredUsername, _ = claims["preferred_username"].(string)
+	token.Audience = token.StringsClaim("aud")
+
+	// some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		token.EmailVerified = verified
+	case string:
+		token.EmailVerified = verified == "true"
+	}
+
+	if err := c.validateClaims(token, claims, nonce); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (c *Client) validateClaims(token *IDToken, claims jwt.MapClaims, nonce string) error {
+	now := time.Now()
+
+	if token.Issuer != c.metadata.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, token.Issuer)
+	}
+	if token.Subject == "" {
+		return fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	audienceOK := false
+	for _, aud := range token.Audience {
+		if aud == c.config.ClientID {
+			audienceOK = true
+			break
+		}
+	}
+	if !audienceOK {
+		return fmt.Errorf("%w: token was not issued for client %q", ErrInvalidIDToken, c.config.ClientID)
+	}
+	if azp, ok := claims["azp"].(string); ok && len(token.Audience) > 1 && azp != c.config.ClientID {
+		return fmt.Errorf("%w: token was authorized for %q", ErrInvalidIDToken, azp)
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing expiry", ErrInvalidIDToken)
+	}
+	token.ExpiresAt = time.Unix(int64(exp), 0)
+	if now.After(token.ExpiresAt.Add(clockSkew)) {
+		return fmt.Errorf("%w: token expired at %s", ErrInvalidIDToken, token.ExpiresAt.Format(time.RFC3339))
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: token was issued in the future", ErrInvalidIDToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && time.Unix(int64(nbf), 0).After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidIDToken)
+	}
+
+	if nonce != "" && token.Nonce != nonce {
+		return fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+// DisplayName picks the best name the provider gave us for the user
+func (t *IDToken) DisplayName() string {
+	for _, name := range []string{t.Name, t.PreferredUsername, strings.TrimSpace(t.GivenName + " " + t.FamilyName)} {
+		if name != "" {
+			return name
+		}
+	}
+	return t.Email
+}
@@ -0,0 +1,156 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// jsonWebKey is a public key published by the provider, only the members
+// needed for RSA and EC signature keys are decoded
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type keySet struct {
+	keys map[string]crypto.PublicKey
+	// only is set when the provider publishes a single key, tokens without a kid can use it
+	only crypto.PublicKey
+}
+
+func parseKeySet(keys []jsonWebKey) (*keySet, error) {
+	set := &keySet{keys: map[string]crypto.PublicKey{}}
+	for _, k := range keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		publicKey, err := k.publicKey()
+		if err != nil {
+			// keys of types we do not support are skipped, the provider may
+			// still sign our tokens with a supported one
+			continue
+		}
+		set.keys[k.Kid] = publicKey
+	}
+	if len(set.keys) == 0 {
+		return nil, fmt.Errorf("oidc: key set does not contain any usable signing keys")
+	}
+	if len(set.keys) == 1 {
+		for _, key := range set.keys {
+			set.only = key
+		}
+	}
+	return set, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("oidc: RSA exponent of key %q is too large", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("oidc: point of key %q is not on curve %s", k.Kid, k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// fetchKeys downloads the key set from the jwks_uri of the provider
+func (c *Client) fetchKeys(ctx context.Context) (*keySet, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, c.httpClient, c.metadata.JWKSURI, &document); err != nil {
+		return nil, fmt.Errorf("oidc: failed to fetch key set: %w", err)
+	}
+	return parseKeySet(document.Keys)
+}
+
+// signingKey returns the key with the given kid, the key set is fetched again
+// when the kid is unknown since the provider may have rotated its keys
+func (c *Client) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys != nil {
+		if key := c.keys.lookup(kid); key != nil {
+			return key, nil
+		}
+		if time.Since(c.keysFetchedAt) < jwksRefreshInterval {
+			return nil, fmt.Errorf("oidc: no signing key with kid %q", kid)
+		}
+	}
+
+	keys, err := c.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	c.keysFetchedAt = time.Now()
+
+	if key := c.keys.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: no signing key with kid %q", kid)
+}
+
+func (s *keySet) lookup(kid string) crypto.PublicKey {
+	if kid == "" {
+		return s.only
+	}
+	return s.keys[kid]
+}
@@ -0,0 +1,240 @@
+// Package oidc implements the relying party side of OpenID Connect, that is
+// provider discovery, the authorization code flow with PKCE and validation of
+// the ID tokens returned by the provider.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+// clockSkew is how far the clocks of the provider and this server may drift apart
+const clockSkew = 2 * time.Minute
+
+// jwksRefreshInterval limits how often the keys are fetched again when a token
+// is signed with a key we have not seen yet
+const jwksRefreshInterval = time.Minute
+
+// Config holds the client registration at the identity provider
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+// ProviderMetadata is the subset of the discovery document used by the client
+type ProviderMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	UserinfoEndpoint              string   `json:"userinfo_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// TokenResponse is returned by the token endpoint of the provider
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// TokenError is the error returned by the token endpoint of the provider
+type TokenError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oidc: token request failed with %s: %s", e.Code, e.Description)
+	}
+	return fmt.Sprintf("oidc: token request failed with status %d %s", e.StatusCode, e.Code)
+}
+
+// Client talks to a single identity provider
+type Client struct {
+	config     Config
+	metadata   ProviderMetadata
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	keys          *keySet
+	keysFetchedAt time.Time
+}
+
+// Discover fetches the discovery document of the issuer
+func Discover(ctx context.Context, httpClient *http.Client, issuerURL string) (*ProviderMetadata, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	wellKnown := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	var metadata ProviderMetadata
+	if err := getJSON(ctx, httpClient, wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: failed to fetch discovery document: %w", err)
+	}
+
+	// the issuer in the document must be the one we asked for, see
+	// OpenID Connect Discovery 1.0 section 4.3
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return nil, fmt.Errorf("oidc: issuer %q in discovery document does not match %q", metadata.Issuer, issuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery document of %q is missing required endpoints", issuerURL)
+	}
+	return &metadata, nil
+}
+
+// NewClient discovers the provider and returns a client for it
+func NewClient(ctx context.Context, config Config) (*Client, error) {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	metadata, err := Discover(ctx, httpClient, config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		config:     config,
+		metadata:   *metadata,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Metadata returns the discovery document of the provider
+func (c *Client) Metadata() ProviderMetadata {
+	return c.metadata
+}
+
+// AuthCodeURL builds the URL of the provider the user is sent to for login
+func (c *Client) AuthCodeURL(state, nonce, codeChallenge string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.config.ClientID},
+		"redirect_uri":          {c.config.RedirectURL},
+		"scope":                 {strings.Join(c.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(c.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return c.metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades the authorization code for tokens at the token endpoint
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"client_id":     {c.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		// client_secret_basic, the credentials are form encoded first as per RFC 6749 section 2.3.1
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: failed to read token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{StatusCode: res.StatusCode}
+		_ = json.Unmarshal(body, tokenErr)
+		return nil, tokenErr
+	}
+
+	var tokens TokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: failed to parse token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc: token response did not include an id_token")
+	}
+	return &tokens, nil
+}
+
+// NewPKCEVerifier creates a random code verifier for the PKCE extension
+func NewPKCEVerifier() (string, error) {
+	return RandomString(32)
+}
+
+// PKCEChallenge derives the S256 code challenge from the code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RandomString returns n random bytes encoded as URL safe base64, suitable for state and nonce values
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, endpoint string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, endpoint)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(target)
+}
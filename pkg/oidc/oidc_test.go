@@ -0,0 +1,136 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-malawi/qatarina/pkg/oidc"
+	"github.com/golang-malawi/qatarina/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://qatarina.test/v1/auth/oidc/callback"
+
+func newClient(t *testing.T, provider *oidctest.Provider) *oidc.Client {
+	t.Helper()
+	client, err := oidc.NewClient(context.Background(), oidc.Config{
+		IssuerURL:    provider.URL,
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+// authorize follows the authorization URL and returns the code and state passed back to the redirect URL
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	httpClient := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := httpClient.Get(authURL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider := oidctest.NewProvider("qatarina", "secret")
+	defer provider.Close()
+	provider.SetUser(map[string]any{
+		"sub":            "user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane Banda",
+		"groups":         []string{"qa-leads", "staff"},
+	})
+
+	client := newClient(t, provider)
+	verifier, err := oidc.NewPKCEVerifier()
+	require.NoError(t, err)
+
+	code, state := authorize(t, client.AuthCodeURL("state-1", "nonce-1", oidc.PKCEChallenge(verifier)))
+	assert.Equal(t, "state-1", state)
+
+	tokens, err := client.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	idToken, err := client.VerifyIDToken(context.Background(), tokens.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", idToken.Subject)
+	assert.Equal(t, "jane@example.com", idToken.Email)
+	assert.True(t, idToken.EmailVerified)
+	assert.Equal(t, "Jane Banda", idToken.DisplayName())
+	assert.Equal(t, []string{"qa-leads", "staff"}, idToken.StringsClaim("groups"))
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	provider := oidctest.NewProvider("qatarina", "secret")
+	defer provider.Close()
+	provider.SetUser(map[string]any{"sub": "user-1"})
+
+	client := newClient(t, provider)
+	verifier, err := oidc.NewPKCEVerifier()
+	require.NoError(t, err)
+
+	code, _ := authorize(t, client.AuthCodeURL("state", "nonce", oidc.PKCEChallenge(verifier)))
+	_, err = client.Exchange(context.Background(), code, "not-the-verifier")
+
+	var tokenErr *oidc.TokenError
+	require.True(t, errors.As(err, &tokenErr))
+	assert.Equal(t, "invalid_grant", tokenErr.Code)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider := oidctest.NewProvider("qatarina", "secret")
+	defer provider.Close()
+	client := newClient(t, provider)
+
+	other := oidctest.NewProvider("qatarina", "secret")
+	defer other.Close()
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		valid bool
+	}{
+		{"valid", provider.SignIDToken(map[string]any{"sub": "1", "nonce": "n"}), "n", true},
+		{"wrong nonce", provider.SignIDToken(map[string]any{"sub": "1", "nonce": "other"}), "n", false},
+		{"wrong audience", provider.SignIDToken(map[string]any{"sub": "1", "nonce": "n", "aud": "someone-else"}), "n", false},
+		{"wrong issuer", provider.SignIDToken(map[string]any{"sub": "1", "nonce": "n", "iss": "https://evil.example.com"}), "n", false},
+		{"expired", provider.SignIDToken(map[string]any{"sub": "1", "nonce": "n", "exp": time.Now().Add(-time.Hour).Unix()}), "n", false},
+		{"missing subject", provider.SignIDToken(map[string]any{"nonce": "n"}), "n", false},
+		{"signed by another provider", other.SignIDToken(map[string]any{"sub": "1", "nonce": "n", "iss": provider.URL}), "n", false},
+		{"malformed", "not.a.token", "n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.VerifyIDToken(context.Background(), tt.token, tt.nonce)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	provider := oidctest.NewProvider("qatarina", "secret")
+	defer provider.Close()
+
+	_, err := oidc.Discover(context.Background(), nil, provider.URL+"/tenant")
+	assert.Error(t, err)
+}
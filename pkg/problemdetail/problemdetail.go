@@ -78,3 +78,21 @@ func ValidationErrors(ctx *fiber.Ctx, message string, validationErrors any) erro
 		Context: validationErrors,
 	})
 }
+
+func Forbidden(ctx *fiber.Ctx, message string) error {
+	return ctx.Status(http.StatusForbidden).JSON(ProblemDetail{
+		Type:    "problemdetail.example.com/http/types/Forbidden",
+		Title:   "Forbidden",
+		Detail:  message,
+		Context: nil,
+	})
+}
+
+func TooManyRequests(ctx *fiber.Ctx, message string) error {
+	return ctx.Status(http.StatusTooManyRequests).JSON(ProblemDetail{
+		Type:    "problemdetail.example.com/http/types/TooManyRequests",
+		Title:   "Too Many Requests",
+		Detail:  message,
+		Context: nil,
+	})
+}
@@ -0,0 +1,415 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidFilter = errors.New("scim: invalid filter")
+
+// Attributes exposes the attributes of a resource to filters. Paths are
+// lower case, sub-attributes are joined with a dot as in "name.givenname".
+type Attributes interface {
+	// Values returns the values of a simple attribute as strings
+	Values(path string) []string
+	// Complex returns the elements of a multi-valued complex attribute like emails
+	Complex(path string) []Attributes
+}
+
+// AttributeMap implements Attributes, the values are a string, a []string or
+// an []AttributeMap for multi-valued complex attributes
+type AttributeMap map[string]any
+
+func (m AttributeMap) Values(path string) []string {
+	switch v := m[path].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	// emails.value collects the value of every email
+	parent, sub, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, element := range m.Complex(parent) {
+		values = append(values, element.Values(sub)...)
+	}
+	return values
+}
+
+func (m AttributeMap) Complex(path string) []Attributes {
+	elements, _ := m[path].([]AttributeMap)
+	attrs := make([]Attributes, 0, len(elements))
+	for _, element := range elements {
+		attrs = append(attrs, element)
+	}
+	return attrs
+}
+
+// Filter is a parsed filter expression of RFC 7644 section 3.4.2.2
+type Filter interface {
+	Matches(attrs Attributes) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f logicalFilter) Matches(attrs Attributes) bool {
+	if f.and {
+		return f.left.Matches(attrs) && f.right.Matches(attrs)
+	}
+	return f.left.Matches(attrs) || f.right.Matches(attrs)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f notFilter) Matches(attrs Attributes) bool {
+	return !f.filter.Matches(attrs)
+}
+
+// valuePathFilter matches when an element of a complex attribute matches, as in emails[type eq "work"]
+type valuePathFilter struct {
+	attribute string
+	filter    Filter
+}
+
+func (f valuePathFilter) Matches(attrs Attributes) bool {
+	for _, element := range attrs.Complex(f.attribute) {
+		if f.filter.Matches(element) {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	attribute string
+	op        string
+	value     string
+}
+
+func (f compareFilter) Matches(attrs Attributes) bool {
+	values := attrs.Values(f.attribute)
+	if f.op == "pr" {
+		for _, v := range values {
+			if v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if f.op == "ne" {
+		return !(compareFilter{f.attribute, "eq", f.value}).Matches(attrs)
+	}
+	// string attributes of the core schemas are case insensitive, except ids
+	// which are compared the same way since they are numeric here
+	want := strings.ToLower(f.value)
+	for _, v := range values {
+		got := strings.ToLower(v)
+		var ok bool
+		switch f.op {
+		case "eq":
+			ok = got == want
+		case "co":
+			ok = strings.Contains(got, want)
+		case "sw":
+			ok = strings.HasPrefix(got, want)
+		case "ew":
+			ok = strings.HasSuffix(got, want)
+		case "gt":
+			ok = got > want
+		case "ge":
+			ok = got >= want
+		case "lt":
+			ok = got < want
+		case "le":
+			ok = got <= want
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+var compareOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// ParseFilter parses a filter like `userName eq "bjensen" and not (active eq false)`
+func ParseFilter(s string) (Filter, error) {
+	p := &filterParser{tokens: tokenize(s)}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidFilter, tok.text)
+	}
+	return filter, nil
+}
+
+// Path is a parsed PATCH path like members[value eq "2"] or name.givenName
+type Path struct {
+	// Attribute is the lower case attribute, with its sub-attribute when the
+	// path has no value filter
+	Attribute string
+	// Filter selects elements of a multi-valued attribute, nil when absent
+	Filter Filter
+	// SubAttribute is the lower case sub-attribute after a value filter
+	SubAttribute string
+}
+
+// ParsePath parses the path of a PATCH operation
+func ParsePath(s string) (*Path, error) {
+	attribute, rest, hasFilter := strings.Cut(s, "[")
+	path := &Path{Attribute: normalizeAttribute(attribute)}
+	if path.Attribute == "" {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidFilter)
+	}
+	if !hasFilter {
+		return path, nil
+	}
+	expression, sub, ok := strings.Cut(rest, "]")
+	if !ok {
+		return nil, fmt.Errorf("%w: missing ] in path %q", ErrInvalidFilter, s)
+	}
+	filter, err := ParseFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	path.Filter = filter
+	if sub != "" {
+		if !strings.HasPrefix(sub, ".") {
+			return nil, fmt.Errorf("%w: unexpected %q after ] in path", ErrInvalidFilter, sub)
+		}
+		path.SubAttribute = strings.ToLower(sub[1:])
+	}
+	return path, nil
+}
+
+// normalizeAttribute lower cases the attribute and strips the schema URI of
+// fully qualified core attributes
+func normalizeAttribute(attribute string) string {
+	attribute = strings.ToLower(strings.TrimSpace(attribute))
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(attribute, prefix) {
+			return strings.TrimPrefix(attribute, prefix)
+		}
+	}
+	return attribute
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenError
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokenLBracket, "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokenRBracket, "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return append(tokens, token{tokenError, "unterminated string"}, token{tokenEOF, "end of filter"})
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return append(tokens, token{tokenError, "invalid string " + s[i:end+1]}, token{tokenEOF, "end of filter"})
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, s[i:end]})
+			i = end
+		}
+	}
+	return append(tokens, token{tokenEOF, "end of filter"})
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokenWord && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	if tok := p.next(); tok.kind != kind {
+		return fmt.Errorf("%w: expected %s got %q", ErrInvalidFilter, text, tok.text)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.keyword("not") {
+		filter, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{filter}, nil
+	}
+	if p.peek().kind == tokenLParen {
+		return p.parseGroup()
+	}
+	return p.parseAttributeExpression()
+}
+
+func (p *filterParser) parseGroup() (Filter, error) {
+	if err := p.expect(tokenLParen, "("); err != nil {
+		return nil, err
+	}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenRParen, ")"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (p *filterParser) parseAttributeExpression() (Filter, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenWord:
+	case tokenError:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, tok.text)
+	default:
+		return nil, fmt.Errorf("%w: expected attribute got %q", ErrInvalidFilter, tok.text)
+	}
+	attribute := normalizeAttribute(tok.text)
+
+	if p.peek().kind == tokenLBracket {
+		p.next()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRBracket, "]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{attribute: attribute, filter: filter}, nil
+	}
+
+	opToken := p.next()
+	op := strings.ToLower(opToken.text)
+	if opToken.kind != tokenWord || (op != "pr" && !compareOperators[op]) {
+		return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, opToken.text)
+	}
+	if op == "pr" {
+		return compareFilter{attribute: attribute, op: op}, nil
+	}
+
+	value := p.next()
+	switch value.kind {
+	case tokenString:
+		return compareFilter{attribute: attribute, op: op, value: value.text}, nil
+	case tokenWord:
+		// true, false, null and numbers are compared by their text
+		if strings.EqualFold(value.text, "null") {
+			return nil, fmt.Errorf("%w: compare with null is not supported, use pr", ErrInvalidFilter)
+		}
+		return compareFilter{attribute: attribute, op: op, value: strings.ToLower(value.text)}, nil
+	case tokenError:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, value.text)
+	}
+	return nil, fmt.Errorf("%w: expected value got %q", ErrInvalidFilter, value.text)
+}
@@ -0,0 +1,118 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bjensen = AttributeMap{
+	"id":             "7",
+	"username":       "bjensen@example.com",
+	"externalid":     "00u1",
+	"name.givenname": "Barbara",
+	"active":         "true",
+	"emails": []AttributeMap{
+		{"value": "bjensen@example.com", "type": "work"},
+		{"value": "babs@example.org", "type": "home"},
+	},
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{`userName eq "bjensen@example.com"`, true},
+		{`USERNAME Eq "BJensen@Example.com"`, true},
+		{`userName ne "bjensen@example.com"`, false},
+		{`userName sw "bjen"`, true},
+		{`userName ew "example.com"`, true},
+		{`name.givenName co "arb"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen@example.com"`, true},
+		{`externalId pr`, true},
+		{`title pr`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`emails.value eq "babs@example.org"`, true},
+		{`emails[type eq "work" and value co "example.com"]`, true},
+		{`emails[type eq "work" and value co "example.org"]`, false},
+		{`id eq "7" and (active eq false or userName sw "b")`, true},
+		{`not (userName eq "bjensen@example.com")`, false},
+		{`userName eq "nobody" or externalId eq "00u1"`, true},
+		{`id gt "6" and id le "7"`, true},
+		{`userName eq "quote\"d"`, false},
+	}
+
+	for _, tt := range tests {
+		filter, err := ParseFilter(tt.filter)
+		require.NoError(t, err, tt.filter)
+		assert.Equal(t, tt.matches, filter.Matches(bjensen), tt.filter)
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName is "x"`,
+		`userName eq`,
+		`userName eq "unterminated`,
+		`(userName eq "x"`,
+		`userName eq "x" extra`,
+		`emails[type eq "work"`,
+		`userName eq null`,
+	} {
+		_, err := ParseFilter(filter)
+		assert.ErrorIs(t, err, ErrInvalidFilter, filter)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath(`members[value eq "2"]`)
+	require.NoError(t, err)
+	assert.Equal(t, "members", path.Attribute)
+	assert.True(t, path.Filter.Matches(AttributeMap{"value": "2"}))
+	assert.False(t, path.Filter.Matches(AttributeMap{"value": "3"}))
+
+	path, err = ParsePath(`emails[type eq "work"].value`)
+	require.NoError(t, err)
+	assert.Equal(t, "emails", path.Attribute)
+	assert.Equal(t, "value", path.SubAttribute)
+
+	path, err = ParsePath(`name.givenName`)
+	require.NoError(t, err)
+	assert.Equal(t, "name.givenname", path.Attribute)
+	assert.Nil(t, path.Filter)
+
+	_, err = ParsePath(`members[value eq "2"`)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestNewListResponse(t *testing.T) {
+	resources := []string{"a", "b", "c"}
+
+	list := NewListResponse(resources, 2, 1)
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	assert.Equal(t, []any{"b"}, list.Resources)
+
+	list = NewListResponse(resources, 0, -1)
+	assert.Equal(t, 1, list.StartIndex)
+	assert.Len(t, list.Resources, 3)
+
+	list = NewListResponse(resources, 5, 10)
+	assert.Equal(t, []any{}, list.Resources)
+}
+
+func TestParseBool(t *testing.T) {
+	for raw, want := range map[string]bool{`true`: true, `false`: false, `"False"`: false, `"True"`: true} {
+		got, err := ParseBool(json.RawMessage(raw))
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	_, err := ParseBool(json.RawMessage(`"maybe"`))
+	assert.Error(t, err)
+}
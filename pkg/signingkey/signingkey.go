@@ -0,0 +1,206 @@
+// Package signingkey generates the asymmetric keys access tokens are signed
+// with and publishes their public halves as a JSON Web Key Set (RFC 7517) so
+// other services can verify the tokens without sharing a secret.
+package signingkey
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms, named as in the alg header of a JWT
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// rsaKeySize is the modulus size of generated RSA keys
+const rsaKeySize = 3072
+
+var ErrUnsupportedAlgorithm = errors.New("signingkey: unsupported algorithm, use RS256 or EdDSA")
+
+// Algorithms lists the supported signing algorithms
+func Algorithms() []string {
+	return []string{RS256, EdDSA}
+}
+
+// Key is a private signing key with its key ID
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// Generate creates a new key for the algorithm, the key ID is the JWK
+// thumbprint of the public key
+func Generate(algorithm string) (*Key, error) {
+	var privateKey crypto.Signer
+	var err error
+	switch algorithm {
+	case RS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case EdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signingkey: failed to generate key: %w", err)
+	}
+	return newKey(algorithm, privateKey)
+}
+
+func newKey(algorithm string, privateKey crypto.Signer) (*Key, error) {
+	key := &Key{Algorithm: algorithm, PrivateKey: privateKey}
+	thumbprint, err := key.PublicJWK().Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = thumbprint
+	return key, nil
+}
+
+// ParsePEM reads a key stored with MarshalPEM
+func ParsePEM(algorithm string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("signingkey: no PKCS #8 private key found in PEM data")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("signingkey: failed to parse private key: %w", err)
+	}
+
+	var privateKey crypto.Signer
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != RS256 {
+			return nil, fmt.Errorf("signingkey: RSA key cannot be used with %s", algorithm)
+		}
+		privateKey = k
+	case ed25519.PrivateKey:
+		if algorithm != EdDSA {
+			return nil, fmt.Errorf("signingkey: Ed25519 key cannot be used with %s", algorithm)
+		}
+		privateKey = k
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return newKey(algorithm, privateKey)
+}
+
+// MarshalPEM encodes the private key as PKCS #8 PEM
+func (k *Key) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("signingkey: failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// SigningMethod returns the JWT signing method of the key
+func (k *Key) SigningMethod() jwt.SigningMethod {
+	return SigningMethod(k.Algorithm)
+}
+
+// PublicKey returns the public half of the key
+func (k *Key) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+// Sign signs the claims with the key and sets the kid header
+func (k *Key) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.SigningMethod(), claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.PrivateKey)
+}
+
+// SigningMethod returns the JWT signing method for the algorithm, or nil when it is not supported
+func SigningMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case RS256:
+		return jwt.SigningMethodRS256
+	case EdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+// JWK is the public part of a signing key as a JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	// RSA members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP members, RFC 8037
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set as served on /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public key as a JWK
+func (k *Key) PublicJWK() JWK {
+	jwk := JWK{Use: "sig", Kid: k.ID, Alg: k.Algorithm}
+	switch publicKey := k.PublicKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(publicKey.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(publicKey)
+	}
+	return jwk
+}
+
+// Thumbprint computes the RFC 7638 SHA-256 thumbprint of the key
+func (j JWK) Thumbprint() (string, error) {
+	// the required members in lexicographic order, json.Marshal keeps struct field order
+	var members any
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return encode(sum[:]), nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package signingkey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSignAndVerify(t *testing.T) {
+	for _, alg := range Algorithms() {
+		t.Run(alg, func(t *testing.T) {
+			key, err := Generate(alg)
+			require.NoError(t, err)
+
+			signed, err := key.Sign(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+			require.NoError(t, err)
+
+			token, err := jwt.Parse(signed, func(t *jwt.Token) (interface{}, error) {
+				return key.PublicKey(), nil
+			}, jwt.WithValidMethods([]string{alg}))
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, token.Header["kid"])
+		})
+	}
+}
+
+func TestPEMRoundTrip(t *testing.T) {
+	key, err := Generate(EdDSA)
+	require.NoError(t, err)
+
+	data, err := key.MarshalPEM()
+	require.NoError(t, err)
+
+	parsed, err := ParsePEM(EdDSA, data)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, parsed.ID)
+
+	_, err = ParsePEM(RS256, data)
+	assert.Error(t, err, "an Ed25519 key must not be usable with RS256")
+}
+
+func TestGenerateUnsupportedAlgorithm(t *testing.T) {
+	_, err := Generate("HS256")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+// RFC 7638 section 3.1 example
+func TestThumbprint(t *testing.T) {
+	jwk := JWK{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbO" +
+			"pbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E: "AQAB",
+	}
+	thumbprint, err := jwk.Thumbprint()
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
@@ -9,6 +9,19 @@ OR last_name ILIKE '%' || $1 || '%'
 OR display_name ILIKE '%' || $1 || '%'
 OR email ILIKE '%' || $1 || '%';
 
+-- name: ListUsersByOrg :many
+SELECT * FROM users u
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.user_id = u.id AND m.org_id = $1 AND m.removed_at IS NULL)
+ORDER BY created_at DESC;
+
+-- name: SearchUsersByOrg :many
+SELECT * FROM users u
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.user_id = u.id AND m.org_id = $1 AND m.removed_at IS NULL)
+AND (first_name ILIKE '%' || $2 || '%'
+OR last_name ILIKE '%' || $2 || '%'
+OR display_name ILIKE '%' || $2 || '%'
+OR email ILIKE '%' || $2 || '%');
+
 -- name: GetUser :one
 SELECT * FROM users WHERE id = $1;
 
@@ -16,7 +29,7 @@ SELECT * FROM users WHERE id = $1;
 SELECT EXISTS(SELECT id FROM users WHERE id = $1);
 
 -- name: FindUserLoginByEmail :one
-SELECT id, display_name, email, password, last_login_at FROM users WHERE email = $1 AND is_activated AND deleted_at IS NULL;
+SELECT id, display_name, email, password, last_login_at, is_verified FROM users WHERE email = $1 AND is_activated AND deleted_at IS NULL;
 
 -- name: UpdateUserLastLogin :execrows
 UPDATE users SET last_login_at = $1 WHERE id = $2 AND is_activated AND deleted_at IS NULL;
@@ -32,10 +45,6 @@ UPDATE users
 SET password = $2, updated_at = $3
 WHERE id = $1;
 
--- name: CreateInvite :exec
-INSERT INTO invites (sender_email, receiver_email, token, expires_at)
-VALUES ($1, $2, $3, $4);
-
 -- name: CreateUser :one
 INSERT INTO users (
     first_name, last_name, display_name, email, password, phone,
@@ -59,7 +68,7 @@ SELECT * FROM projects ORDER BY created_at DESC;
 
 -- name: SearchProject :many
 SELECT * FROM projects
-WHERE title ILIKE '%' || $1 || '%';
+WHERE org_id = $1 AND title ILIKE '%' || $2 || '%';
 
 -- name: GetProject :one
 SELECT * FROM projects WHERE id = $1;
@@ -87,13 +96,15 @@ INSERT INTO projects (
     title, code, description, version, is_active, is_public, website_url,
     github_url, trello_url, jira_url, monday_url,
     owner_user_id, created_at, updated_at, deleted_at,
-    automated_testing_enabled, supported_runners
+    automated_testing_enabled, supported_runners, org_id,
+    parent_project_id, testcase_template
 )
 VALUES (
     $1, $2, $3, $4, $5, $6, $7,
     $8, $9, $10, $11,
     $12, $13, $14, $15,
-    $16, $17
+    $16, $17, $18,
+    $19, $20
 )
 RETURNING id;
 
@@ -127,7 +138,9 @@ SET
 WHERE id = $1;
 
 -- name: ListTestCases :many
-SELECT * FROM test_cases ORDER BY created_at DESC;
+SELECT * FROM test_cases
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC;
 
 -- name: GetTestCase :one
 SELECT * FROM test_cases WHERE id = $1;
@@ -200,7 +213,9 @@ WHERE tr.test_plan_id = $1
 GROUP BY tc.id, tp.id, tp.description;
 
 -- name: ListTestCasesByCreator :many
-SELECT * FROM test_cases WHERE created_by_id = $1;
+SELECT * FROM test_cases
+WHERE created_by_id = $1
+AND project_id IN (SELECT id FROM projects WHERE org_id = $2);
 
 -- name: ListTestCasesByAssignedUser :many
 SELECT
@@ -250,8 +265,10 @@ SELECT EXISTS(
 
 -- name: SearchTestCases :many
 SELECT * FROM test_cases
-WHERE title ILIKE '%' || $1 || '%'
-OR code ILIKE '%' || $1 || '%';
+WHERE project_id = $1
+AND (title ILIKE '%' || $2 || '%'
+OR code ILIKE '%' || $2 || '%'
+OR id IN (SELECT test_case_id FROM test_case_code_aliases WHERE code ILIKE '%' || $2 || '%'));
 
 -- name: DeleteTestCase :execrows
 DELETE FROM test_cases WHERE id = $1;
@@ -328,6 +345,7 @@ INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
 LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
 WHERE pc.assigned_to_id = sqlc.arg(user_id)
   AND (sqlc.arg(include_closed)::bool = true OR COALESCE(tr.is_closed, false) = false)
+  AND tc.project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
 GROUP BY tc.id
 ORDER BY tc.created_at DESC
 LIMIT sqlc.arg(row_limit)::int OFFSET sqlc.arg(row_offset)::int;
@@ -340,6 +358,7 @@ FROM (
   INNER JOIN test_plan_cases pc ON pc.test_case_id = tc.id
   LEFT JOIN test_runs tr ON tr.test_case_id = tc.id AND tr.test_plan_id = pc.test_plan_id
   WHERE pc.assigned_to_id = sqlc.arg(user_id)
+    AND tc.project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
   GROUP BY tc.id
   HAVING sqlc.arg(include_closed)::bool = true OR COALESCE(BOOL_OR(tr.is_closed), false)::boolean = false
 ) sub;
@@ -400,7 +419,9 @@ SELECT * FROM test_cases WHERE project_id = $1 AND suggested = $2;
 -- name: UpdateSuggestedFlag :exec
 UPDATE test_cases SET suggested = $2 WHERE id = $1;
 -- name: ListTestPlans :many
-SELECT * FROM test_plans ORDER BY created_at DESC;
+SELECT * FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC;
 
 -- name: ListTestPlansByProject :many
 SELECT * FROM test_plans WHERE project_id = $1;
@@ -465,7 +486,9 @@ VALUES (
 RETURNING id;
 
 -- name: ListTestRuns :many
-SELECT * FROM test_runs ORDER BY created_at DESC;
+SELECT * FROM test_runs
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
+ORDER BY created_at DESC;
 
 -- name: ListTestRunsByPlan :many
 SELECT
@@ -486,6 +509,9 @@ SELECT
     tr.created_at,
     tr.updated_at,
     tr.environment_id,
+    tr.test_case_revision,
+    tr.data_row,
+    tr.data_values,
     tc.title AS test_case_title,
     u.display_name AS executed_by
 FROM test_runs tr
@@ -560,7 +586,7 @@ INNER JOIN users u On u.id = project_testers.user_id
 WHERE project_testers.role ILIKE '%' || $1 || '%';
 
 -- name: DeleteProjectTester :execrows
-DELETE FROM project_testers WHERE user_id = $1;
+DELETE FROM project_testers WHERE project_id = $1 AND user_id = $2;
 
 -- name: GetTesterByID :one
 SELECT
@@ -575,7 +601,7 @@ INNER JOIN projects p ON p.id = pt.project_id
 WHERE pt.user_id = $1;
 
 -- name: UpdateProjectTesterRole :execrows
-UPDATE project_testers SET role = $2 WHERE user_id = $1;
+UPDATE project_testers SET role = $3, updated_at = now() WHERE project_id = $1 AND user_id = $2;
 
 -- name: GetAllProjectTesters :many
 SELECT
@@ -587,6 +613,7 @@ SELECT
 FROM project_testers pt
 INNER JOIN users u ON u.id = pt.user_id
 INNER JOIN projects p ON p.id = pt.project_id
+WHERE p.org_id = $1
 ORDER BY pt.created_at DESC;
 
 -- name: GetTestersByProject :many
@@ -614,6 +641,7 @@ WHERE id = $1;
 
 -- name: GetAllModules :many
 SELECT * FROM modules
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = $1)
 ORDER BY created_at DESC;
 
 -- name: UpdateProjectModule :exec
@@ -635,6 +663,7 @@ SELECT * FROM pages WHERE id = $1;
 
 -- name: GetAllPages :many
 SELECT * FROM pages
+WHERE org_id = $1
 ORDER BY created_at DESC;
 
 -- name: UpdatePage :exec
@@ -645,16 +674,24 @@ WHERE id = $1;
 DELETE FROM pages WHERE id = $1;
 
 -- name: GetProjectCount :one
-SELECT COUNT(*) FROM projects;
+SELECT COUNT(*) FROM projects
+WHERE org_id = sqlc.arg(org_id)
+AND (sqlc.arg(project_ids)::int[] IS NULL OR id = ANY(sqlc.arg(project_ids)::int[]));
 
 -- name: GetTesterCount :one
-SELECT COUNT(DISTINCT user_id) FROM project_testers WHERE is_active = true;
+SELECT COUNT(DISTINCT pt.user_id)
+FROM project_testers pt
+INNER JOIN projects p ON p.id = pt.project_id
+WHERE pt.is_active = true AND p.org_id = sqlc.arg(org_id)
+AND (sqlc.arg(project_ids)::int[] IS NULL OR p.id = ANY(sqlc.arg(project_ids)::int[]));
 
 -- name: GetTesterCountByProject :one
 SELECT COUNT(DISTINCT user_id) FROM project_testers WHERE project_id = $1 AND is_active = true;
 
 -- name: GetTestCaseCount :one
-SELECT COUNT(*) FROM test_cases;
+SELECT COUNT(*) FROM test_cases
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
+AND (sqlc.arg(project_ids)::int[] IS NULL OR project_id = ANY(sqlc.arg(project_ids)::int[]));
 
 -- name: GetTestCasesWithTestersByPlan :many
 SELECT
@@ -668,17 +705,23 @@ WHERE tr.test_plan_id = $1
 GROUP BY tc.id, tc.title, tr.test_plan_id;
 
 -- name: GetTestPlanCount :one
-SELECT COUNT(*) FROM test_plans;
+SELECT COUNT(*) FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
+AND (sqlc.arg(project_ids)::int[] IS NULL OR project_id = ANY(sqlc.arg(project_ids)::int[]));
 
 -- name: GetTestPlanStatusRatio :one
 SELECT
 COUNT(*) FILTER (WHERE is_complete = true) AS closed,
 COUNT(*) FILTER (WHERE is_complete = false) AS open
-FROM test_plans;
+FROM test_plans
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
+AND (sqlc.arg(project_ids)::int[] IS NULL OR project_id = ANY(sqlc.arg(project_ids)::int[]));
 
 -- name: GetRecentProjects :many
 SELECT id, title AS name, updated_at
 FROM projects
+WHERE org_id = sqlc.arg(org_id)
+AND (sqlc.arg(project_ids)::int[] IS NULL OR id = ANY(sqlc.arg(project_ids)::int[]))
 ORDER BY updated_at DESC
 LIMIT 5;
 
@@ -726,25 +769,32 @@ SELECT
 FROM test_run_results trr
 INNER JOIN test_runs tr ON tr.id = trr.test_run_id
 WHERE trr.executed_by = $1
+AND tr.project_id IN (SELECT id FROM projects WHERE org_id = $2)
 GROUP BY tr.test_case_id;
 
 -- name: CreateOrg :one
-INSERT INTO orgs (  name, address, country, github_url, website_url, created_by_id, created_at, updated_at
-) VALUES ($1, $2, $3, $4, $5, $6, now(), now())
-RETURNING id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at;
+INSERT INTO orgs (  name, address, country, github_url, website_url, created_by_id, require_two_factor, created_at, updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now())
+RETURNING id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor;
 
 -- name: GetOrgByID :one
-SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at
+SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor
 FROM orgs
 WHERE id = $1;
 
 -- name: ListOrgs :many
-SELECT id, name, address, country, github_url, website_url, created_by_id,  created_at, updated_at
+SELECT id, name, address, country, github_url, website_url, created_by_id,  created_at, updated_at, require_two_factor
 FROM orgs
 ORDER BY name;
 
+-- name: ListOrgsByMember :many
+SELECT id, name, address, country, github_url, website_url, created_by_id, created_at, updated_at, require_two_factor
+FROM orgs o
+WHERE EXISTS (SELECT 1 FROM org_members m WHERE m.org_id = o.id AND m.user_id = $1 AND m.removed_at IS NULL)
+ORDER BY name;
+
 -- name: UpdateOrg :exec
-UPDATE orgs SET name = $2, address = $3, country = $4, github_url = $5, website_url = $6, updated_at = now()
+UPDATE orgs SET name = $2, address = $3, country = $4, github_url = $5, website_url = $6, require_two_factor = $7, updated_at = now()
 WHERE id = $1;
 
 -- name: DeleteOrg :exec
@@ -858,3 +908,774 @@ FROM test_plan_comments c
 JOIN test_plans tp ON tp.id = c.test_plan_id
 WHERE c.id = $1
 RETURNING id;
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+VALUES ($1, $2, $3, now());
+
+-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+RETURNING user_id;
+
+-- name: InvalidatePasswordResetTokens :execrows
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO refresh_tokens (
+    user_id, family_id, token_hash, user_agent, ip_address, expires_at, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, now());
+
+-- name: GetRefreshTokenByHash :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
+
+-- name: MarkRefreshTokenUsed :execrows
+UPDATE refresh_tokens
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL;
+
+-- name: RevokeAllUserRefreshTokens :execrows
+UPDATE refresh_tokens
+SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: ListUserSessions :many
+SELECT
+    r.family_id, r.user_agent, r.ip_address, r.created_at, r.expires_at,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = r.family_id)::timestamp AS started_at
+FROM refresh_tokens r
+WHERE r.user_id = $1 AND r.used_at IS NULL AND r.revoked_at IS NULL AND r.expires_at > now()
+ORDER BY r.created_at DESC;
+
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (
+    user_id, created_by_id, name, token_prefix, token_hash, scopes, project_id, expires_at, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
+RETURNING *;
+
+-- name: ListApiTokensByCreator :many
+SELECT * FROM api_tokens
+WHERE created_by_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: GetApiTokenByHash :one
+SELECT t.id, t.user_id, t.scopes, t.project_id, u.email, u.display_name
+FROM api_tokens t
+INNER JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1
+AND t.revoked_at IS NULL
+AND (t.expires_at IS NULL OR t.expires_at > now())
+AND u.is_activated AND u.deleted_at IS NULL;
+
+-- name: TouchApiToken :exec
+UPDATE api_tokens SET last_used_at = now() WHERE id = $1;
+
+-- name: RevokeApiToken :execrows
+UPDATE api_tokens
+SET revoked_at = now()
+WHERE id = $1 AND created_by_id = $2 AND revoked_at IS NULL;
+
+-- name: GetProjectAccess :one
+SELECT
+    p.owner_user_id,
+    p.org_id,
+    COALESCE((SELECT u.is_super_admin FROM users u WHERE u.id = $2), false)::boolean AS is_super_admin,
+    COALESCE((SELECT pt.role FROM project_testers pt WHERE pt.project_id = p.id AND pt.user_id = $2 AND pt.is_active), '')::text AS role
+FROM projects p
+WHERE p.id = $1 AND p.deleted_at IS NULL;
+
+-- name: GetTestCaseProjectID :one
+SELECT project_id FROM test_cases WHERE id = $1;
+
+-- name: GetTestPlanProjectID :one
+SELECT project_id FROM test_plans WHERE id = $1;
+
+-- name: GetTestRunProjectID :one
+SELECT project_id FROM test_runs WHERE id = $1;
+
+-- name: IsUserSuperAdmin :one
+SELECT COALESCE(is_super_admin, false)::boolean AS is_super_admin FROM users WHERE id = $1;
+
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles WHERE scope = $1 AND throttle_key = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, throttle_key, failed_attempts, last_failed_at, updated_at)
+VALUES ($1, $2, 1, now(), now())
+ON CONFLICT (scope, throttle_key) DO UPDATE SET
+    failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < now() - interval '15 minutes' THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    lockouts = CASE
+        WHEN login_throttles.last_failed_at < now() - interval '24 hours' THEN 0
+        ELSE login_throttles.lockouts
+    END,
+    last_failed_at = now(),
+    updated_at = now()
+RETURNING *;
+
+-- name: LockLoginThrottle :exec
+UPDATE login_throttles
+SET locked_until = $2, lockouts = lockouts + 1, failed_attempts = 0, updated_at = now()
+WHERE id = $1;
+
+-- name: ResetLoginThrottle :execrows
+UPDATE login_throttles
+SET failed_attempts = 0, lockouts = 0, locked_until = NULL, updated_at = now()
+WHERE scope = $1 AND throttle_key = $2;
+
+-- name: CreateAuditLog :exec
+INSERT INTO audit_logs (actor_id, action, subject_type, subject_id, ip_address, details, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, now());
+
+-- name: ConfirmUserEmail :execrows
+UPDATE users
+SET is_verified = true, email_confirmed_at = COALESCE(email_confirmed_at, now()), updated_at = now()
+WHERE id = $1 AND email = $2 AND is_activated AND deleted_at IS NULL;
+
+-- name: GetUserIdentity :one
+SELECT i.user_id, u.email, u.display_name
+FROM user_identities i
+INNER JOIN users u ON u.id = i.user_id
+WHERE i.provider = $1 AND i.subject = $2
+AND u.is_activated AND u.deleted_at IS NULL;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+    user_id, provider, subject, email, last_login_at, created_at
+) VALUES ($1, $2, $3, $4, now(), now());
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = $3, last_login_at = now()
+WHERE provider = $1 AND subject = $2;
+
+-- name: AddOrgMember :exec
+INSERT INTO org_members (org_id, user_id, role, created_at)
+VALUES ($1, $2, $3, now());
+
+-- name: GetOrgMemberRole :one
+SELECT role FROM org_members
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL
+LIMIT 1;
+
+-- name: UpsertProjectTester :exec
+INSERT INTO project_testers (
+    project_id, user_id, role, is_active, created_at, updated_at
+) VALUES (
+    $1, $2, $3, true, now(), now()
+)
+ON CONFLICT (project_id, user_id) DO UPDATE
+SET role = EXCLUDED.role, is_active = true, updated_at = now();
+
+-- name: GetTotpCredential :one
+SELECT * FROM totp_credentials WHERE user_id = $1;
+
+-- name: CreatePendingTotpCredential :execrows
+INSERT INTO totp_credentials (user_id, secret, created_at, updated_at)
+VALUES ($1, $2, now(), now())
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = now()
+WHERE totp_credentials.confirmed_at IS NULL;
+
+-- name: ConfirmTotpCredential :execrows
+UPDATE totp_credentials
+SET confirmed_at = now(), last_used_step = $2, updated_at = now()
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: UseTotpStep :execrows
+UPDATE totp_credentials
+SET last_used_step = $2, updated_at = now()
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2;
+
+-- name: DeleteTotpCredential :execrows
+DELETE FROM totp_credentials WHERE user_id = $1;
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+SELECT sqlc.arg(user_id)::integer, unnest(sqlc.arg(code_hashes)::text[]), now();
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
+
+-- name: UserRequiresTwoFactor :one
+SELECT EXISTS(
+    SELECT 1 FROM org_members m
+    INNER JOIN orgs o ON o.id = m.org_id
+    WHERE m.user_id = $1 AND m.removed_at IS NULL AND o.require_two_factor
+) OR EXISTS(
+    SELECT 1 FROM users u
+    INNER JOIN orgs o ON o.id = u.org_id
+    WHERE u.id = $1 AND o.require_two_factor
+) AS requires_two_factor;
+
+-- name: CreateInvite :one
+INSERT INTO invites (
+    sender_email, receiver_email, token, expires_at, invited_by_id, org_id, project_id, role, created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, now()
+)
+RETURNING *;
+
+-- name: GetInvite :one
+SELECT * FROM invites WHERE id = $1;
+
+-- name: GetInviteByToken :one
+SELECT * FROM invites WHERE token = $1;
+
+-- name: ListInvitesBySender :many
+SELECT * FROM invites WHERE invited_by_id = $1 ORDER BY created_at DESC;
+
+-- name: ListProjectInvites :many
+SELECT * FROM invites WHERE project_id = $1 ORDER BY created_at DESC;
+
+-- name: RevokePendingInvites :exec
+UPDATE invites SET revoked_at = now()
+WHERE receiver_email = $1
+  AND org_id IS NOT DISTINCT FROM $2
+  AND project_id IS NOT DISTINCT FROM $3
+  AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: RevokeInvite :execrows
+UPDATE invites SET revoked_at = now()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: RenewInvite :execrows
+UPDATE invites SET token = $2, expires_at = $3
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL;
+
+-- name: AcceptInvite :execrows
+UPDATE invites SET accepted_at = now(), accepted_by_id = $2
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now();
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = $1;
+
+-- name: SetUserSuperAdmin :execrows
+UPDATE users SET is_super_admin = $2, updated_at = now() WHERE id = $1;
+
+-- name: CountSuperAdmins :one
+SELECT COUNT(*) FROM users WHERE is_super_admin AND is_activated AND deleted_at IS NULL;
+
+-- name: DeactivateUser :execrows
+UPDATE users SET is_activated = false, updated_at = now() WHERE id = $1 AND is_activated;
+
+-- name: UpdateProjectOwner :execrows
+UPDATE projects SET owner_user_id = $2, updated_at = now() WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: CreateSigningKey :exec
+INSERT INTO signing_keys (kid, algorithm, private_key, created_at, activates_at)
+VALUES ($1, $2, $3, now(), $4);
+
+-- name: ListSigningKeys :many
+SELECT * FROM signing_keys ORDER BY activates_at DESC, created_at DESC;
+
+-- name: RetireSigningKey :execrows
+UPDATE signing_keys SET retired_at = $2
+WHERE kid = $1 AND (retired_at IS NULL OR retired_at > $2);
+
+-- name: RetireOtherSigningKeys :execrows
+UPDATE signing_keys SET retired_at = $2
+WHERE kid <> $1 AND (retired_at IS NULL OR retired_at > $2);
+
+-- name: DeleteSigningKey :execrows
+DELETE FROM signing_keys WHERE kid = $1;
+
+-- name: ReactivateUser :execrows
+UPDATE users SET is_activated = true, updated_at = now() WHERE id = $1 AND NOT is_activated AND deleted_at IS NULL;
+
+-- name: ListOpenTestPlansAssignedTo :many
+SELECT id, project_id, description FROM test_plans
+WHERE assigned_to_id = $1 AND NOT COALESCE(is_complete, false) AND closed_at IS NULL
+ORDER BY project_id, id;
+
+-- name: ListOpenPlanCasesAssignedTo :many
+SELECT pc.test_plan_id, pc.test_case_id, tp.project_id, tc.title
+FROM test_plan_cases pc
+INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
+INNER JOIN test_cases tc ON tc.id = pc.test_case_id
+WHERE pc.assigned_to_id = $1
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+)
+ORDER BY tp.project_id, pc.test_plan_id, tc.title;
+
+-- name: ListOpenTestRunsAssignedTo :many
+SELECT id, project_id, test_plan_id, code FROM test_runs
+WHERE assigned_to_id = $1 AND NOT COALESCE(is_closed, false)
+ORDER BY project_id, test_plan_id, code;
+
+-- name: ReassignOpenTestPlans :execrows
+UPDATE test_plans SET assigned_to_id = sqlc.arg(to_user_id), updated_at = now()
+WHERE assigned_to_id = sqlc.arg(from_user_id) AND NOT COALESCE(is_complete, false) AND closed_at IS NULL;
+
+-- name: DeleteDuplicatePlanCaseAssignments :execrows
+DELETE FROM test_plan_cases pc
+USING test_plans tp
+WHERE tp.id = pc.test_plan_id
+AND pc.assigned_to_id = sqlc.arg(from_user_id)
+AND EXISTS (
+    SELECT 1 FROM test_plan_cases other
+    WHERE other.test_plan_id = pc.test_plan_id AND other.test_case_id = pc.test_case_id
+    AND other.assigned_to_id = sqlc.arg(to_user_id)
+)
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+);
+
+-- name: ReassignOpenPlanCases :execrows
+UPDATE test_plan_cases pc SET assigned_to_id = sqlc.arg(to_user_id)
+FROM test_plans tp
+WHERE tp.id = pc.test_plan_id
+AND pc.assigned_to_id = sqlc.arg(from_user_id)
+AND NOT COALESCE(tp.is_complete, false)
+AND NOT EXISTS (
+    SELECT 1 FROM test_runs tr
+    WHERE tr.test_plan_id = pc.test_plan_id AND tr.test_case_id = pc.test_case_id AND tr.is_closed
+);
+
+-- name: ReassignOpenTestRuns :execrows
+UPDATE test_runs SET assigned_to_id = sqlc.arg(to_user_id), updated_at = now()
+WHERE assigned_to_id = sqlc.arg(from_user_id) AND NOT COALESCE(is_closed, false);
+
+-- name: CreateSCIMToken :one
+INSERT INTO scim_tokens (org_id, name, token_hash, created_at)
+VALUES ($1, $2, $3, now())
+RETURNING id;
+
+-- name: ListSCIMTokens :many
+SELECT * FROM scim_tokens ORDER BY org_id, created_at DESC;
+
+-- name: GetActiveSCIMToken :one
+SELECT * FROM scim_tokens WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: TouchSCIMToken :exec
+UPDATE scim_tokens SET last_used_at = now() WHERE id = $1;
+
+-- name: RevokeSCIMToken :execrows
+UPDATE scim_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: ListSCIMUsers :many
+SELECT u.id, u.first_name, u.last_name, u.display_name, u.email, u.is_activated, u.created_at, u.updated_at,
+    s.external_id, s.user_name, s.manages_account,
+    COALESCE((
+        SELECT m.role FROM org_members m
+        WHERE m.org_id = s.org_id AND m.user_id = s.user_id AND m.removed_at IS NULL
+        ORDER BY m.id DESC LIMIT 1
+    ), '')::text AS role
+FROM scim_users s
+INNER JOIN users u ON u.id = s.user_id
+WHERE s.org_id = $1 AND u.deleted_at IS NULL
+ORDER BY u.id;
+
+-- name: GetSCIMUser :one
+SELECT u.id, u.first_name, u.last_name, u.display_name, u.email, u.is_activated, u.created_at, u.updated_at,
+    s.external_id, s.user_name, s.manages_account,
+    COALESCE((
+        SELECT m.role FROM org_members m
+        WHERE m.org_id = s.org_id AND m.user_id = s.user_id AND m.removed_at IS NULL
+        ORDER BY m.id DESC LIMIT 1
+    ), '')::text AS role
+FROM scim_users s
+INNER JOIN users u ON u.id = s.user_id
+WHERE s.org_id = $1 AND s.user_id = $2 AND u.deleted_at IS NULL;
+
+-- name: LinkSCIMUser :exec
+INSERT INTO scim_users (org_id, user_id, external_id, user_name, manages_account, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, now(), now())
+ON CONFLICT (org_id, user_id) DO UPDATE SET external_id = EXCLUDED.external_id, user_name = EXCLUDED.user_name, updated_at = now();
+
+-- name: UnlinkSCIMUser :execrows
+DELETE FROM scim_users WHERE org_id = $1 AND user_id = $2;
+
+-- name: UpdateUserNames :execrows
+UPDATE users SET first_name = $2, last_name = $3, display_name = $4, email = $5, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: SetOrgMemberRole :execrows
+UPDATE org_members SET role = $3
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL;
+
+-- name: RemoveOrgMember :execrows
+UPDATE org_members SET removed_at = now()
+WHERE org_id = $1 AND user_id = $2 AND removed_at IS NULL;
+
+-- name: CountOrgOwners :one
+SELECT COUNT(DISTINCT m.user_id) FROM org_members m
+INNER JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1 AND m.role = 'owner' AND m.removed_at IS NULL AND u.is_activated AND u.deleted_at IS NULL;
+
+-- name: ListOrgMembers :many
+SELECT DISTINCT ON (m.user_id) m.user_id, m.role, m.created_at,
+    u.first_name, u.last_name, u.display_name, u.email, u.is_activated
+FROM org_members m
+INNER JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1 AND m.removed_at IS NULL AND u.deleted_at IS NULL
+ORDER BY m.user_id, m.id DESC;
+
+-- name: ListUserOrgs :many
+SELECT DISTINCT ON (o.id) o.id, o.name, m.role
+FROM org_members m
+INNER JOIN orgs o ON o.id = m.org_id
+WHERE m.user_id = $1 AND m.removed_at IS NULL
+ORDER BY o.id, m.id DESC;
+
+-- name: SetUserDefaultOrg :execrows
+UPDATE users SET org_id = $2, updated_at = now() WHERE id = $1;
+
+-- name: ClearUserDefaultOrg :execrows
+UPDATE users SET org_id = NULL, updated_at = now() WHERE id = $1 AND org_id = $2;
+
+-- name: UpsertSessionOrg :exec
+INSERT INTO session_orgs (family_id, user_id, org_id, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (family_id) DO UPDATE SET org_id = EXCLUDED.org_id, updated_at = now();
+
+-- name: GetSessionOrg :one
+SELECT org_id FROM session_orgs WHERE family_id = $1 AND user_id = $2;
+
+-- name: ListProjectsByOrg :many
+SELECT * FROM projects WHERE org_id = $1 ORDER BY created_at DESC;
+
+-- name: GetProjectOrgID :one
+SELECT org_id FROM projects WHERE id = $1;
+
+-- name: GetTestCaseOrgID :one
+SELECT p.org_id
+FROM test_cases tc
+INNER JOIN projects p ON p.id = tc.project_id
+WHERE tc.id = $1;
+
+-- name: GetTestPlanOrgID :one
+SELECT p.org_id
+FROM test_plans tp
+INNER JOIN projects p ON p.id = tp.project_id
+WHERE tp.id = $1;
+
+-- name: GetTestRunOrgID :one
+SELECT p.org_id
+FROM test_runs tr
+INNER JOIN projects p ON p.id = tr.project_id
+WHERE tr.id = $1;
+
+-- name: GetTestPlanCommentOrgID :one
+SELECT p.org_id
+FROM test_plan_comments c
+INNER JOIN test_plans tp ON tp.id = c.test_plan_id
+INNER JOIN projects p ON p.id = tp.project_id
+WHERE c.id = $1;
+
+-- name: ListTestPlanCases :many
+SELECT * FROM test_plan_cases WHERE test_plan_id = $1;
+
+-- name: SetTestCaseParent :exec
+UPDATE test_cases SET parent_test_case_id = $2 WHERE id = $1;
+
+-- name: CopyTestCaseSequences :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+SELECT sqlc.arg(target_project_id)::int, prefix, current_val, now()
+FROM test_case_sequences
+WHERE project_id = sqlc.arg(source_project_id)
+ON CONFLICT (project_id, prefix) DO NOTHING;
+
+-- name: GetTestRunResultCounts :one
+SELECT
+    COUNT(*) FILTER (WHERE result_state = 'passed') AS passed_count,
+    COUNT(*) FILTER (WHERE result_state = 'failed') AS failed_count,
+    COUNT(*) FILTER (WHERE result_state = 'pending') AS pending_count
+FROM test_runs
+WHERE project_id IN (SELECT id FROM projects WHERE org_id = sqlc.arg(org_id))
+AND (sqlc.arg(project_ids)::int[] IS NULL OR project_id = ANY(sqlc.arg(project_ids)::int[]));
+
+-- name: ListChildProjects :many
+SELECT * FROM projects WHERE parent_project_id = $1 ORDER BY title;
+
+-- name: GetProjectSubtreeIDs :many
+WITH RECURSIVE subtree AS (
+    SELECT p.id FROM projects p WHERE p.id = $1
+    UNION
+    SELECT c.id FROM projects c INNER JOIN subtree s ON c.parent_project_id = s.id
+)
+SELECT id FROM subtree;
+
+-- name: SetProjectParent :execrows
+UPDATE projects SET parent_project_id = $2, updated_at = now() WHERE id = $1;
+
+-- name: ListTestPlanCasesByProject :many
+SELECT pc.test_plan_id, pc.test_case_id, pc.assigned_to_id
+FROM test_plan_cases pc
+INNER JOIN test_plans tp ON tp.id = pc.test_plan_id
+WHERE tp.project_id = $1;
+
+-- name: ListTestRunResultsByProject :many
+SELECT trr.id, trr.test_run_id, trr.status, trr.result, trr.notes, trr.executed_by, trr.executed_at, trr.created_at, trr.updated_at
+FROM test_run_results trr
+INNER JOIN test_runs tr ON tr.id = trr.test_run_id
+WHERE tr.project_id = $1
+ORDER BY trr.executed_at;
+
+-- name: ListTestPlanCommentsByProject :many
+SELECT c.id, c.test_plan_id, c.user_id, c.content, c.created_at, c.updated_at
+FROM test_plan_comments c
+INNER JOIN test_plans tp ON tp.id = c.test_plan_id
+WHERE tp.project_id = $1
+ORDER BY c.created_at;
+
+-- name: ListPagesByProject :many
+SELECT * FROM pages WHERE project_id = $1 ORDER BY id;
+
+-- name: ListUsersByIDs :many
+SELECT id, email, display_name FROM users WHERE id = ANY($1::int[]);
+
+-- name: ImportTestRun :exec
+INSERT INTO test_runs (
+    id, project_id, test_plan_id, test_case_id, owner_id, tested_by_id, assigned_to_id, assignee_can_change_code, code, external_issue_id, result_state, is_closed, notes, actual_result, expected_result, reactions, tested_on, created_at, updated_at, environment_id, data_row, data_values
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
+);
+
+-- name: ImportTestPlanComment :exec
+INSERT INTO test_plan_comments (id, test_plan_id, user_id, content, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ImportPage :one
+INSERT INTO pages (
+    parent_page_id, page_version, org_id, project_id, code, title, file_path, content, page_type, mime_type, has_embedded_media, external_content_url, notion_url, last_edited_by, created_by, created_at, updated_at, deleted_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+)
+RETURNING id;
+
+-- name: ImportReport :exec
+INSERT INTO reports (id, project_id, name, type, status, created_at, file_path, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: SetPageParent :exec
+UPDATE pages SET parent_page_id = $2 WHERE id = $1;
+
+-- name: AdvanceTestCaseSequence :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, prefix) DO UPDATE
+SET current_val = GREATEST(test_case_sequences.current_val, EXCLUDED.current_val);
+
+-- name: CreateCustomField :one
+INSERT INTO custom_fields (project_id, entity, name, label, field_type, options, is_required, default_value, position, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+RETURNING *;
+
+-- name: UpdateCustomField :one
+UPDATE custom_fields SET label = $2, options = $3, is_required = $4, default_value = $5, position = $6, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetCustomField :one
+SELECT * FROM custom_fields WHERE id = $1;
+
+-- name: DeleteCustomField :execrows
+DELETE FROM custom_fields WHERE id = $1;
+
+-- name: ListCustomFieldsByProject :many
+SELECT * FROM custom_fields WHERE project_id = $1 ORDER BY entity, position, id;
+
+-- name: ListCustomFieldsByEntity :many
+SELECT * FROM custom_fields WHERE project_id = $1 AND entity = $2 ORDER BY position, id;
+
+-- name: UpsertCustomFieldValue :exec
+INSERT INTO custom_field_values (field_id, entity_id, value, updated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (field_id, entity_id) DO UPDATE SET value = EXCLUDED.value, updated_at = now();
+
+-- name: DeleteCustomFieldValuesByEntity :exec
+DELETE FROM custom_field_values WHERE entity_id = $1;
+
+-- name: ListCustomFieldValues :many
+SELECT v.entity_id, f.name, v.value
+FROM custom_field_values v
+INNER JOIN custom_fields f ON f.id = v.field_id
+WHERE v.entity_id = ANY($1::uuid[])
+ORDER BY f.position, f.id;
+
+-- name: SetProjectTestCaseCodePattern :exec
+UPDATE projects SET testcase_code_pattern = $2, updated_at = now() WHERE id = $1;
+
+-- name: SetTestCaseSequence :exec
+INSERT INTO test_case_sequences (project_id, prefix, current_val, last_generated_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, prefix) DO UPDATE
+SET current_val = EXCLUDED.current_val, last_generated_at = now();
+
+-- name: DeleteTestCaseSequences :exec
+DELETE FROM test_case_sequences WHERE project_id = $1;
+
+-- name: SetTestCaseCode :exec
+UPDATE test_cases SET code = $2, updated_at = now() WHERE id = $1;
+
+-- name: RenameTestRunCodes :execrows
+-- Runs of data-driven test cases are coded <code>#<row> and keep their row suffix
+UPDATE test_runs SET code = sqlc.arg(new_code)::text || substr(code, length(sqlc.arg(old_code)::text) + 1), updated_at = now()
+WHERE test_case_id = sqlc.arg(test_case_id)
+AND (code = sqlc.arg(old_code)::text
+    OR (left(code, length(sqlc.arg(old_code)::text) + 1) = sqlc.arg(old_code)::text || '#'
+        AND substr(code, length(sqlc.arg(old_code)::text) + 2) ~ '^[0-9]+$'));
+
+-- name: ReplaceCodeInTestPlans :execrows
+UPDATE test_plans SET description = regexp_replace(description, sqlc.arg(pattern)::text, sqlc.arg(replacement)::text, 'g')
+WHERE project_id = sqlc.arg(project_id) AND description ~ sqlc.arg(pattern)::text;
+
+-- name: ReplaceCodeInTestRuns :execrows
+UPDATE test_runs SET notes = regexp_replace(notes, sqlc.arg(pattern)::text, sqlc.arg(replacement)::text, 'g')
+WHERE project_id = sqlc.arg(project_id) AND notes ~ sqlc.arg(pattern)::text;
+
+-- name: CreateTestCaseCodeAlias :exec
+INSERT INTO test_case_code_aliases (project_id, code, test_case_id, created_at)
+VALUES ($1, $2, $3, now())
+ON CONFLICT (project_id, code) DO UPDATE SET test_case_id = EXCLUDED.test_case_id, created_at = now();
+
+-- name: DeleteTestCaseCodeAlias :exec
+DELETE FROM test_case_code_aliases WHERE project_id = $1 AND code = $2;
+
+-- name: ListTestCaseCodeAliases :many
+SELECT * FROM test_case_code_aliases WHERE project_id = $1 ORDER BY code;
+
+-- name: ListTestCaseSteps :many
+SELECT * FROM test_case_steps WHERE test_case_id = $1 ORDER BY position;
+
+-- name: DeleteTestCaseSteps :exec
+DELETE FROM test_case_steps WHERE test_case_id = $1;
+
+-- name: CreateTestCaseStep :exec
+INSERT INTO test_case_steps (test_case_id, position, action, test_data, expected_result, attachments, shared_step_id, parameters, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now(), now());
+
+-- name: ListTestRunStepResults :many
+SELECT * FROM test_run_step_results WHERE test_run_id = $1 ORDER BY position;
+
+-- name: RecordTestRunStepResult :execrows
+UPDATE test_run_step_results SET
+    status = $3,
+    actual_result = $4,
+    notes = $5,
+    executed_by_id = $6,
+    executed_at = $7,
+    updated_at = now()
+WHERE test_run_id = $1 AND position = $2;
+
+-- name: CreateTestCaseRevision :one
+INSERT INTO test_case_revisions (test_case_id, revision, title, description, kind, tags, runner, script_path, steps, author_id, created_at)
+VALUES (
+    sqlc.arg(test_case_id),
+    (SELECT COALESCE(MAX(revision), 0) + 1 FROM test_case_revisions WHERE test_case_id = sqlc.arg(test_case_id)),
+    sqlc.arg(title), sqlc.arg(description), sqlc.arg(kind), sqlc.arg(tags), sqlc.arg(runner), sqlc.arg(script_path), sqlc.arg(steps), sqlc.arg(author_id), now()
+)
+RETURNING revision;
+
+-- name: GetLatestTestCaseRevision :one
+SELECT * FROM test_case_revisions WHERE test_case_id = $1 ORDER BY revision DESC LIMIT 1;
+
+-- name: GetTestCaseRevision :one
+SELECT * FROM test_case_revisions WHERE test_case_id = $1 AND revision = $2;
+
+-- name: ListTestCaseRevisions :many
+SELECT * FROM test_case_revisions WHERE test_case_id = $1 ORDER BY revision DESC;
+
+-- name: RestoreTestCaseContent :exec
+UPDATE test_cases SET
+    title = $2,
+    description = $3,
+    kind = $4,
+    tags = $5,
+    runner = $6,
+    script_path = $7,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: SetTestRunTestCaseRevision :exec
+UPDATE test_runs SET test_case_revision = $2 WHERE id = $1;
+
+-- name: CreateTestRunStepResult :exec
+INSERT INTO test_run_step_results (test_run_id, position, step_id, action, test_data, expected_result, status, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, 'pending', now())
+ON CONFLICT (test_run_id, position) DO NOTHING;
+
+-- name: ListSharedStepsByProject :many
+SELECT * FROM shared_steps WHERE project_id = $1 ORDER BY name;
+
+-- name: GetSharedStep :one
+SELECT * FROM shared_steps WHERE id = $1;
+
+-- name: CreateSharedStep :one
+INSERT INTO shared_steps (project_id, name, description, parameters, steps, created_by_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now(), now())
+RETURNING *;
+
+-- name: UpdateSharedStep :one
+UPDATE shared_steps SET
+    name = $2,
+    description = $3,
+    parameters = $4,
+    steps = $5,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteSharedStep :execrows
+DELETE FROM shared_steps WHERE id = $1;
+
+-- name: ListSharedStepReferences :many
+SELECT * FROM test_case_steps WHERE shared_step_id = $1 ORDER BY test_case_id, position;
+
+-- name: ListSharedStepUsages :many
+SELECT tc.id AS test_case_id, tc.code, tc.title, COUNT(*) AS usages
+FROM test_case_steps s
+INNER JOIN test_cases tc ON tc.id = s.test_case_id
+WHERE s.shared_step_id = $1
+GROUP BY tc.id, tc.code, tc.title
+ORDER BY tc.code;
+
+-- name: GetTestCaseDataset :one
+SELECT * FROM test_case_datasets WHERE test_case_id = $1;
+
+-- name: UpsertTestCaseDataset :one
+INSERT INTO test_case_datasets (test_case_id, parameters, rows, updated_by_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, now(), now())
+ON CONFLICT (test_case_id) DO UPDATE SET
+    parameters = EXCLUDED.parameters,
+    rows = EXCLUDED.rows,
+    updated_by_id = EXCLUDED.updated_by_id,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteTestCaseDataset :execrows
+DELETE FROM test_case_datasets WHERE test_case_id = $1;
+
+-- name: CreateDataDrivenTestRun :execrows
+INSERT INTO test_runs (
+id, project_id, test_plan_id, test_case_id, owner_id, assigned_to_id, code, created_at, updated_at,
+result_state, is_closed, assignee_can_change_code, notes, reactions, tested_on, expected_result, environment_id, data_row, data_values
+)
+VALUES (
+$1, $2, $3, $4, $5, $6, $7, now(), now(),
+'pending', false, false, 'None', '{}'::jsonb, now(), 'Test to Pass', $8, $9, $10
+)
+ON CONFLICT (test_plan_id, test_case_id, assigned_to_id, data_values) WHERE data_values IS NOT NULL DO NOTHING;
@@ -217,7 +217,7 @@ function EditTestCase() {
     setScriptValidationStatus("validating");
     setScriptValidationMessage("Scanning script file...");
     try {
-      const result = await validateTestCaseScript(file, runner);
+      const result = await validateTestCaseScript(projectId, file, runner);
       setScriptValidationStatus("success");
       const message = result.output || `Script validated successfully using ${runner}.`;
       setScriptValidationMessage(message);
@@ -57,7 +57,7 @@ const search = Route.useSearch();
     setScriptValidationStatus("validating");
     setScriptValidationMessage(t("test_cases.script.scanning"));
     try {
-      const result = await validateTestCaseScript(file, runner);
+      const result = await validateTestCaseScript(project_id, file, runner);
       setScriptValidationStatus("success");
       const message = result.output || t("test_cases.script.validated_success", { runner });
       setScriptValidationMessage(message);
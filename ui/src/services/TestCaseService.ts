@@ -60,8 +60,13 @@ export async function createTestCase(
   return apiClient.request("post", "/v1/test-cases", { body: data as any });
 }
 
-export async function validateTestCaseScript(file: File, runner: string) {
+export async function validateTestCaseScript(
+  projectId: string,
+  file: File,
+  runner: string,
+) {
   const formData = new FormData();
+  formData.append("project_id", projectId);
   formData.append("script_file", file);
   formData.append("runner", runner);
 